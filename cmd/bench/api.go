@@ -0,0 +1,149 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+type api struct {
+	target string
+	client *http.Client
+	token  string
+}
+
+func newAPI(target string) *api {
+	return &api{
+		target: strings.TrimSuffix(target, "/"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type apiResp struct {
+	Error string          `json:"error"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func (a *api) post(ctx context.Context, path, token string, body, data any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.target+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	var r apiResp
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	if r.Error != "" {
+		return fmt.Errorf("%s: %s", path, r.Error)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Data, data)
+}
+
+func (a *api) login(ctx context.Context, username, password string) error {
+	token, err := a.userToken(ctx, username, password)
+	if err != nil {
+		return err
+	}
+	a.token = token
+	return nil
+}
+
+func (a *api) userToken(ctx context.Context, username, password string) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err := a.post(ctx, "/api/user/login", "", &model.LoginUserReq{
+		Username: username,
+		Password: password,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.Token == "" {
+		return "", errors.New("empty user token")
+	}
+	return resp.Token, nil
+}
+
+type room struct {
+	id string
+	// room token of the creator, used by the driver
+	token string
+	// send time of the pending seeks by seek
+	sent sync.Map
+}
+
+type roomTokenResp struct {
+	RoomID string `json:"roomId"`
+	Token  string `json:"token"`
+}
+
+func (a *api) createRoom(ctx context.Context, name string) (*room, error) {
+	var resp roomTokenResp
+	err := a.post(ctx, "/api/room/create", a.token, &model.CreateRoomReq{
+		RoomName: name,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &room{
+		id:    resp.RoomID,
+		token: resp.Token,
+	}, nil
+}
+
+// joinMember creates a user and joins it to the room, returning the room token
+func (a *api) joinMember(ctx context.Context, username string, r *room) (string, error) {
+	password := username + "-password"
+	err := a.post(ctx, "/api/admin/user/add", a.token, &model.AddUserReq{
+		Username: username,
+		Password: password,
+		Role:     dbModel.RoleUser,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	token, err := a.userToken(ctx, username, password)
+	if err != nil {
+		return "", err
+	}
+	var resp roomTokenResp
+	err = a.post(ctx, "/api/room/login", token, &model.LoginRoomReq{
+		RoomId: r.id,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
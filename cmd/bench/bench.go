@@ -0,0 +1,141 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/utils"
+)
+
+const BenchLong = `bench spawns synthetic websocket members across rooms of a running server,
+members chat while one driver per room seeks, and the delivery latency of
+the seeks to every member is reported.
+
+The admin account is used to create the rooms and the members.`
+
+var BenchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "load test a running server",
+	Long:  BenchLong,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return bootstrap.New(bootstrap.WithContext(cmd.Context())).Add(
+			bootstrap.InitStdLog,
+		).Run()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flags.Rooms <= 0 || flags.Members <= 0 {
+			return errors.New("rooms and members must be positive")
+		}
+		if flags.Username == "" || flags.Password == "" {
+			return errors.New("missing admin username or password")
+		}
+		return Run(cmd.Context(), &flags)
+	},
+}
+
+type Flags struct {
+	Target       string
+	Username     string
+	Password     string
+	Rooms        int
+	Members      int
+	Duration     time.Duration
+	SeekInterval time.Duration
+	ChatInterval time.Duration
+}
+
+var flags Flags
+
+func Run(ctx context.Context, f *Flags) error {
+	api := newAPI(f.Target)
+	if err := api.login(ctx, f.Username, f.Password); err != nil {
+		return fmt.Errorf("login admin: %w", err)
+	}
+
+	runID := "bench" + utils.RandString(6)
+	stats := newStats()
+
+	rooms := make([]*room, f.Rooms)
+	for i := range rooms {
+		r, err := api.createRoom(ctx, fmt.Sprintf("%s-%d", runID, i))
+		if err != nil {
+			return fmt.Errorf("create room: %w", err)
+		}
+		rooms[i] = r
+	}
+	fmt.Printf("created %d rooms\n", len(rooms))
+
+	members := make([]*member, 0, f.Members)
+	for i := 0; i < f.Members; i++ {
+		r := rooms[i%len(rooms)]
+		token, err := api.joinMember(ctx, fmt.Sprintf("%s-%d", runID, i), r)
+		if err != nil {
+			return fmt.Errorf("join member %d: %w", i, err)
+		}
+		m, err := dialMember(ctx, f.Target, token, r, stats)
+		if err != nil {
+			return fmt.Errorf("connect member %d: %w", i, err)
+		}
+		members = append(members, m)
+	}
+	fmt.Printf("connected %d members\n", len(members))
+
+	drivers := make([]*member, 0, len(rooms))
+	for i, r := range rooms {
+		d, err := dialMember(ctx, f.Target, r.token, r, stats)
+		if err != nil {
+			return fmt.Errorf("connect driver %d: %w", i, err)
+		}
+		drivers = append(drivers, d)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.Duration)
+	defer cancel()
+
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	for _, m := range members {
+		wg.Add(1)
+		go func(m *member) {
+			defer wg.Done()
+			m.chat(ctx, f.ChatInterval)
+		}(m)
+	}
+	for _, d := range drivers {
+		wg.Add(1)
+		go func(d *member) {
+			defer wg.Done()
+			d.seek(ctx, f.SeekInterval)
+		}(d)
+	}
+	wg.Wait()
+
+	for _, m := range append(members, drivers...) {
+		m.close()
+	}
+
+	stats.report(time.Since(start))
+	return nil
+}
+
+// jitter spreads the members so they do not all act at the same instant
+func jitter(d time.Duration) time.Duration {
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+func init() {
+	BenchCmd.Flags().StringVar(&flags.Target, "target", "http://127.0.0.1:8080", "target server url")
+	BenchCmd.Flags().StringVarP(&flags.Username, "username", "u", "", "admin username")
+	BenchCmd.Flags().StringVarP(&flags.Password, "password", "p", "", "admin password")
+	BenchCmd.Flags().IntVar(&flags.Rooms, "rooms", 1, "number of rooms")
+	BenchCmd.Flags().IntVar(&flags.Members, "members", 10, "number of members, spread across the rooms")
+	BenchCmd.Flags().DurationVar(&flags.Duration, "duration", time.Minute, "bench duration")
+	BenchCmd.Flags().DurationVar(&flags.SeekInterval, "seek-interval", 2*time.Second, "interval of the seeks of each room")
+	BenchCmd.Flags().DurationVar(&flags.ChatInterval, "chat-interval", 5*time.Second, "mean interval of the chat messages of each member")
+}
@@ -0,0 +1,136 @@
+package bench
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"google.golang.org/protobuf/proto"
+)
+
+type member struct {
+	conn  *websocket.Conn
+	room  *room
+	stats *stats
+	wg    sync.WaitGroup
+}
+
+func dialMember(ctx context.Context, target, token string, r *room, s *stats) (*member, error) {
+	addr := "ws" + strings.TrimPrefix(strings.TrimSuffix(target, "/"), "http") + "/api/room/ws"
+	dialer := websocket.Dialer{
+		Subprotocols:      []string{token},
+		HandshakeTimeout:  10 * time.Second,
+		EnableCompression: true,
+	}
+	conn, _, err := dialer.DialContext(ctx, addr, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	m := &member{
+		conn:  conn,
+		room:  r,
+		stats: s,
+	}
+	m.wg.Add(1)
+	go m.read()
+	return m, nil
+}
+
+func (m *member) read() {
+	defer m.wg.Done()
+	for {
+		t, data, err := m.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if t != websocket.BinaryMessage {
+			continue
+		}
+		var msg pb.ElementMessage
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			m.stats.error()
+			continue
+		}
+		switch msg.Type {
+		case pb.ElementMessageType_CHANGE_SEEK:
+			seek := msg.GetMovieStatusChanged().GetStatus().GetSeek()
+			if sent, ok := m.room.sent.Load(seek); ok {
+				m.stats.latency(time.Since(sent.(time.Time)))
+			}
+		case pb.ElementMessageType_CHAT_MESSAGE:
+			m.stats.chatReceived()
+		case pb.ElementMessageType_ERROR:
+			m.stats.error()
+		}
+	}
+}
+
+func (m *member) send(msg *pb.ElementMessage) error {
+	msg.Time = time.Now().UnixMilli()
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return m.conn.WriteMessage(websocket.BinaryMessage, b)
+}
+
+func (m *member) chat(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(jitter(interval)):
+		}
+		err := m.send(&pb.ElementMessage{
+			Type:    pb.ElementMessageType_CHAT_MESSAGE,
+			ChatReq: "bench " + time.Now().Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			m.stats.error()
+			return
+		}
+		m.stats.chatSent()
+	}
+}
+
+// seek changes the room seek, every seek value is unique in the room
+// so the members can match the broadcast to its send time
+func (m *member) seek(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for seq := 1; ; seq++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		seek := float64(seq)
+		m.room.sent.Store(seek, time.Now())
+		err := m.send(&pb.ElementMessage{
+			Type: pb.ElementMessageType_CHANGE_SEEK,
+			ChangeMovieStatusReq: &pb.MovieStatus{
+				Seek: seek,
+				Rate: 1,
+			},
+		})
+		if err != nil {
+			m.stats.error()
+			return
+		}
+		m.stats.seekSent()
+	}
+}
+
+func (m *member) close() {
+	_ = m.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second),
+	)
+	_ = m.conn.Close()
+	m.wg.Wait()
+}
@@ -0,0 +1,62 @@
+package bench
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type stats struct {
+	lock      sync.Mutex
+	latencies []time.Duration
+
+	seeks         atomic.Int64
+	chats         atomic.Int64
+	chatsReceived atomic.Int64
+	errors        atomic.Int64
+}
+
+func newStats() *stats {
+	return &stats{}
+}
+
+func (s *stats) latency(d time.Duration) {
+	s.lock.Lock()
+	s.latencies = append(s.latencies, d)
+	s.lock.Unlock()
+}
+
+func (s *stats) seekSent()     { s.seeks.Add(1) }
+func (s *stats) chatSent()     { s.chats.Add(1) }
+func (s *stats) chatReceived() { s.chatsReceived.Add(1) }
+func (s *stats) error()        { s.errors.Add(1) }
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
+
+func (s *stats) report(elapsed time.Duration) {
+	s.lock.Lock()
+	latencies := slices.Clone(s.latencies)
+	s.lock.Unlock()
+	slices.Sort(latencies)
+
+	fmt.Printf("elapsed: %s\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("seeks sent: %d, delivered: %d\n", s.seeks.Load(), len(latencies))
+	fmt.Printf("chats sent: %d, received: %d\n", s.chats.Load(), s.chatsReceived.Load())
+	fmt.Printf("errors: %d\n", s.errors.Load())
+	if len(latencies) == 0 {
+		return
+	}
+	fmt.Println("status delivery latency:")
+	for _, p := range []float64{0.5, 0.9, 0.99, 0.999} {
+		fmt.Printf("- p%g: %s\n", p*100, percentile(latencies, p))
+	}
+	fmt.Printf("- max: %s\n", latencies[len(latencies)-1])
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/synctv-org/synctv/cmd/admin"
+	"github.com/synctv-org/synctv/cmd/bench"
 	"github.com/synctv-org/synctv/cmd/flags"
 	"github.com/synctv-org/synctv/cmd/root"
 	"github.com/synctv-org/synctv/cmd/setting"
@@ -112,4 +113,5 @@ func init() {
 	RootCmd.AddCommand(user.UserCmd)
 	RootCmd.AddCommand(setting.SettingCmd)
 	RootCmd.AddCommand(root.RootCmd)
+	RootCmd.AddCommand(bench.BenchCmd)
 }
@@ -15,6 +15,8 @@ import (
 	"github.com/synctv-org/synctv/internal/rtmp"
 	sysnotify "github.com/synctv-org/synctv/internal/sysNotify"
 	"github.com/synctv-org/synctv/server"
+	"github.com/synctv-org/synctv/server/playersync"
+	"github.com/synctv-org/synctv/server/syncplay"
 	"github.com/synctv-org/synctv/utils"
 )
 
@@ -28,12 +30,29 @@ var ServerCmd = &cobra.Command{
 			bootstrap.InitConfig,
 			bootstrap.InitGinMode,
 			bootstrap.InitLog,
+			bootstrap.InitChaos,
 			bootstrap.InitDatabase,
+			bootstrap.InitChatEncryption,
+			bootstrap.InitWal,
 			bootstrap.InitProvider,
+			bootstrap.InitProviderToken,
 			bootstrap.InitOp,
 			bootstrap.InitRtmp,
 			bootstrap.InitVendorBackend,
 			bootstrap.InitSetting,
+			bootstrap.InitStorage,
+			bootstrap.InitMedia,
+			bootstrap.InitJanitor,
+			bootstrap.InitJobs,
+			bootstrap.InitRoomMirror,
+			bootstrap.InitTrialAdmin,
+			bootstrap.InitLoginHistory,
+			bootstrap.InitMovieTrash,
+			bootstrap.InitMoviePoll,
+			bootstrap.InitEmbyPlaybackReport,
+			bootstrap.InitAlistWatch,
+			bootstrap.InitBilibiliLive,
+			bootstrap.InitSourceRotation,
 		)
 		if !flags.Server.DisableUpdateCheck {
 			boot.Add(bootstrap.InitCheckUpdate)
@@ -143,6 +162,44 @@ func Server(cmd *cobra.Command, args []string) {
 			log.Panic("cert and key must be both set")
 		}
 	}
+	if conf.Conf.Server.Player.Enable {
+		if conf.Conf.Server.Player.Listen == "" {
+			conf.Conf.Server.Player.Listen = conf.Conf.Server.Http.Listen
+		}
+		serverPlayerAddr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:%d", conf.Conf.Server.Player.Listen, conf.Conf.Server.Player.Port))
+		if err != nil {
+			log.Fatal(err)
+		}
+		playerListener, err := net.ListenTCP("tcp", serverPlayerAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			if err := playersync.Serve(playerListener); err != nil {
+				log.Errorf("player sync server error: %v", err)
+			}
+		}()
+		log.Infof("player sync run on tcp://%s:%d", serverPlayerAddr.IP, serverPlayerAddr.Port)
+	}
+	if conf.Conf.Server.Syncplay.Enable {
+		if conf.Conf.Server.Syncplay.Listen == "" {
+			conf.Conf.Server.Syncplay.Listen = conf.Conf.Server.Http.Listen
+		}
+		serverSyncplayAddr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:%d", conf.Conf.Server.Syncplay.Listen, conf.Conf.Server.Syncplay.Port))
+		if err != nil {
+			log.Fatal(err)
+		}
+		syncplayListener, err := net.ListenTCP("tcp", serverSyncplayAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			if err := syncplay.Serve(syncplayListener); err != nil {
+				log.Errorf("syncplay server error: %v", err)
+			}
+		}()
+		log.Infof("syncplay run on tcp://%s:%d", serverSyncplayAddr.IP, serverSyncplayAddr.Port)
+	}
 	if conf.Conf.Server.Rtmp.Enable {
 		log.Infof("rtmp run on tcp://%s:%d", serverRtmpAddr.IP, serverRtmpAddr.Port)
 	}
@@ -0,0 +1,124 @@
+// Package activitypub publishes live sessions of public rooms from an
+// instance wide actor, followable from mastodon and other fediverse servers.
+package activitypub
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+var (
+	Enable = settings.NewBoolSetting("activitypub_enable", false, model.SettingGroupActivityPub)
+	// domain the instance is reachable at over https, e.g. synctv.example.com
+	Domain = settings.NewStringSetting("activitypub_domain", "", model.SettingGroupActivityPub, settings.WithValidatorString(func(s string) error {
+		if strings.ContainsAny(s, "/:@ ") {
+			return errors.New("domain must be a bare host name")
+		}
+		return nil
+	}))
+	Username = settings.NewStringSetting("activitypub_username", "synctv", model.SettingGroupActivityPub, settings.WithValidatorString(func(s string) error {
+		if s == "" || strings.ContainsAny(s, "/:@ ") {
+			return errors.New("invalid username")
+		}
+		return nil
+	}))
+	DisplayName = settings.NewStringSetting("activitypub_display_name", "SyncTV", model.SettingGroupActivityPub)
+	// pem encoded pkcs8 rsa key used to sign deliveries, generated if empty
+	PrivateKeyPem = settings.NewStringSetting("activitypub_private_key", "", model.SettingGroupActivityPub)
+)
+
+const (
+	ContentType   = "application/activity+json"
+	ldContentType = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+	publicAddress = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+var (
+	ErrDisabled = errors.New("activitypub is not enabled")
+	keyLock     sync.Mutex
+)
+
+// Enabled reports whether the actor is published, the domain is required
+func Enabled() bool {
+	return Enable.Get() && Domain.Get() != ""
+}
+
+// IsActivityPubContentType reports whether the accept or content type header asks for activitypub json
+func IsActivityPubContentType(s string) bool {
+	return strings.Contains(s, "application/activity+json") || strings.Contains(s, "application/ld+json")
+}
+
+func baseURL() string {
+	return "https://" + Domain.Get()
+}
+
+func ActorID() string {
+	return baseURL() + "/ap/actor"
+}
+
+func KeyID() string {
+	return ActorID() + "#main-key"
+}
+
+func NoteID(id string) string {
+	return fmt.Sprintf("%s/ap/notes/%s", baseURL(), id)
+}
+
+func RoomURL(roomID string) string {
+	return fmt.Sprintf("%s/web/cinema/%s", baseURL(), roomID)
+}
+
+// PrivateKey returns the signing key, generates and saves a new one if not set
+func PrivateKey() (*rsa.PrivateKey, error) {
+	keyLock.Lock()
+	defer keyLock.Unlock()
+	if s := PrivateKeyPem.Get(); s != "" {
+		block, _ := pem.Decode([]byte(s))
+		if block == nil {
+			return nil, errors.New("invalid activitypub private key pem")
+		}
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rk, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("activitypub private key must be a rsa key")
+		}
+		return rk, nil
+	}
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	b, err := x509.MarshalPKCS8PrivateKey(k)
+	if err != nil {
+		return nil, err
+	}
+	err = PrivateKeyPem.Set(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b})))
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func PublicKeyPem() (string, error) {
+	k, err := PrivateKey()
+	if err != nil {
+		return "", err
+	}
+	b, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b})), nil
+}
@@ -0,0 +1,161 @@
+package activitypub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"html"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/events"
+	"github.com/synctv-org/synctv/internal/jobs"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+const deliverTimeout = 30 * time.Second
+
+// PublishLive posts that the room is now live and delivers it to the followers
+func PublishLive(roomID, roomName, username string) {
+	if !Enabled() {
+		return
+	}
+	note := &model.ActivityPubNote{
+		RoomID: roomID,
+		Content: fmt.Sprintf(
+			`<p>%s is now live in room <a href="%s">%s</a></p>`,
+			html.EscapeString(username),
+			html.EscapeString(RoomURL(roomID)),
+			html.EscapeString(roomName),
+		),
+	}
+	if err := db.CreateActivityPubNote(note); err != nil {
+		log.Errorf("activitypub: create note error: %v", err)
+		return
+	}
+	go deliverToFollowers(createActivity(note))
+}
+
+func deliverToFollowers(activity Object) {
+	followers, err := db.GetActivityPubFollowers()
+	if err != nil {
+		log.Errorf("activitypub: get followers error: %v", err)
+		return
+	}
+	inboxes := make(map[string]struct{}, len(followers))
+	for _, f := range followers {
+		if f.SharedInbox != "" {
+			inboxes[f.SharedInbox] = struct{}{}
+		} else {
+			inboxes[f.Inbox] = struct{}{}
+		}
+	}
+	for inbox := range inboxes {
+		enqueueDelivery(inbox, activity)
+	}
+}
+
+const deliverJobKind = "activitypub.deliver"
+
+type deliverJob struct {
+	Inbox    string `json:"inbox"`
+	Activity Object `json:"activity"`
+}
+
+func init() {
+	jobs.Register(deliverJobKind, runDeliverJob, jobs.WithTimeout(deliverTimeout))
+	events.Rooms.Subscribe("activitypub", func(e events.RoomEvent) {
+		if e.Type == events.RoomLiveStarted && e.Public {
+			PublishLive(e.RoomID, e.RoomName, e.Username)
+		}
+	})
+}
+
+func enqueueDelivery(inbox string, activity Object) {
+	if err := jobs.Enqueue(deliverJobKind, &deliverJob{Inbox: inbox, Activity: activity}); err != nil {
+		log.Errorf("activitypub: enqueue delivery to %s error: %v", inbox, err)
+	}
+}
+
+// runDeliverJob posts the activity, followers behind a gone inbox are removed
+func runDeliverJob(ctx context.Context, payload []byte) error {
+	var job deliverJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return jobs.Permanent(err)
+	}
+	err := post(ctx, job.Inbox, job.Activity)
+	if errors.Is(err, errGone) {
+		return db.DeleteActivityPubFollowersByInbox(job.Inbox)
+	}
+	return err
+}
+
+type inboxActivity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// objectID returns the id of an object that may be embedded or referenced
+func objectID(raw json.RawMessage) (id, typ string) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, ""
+	}
+	var o struct {
+		ID     string `json:"id"`
+		Type   string `json:"type"`
+		Object any    `json:"object"`
+	}
+	if json.Unmarshal(raw, &o) == nil {
+		return o.ID, o.Type
+	}
+	return "", ""
+}
+
+// HandleInbox processes a verified activity sent to the inbox by actor
+func HandleInbox(ctx context.Context, actor *RemoteActor, body []byte) error {
+	var a inboxActivity
+	if err := json.Unmarshal(body, &a); err != nil {
+		return err
+	}
+	if a.Actor != actor.ID {
+		return errors.New("actor does not match the signature")
+	}
+	switch a.Type {
+	case "Follow":
+		if id, _ := objectID(a.Object); id != ActorID() {
+			return errors.New("follow target is not the instance actor")
+		}
+		err := db.CreateActivityPubFollower(&model.ActivityPubFollower{
+			ActorID:     actor.ID,
+			Inbox:       actor.Inbox,
+			SharedInbox: actor.Endpoints.SharedInbox,
+		})
+		if err != nil {
+			return err
+		}
+		var follow any
+		_ = json.Unmarshal(body, &follow)
+		accept := Object{
+			"id":     fmt.Sprintf("%s#accepts/%d", ActorID(), time.Now().UnixNano()),
+			"type":   "Accept",
+			"actor":  ActorID(),
+			"object": follow,
+		}
+		enqueueDelivery(actor.Inbox, accept)
+	case "Undo":
+		if _, typ := objectID(a.Object); typ == "Follow" {
+			return db.DeleteActivityPubFollower(actor.ID)
+		}
+	case "Delete":
+		// the actor deleted itself
+		if id, _ := objectID(a.Object); id == actor.ID {
+			return db.DeleteActivityPubFollower(actor.ID)
+		}
+	}
+	return nil
+}
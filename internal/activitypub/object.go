@@ -0,0 +1,134 @@
+package activitypub
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+type Object = map[string]any
+
+var contextStreams = []any{
+	"https://www.w3.org/ns/activitystreams",
+	"https://w3id.org/security/v1",
+}
+
+func Actor() (Object, error) {
+	pub, err := PublicKeyPem()
+	if err != nil {
+		return nil, err
+	}
+	id := ActorID()
+	return Object{
+		"@context":                  contextStreams,
+		"id":                        id,
+		"type":                      "Service",
+		"preferredUsername":         Username.Get(),
+		"name":                      DisplayName.Get(),
+		"summary":                   "Live sessions of public rooms on " + Domain.Get(),
+		"url":                       baseURL(),
+		"inbox":                     baseURL() + "/ap/inbox",
+		"outbox":                    baseURL() + "/ap/outbox",
+		"followers":                 baseURL() + "/ap/followers",
+		"manuallyApprovesFollowers": false,
+		"discoverable":              true,
+		"publicKey": Object{
+			"id":           KeyID(),
+			"owner":        id,
+			"publicKeyPem": pub,
+		},
+	}, nil
+}
+
+// WebFinger returns the jrd of the actor, ok is false if resource is not the actor
+func WebFinger(resource string) (Object, bool) {
+	subject := "acct:" + Username.Get() + "@" + Domain.Get()
+	if resource != subject && resource != ActorID() {
+		return nil, false
+	}
+	return Object{
+		"subject": subject,
+		"aliases": []string{ActorID()},
+		"links": []Object{
+			{
+				"rel":  "self",
+				"type": ContentType,
+				"href": ActorID(),
+			},
+		},
+	}, true
+}
+
+func Note(n *model.ActivityPubNote) Object {
+	return Object{
+		"id":           NoteID(n.ID),
+		"type":         "Note",
+		"attributedTo": ActorID(),
+		"content":      n.Content,
+		"url":          RoomURL(n.RoomID),
+		"published":    n.CreatedAt.UTC().Format(time.RFC3339),
+		"to":           []string{publicAddress},
+		"cc":           []string{baseURL() + "/ap/followers"},
+	}
+}
+
+func createActivity(n *model.ActivityPubNote) Object {
+	note := Note(n)
+	return Object{
+		"id":        NoteID(n.ID) + "/activity",
+		"type":      "Create",
+		"actor":     ActorID(),
+		"published": note["published"],
+		"to":        note["to"],
+		"cc":        note["cc"],
+		"object":    note,
+	}
+}
+
+func withContext(o Object) Object {
+	o["@context"] = contextStreams
+	return o
+}
+
+func NoteObject(n *model.ActivityPubNote) Object {
+	return withContext(Note(n))
+}
+
+const outboxSize = 20
+
+func Outbox() (Object, error) {
+	total, err := db.GetActivityPubNotesCount()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := db.GetActivityPubNotes(db.OrderByCreatedAtDesc, func(d *gorm.DB) *gorm.DB {
+		return d.Limit(outboxSize)
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Object, len(notes))
+	for i, n := range notes {
+		items[i] = createActivity(n)
+	}
+	return withContext(Object{
+		"id":           baseURL() + "/ap/outbox",
+		"type":         "OrderedCollection",
+		"totalItems":   total,
+		"orderedItems": items,
+	}), nil
+}
+
+func Followers() (Object, error) {
+	total, err := db.GetActivityPubFollowersCount()
+	if err != nil {
+		return nil, err
+	}
+	return withContext(Object{
+		"id":         baseURL() + "/ap/followers",
+		"type":       "OrderedCollection",
+		"totalItems": total,
+	}), nil
+}
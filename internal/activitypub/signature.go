@@ -0,0 +1,272 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/synccache"
+	uhc "github.com/zijiren233/go-uhc"
+)
+
+const (
+	maxClockSkew  = time.Hour
+	maxObjectSize = 1024 * 1024
+)
+
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func signingString(req *http.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		switch h {
+		case "(request-target)":
+			lines[i] = fmt.Sprintf("(request-target): %s %s", strings.ToLower(req.Method), req.URL.RequestURI())
+		case "host":
+			host := req.Host
+			if host == "" {
+				host = req.URL.Host
+			}
+			lines[i] = "host: " + host
+		default:
+			v := req.Header.Get(h)
+			if v == "" {
+				return "", fmt.Errorf("signed header %s is missing", h)
+			}
+			lines[i] = h + ": " + v
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// sign signs the request with the actor key, http signatures draft-cavage rsa-sha256
+func sign(req *http.Request, body []byte) error {
+	key, err := PrivateKey()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", digest(body))
+		headers = append(headers, "digest")
+	}
+	s, err := signingString(req, headers)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(s))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		KeyID(),
+		strings.Join(headers, " "),
+		base64.StdEncoding.EncodeToString(sig),
+	))
+	return nil
+}
+
+func parseSignature(header string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[k] = strings.Trim(v, `"`)
+	}
+	return params
+}
+
+// RemoteActor is the part of a remote actor document used for delivery and verification
+type RemoteActor struct {
+	ID        string `json:"id"`
+	Inbox     string `json:"inbox"`
+	Endpoints struct {
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	PublicKey struct {
+		ID           string `json:"id"`
+		Owner        string `json:"owner"`
+		PublicKeyPem string `json:"publicKeyPem"`
+	} `json:"publicKey"`
+}
+
+var actorCache = synccache.NewSyncCache[string, *RemoteActor](time.Minute * 10)
+
+func checkRemoteURL(u string) error {
+	if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		return errors.New("invalid remote url")
+	}
+	if !settings.AllowProxyToLocal.Get() {
+		if l, err := utils.ParseURLIsLocalIP(u); err != nil {
+			return err
+		} else if l {
+			return errors.New("remote url is local")
+		}
+	}
+	return nil
+}
+
+// fetchActor fetches a remote actor with a signed get, for servers in secure mode
+func fetchActor(ctx context.Context, id string) (*RemoteActor, error) {
+	if e, ok := actorCache.Load(id); ok {
+		return e.Value(), nil
+	}
+	if err := checkRemoteURL(id); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ContentType)
+	req.Header.Set("User-Agent", utils.UA)
+	if err := sign(req, nil); err != nil {
+		return nil, err
+	}
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch actor %s: %s", id, resp.Status)
+	}
+	var actor RemoteActor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxObjectSize)).Decode(&actor); err != nil {
+		return nil, err
+	}
+	if actor.ID == "" || actor.Inbox == "" {
+		return nil, fmt.Errorf("invalid actor %s", id)
+	}
+	actorCache.Store(id, &actor, time.Hour)
+	return &actor, nil
+}
+
+// Verify checks the http signature of an inbox request and returns the signing actor
+func Verify(ctx context.Context, req *http.Request, body []byte) (*RemoteActor, error) {
+	params := parseSignature(req.Header.Get("Signature"))
+	keyID := params["keyId"]
+	if keyID == "" || params["signature"] == "" {
+		return nil, errors.New("missing signature")
+	}
+	if alg := params["algorithm"]; alg != "" && alg != "rsa-sha256" && alg != "hs2019" {
+		return nil, fmt.Errorf("unsupported signature algorithm: %s", alg)
+	}
+	headers := strings.Fields(params["headers"])
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	var signedDigest, signedTarget bool
+	for _, h := range headers {
+		switch h {
+		case "digest":
+			signedDigest = true
+		case "(request-target)":
+			signedTarget = true
+		}
+	}
+	if !signedTarget || (body != nil && !signedDigest) {
+		return nil, errors.New("signature does not cover the request")
+	}
+	if body != nil && req.Header.Get("Digest") != digest(body) {
+		return nil, errors.New("digest mismatch")
+	}
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+	if d := time.Since(date); d > maxClockSkew || d < -maxClockSkew {
+		return nil, errors.New("date out of range")
+	}
+
+	actorID, _, _ := strings.Cut(keyID, "#")
+	actor, err := fetchActor(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.PublicKey.ID != keyID {
+		return nil, errors.New("unknown key")
+	}
+	block, _ := pem.Decode([]byte(actor.PublicKey.PublicKeyPem))
+	if block == nil {
+		return nil, errors.New("invalid actor public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("actor public key is not rsa")
+	}
+	sig, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return nil, err
+	}
+	s, err := signingString(req, headers)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(s))
+	if err := rsa.VerifyPKCS1v15(rsaPub, crypto.SHA256, sum[:], sig); err != nil {
+		return nil, errors.New("invalid signature")
+	}
+	return actor, nil
+}
+
+// post delivers a signed activity to an inbox
+func post(ctx context.Context, inbox string, activity Object) error {
+	if err := checkRemoteURL(inbox); err != nil {
+		return err
+	}
+	body, err := json.Marshal(withContext(activity))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ldContentType)
+	req.Header.Set("User-Agent", utils.UA)
+	if err := sign(req, body); err != nil {
+		return err
+	}
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		return errGone
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("deliver to %s: %s", inbox, resp.Status)
+	}
+	return nil
+}
+
+var errGone = errors.New("inbox gone")
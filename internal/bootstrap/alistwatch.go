@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitAlistWatch(ctx context.Context) error {
+	op.StartAlistWatch(ctx)
+	return nil
+}
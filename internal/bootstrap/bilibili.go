@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitBilibiliLive(ctx context.Context) error {
+	op.StartBilibiliLive(ctx)
+	return nil
+}
@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/cmd/flags"
+	"github.com/synctv-org/synctv/internal/chaos"
+	"github.com/synctv-org/synctv/internal/conf"
+)
+
+func InitChaos(ctx context.Context) error {
+	if !conf.Conf.Chaos.Enable {
+		return nil
+	}
+	if !flags.Global.Dev {
+		log.Warn("chaos: fault injection is only enabled in dev mode, ignored")
+		return nil
+	}
+	if err := chaos.Load(conf.Conf.Chaos.Rules); err != nil {
+		return err
+	}
+	log.Warnf("chaos: injecting faults into vendor and plugin calls with %d rules", len(conf.Conf.Chaos.Rules))
+	return nil
+}
@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func InitChatEncryption(ctx context.Context) error {
+	if err := model.SetChatEncryptionKeys(conf.Conf.Chat.EncryptionKeys); err != nil {
+		return err
+	}
+	// move chat stored with a rotated out key or in plain onto the current key
+	go func() {
+		n, err := db.ReencryptChat(model.CurrentChatKeyID())
+		if err != nil {
+			log.Errorf("reencrypt chat error: %v", err)
+			return
+		}
+		if n != 0 {
+			log.Infof("reencrypted the chat of %d pinned messages and snapshots", n)
+		}
+	}()
+	return nil
+}
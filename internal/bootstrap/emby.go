@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitEmbyPlaybackReport(ctx context.Context) error {
+	op.StartEmbyPlaybackReport(ctx)
+	return nil
+}
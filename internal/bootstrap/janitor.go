@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/janitor"
+)
+
+func InitJanitor(ctx context.Context) error {
+	janitor.Start(ctx)
+	return nil
+}
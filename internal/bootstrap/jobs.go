@@ -0,0 +1,11 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/jobs"
+)
+
+func InitJobs(ctx context.Context) error {
+	return jobs.Start(ctx)
+}
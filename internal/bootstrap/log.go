@@ -13,6 +13,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/cmd/flags"
 	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/roomlog"
 	"github.com/synctv-org/synctv/utils"
 	"github.com/zijiren233/go-colorable"
 )
@@ -95,6 +96,7 @@ func InitLog(ctx context.Context) (err error) {
 			},
 		})
 	}
+	logrus.AddHook(roomlog.Hook{})
 	log.SetOutput(logrus.StandardLogger().Writer())
 	return nil
 }
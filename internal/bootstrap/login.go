@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitLoginHistory(ctx context.Context) error {
+	op.StartLoginHistoryCleanup(ctx)
+	return nil
+}
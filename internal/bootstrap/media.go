@@ -0,0 +1,17 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/media"
+	"github.com/synctv-org/synctv/utils"
+)
+
+func InitMedia(ctx context.Context) error {
+	path, err := utils.OptFilePath(conf.Conf.Media.Path)
+	if err != nil {
+		return err
+	}
+	return media.Init(path)
+}
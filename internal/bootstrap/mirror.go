@@ -0,0 +1,11 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitRoomMirror(ctx context.Context) error {
+	return op.StartRoomMirrors()
+}
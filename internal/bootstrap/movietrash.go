@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitMovieTrash(ctx context.Context) error {
+	op.StartMovieTrashCleanup(ctx)
+	return nil
+}
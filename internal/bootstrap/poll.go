@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitMoviePoll(ctx context.Context) error {
+	op.StartMoviePollCloser(ctx)
+	return nil
+}
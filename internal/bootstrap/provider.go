@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
@@ -14,10 +15,12 @@ import (
 	"github.com/synctv-org/synctv/cmd/flags"
 	"github.com/synctv-org/synctv/internal/conf"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/internal/provider/aggregations"
 	"github.com/synctv-org/synctv/internal/provider/plugins"
 	"github.com/synctv-org/synctv/internal/provider/providers"
+	_ "github.com/synctv-org/synctv/internal/provider/providers/all"
 	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/utils"
 	"github.com/zijiren233/gencontainer/refreshcache"
@@ -28,12 +31,31 @@ var (
 )
 
 type ProviderGroupSetting struct {
+	Provider          provider.OAuth2Provider
 	Enabled           settings.BoolSetting
+	DisabledReason    settings.StringSetting
 	ClientID          settings.StringSetting
 	ClientSecret      settings.StringSetting
 	RedirectURL       settings.StringSetting
 	DisableUserSignup settings.BoolSetting
 	SignupNeedReview  settings.BoolSetting
+	// empty uses the global oauth2_username_collision
+	UsernameCollision settings.StringSetting
+}
+
+func validateProviderUsernameCollision(s string) error {
+	if s == "" {
+		return nil
+	}
+	return settings.ValidateUsernameCollision(s)
+}
+
+// UsernameCollisionStrategy returns the collision strategy used by the provider
+func (p *ProviderGroupSetting) UsernameCollisionStrategy() model.UsernameCollision {
+	if s := p.UsernameCollision.Get(); s != "" {
+		return s
+	}
+	return settings.OAuth2UsernameCollision.Get()
 }
 
 var (
@@ -57,6 +79,17 @@ var (
 	}, 0)
 )
 
+// ProviderDisabledReason returns the reason the admin gave when turning the
+// provider off, providers never enabled have none
+func ProviderDisabledReason(p provider.OAuth2Provider) (string, bool) {
+	s, ok := ProviderGroupSettings[model.SettingGroup(fmt.Sprintf("%s_%s", model.SettingGroupOauth2, p))]
+	if !ok || s.Enabled.Get() {
+		return "", false
+	}
+	reason := s.DisabledReason.Get()
+	return reason, reason != ""
+}
+
 func InitProvider(ctx context.Context) (err error) {
 	logOur := log.StandardLogger().Writer()
 	logLevle := hclog.Info
@@ -75,18 +108,48 @@ func InitProvider(ctx context.Context) (err error) {
 			log.Fatalf("create plugin dir: %s failed: %s", filepath.Dir(op.PluginFile), err)
 			return err
 		}
-		err = plugins.InitProviderPlugins(op.PluginFile, op.Args, hclog.New(&hclog.LoggerOptions{
-			Name:   op.PluginFile,
-			Level:  logLevle,
-			Output: logOur,
-			Color:  hclog.ForceColor,
-		}))
+		if strings.HasSuffix(op.PluginFile, ".wasm") {
+			err = plugins.InitWasmProviderPlugin(ctx, op.PluginFile, op.Args)
+		} else {
+			err = plugins.InitProviderPlugins(ctx, op.PluginFile, op.Args, hclog.New(&hclog.LoggerOptions{
+				Name:   op.PluginFile,
+				Level:  logLevle,
+				Output: logOur,
+				Color:  hclog.ForceColor,
+			}))
+		}
 		if err != nil {
 			log.Fatalf("load oauth2 plugin: %s failed: %s", op.PluginFile, err)
 			return err
 		}
 	}
 
+	for _, c := range conf.Conf.Oauth2Customs {
+		if _, ok := op.IsOrgProvider(c.Name); ok {
+			log.Fatalf("custom oauth2 provider %s uses the name of organization providers", c.Name)
+			return fmt.Errorf("custom oauth2 provider %s is reserved", c.Name)
+		}
+		if _, ok := providers.AllProvider()[c.Name]; ok {
+			log.Fatalf("custom oauth2 provider %s conflicts with a registered provider", c.Name)
+			return fmt.Errorf("custom oauth2 provider %s already exists", c.Name)
+		}
+		pi, err := providers.NewCustomProvider(providers.CustomOption{
+			Name:          c.Name,
+			AuthURL:       c.AuthURL,
+			TokenURL:      c.TokenURL,
+			UserInfoURL:   c.UserInfoURL,
+			Scopes:        c.Scopes,
+			IDField:       c.IDField,
+			UsernameField: c.UsernameField,
+		})
+		if err != nil {
+			log.Fatalf("load custom oauth2 provider error: %v", err)
+			return err
+		}
+		log.Infof("load custom oauth2 provider: %s", c.Name)
+		providers.RegisterProviderWithSource(providers.ProviderSourceConfig, pi)
+	}
+
 	for _, pi := range providers.AllProvider() {
 		InitProviderSetting(pi)
 	}
@@ -99,9 +162,11 @@ func InitProvider(ctx context.Context) (err error) {
 
 func InitProviderSetting(pi provider.Provider) {
 	group := model.SettingGroup(fmt.Sprintf("%s_%s", model.SettingGroupOauth2, pi.Provider()))
-	groupSettings := &ProviderGroupSetting{}
+	groupSettings := &ProviderGroupSetting{Provider: pi.Provider()}
 	ProviderGroupSettings[group] = groupSettings
 
+	groupSettings.DisabledReason = settings.NewStringSetting(fmt.Sprintf("%s_disabled_reason", group), "", group)
+
 	groupSettings.Enabled = settings.NewBoolSetting(fmt.Sprintf("%s_enabled", group), false, group,
 		settings.WithBeforeInitBool(func(bs settings.BoolSetting, b bool) (bool, error) {
 			defer Oauth2EnabledCache.Refresh(context.Background())
@@ -168,13 +233,85 @@ func InitProviderSetting(pi provider.Provider) {
 	groupSettings.DisableUserSignup = settings.NewBoolSetting(fmt.Sprintf("%s_disable_user_signup", group), false, group)
 
 	groupSettings.SignupNeedReview = settings.NewBoolSetting(fmt.Sprintf("%s_signup_need_review", group), false, group)
+
+	groupSettings.UsernameCollision = settings.NewStringSetting(fmt.Sprintf("%s_username_collision", group), "", group, settings.WithValidatorString(validateProviderUsernameCollision))
+
+	switch pi := pi.(type) {
+	case *plugins.GRPCClient:
+		settings.NewStringSetting(fmt.Sprintf("%s_scopes", group), "", group,
+			settings.WithBeforeInitString(func(ss settings.StringSetting, s string) (string, error) {
+				opt.Scopes = splitScopes(s)
+				pi.Init(opt)
+				return s, nil
+			}),
+			settings.WithInitPriorityString(1),
+			settings.WithBeforeSetString(func(ss settings.StringSetting, s string) (string, error) {
+				opt.Scopes = splitScopes(s)
+				pi.Init(opt)
+				return s, nil
+			}),
+		)
+		settings.NewStringSetting(fmt.Sprintf("%s_auth_url", group), "", group,
+			settings.WithBeforeInitString(func(ss settings.StringSetting, s string) (string, error) {
+				opt.AuthURL = s
+				pi.Init(opt)
+				return s, nil
+			}),
+			settings.WithInitPriorityString(1),
+			settings.WithBeforeSetString(func(ss settings.StringSetting, s string) (string, error) {
+				opt.AuthURL = s
+				pi.Init(opt)
+				return s, nil
+			}),
+		)
+		settings.NewStringSetting(fmt.Sprintf("%s_token_url", group), "", group,
+			settings.WithBeforeInitString(func(ss settings.StringSetting, s string) (string, error) {
+				opt.TokenURL = s
+				pi.Init(opt)
+				return s, nil
+			}),
+			settings.WithInitPriorityString(1),
+			settings.WithBeforeSetString(func(ss settings.StringSetting, s string) (string, error) {
+				opt.TokenURL = s
+				pi.Init(opt)
+				return s, nil
+			}),
+		)
+	case *providers.GiteaProvider:
+		settings.NewStringSetting(fmt.Sprintf("%s_base_url", group), providers.DefaultGiteaBaseURL, group,
+			settings.WithBeforeInitString(func(ss settings.StringSetting, s string) (string, error) {
+				pi.SetBaseURL(s)
+				return s, nil
+			}),
+			settings.WithBeforeSetString(func(ss settings.StringSetting, s string) (string, error) {
+				if _, err := url.ParseRequestURI(s); err != nil {
+					return s, err
+				}
+				pi.SetBaseURL(s)
+				return s, nil
+			}),
+		)
+	}
+}
+
+// splitScopes splits the comma separated scopes of a setting
+func splitScopes(s string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
 }
 
 func InitAggregationProviderSetting(pi provider.Provider) {
 	group := model.SettingGroup(fmt.Sprintf("%s_%s", model.SettingGroupOauth2, pi.Provider()))
-	groupSettings := &ProviderGroupSetting{}
+	groupSettings := &ProviderGroupSetting{Provider: pi.Provider()}
 	ProviderGroupSettings[group] = groupSettings
 
+	groupSettings.DisabledReason = settings.LoadOrNewStringSetting(fmt.Sprintf("%s_disabled_reason", group), "", group)
+
 	groupSettings.Enabled = settings.LoadOrNewBoolSetting(fmt.Sprintf("%s_enabled", group), false, group,
 		settings.WithBeforeSetBool(func(bs settings.BoolSetting, b bool) (bool, error) {
 			defer Oauth2EnabledCache.Refresh(context.Background())
@@ -218,6 +355,8 @@ func InitAggregationProviderSetting(pi provider.Provider) {
 	groupSettings.DisableUserSignup = settings.LoadOrNewBoolSetting(fmt.Sprintf("%s_disable_user_signup", group), false, group)
 
 	groupSettings.SignupNeedReview = settings.LoadOrNewBoolSetting(fmt.Sprintf("%s_signup_need_review", group), false, group)
+
+	groupSettings.UsernameCollision = settings.LoadOrNewStringSetting(fmt.Sprintf("%s_username_collision", group), "", group, settings.WithValidatorString(validateProviderUsernameCollision))
 }
 
 func InitAggregationSetting(pi provider.AggregationProviderInterface) {
@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitProviderToken(ctx context.Context) error {
+	if err := model.ProviderTokenKeyring.Set(conf.Conf.ProviderToken.EncryptionKeys); err != nil {
+		return err
+	}
+	// move tokens and vendor credentials encrypted with a rotated out key or
+	// the legacy keys onto the current key
+	go func() {
+		keyID := model.ProviderTokenKeyring.CurrentID()
+		n, err := db.ReencryptUserProviderTokens(keyID)
+		if err != nil {
+			log.Errorf("reencrypt provider tokens error: %v", err)
+		} else if n != 0 {
+			log.Infof("reencrypted %d provider tokens", n)
+		}
+		n, err = db.ReencryptVendors(keyID)
+		if err != nil {
+			log.Errorf("reencrypt vendor credentials error: %v", err)
+		} else if n != 0 {
+			log.Infof("reencrypted %d vendor credentials", n)
+		}
+	}()
+	op.StartProviderTokenRefresh(ctx)
+	return nil
+}
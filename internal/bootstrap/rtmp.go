@@ -31,7 +31,7 @@ func auth(ReqAppName, ReqChannelName string, IsPublisher bool) (*rtmps.Channel,
 			log.Errorf("rtmp: get room by id error: %v", err)
 			return nil, err
 		}
-		return r.Value().GetChannel(channelName)
+		return r.Value().PublishChannel(channelName)
 	}
 
 	if !settings.RtmpPlayer.Get() {
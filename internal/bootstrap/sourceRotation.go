@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitSourceRotation(ctx context.Context) error {
+	op.StartSourceRotation(ctx)
+	return nil
+}
@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/storage"
+	"github.com/synctv-org/synctv/utils"
+)
+
+func InitStorage(ctx context.Context) error {
+	// the local driver keeps files where the media directory always had them
+	path, err := utils.OptFilePath(conf.Conf.Media.Path)
+	if err != nil {
+		return err
+	}
+	return storage.Init(conf.Conf.Storage, path)
+}
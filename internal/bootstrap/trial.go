@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+func InitTrialAdmin(ctx context.Context) error {
+	op.StartTrialAdminExpiry(ctx)
+	return nil
+}
@@ -2,10 +2,51 @@ package bootstrap
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 
+	"github.com/hashicorp/go-hclog"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/cmd/flags"
+	"github.com/synctv-org/synctv/internal/conf"
 	"github.com/synctv-org/synctv/internal/vendor"
+	"github.com/synctv-org/synctv/utils"
 )
 
 func InitVendorBackend(ctx context.Context) error {
-	return vendor.Init(ctx)
+	if err := vendor.Init(ctx); err != nil {
+		return err
+	}
+	return initVendorPlugins()
+}
+
+func initVendorPlugins() (err error) {
+	logLevel := hclog.Info
+	if flags.Global.Dev {
+		logLevel = hclog.Debug
+	}
+	for _, vp := range conf.Conf.VendorPlugins {
+		vp.PluginFile, err = utils.OptFilePath(vp.PluginFile)
+		if err != nil {
+			log.Fatalf("vendor plugin file path error: %v", err)
+			return err
+		}
+		log.Infof("load vendor plugin: %s", vp.PluginFile)
+		err = os.MkdirAll(filepath.Dir(vp.PluginFile), 0755)
+		if err != nil {
+			log.Fatalf("create plugin dir: %s failed: %s", filepath.Dir(vp.PluginFile), err)
+			return err
+		}
+		err = vendor.InitVendorPlugin(vp.PluginFile, vp.Args, hclog.New(&hclog.LoggerOptions{
+			Name:   vp.PluginFile,
+			Level:  logLevel,
+			Output: log.StandardLogger().Writer(),
+			Color:  hclog.ForceColor,
+		}))
+		if err != nil {
+			log.Fatalf("load vendor plugin: %s failed: %s", vp.PluginFile, err)
+			return err
+		}
+	}
+	return nil
 }
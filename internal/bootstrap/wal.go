@@ -0,0 +1,20 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/wal"
+	"github.com/synctv-org/synctv/utils"
+)
+
+func InitWal(ctx context.Context) error {
+	if !conf.Conf.Wal.Enable {
+		return nil
+	}
+	path, err := utils.OptFilePath(conf.Conf.Wal.Path)
+	if err != nil {
+		return err
+	}
+	return wal.Init(path)
+}
@@ -79,10 +79,10 @@ func AlistAuthorizationCacheWithConfigInitFunc(ctx context.Context, v *model.Ali
 	}
 }
 
-type AlistMovieCache = refreshcache.RefreshCache[*AlistMovieCacheData, *AlistMovieCacheFuncArgs]
+type AlistMovieCache = StaleCache[*AlistMovieCacheData, *AlistMovieCacheFuncArgs]
 
 func NewAlistMovieCache(movie *model.Movie, subPath string) *AlistMovieCache {
-	return refreshcache.NewRefreshCache(NewAlistMovieCacheInitFunc(movie, subPath), time.Minute*14)
+	return NewStaleCache(NewAlistMovieCacheInitFunc(movie, subPath), time.Minute*10, time.Minute*4)
 }
 
 type AlistProvider = string
@@ -104,6 +104,8 @@ type AlistMovieCacheData struct {
 	Subtitles []*AlistSubtitle
 	Provider  string
 	Ali       *AlistAliCache
+	// when the earliest signed url expires, zero if unknown
+	ExpireAt time.Time
 }
 
 type AlistAliCache struct {
@@ -198,84 +200,98 @@ func NewAlistMovieCacheInitFunc(movie *model.Movie, subPath string) func(ctx con
 		if aucd.Host == "" {
 			return nil, errors.New("not bind alist vendor")
 		}
-		cli := vendor.LoadAlistClient(movie.MovieBase.VendorInfo.Backend)
-		fg, err := cli.FsGet(ctx, &alist.FsGetReq{
+		backend := movie.MovieBase.VendorInfo.Backend
+		password := movie.MovieBase.VendorInfo.Alist.Password
+		userAgent := args[0].UserAgent
+		key := flightKey("alist", backend, aucd.Host, aucd.Token, truePath, password, userAgent)
+		return doFlight(ctx, key, func(ctx context.Context) (*AlistMovieCacheData, error) {
+			return alistFsGet(ctx, aucd, backend, truePath, password, userAgent)
+		})
+	}
+}
+
+func alistFsGet(ctx context.Context, aucd *AlistUserCacheData, backend, truePath, password, userAgent string) (*AlistMovieCacheData, error) {
+	cli := vendor.LoadAlistClient(backend)
+	fg, err := cli.FsGet(ctx, &alist.FsGetReq{
+		Host:      aucd.Host,
+		Token:     aucd.Token,
+		Path:      truePath,
+		Password:  password,
+		UserAgent: userAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if fg.IsDir {
+		return nil, fmt.Errorf("path is dir: %s", truePath)
+	}
+
+	cache := &AlistMovieCacheData{
+		URL:      fg.RawUrl,
+		Provider: fg.Provider,
+		ExpireAt: URLExpiry(fg.RawUrl),
+	}
+
+	prefix := strings.TrimSuffix(truePath, fg.Name)
+	for _, related := range fg.Related {
+		if related.Type != 4 {
+			continue
+		}
+		// 弹幕文件
+		if utils.GetFileExtension(related.Name) == "xml" {
+			continue
+		}
+		resp, err := cli.FsGet(ctx, &alist.FsGetReq{
 			Host:      aucd.Host,
 			Token:     aucd.Token,
-			Path:      truePath,
-			Password:  movie.MovieBase.VendorInfo.Alist.Password,
-			UserAgent: args[0].UserAgent,
+			Path:      prefix + related.Name,
+			Password:  password,
+			UserAgent: userAgent,
 		})
 		if err != nil {
 			return nil, err
 		}
+		cache.Subtitles = append(cache.Subtitles, &AlistSubtitle{
+			Name: related.Name,
+			URL:  resp.RawUrl,
+			Type: utils.GetFileExtension(resp.Name),
+			Cache: refreshcache.NewRefreshCache(func(ctx context.Context, args ...struct{}) ([]byte, error) {
+				r, err := http.NewRequestWithContext(ctx, http.MethodGet, resp.RawUrl, nil)
+				if err != nil {
+					return nil, err
+				}
+				resp, err := uhc.Do(r)
+				if err != nil {
+					return nil, err
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+				}
+				return io.ReadAll(resp.Body)
+			}, -1),
+		})
+	}
 
-		if fg.IsDir {
-			return nil, fmt.Errorf("path is dir: %s", truePath)
-		}
-
-		cache := &AlistMovieCacheData{
-			URL:      fg.RawUrl,
-			Provider: fg.Provider,
+	if fg.Provider == AlistProviderAli {
+		fo, err := cli.FsOther(ctx, &alist.FsOtherReq{
+			Host:     aucd.Host,
+			Token:    aucd.Token,
+			Path:     truePath,
+			Password: password,
+			Method:   "video_preview",
+		})
+		if err != nil {
+			return nil, err
 		}
-
-		prefix := strings.TrimSuffix(truePath, fg.Name)
-		for _, related := range fg.Related {
-			if related.Type != 4 {
-				continue
-			}
-			// 弹幕文件
-			if utils.GetFileExtension(related.Name) == "xml" {
-				continue
-			}
-			resp, err := cli.FsGet(ctx, &alist.FsGetReq{
-				Host:      aucd.Host,
-				Token:     aucd.Token,
-				Path:      prefix + related.Name,
-				Password:  movie.MovieBase.VendorInfo.Alist.Password,
-				UserAgent: args[0].UserAgent,
-			})
-			if err != nil {
-				return nil, err
-			}
-			cache.Subtitles = append(cache.Subtitles, &AlistSubtitle{
-				Name: related.Name,
-				URL:  resp.RawUrl,
-				Type: utils.GetFileExtension(resp.Name),
-				Cache: refreshcache.NewRefreshCache(func(ctx context.Context, args ...struct{}) ([]byte, error) {
-					r, err := http.NewRequestWithContext(ctx, http.MethodGet, resp.RawUrl, nil)
-					if err != nil {
-						return nil, err
-					}
-					resp, err := uhc.Do(r)
-					if err != nil {
-						return nil, err
-					}
-					defer resp.Body.Close()
-					if resp.StatusCode != http.StatusOK {
-						return nil, fmt.Errorf("status code: %d", resp.StatusCode)
-					}
-					return io.ReadAll(resp.Body)
-				}, -1),
-			})
+		cache.Ali = &AlistAliCache{
+			M3U8ListFile: genAliM3U8ListFile(fo.VideoPreviewPlayInfo.LiveTranscodingTaskList),
 		}
-
-		if fg.Provider == AlistProviderAli {
-			fo, err := cli.FsOther(ctx, &alist.FsOtherReq{
-				Host:     aucd.Host,
-				Token:    aucd.Token,
-				Path:     truePath,
-				Password: movie.MovieBase.VendorInfo.Alist.Password,
-				Method:   "video_preview",
-			})
-			if err != nil {
-				return nil, err
-			}
-			cache.Ali = &AlistAliCache{
-				M3U8ListFile: genAliM3U8ListFile(fo.VideoPreviewPlayInfo.LiveTranscodingTaskList),
-			}
-			cache.Subtitles = append(cache.Subtitles, newAliSubtitles(fo.VideoPreviewPlayInfo.LiveTranscodingSubtitleTaskList)...)
+		for _, v := range fo.VideoPreviewPlayInfo.LiveTranscodingTaskList {
+			cache.ExpireAt = earliest(cache.ExpireAt, URLExpiry(v.Url))
 		}
-		return cache, nil
+		cache.Subtitles = append(cache.Subtitles, newAliSubtitles(fo.VideoPreviewPlayInfo.LiveTranscodingSubtitleTaskList)...)
 	}
+	return cache, nil
 }
@@ -356,22 +356,51 @@ func BilibiliLiveCacheInitFunc(ctx context.Context, movie *model.Movie, args ...
 	if err != nil {
 		return nil, err
 	}
-	return genBilibiliLiveM3U8ListFile(resp.LiveStreams), nil
+	return genBilibiliLiveM3U8ListFile(selectBilibiliLiveStreams(resp.LiveStreams, movie.MovieBase.VendorInfo.Bilibili.Quality)), nil
+}
+
+// selectBilibiliLiveStreams keeps the best streams not above quality,
+// all streams are kept when quality is not set
+func selectBilibiliLiveStreams(streams []*bilibili.LiveStream, quality uint64) []*bilibili.LiveStream {
+	if quality == 0 {
+		return streams
+	}
+	var best uint64
+	for _, v := range streams {
+		if len(v.Urls) != 0 && v.Quality <= quality && v.Quality > best {
+			best = v.Quality
+		}
+	}
+	if best == 0 {
+		// the room has no stream at or below the quality, use the lowest
+		for _, v := range streams {
+			if len(v.Urls) != 0 && (best == 0 || v.Quality < best) {
+				best = v.Quality
+			}
+		}
+	}
+	selected := make([]*bilibili.LiveStream, 0, 1)
+	for _, v := range streams {
+		if v.Quality == best {
+			selected = append(selected, v)
+		}
+	}
+	return selected
 }
 
 type BilibiliMovieCache struct {
 	NoSharedMovie *MapCache[string, *BilibiliUserCache]
-	SharedMpd     *refreshcache.RefreshCache[*BilibiliMpdCache, *BilibiliUserCache]
+	SharedMpd     *StaleCache[*BilibiliMpdCache, *BilibiliUserCache]
 	Subtitle      *refreshcache.RefreshCache[BilibiliSubtitleCache, *BilibiliUserCache]
-	Live          *refreshcache.RefreshCache[[]byte, struct{}]
+	Live          *StaleCache[[]byte, struct{}]
 }
 
 func NewBilibiliMovieCache(movie *model.Movie) *BilibiliMovieCache {
 	return &BilibiliMovieCache{
 		NoSharedMovie: newMapCache(NewBilibiliNoSharedMovieCacheInitFunc(movie), time.Minute*60),
-		SharedMpd:     refreshcache.NewRefreshCache(NewBilibiliSharedMpdCacheInitFunc(movie), time.Minute*60),
+		SharedMpd:     NewStaleCache(NewBilibiliSharedMpdCacheInitFunc(movie), time.Minute*50, time.Minute*10),
 		Subtitle:      refreshcache.NewRefreshCache(NewBilibiliSubtitleCacheInitFunc(movie), 0),
-		Live:          refreshcache.NewRefreshCache(NewBilibiliLiveCacheInitFunc(movie), time.Minute*55),
+		Live:          NewStaleCache(NewBilibiliLiveCacheInitFunc(movie), time.Minute*45, time.Minute*10),
 	}
 }
 
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/vendor"
+)
+
+// the stream urls of acfun and youku are signed and expire, they are
+// resolved again with the cookies of the movie creator
+type CookieVendorMovieCache = StaleCache[*vendor.VendorStreams, struct{}]
+
+func NewCookieVendorMovieCache(movie *model.Movie) *CookieVendorMovieCache {
+	return NewStaleCache(NewCookieVendorMovieCacheInitFunc(movie), time.Minute*15, time.Minute*5)
+}
+
+func NewCookieVendorMovieCacheInitFunc(movie *model.Movie) func(ctx context.Context, args ...struct{}) (*vendor.VendorStreams, error) {
+	return func(ctx context.Context, args ...struct{}) (*vendor.VendorStreams, error) {
+		info := movie.MovieBase.VendorInfo
+		var cookies map[string]string
+		v, err := db.GetCookieVendor(movie.CreatorID, info.Vendor)
+		switch {
+		case err == nil:
+			cookies = v.Cookies
+		case !errors.Is(err, db.ErrNotFound("vendor")):
+			return nil, err
+		}
+		switch info.Vendor {
+		case model.VendorAcfun:
+			return vendor.AcfunResolve(ctx, info.Acfun.ID, cookies)
+		case model.VendorYouku:
+			return vendor.YoukuResolve(ctx, info.Youku.Vid, cookies)
+		default:
+			return nil, fmt.Errorf("vendor %s has no cookie binding", info.Vendor)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/vendor"
+)
+
+// the play addresses of videos expire in about an hour
+type DouyinMovieCache = StaleCache[*vendor.DouyinStream, struct{}]
+
+func NewDouyinMovieCache(movie *model.Movie) *DouyinMovieCache {
+	return NewStaleCache(NewDouyinMovieCacheInitFunc(movie), time.Minute*25, time.Minute*5)
+}
+
+func NewDouyinMovieCacheInitFunc(movie *model.Movie) func(ctx context.Context, args ...struct{}) (*vendor.DouyinStream, error) {
+	return func(ctx context.Context, args ...struct{}) (*vendor.DouyinStream, error) {
+		return vendor.DouyinResolve(ctx, movie.MovieBase.VendorInfo.Douyin, movie.MovieBase.Live)
+	}
+}
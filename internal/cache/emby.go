@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/db"
@@ -26,6 +28,8 @@ type EmbyUserCacheData struct {
 	ApiKey   string
 	UserID   string
 	Backend  string
+	// report playback in rooms back to the server
+	ReportPlayback bool
 }
 
 func NewEmbyUserCache(userID string) *EmbyUserCache {
@@ -46,11 +50,12 @@ func EmbyAuthorizationCacheWithUserIDInitFunc(userID, serverID string) (*EmbyUse
 		return nil, db.ErrNotFound("vendor")
 	}
 	return &EmbyUserCacheData{
-		Host:     v.Host,
-		ServerID: v.ServerID,
-		ApiKey:   v.ApiKey,
-		UserID:   v.EmbyUserID,
-		Backend:  v.Backend,
+		Host:           v.Host,
+		ServerID:       v.ServerID,
+		ApiKey:         v.ApiKey,
+		UserID:         v.EmbyUserID,
+		Backend:        v.Backend,
+		ReportPlayback: v.ReportPlayback,
 	}, nil
 }
 
@@ -69,6 +74,10 @@ type EmbySource struct {
 type EmbyMovieCacheData struct {
 	Sources            []EmbySource
 	TranscodeSessionID string
+	// when the earliest source url expires, zero if unknown. stream urls
+	// carry the api key instead of a signature and only expire once the
+	// key is revoked, but transcoding and remote sources may be signed
+	ExpireAt time.Time
 }
 
 type EmbyMovieCache = refreshcache.RefreshCache[*EmbyMovieCacheData, *EmbyUserCache]
@@ -146,85 +155,103 @@ func NewEmbyMovieCacheInitFunc(movie *model.Movie, subPath string) func(ctx cont
 		if aucd.Host == "" || aucd.ApiKey == "" {
 			return nil, errors.New("not bind emby vendor")
 		}
-		cli := vendor.LoadEmbyClient(aucd.Backend)
-		data, err := cli.PlaybackInfo(ctx, &emby.PlaybackInfoReq{
-			Host:   aucd.Host,
-			Token:  aucd.ApiKey,
-			UserId: aucd.UserID,
-			ItemId: truePath,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("playback info: %w", err)
-		}
-		var resp EmbyMovieCacheData = EmbyMovieCacheData{
-			Sources:            make([]EmbySource, len(data.MediaSourceInfo)),
-			TranscodeSessionID: data.PlaySessionID,
+		if movie.MovieBase.VendorInfo.Emby.Channel {
+			u, err := vendor.EmbyLiveTvStreamURL(aucd.Host, aucd.ApiKey, truePath)
+			if err != nil {
+				return nil, err
+			}
+			return &EmbyMovieCacheData{
+				Sources: []EmbySource{{URL: u, Name: "live"}},
+			}, nil
 		}
-		u, err := url.Parse(aucd.Host)
-		if err != nil {
-			return nil, err
+		transcode := movie.MovieBase.VendorInfo.Emby.Transcode
+		key := flightKey("emby", aucd.Backend, aucd.Host, aucd.UserID, truePath, strconv.FormatBool(transcode))
+		return doFlight(ctx, key, func(ctx context.Context) (*EmbyMovieCacheData, error) {
+			return embyPlaybackInfo(ctx, aucd, truePath, transcode)
+		})
+	}
+}
+
+func embyPlaybackInfo(ctx context.Context, aucd *EmbyUserCacheData, truePath string, transcode bool) (*EmbyMovieCacheData, error) {
+	cli := vendor.LoadEmbyClient(aucd.Backend)
+	data, err := cli.PlaybackInfo(ctx, &emby.PlaybackInfoReq{
+		Host:   aucd.Host,
+		Token:  aucd.ApiKey,
+		UserId: aucd.UserID,
+		ItemId: truePath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("playback info: %w", err)
+	}
+	var resp EmbyMovieCacheData = EmbyMovieCacheData{
+		Sources:            make([]EmbySource, len(data.MediaSourceInfo)),
+		TranscodeSessionID: data.PlaySessionID,
+	}
+	u, err := url.Parse(aucd.Host)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range data.MediaSourceInfo {
+		if transcode && v.TranscodingUrl != "" {
+			resp.Sources[i].URL = fmt.Sprintf("%s/emby%s", aucd.Host, v.TranscodingUrl)
+			resp.Sources[i].IsTranscode = true
+			resp.Sources[i].Name = v.Name
+		} else if v.DirectPlayUrl != "" {
+			resp.Sources[i].URL = fmt.Sprintf("%s/emby%s", aucd.Host, v.DirectPlayUrl)
+			resp.Sources[i].IsTranscode = false
+			resp.Sources[i].Name = v.Name
+		} else {
+			if v.Container == "" {
+				continue
+			}
+			result, err := url.JoinPath("emby", "Videos", truePath, fmt.Sprintf("stream.%s", v.Container))
+			if err != nil {
+				return nil, err
+			}
+			u.Path = result
+			query := url.Values{}
+			query.Set("api_key", aucd.ApiKey)
+			query.Set("Static", "true")
+			query.Set("MediaSourceId", v.Id)
+			u.RawQuery = query.Encode()
+			resp.Sources[i].URL = u.String()
+			resp.Sources[i].Name = v.Name
 		}
-		for i, v := range data.MediaSourceInfo {
-			if movie.MovieBase.VendorInfo.Emby.Transcode && v.TranscodingUrl != "" {
-				resp.Sources[i].URL = fmt.Sprintf("%s/emby%s", aucd.Host, v.TranscodingUrl)
-				resp.Sources[i].IsTranscode = true
-				resp.Sources[i].Name = v.Name
-			} else if v.DirectPlayUrl != "" {
-				resp.Sources[i].URL = fmt.Sprintf("%s/emby%s", aucd.Host, v.DirectPlayUrl)
-				resp.Sources[i].IsTranscode = false
-				resp.Sources[i].Name = v.Name
-			} else {
-				if v.Container == "" {
-					continue
-				}
-				result, err := url.JoinPath("emby", "Videos", truePath, fmt.Sprintf("stream.%s", v.Container))
+		resp.ExpireAt = earliest(resp.ExpireAt, URLExpiry(resp.Sources[i].URL))
+		for _, msi := range v.MediaStreamInfo {
+			switch msi.Type {
+			case "Subtitle":
+				subtutleType := "srt"
+				result, err := url.JoinPath("emby", "Videos", truePath, v.Id, "Subtitles", fmt.Sprintf("%d", msi.Index), fmt.Sprintf("Stream.%s", subtutleType))
 				if err != nil {
 					return nil, err
 				}
 				u.Path = result
-				query := url.Values{}
-				query.Set("api_key", aucd.ApiKey)
-				query.Set("Static", "true")
-				query.Set("MediaSourceId", v.Id)
-				u.RawQuery = query.Encode()
-				resp.Sources[i].URL = u.String()
-				resp.Sources[i].Name = v.Name
-			}
-			for _, msi := range v.MediaStreamInfo {
-				switch msi.Type {
-				case "Subtitle":
-					subtutleType := "srt"
-					result, err := url.JoinPath("emby", "Videos", truePath, v.Id, "Subtitles", fmt.Sprintf("%d", msi.Index), fmt.Sprintf("Stream.%s", subtutleType))
-					if err != nil {
-						return nil, err
-					}
-					u.Path = result
-					u.RawQuery = ""
-					url := u.String()
-					name := msi.DisplayTitle
-					if name == "" {
-						if msi.Title != "" {
-							name = msi.Title
-						} else {
-							name = msi.DisplayLanguage
-						}
+				u.RawQuery = ""
+				url := u.String()
+				name := msi.DisplayTitle
+				if name == "" {
+					if msi.Title != "" {
+						name = msi.Title
+					} else {
+						name = msi.DisplayLanguage
 					}
-					resp.Sources[i].Subtitles = append(resp.Sources[i].Subtitles, struct {
-						URL   string
-						Type  string
-						Name  string
-						Cache *refreshcache.RefreshCache[[]byte, struct{}]
-					}{
-						URL:   url,
-						Type:  subtutleType,
-						Name:  name,
-						Cache: refreshcache.NewRefreshCache(newEmbySubtitleCacheInitFunc(url), 0),
-					})
 				}
+				resp.Sources[i].Subtitles = append(resp.Sources[i].Subtitles, struct {
+					URL   string
+					Type  string
+					Name  string
+					Cache *refreshcache.RefreshCache[[]byte, struct{}]
+				}{
+					URL:   url,
+					Type:  subtutleType,
+					Name:  name,
+					Cache: refreshcache.NewRefreshCache(newEmbySubtitleCacheInitFunc(url), 0),
+				})
 			}
 		}
-		return &resp, nil
 	}
+	return &resp, nil
 }
 
 func newEmbySubtitleCacheInitFunc(url string) func(ctx context.Context, args ...struct{}) ([]byte, error) {
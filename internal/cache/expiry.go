@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLExpiry reads when a signed url expires from its query, it is zero if
+// the url carries no known expiry
+func URLExpiry(rawURL string) time.Time {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return time.Time{}
+	}
+	q := u.Query()
+	// aws v4 signs the start and the lifetime
+	if date, expires := q.Get("X-Amz-Date"), q.Get("X-Amz-Expires"); date != "" && expires != "" {
+		t, err := time.Parse("20060102T150405Z", date)
+		sec, err2 := strconv.ParseInt(expires, 10, 64)
+		if err == nil && err2 == nil {
+			return t.Add(time.Duration(sec) * time.Second)
+		}
+	}
+	// aws v2, aliyun oss, tencent cos and most cdns sign the unix time
+	for _, k := range []string{"Expires", "expires", "x-oss-expires", "x-cos-expires", "e"} {
+		if t := unixExpiry(q.Get(k)); !t.IsZero() {
+			return t
+		}
+	}
+	// alist signs as <signature>:<unix time>, 0 never expires
+	if sign := q.Get("sign"); sign != "" {
+		if i := strings.LastIndexByte(sign, ':'); i != -1 {
+			return unixExpiry(sign[i+1:])
+		}
+	}
+	return time.Time{}
+}
+
+func unixExpiry(s string) time.Time {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	// anything before 2001 is a lifetime or a flag rather than a time
+	if err != nil || sec < 1e9 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// earliest is the earlier of the non zero times
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || !b.IsZero() && b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// an origin request shared by waiters outlives the caller that started it,
+// but not by more than this
+const flightTimeout = time.Minute
+
+// flights dedupes origin requests across all caches, every movie of every
+// room resolving the same vendor item waits on the one request in flight
+var flights singleflight.Group
+
+func flightKey(vendor string, parts ...string) string {
+	return vendor + "\x00" + strings.Join(parts, "\x00")
+}
+
+// doFlight runs fn unless a call with the same key is in flight, in which
+// case it waits for that call's result. fn must not depend on the caller
+// beyond what the key covers, since its result is handed to every waiter.
+func doFlight[T any](ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ch := flights.DoChan(key, func() (any, error) {
+		// a waiter leaving must not fail the others
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flightTimeout)
+		defer cancel()
+		return fn(ctx)
+	})
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			var zero T
+			return zero, r.Err
+		}
+		return r.Val.(T), nil
+	}
+}
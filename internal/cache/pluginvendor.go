@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/vendor"
+	vendorpb "github.com/synctv-org/synctv/proto/vendor"
+)
+
+// PluginVendorMovie is the streams and subtitles resolved by a vendor plugin
+type PluginVendorMovie struct {
+	Streams   []*vendorpb.Stream
+	Subtitles []*vendorpb.Subtitle
+	ExpireAt  time.Time
+}
+
+type PluginVendorMovieCache = StaleCache[*PluginVendorMovie, struct{}]
+
+func NewPluginVendorMovieCache(movie *model.Movie) *PluginVendorMovieCache {
+	return NewStaleCache(NewPluginVendorMovieCacheInitFunc(movie), time.Minute*10, time.Minute*5)
+}
+
+// NewPluginVendorMovieCacheInitFunc resolves the movie with GetURL the first
+// time, later refreshes happen because the urls aged and use Refresh so the
+// plugin does not answer from its own cache
+func NewPluginVendorMovieCacheInitFunc(movie *model.Movie) func(ctx context.Context, args ...struct{}) (*PluginVendorMovie, error) {
+	var resolved atomic.Bool
+	return func(ctx context.Context, args ...struct{}) (*PluginVendorMovie, error) {
+		info := movie.MovieBase.VendorInfo.Plugin
+		p, err := vendor.LoadPlugin(info.Name)
+		if err != nil {
+			return nil, err
+		}
+		var resp *vendorpb.GetURLResp
+		if resolved.Load() {
+			resp, err = p.Refresh(ctx, info.ID)
+		} else {
+			resp, err = p.GetURL(ctx, info.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		resolved.Store(true)
+		subtitles, err := p.GetSubtitles(ctx, info.ID)
+		if err != nil {
+			return nil, err
+		}
+		m := &PluginVendorMovie{
+			Streams:   resp.Streams,
+			Subtitles: subtitles,
+		}
+		if resp.ExpiresAt != 0 {
+			m.ExpireAt = time.Unix(resp.ExpiresAt, 0)
+		}
+		for _, s := range resp.Streams {
+			m.ExpireAt = earliest(m.ExpireAt, URLExpiry(s.Url))
+		}
+		return m, nil
+	}
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zijiren233/gencontainer/refreshcache"
+)
+
+type staleEntry[T any] struct {
+	data T
+	at   time.Time
+}
+
+// StaleCache is a refresh cache serving stale-while-revalidate: data older
+// than ttl is still served for up to maxStale while one background refresh
+// replaces it, so viewers never wait on the origin because signed urls are
+// about to expire. Only data older than ttl+maxStale is refreshed in the
+// foreground, with concurrent callers waiting on the same refresh.
+type StaleCache[T any, A any] struct {
+	*refreshcache.RefreshCache[T, A]
+	ttl          time.Duration
+	last         atomic.Pointer[staleEntry[T]]
+	revalidating atomic.Bool
+}
+
+func NewStaleCache[T any, A any](refreshFunc refreshcache.RefreshFunc[T, A], ttl, maxStale time.Duration) *StaleCache[T, A] {
+	c := &StaleCache[T, A]{ttl: ttl}
+	c.RefreshCache = refreshcache.NewRefreshCache(func(ctx context.Context, args ...A) (T, error) {
+		data, err := refreshFunc(ctx, args...)
+		if err == nil {
+			c.last.Store(&staleEntry[T]{data: data, at: time.Now()})
+		}
+		return data, err
+	}, ttl+maxStale)
+	return c
+}
+
+func (c *StaleCache[T, A]) Get(ctx context.Context, args ...A) (T, error) {
+	e := c.last.Load()
+	if e == nil {
+		return c.RefreshCache.Get(ctx, args...)
+	}
+	age := time.Since(e.at)
+	if age < c.ttl {
+		return e.data, nil
+	}
+	if age < time.Duration(c.RefreshCache.MaxAge()) {
+		c.revalidate(ctx, args...)
+		return e.data, nil
+	}
+	return c.RefreshCache.Get(ctx, args...)
+}
+
+func (c *StaleCache[T, A]) revalidate(ctx context.Context, args ...A) {
+	if !c.revalidating.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer c.revalidating.Store(false)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flightTimeout)
+		defer cancel()
+		if _, err := c.RefreshCache.Refresh(ctx, args...); err != nil {
+			log.Warnf("revalidate stale cache error: %v", err)
+		}
+	}()
+}
+
+func (c *StaleCache[T, A]) Clear(ctx context.Context, args ...A) error {
+	c.last.Store(nil)
+	return c.RefreshCache.Clear(ctx, args...)
+}
@@ -0,0 +1,167 @@
+// Package chaos injects latency and failures into the calls to vendor
+// backends and plugins, to exercise circuit breakers, source rotation and
+// clients without flaky upstreams. It is only enabled in dev mode
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/synctv-org/synctv/internal/conf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// prefixes of call targets
+const (
+	TargetVendor       = "vendor:"
+	TargetVendorPlugin = "vendor_plugin:"
+	TargetOauth2Plugin = "oauth2_plugin:"
+)
+
+type rule struct {
+	target           string
+	method           string
+	latency          time.Duration
+	jitter           time.Duration
+	errorRate        float64
+	tokenExpiredRate float64
+}
+
+var rules atomic.Pointer[[]rule]
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Load replaces the rules, nil disables fault injection
+func Load(rs conf.ChaosRules) error {
+	if len(rs) == 0 {
+		rules.Store(nil)
+		return nil
+	}
+	parsed := make([]rule, 0, len(rs))
+	for i, r := range rs {
+		if _, err := path.Match(r.Target, ""); err != nil {
+			return fmt.Errorf("chaos rule %d: invalid target: %w", i, err)
+		}
+		latency, err := parseDuration(r.Latency)
+		if err != nil {
+			return fmt.Errorf("chaos rule %d: invalid latency: %w", i, err)
+		}
+		jitter, err := parseDuration(r.Jitter)
+		if err != nil {
+			return fmt.Errorf("chaos rule %d: invalid jitter: %w", i, err)
+		}
+		if r.ErrorRate < 0 || r.TokenExpiredRate < 0 || r.ErrorRate+r.TokenExpiredRate > 1 {
+			return fmt.Errorf("chaos rule %d: rates must be between 0 and 1", i)
+		}
+		parsed = append(parsed, rule{
+			target:           r.Target,
+			method:           r.Method,
+			latency:          latency,
+			jitter:           jitter,
+			errorRate:        r.ErrorRate,
+			tokenExpiredRate: r.TokenExpiredRate,
+		})
+	}
+	rules.Store(&parsed)
+	return nil
+}
+
+func Enabled() bool {
+	return rules.Load() != nil
+}
+
+// match reports if the rule applies to the call, method is the full rpc
+// method like /proto.Oauth2PluginV2/GetUserInfo
+func (r *rule) match(target, method string) bool {
+	if ok, _ := path.Match(r.target, target); !ok {
+		return false
+	}
+	return r.method == "" || strings.HasSuffix(method, "/"+r.method)
+}
+
+func (r *rule) inject(ctx context.Context) error {
+	delay := r.latency
+	if r.jitter > 0 {
+		delay += rand.N(r.jitter)
+	}
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	p := rand.Float64()
+	switch {
+	case p < r.errorRate:
+		return status.Error(codes.Unavailable, "chaos: injected failure")
+	case p < r.errorRate+r.tokenExpiredRate:
+		return status.Error(codes.Unauthenticated, "chaos: injected token expiry")
+	}
+	return nil
+}
+
+// Inject applies the rules matching the call, an error fails the call
+// before it is sent
+func Inject(ctx context.Context, target, method string) error {
+	rs := rules.Load()
+	if rs == nil {
+		return nil
+	}
+	for i := range *rs {
+		r := &(*rs)[i]
+		if !r.match(target, method) {
+			continue
+		}
+		if err := r.inject(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnaryClientInterceptor injects faults into the calls of plugin
+// connections, the internal services of go-plugin are left alone
+func UnaryClientInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !strings.HasPrefix(method, "/plugin.") {
+			if err := Inject(ctx, target, method); err != nil {
+				return err
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Middleware injects faults into the calls of vendor backends, it goes
+// after the circuit breaker so the breaker sees the failures
+func Middleware(target string) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			var method string
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				method = tr.Operation()
+			}
+			if err := Inject(ctx, target, method); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}
+	}
+}
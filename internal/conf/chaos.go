@@ -0,0 +1,26 @@
+package conf
+
+type ChaosConfig struct {
+	Enable bool       `yaml:"enable" hc:"inject faults into the calls to vendor backends and plugins, only honored in dev mode" env:"CHAOS_ENABLE"`
+	Rules  ChaosRules `yaml:"rules"`
+}
+
+type ChaosRules []ChaosRule
+
+type ChaosRule struct {
+	Target string `yaml:"target" hc:"glob of vendor:<endpoint>, vendor_plugin:<file> or oauth2_plugin:<file>"`
+	Method string `yaml:"method" lc:"rpc method name like GetUserInfo, empty for all"`
+	// added before the call
+	Latency string `yaml:"latency" lc:"like 500ms"`
+	Jitter  string `yaml:"jitter" lc:"random latency added on top, up to it"`
+	// fractions of calls, from 0 to 1
+	ErrorRate        float64 `yaml:"error_rate" lc:"calls failing as unavailable"`
+	TokenExpiredRate float64 `yaml:"token_expired_rate" lc:"calls failing as if the upstream token expired"`
+}
+
+func DefaultChaosConfig() ChaosConfig {
+	return ChaosConfig{
+		Enable: false,
+		Rules:  ChaosRules{},
+	}
+}
@@ -0,0 +1,9 @@
+package conf
+
+type ChatConfig struct {
+	EncryptionKeys []string `yaml:"encryption_keys" hc:"master keys encrypting pinned chat messages and snapshot chat at rest, empty disables encryption. the first key encrypts, the others only decrypt: to rotate put a new key first, stored chat is encrypted with it on startup and the old key can be removed after" env:"CHAT_ENCRYPTION_KEYS"`
+}
+
+func DefaultChatConfig() ChatConfig {
+	return ChatConfig{}
+}
@@ -20,8 +20,32 @@ type Config struct {
 	// Oauth2Plugins
 	Oauth2Plugins Oauth2Plugins `yaml:"oauth2_plugins"`
 
+	// Oauth2Customs
+	Oauth2Customs Oauth2Customs `yaml:"oauth2_customs"`
+
+	// VendorPlugins
+	VendorPlugins VendorPlugins `yaml:"vendor_plugins"`
+
 	// RateLimit
 	RateLimit RateLimitConfig `yaml:"rate_limit"`
+
+	// Media
+	Media MediaConfig `yaml:"media"`
+
+	// Storage
+	Storage StorageConfig `yaml:"storage"`
+
+	// Chat
+	Chat ChatConfig `yaml:"chat"`
+
+	// ProviderToken
+	ProviderToken ProviderTokenConfig `yaml:"provider_token"`
+
+	// Wal
+	Wal WalConfig `yaml:"wal"`
+
+	// Chaos
+	Chaos ChaosConfig `yaml:"chaos"`
 }
 
 func (c *Config) Save(file string) error {
@@ -44,8 +68,30 @@ func DefaultConfig() *Config {
 
 		// OAuth2
 		Oauth2Plugins: DefaultOauth2Plugins(),
+		Oauth2Customs: DefaultOauth2Customs(),
+
+		// VendorPlugins
+		VendorPlugins: DefaultVendorPlugins(),
 
 		// RateLimit
 		RateLimit: DefaultRateLimitConfig(),
+
+		// Media
+		Media: DefaultMediaConfig(),
+
+		// Storage
+		Storage: DefaultStorageConfig(),
+
+		// Chat
+		Chat: DefaultChatConfig(),
+
+		// ProviderToken
+		ProviderToken: DefaultProviderTokenConfig(),
+
+		// Wal
+		Wal: DefaultWalConfig(),
+
+		// Chaos
+		Chaos: DefaultChaosConfig(),
 	}
 }
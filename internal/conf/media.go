@@ -0,0 +1,11 @@
+package conf
+
+type MediaConfig struct {
+	Path string `yaml:"path" hc:"where uploaded media is stored, if it is a relative path, the data-dir directory will be used." env:"MEDIA_PATH"`
+}
+
+func DefaultMediaConfig() MediaConfig {
+	return MediaConfig{
+		Path: "media",
+	}
+}
@@ -1,10 +1,24 @@
 package conf
 
 type Oauth2Plugins []struct {
-	PluginFile string   `yaml:"plugin_file"`
+	PluginFile string   `yaml:"plugin_file" hc:"files ending in .wasm are run as WebAssembly plugins, others as subprocesses"`
 	Args       []string `yaml:"args"`
 }
 
 func DefaultOauth2Plugins() Oauth2Plugins {
 	return nil
 }
+
+type Oauth2Customs []struct {
+	Name          string   `yaml:"name" hc:"provider name, also used in the callback url /oauth2/callback/<name>"`
+	AuthURL       string   `yaml:"auth_url"`
+	TokenURL      string   `yaml:"token_url"`
+	UserInfoURL   string   `yaml:"userinfo_url"`
+	Scopes        []string `yaml:"scopes"`
+	IDField       string   `yaml:"id_field" hc:"dot separated path of the user id in the userinfo json, default: id"`
+	UsernameField string   `yaml:"username_field" hc:"dot separated path of the username in the userinfo json, default: username"`
+}
+
+func DefaultOauth2Customs() Oauth2Customs {
+	return nil
+}
@@ -0,0 +1,9 @@
+package conf
+
+type ProviderTokenConfig struct {
+	EncryptionKeys []string `yaml:"encryption_keys" hc:"master keys encrypting the oauth2 tokens kept for membership checks and the credentials of vendor bindings, empty encrypts them with legacy per user keys. the first key encrypts, the others only decrypt: to rotate put a new key first, they are encrypted with it on startup and the old key can be removed after" env:"PROVIDER_TOKEN_ENCRYPTION_KEYS"`
+}
+
+func DefaultProviderTokenConfig() ProviderTokenConfig {
+	return ProviderTokenConfig{}
+}
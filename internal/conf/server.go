@@ -1,8 +1,12 @@
 package conf
 
 type ServerConfig struct {
-	Http HttpServerConfig `yaml:"http"`
-	Rtmp RtmpServerConfig `yaml:"rtmp"`
+	Http     HttpServerConfig     `yaml:"http"`
+	Rtmp     RtmpServerConfig     `yaml:"rtmp"`
+	Player   PlayerServerConfig   `yaml:"player" hc:"tcp/json sync protocol for external players like mpv and vlc"`
+	Syncplay SyncplayServerConfig `yaml:"syncplay" hc:"syncplay compatible server, syncplay users join rooms as guest"`
+	Ws       WsServerConfig       `yaml:"ws" hc:"room websocket connections"`
+	Cdn      CdnServerConfig      `yaml:"cdn" hc:"serve proxied media at urls a cdn in front of synctv can cache for every user"`
 }
 
 type HttpServerConfig struct {
@@ -10,8 +14,13 @@ type HttpServerConfig struct {
 	Port   uint16 `yaml:"port" env:"SERVER_PORT"`
 	Quic   bool   `yaml:"quic" hc:"enable http3/quic need set cert and key file" env:"SERVER_QUIC"`
 
+	TrustedProxies []string `yaml:"trusted_proxies" hc:"ips or cidrs of reverse proxies, X-Forwarded-For and X-Real-IP are only honored from them when resolving the client ip used for logs, logins and rate limiting. empty trusts no proxy" env:"SERVER_TRUSTED_PROXIES"`
+
 	CertPath string `yaml:"cert_path" env:"SERVER_CERT_PATH"`
 	KeyPath  string `yaml:"key_path" env:"SERVER_KEY_PATH"`
+
+	Compression        bool `yaml:"compression" hc:"compress api responses and web assets with brotli or gzip" env:"SERVER_COMPRESSION"`
+	CompressionMinSize int  `yaml:"compression_min_size" lc:"responses of a known length smaller than this many bytes are sent uncompressed" env:"SERVER_COMPRESSION_MIN_SIZE"`
 }
 
 type RtmpServerConfig struct {
@@ -20,6 +29,32 @@ type RtmpServerConfig struct {
 	Port   uint16 `yaml:"port" lc:"default use server port" env:"RTMP_PORT"`
 }
 
+type PlayerServerConfig struct {
+	Enable bool   `yaml:"enable" env:"PLAYER_ENABLE"`
+	Listen string `yaml:"listen" lc:"default use http listen" env:"PLAYER_LISTEN"`
+	Port   uint16 `yaml:"port" env:"PLAYER_PORT"`
+}
+
+type SyncplayServerConfig struct {
+	Enable   bool   `yaml:"enable" env:"SYNCPLAY_ENABLE"`
+	Listen   string `yaml:"listen" lc:"default use http listen" env:"SYNCPLAY_LISTEN"`
+	Port     uint16 `yaml:"port" env:"SYNCPLAY_PORT"`
+	Password string `yaml:"password" lc:"server password asked by syncplay clients, empty for none" env:"SYNCPLAY_PASSWORD"`
+}
+
+type WsServerConfig struct {
+	Compression          bool `yaml:"compression" hc:"negotiate permessage-deflate with clients" env:"WS_COMPRESSION"`
+	CompressionLevel     int  `yaml:"compression_level" lc:"flate level, 1 fastest to 9 smallest" env:"WS_COMPRESSION_LEVEL"`
+	CompressionThreshold int  `yaml:"compression_threshold" lc:"messages smaller than this many bytes are sent uncompressed" env:"WS_COMPRESSION_THRESHOLD"`
+}
+
+type CdnServerConfig struct {
+	Enable         bool   `yaml:"enable" hc:"proxied files and live hls segments are served at signed urls instead of urls with the user token" env:"CDN_ENABLE"`
+	SignExpire     string `yaml:"sign_expire" lc:"lifetime of signed urls, urls stay the same for half of it" env:"CDN_SIGN_EXPIRE"`
+	MaxAge         int    `yaml:"max_age" lc:"seconds cdns keep proxied files and hls segments" env:"CDN_MAX_AGE"`
+	ClientIPHeader string `yaml:"client_ip_header" hc:"header the cdn sends the client ip in, like CF-Connecting-IP, it is only honored from server.http.trusted_proxies so add the ranges of the cdn there" env:"CDN_CLIENT_IP_HEADER"`
+}
+
 func DefaultServerConfig() ServerConfig {
 	return ServerConfig{
 		Http: HttpServerConfig{
@@ -28,10 +63,33 @@ func DefaultServerConfig() ServerConfig {
 			Quic:     true,
 			CertPath: "",
 			KeyPath:  "",
+
+			TrustedProxies: []string{"127.0.0.1", "::1"},
+
+			Compression:        true,
+			CompressionMinSize: 1024,
 		},
 		Rtmp: RtmpServerConfig{
 			Enable: true,
 			Port:   0,
 		},
+		Player: PlayerServerConfig{
+			Enable: false,
+			Port:   8081,
+		},
+		Syncplay: SyncplayServerConfig{
+			Enable: false,
+			Port:   8999,
+		},
+		Ws: WsServerConfig{
+			Compression:          true,
+			CompressionLevel:     1,
+			CompressionThreshold: 256,
+		},
+		Cdn: CdnServerConfig{
+			Enable:     false,
+			SignExpire: "6h",
+			MaxAge:     86400,
+		},
 	}
 }
@@ -0,0 +1,40 @@
+package conf
+
+type StorageDriver string
+
+const (
+	StorageDriverLocal  StorageDriver = "local"
+	StorageDriverS3     StorageDriver = "s3"
+	StorageDriverWebDAV StorageDriver = "webdav"
+)
+
+type StorageConfig struct {
+	Driver StorageDriver       `yaml:"driver" lc:"default: local" hc:"support local, s3, webdav. local stores files in the media path" env:"STORAGE_DRIVER"`
+	S3     S3StorageConfig     `yaml:"s3" hc:"amazon s3 or a s3 compatible object store"`
+	WebDAV WebDAVStorageConfig `yaml:"webdav"`
+}
+
+type S3StorageConfig struct {
+	Endpoint        string `yaml:"endpoint" hc:"like https://s3.us-east-1.amazonaws.com" env:"STORAGE_S3_ENDPOINT"`
+	Region          string `yaml:"region" lc:"default: us-east-1" env:"STORAGE_S3_REGION"`
+	Bucket          string `yaml:"bucket" env:"STORAGE_S3_BUCKET"`
+	AccessKeyID     string `yaml:"access_key_id" env:"STORAGE_S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"STORAGE_S3_SECRET_ACCESS_KEY"`
+	Prefix          string `yaml:"prefix" hc:"prepended to every key, like synctv/" env:"STORAGE_S3_PREFIX"`
+	PathStyle       bool   `yaml:"path_style" hc:"put the bucket in the path instead of the host, most self hosted stores need it" env:"STORAGE_S3_PATH_STYLE"`
+}
+
+type WebDAVStorageConfig struct {
+	URL      string `yaml:"url" hc:"url of the directory files are stored in" env:"STORAGE_WEBDAV_URL"`
+	Username string `yaml:"username" env:"STORAGE_WEBDAV_USERNAME"`
+	Password string `yaml:"password" env:"STORAGE_WEBDAV_PASSWORD"`
+}
+
+func DefaultStorageConfig() StorageConfig {
+	return StorageConfig{
+		Driver: StorageDriverLocal,
+		S3: S3StorageConfig{
+			Region: "us-east-1",
+		},
+	}
+}
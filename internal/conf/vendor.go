@@ -0,0 +1,10 @@
+package conf
+
+type VendorPlugins []struct {
+	PluginFile string   `yaml:"plugin_file" hc:"media vendor plugin, movies of it are added with the vendor plugin and the name it reports"`
+	Args       []string `yaml:"args"`
+}
+
+func DefaultVendorPlugins() VendorPlugins {
+	return nil
+}
@@ -0,0 +1,12 @@
+package conf
+
+type WalConfig struct {
+	Enable bool   `yaml:"enable" hc:"keep a write-ahead log of room mutations, flushed before api calls are acknowledged, to recover the playing state after a crash and replay room events" env:"WAL_ENABLE"`
+	Path   string `yaml:"path" hc:"where the logs are stored, if it is a relative path, the data-dir directory will be used." env:"WAL_PATH"`
+}
+
+func DefaultWalConfig() WalConfig {
+	return WalConfig{
+		Path: "wal",
+	}
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduleUserDeletion requests the deletion of the user at scheduledAt,
+// replacing a pending request
+func ScheduleUserDeletion(userID string, scheduledAt time.Time) (*model.UserDeletion, error) {
+	d := &model.UserDeletion{UserID: userID, ScheduledAt: scheduledAt}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"created_at", "scheduled_at"}),
+	}).Create(d).Error
+	return d, err
+}
+
+func GetUserDeletion(userID string) (*model.UserDeletion, error) {
+	d := &model.UserDeletion{}
+	err := db.Where("user_id = ?", userID).First(d).Error
+	return d, HandleNotFound(err, "deletion request")
+}
+
+func GetUserDeletions(scopes ...func(*gorm.DB) *gorm.DB) ([]*model.UserDeletion, error) {
+	var deletions []*model.UserDeletion
+	err := db.Scopes(scopes...).Find(&deletions).Error
+	return deletions, err
+}
+
+func GetUserDeletionsCount(scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.UserDeletion{}).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+func DeleteUserDeletion(userID string) error {
+	result := db.Where("user_id = ?", userID).Delete(&model.UserDeletion{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "deletion request")
+	}
+	return nil
+}
+
+// GetRoomSuccessor returns the active member a room passes to when its creator
+// is erased, admins before members and older members first
+func GetRoomSuccessor(roomID, creatorID string) (*model.RoomMember, error) {
+	m := &model.RoomMember{}
+	err := db.
+		Where("room_id = ? AND user_id <> ? AND status = ?", roomID, creatorID, model.RoomMemberStatusActive).
+		Order("role DESC").
+		Order("created_at ASC").
+		First(m).Error
+	return m, HandleNotFound(err, "room member")
+}
+
+// TransferRoom makes the member the creator of the room
+func TransferRoom(roomID, userID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Room{}).Where("id = ?", roomID).Update("creator_id", userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return HandleNotFound(gorm.ErrRecordNotFound, "room")
+		}
+		return tx.Model(&model.RoomMember{}).
+			Where("room_id = ? AND user_id = ?", roomID, userID).
+			Updates(map[string]any{
+				"role":              model.RoomMemberRoleCreator,
+				"permissions":       model.AllPermissions,
+				"admin_permissions": model.AllAdminPermissions,
+				"trial":             false,
+			}).Error
+	})
+}
+
+// AnonymizeUser erases the personal data of the user. the user row is kept
+// under a placeholder name so movies, comments and votes stay in their rooms,
+// rooms created by the user must be transferred or deleted before
+func AnonymizeUser(userID, username string) error {
+	if userID == GuestUserID {
+		return errors.New("cannot erase guest user")
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []any{
+			&model.UserProvider{},
+			&model.UserSecret{},
+			&model.BilibiliVendor{},
+			&model.AlistVendor{},
+			&model.EmbyVendor{},
+			&model.CookieVendor{},
+			&model.NotificationSubscription{},
+			&model.NotificationPreference{},
+			&model.RoomFollow{},
+			&model.PresencePreference{},
+			&model.UserAchievement{},
+			&model.UserStats{},
+			&model.RoomMember{},
+			&model.OrganizationMember{},
+			&model.UserDeletion{},
+			&model.UserLogin{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		err := tx.Model(&model.PinnedChatMessage{}).
+			Where("sender_id = ?", userID).
+			Update("sender_name", username).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]any{
+			"username":               username,
+			"hashed_password":        []byte{},
+			"email":                  nil,
+			"role":                   model.RoleBanned,
+			"registered_by_provider": false,
+			"registered_by_email":    false,
+			"max_content_rating":     "",
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return HandleNotFound(gorm.ErrRecordNotFound, "user")
+		}
+		return nil
+	})
+}
+
+func GetRoomMembersByUser(userID string) ([]*model.RoomMember, error) {
+	var members []*model.RoomMember
+	err := db.Where("user_id = ?", userID).Find(&members).Error
+	return members, err
+}
+
+func GetMoviesByCreator(userID string) ([]*model.Movie, error) {
+	var movies []*model.Movie
+	err := db.Where("creator_id = ?", userID).Order("created_at").Find(&movies).Error
+	return movies, err
+}
+
+func GetMovieCommentsByCreator(userID string) ([]*model.MovieComment, error) {
+	var comments []*model.MovieComment
+	err := db.Where("creator_id = ?", userID).Order("created_at").Find(&comments).Error
+	return comments, err
+}
+
+func GetPinnedChatMessagesBySender(userID string) ([]*model.PinnedChatMessage, error) {
+	var messages []*model.PinnedChatMessage
+	err := db.Where("sender_id = ?", userID).Order("sent_at").Find(&messages).Error
+	return messages, err
+}
+
+func GetFollowedRoomIDs(userID string) ([]string, error) {
+	var ids []string
+	err := db.Model(&model.RoomFollow{}).Where("user_id = ?", userID).Pluck("room_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetOrCreateUserStats(userID string) (*model.UserStats, error) {
+	s := &model.UserStats{UserID: userID}
+	err := db.Where(model.UserStats{UserID: userID}).FirstOrCreate(s).Error
+	return s, err
+}
+
+// IncrUserChatMessages returns the chat messages count after the increment
+func IncrUserChatMessages(userID string) (int64, error) {
+	var s *model.UserStats
+	err := Transactional(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserStats{UserID: userID}).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&model.UserStats{}).
+			Where("user_id = ?", userID).
+			Update("chat_messages", gorm.Expr("chat_messages + 1")).Error
+		if err != nil {
+			return err
+		}
+		s = &model.UserStats{}
+		return tx.Where("user_id = ?", userID).First(s).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return s.ChatMessages, nil
+}
+
+// RecordUserWatchDay counts the day of t for the user, see UserStats.RecordWatchDay.
+// the update is conditional on the last watch day so concurrent joins count once
+func RecordUserWatchDay(userID string, t time.Time) (*model.UserStats, bool, error) {
+	s, err := GetOrCreateUserStats(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	last := s.LastWatchDay
+	if !s.RecordWatchDay(t) {
+		return s, false, nil
+	}
+	result := db.Model(&model.UserStats{}).
+		Where("user_id = ? AND last_watch_day = ?", userID, last).
+		Updates(map[string]any{
+			"movie_nights":   s.MovieNights,
+			"streak":         s.Streak,
+			"longest_streak": s.LongestStreak,
+			"last_watch_day": s.LastWatchDay,
+		})
+	return s, result.RowsAffected != 0, result.Error
+}
+
+// CreateUserAchievement reports whether the achievement was newly unlocked
+func CreateUserAchievement(userID string, a model.Achievement) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserAchievement{
+		UserID:      userID,
+		Achievement: a,
+	})
+	return result.RowsAffected != 0, result.Error
+}
+
+func GetUserAchievements(userID string) ([]*model.UserAchievement, error) {
+	var achievements []*model.UserAchievement
+	err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&achievements).Error
+	return achievements, err
+}
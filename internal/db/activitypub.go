@@ -0,0 +1,53 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateActivityPubFollower(f *model.ActivityPubFollower) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(f).Error
+}
+
+func DeleteActivityPubFollower(actorID string) error {
+	return db.Where("actor_id = ?", actorID).Delete(&model.ActivityPubFollower{}).Error
+}
+
+func DeleteActivityPubFollowersByInbox(inbox string) error {
+	return db.Where("inbox = ? OR shared_inbox = ?", inbox, inbox).Delete(&model.ActivityPubFollower{}).Error
+}
+
+func GetActivityPubFollowers() ([]*model.ActivityPubFollower, error) {
+	var followers []*model.ActivityPubFollower
+	err := db.Find(&followers).Error
+	return followers, err
+}
+
+func GetActivityPubFollowersCount() (int64, error) {
+	var count int64
+	err := db.Model(&model.ActivityPubFollower{}).Count(&count).Error
+	return count, err
+}
+
+func CreateActivityPubNote(note *model.ActivityPubNote) error {
+	return db.Create(note).Error
+}
+
+func GetActivityPubNote(id string) (*model.ActivityPubNote, error) {
+	note := &model.ActivityPubNote{}
+	err := db.Where("id = ?", id).First(note).Error
+	return note, HandleNotFound(err, "note")
+}
+
+func GetActivityPubNotes(scopes ...func(*gorm.DB) *gorm.DB) ([]*model.ActivityPubNote, error) {
+	var notes []*model.ActivityPubNote
+	err := db.Scopes(scopes...).Find(&notes).Error
+	return notes, err
+}
+
+func GetActivityPubNotesCount() (int64, error) {
+	var count int64
+	err := db.Model(&model.ActivityPubNote{}).Count(&count).Error
+	return count, err
+}
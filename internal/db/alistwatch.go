@@ -0,0 +1,50 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateAlistWatch(watch *model.AlistWatch) error {
+	return db.Create(watch).Error
+}
+
+func GetAlistWatchesByRoomID(roomID string) ([]*model.AlistWatch, error) {
+	var watches []*model.AlistWatch
+	err := db.Where("room_id = ?", roomID).Order("created_at").Find(&watches).Error
+	return watches, err
+}
+
+func GetAlistWatch(roomID, id string) (*model.AlistWatch, error) {
+	watch := &model.AlistWatch{}
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(watch).Error
+	return watch, HandleNotFound(err, "alist watch")
+}
+
+// GetDueAlistWatches returns the watches last checked before before
+func GetDueAlistWatches(before time.Time) ([]*model.AlistWatch, error) {
+	var watches []*model.AlistWatch
+	err := db.Where("last_check_at < ?", before).Find(&watches).Error
+	return watches, err
+}
+
+func UpdateAlistWatchCheck(id string, known []string, checkAt time.Time, lastError string) error {
+	return db.Model(&model.AlistWatch{ID: id}).Select("known", "last_check_at", "last_error").Updates(&model.AlistWatch{
+		Known:       known,
+		LastCheckAt: checkAt,
+		LastError:   lastError,
+	}).Error
+}
+
+func DeleteAlistWatch(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.AlistWatch{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "alist watch")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateArrWebhook(hook *model.ArrWebhook) error {
+	return db.Create(hook).Error
+}
+
+func GetArrWebhooksByRoomID(roomID string) ([]*model.ArrWebhook, error) {
+	var hooks []*model.ArrWebhook
+	err := db.Where("room_id = ?", roomID).Order("created_at").Find(&hooks).Error
+	return hooks, err
+}
+
+func GetArrWebhook(id string) (*model.ArrWebhook, error) {
+	hook := &model.ArrWebhook{}
+	err := db.Where("id = ?", id).First(hook).Error
+	return hook, HandleNotFound(err, "webhook")
+}
+
+func UpdateArrWebhookEvent(id string, at time.Time, lastError string) error {
+	return db.Model(&model.ArrWebhook{ID: id}).Select("last_event_at", "last_error").Updates(&model.ArrWebhook{
+		LastEventAt: at,
+		LastError:   lastError,
+	}).Error
+}
+
+func DeleteArrWebhook(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.ArrWebhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "webhook")
+	}
+	return nil
+}
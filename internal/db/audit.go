@@ -0,0 +1,39 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateRoomAudit(roomID, userID string, action model.RoomAuditAction, target string) error {
+	return db.Create(&model.RoomAudit{
+		RoomID: roomID,
+		UserID: userID,
+		Action: action,
+		Target: target,
+	}).Error
+}
+
+func GetRoomAuditsCount(roomID string, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.RoomAudit{}).Where("room_id = ?", roomID).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+func GetRoomAudits(roomID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.RoomAudit, error) {
+	var audits []*model.RoomAudit
+	err := db.Where("room_id = ?", roomID).Scopes(scopes...).Find(&audits).Error
+	return audits, err
+}
+
+func WhereRoomAuditAction(action model.RoomAuditAction) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("action = ?", action)
+	}
+}
+
+func WhereRoomAuditUserID(userID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", userID)
+	}
+}
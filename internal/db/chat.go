@@ -0,0 +1,70 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreatePinnedChatMessage(m *model.PinnedChatMessage) error {
+	return db.Create(m).Error
+}
+
+func GetPinnedChatMessages(roomID string) ([]*model.PinnedChatMessage, error) {
+	var messages []*model.PinnedChatMessage
+	err := db.Where("room_id = ?", roomID).Order("created_at asc").Find(&messages).Error
+	return messages, err
+}
+
+func GetPinnedChatMessagesCount(roomID string) (int64, error) {
+	var count int64
+	err := db.Model(&model.PinnedChatMessage{}).Where("room_id = ?", roomID).Count(&count).Error
+	return count, err
+}
+
+func DeletePinnedChatMessage(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.PinnedChatMessage{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "pinned message")
+	}
+	return nil
+}
+
+func DeletePinnedChatMessagesBySender(roomID, senderID string) (int64, error) {
+	result := db.Where("room_id = ? AND sender_id = ?", roomID, senderID).Delete(&model.PinnedChatMessage{})
+	return result.RowsAffected, result.Error
+}
+
+// ReencryptChat saves the pinned messages and snapshots not encrypted with the
+// chat key again, which encrypts them with it
+func ReencryptChat(keyID string) (int64, error) {
+	var (
+		messages []*model.PinnedChatMessage
+		count    int64
+	)
+	err := db.Where("key_id IS NULL OR key_id <> ?", keyID).FindInBatches(&messages, 100, func(tx *gorm.DB, batch int) error {
+		for _, m := range messages {
+			if err := db.Save(m).Error; err != nil {
+				return err
+			}
+		}
+		count += int64(len(messages))
+		return nil
+	}).Error
+	if err != nil {
+		return count, err
+	}
+	var snapshots []*model.RoomSnapshot
+	err = db.Where("chat_key_id IS NULL OR chat_key_id <> ?", keyID).FindInBatches(&snapshots, 10, func(tx *gorm.DB, batch int) error {
+		for _, s := range snapshots {
+			if err := db.Save(s).Error; err != nil {
+				return err
+			}
+		}
+		count += int64(len(snapshots))
+		return nil
+	}).Error
+	return count, err
+}
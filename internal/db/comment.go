@@ -0,0 +1,94 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateMovieComment(comment *model.MovieComment) error {
+	return db.Create(comment).Error
+}
+
+func GetMovieComment(roomID, id string) (*model.MovieComment, error) {
+	comment := &model.MovieComment{}
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(comment).Error
+	return comment, HandleNotFound(err, "comment")
+}
+
+// GetMovieComments returns the root comments of the movie with their replies and reactions
+func GetMovieComments(roomID, movieID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.MovieComment, error) {
+	var comments []*model.MovieComment
+	err := db.
+		Where("room_id = ? AND movie_id = ? AND parent_id = ?", roomID, movieID, "").
+		Preload("Replies", OrderByCreatedAtAsc).
+		Preload("Replies.Reactions").
+		Preload("Reactions").
+		Scopes(scopes...).
+		Find(&comments).Error
+	return comments, err
+}
+
+func GetMovieCommentsCount(roomID, movieID string) (int64, error) {
+	var count int64
+	err := db.Model(&model.MovieComment{}).
+		Where("room_id = ? AND movie_id = ? AND parent_id = ?", roomID, movieID, "").
+		Count(&count).Error
+	return count, err
+}
+
+func UpdateMovieCommentContent(roomID, id, content string) error {
+	result := db.Model(&model.MovieComment{}).
+		Where("room_id = ? AND id = ?", roomID, id).
+		Update("content", content)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "comment")
+	}
+	return nil
+}
+
+// DeleteMovieComment deletes the comment and the replies of its thread
+func DeleteMovieComment(roomID, id string) error {
+	return Transactional(func(tx *gorm.DB) error {
+		var ids []string
+		err := tx.Model(&model.MovieComment{}).
+			Where("room_id = ? AND (id = ? OR parent_id = ?)", roomID, id, id).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return HandleNotFound(gorm.ErrRecordNotFound, "comment")
+		}
+		if err := tx.Where("comment_id IN ?", ids).Delete(&model.MovieCommentReaction{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&model.MovieComment{}).Error
+	})
+}
+
+func AddMovieCommentReaction(commentID, userID, emoji string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.MovieCommentReaction{
+		CommentID: commentID,
+		UserID:    userID,
+		Emoji:     emoji,
+	}).Error
+}
+
+func RemoveMovieCommentReaction(commentID, userID, emoji string) error {
+	return db.Where("comment_id = ? AND user_id = ? AND emoji = ?", commentID, userID, emoji).
+		Delete(&model.MovieCommentReaction{}).Error
+}
+
+// GetMovieCommentReactionEmojis returns the distinct emojis reacted to the comment
+func GetMovieCommentReactionEmojis(commentID string) ([]string, error) {
+	var emojis []string
+	err := db.Model(&model.MovieCommentReaction{}).
+		Where("comment_id = ?", commentID).
+		Distinct().
+		Pluck("emoji", &emojis).Error
+	return emojis, err
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortColumn is a column lists are sorted by, ties are broken by the id
+type SortColumn struct {
+	Column string
+	// times are kept in cursors as unix nanoseconds
+	Time bool
+}
+
+// CursorPage is a page of a list sorted by a column, it starts after the row
+// the cursor marks, or at the offset for clients paging by page numbers
+type CursorPage struct {
+	Sort     SortColumn
+	Desc     bool
+	IDColumn string
+	Cursor   string
+	Offset   int
+	Limit    int
+}
+
+type cursor struct {
+	Value any    `json:"v"`
+	ID    string `json:"i"`
+}
+
+func (p *CursorPage) decodeCursor() (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if n, ok := c.Value.(json.Number); ok {
+		i, err := n.Int64()
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.Value = i
+	}
+	if p.Sort.Time {
+		ns, ok := c.Value.(int64)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		c.Value = time.Unix(0, ns)
+	}
+	return &c, nil
+}
+
+// Scope orders the rows and skips the rows up to the cursor, it gets one row
+// more than the limit to tell whether there is a next page
+func (p *CursorPage) Scope() (func(*gorm.DB) *gorm.DB, error) {
+	var c *cursor
+	if p.Cursor != "" {
+		var err error
+		if c, err = p.decodeCursor(); err != nil {
+			return nil, err
+		}
+	}
+	order, cmp := "ASC", ">"
+	if p.Desc {
+		order, cmp = "DESC", "<"
+	}
+	column, id := p.Sort.Column, p.IDColumn
+	return func(db *gorm.DB) *gorm.DB {
+		if c != nil {
+			db = db.Where(
+				fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", column, cmp, column, id, cmp),
+				c.Value, c.Value, c.ID,
+			)
+		} else if p.Offset > 0 {
+			db = db.Offset(p.Offset)
+		}
+		return db.
+			Order(fmt.Sprintf("%s %s", column, order)).
+			Order(fmt.Sprintf("%s %s", id, order)).
+			Limit(p.Limit + 1)
+	}, nil
+}
+
+// NextCursor trims the extra row of the page and returns the cursor of the
+// next page, empty if rows is the last page. key returns the sorted value
+// and the id of a row
+func NextCursor[T any](p *CursorPage, rows []T, key func(T) (any, string)) ([]T, string) {
+	if len(rows) <= p.Limit {
+		return rows, ""
+	}
+	rows = rows[:p.Limit]
+	v, id := key(rows[len(rows)-1])
+	if t, ok := v.(time.Time); ok {
+		v = t.UnixNano()
+	}
+	b, err := json.Marshal(cursor{Value: v, ID: id})
+	if err != nil {
+		return rows, ""
+	}
+	return rows, base64.RawURLEncoding.EncodeToString(b)
+}
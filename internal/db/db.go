@@ -25,6 +25,10 @@ func Init(d *gorm.DB, t conf.DatabaseType) error {
 	if err != nil {
 		return err
 	}
+	err = initSearchIndex()
+	if err != nil {
+		return err
+	}
 	err = initGuestUser()
 	if err != nil {
 		return err
@@ -282,6 +286,12 @@ func WhereIDIn(ids []string) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+func WhereOrgID(orgID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("org_id = ?", orgID)
+	}
+}
+
 func WhereRoomSettingWithoutHidden() func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.Where("hidden = ?", false)
@@ -335,6 +345,34 @@ func HandleNotFound(err error, errMsg ...string) error {
 	return err
 }
 
+// reencrypt saves the rows matching the query with the key id again, which
+// encrypts them with the key. saved rows stop matching, so rows are fetched
+// from the start until none is left instead of paging with FindInBatches,
+// which needs a single primary key
+func reencrypt[T any](query, keyID string) (int64, error) {
+	var count int64
+	for {
+		var rows []*T
+		if err := db.Where(query, keyID).Limit(100).Find(&rows).Error; err != nil {
+			return count, err
+		}
+		for _, r := range rows {
+			if err := db.Save(r).Error; err != nil {
+				return count, err
+			}
+		}
+		count += int64(len(rows))
+		if len(rows) < 100 {
+			return count, nil
+		}
+	}
+}
+
+// reencryptKeyID reencrypts the rows of models keeping their key id in key_id
+func reencryptKeyID[T any](keyID string) (int64, error) {
+	return reencrypt[T]("key_id IS NULL OR key_id <> ?", keyID)
+}
+
 func Transactional(txFunc func(*gorm.DB) error) (err error) {
 	tx := db.Begin()
 	defer func() {
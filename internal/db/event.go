@@ -0,0 +1,62 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateRoomEvent(event *model.RoomEvent) error {
+	return db.Create(event).Error
+}
+
+func GetRoomEvent(roomID, id string) (*model.RoomEvent, error) {
+	event := &model.RoomEvent{}
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(event).Error
+	return event, HandleNotFound(err, "event")
+}
+
+func SaveRoomEvent(event *model.RoomEvent) error {
+	return db.Where("room_id = ? AND id = ?", event.RoomID, event.ID).Omit("created_at").Save(event).Error
+}
+
+func DeleteRoomEvent(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.RoomEvent{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "event")
+	}
+	return nil
+}
+
+func GetRoomEvents(roomID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.RoomEvent, error) {
+	var events []*model.RoomEvent
+	err := db.Where("room_id = ?", roomID).Scopes(scopes...).Find(&events).Error
+	return events, err
+}
+
+func GetRoomEventsCount(roomID string, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.RoomEvent{}).Where("room_id = ?", roomID).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+// GetUserRoomEvents returns the events of all rooms the user is an active member of
+func GetUserRoomEvents(userID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.RoomEvent, error) {
+	var events []*model.RoomEvent
+	err := db.Where("room_id IN (?)",
+		db.Model(&model.RoomMember{}).
+			Select("room_id").
+			Where("user_id = ? AND status = ?", userID, model.RoomMemberStatusActive),
+	).Scopes(scopes...).Find(&events).Error
+	return events, err
+}
+
+func WhereRoomEventStartAfter(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("start_at >= ?", t)
+	}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateJob(job *model.Job) error {
+	return db.Create(job).Error
+}
+
+func GetJob(id string) (*model.Job, error) {
+	job := &model.Job{}
+	err := db.Where("id = ?", id).First(job).Error
+	return job, HandleNotFound(err, "job")
+}
+
+func GetJobsCount(scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.Job{}).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+func GetJobs(scopes ...func(*gorm.DB) *gorm.DB) ([]*model.Job, error) {
+	var jobs []*model.Job
+	err := db.Scopes(scopes...).Find(&jobs).Error
+	return jobs, err
+}
+
+// ClaimDueJobs marks up to limit pending jobs due by now as running and
+// returns them. a job another server claimed first is skipped
+func ClaimDueJobs(now time.Time, limit int) ([]*model.Job, error) {
+	var due []*model.Job
+	err := db.
+		Where("status = ? AND run_at <= ?", model.JobStatusPending, now).
+		Order("run_at ASC").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+	claimed := due[:0]
+	for _, j := range due {
+		result := db.Model(&model.Job{}).
+			Where("id = ? AND status = ?", j.ID, model.JobStatusPending).
+			Updates(map[string]any{
+				"status":   model.JobStatusRunning,
+				"attempts": gorm.Expr("attempts + 1"),
+			})
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		j.Status = model.JobStatusRunning
+		j.Attempts++
+		claimed = append(claimed, j)
+	}
+	return claimed, nil
+}
+
+func SetJobSucceeded(id string) error {
+	err := db.Model(&model.Job{}).Where("id = ?", id).Updates(map[string]any{
+		"status":     model.JobStatusSucceeded,
+		"last_error": "",
+	}).Error
+	return HandleNotFound(err, "job")
+}
+
+// SetJobFailed records the error of the attempt, the job runs again at
+// retryAt unless it is zero
+func SetJobFailed(id string, jobErr string, retryAt time.Time) error {
+	updates := map[string]any{
+		"status":     model.JobStatusFailed,
+		"last_error": jobErr,
+	}
+	if !retryAt.IsZero() {
+		updates["status"] = model.JobStatusPending
+		updates["run_at"] = retryAt
+	}
+	err := db.Model(&model.Job{}).Where("id = ?", id).Updates(updates).Error
+	return HandleNotFound(err, "job")
+}
+
+// RetryJob queues a failed job again with a fresh attempt budget
+func RetryJob(id string) error {
+	result := db.Model(&model.Job{}).
+		Where("id = ? AND status = ?", id, model.JobStatusFailed).
+		Updates(map[string]any{
+			"status":   model.JobStatusPending,
+			"attempts": 0,
+			"run_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "failed job")
+	}
+	return nil
+}
+
+// ResetRunningJobs queues jobs interrupted by a restart again
+func ResetRunningJobs() error {
+	return db.Model(&model.Job{}).
+		Where("status = ?", model.JobStatusRunning).
+		Update("status", model.JobStatusPending).Error
+}
+
+func DeleteSucceededJobs(before time.Time) error {
+	return db.
+		Where("status = ? AND updated_at < ?", model.JobStatusSucceeded, before).
+		Delete(&model.Job{}).Error
+}
@@ -0,0 +1,16 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateLiveSession(session *model.LiveSession) error {
+	return db.Create(session).Error
+}
+
+func GetLiveSessions(roomID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.LiveSession, error) {
+	var sessions []*model.LiveSession
+	err := db.Where("room_id = ?", roomID).Scopes(scopes...).Find(&sessions).Error
+	return sessions, err
+}
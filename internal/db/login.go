@@ -0,0 +1,57 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateUserLogin(l *model.UserLogin) error {
+	return db.Create(l).Error
+}
+
+func GetUserLogin(id string) (*model.UserLogin, error) {
+	l := &model.UserLogin{}
+	err := db.Where("id = ?", id).First(l).Error
+	return l, HandleNotFound(err, "login")
+}
+
+func GetUserLogins(userID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.UserLogin, error) {
+	var logins []*model.UserLogin
+	err := db.Where("user_id = ?", userID).Scopes(scopes...).Find(&logins).Error
+	return logins, err
+}
+
+func GetUserLoginsCount(userID string, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.UserLogin{}).Where("user_id = ?", userID).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+// HasUserLoginFrom reports whether the user logged in from the network or
+// with the device before
+func HasUserLoginFrom(userID, network, device string) (bool, error) {
+	var count int64
+	err := db.Model(&model.UserLogin{}).
+		Where("user_id = ? AND (network = ? OR device = ?)", userID, network, device).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeUserLogins revokes the logins of the user, all of them if ids is empty
+func RevokeUserLogins(userID string, ids ...string) (int64, error) {
+	tx := db.Model(&model.UserLogin{}).Where("user_id = ? AND revoked = ?", userID, false)
+	if len(ids) != 0 {
+		tx = tx.Where("id IN ?", ids)
+	}
+	result := tx.Update("revoked", true)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteUserLoginsBefore deletes the logins made before t, their tokens expired
+func DeleteUserLoginsBefore(t time.Time) (int64, error) {
+	result := db.Where("created_at < ?", t).Delete(&model.UserLogin{})
+	return result.RowsAffected, result.Error
+}
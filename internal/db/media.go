@@ -0,0 +1,108 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateMediaFile(file *model.MediaFile) error {
+	return db.Create(file).Error
+}
+
+func GetMediaFile(id string) (*model.MediaFile, error) {
+	file := &model.MediaFile{}
+	err := db.Where("id = ?", id).First(file).Error
+	return file, HandleNotFound(err, "media file")
+}
+
+func GetUserMediaFile(userID, id string) (*model.MediaFile, error) {
+	file := &model.MediaFile{}
+	err := db.Where("user_id = ? AND id = ?", userID, id).First(file).Error
+	return file, HandleNotFound(err, "media file")
+}
+
+func GetUserMediaFiles(userID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.MediaFile, error) {
+	var files []*model.MediaFile
+	err := db.Where("user_id = ?", userID).Scopes(scopes...).Find(&files).Error
+	return files, err
+}
+
+func GetUserMediaFilesCount(userID string, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.MediaFile{}).Where("user_id = ?", userID).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+// GetUserMediaUsage returns the bytes reserved by the files of the user
+func GetUserMediaUsage(userID string) (int64, error) {
+	var usage int64
+	err := db.Model(&model.MediaFile{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("user_id = ?", userID).
+		Scan(&usage).Error
+	return usage, err
+}
+
+func GetMediaFilesByStatus(status model.MediaFileStatus) ([]*model.MediaFile, error) {
+	var files []*model.MediaFile
+	err := db.Where("status = ?", status).Find(&files).Error
+	return files, err
+}
+
+func CountMediaFilesByChecksum(checksum string) (int64, error) {
+	var count int64
+	err := db.Model(&model.MediaFile{}).Where("checksum = ?", checksum).Count(&count).Error
+	return count, err
+}
+
+func UpdateMediaFileUploaded(id string, uploaded int64) error {
+	return db.Model(&model.MediaFile{}).Where("id = ?", id).Update("uploaded", uploaded).Error
+}
+
+func UpdateMediaFileStatus(id string, status model.MediaFileStatus) error {
+	return db.Model(&model.MediaFile{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func SetMediaFileReady(id, checksum string) error {
+	return db.Model(&model.MediaFile{}).Where("id = ?", id).Updates(map[string]any{
+		"checksum": checksum,
+		"status":   model.MediaFileStatusReady,
+	}).Error
+}
+
+func DeleteMediaFile(userID, id string) error {
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&model.MediaFile{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "media file")
+	}
+	return nil
+}
+
+func WhereMediaFileStatus(status model.MediaFileStatus) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", status)
+	}
+}
+
+// GetStaleMediaFiles returns the files in one of the statuses not updated since before
+func GetStaleMediaFiles(before time.Time, status ...model.MediaFileStatus) ([]*model.MediaFile, error) {
+	var files []*model.MediaFile
+	err := db.Where("status IN ? AND updated_at < ?", status, before).Find(&files).Error
+	return files, err
+}
+
+func DeleteMediaFileByID(id string) error {
+	result := db.Where("id = ?", id).Delete(&model.MediaFile{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "media file")
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package db
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/synctv-org/synctv/internal/model"
 	"gorm.io/gorm"
@@ -129,6 +131,9 @@ func RoomSetAdmin(roomID, userID string, permissions model.RoomAdminPermission)
 		"role":              model.RoomMemberRoleAdmin,
 		"permissions":       model.AllPermissions,
 		"admin_permissions": permissions,
+		"trial":             false,
+		"trial_expire_at":   nil,
+		"trial_actions":     0,
 	}).Error
 }
 
@@ -137,5 +142,79 @@ func RoomSetMember(roomID, userID string, permissions model.RoomMemberPermission
 		"role":              model.RoomMemberRoleMember,
 		"permissions":       permissions,
 		"admin_permissions": model.NoAdminPermission,
+		"trial":             false,
+		"trial_expire_at":   nil,
+		"trial_actions":     0,
 	}).Error
 }
+
+// RoomSetTrialAdmin makes the member an admin until expireAt or actions admin actions,
+// then it reverts to a member with revertPermissions
+func RoomSetTrialAdmin(roomID, userID string, permissions model.RoomAdminPermission, expireAt *time.Time, actions int64, revertPermissions model.RoomMemberPermission) error {
+	result := db.Model(&model.RoomMember{}).Where("room_id = ? AND user_id = ?", roomID, userID).Updates(map[string]interface{}{
+		"role":              model.RoomMemberRoleAdmin,
+		"permissions":       model.AllPermissions,
+		"admin_permissions": permissions,
+		"trial":             true,
+		"trial_expire_at":   expireAt,
+		"trial_actions":     actions,
+		"trial_permissions": revertPermissions,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "room member")
+	}
+	return nil
+}
+
+// RoomRevertTrialAdmin reverts a trial admin to a member,
+// it reports false if the member is not a trial admin
+func RoomRevertTrialAdmin(roomID, userID string) (bool, error) {
+	var reverted bool
+	err := Transactional(func(tx *gorm.DB) error {
+		member := &model.RoomMember{}
+		err := tx.Where("room_id = ? AND user_id = ? AND trial = ?", roomID, userID, true).First(member).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		reverted = true
+		return tx.Model(&model.RoomMember{}).Where("room_id = ? AND user_id = ?", roomID, userID).Updates(map[string]interface{}{
+			"role":              model.RoomMemberRoleMember,
+			"permissions":       member.TrialPermissions,
+			"admin_permissions": model.NoAdminPermission,
+			"trial":             false,
+			"trial_expire_at":   nil,
+			"trial_actions":     0,
+		}).Error
+	})
+	return reverted, err
+}
+
+// ConsumeTrialAdminAction uses up one action of a trial admin and returns the actions left
+func ConsumeTrialAdminAction(roomID, userID string) (int64, error) {
+	var left int64
+	err := Transactional(func(tx *gorm.DB) error {
+		err := tx.Model(&model.RoomMember{}).
+			Where("room_id = ? AND user_id = ? AND trial = ? AND trial_actions > 0", roomID, userID, true).
+			Update("trial_actions", gorm.Expr("trial_actions - 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.RoomMember{}).
+			Select("trial_actions").
+			Where("room_id = ? AND user_id = ?", roomID, userID).
+			Scan(&left).Error
+	})
+	return left, err
+}
+
+func GetExpiredTrialAdmins(now time.Time) ([]*model.RoomMember, error) {
+	var members []*model.RoomMember
+	err := db.Where("trial = ? AND trial_expire_at <= ?", true, now).Find(&members).Error
+	return members, err
+}
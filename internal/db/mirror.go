@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetRoomMirror(roomID string) (*model.RoomMirror, error) {
+	mirror := &model.RoomMirror{}
+	err := db.Where("room_id = ?", roomID).First(mirror).Error
+	return mirror, HandleNotFound(err, "room mirror")
+}
+
+func GetEnabledRoomMirrors() ([]*model.RoomMirror, error) {
+	var mirrors []*model.RoomMirror
+	err := db.Where("enabled = ?", true).Find(&mirrors).Error
+	return mirrors, err
+}
+
+func SaveRoomMirror(mirror *model.RoomMirror) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(mirror).Error
+}
+
+func DeleteRoomMirror(roomID string) error {
+	result := db.Where("room_id = ?", roomID).Delete(&model.RoomMirror{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "room mirror")
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package db
 
 import (
+	"errors"
+
+	"github.com/synctv-org/synctv/internal/conf"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/utils"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -25,9 +29,37 @@ func WithParentMovieID(parentMovieID string) func(*gorm.DB) *gorm.DB {
 	}
 }
 
+func WithMovieIDs(ids []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id IN ?", ids)
+	}
+}
+
 func GetMoviesByRoomID(roomID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.Movie, error) {
 	movies := []*model.Movie{}
-	err := db.Where("room_id = ?", roomID).Order("position ASC").Scopes(scopes...).Find(&movies).Error
+	// scopes are applied before the order by position, so they can sort
+	err := db.Where("room_id = ?", roomID).Scopes(append(scopes, OrderByAsc("position"))...).Find(&movies).Error
+	return movies, err
+}
+
+func WhereMovieNameLike(name string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch dbType {
+		case conf.DatabaseTypePostgres:
+			return db.Where("base_name ILIKE ?", utils.LIKE(name))
+		default:
+			return db.Where("base_name LIKE ?", utils.LIKE(name))
+		}
+	}
+}
+
+// GetRecentMoviesByRoomID returns the latest added movies, folders are excluded
+func GetRecentMoviesByRoomID(roomID string, limit int) ([]*model.Movie, error) {
+	movies := []*model.Movie{}
+	err := db.Where("room_id = ? AND base_is_folder = ?", roomID, false).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&movies).Error
 	return movies, err
 }
 
@@ -97,6 +129,43 @@ func SaveMovie(movie *model.Movie, columns ...clause.Column) error {
 	return HandleNotFound(err, "room or movie")
 }
 
+var ErrMovieVersionConflict = errors.New("movie was changed by someone else")
+
+// SaveMovieIfVersion saves the movie if the stored movie is still at version,
+// and bumps the version. The position is left alone, it is changed by swaps
+func SaveMovieIfVersion(movie *model.Movie, version uint64) error {
+	movie.Version = version + 1
+	result := db.Model(movie).
+		Where("room_id = ? AND version = ?", movie.RoomID, version).
+		Select("*").
+		Omit("created_at", "position").
+		Updates(movie)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		movie.Version = version
+		return ErrMovieVersionConflict
+	}
+	return nil
+}
+
+// UpdateMovieExtensions changes the extensions of the movie with fn in a transaction
+func UpdateMovieExtensions(roomID, id string, fn func(*model.MovieExtensions) error) (*model.Movie, error) {
+	movie := &model.Movie{}
+	err := Transactional(func(tx *gorm.DB) error {
+		err := tx.Where("room_id = ? AND id = ?", roomID, id).First(movie).Error
+		if err != nil {
+			return HandleNotFound(err, "room or movie")
+		}
+		if err := fn(&movie.Extensions); err != nil {
+			return err
+		}
+		return tx.Model(movie).Select("base_extensions").Updates(movie).Error
+	})
+	return movie, err
+}
+
 func SwapMoviePositions(roomID, movie1ID, movie2ID string) (err error) {
 	return Transactional(func(tx *gorm.DB) error {
 		movie1 := &model.Movie{}
@@ -117,3 +186,99 @@ func SwapMoviePositions(roomID, movie1ID, movie2ID string) (err error) {
 		return tx.Omit("created_at").Save(movie2).Error
 	})
 }
+
+func CreateMovieUnlock(movieID, userID string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.MovieUnlock{
+		MovieID: movieID,
+		UserID:  userID,
+	}).Error
+}
+
+func IsMovieUnlocked(movieID, userID string) (bool, error) {
+	var count int64
+	err := db.Model(&model.MovieUnlock{}).Where("movie_id = ? AND user_id = ?", movieID, userID).Count(&count).Error
+	return count != 0, err
+}
+
+// DeleteMovieUnlocks forgets who entered the password of a movie
+func DeleteMovieUnlocks(movieID string) error {
+	return db.Where("movie_id = ?", movieID).Delete(&model.MovieUnlock{}).Error
+}
+
+// GetMovieTree returns the movie followed by its descendants, parents come
+// before their children
+func GetMovieTree(roomID, id string) ([]*model.Movie, error) {
+	movie := &model.Movie{}
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(movie).Error
+	if err != nil {
+		return nil, HandleNotFound(err, "room or movie")
+	}
+	if !movie.IsFolder {
+		return []*model.Movie{movie}, nil
+	}
+	children, err := movieDescendants(db, roomID, []string{movie.ID})
+	if err != nil {
+		return nil, err
+	}
+	return append([]*model.Movie{movie}, children...), nil
+}
+
+// GetRoomMovieTree returns all movies of the room, parents come before their
+// children
+func GetRoomMovieTree(roomID string) ([]*model.Movie, error) {
+	movies, err := GetMoviesByRoomID(roomID, WithParentMovieID(""))
+	if err != nil {
+		return nil, err
+	}
+	var folders []string
+	for _, m := range movies {
+		if m.IsFolder {
+			folders = append(folders, m.ID)
+		}
+	}
+	children, err := movieDescendants(db, roomID, folders)
+	if err != nil {
+		return nil, err
+	}
+	return append(movies, children...), nil
+}
+
+// CreateMovieTree creates the movies in order, parents must come before
+// their children
+func CreateMovieTree(movies []*model.Movie) error {
+	return Transactional(func(tx *gorm.DB) error {
+		for _, m := range movies {
+			if err := tx.Create(m).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MoveMovieTree moves the movie with its descendants to another room, under
+// the folder parentID of that room
+func MoveMovieTree(fromRoomID, toRoomID, id, parentID string, position uint, descendants []string) error {
+	return Transactional(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Movie{}).
+			Where("room_id = ? AND id = ?", fromRoomID, id).
+			Updates(map[string]any{
+				"room_id":        toRoomID,
+				"base_parent_id": model.EmptyNullString(parentID),
+				"position":       position,
+				"version":        gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return HandleNotFound(gorm.ErrRecordNotFound, "room or movie")
+		}
+		if len(descendants) == 0 {
+			return nil
+		}
+		return tx.Model(&model.Movie{}).
+			Where("room_id = ? AND id IN ?", fromRoomID, descendants).
+			Update("room_id", toRoomID).Error
+	})
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// movieDescendants returns the movies in the folders, and in their folders
+func movieDescendants(tx *gorm.DB, roomID string, folders []string) ([]*model.Movie, error) {
+	var all []*model.Movie
+	for len(folders) != 0 {
+		var children []*model.Movie
+		err := tx.Where("room_id = ? AND base_parent_id IN ?", roomID, folders).Find(&children).Error
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, children...)
+		folders = folders[:0]
+		for _, c := range children {
+			if c.IsFolder {
+				folders = append(folders, c.ID)
+			}
+		}
+	}
+	return all, nil
+}
+
+// TrashMovies moves the movies of the room matched by the scopes, with the
+// descendants of folders, to the trash as one batch and returns the batch id.
+// An empty batch id means no movie matched
+func TrashMovies(roomID, trashedBy string, keep time.Duration, scopes ...func(*gorm.DB) *gorm.DB) (string, error) {
+	batchID := utils.SortUUID()
+	err := Transactional(func(tx *gorm.DB) error {
+		var movies []*model.Movie
+		err := tx.Where("room_id = ?", roomID).Scopes(scopes...).Find(&movies).Error
+		if err != nil {
+			return err
+		}
+		if len(movies) == 0 {
+			batchID = ""
+			return nil
+		}
+		var folders []string
+		for _, m := range movies {
+			if m.IsFolder {
+				folders = append(folders, m.ID)
+			}
+		}
+		children, err := movieDescendants(tx, roomID, folders)
+		if err != nil {
+			return err
+		}
+		movies = append(movies, children...)
+
+		now := time.Now()
+		trashed := make([]*model.TrashedMovie, len(movies))
+		ids := make([]string, len(movies))
+		for i, m := range movies {
+			trashed[i] = model.NewTrashedMovie(m, batchID, trashedBy, now, now.Add(keep))
+			ids[i] = m.ID
+		}
+		err = tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(trashed, 100).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("room_id = ? AND id IN ?", roomID, ids).Delete(&model.Movie{}).Error
+	})
+	return batchID, err
+}
+
+func WithTrashBatchID(batchID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("batch_id = ?", batchID)
+	}
+}
+
+func GetTrashedMoviesByRoomID(roomID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.TrashedMovie, error) {
+	var movies []*model.TrashedMovie
+	err := db.Where("room_id = ? AND expires_at > ?", roomID, time.Now()).
+		Scopes(scopes...).
+		Order("trashed_at DESC").
+		Order("position").
+		Find(&movies).Error
+	return movies, err
+}
+
+// RestoreTrashedMovies moves a batch out of the trash back to the movies of
+// the room. Movies whose folder no longer exists are restored to the top
+// level
+func RestoreTrashedMovies(roomID, batchID string) ([]*model.Movie, error) {
+	var restored []*model.Movie
+	err := Transactional(func(tx *gorm.DB) error {
+		var trashed []*model.TrashedMovie
+		err := tx.Where("room_id = ? AND batch_id = ? AND expires_at > ?", roomID, batchID, time.Now()).
+			Find(&trashed).Error
+		if err != nil {
+			return err
+		}
+		if len(trashed) == 0 {
+			return HandleNotFound(gorm.ErrRecordNotFound, "trashed movies")
+		}
+
+		inBatch := make(map[model.EmptyNullString]bool, len(trashed))
+		for _, t := range trashed {
+			inBatch[model.EmptyNullString(t.ID)] = true
+		}
+		// parents are created before their children, the parent of a
+		// movie is checked when it is saved
+		level := make([]*model.Movie, 0, len(trashed))
+		pending := make(map[model.EmptyNullString][]*model.Movie)
+		for _, t := range trashed {
+			m := t.Movie()
+			switch {
+			case m.ParentID == "":
+				level = append(level, m)
+			case inBatch[m.ParentID]:
+				pending[m.ParentID] = append(pending[m.ParentID], m)
+			default:
+				var count int64
+				err := tx.Model(&model.Movie{}).Where("room_id = ? AND id = ?", roomID, m.ParentID).Count(&count).Error
+				if err != nil {
+					return err
+				}
+				if count == 0 {
+					m.ParentID = ""
+				}
+				level = append(level, m)
+			}
+		}
+		for len(level) != 0 {
+			if err := tx.Create(level).Error; err != nil {
+				return err
+			}
+			restored = append(restored, level...)
+			var next []*model.Movie
+			for _, m := range level {
+				next = append(next, pending[model.EmptyNullString(m.ID)]...)
+			}
+			level = next
+		}
+		return tx.Where("room_id = ? AND batch_id = ?", roomID, batchID).Delete(&model.TrashedMovie{}).Error
+	})
+	return restored, err
+}
+
+func DeleteTrashedMovies(roomID, batchID string) error {
+	result := db.Where("room_id = ? AND batch_id = ?", roomID, batchID).Delete(&model.TrashedMovie{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "trashed movies")
+	}
+	return nil
+}
+
+func DeleteExpiredTrashedMovies() (int64, error) {
+	result := db.Where("expires_at <= ?", time.Now()).Delete(&model.TrashedMovie{})
+	return result.RowsAffected, result.Error
+}
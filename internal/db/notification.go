@@ -0,0 +1,75 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateNotificationSubscription(s *model.NotificationSubscription) error {
+	return db.Create(s).Error
+}
+
+func GetNotificationSubscriptions(userID string) ([]*model.NotificationSubscription, error) {
+	var subs []*model.NotificationSubscription
+	err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&subs).Error
+	return subs, err
+}
+
+func DeleteNotificationSubscription(userID, id string) error {
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&model.NotificationSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "subscription")
+	}
+	return nil
+}
+
+func DeleteNotificationSubscriptionByID(id string) error {
+	return db.Where("id = ?", id).Delete(&model.NotificationSubscription{}).Error
+}
+
+func GetOrCreateNotificationPreference(userID string) (*model.NotificationPreference, error) {
+	p := model.DefaultNotificationPreference()
+	p.UserID = userID
+	err := db.Where(model.NotificationPreference{UserID: userID}).Attrs(p).FirstOrCreate(p).Error
+	return p, err
+}
+
+func UpdateNotificationPreference(userID string, preference map[string]any) (*model.NotificationPreference, error) {
+	if _, err := GetOrCreateNotificationPreference(userID); err != nil {
+		return nil, err
+	}
+	p := &model.NotificationPreference{
+		UserID: userID,
+	}
+	err := db.Model(p).
+		Clauses(clause.Returning{}).
+		Updates(preference).Error
+	return p, HandleNotFound(err, "notification preference")
+}
+
+func FollowRoom(userID, roomID string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.RoomFollow{
+		UserID: userID,
+		RoomID: roomID,
+	}).Error
+}
+
+func UnfollowRoom(userID, roomID string) error {
+	return db.Where("user_id = ? AND room_id = ?", userID, roomID).Delete(&model.RoomFollow{}).Error
+}
+
+func IsFollowingRoom(userID, roomID string) (bool, error) {
+	var count int64
+	err := db.Model(&model.RoomFollow{}).Where("user_id = ? AND room_id = ?", userID, roomID).Count(&count).Error
+	return count != 0, err
+}
+
+func GetRoomFollowerIDs(roomID string) ([]string, error) {
+	var ids []string
+	err := db.Model(&model.RoomFollow{}).Where("room_id = ?", roomID).Pluck("user_id", &ids).Error
+	return ids, err
+}
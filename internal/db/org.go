@@ -0,0 +1,246 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateOrganization(o *model.Organization) error {
+	err := db.Create(o).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return errors.New("organization already exists")
+	}
+	return err
+}
+
+func GetOrganizationByID(id string) (*model.Organization, error) {
+	o := &model.Organization{}
+	err := db.Where("id = ?", id).First(o).Error
+	return o, HandleNotFound(err, "organization")
+}
+
+func GetOrganizationByName(name string) (*model.Organization, error) {
+	o := &model.Organization{}
+	err := db.Where("name = ?", name).First(o).Error
+	return o, HandleNotFound(err, "organization")
+}
+
+func GetOrganizationByInviteCode(code string) (*model.Organization, error) {
+	if code == "" {
+		return nil, ErrNotFound("organization")
+	}
+	o := &model.Organization{}
+	err := db.Where("invite_code = ?", code).First(o).Error
+	return o, HandleNotFound(err, "organization")
+}
+
+func GetOrganizations(scopes ...func(*gorm.DB) *gorm.DB) ([]*model.Organization, error) {
+	var orgs []*model.Organization
+	err := db.Scopes(scopes...).Find(&orgs).Error
+	return orgs, err
+}
+
+func GetOrganizationsCount(scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.Organization{}).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+// GetUserOrganizations gets the organizations the user is a member of
+func GetUserOrganizations(userID string) ([]*model.Organization, error) {
+	var orgs []*model.Organization
+	err := db.
+		Where("id IN (?)", db.Model(&model.OrganizationMember{}).Select("org_id").Where("user_id = ?", userID)).
+		Order("name").
+		Find(&orgs).Error
+	return orgs, err
+}
+
+func UpdateOrganization(id string, columns map[string]any) (*model.Organization, error) {
+	o := &model.Organization{ID: id}
+	err := db.Model(o).
+		Clauses(clause.Returning{}).
+		Updates(columns).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, errors.New("organization already exists")
+	}
+	return o, HandleNotFound(err, "organization")
+}
+
+// ResetOrganizationInviteCode makes invites with the old code invalid
+func ResetOrganizationInviteCode(id string) (string, error) {
+	code := utils.SortUUID()
+	result := db.Model(&model.Organization{ID: id}).Update("invite_code", code)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", ErrNotFound("organization")
+	}
+	return code, nil
+}
+
+func DeleteOrganizationByID(id string) error {
+	err := db.Unscoped().Select(clause.Associations).Delete(&model.Organization{ID: id}).Error
+	return HandleNotFound(err, "organization")
+}
+
+// GetOrganizationRoomIDs gets the ids of all rooms of the organization
+func GetOrganizationRoomIDs(orgID string) ([]string, error) {
+	var ids []string
+	err := db.Model(&model.Room{}).Where("org_id = ?", orgID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// AddOrganizationMember adds the user to the organization, the member quota
+// of the organization is checked, an existing member keeps its role
+func AddOrganizationMember(orgID, userID string, role model.OrgRole) (*model.OrganizationMember, error) {
+	m := &model.OrganizationMember{
+		OrgID:  orgID,
+		UserID: userID,
+		Role:   role,
+	}
+	return m, Transactional(func(tx *gorm.DB) error {
+		err := tx.Where("org_id = ? AND user_id = ?", orgID, userID).First(m).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		org := &model.Organization{}
+		err = tx.Select("max_members").Where("id = ?", orgID).First(org).Error
+		if err != nil {
+			return HandleNotFound(err, "organization")
+		}
+		if org.MaxMembers != 0 {
+			var count int64
+			tx.Model(&model.OrganizationMember{}).Where("org_id = ?", orgID).Count(&count)
+			if count >= org.MaxMembers {
+				return errors.New("organization member count is over limit")
+			}
+		}
+		return tx.Create(m).Error
+	})
+}
+
+func GetOrganizationMember(orgID, userID string) (*model.OrganizationMember, error) {
+	m := &model.OrganizationMember{}
+	err := db.Where("org_id = ? AND user_id = ?", orgID, userID).First(m).Error
+	return m, HandleNotFound(err, "organization member")
+}
+
+func GetOrganizationMembers(orgID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.OrganizationMember, error) {
+	var members []*model.OrganizationMember
+	err := db.Where("org_id = ?", orgID).Scopes(scopes...).Find(&members).Error
+	return members, err
+}
+
+func GetOrganizationMembersCount(orgID string, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&model.OrganizationMember{}).Where("org_id = ?", orgID).Scopes(scopes...).Count(&count).Error
+	return count, err
+}
+
+func SetOrganizationMemberRole(orgID, userID string, role model.OrgRole) error {
+	result := db.Model(&model.OrganizationMember{}).
+		Where("org_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound("organization member")
+	}
+	return nil
+}
+
+func DeleteOrganizationMember(orgID, userID string) error {
+	result := db.Where("org_id = ? AND user_id = ?", orgID, userID).Delete(&model.OrganizationMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound("organization member")
+	}
+	return nil
+}
+
+// GetOrgProvider gets the identity provider of the organization, a new
+// disabled one if it has none
+func GetOrgProvider(orgID string) (*model.OrgProvider, error) {
+	p := &model.OrgProvider{}
+	err := db.Where("org_id = ?", orgID).First(p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.NewOrgProvider(orgID), nil
+	}
+	return p, err
+}
+
+func SaveOrgProvider(p *model.OrgProvider) error {
+	return db.Save(p).Error
+}
+
+// moveOrgProviderSettings moves the identity providers of organizations
+// kept as settings groups into their own table
+func moveOrgProviderSettings(d *gorm.DB) error {
+	prefix := string(model.SettingGroupOauth2) + "_org_"
+	var items []*model.Setting
+	err := d.Where(clause.Like{Column: clause.Column{Name: "group"}, Value: prefix + "%"}).Find(&items).Error
+	if err != nil {
+		return err
+	}
+	providers := make(map[string]*model.OrgProvider)
+	for _, item := range items {
+		orgID := strings.TrimPrefix(string(item.Group), prefix)
+		p, ok := providers[orgID]
+		if !ok {
+			p = model.NewOrgProvider(orgID)
+			providers[orgID] = p
+		}
+		switch strings.TrimPrefix(item.Name, string(item.Group)+"_") {
+		case "enabled":
+			p.Enabled, _ = strconv.ParseBool(item.Value)
+		case "restrict_room_login":
+			p.RestrictRoomLogin, _ = strconv.ParseBool(item.Value)
+		case "client_id":
+			p.ClientID = item.Value
+		case "client_secret":
+			p.ClientSecret = item.Value
+		case "redirect_url":
+			p.RedirectURL = item.Value
+		case "auth_url":
+			p.AuthURL = item.Value
+		case "token_url":
+			p.TokenURL = item.Value
+		case "userinfo_url":
+			p.UserInfoURL = item.Value
+		case "scopes":
+			p.Scopes = item.Value
+		case "id_field":
+			p.IDField = item.Value
+		case "username_field":
+			p.UsernameField = item.Value
+		}
+	}
+	return d.Transaction(func(tx *gorm.DB) error {
+		for _, p := range providers {
+			if err := tx.Where("id = ?", p.OrgID).First(&model.Organization{}).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+			if err := tx.Save(p).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where(clause.Like{Column: clause.Column{Name: "group"}, Value: prefix + "%"}).Delete(&model.Setting{}).Error
+	})
+}
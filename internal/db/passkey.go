@@ -0,0 +1,82 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+// MaxUserPasskeys is the number of passkeys a user can register
+const MaxUserPasskeys = 20
+
+// PasskeyCredentialHash is the key passkeys are looked up by
+func PasskeyCredentialHash(credentialID []byte) string {
+	h := sha256.Sum256(credentialID)
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+func CreateUserPasskey(passkey *model.UserPasskey) error {
+	passkey.CredentialHash = PasskeyCredentialHash(passkey.CredentialID)
+	return Transactional(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.UserPasskey{}).Where("user_id = ?", passkey.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxUserPasskeys {
+			return errors.New("passkey count is over limit")
+		}
+		var exists int64
+		if err := tx.Model(&model.UserPasskey{}).Where("credential_hash = ?", passkey.CredentialHash).Count(&exists).Error; err != nil {
+			return err
+		}
+		if exists != 0 {
+			return errors.New("passkey already registered")
+		}
+		return tx.Create(passkey).Error
+	})
+}
+
+func GetUserPasskeys(userID string) ([]*model.UserPasskey, error) {
+	var passkeys []*model.UserPasskey
+	err := db.Where("user_id = ?", userID).Order("created_at").Find(&passkeys).Error
+	return passkeys, err
+}
+
+func GetPasskeyByCredentialID(credentialID []byte) (*model.UserPasskey, error) {
+	passkey := &model.UserPasskey{}
+	err := db.Where("credential_hash = ?", PasskeyCredentialHash(credentialID)).First(passkey).Error
+	return passkey, HandleNotFound(err, "passkey")
+}
+
+// UsePasskey records a sign in with the passkey, the signature counter only
+// moves forward so concurrent sign ins can not roll it back
+func UsePasskey(id string, signCount uint32) error {
+	result := db.Model(&model.UserPasskey{}).
+		Where("id = ? AND (sign_count < ? OR sign_count = 0)", id, signCount).
+		Updates(map[string]any{
+			"sign_count":   signCount,
+			"last_used_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("passkey was used concurrently")
+	}
+	return nil
+}
+
+func DeleteUserPasskey(userID, id string) error {
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&model.UserPasskey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "passkey")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateMoviePoll(poll *model.MoviePoll) error {
+	return db.Create(poll).Error
+}
+
+func GetMoviePoll(roomID, id string) (*model.MoviePoll, error) {
+	poll := &model.MoviePoll{}
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(poll).Error
+	return poll, HandleNotFound(err, "poll")
+}
+
+// GetMoviePolls returns the polls of the room, newest first
+func GetMoviePolls(roomID string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.MoviePoll, error) {
+	var polls []*model.MoviePoll
+	err := db.Where("room_id = ?", roomID).Order("created_at DESC").Scopes(scopes...).Find(&polls).Error
+	return polls, err
+}
+
+// GetDueMoviePolls returns the polls that are not closed but past their close time
+func GetDueMoviePolls(now time.Time) ([]*model.MoviePoll, error) {
+	var polls []*model.MoviePoll
+	err := db.Where("closed = ? AND close_at <= ?", false, now).Find(&polls).Error
+	return polls, err
+}
+
+// CloseMoviePoll reports whether the poll was closed by this call
+func CloseMoviePoll(id, winnerID string) (bool, error) {
+	result := db.Model(&model.MoviePoll{}).
+		Where("id = ? AND closed = ?", id, false).
+		Updates(map[string]any{
+			"closed":    true,
+			"winner_id": winnerID,
+		})
+	return result.RowsAffected != 0, result.Error
+}
+
+func DeleteMoviePoll(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.MoviePoll{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "poll")
+	}
+	return nil
+}
+
+func SaveMoviePollBallot(ballot *model.MoviePollBallot) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "poll_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"ranking", "updated_at"}),
+	}).Create(ballot).Error
+}
+
+func GetMoviePollBallots(pollID string) ([]*model.MoviePollBallot, error) {
+	var ballots []*model.MoviePollBallot
+	err := db.Where("poll_id = ?", pollID).Find(&ballots).Error
+	return ballots, err
+}
+
+func GetMoviePollBallot(pollID, userID string) (*model.MoviePollBallot, error) {
+	ballot := &model.MoviePollBallot{}
+	err := db.Where("poll_id = ? AND user_id = ?", pollID, userID).First(ballot).Error
+	return ballot, HandleNotFound(err, "ballot")
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+func GetOrCreatePresencePreference(userID string) (*model.PresencePreference, error) {
+	p := model.DefaultPresencePreference()
+	p.UserID = userID
+	err := db.Where(model.PresencePreference{UserID: userID}).Attrs(p).FirstOrCreate(p).Error
+	return p, err
+}
+
+func UpdatePresencePreference(userID string, preference map[string]any) (*model.PresencePreference, error) {
+	if _, err := GetOrCreatePresencePreference(userID); err != nil {
+		return nil, err
+	}
+	p := &model.PresencePreference{
+		UserID: userID,
+	}
+	err := db.Model(p).
+		Clauses(clause.Returning{}).
+		Updates(preference).Error
+	return p, HandleNotFound(err, "presence preference")
+}
@@ -54,6 +54,14 @@ func WithSettingHidden(hidden bool) CreateRoomConfig {
 	}
 }
 
+// WithOrg creates the room in the namespace of the organization, the
+// room quota of the organization is checked
+func WithOrg(orgID string) CreateRoomConfig {
+	return func(r *model.Room) {
+		r.OrgID = orgID
+	}
+}
+
 // if maxCount is 0, it will be ignored
 func CreateRoom(name, password string, maxCount int64, conf ...CreateRoomConfig) (*model.Room, error) {
 	r := &model.Room{
@@ -79,6 +87,20 @@ func CreateRoom(name, password string, maxCount int64, conf ...CreateRoomConfig)
 				return errors.New("room count is over limit")
 			}
 		}
+		if r.OrgID != "" {
+			org := &model.Organization{}
+			err := tx.Select("max_rooms").Where("id = ?", r.OrgID).First(org).Error
+			if err != nil {
+				return HandleNotFound(err, "organization")
+			}
+			if org.MaxRooms != 0 {
+				var count int64
+				tx.Model(&model.Room{}).Where("org_id = ?", r.OrgID).Count(&count)
+				if count >= org.MaxRooms {
+					return errors.New("organization room count is over limit")
+				}
+			}
+		}
 		err := tx.Create(r).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrDuplicatedKey) {
@@ -101,6 +123,16 @@ func GetRoomByID(id string) (*model.Room, error) {
 	return r, HandleNotFound(err, "room")
 }
 
+// GetRoomByName gets the room by its name in the namespace of the
+// organization, orgID is empty for the namespace of the server
+func GetRoomByName(orgID, name string) (*model.Room, error) {
+	r := &model.Room{}
+	err := db.
+		Where("org_id = ? AND name = ?", orgID, name).
+		First(r).Error
+	return r, HandleNotFound(err, "room")
+}
+
 func GetOrCreateRoomSettings(roomID string) (*model.RoomSettings, error) {
 	rs := &model.RoomSettings{}
 	err := db.Where(&model.RoomSettings{ID: roomID}).Attrs(model.DefaultRoomSettings()).FirstOrCreate(rs).Error
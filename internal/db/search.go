@@ -0,0 +1,275 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchIndex is the full text search of a database type over room names,
+// descriptions and tags and movie names
+type searchIndex interface {
+	// init creates the index if there is none and fills it with the stored
+	// rooms and movies, the index is kept up to date by the database
+	init(*gorm.DB) error
+	// matchRooms filters rooms joined with their settings by the query and
+	// orders them by rank
+	matchRooms(query string) func(*gorm.DB) *gorm.DB
+	// matchMovies filters movies by the query and orders them by rank
+	matchMovies(query string) func(*gorm.DB) *gorm.DB
+}
+
+// sqlite builds without fts5, like cgo builds without the sqlite_fts5 tag,
+// scan the tables instead
+var fts5Missing bool
+
+func getSearchIndex() searchIndex {
+	switch dbType {
+	case conf.DatabaseTypeSqlite3:
+		if fts5Missing {
+			return likeIndex{}
+		}
+		return fts5Index{}
+	case conf.DatabaseTypePostgres:
+		return tsvectorIndex{}
+	default:
+		return likeIndex{}
+	}
+}
+
+func initSearchIndex() error {
+	err := getSearchIndex().init(db)
+	if err != nil && dbType == conf.DatabaseTypeSqlite3 && strings.Contains(err.Error(), "no such module: fts5") {
+		log.Warn("sqlite is built without fts5, search scans the tables")
+		fts5Missing = true
+		return nil
+	}
+	return err
+}
+
+// searchableRooms are the active rooms listed in the organization, empty is
+// the server
+func searchableRooms(orgID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			InnerJoins("JOIN room_settings ON rooms.id = room_settings.id").
+			Where("rooms.status = ? AND room_settings.hidden = ? AND rooms.org_id = ?", model.RoomStatusActive, false, orgID)
+	}
+}
+
+func SearchRooms(query, orgID string, page, pageSize int) ([]*model.Room, int64, error) {
+	scopes := []func(*gorm.DB) *gorm.DB{
+		searchableRooms(orgID),
+		getSearchIndex().matchRooms(query),
+	}
+	var total int64
+	if err := db.Model(&model.Room{}).Scopes(scopes...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rooms []*model.Room
+	err := db.Select("rooms.*").
+		Preload("Settings").
+		Scopes(append(scopes, Paginate(page, pageSize))...).
+		Find(&rooms).Error
+	return rooms, total, err
+}
+
+type MovieSearchResult struct {
+	ID       string
+	Name     string
+	RoomID   string
+	RoomName string
+}
+
+// SearchMovies searches the playlists of searchable rooms anyone can see the
+// playlist of, rooms with a password are left out
+func SearchMovies(query, orgID string, page, pageSize int) ([]*MovieSearchResult, int64, error) {
+	scopes := []func(*gorm.DB) *gorm.DB{
+		func(db *gorm.DB) *gorm.DB {
+			return db.InnerJoins("JOIN rooms ON rooms.id = movies.room_id")
+		},
+		searchableRooms(orgID),
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("room_settings.can_get_movie_list = ? AND (rooms.hashed_password IS NULL OR length(rooms.hashed_password) = 0)", true)
+		},
+		getSearchIndex().matchMovies(query),
+	}
+	var total int64
+	if err := db.Model(&model.Movie{}).Scopes(scopes...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var movies []*MovieSearchResult
+	err := db.Model(&model.Movie{}).
+		Select("movies.id AS id, movies.base_name AS name, rooms.id AS room_id, rooms.name AS room_name").
+		Scopes(append(scopes, Paginate(page, pageSize))...).
+		Scan(&movies).Error
+	return movies, total, err
+}
+
+// fts5Index keeps fts5 tables sharing the rowids of rooms and movies up to
+// date with triggers
+type fts5Index struct{}
+
+var fts5Statements = []string{
+	`CREATE VIRTUAL TABLE room_search USING fts5(name, description, tags, tokenize = 'unicode61 remove_diacritics 2')`,
+	`CREATE VIRTUAL TABLE movie_search USING fts5(name, tokenize = 'unicode61 remove_diacritics 2')`,
+	`INSERT INTO room_search(rowid, name, description, tags)
+		SELECT rooms.rowid, rooms.name, COALESCE(room_settings.description, ''), COALESCE(room_settings.tags, '')
+		FROM rooms LEFT JOIN room_settings ON room_settings.id = rooms.id`,
+	`INSERT INTO movie_search(rowid, name) SELECT rowid, base_name FROM movies`,
+	`CREATE TRIGGER room_search_insert AFTER INSERT ON rooms BEGIN
+		INSERT INTO room_search(rowid, name) VALUES (new.rowid, new.name);
+	END`,
+	`CREATE TRIGGER room_search_update AFTER UPDATE OF name ON rooms BEGIN
+		UPDATE room_search SET name = new.name WHERE rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER room_search_delete AFTER DELETE ON rooms BEGIN
+		DELETE FROM room_search WHERE rowid = old.rowid;
+	END`,
+	`CREATE TRIGGER room_settings_search_insert AFTER INSERT ON room_settings BEGIN
+		UPDATE room_search SET description = new.description, tags = new.tags
+		WHERE rowid = (SELECT rowid FROM rooms WHERE id = new.id);
+	END`,
+	`CREATE TRIGGER room_settings_search_update AFTER UPDATE OF description, tags ON room_settings BEGIN
+		UPDATE room_search SET description = new.description, tags = new.tags
+		WHERE rowid = (SELECT rowid FROM rooms WHERE id = new.id);
+	END`,
+	`CREATE TRIGGER movie_search_insert AFTER INSERT ON movies BEGIN
+		INSERT INTO movie_search(rowid, name) VALUES (new.rowid, new.base_name);
+	END`,
+	`CREATE TRIGGER movie_search_update AFTER UPDATE OF base_name ON movies BEGIN
+		UPDATE movie_search SET name = new.base_name WHERE rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER movie_search_delete AFTER DELETE ON movies BEGIN
+		DELETE FROM movie_search WHERE rowid = old.rowid;
+	END`,
+}
+
+func (fts5Index) init(d *gorm.DB) error {
+	if d.Migrator().HasTable("room_search") {
+		return nil
+	}
+	log.Info("building search index...")
+	return d.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range fts5Statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// fts5Query turns the words of the query into prefix matches of quoted
+// strings, so the fts5 query syntax can not be used
+func fts5Query(query string) string {
+	words := strings.Fields(query)
+	if len(words) > maxSearchWords {
+		words = words[:maxSearchWords]
+	}
+	for i, w := range words {
+		words[i] = fmt.Sprintf(`"%s"*`, strings.ReplaceAll(w, `"`, `""`))
+	}
+	return strings.Join(words, " ")
+}
+
+const maxSearchWords = 8
+
+func (fts5Index) matchRooms(query string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			InnerJoins("JOIN room_search ON room_search.rowid = rooms.rowid").
+			Where("room_search MATCH ?", fts5Query(query)).
+			// names weigh more than tags and tags more than descriptions
+			Order("bm25(room_search, 10.0, 2.0, 5.0)")
+	}
+}
+
+func (fts5Index) matchMovies(query string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			InnerJoins("JOIN movie_search ON movie_search.rowid = movies.rowid").
+			Where("movie_search MATCH ?", fts5Query(query)).
+			Order("bm25(movie_search)")
+	}
+}
+
+// tsvectorIndex uses gin expression indexes, which postgres keeps up to date
+type tsvectorIndex struct{}
+
+const (
+	pgRoomNameVector     = "to_tsvector('simple', rooms.name)"
+	pgRoomSettingsVector = "to_tsvector('simple', COALESCE(room_settings.description, '') || ' ' || COALESCE(room_settings.tags, ''))"
+	pgMovieNameVector    = "to_tsvector('simple', movies.base_name)"
+	pgQuery              = "websearch_to_tsquery('simple', ?)"
+)
+
+func (tsvectorIndex) init(d *gorm.DB) error {
+	for _, stmt := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_rooms_search ON rooms USING GIN (` + pgRoomNameVector + `)`,
+		`CREATE INDEX IF NOT EXISTS idx_room_settings_search ON room_settings USING GIN (` + pgRoomSettingsVector + `)`,
+		`CREATE INDEX IF NOT EXISTS idx_movies_search ON movies USING GIN (` + pgMovieNameVector + `)`,
+	} {
+		if err := d.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tsvectorIndex) matchRooms(query string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where(pgRoomNameVector+" @@ "+pgQuery+" OR "+pgRoomSettingsVector+" @@ "+pgQuery, query, query).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "ts_rank(setweight(" + pgRoomNameVector + ", 'A') || setweight(" + pgRoomSettingsVector + ", 'B'), " + pgQuery + ") DESC",
+				Vars: []any{query},
+			}})
+	}
+}
+
+func (tsvectorIndex) matchMovies(query string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where(pgMovieNameVector+" @@ "+pgQuery, query).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "ts_rank(" + pgMovieNameVector + ", " + pgQuery + ") DESC",
+				Vars: []any{query},
+			}})
+	}
+}
+
+// likeIndex scans the tables for databases without a search index here,
+// names matching rank before descriptions and tags
+type likeIndex struct{}
+
+func (likeIndex) init(*gorm.DB) error {
+	return nil
+}
+
+func (likeIndex) matchRooms(query string) func(*gorm.DB) *gorm.DB {
+	like := utils.LIKE(query)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("rooms.name LIKE ? OR room_settings.description LIKE ? OR room_settings.tags LIKE ?", like, like, like).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "CASE WHEN rooms.name LIKE ? THEN 0 ELSE 1 END, rooms.created_at DESC",
+				Vars: []any{like},
+			}})
+	}
+}
+
+func (likeIndex) matchMovies(query string) func(*gorm.DB) *gorm.DB {
+	like := utils.LIKE(query)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("movies.base_name LIKE ?", like).
+			Order("movies.position")
+	}
+}
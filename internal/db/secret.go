@@ -0,0 +1,31 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+func GetUserSecrets(userID string) ([]*model.UserSecret, error) {
+	var secrets []*model.UserSecret
+	err := db.Where("user_id = ?", userID).Order("name").Find(&secrets).Error
+	return secrets, err
+}
+
+// SaveUserSecret creates the secret or rotates the one with the same name
+func SaveUserSecret(secret *model.UserSecret) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "kind", "header", "default_host", "value"}),
+	}).Create(secret).Error
+}
+
+func DeleteUserSecret(userID, name string) error {
+	result := db.Where("user_id = ? AND name = ?", userID, name).Delete(&model.UserSecret{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound("secret")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func CreateRoomSnapshot(snapshot *model.RoomSnapshot) error {
+	return db.Create(snapshot).Error
+}
+
+func GetRoomSnapshot(id string) (*model.RoomSnapshot, error) {
+	snapshot := &model.RoomSnapshot{}
+	err := db.Where("id = ?", id).First(snapshot).Error
+	return snapshot, HandleNotFound(err, "snapshot")
+}
+
+// GetRoomSnapshots returns the snapshots of the room without the chat and timeline, newest first
+func GetRoomSnapshots(roomID string) ([]*model.RoomSnapshot, error) {
+	var snapshots []*model.RoomSnapshot
+	err := db.Omit("chat", "timeline").Where("room_id = ?", roomID).Order("created_at DESC").Find(&snapshots).Error
+	return snapshots, err
+}
+
+func DeleteRoomSnapshot(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.RoomSnapshot{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return HandleNotFound(gorm.ErrRecordNotFound, "snapshot")
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/synctv-org/synctv/cmd/flags"
 	"github.com/synctv-org/synctv/internal/conf"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
 	"gorm.io/gorm"
 )
 
@@ -15,7 +16,7 @@ type dbVersion struct {
 	Upgrade     func(*gorm.DB) error
 }
 
-const CurrentVersion = "0.0.10"
+const CurrentVersion = "0.0.43"
 
 var models = []any{
 	new(model.Setting),
@@ -25,10 +26,42 @@ var models = []any{
 	new(model.RoomSettings),
 	new(model.RoomMember),
 	new(model.Movie),
+	new(model.MovieUnlock),
 	new(model.BilibiliVendor),
 	new(model.AlistVendor),
 	new(model.EmbyVendor),
+	new(model.CookieVendor),
 	new(model.VendorBackend),
+	new(model.PinnedChatMessage),
+	new(model.RoomAudit),
+	new(model.NotificationSubscription),
+	new(model.NotificationPreference),
+	new(model.RoomFollow),
+	new(model.RoomEvent),
+	new(model.LiveSession),
+	new(model.RoomMirror),
+	new(model.ActivityPubFollower),
+	new(model.ActivityPubNote),
+	new(model.MediaFile),
+	new(model.UserSecret),
+	new(model.AlistWatch),
+	new(model.ArrWebhook),
+	new(model.RoomSnapshot),
+	new(model.MovieComment),
+	new(model.MovieCommentReaction),
+	new(model.PresencePreference),
+	new(model.MoviePoll),
+	new(model.MoviePollBallot),
+	new(model.UserAchievement),
+	new(model.UserStats),
+	new(model.Job),
+	new(model.UserDeletion),
+	new(model.UserLogin),
+	new(model.Organization),
+	new(model.OrganizationMember),
+	new(model.OrgProvider),
+	new(model.UserPasskey),
+	new(model.TrashedMovie),
 }
 
 var dbVersions = map[string]dbVersion{
@@ -76,6 +109,115 @@ var dbVersions = map[string]dbVersion{
 		NextVersion: "0.0.10",
 	},
 	"0.0.10": {
+		NextVersion: "0.0.11",
+	},
+	"0.0.11": {
+		NextVersion: "0.0.12",
+	},
+	"0.0.12": {
+		NextVersion: "0.0.13",
+	},
+	"0.0.13": {
+		NextVersion: "0.0.14",
+	},
+	"0.0.14": {
+		NextVersion: "0.0.15",
+	},
+	"0.0.15": {
+		NextVersion: "0.0.16",
+	},
+	"0.0.16": {
+		NextVersion: "0.0.17",
+	},
+	"0.0.17": {
+		NextVersion: "0.0.18",
+	},
+	"0.0.18": {
+		NextVersion: "0.0.19",
+	},
+	"0.0.19": {
+		NextVersion: "0.0.20",
+	},
+	"0.0.20": {
+		NextVersion: "0.0.21",
+	},
+	"0.0.21": {
+		NextVersion: "0.0.22",
+	},
+	"0.0.22": {
+		NextVersion: "0.0.23",
+	},
+	"0.0.23": {
+		NextVersion: "0.0.24",
+	},
+	"0.0.24": {
+		NextVersion: "0.0.25",
+	},
+	"0.0.25": {
+		NextVersion: "0.0.26",
+	},
+	"0.0.26": {
+		NextVersion: "0.0.27",
+	},
+	"0.0.27": {
+		NextVersion: "0.0.28",
+	},
+	"0.0.28": {
+		NextVersion: "0.0.29",
+	},
+	"0.0.29": {
+		NextVersion: "0.0.30",
+	},
+	"0.0.30": {
+		NextVersion: "0.0.31",
+	},
+	"0.0.31": {
+		NextVersion: "0.0.32",
+	},
+	"0.0.32": {
+		NextVersion: "0.0.33",
+	},
+	"0.0.33": {
+		NextVersion: "0.0.34",
+		Upgrade: func(db *gorm.DB) error {
+			// room names are unique per organization now
+			if db.Migrator().HasIndex(&model.Room{}, "idx_rooms_name") {
+				return db.Migrator().DropIndex(&model.Room{}, "idx_rooms_name")
+			}
+			return nil
+		},
+	},
+	"0.0.34": {
+		NextVersion: "0.0.35",
+	},
+	"0.0.35": {
+		NextVersion: "0.0.36",
+		// names are counted in characters and normalized now
+		Upgrade: normalizeNames,
+	},
+	"0.0.36": {
+		NextVersion: "0.0.37",
+	},
+	"0.0.37": {
+		NextVersion: "0.0.38",
+	},
+	"0.0.38": {
+		NextVersion: "0.0.39",
+	},
+	"0.0.39": {
+		NextVersion: "0.0.40",
+	},
+	"0.0.40": {
+		NextVersion: "0.0.41",
+	},
+	"0.0.41": {
+		NextVersion: "0.0.42",
+		Upgrade:     moveOrgProviderSettings,
+	},
+	"0.0.42": {
+		NextVersion: "0.0.43",
+	},
+	"0.0.43": {
 		NextVersion: "",
 	},
 }
@@ -146,3 +288,69 @@ func autoMigrate(dst ...any) error {
 		return fmt.Errorf("unknown database type: %s", conf.Conf.Database.Type)
 	}
 }
+
+// normalizeNames normalizes the names of users and rooms stored before names
+// were normalized, names taken after normalizing get a number appended
+func normalizeNames(d *gorm.DB) error {
+	var users []*model.User
+	err := d.Select("id", "username").FindInBatches(&users, 100, func(tx *gorm.DB, _ int) error {
+		for _, u := range users {
+			name, err := normalizedName(d.Model(&model.User{}), "username", u.ID, u.Username)
+			if err != nil {
+				return err
+			}
+			if name == u.Username {
+				continue
+			}
+			if err := d.Model(&model.User{}).Where("id = ?", u.ID).Update("username", name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+	var rooms []*model.Room
+	return d.Select("id", "org_id", "name").FindInBatches(&rooms, 100, func(tx *gorm.DB, _ int) error {
+		for _, r := range rooms {
+			name, err := normalizedName(d.Model(&model.Room{}).Where("org_id = ?", r.OrgID), "name", r.ID, r.Name)
+			if err != nil {
+				return err
+			}
+			if name == r.Name {
+				continue
+			}
+			if err := d.Model(&model.Room{}).Where("id = ?", r.ID).Update("name", name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// normalizedName normalizes the name of the row with the id, scope is the
+// rows the name must be unique in
+func normalizedName(scope *gorm.DB, column, id, name string) (string, error) {
+	normalized := namepolicy.Truncate(namepolicy.Normalize(name), "")
+	if normalized == name {
+		return name, nil
+	}
+	if normalized == "" {
+		normalized = id[:8]
+	}
+	candidate := normalized
+	for i := 2; ; i++ {
+		var count int64
+		err := scope.Session(&gorm.Session{}).
+			Where(column+" = ? AND id <> ?", candidate, id).
+			Count(&count).Error
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = namepolicy.Truncate(normalized, fmt.Sprintf("#%d", i))
+	}
+}
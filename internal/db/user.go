@@ -4,12 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/utils"
 	"github.com/zijiren233/stream"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -214,6 +216,26 @@ func BindProvider(uid string, p provider.OAuth2Provider, puid string) error {
 	return err
 }
 
+func GetUserProvider(uid string, p provider.OAuth2Provider) (*model.UserProvider, error) {
+	var userProvider model.UserProvider
+	err := db.Where("user_id = ? AND provider = ?", uid, p).First(&userProvider).Error
+	return &userProvider, HandleNotFound(err, "provider")
+}
+
+func SaveUserProviderToken(p provider.OAuth2Provider, puid string, tk *oauth2.Token) error {
+	var userProvider model.UserProvider
+	err := db.Where("provider = ? AND provider_user_id = ?", p, puid).First(&userProvider).Error
+	if err != nil {
+		return HandleNotFound(err, "provider")
+	}
+	userProvider.AccessToken = tk.AccessToken
+	if tk.RefreshToken != "" {
+		userProvider.RefreshToken = tk.RefreshToken
+	}
+	userProvider.TokenExpiry = tk.Expiry
+	return db.Save(&userProvider).Error
+}
+
 // 当用户是通过provider注册的时候，则最少保留一个provider，否则禁止解除绑定
 func UnBindProvider(uid string, p provider.OAuth2Provider) error {
 	return Transactional(func(tx *gorm.DB) error {
@@ -243,6 +265,12 @@ func GetUserByUsername(username string) (*model.User, error) {
 	return u, HandleNotFound(err, "user")
 }
 
+func UsernameExists(username string) (bool, error) {
+	var count int64
+	err := db.Model(&model.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
+
 func GetUserByUsernameLike(username string, scopes ...func(*gorm.DB) *gorm.DB) ([]*model.User, error) {
 	var users []*model.User
 	err := db.Where(`username LIKE ?`, fmt.Sprintf("%%%s%%", username)).Scopes(scopes...).Find(&users).Error
@@ -345,6 +373,20 @@ func GetAdmins() ([]*model.User, error) {
 	return users, err
 }
 
+// GetAdminUsernames returns the usernames of admins and root keyed by id
+func GetAdminUsernames() (map[string]string, error) {
+	var users []*model.User
+	err := db.Select("id", "username").Where("role >= ?", model.RoleAdmin).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	return names, nil
+}
+
 func AddAdminByID(userID string) error {
 	err := db.Model(&model.User{}).Where("id = ?", userID).Update("role", model.RoleAdmin).Error
 	return HandleNotFound(err, "user")
@@ -407,6 +449,11 @@ func SetUsernameByID(userID string, username string) error {
 	return HandleNotFound(err, "user")
 }
 
+func SetUserMaxContentRatingByID(userID string, rating model.ContentRating) error {
+	err := db.Model(&model.User{}).Where("id = ?", userID).Update("max_content_rating", rating).Error
+	return HandleNotFound(err, "user")
+}
+
 func GetAllUserCount(scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
 	var count int64
 	err := db.Model(&model.User{}).Scopes(scopes...).Count(&count).Error
@@ -447,3 +494,27 @@ func UnbindEmail(uid string) error {
 		return tx.Model(&model.User{}).Where("id = ?", uid).Update("email", sql.NullString{}).Error
 	})
 }
+
+// GetUserProvidersExpiringBefore returns the providers with a refresh token
+// whose access token expires before t
+func GetUserProvidersExpiringBefore(t time.Time) ([]*model.UserProvider, error) {
+	var providers []*model.UserProvider
+	err := db.
+		Where("refresh_token <> '' AND token_expiry > ? AND token_expiry < ?", time.Time{}, t).
+		Find(&providers).Error
+	return providers, err
+}
+
+// ClearUserProviderToken forgets the tokens of the provider, like when its
+// refresh token was revoked
+func ClearUserProviderToken(p provider.OAuth2Provider, puid string) error {
+	return db.Model(&model.UserProvider{}).
+		Where("provider = ? AND provider_user_id = ?", p, puid).
+		Updates(map[string]any{"access_token": "", "refresh_token": "", "token_expiry": time.Time{}}).Error
+}
+
+// ReencryptUserProviderTokens saves the provider tokens not encrypted with the
+// key again, which encrypts them with it
+func ReencryptUserProviderTokens(keyID string) (int64, error) {
+	return reencrypt[model.UserProvider]("access_token <> '' AND (token_key_id IS NULL OR token_key_id <> ?)", keyID)
+}
@@ -105,11 +105,69 @@ func CreateOrSaveEmbyVendor(vendorInfo *model.EmbyVendor) (*model.EmbyVendor, er
 		}).Error, gorm.ErrRecordNotFound) {
 			return tx.Create(&vendorInfo).Error
 		} else {
-			return tx.Omit("created_at").Save(&vendorInfo).Error
+			return tx.Omit("created_at", "report_playback").Save(&vendorInfo).Error
 		}
 	})
 }
 
+func SetEmbyVendorReportPlayback(userID, serverID string, report bool) error {
+	result := db.Model(&model.EmbyVendor{}).
+		Where("user_id = ? AND server_id = ?", userID, serverID).
+		Update("report_playback", report)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound("vendor")
+	}
+	return nil
+}
+
 func DeleteEmbyVendor(userID, serverID string) error {
 	return db.Where("user_id = ? AND server_id = ?", userID, serverID).Delete(&model.EmbyVendor{}).Error
 }
+
+func GetCookieVendor(userID string, vendor model.VendorName) (*model.CookieVendor, error) {
+	var v model.CookieVendor
+	err := db.Where("user_id = ? AND vendor = ?", userID, vendor).First(&v).Error
+	return &v, HandleNotFound(err, "vendor")
+}
+
+func CreateOrSaveCookieVendor(vendorInfo *model.CookieVendor) (*model.CookieVendor, error) {
+	if vendorInfo.UserID == "" || vendorInfo.Vendor == "" {
+		return nil, errors.New("user_id and vendor must not be empty")
+	}
+	return vendorInfo, Transactional(func(tx *gorm.DB) error {
+		if errors.Is(tx.First(&model.CookieVendor{
+			UserID: vendorInfo.UserID,
+			Vendor: vendorInfo.Vendor,
+		}).Error, gorm.ErrRecordNotFound) {
+			return tx.Create(&vendorInfo).Error
+		} else {
+			return tx.Omit("created_at").Save(&vendorInfo).Error
+		}
+	})
+}
+
+func DeleteCookieVendor(userID string, vendor model.VendorName) error {
+	return db.Where("user_id = ? AND vendor = ?", userID, vendor).Delete(&model.CookieVendor{}).Error
+}
+
+// ReencryptVendors saves the vendor bindings not encrypted with the provider
+// token key again, which encrypts them with it
+func ReencryptVendors(keyID string) (int64, error) {
+	var count int64
+	for _, reencrypt := range []func(string) (int64, error){
+		reencryptKeyID[model.BilibiliVendor],
+		reencryptKeyID[model.AlistVendor],
+		reencryptKeyID[model.EmbyVendor],
+		reencryptKeyID[model.CookieVendor],
+	} {
+		n, err := reencrypt(keyID)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,87 @@
+// Package events is an in process bus of typed events, subsystems publish
+// what happened and integrations like notifications or achievements
+// subscribe to it, so adding an integration never touches the publisher.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Bus delivers events of type E to its subscribers
+type Bus[E any] struct {
+	name   string
+	lock   sync.RWMutex
+	subs   []*subscriber[E]
+	nextID atomic.Uint64
+}
+
+type subscriber[E any] struct {
+	id    uint64
+	name  string
+	fn    func(E)
+	async bool
+}
+
+func NewBus[E any](name string) *Bus[E] {
+	return &Bus[E]{name: name}
+}
+
+// Subscribe calls fn with every published event on the publishing goroutine,
+// fn must return quickly. The returned func removes the subscription.
+func (b *Bus[E]) Subscribe(name string, fn func(E)) (unsubscribe func()) {
+	return b.subscribe(name, fn, false)
+}
+
+// SubscribeAsync calls fn with every published event on a new goroutine
+func (b *Bus[E]) SubscribeAsync(name string, fn func(E)) (unsubscribe func()) {
+	return b.subscribe(name, fn, true)
+}
+
+func (b *Bus[E]) subscribe(name string, fn func(E), async bool) func() {
+	s := &subscriber[E]{
+		id:    b.nextID.Add(1),
+		name:  name,
+		fn:    fn,
+		async: async,
+	}
+	b.lock.Lock()
+	b.subs = append(b.subs, s)
+	b.lock.Unlock()
+	return func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		for i, sub := range b.subs {
+			if sub.id == s.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers e to the subscribers in the order they subscribed, a
+// panicking subscriber is logged and does not affect the others
+func (b *Bus[E]) Publish(e E) {
+	b.lock.RLock()
+	subs := b.subs
+	b.lock.RUnlock()
+	for _, s := range subs {
+		if s.async {
+			go b.call(s, e)
+		} else {
+			b.call(s, e)
+		}
+	}
+}
+
+func (b *Bus[E]) call(s *subscriber[E], e E) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("events: %s subscriber %s panicked: %v", b.name, s.name, r)
+		}
+	}()
+	s.fn(e)
+}
@@ -0,0 +1,16 @@
+package events
+
+import "testing"
+
+func TestBusPublish(t *testing.T) {
+	b := NewBus[int]("test")
+	var got []int
+	b.Subscribe("panics", func(int) { panic("boom") })
+	unsubscribe := b.Subscribe("collect", func(i int) { got = append(got, i) })
+	b.Publish(1)
+	unsubscribe()
+	b.Publish(2)
+	if len(got) != 1 || got[0] != 1 {
+		t.Fatalf("got %v, want [1]", got)
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+type RoomEventType string
+
+const (
+	RoomCreated RoomEventType = "created"
+	// the current movie of the room was set to a live movie
+	RoomLiveStarted RoomEventType = "live_started"
+	// a room admin moderated the room, see Action and Target
+	RoomModerated RoomEventType = "moderated"
+	// the settings, the password or the status of the room changed
+	RoomUpdated RoomEventType = "updated"
+	RoomDeleted RoomEventType = "deleted"
+)
+
+// RoomEvent is published for changes of a room, UserID is the user causing it
+type RoomEvent struct {
+	Type     RoomEventType
+	RoomID   string
+	RoomName string
+	UserID   string
+	Username string
+	// the room is neither hidden nor needs a password
+	Public bool
+	// the audit action and its target of RoomModerated
+	Action string
+	Target string
+}
+
+type UserEventType string
+
+const (
+	// the user joined a room without resuming a dropped session
+	UserJoinedRoom  UserEventType = "joined_room"
+	UserChatMessage UserEventType = "chat_message"
+)
+
+// UserEvent is published for what a user did in a room
+type UserEvent struct {
+	Type   UserEventType
+	UserID string
+	RoomID string
+}
+
+type PlaybackEventType string
+
+const (
+	PlaybackMovieChanged PlaybackEventType = "movie_changed"
+)
+
+// PlaybackEvent is published when the playback of a room changes
+type PlaybackEvent struct {
+	Type    PlaybackEventType
+	RoomID  string
+	UserID  string
+	MovieID string
+	Live    bool
+}
+
+var (
+	Rooms    = NewBus[RoomEvent]("room")
+	Users    = NewBus[UserEvent]("user")
+	Playback = NewBus[PlaybackEvent]("playback")
+)
@@ -0,0 +1,128 @@
+package ical
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	timeFormat   = "20060102T150405Z"
+	maxLineBytes = 75
+)
+
+type Event struct {
+	UID         string
+	Created     time.Time
+	LastMod     time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	URL         string
+	Categories  []string
+}
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []*Event
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// FoldLine folds content lines longer than 75 octets (RFC 5545 3.1),
+// never splitting a multi-byte character
+func FoldLine(line string) string {
+	if len(line) <= maxLineBytes {
+		return line
+	}
+	var b strings.Builder
+	limit := maxLineBytes
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		b.WriteString(line[:i])
+		b.WriteString("\r\n ")
+		line = line[i:]
+		// the leading space counts towards the line length
+		limit = maxLineBytes - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *writer) line(name, value string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = io.WriteString(w.w, FoldLine(name+":"+value)+"\r\n")
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func (c *Calendar) Encode(w io.Writer) error {
+	cw := &writer{w: w}
+	cw.line("BEGIN", "VCALENDAR")
+	cw.line("VERSION", "2.0")
+	cw.line("PRODID", c.ProdID)
+	cw.line("CALSCALE", "GREGORIAN")
+	cw.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		cw.line("X-WR-CALNAME", EscapeText(c.Name))
+	}
+	now := formatTime(time.Now())
+	for _, e := range c.Events {
+		cw.line("BEGIN", "VEVENT")
+		cw.line("UID", e.UID)
+		cw.line("DTSTAMP", now)
+		if !e.Created.IsZero() {
+			cw.line("CREATED", formatTime(e.Created))
+		}
+		if !e.LastMod.IsZero() {
+			cw.line("LAST-MODIFIED", formatTime(e.LastMod))
+		}
+		cw.line("DTSTART", formatTime(e.Start))
+		if e.End.After(e.Start) {
+			cw.line("DTEND", formatTime(e.End))
+		}
+		cw.line("SUMMARY", EscapeText(e.Summary))
+		if e.Description != "" {
+			cw.line("DESCRIPTION", EscapeText(e.Description))
+		}
+		if e.URL != "" {
+			cw.line("URL", e.URL)
+		}
+		if len(e.Categories) != 0 {
+			cats := make([]string, len(e.Categories))
+			for i, c := range e.Categories {
+				cats[i] = EscapeText(c)
+			}
+			cw.line("CATEGORIES", strings.Join(cats, ","))
+		}
+		cw.line("END", "VEVENT")
+	}
+	cw.line("END", "VCALENDAR")
+	return cw.err
+}
@@ -0,0 +1,62 @@
+package ical_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/ical"
+)
+
+func TestEscapeText(t *testing.T) {
+	got := ical.EscapeText("a,b;c\\d\ne")
+	want := `a\,b\;c\\d\ne`
+	if got != want {
+		t.Errorf("EscapeText() = %q, want %q", got, want)
+	}
+}
+
+func TestFoldLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("一二三", 20)
+	folded := ical.FoldLine(line)
+	for _, l := range strings.Split(folded, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line too long: %d", len(l))
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Errorf("unfolded line mismatch")
+	}
+}
+
+func TestCalendarWriteTo(t *testing.T) {
+	var b strings.Builder
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := (&ical.Calendar{
+		ProdID: "-//test//EN",
+		Name:   "room",
+		Events: []*ical.Event{
+			{
+				UID:     "1@test",
+				Start:   start,
+				End:     start.Add(time.Hour),
+				Summary: "movie night",
+			},
+		},
+	}).Encode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20240102T030405Z\r\n",
+		"DTEND:20240102T040405Z\r\n",
+		"SUMMARY:movie night\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package janitor removes stored artifacts that are no longer referenced,
+// such as abandoned uploads and media blobs without any file record.
+//
+// Subsystems that keep files on disk register a Sweeper, the janitor runs
+// all sweepers on a schedule and on demand, or reports what they would
+// remove without removing anything.
+package janitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+// hours, 0 disables the scheduled run
+var Interval = settings.NewInt64Setting("janitor_interval", 24, model.SettingGroupServer, settings.WithValidatorInt64(func(i int64) error {
+	if i < 0 {
+		return errors.New("janitor interval must be greater than or equal to 0")
+	}
+	return nil
+}))
+
+var ErrRunning = errors.New("janitor is already running")
+
+// Artifact is a stored object that can be reclaimed
+type Artifact struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+	// remove deletes the artifact, it should check again that it is
+	// still unreferenced since time passes between scan and removal
+	remove func() error
+}
+
+func NewArtifact(path string, size int64, reason string, remove func() error) *Artifact {
+	return &Artifact{
+		Path:   path,
+		Size:   size,
+		Reason: reason,
+		remove: remove,
+	}
+}
+
+// Sweeper finds the unreferenced artifacts of a subsystem
+type Sweeper interface {
+	Name() string
+	Scan(ctx context.Context) ([]*Artifact, error)
+}
+
+type SweeperReport struct {
+	Name        string      `json:"name"`
+	Artifacts   []*Artifact `json:"artifacts"`
+	Reclaimable int64       `json:"reclaimable"`
+	Removed     int         `json:"removed"`
+	Errors      []string    `json:"errors,omitempty"`
+}
+
+type Report struct {
+	DryRun      bool             `json:"dryRun"`
+	StartedAt   int64            `json:"startedAt"`
+	Duration    int64            `json:"duration"`
+	Reclaimable int64            `json:"reclaimable"`
+	Sweepers    []*SweeperReport `json:"sweepers"`
+}
+
+var (
+	sweepersLock sync.Mutex
+	sweepers     []Sweeper
+
+	running sync.Mutex
+
+	lastLock sync.RWMutex
+	last     *Report
+)
+
+func Register(s Sweeper) {
+	sweepersLock.Lock()
+	defer sweepersLock.Unlock()
+	sweepers = append(sweepers, s)
+}
+
+func registered() []Sweeper {
+	sweepersLock.Lock()
+	defer sweepersLock.Unlock()
+	return append([]Sweeper(nil), sweepers...)
+}
+
+// Run scans all sweepers and removes what they found unless dryRun
+func Run(ctx context.Context, dryRun bool) (*Report, error) {
+	if !running.TryLock() {
+		return nil, ErrRunning
+	}
+	defer running.Unlock()
+
+	start := time.Now()
+	report := &Report{
+		DryRun:    dryRun,
+		StartedAt: start.UnixMilli(),
+	}
+	for _, s := range registered() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sr := &SweeperReport{Name: s.Name()}
+		report.Sweepers = append(report.Sweepers, sr)
+
+		artifacts, err := s.Scan(ctx)
+		if err != nil {
+			sr.Errors = append(sr.Errors, err.Error())
+			continue
+		}
+		sr.Artifacts = artifacts
+		for _, a := range artifacts {
+			sr.Reclaimable += a.Size
+			if dryRun {
+				continue
+			}
+			if err := a.remove(); err != nil {
+				sr.Errors = append(sr.Errors, err.Error())
+				continue
+			}
+			sr.Removed++
+		}
+		report.Reclaimable += sr.Reclaimable
+	}
+	report.Duration = time.Since(start).Milliseconds()
+
+	if !dryRun {
+		lastLock.Lock()
+		last = report
+		lastLock.Unlock()
+	}
+	return report, nil
+}
+
+// LastReport returns the report of the last run that removed artifacts
+func LastReport() *Report {
+	lastLock.RLock()
+	defer lastLock.RUnlock()
+	return last
+}
+
+// Start runs the janitor every Interval hours until ctx is done
+func Start(ctx context.Context) {
+	go func() {
+		for {
+			wait := time.Duration(Interval.Get()) * time.Hour
+			if wait == 0 {
+				// check again later whether it was enabled
+				wait = time.Hour
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+			if Interval.Get() == 0 {
+				continue
+			}
+			report, err := Run(ctx, false)
+			if err != nil {
+				log.Errorf("janitor run error: %v", err)
+				continue
+			}
+			for _, s := range report.Sweepers {
+				if s.Removed != 0 || len(s.Errors) != 0 {
+					log.Infof("janitor: %s removed %d artifacts, %d bytes, errors: %v", s.Name, s.Removed, s.Reclaimable, s.Errors)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,255 @@
+// Package jobs runs background work persisted in the database, so it
+// survives restarts and is retried with backoff when it fails.
+//
+// Subsystems register a Handler for each kind of job they enqueue, jobs
+// failing all their attempts are kept for admins to inspect and retry.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	json "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+// jobs run at the same time by this server
+var Concurrency = settings.NewInt64Setting("job_concurrency", 4, model.SettingGroupServer, settings.WithValidatorInt64(func(i int64) error {
+	if i < 1 || i > 64 {
+		return errors.New("job concurrency must be between 1 and 64")
+	}
+	return nil
+}))
+
+const (
+	pollInterval       = 2 * time.Second
+	defaultMaxAttempts = 5
+	defaultTimeout     = 5 * time.Minute
+	backoffBase        = 10 * time.Second
+	backoffMax         = time.Hour
+	// succeeded jobs are kept this long for inspection
+	retention = 7 * 24 * time.Hour
+)
+
+var ErrUnknownKind = errors.New("unknown job kind")
+
+// Handler runs a job with the payload it was enqueued with, a returned
+// error fails the attempt
+type Handler func(ctx context.Context, payload []byte) error
+
+type handler struct {
+	run         Handler
+	maxAttempts int
+	timeout     time.Duration
+}
+
+type Option func(*handler)
+
+func WithMaxAttempts(n int) Option {
+	return func(h *handler) {
+		h.maxAttempts = n
+	}
+}
+
+// WithTimeout limits how long an attempt may run
+func WithTimeout(d time.Duration) Option {
+	return func(h *handler) {
+		h.timeout = d
+	}
+}
+
+var (
+	handlersLock sync.RWMutex
+	handlers     = make(map[string]*handler)
+
+	wake    = make(chan struct{}, 1)
+	running atomic.Int64
+)
+
+// Register sets the handler of the kind, it must be called before Start
+func Register(kind string, run Handler, opts ...Option) {
+	h := &handler{
+		run:         run,
+		maxAttempts: defaultMaxAttempts,
+		timeout:     defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	handlers[kind] = h
+}
+
+func handlerOf(kind string) (*handler, bool) {
+	handlersLock.RLock()
+	defer handlersLock.RUnlock()
+	h, ok := handlers[kind]
+	return h, ok
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, the job fails right away
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Enqueue queues a job of the kind, payload is encoded as json
+func Enqueue(kind string, payload any) error {
+	return EnqueueAt(kind, payload, time.Now())
+}
+
+// EnqueueAt queues a job of the kind to run once runAt has passed
+func EnqueueAt(kind string, payload any, runAt time.Time) error {
+	h, ok := handlerOf(kind)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	err = db.CreateJob(&model.Job{
+		Kind:        kind,
+		Payload:     string(b),
+		Status:      model.JobStatusPending,
+		RunAt:       runAt,
+		MaxAttempts: h.maxAttempts,
+	})
+	if err != nil {
+		return err
+	}
+	if !runAt.After(time.Now()) {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Retry queues a failed job again
+func Retry(id string) error {
+	if err := db.RetryJob(id); err != nil {
+		return err
+	}
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Running is the number of jobs running on this server
+func Running() int64 {
+	return running.Load()
+}
+
+// backoff is the delay before the next attempt, doubling per attempt with jitter
+func backoff(attempts int) time.Duration {
+	d := backoffMax
+	if attempts < 16 {
+		d = min(backoffBase<<(attempts-1), backoffMax)
+	}
+	return d + time.Duration(rand.Int63n(int64(d/4)+1))
+}
+
+// Start runs due jobs until ctx is done, jobs left running by a previous
+// process are queued again first
+func Start(ctx context.Context) error {
+	if err := db.ResetRunningJobs(); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		lastCleanup := time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+			if time.Since(lastCleanup) > time.Hour {
+				lastCleanup = time.Now()
+				if err := db.DeleteSucceededJobs(time.Now().Add(-retention)); err != nil {
+					log.Errorf("jobs: delete succeeded jobs error: %v", err)
+				}
+			}
+			free := Concurrency.Get() - running.Load()
+			if free <= 0 {
+				continue
+			}
+			claimed, err := db.ClaimDueJobs(time.Now(), int(free))
+			if err != nil {
+				log.Errorf("jobs: claim jobs error: %v", err)
+			}
+			for _, job := range claimed {
+				running.Add(1)
+				go func(job *model.Job) {
+					defer running.Add(-1)
+					run(ctx, job)
+					// a slot is free again
+					select {
+					case wake <- struct{}{}:
+					default:
+					}
+				}(job)
+			}
+		}
+	}()
+	return nil
+}
+
+func run(ctx context.Context, job *model.Job) {
+	h, ok := handlerOf(job.Kind)
+	if !ok {
+		if err := db.SetJobFailed(job.ID, ErrUnknownKind.Error(), time.Time{}); err != nil {
+			log.Errorf("jobs: set job %s failed error: %v", job.ID, err)
+		}
+		return
+	}
+	err := attempt(ctx, h, job)
+	if err == nil {
+		if err := db.SetJobSucceeded(job.ID); err != nil {
+			log.Errorf("jobs: set job %s succeeded error: %v", job.ID, err)
+		}
+		return
+	}
+	var retryAt time.Time
+	var permanent *permanentError
+	if !errors.As(err, &permanent) && job.Attempts < job.MaxAttempts {
+		retryAt = time.Now().Add(backoff(job.Attempts))
+	} else {
+		log.Warnf("jobs: %s job %s failed: %v", job.Kind, job.ID, err)
+	}
+	if err := db.SetJobFailed(job.ID, err.Error(), retryAt); err != nil {
+		log.Errorf("jobs: set job %s failed error: %v", job.ID, err)
+	}
+}
+
+func attempt(ctx context.Context, h *handler, job *model.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	return h.run(ctx, []byte(job.Payload))
+}
@@ -0,0 +1,203 @@
+package llhls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	flvTagAudio = 8
+	flvTagVideo = 9
+
+	flvCodecAVC = 7
+	flvSoundAAC = 10
+)
+
+var (
+	annexBStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+	// access unit delimiter, ts demuxers of some players expect one per frame
+	annexBAUD = []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xf0}
+)
+
+var ErrUnsupportedCodec = errors.New("llhls only supports h264 and aac")
+
+// flvDemuxer converts flv tags to annex b h264 and adts aac
+type flvDemuxer struct {
+	sps, pps      [][]byte
+	nalLengthSize int
+	asc           []byte
+}
+
+// DemuxFLV reads a flv stream and writes its frames into s until r fails
+func DemuxFLV(r io.Reader, s *Stream) error {
+	var head [11]byte
+	if _, err := io.ReadFull(r, head[:9]); err != nil {
+		return err
+	}
+	if string(head[:3]) != "FLV" {
+		return errors.New("invalid flv header")
+	}
+	// skip the rest of the header and the first previous tag size
+	if _, err := io.CopyN(io.Discard, r, int64(binary.BigEndian.Uint32(head[5:9]))-9+4); err != nil {
+		return err
+	}
+
+	d := &flvDemuxer{}
+	var body []byte
+	for {
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return err
+		}
+		size := int(head[1])<<16 | int(head[2])<<8 | int(head[3])
+		ts := int64(head[7])<<24 | int64(head[4])<<16 | int64(head[5])<<8 | int64(head[6])
+		if cap(body) < size+4 {
+			body = make([]byte, size+4)
+		}
+		body = body[:size+4]
+		if _, err := io.ReadFull(r, body); err != nil {
+			return err
+		}
+		var err error
+		switch head[0] {
+		case flvTagVideo:
+			err = d.video(s, ts, body[:size])
+		case flvTagAudio:
+			err = d.audio(s, ts, body[:size])
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *flvDemuxer) video(s *Stream, ts int64, b []byte) error {
+	if len(b) < 5 {
+		return nil
+	}
+	if b[0]&0x0f != flvCodecAVC {
+		return ErrUnsupportedCodec
+	}
+	key := b[0]>>4 == 1
+	cts := int64(int32(uint32(b[2])<<24|uint32(b[3])<<16|uint32(b[4])<<8) >> 8)
+	switch b[1] {
+	case 0:
+		if err := d.parseAVCConfig(b[5:]); err != nil {
+			return err
+		}
+		s.setTracks(true, false)
+	case 1:
+		if d.nalLengthSize == 0 {
+			return nil
+		}
+		au := bytes.NewBuffer(make([]byte, 0, len(b)+64))
+		au.Write(annexBAUD)
+		if key {
+			for _, ps := range d.sps {
+				au.Write(annexBStartCode)
+				au.Write(ps)
+			}
+			for _, ps := range d.pps {
+				au.Write(annexBStartCode)
+				au.Write(ps)
+			}
+		}
+		data := b[5:]
+		for len(data) >= d.nalLengthSize {
+			var n int
+			for i := 0; i < d.nalLengthSize; i++ {
+				n = n<<8 | int(data[i])
+			}
+			data = data[d.nalLengthSize:]
+			if n > len(data) {
+				return errors.New("invalid avc nalu length")
+			}
+			au.Write(annexBStartCode)
+			au.Write(data[:n])
+			data = data[n:]
+		}
+		s.WriteVideo(ts*90, (ts+cts)*90, key, au.Bytes())
+	}
+	return nil
+}
+
+func (d *flvDemuxer) parseAVCConfig(b []byte) error {
+	if len(b) < 6 {
+		return errors.New("invalid avc decoder configuration")
+	}
+	d.nalLengthSize = int(b[4]&0x03) + 1
+	d.sps, d.pps = nil, nil
+	readSets := func(b []byte, count int) ([][]byte, []byte, error) {
+		sets := make([][]byte, 0, count)
+		for i := 0; i < count; i++ {
+			if len(b) < 2 {
+				return nil, nil, errors.New("invalid avc parameter set")
+			}
+			n := int(binary.BigEndian.Uint16(b))
+			if len(b) < 2+n {
+				return nil, nil, errors.New("invalid avc parameter set")
+			}
+			sets = append(sets, bytes.Clone(b[2:2+n]))
+			b = b[2+n:]
+		}
+		return sets, b, nil
+	}
+	var err error
+	rest := b[6:]
+	if d.sps, rest, err = readSets(rest, int(b[5]&0x1f)); err != nil {
+		return err
+	}
+	if len(rest) < 1 {
+		return errors.New("invalid avc decoder configuration")
+	}
+	d.pps, _, err = readSets(rest[1:], int(rest[0]))
+	return err
+}
+
+func (d *flvDemuxer) audio(s *Stream, ts int64, b []byte) error {
+	if len(b) < 2 {
+		return nil
+	}
+	if b[0]>>4 != flvSoundAAC {
+		return ErrUnsupportedCodec
+	}
+	switch b[1] {
+	case 0:
+		if len(b) < 4 {
+			return errors.New("invalid aac audio specific config")
+		}
+		d.asc = bytes.Clone(b[2:])
+		s.setTracks(false, true)
+	case 1:
+		if d.asc == nil {
+			return nil
+		}
+		frame, err := adts(d.asc, b[2:])
+		if err != nil {
+			return err
+		}
+		s.WriteAudio(ts*90, frame)
+	}
+	return nil
+}
+
+// adts prefixes a raw aac frame with the header built from the audio specific config
+func adts(asc, raw []byte) ([]byte, error) {
+	profile := asc[0] >> 3
+	freq := (asc[0]&0x07)<<1 | asc[1]>>7
+	channels := (asc[1] >> 3) & 0x0f
+	if profile == 0 || profile > 4 || freq > 12 {
+		return nil, fmt.Errorf("unsupported aac config: profile %d, frequency index %d", profile, freq)
+	}
+	n := len(raw) + 7
+	return append([]byte{
+		0xff, 0xf1,
+		(profile-1)<<6 | freq<<2 | channels>>2,
+		(channels&0x03)<<6 | byte(n>>11),
+		byte(n >> 3),
+		byte(n&0x07)<<5 | 0x1f,
+		0xfc,
+	}, raw...), nil
+}
@@ -0,0 +1,387 @@
+package llhls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPartTarget    = 500 * time.Millisecond
+	DefaultSegmentTarget = 2 * time.Second
+
+	PlaylistContentType = "application/vnd.apple.mpegurl"
+	TSContentType       = "video/mp2t"
+
+	// complete segments kept in the playlist
+	segmentWindow = 6
+	// complete segments whose parts are still listed
+	partSegments = 3
+	// audio only streams start once no video showed up for this long
+	audioOnlyDelay = 90000
+)
+
+var (
+	ErrStreamClosed = errors.New("llhls stream closed")
+	ErrNotFound     = errors.New("llhls file not found")
+	// the requested media sequence number is too far in the future
+	ErrFutureMSN    = errors.New("llhls msn too far in the future")
+	ErrBlockTimeout = errors.New("llhls blocking request timed out")
+)
+
+type Part struct {
+	Data        []byte
+	Duration    float64
+	Independent bool
+}
+
+type Segment struct {
+	MSN      int
+	Parts    []*Part
+	Duration float64
+
+	data     []byte
+	complete bool
+}
+
+// Stream cuts h264 and aac frames into mpeg-ts segments made of partial
+// segments and serves them as a low latency hls playlist with blocking reload
+type Stream struct {
+	partTarget    int64
+	segmentTarget int64
+
+	lock   sync.RWMutex
+	notify chan struct{}
+	closed bool
+
+	hasVideo   bool
+	hasAudio   bool
+	firstAudio int64
+
+	mux             *tsMuxer
+	segments        []*Segment
+	buf             bytes.Buffer
+	partStart       int64
+	partIndependent bool
+	lastDts         int64
+	maxDuration     float64
+}
+
+func NewStream(partTarget, segmentTarget time.Duration) *Stream {
+	return &Stream{
+		partTarget:    partTarget.Microseconds() * 90 / 1000,
+		segmentTarget: segmentTarget.Microseconds() * 90 / 1000,
+		notify:        make(chan struct{}),
+		firstAudio:    -1,
+	}
+}
+
+func (s *Stream) setTracks(video, audio bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hasVideo = s.hasVideo || video
+	s.hasAudio = s.hasAudio || audio
+}
+
+// WriteVideo writes an annex b access unit, timestamps are in 90khz
+func (s *Stream) WriteVideo(dts, pts int64, key bool, au []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	if s.mux == nil {
+		// the first part must be independent
+		if !key {
+			return
+		}
+		s.start(true, s.hasAudio, dts)
+	} else if !s.mux.hasVideo {
+		return
+	}
+	s.cut(dts, key)
+	s.mux.writePES(&s.buf, pidVideo, pts, dts, key, au)
+	s.lastDts = dts
+}
+
+// WriteAudio writes an adts frame, pts is in 90khz
+func (s *Stream) WriteAudio(pts int64, frame []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	if s.mux == nil {
+		if s.hasVideo {
+			return
+		}
+		if s.firstAudio < 0 {
+			s.firstAudio = pts
+		}
+		if pts-s.firstAudio < audioOnlyDelay {
+			return
+		}
+		s.start(false, true, pts)
+	} else if !s.mux.hasAudio {
+		return
+	}
+	// with video, parts are only cut on video frames
+	if !s.mux.hasVideo {
+		s.cut(pts, true)
+		s.lastDts = pts
+	}
+	s.mux.writePES(&s.buf, pidAudio, pts, pts, false, frame)
+}
+
+func (s *Stream) start(video, audio bool, dts int64) {
+	s.mux = newTsMuxer(video, audio)
+	s.segments = append(s.segments, &Segment{})
+	s.beginPart(dts, true)
+	s.lastDts = dts
+}
+
+func (s *Stream) current() *Segment {
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *Stream) cut(dts int64, key bool) {
+	elapsed := dts - s.partStart
+	if elapsed <= 0 {
+		if elapsed < 0 {
+			// the source restarted its timestamps
+			s.partStart = dts
+		}
+		return
+	}
+	seg := s.current()
+	if key && seg.Duration+float64(elapsed)/90000 >= float64(s.segmentTarget)/90000 {
+		s.finishPart(dts)
+		s.finishSegment()
+		s.beginPart(dts, true)
+		s.broadcast()
+		return
+	}
+	// cut before the next frame would overrun the part target
+	if elapsed+max(dts-s.lastDts, 0) > s.partTarget {
+		s.finishPart(dts)
+		s.beginPart(dts, key)
+		s.broadcast()
+	}
+}
+
+func (s *Stream) beginPart(dts int64, independent bool) {
+	s.partStart = dts
+	s.partIndependent = independent
+	s.mux.writeTables(&s.buf)
+}
+
+func (s *Stream) finishPart(dts int64) {
+	seg := s.current()
+	p := &Part{
+		Data:        bytes.Clone(s.buf.Bytes()),
+		Duration:    float64(dts-s.partStart) / 90000,
+		Independent: s.partIndependent,
+	}
+	seg.Parts = append(seg.Parts, p)
+	seg.Duration += p.Duration
+	s.buf.Reset()
+}
+
+func (s *Stream) finishSegment() {
+	seg := s.current()
+	size := 0
+	for _, p := range seg.Parts {
+		size += len(p.Data)
+	}
+	seg.data = make([]byte, 0, size)
+	for _, p := range seg.Parts {
+		seg.data = append(seg.data, p.Data...)
+	}
+	seg.complete = true
+	s.maxDuration = max(s.maxDuration, seg.Duration)
+
+	if len(s.segments) > segmentWindow {
+		s.segments = append(s.segments[:0], s.segments[len(s.segments)-segmentWindow:]...)
+	}
+	s.segments = append(s.segments, &Segment{MSN: seg.MSN + 1})
+}
+
+func (s *Stream) broadcast() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+func (s *Stream) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.broadcast()
+}
+
+func (s *Stream) Closed() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.closed
+}
+
+// has reports whether the part of the segment is available, a negative part
+// waits for the whole segment. must be called with the lock held
+func (s *Stream) has(msn, part int) bool {
+	if len(s.segments) == 0 {
+		return false
+	}
+	cur := s.current()
+	switch {
+	case msn < cur.MSN:
+		return true
+	case msn == cur.MSN:
+		return part >= 0 && part < len(cur.Parts)
+	default:
+		return false
+	}
+}
+
+func (s *Stream) blockTimeout() time.Duration {
+	return 3 * time.Duration(max(s.segmentTarget*1000/90, int64(s.maxDuration*1e6))) * time.Microsecond
+}
+
+// wait blocks until the part is available, msn may be at most two segments
+// ahead of the current one
+func (s *Stream) wait(ctx context.Context, msn, part int) error {
+	timer := time.NewTimer(s.blockTimeout())
+	defer timer.Stop()
+	for {
+		s.lock.RLock()
+		ok := s.has(msn, part)
+		closed := s.closed
+		next := 0
+		if len(s.segments) != 0 {
+			next = s.current().MSN
+		}
+		ch := s.notify
+		s.lock.RUnlock()
+		if ok {
+			return nil
+		}
+		if closed {
+			return ErrStreamClosed
+		}
+		if msn > next+2 {
+			return ErrFutureMSN
+		}
+		select {
+		case <-ch:
+		case <-timer.C:
+			return ErrBlockTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Playlist renders the media playlist, a non negative msn is the blocking
+// reload request of _HLS_msn and _HLS_part, part is -1 when not given.
+// uri maps a file name to its url
+func (s *Stream) Playlist(ctx context.Context, msn, part int, uri func(name string) string) ([]byte, error) {
+	if msn >= 0 {
+		if err := s.wait(ctx, msn, part); err != nil {
+			return nil, err
+		}
+	} else if err := s.wait(ctx, 0, 0); err != nil {
+		// wait for the first part so the playlist is never empty
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	partTarget := float64(s.partTarget) / 90000
+	target := int(math.Ceil(max(float64(s.segmentTarget)/90000, s.maxDuration)))
+
+	b := &strings.Builder{}
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:9\n")
+	fmt.Fprintf(b, "#EXT-X-TARGETDURATION:%d\n", target)
+	fmt.Fprintf(b, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", 3*partTarget)
+	fmt.Fprintf(b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget)
+	fmt.Fprintf(b, "#EXT-X-MEDIA-SEQUENCE:%d\n", s.segments[0].MSN)
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for i, seg := range s.segments {
+		if i >= len(s.segments)-1-partSegments {
+			for j, p := range seg.Parts {
+				fmt.Fprintf(b, "#EXT-X-PART:DURATION=%.5f,URI=\"%s\"", p.Duration, uri(partName(seg.MSN, j)))
+				if p.Independent {
+					b.WriteString(",INDEPENDENT=YES")
+				}
+				b.WriteByte('\n')
+			}
+		}
+		if seg.complete {
+			fmt.Fprintf(b, "#EXTINF:%.5f,\n%s\n", seg.Duration, uri(segmentName(seg.MSN)))
+		}
+	}
+	cur := s.current()
+	fmt.Fprintf(b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", uri(partName(cur.MSN, len(cur.Parts))))
+	return []byte(b.String()), nil
+}
+
+// File returns a segment or a part by its name in the playlist, the part of
+// the preload hint blocks until it is cut
+func (s *Stream) File(ctx context.Context, name string) ([]byte, error) {
+	var msn, part int
+	if _, err := fmt.Sscanf(name, "part%d.%d.ts", &msn, &part); err == nil {
+		return s.part(ctx, msn, part)
+	}
+	if _, err := fmt.Sscanf(name, "seg%d.ts", &msn); err == nil {
+		return s.segment(msn)
+	}
+	return nil, ErrNotFound
+}
+
+func (s *Stream) part(ctx context.Context, msn, part int) ([]byte, error) {
+	if err := s.wait(ctx, msn, part); err != nil {
+		return nil, err
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	seg := s.find(msn)
+	if seg == nil || part < 0 || part >= len(seg.Parts) {
+		return nil, ErrNotFound
+	}
+	return seg.Parts[part].Data, nil
+}
+
+func (s *Stream) segment(msn int) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	seg := s.find(msn)
+	if seg == nil || !seg.complete {
+		return nil, ErrNotFound
+	}
+	return seg.data, nil
+}
+
+func (s *Stream) find(msn int) *Segment {
+	if len(s.segments) == 0 {
+		return nil
+	}
+	i := msn - s.segments[0].MSN
+	if i < 0 || i >= len(s.segments) {
+		return nil
+	}
+	return s.segments[i]
+}
+
+func partName(msn, part int) string {
+	return fmt.Sprintf("part%d.%d.ts", msn, part)
+}
+
+func segmentName(msn int) string {
+	return fmt.Sprintf("seg%d.ts", msn)
+}
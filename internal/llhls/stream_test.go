@@ -0,0 +1,83 @@
+package llhls
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFrames writes 30fps video with a keyframe every second
+func writeFrames(s *Stream, from, to int) {
+	for i := from; i < to; i++ {
+		dts := int64(i) * 3000
+		s.WriteVideo(dts, dts, i%30 == 0, []byte{0x00, 0x00, 0x00, 0x01, 0x65, 0x88})
+	}
+}
+
+func TestStreamPlaylist(t *testing.T) {
+	s := NewStream(DefaultPartTarget, DefaultSegmentTarget)
+	writeFrames(s, 0, 150)
+
+	b, err := s.Playlist(context.Background(), -1, -1, func(name string) string { return name })
+	if err != nil {
+		t.Fatalf("Playlist() error = %v", err)
+	}
+	playlist := string(b)
+	for _, want := range []string{
+		"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES",
+		"#EXT-X-PART-INF:PART-TARGET=0.500",
+		"#EXT-X-PART:DURATION=0.50000,URI=\"part0.0.ts\",INDEPENDENT=YES",
+		"#EXTINF:2.00000,\nseg0.ts",
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part2.1.ts\"",
+	} {
+		if !strings.Contains(playlist, want) {
+			t.Errorf("playlist missing %q:\n%s", want, playlist)
+		}
+	}
+
+	seg, err := s.File(context.Background(), "seg0.ts")
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	if len(seg) == 0 || len(seg)%tsPacketSize != 0 {
+		t.Fatalf("segment size %d is not a multiple of %d", len(seg), tsPacketSize)
+	}
+	for i := 0; i < len(seg); i += tsPacketSize {
+		if seg[i] != 0x47 {
+			t.Fatalf("packet %d has no sync byte", i/tsPacketSize)
+		}
+	}
+	if _, err := s.File(context.Background(), "seg9.ts"); err != ErrNotFound {
+		t.Errorf("File() of missing segment error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestStreamBlockingReload(t *testing.T) {
+	s := NewStream(DefaultPartTarget, DefaultSegmentTarget)
+	writeFrames(s, 0, 30)
+
+	done := make(chan []byte)
+	go func() {
+		b, _ := s.Playlist(context.Background(), 1, 0, func(name string) string { return name })
+		done <- b
+	}()
+	select {
+	case <-done:
+		t.Fatal("playlist returned before the part was cut")
+	case <-time.After(50 * time.Millisecond):
+	}
+	writeFrames(s, 30, 90)
+	select {
+	case b := <-done:
+		if !strings.Contains(string(b), "part1.0.ts") {
+			t.Errorf("playlist missing the awaited part:\n%s", b)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocking reload did not return")
+	}
+
+	if _, err := s.Playlist(context.Background(), 9, -1, func(name string) string { return name }); err != ErrFutureMSN {
+		t.Errorf("Playlist() error = %v, want %v", err, ErrFutureMSN)
+	}
+}
@@ -0,0 +1,217 @@
+package llhls
+
+import "bytes"
+
+const (
+	tsPacketSize = 188
+
+	pidPAT   = 0x0000
+	pidPMT   = 0x1000
+	pidVideo = 0x0100
+	pidAudio = 0x0101
+
+	streamTypeH264 = 0x1b
+	streamTypeAAC  = 0x0f
+
+	streamIDVideo = 0xe0
+	streamIDAudio = 0xc0
+)
+
+// tsMuxer writes h264 and adts aac into mpeg-ts, the continuity counters are
+// kept across parts so parts of a segment concatenate into a valid stream
+type tsMuxer struct {
+	hasVideo bool
+	hasAudio bool
+	cc       map[uint16]byte
+}
+
+func newTsMuxer(hasVideo, hasAudio bool) *tsMuxer {
+	return &tsMuxer{
+		hasVideo: hasVideo,
+		hasAudio: hasAudio,
+		cc:       make(map[uint16]byte, 4),
+	}
+}
+
+func (m *tsMuxer) pcrPID() uint16 {
+	if m.hasVideo {
+		return pidVideo
+	}
+	return pidAudio
+}
+
+func (m *tsMuxer) nextCC(pid uint16) byte {
+	cc := m.cc[pid]
+	m.cc[pid] = (cc + 1) & 0x0f
+	return cc
+}
+
+// writeTables writes the pat and pmt, every part starts with them
+func (m *tsMuxer) writeTables(w *bytes.Buffer) {
+	pat := []byte{
+		0x00,       // table id
+		0xb0, 0x0d, // section length
+		0x00, 0x01, // transport stream id
+		0xc1, 0x00, 0x00,
+		0x00, 0x01, // program number
+		0xe0 | pidPMT>>8, pidPMT & 0xff,
+	}
+	m.writeSection(w, pidPAT, pat)
+
+	pcr := m.pcrPID()
+	pmt := []byte{
+		0x02,       // table id
+		0xb0, 0x00, // section length, set below
+		0x00, 0x01, // program number
+		0xc1, 0x00, 0x00,
+		0xe0 | byte(pcr>>8), byte(pcr),
+		0xf0, 0x00, // program info length
+	}
+	if m.hasVideo {
+		pmt = append(pmt, streamTypeH264, 0xe0|pidVideo>>8, pidVideo&0xff, 0xf0, 0x00)
+	}
+	if m.hasAudio {
+		pmt = append(pmt, streamTypeAAC, 0xe0|pidAudio>>8, pidAudio&0xff, 0xf0, 0x00)
+	}
+	// section length counts the bytes after it including the crc
+	pmt[2] = byte(len(pmt) - 3 + 4)
+	m.writeSection(w, pidPMT, pmt)
+}
+
+func (m *tsMuxer) writeSection(w *bytes.Buffer, pid uint16, section []byte) {
+	var pkt [tsPacketSize]byte
+	for i := range pkt {
+		pkt[i] = 0xff
+	}
+	pkt[0] = 0x47
+	pkt[1] = 0x40 | byte(pid>>8)&0x1f
+	pkt[2] = byte(pid)
+	pkt[3] = 0x10 | m.nextCC(pid)
+	pkt[4] = 0x00 // pointer field
+	n := copy(pkt[5:], section)
+	crc := crc32MPEG(section)
+	pkt[5+n] = byte(crc >> 24)
+	pkt[6+n] = byte(crc >> 16)
+	pkt[7+n] = byte(crc >> 8)
+	pkt[8+n] = byte(crc)
+	w.Write(pkt[:])
+}
+
+// writePES packetizes one access unit, pts and dts are in 90khz
+func (m *tsMuxer) writePES(w *bytes.Buffer, pid uint16, pts, dts int64, key bool, data []byte) {
+	streamID := byte(streamIDAudio)
+	if pid == pidVideo {
+		streamID = streamIDVideo
+	}
+	header := []byte{0x00, 0x00, 0x01, streamID, 0x00, 0x00, 0x80}
+	if pts != dts {
+		header = append(header, 0xc0, 10)
+		header = appendTimestamp(header, 0x03, pts)
+		header = appendTimestamp(header, 0x01, dts)
+	} else {
+		header = append(header, 0x80, 5)
+		header = appendTimestamp(header, 0x02, pts)
+	}
+	// video pes may leave the length unbounded
+	if size := len(header) - 6 + len(data); pid != pidVideo && size <= 0xffff {
+		header[4] = byte(size >> 8)
+		header[5] = byte(size)
+	}
+	pes := append(header, data...)
+
+	first := true
+	for len(pes) > 0 {
+		var pkt [tsPacketSize]byte
+		pkt[0] = 0x47
+		pkt[1] = byte(pid>>8) & 0x1f
+		if first {
+			pkt[1] |= 0x40
+		}
+		pkt[2] = byte(pid)
+
+		var af []byte
+		if first && (key || pid == m.pcrPID()) {
+			var flags byte
+			if key {
+				flags |= 0x40
+			}
+			af = append(af, flags)
+			if pid == m.pcrPID() {
+				af[0] |= 0x10
+				af = appendPCR(af, dts)
+			}
+		}
+		space := tsPacketSize - 4
+		if af != nil {
+			space -= 1 + len(af)
+		}
+		if stuffing := space - len(pes); stuffing > 0 {
+			switch {
+			case af != nil:
+				af = append(af, bytes.Repeat([]byte{0xff}, stuffing)...)
+			case stuffing == 1:
+				af = []byte{}
+			default:
+				af = append([]byte{0x00}, bytes.Repeat([]byte{0xff}, stuffing-2)...)
+			}
+			space = len(pes)
+		}
+
+		i := 4
+		if af != nil {
+			pkt[3] = 0x30 | m.nextCC(pid)
+			pkt[4] = byte(len(af))
+			i += 1 + copy(pkt[5:], af)
+		} else {
+			pkt[3] = 0x10 | m.nextCC(pid)
+		}
+		copy(pkt[i:], pes[:space])
+		pes = pes[space:]
+		w.Write(pkt[:])
+		first = false
+	}
+}
+
+func appendTimestamp(b []byte, prefix byte, ts int64) []byte {
+	return append(b,
+		prefix<<4|byte(ts>>29)&0x0e|1,
+		byte(ts>>22),
+		byte(ts>>14)&0xfe|1,
+		byte(ts>>7),
+		byte(ts<<1)&0xfe|1,
+	)
+}
+
+func appendPCR(b []byte, base int64) []byte {
+	return append(b,
+		byte(base>>25),
+		byte(base>>17),
+		byte(base>>9),
+		byte(base>>1),
+		byte(base<<7)&0x80|0x7e,
+		0x00,
+	)
+}
+
+var crc32MPEGTable = func() (t [256]uint32) {
+	for i := range t {
+		c := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04c11db7
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return
+}()
+
+func crc32MPEG(b []byte) uint32 {
+	crc := uint32(0xffffffff)
+	for _, v := range b {
+		crc = crc<<8 ^ crc32MPEGTable[byte(crc>>24)^v]
+	}
+	return crc
+}
@@ -0,0 +1,137 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/janitor"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/storage"
+)
+
+// sweeper finds expired uploads, part files without a record
+// and blobs no ready file references
+type sweeper struct{}
+
+func (sweeper) Name() string {
+	return "media"
+}
+
+func (sweeper) Scan(ctx context.Context) ([]*janitor.Artifact, error) {
+	var artifacts []*janitor.Artifact
+
+	expired, err := scanExpired()
+	if err != nil {
+		return nil, err
+	}
+	artifacts = append(artifacts, expired...)
+
+	parts, err := scanParts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	artifacts = append(artifacts, parts...)
+
+	blobs, err := scanBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(artifacts, blobs...), nil
+}
+
+func scanExpired() ([]*janitor.Artifact, error) {
+	before := time.Now().Add(-time.Duration(UploadExpire.Get()) * time.Hour)
+	files, err := db.GetStaleMediaFiles(before, model.MediaFileStatusUploading, model.MediaFileStatusFailed)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := make([]*janitor.Artifact, 0, len(files))
+	for _, f := range files {
+		reason := "upload expired"
+		if f.Status == model.MediaFileStatusFailed {
+			reason = "upload failed"
+		}
+		var size int64
+		if stat, err := os.Stat(partPath(f.ID)); err == nil {
+			size = stat.Size()
+		}
+		artifacts = append(artifacts, janitor.NewArtifact(partPath(f.ID), size, reason, func() error {
+			if _, ok := busy.Load(f.ID); ok {
+				return ErrUploadBusy
+			}
+			if err := db.DeleteMediaFileByID(f.ID); err != nil {
+				return err
+			}
+			return removeStored(f)
+		}))
+	}
+	return artifacts, nil
+}
+
+func scanParts(ctx context.Context) ([]*janitor.Artifact, error) {
+	entries, err := os.ReadDir(uploadsDir())
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []*janitor.Artifact
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		id, ok := strings.CutSuffix(e.Name(), ".part")
+		if !ok || e.IsDir() {
+			continue
+		}
+		// the record is created before its part file
+		if _, err := db.GetMediaFile(id); !errors.Is(err, db.ErrNotFound("media file")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, janitor.NewArtifact(partPath(id), info.Size(), "no media file record", func() error {
+			if _, err := db.GetMediaFile(id); !errors.Is(err, db.ErrNotFound("media file")) {
+				return err
+			}
+			return removeIfExists(partPath(id))
+		}))
+	}
+	return artifacts, nil
+}
+
+func scanBlobs(ctx context.Context) ([]*janitor.Artifact, error) {
+	blobs, err := storage.Default().List(ctx, blobsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []*janitor.Artifact
+	for _, b := range blobs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		checksum := strings.TrimPrefix(b.Key, blobsPrefix)
+		if referenced, err := blobReferenced(checksum); err != nil || referenced {
+			continue
+		}
+		artifacts = append(artifacts, janitor.NewArtifact(b.Key, b.Size, "no media file references the blob", func() error {
+			store.Lock()
+			defer store.Unlock()
+			referenced, err := blobReferenced(checksum)
+			if err != nil || referenced {
+				return err
+			}
+			return storage.Default().Delete(context.Background(), b.Key)
+		}))
+	}
+	return artifacts, nil
+}
+
+func blobReferenced(checksum string) (bool, error) {
+	count, err := db.CountMediaFilesByChecksum(checksum)
+	return count != 0, err
+}
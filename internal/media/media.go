@@ -0,0 +1,277 @@
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/janitor"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/storage"
+	"github.com/zijiren233/gencontainer/rwmap"
+)
+
+var (
+	EnableUpload = settings.NewBoolSetting("media_upload_enable", false, model.SettingGroupMedia)
+	// MiB, 0 is unlimited
+	UserQuota = settings.NewInt64Setting("media_user_quota", 10240, model.SettingGroupMedia, settings.WithValidatorInt64(func(i int64) error {
+		if i < 0 {
+			return errors.New("media user quota must be greater than or equal to 0")
+		}
+		return nil
+	}))
+	// MiB, 0 is unlimited
+	MaxFileSize = settings.NewInt64Setting("media_max_file_size", 4096, model.SettingGroupMedia, settings.WithValidatorInt64(func(i int64) error {
+		if i < 0 {
+			return errors.New("media max file size must be greater than or equal to 0")
+		}
+		return nil
+	}))
+	// hours, unfinished and failed uploads untouched for longer are removed
+	UploadExpire = settings.NewInt64Setting("media_upload_expire", 72, model.SettingGroupMedia, settings.WithValidatorInt64(func(i int64) error {
+		if i <= 0 {
+			return errors.New("media upload expire must be greater than 0")
+		}
+		return nil
+	}))
+)
+
+var (
+	ErrUploadDisabled = errors.New("media upload is not enabled")
+	ErrQuotaExceeded  = errors.New("media quota exceeded")
+	ErrFileTooLarge   = errors.New("media file too large")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrUploadBusy     = errors.New("upload is in progress")
+	ErrNotUploading   = errors.New("media file is not uploading")
+	ErrNotReady       = errors.New("media file is not ready")
+)
+
+var (
+	dir string
+	// ids of files being written or processed
+	busy rwmap.RWMap[string, struct{}]
+	// guards moving parts into blobs against removing them
+	store sync.Mutex
+)
+
+// Init sets the directory unfinished uploads are written to and resumes
+// processing interrupted by a restart, complete files are kept in the storage
+func Init(path string) error {
+	dir = path
+	if err := os.MkdirAll(uploadsDir(), 0o755); err != nil {
+		return err
+	}
+	files, err := db.GetMediaFilesByStatus(model.MediaFileStatusProcessing)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		go process(f)
+	}
+	janitor.Register(sweeper{})
+	return nil
+}
+
+func uploadsDir() string {
+	return filepath.Join(dir, "uploads")
+}
+
+func partPath(id string) string {
+	return filepath.Join(uploadsDir(), id+".part")
+}
+
+const blobsPrefix = "blobs/"
+
+func blobKey(checksum string) string {
+	return blobsPrefix + checksum
+}
+
+// CheckQuota checks whether the user may upload another file of size bytes
+func CheckQuota(userID string, size int64) error {
+	if max := MaxFileSize.Get(); max != 0 && size > max*1024*1024 {
+		return ErrFileTooLarge
+	}
+	quota := UserQuota.Get()
+	if quota == 0 {
+		return nil
+	}
+	usage, err := db.GetUserMediaUsage(userID)
+	if err != nil {
+		return err
+	}
+	if usage+size > quota*1024*1024 {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Create reserves a new upload
+func Create(file *model.MediaFile) error {
+	if !EnableUpload.Get() {
+		return ErrUploadDisabled
+	}
+	if err := CheckQuota(file.UserID, file.Size); err != nil {
+		return err
+	}
+	file.Status = model.MediaFileStatusUploading
+	file.Uploaded = 0
+	if err := db.CreateMediaFile(file); err != nil {
+		return err
+	}
+	f, err := os.Create(partPath(file.ID))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Write appends r to the upload at offset and returns the new offset,
+// the file is processed in the background once complete
+func Write(file *model.MediaFile, offset int64, r io.Reader) (int64, error) {
+	if file.Status != model.MediaFileStatusUploading {
+		return file.Uploaded, ErrNotUploading
+	}
+	if _, loaded := busy.LoadOrStore(file.ID, struct{}{}); loaded {
+		return file.Uploaded, ErrUploadBusy
+	}
+	defer busy.Delete(file.ID)
+
+	f, err := os.OpenFile(partPath(file.ID), os.O_WRONLY, 0o644)
+	if err != nil {
+		return file.Uploaded, err
+	}
+	defer f.Close()
+	// the part file is the truth, the record may lag behind after a crash
+	stat, err := f.Stat()
+	if err != nil {
+		return file.Uploaded, err
+	}
+	if stat.Size() != offset {
+		return stat.Size(), ErrOffsetMismatch
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	n, copyErr := io.Copy(f, io.LimitReader(r, file.Size-offset))
+	file.Uploaded = offset + n
+	if err := db.UpdateMediaFileUploaded(file.ID, file.Uploaded); err != nil {
+		return file.Uploaded, err
+	}
+	if file.Uploaded == file.Size {
+		if err := db.UpdateMediaFileStatus(file.ID, model.MediaFileStatusProcessing); err != nil {
+			return file.Uploaded, err
+		}
+		file.Status = model.MediaFileStatusProcessing
+		go process(file)
+	}
+	// keep what was received, the client resumes from the new offset
+	return file.Uploaded, copyErr
+}
+
+// process checksums a complete upload and moves it into the storage,
+// files with the same content share a blob
+func process(file *model.MediaFile) {
+	ctx := context.Background()
+	if _, loaded := busy.LoadOrStore(file.ID, struct{}{}); loaded {
+		return
+	}
+	defer busy.Delete(file.ID)
+
+	checksum, err := checksumFile(partPath(file.ID))
+	if err != nil {
+		log.Errorf("media: checksum %s error: %v", file.ID, err)
+		_ = db.UpdateMediaFileStatus(file.ID, model.MediaFileStatusFailed)
+		return
+	}
+	store.Lock()
+	defer store.Unlock()
+	blob := blobKey(checksum)
+	_, err = storage.Default().Stat(ctx, blob)
+	switch {
+	case err == nil:
+		err = os.Remove(partPath(file.ID))
+	case errors.Is(err, storage.ErrNotExist):
+		err = storage.PutFile(ctx, storage.Default(), blob, partPath(file.ID))
+	}
+	if err != nil {
+		log.Errorf("media: store %s error: %v", file.ID, err)
+		_ = db.UpdateMediaFileStatus(file.ID, model.MediaFileStatusFailed)
+		return
+	}
+	if err := db.SetMediaFileReady(file.ID, checksum); err != nil {
+		log.Errorf("media: set %s ready error: %v", file.ID, err)
+	}
+}
+
+func checksumFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Delete removes the file of the user, the blob is removed with its last file
+func Delete(userID, id string) error {
+	file, err := db.GetUserMediaFile(userID, id)
+	if err != nil {
+		return err
+	}
+	if _, ok := busy.Load(id); ok {
+		return ErrUploadBusy
+	}
+	if err := db.DeleteMediaFile(userID, id); err != nil {
+		return err
+	}
+	return removeStored(file)
+}
+
+// removeStored removes the content of a deleted file record
+func removeStored(file *model.MediaFile) error {
+	if file.Status != model.MediaFileStatusReady {
+		return removeIfExists(partPath(file.ID))
+	}
+	store.Lock()
+	defer store.Unlock()
+	count, err := db.CountMediaFilesByChecksum(file.Checksum)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return storage.Default().Delete(context.Background(), blobKey(file.Checksum))
+	}
+	return nil
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Open opens the content of a ready file
+func Open(ctx context.Context, file *model.MediaFile) (storage.Object, error) {
+	if file.Status != model.MediaFileStatusReady {
+		return nil, ErrNotReady
+	}
+	f, err := storage.Default().Open(ctx, blobKey(file.Checksum))
+	if err != nil {
+		return nil, fmt.Errorf("open media file error: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// UserDeletion is a deletion the user requested, the account is erased at
+// ScheduledAt unless the user cancels it before
+type UserDeletion struct {
+	UserID      string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt   time.Time
+	ScheduledAt time.Time `gorm:"not null"`
+}
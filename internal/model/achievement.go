@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+type Achievement string
+
+const (
+	AchievementFirstRoom       Achievement = "first_room"
+	AchievementMovieNights10   Achievement = "movie_nights_10"
+	AchievementChatMessages100 Achievement = "chat_messages_100"
+	AchievementWeekStreak      Achievement = "week_streak"
+)
+
+type AchievementInfo struct {
+	ID          Achievement `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+}
+
+var Achievements = []*AchievementInfo{
+	{ID: AchievementFirstRoom, Name: "Host", Description: "Create your first room"},
+	{ID: AchievementMovieNights10, Name: "Regular", Description: "Watch on 10 different days"},
+	{ID: AchievementChatMessages100, Name: "Chatterbox", Description: "Send 100 chat messages"},
+	{ID: AchievementWeekStreak, Name: "On a Roll", Description: "Watch 7 days in a row"},
+}
+
+func GetAchievementInfo(a Achievement) *AchievementInfo {
+	for _, info := range Achievements {
+		if info.ID == a {
+			return info
+		}
+	}
+	return nil
+}
+
+type UserAchievement struct {
+	UserID      string      `gorm:"primaryKey;type:char(32)"`
+	Achievement Achievement `gorm:"primaryKey;type:varchar(32)"`
+	CreatedAt   time.Time
+}
+
+// UserStats are the counters achievements are unlocked by. a movie night is
+// a day the user watched in a room, days are in the server time zone
+type UserStats struct {
+	UserID        string    `gorm:"primaryKey;type:char(32)" json:"-"`
+	UpdatedAt     time.Time `json:"-"`
+	MovieNights   int64     `gorm:"not null;default:0" json:"movieNights"`
+	ChatMessages  int64     `gorm:"not null;default:0" json:"chatMessages"`
+	Streak        int64     `gorm:"not null;default:0" json:"streak"`
+	LongestStreak int64     `gorm:"not null;default:0" json:"longestStreak"`
+	// 2006-01-02
+	LastWatchDay string `gorm:"type:char(10)" json:"lastWatchDay"`
+}
+
+const watchDayLayout = "2006-01-02"
+
+// RecordWatchDay counts the day of t as a movie night and updates the
+// streak, it reports false if the day was already counted
+func (s *UserStats) RecordWatchDay(t time.Time) bool {
+	day := t.Format(watchDayLayout)
+	if s.LastWatchDay == day {
+		return false
+	}
+	if s.LastWatchDay == t.AddDate(0, 0, -1).Format(watchDayLayout) {
+		s.Streak++
+	} else {
+		s.Streak = 1
+	}
+	s.LongestStreak = max(s.LongestStreak, s.Streak)
+	s.MovieNights++
+	s.LastWatchDay = day
+	return true
+}
+
+// CurrentStreak is the streak unless it was broken before t
+func (s *UserStats) CurrentStreak(t time.Time) int64 {
+	switch s.LastWatchDay {
+	case t.Format(watchDayLayout), t.AddDate(0, 0, -1).Format(watchDayLayout):
+		return s.Streak
+	default:
+		return 0
+	}
+}
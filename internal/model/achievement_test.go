@@ -0,0 +1,35 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestUserStatsRecordWatchDay(t *testing.T) {
+	s := &model.UserStats{}
+	day := time.Date(2024, 1, 30, 20, 0, 0, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		if !s.RecordWatchDay(day.AddDate(0, 0, i)) {
+			t.Fatalf("day %d should be recorded", i)
+		}
+	}
+	if s.RecordWatchDay(day.AddDate(0, 0, 2).Add(time.Hour)) {
+		t.Error("same day should not be recorded twice")
+	}
+	if s.Streak != 3 || s.MovieNights != 3 {
+		t.Errorf("streak = %d, movie nights = %d, want 3, 3", s.Streak, s.MovieNights)
+	}
+	if got := s.CurrentStreak(day.AddDate(0, 0, 3)); got != 3 {
+		t.Errorf("CurrentStreak() = %d, want 3", got)
+	}
+	if got := s.CurrentStreak(day.AddDate(0, 0, 4)); got != 0 {
+		t.Errorf("CurrentStreak() after a missed day = %d, want 0", got)
+	}
+
+	s.RecordWatchDay(day.AddDate(0, 0, 5))
+	if s.Streak != 1 || s.LongestStreak != 3 {
+		t.Errorf("streak = %d, longest = %d, want 1, 3", s.Streak, s.LongestStreak)
+	}
+}
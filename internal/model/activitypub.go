@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// ActivityPubFollower is a remote actor following the instance actor
+type ActivityPubFollower struct {
+	ActorID     string `gorm:"primaryKey;type:varchar(512)"`
+	CreatedAt   time.Time
+	Inbox       string `gorm:"not null;type:varchar(512)"`
+	SharedInbox string `gorm:"type:varchar(512)"`
+}
+
+// ActivityPubNote is a post published by the instance actor
+type ActivityPubNote struct {
+	ID        string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
+	RoomID    string `gorm:"not null;index;type:char(32)"`
+	Content   string `gorm:"not null;type:text"`
+}
+
+func (n *ActivityPubNote) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == "" {
+		n.ID = utils.SortUUID()
+	}
+	return nil
+}
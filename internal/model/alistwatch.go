@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// AlistWatch polls an alist directory and appends new files to the playlist of a room
+type AlistWatch struct {
+	ID        string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	RoomID    string `gorm:"not null;index;type:char(32)"`
+	// movies are added as the creator with the creator's alist binding
+	CreatorID string `gorm:"not null;index;type:char(32)"`
+	Backend   string `gorm:"type:varchar(64)"`
+	// {/}serverId/Path of the directory
+	Path     string `gorm:"not null;type:varchar(4096)"`
+	Password string `gorm:"type:varchar(256)"`
+	// files are queued into this playlist folder, empty is the root
+	ParentID EmptyNullString `gorm:"type:char(32)"`
+	// regular expressions on file names, an empty include matches all
+	Include string `gorm:"type:varchar(256)"`
+	Exclude string `gorm:"type:varchar(256)"`
+	// names already in the directory at the last check
+	Known       []string `gorm:"serializer:fastjson;type:text"`
+	LastCheckAt time.Time
+	LastError   string `gorm:"type:varchar(256)"`
+}
+
+func (w *AlistWatch) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+// Filter compiles Include and Exclude into a matcher of file names
+func (w *AlistWatch) Filter() (func(name string) bool, error) {
+	var include, exclude *regexp.Regexp
+	var err error
+	if w.Include != "" {
+		if include, err = regexp.Compile(w.Include); err != nil {
+			return nil, fmt.Errorf("invalid include filter: %w", err)
+		}
+	}
+	if w.Exclude != "" {
+		if exclude, err = regexp.Compile(w.Exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude filter: %w", err)
+		}
+	}
+	return func(name string) bool {
+		if include != nil && !include.MatchString(name) {
+			return false
+		}
+		return exclude == nil || !exclude.MatchString(name)
+	}, nil
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// ArrWebhook receives the import notifications of sonarr or radarr and
+// queues the imported file, found at the same place on an alist server
+type ArrWebhook struct {
+	ID          string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	RoomID      string `gorm:"not null;index;type:char(32)"`
+	CreatorID   string `gorm:"not null;index;type:char(32)"`
+	HashedToken []byte `gorm:"not null"`
+	// directory as seen by sonarr or radarr, e.g. /data/media
+	SourcePath string `gorm:"not null;type:varchar(4096)"`
+	// {/}serverId/Path of the same directory on alist
+	TargetPath string `gorm:"not null;type:varchar(4096)"`
+	Backend    string `gorm:"type:varchar(64)"`
+	Password   string `gorm:"type:varchar(256)"`
+	// files are queued into this playlist folder, empty is the root
+	ParentID    EmptyNullString `gorm:"type:char(32)"`
+	LastEventAt time.Time
+	LastError   string `gorm:"type:varchar(256)"`
+}
+
+func (w *ArrWebhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+// GenToken sets a new token and returns it, only its hash is stored
+func (w *ArrWebhook) GenToken() string {
+	token := utils.RandString(32)
+	sum := sha256.Sum256([]byte(token))
+	w.HashedToken = sum[:]
+	return token
+}
+
+func (w *ArrWebhook) CheckToken(token string) bool {
+	sum := sha256.Sum256([]byte(token))
+	return subtle.ConstantTimeCompare(sum[:], w.HashedToken) == 1
+}
+
+// MapPath maps a file path of sonarr or radarr to the alist path
+func (w *ArrWebhook) MapPath(file string) (string, bool) {
+	source := strings.TrimRight(strings.ReplaceAll(w.SourcePath, `\`, "/"), "/")
+	file = path.Clean(strings.ReplaceAll(file, `\`, "/"))
+	rel, ok := strings.CutPrefix(file, source+"/")
+	if !ok {
+		return "", false
+	}
+	serverID, dir, err := GetAlistServerIdFromPath(w.TargetPath)
+	if err != nil {
+		return "", false
+	}
+	return FormatAlistPath(serverID, path.Join(dir, rel)), true
+}
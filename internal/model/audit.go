@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type RoomAuditAction string
+
+const (
+	RoomAuditActionDeleteChatMessage RoomAuditAction = "delete_chat_message"
+	RoomAuditActionPurgeChatMessages RoomAuditAction = "purge_chat_messages"
+	RoomAuditActionPinChatMessage    RoomAuditAction = "pin_chat_message"
+	RoomAuditActionUnpinChatMessage  RoomAuditAction = "unpin_chat_message"
+	RoomAuditActionCreateRoomEvent   RoomAuditAction = "create_room_event"
+	RoomAuditActionEditRoomEvent     RoomAuditAction = "edit_room_event"
+	RoomAuditActionDeleteRoomEvent   RoomAuditAction = "delete_room_event"
+	RoomAuditActionSetRoomMirror     RoomAuditAction = "set_room_mirror"
+	RoomAuditActionDeleteRoomMirror  RoomAuditAction = "delete_room_mirror"
+	RoomAuditActionGrantTrialAdmin   RoomAuditAction = "grant_trial_admin"
+	RoomAuditActionRevertTrialAdmin  RoomAuditAction = "revert_trial_admin"
+	RoomAuditActionPublishSnapshot   RoomAuditAction = "publish_snapshot"
+	RoomAuditActionDeleteSnapshot    RoomAuditAction = "delete_snapshot"
+	RoomAuditActionDeleteComment     RoomAuditAction = "delete_movie_comment"
+	RoomAuditActionCreateMoviePoll   RoomAuditAction = "create_movie_poll"
+	RoomAuditActionCloseMoviePoll    RoomAuditAction = "close_movie_poll"
+	RoomAuditActionDeleteMoviePoll   RoomAuditAction = "delete_movie_poll"
+)
+
+type RoomAudit struct {
+	ID        string `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt time.Time
+	RoomID    string          `gorm:"not null;index;type:char(32)"`
+	UserID    string          `gorm:"type:char(32)"`
+	Action    RoomAuditAction `gorm:"not null;type:varchar(64)"`
+	Target    string          `gorm:"type:varchar(64)"`
+}
+
+func (r *RoomAudit) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = utils.SortUUID()
+	}
+	return nil
+}
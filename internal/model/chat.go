@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PinnedChatMessage struct {
+	ID         string `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt  time.Time
+	RoomID     string    `gorm:"not null;index;type:char(32)"`
+	SenderID   string    `gorm:"type:char(32)"`
+	SenderName string    `gorm:"type:varchar(32)"`
+	Message    string    `gorm:"type:text"`
+	SentAt     time.Time `gorm:"not null"`
+	PinnedBy   string    `gorm:"type:char(32)"`
+	// id of the chat key the message is encrypted with, empty if stored in plain
+	KeyID string `gorm:"index;type:varchar(16)"`
+}
+
+func (m *PinnedChatMessage) BeforeSave(tx *gorm.DB) error {
+	var err error
+	m.Message, m.KeyID, err = EncryptChatMessage(m.RoomID, m.Message)
+	return err
+}
+
+func (m *PinnedChatMessage) AfterSave(tx *gorm.DB) error {
+	var err error
+	m.Message, err = DecryptChatMessage(m.RoomID, m.KeyID, m.Message)
+	return err
+}
+
+func (m *PinnedChatMessage) AfterFind(tx *gorm.DB) error {
+	return m.AfterSave(tx)
+}
+
+var ChatKeyring = NewKeyring("chat")
+
+// SetChatEncryptionKeys sets the master keys of persisted chat, the first one
+// encrypts new messages and no keys stores them in plain
+func SetChatEncryptionKeys(keys []string) error {
+	return ChatKeyring.Set(keys)
+}
+
+// CurrentChatKeyID is the id of the key new messages are encrypted with,
+// empty if encryption is disabled
+func CurrentChatKeyID() string {
+	return ChatKeyring.CurrentID()
+}
+
+func EncryptChatMessage(roomID, message string) (ciphertext, keyID string, err error) {
+	return ChatKeyring.Encrypt(roomID, message)
+}
+
+func DecryptChatMessage(roomID, keyID, ciphertext string) (string, error) {
+	return ChatKeyring.Decrypt(roomID, keyID, ciphertext)
+}
@@ -0,0 +1,44 @@
+package model_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestChatEncryptionRotation(t *testing.T) {
+	defer model.SetChatEncryptionKeys(nil)
+
+	if err := model.SetChatEncryptionKeys([]string{"old-key-0123456789"}); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, oldID, err := model.EncryptChatMessage("room", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldID == "" || ciphertext == "hello" {
+		t.Fatalf("message is not encrypted")
+	}
+	if m, _ := model.DecryptChatMessage("other room", oldID, ciphertext); m == "hello" {
+		t.Fatalf("rooms share a key")
+	}
+
+	if err := model.SetChatEncryptionKeys([]string{"new-key-0123456789", "old-key-0123456789"}); err != nil {
+		t.Fatal(err)
+	}
+	if model.CurrentChatKeyID() == oldID {
+		t.Fatalf("current key is not rotated")
+	}
+	m, err := model.DecryptChatMessage("room", oldID, ciphertext)
+	if err != nil || m != "hello" {
+		t.Fatalf("decrypt with old key: %q, %v", m, err)
+	}
+
+	if err := model.SetChatEncryptionKeys([]string{"new-key-0123456789"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.DecryptChatMessage("room", oldID, ciphertext); !errors.Is(err, model.ErrUnknownKey) {
+		t.Fatalf("got %v, want ErrUnknownKey", err)
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// MovieComment is a persisted discussion entry of a movie, separate from the
+// live chat. the content is markdown and rendered by the client
+type MovieComment struct {
+	ID        string `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	RoomID    string `gorm:"not null;index;type:char(32)"`
+	MovieID   string `gorm:"not null;index;type:char(32)"`
+	// the root comment of the thread, empty for a root comment
+	ParentID  string                  `gorm:"index;type:char(32)"`
+	CreatorID string                  `gorm:"type:char(32)"`
+	Content   string                  `gorm:"not null;type:text"`
+	Replies   []*MovieComment         `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Reactions []*MovieCommentReaction `gorm:"foreignKey:CommentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+func (c *MovieComment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+type MovieCommentReaction struct {
+	CommentID string `gorm:"primaryKey;type:char(32)"`
+	UserID    string `gorm:"primaryKey;type:char(32)"`
+	Emoji     string `gorm:"primaryKey;type:varchar(32)"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type RoomEventType string
+
+const (
+	RoomEventTypePlayback     RoomEventType = "playback"
+	RoomEventTypeAnnouncement RoomEventType = "announcement"
+)
+
+type RoomEvent struct {
+	ID          string `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	RoomID      string        `gorm:"not null;index;type:char(32)"`
+	CreatorID   string        `gorm:"type:char(32)"`
+	Type        RoomEventType `gorm:"not null;type:varchar(16)"`
+	Title       string        `gorm:"not null;type:varchar(128)"`
+	Description string        `gorm:"type:text"`
+	StartAt     time.Time     `gorm:"not null;index"`
+	EndAt       time.Time
+	// optional, the movie that will be played
+	MovieID string `gorm:"type:char(32)"`
+}
+
+func (r *RoomEvent) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = utils.SortUUID()
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job is a unit of background work run by the job queue, pending jobs are
+// picked up once RunAt has passed
+type Job struct {
+	ID          string    `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Kind        string    `gorm:"not null;index;type:varchar(64)" json:"kind"`
+	Payload     string    `gorm:"type:text" json:"payload"`
+	Status      JobStatus `gorm:"not null;index:idx_job_status_run_at;type:varchar(16)" json:"status"`
+	RunAt       time.Time `gorm:"not null;index:idx_job_status_run_at" json:"runAt"`
+	Attempts    int       `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int       `gorm:"not null" json:"maxAttempts"`
+	LastError   string    `gorm:"type:text" json:"lastError"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == "" {
+		j.ID = utils.SortUUID()
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/synctv-org/synctv/utils"
+)
+
+var ErrUnknownKey = errors.New("encrypted with an unknown key")
+
+// Keyring holds the master keys encrypting a kind of data at rest. the first
+// key encrypts, the others are kept to decrypt data of rotated out keys
+type Keyring struct {
+	purpose string
+	keys    atomic.Pointer[[]ringKey]
+}
+
+type ringKey struct {
+	id string
+	// id of the key before ids were derived with a label, data encrypted
+	// then still names the key by it
+	legacyID string
+	master   []byte
+}
+
+// keyID identifies the key without revealing a digest of the key itself
+func keyID(master []byte) string {
+	h := hmac.New(sha256.New, master)
+	h.Write([]byte("synctv keyring key id"))
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func NewKeyring(purpose string) *Keyring {
+	return &Keyring{purpose: purpose}
+}
+
+// Set replaces the master keys, no keys disables encryption
+func (k *Keyring) Set(keys []string) error {
+	ks := make([]ringKey, 0, len(keys))
+	for _, key := range keys {
+		if len(key) < 16 {
+			return fmt.Errorf("%s encryption key must be at least 16 characters", k.purpose)
+		}
+		sum := sha256.Sum256([]byte(key))
+		ks = append(ks, ringKey{
+			id:       keyID([]byte(key)),
+			legacyID: hex.EncodeToString(sum[:8]),
+			master:   []byte(key),
+		})
+	}
+	k.keys.Store(&ks)
+	return nil
+}
+
+// CurrentID is the id of the key data is encrypted with, empty if encryption
+// is disabled
+func (k *Keyring) CurrentID() string {
+	ks := k.keys.Load()
+	if ks == nil || len(*ks) == 0 {
+		return ""
+	}
+	return (*ks)[0].id
+}
+
+// derive derives the key of the scope, like a room, so scopes never share a key
+func (k *Keyring) derive(key ringKey, scope string) []byte {
+	h := hmac.New(sha256.New, key.master)
+	h.Write([]byte("synctv " + k.purpose + " " + scope))
+	return h.Sum(nil)
+}
+
+// Encrypt encrypts with the current key, plaintext is returned as is with an
+// empty key id if encryption is disabled
+func (k *Keyring) Encrypt(scope, plaintext string) (ciphertext, keyID string, err error) {
+	ks := k.keys.Load()
+	if ks == nil || len(*ks) == 0 {
+		return plaintext, "", nil
+	}
+	key := (*ks)[0]
+	ciphertext, err = utils.CryptoToBase64([]byte(plaintext), k.derive(key, scope))
+	if err != nil {
+		return "", "", err
+	}
+	return ciphertext, key.id, nil
+}
+
+// Decrypt decrypts with the key of the id, an empty id means it is plaintext
+func (k *Keyring) Decrypt(scope, keyID, ciphertext string) (string, error) {
+	if keyID == "" {
+		return ciphertext, nil
+	}
+	ks := k.keys.Load()
+	if ks != nil {
+		for _, key := range *ks {
+			if key.id != keyID && key.legacyID != keyID {
+				continue
+			}
+			b, err := utils.DecryptoFromBase64(ciphertext, k.derive(key, scope))
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		}
+	}
+	return "", fmt.Errorf("%s %w: %s", k.purpose, ErrUnknownKey, keyID)
+}
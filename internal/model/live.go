@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type LiveSession struct {
+	ID        string `gorm:"primaryKey;type:char(32)" json:"id"`
+	RoomID    string `gorm:"not null;index;type:char(32)"`
+	MovieID   string `gorm:"type:char(32)"`
+	MovieName string `gorm:"type:varchar(256)"`
+	CreatorID string `gorm:"type:char(32)"`
+	StartedAt time.Time
+	EndedAt   time.Time `gorm:"index"`
+}
+
+func (l *LiveSession) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = utils.SortUUID()
+	}
+	return nil
+}
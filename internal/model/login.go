@@ -0,0 +1,53 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// UserLogin is a login of a user, the token issued by it carries its id so
+// revoking the login signs the token out
+type UserLogin struct {
+	ID        string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
+	UserID    string `gorm:"not null;index;type:char(32)"`
+	IP        string `gorm:"type:varchar(64)"`
+	UserAgent string `gorm:"type:varchar(512)"`
+	// see LoginNetwork and LoginDevice
+	Network string `gorm:"index;type:varchar(64)"`
+	Device  string `gorm:"type:char(16)"`
+	// neither the network nor the device was seen in a previous login
+	Anomalous bool `gorm:"not null;default:false"`
+	Revoked   bool `gorm:"not null;default:false"`
+}
+
+func (l *UserLogin) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+// LoginNetwork is the /24 of an ipv4 or the /48 of an ipv6 address, it stands
+// in for the location of a login as addresses change within a provider
+func LoginNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// LoginDevice fingerprints the device of a login by its user agent
+func LoginDevice(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,21 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestLoginNetwork(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.7":         "203.0.113.0/24",
+		"2001:db8:1:2::1":     "2001:db8:1::/48",
+		"::ffff:198.51.100.9": "198.51.100.0/24",
+		"not an ip":           "not an ip",
+	}
+	for ip, want := range tests {
+		if got := model.LoginNetwork(ip); got != want {
+			t.Errorf("LoginNetwork(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
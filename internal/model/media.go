@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type MediaFileStatus string
+
+const (
+	MediaFileStatusUploading  MediaFileStatus = "uploading"
+	MediaFileStatusProcessing MediaFileStatus = "processing"
+	MediaFileStatusReady      MediaFileStatus = "ready"
+	MediaFileStatusFailed     MediaFileStatus = "failed"
+)
+
+// MediaFile is a file uploaded to the local media library,
+// ready files are stored once per checksum
+type MediaFile struct {
+	ID          string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      string          `gorm:"not null;index;type:char(32)"`
+	Name        string          `gorm:"not null;type:varchar(256)"`
+	ContentType string          `gorm:"type:varchar(128)"`
+	Size        int64           `gorm:"not null"`
+	Uploaded    int64           `gorm:"not null;default:0"`
+	Checksum    string          `gorm:"index;type:char(64)"`
+	Status      MediaFileStatus `gorm:"not null;index;type:varchar(16)"`
+}
+
+func (m *MediaFile) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+const mediaURLPrefix = "media://"
+
+// MediaURL is the movie url of a media file
+func MediaURL(fileID string) string {
+	return mediaURLPrefix + fileID
+}
+
+// ParseMediaURL returns the media file id of a movie url
+func ParseMediaURL(u string) (string, bool) {
+	id, ok := strings.CutPrefix(u, mediaURLPrefix)
+	if !ok || len(id) != 32 {
+		return "", false
+	}
+	return id, true
+}
@@ -115,6 +115,11 @@ const (
 	PermissionSetRoomSettings
 	PermissionSetRoomPassword
 	PermissionDeleteRoom
+	PermissionDeleteChatMessage
+	PermissionPinChatMessage
+	PermissionManageRoomEvent
+	PermissionPublishSnapshot
+	PermissionManageMoviePoll
 
 	AllAdminPermissions     RoomAdminPermission = math.MaxUint32
 	NoAdminPermission       RoomAdminPermission = 0
@@ -122,7 +127,12 @@ const (
 		PermissionBanRoomMember |
 		PermissionSetUserPermission |
 		PermissionSetRoomSettings |
-		PermissionSetRoomPassword
+		PermissionSetRoomPassword |
+		PermissionDeleteChatMessage |
+		PermissionPinChatMessage |
+		PermissionManageRoomEvent |
+		PermissionPublishSnapshot |
+		PermissionManageMoviePoll
 )
 
 func (p RoomAdminPermission) Has(permission RoomAdminPermission) bool {
@@ -146,6 +156,17 @@ type RoomMember struct {
 	Role             RoomMemberRole   `gorm:"not null;default:1"`
 	Permissions      RoomMemberPermission
 	AdminPermissions RoomAdminPermission
+
+	// trial admins revert to members with TrialPermissions
+	// at TrialExpireAt or once TrialActions admin actions are used up
+	Trial            bool `gorm:"not null;default:false"`
+	TrialExpireAt    *time.Time
+	TrialActions     int64 `gorm:"not null;default:0"`
+	TrialPermissions RoomMemberPermission
+}
+
+func (r *RoomMember) TrialExpired() bool {
+	return r.Trial && r.TrialExpireAt != nil && !time.Now().Before(*r.TrialExpireAt)
 }
 
 var ErrNoPermission = errors.New("no permission")
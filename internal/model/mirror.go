@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// RoomMirror makes a room follow the playback of a room on another synctv instance
+type RoomMirror struct {
+	RoomID    string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatorID string `gorm:"type:char(32)"`
+	// base url of the remote instance, e.g. https://synctv.example.com
+	RemoteURL string `gorm:"not null;type:varchar(512)"`
+	// room token of the remote room
+	Token   string `gorm:"not null;type:text"`
+	Enabled bool   `gorm:"not null;default:true"`
+}
+
+func (m *RoomMirror) BeforeSave(tx *gorm.DB) error {
+	key := utils.GenCryptoKey(m.RoomID)
+	var err error
+	if m.Token, err = utils.CryptoToBase64([]byte(m.Token), key); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *RoomMirror) AfterSave(tx *gorm.DB) error {
+	key := utils.GenCryptoKey(m.RoomID)
+	if v, err := utils.DecryptoFromBase64(m.Token, key); err != nil {
+		return err
+	} else {
+		m.Token = string(v)
+	}
+	return nil
+}
+
+func (m *RoomMirror) AfterFind(tx *gorm.DB) error {
+	return m.AfterSave(tx)
+}
@@ -3,10 +3,17 @@ package model
 import (
 	"database/sql/driver"
 	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/stream"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/http/httpguts"
 	"gorm.io/gorm"
 )
 
@@ -17,8 +24,19 @@ type Movie struct {
 	Position  uint      `gorm:"not null" json:"-"`
 	RoomID    string    `gorm:"not null;index;type:char(32)" json:"-"`
 	CreatorID string    `gorm:"index;type:char(32)" json:"creatorId"`
+	// bumped by every edit, edits can be made on the condition of a version
+	Version   uint64 `gorm:"not null;default:1" json:"version"`
 	MovieBase `gorm:"embedded;embeddedPrefix:base_" json:"base"`
-	Children  []*Movie `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	Children  []*Movie        `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	Unlocks   []*MovieUnlock  `gorm:"foreignKey:MovieID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	Comments  []*MovieComment `gorm:"foreignKey:MovieID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+}
+
+// MovieUnlock records that a user entered the password of a movie
+type MovieUnlock struct {
+	MovieID   string `gorm:"primaryKey;type:char(32)"`
+	UserID    string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
 }
 
 func (m *Movie) Clone() *Movie {
@@ -29,6 +47,7 @@ func (m *Movie) Clone() *Movie {
 		Position:  m.Position,
 		RoomID:    m.RoomID,
 		CreatorID: m.CreatorID,
+		Version:   m.Version,
 		MovieBase: *m.MovieBase.Clone(),
 		Children:  m.Children,
 	}
@@ -38,6 +57,9 @@ func (m *Movie) BeforeCreate(tx *gorm.DB) error {
 	if m.ID == "" {
 		m.ID = utils.SortUUID()
 	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
 	return nil
 }
 
@@ -65,18 +87,29 @@ type MoreSource struct {
 }
 
 type MovieBase struct {
-	Url         string               `gorm:"type:varchar(8192)" json:"url"`
-	MoreSources []*MoreSource        `gorm:"serializer:fastjson;type:text" json:"moreSources,omitempty"`
-	Name        string               `gorm:"not null;type:varchar(256)" json:"name"`
-	Live        bool                 `json:"live"`
-	Proxy       bool                 `json:"proxy"`
-	RtmpSource  bool                 `json:"rtmpSource"`
-	Type        string               `json:"type"`
-	Headers     map[string]string    `gorm:"serializer:fastjson;type:text" json:"headers,omitempty"`
-	Subtitles   map[string]*Subtitle `gorm:"serializer:fastjson;type:text" json:"subtitles,omitempty"`
-	VendorInfo  VendorInfo           `gorm:"embedded;embeddedPrefix:vendor_info_" json:"vendorInfo,omitempty"`
-	IsFolder    bool                 `json:"isFolder"`
-	ParentID    EmptyNullString      `gorm:"type:char(32)" json:"parentId"`
+	Url         string            `gorm:"type:varchar(8192)" json:"url"`
+	MoreSources []*MoreSource     `gorm:"serializer:fastjson;type:text" json:"moreSources,omitempty"`
+	Name        string            `gorm:"not null;type:varchar(256)" json:"name"`
+	Live        bool              `json:"live"`
+	Proxy       bool              `json:"proxy"`
+	AutoProxy   bool              `json:"autoProxy"`
+	ProxyReason string            `gorm:"type:varchar(256)" json:"proxyReason,omitempty"`
+	RtmpSource  bool              `json:"rtmpSource"`
+	Type        string            `json:"type"`
+	Headers     map[string]string `gorm:"serializer:fastjson;type:text" json:"headers,omitempty"`
+	HeaderRules HeaderRules       `gorm:"serializer:fastjson;type:text" json:"headerRules,omitempty"`
+	Restriction *WatchRestriction `gorm:"serializer:fastjson;type:text" json:"restriction,omitempty"`
+	Rating      ContentRating     `gorm:"type:varchar(8)" json:"rating,omitempty"`
+	// Password is only read from requests, nil keeps the current password
+	// and empty removes it
+	Password       *string              `gorm:"-" json:"password,omitempty"`
+	HashedPassword []byte               `json:"-"`
+	Subtitles      map[string]*Subtitle `gorm:"serializer:fastjson;type:text" json:"subtitles,omitempty"`
+	VendorInfo     VendorInfo           `gorm:"embedded;embeddedPrefix:vendor_info_" json:"vendorInfo,omitempty"`
+	IsFolder       bool                 `json:"isFolder"`
+	ParentID       EmptyNullString      `gorm:"type:char(32)" json:"parentId"`
+	// set by integrations only, see MovieExtensions
+	Extensions MovieExtensions `gorm:"serializer:fastjson;type:text" json:"extensions,omitempty"`
 }
 
 func (m *MovieBase) Clone() *MovieBase {
@@ -92,6 +125,15 @@ func (m *MovieBase) Clone() *MovieBase {
 	for k, v := range m.Headers {
 		hds[k] = v
 	}
+	hrs := make(HeaderRules, len(m.HeaderRules))
+	for i, r := range m.HeaderRules {
+		rule := *r
+		hrs[i] = &rule
+	}
+	var wr *WatchRestriction
+	if m.Restriction != nil {
+		wr = m.Restriction.Clone()
+	}
 	sbs := make(map[string]*Subtitle, len(m.Subtitles))
 	for k, v := range m.Subtitles {
 		sbs[k] = &Subtitle{
@@ -100,19 +142,271 @@ func (m *MovieBase) Clone() *MovieBase {
 		}
 	}
 	return &MovieBase{
-		Url:         m.Url,
-		MoreSources: mss,
-		Name:        m.Name,
-		Live:        m.Live,
-		Proxy:       m.Proxy,
-		RtmpSource:  m.RtmpSource,
-		Type:        m.Type,
-		Headers:     hds,
-		Subtitles:   sbs,
-		VendorInfo:  m.VendorInfo,
-		IsFolder:    m.IsFolder,
-		ParentID:    m.ParentID,
+		Url:            m.Url,
+		MoreSources:    mss,
+		Name:           m.Name,
+		Live:           m.Live,
+		Proxy:          m.Proxy,
+		AutoProxy:      m.AutoProxy,
+		ProxyReason:    m.ProxyReason,
+		RtmpSource:     m.RtmpSource,
+		Type:           m.Type,
+		Headers:        hds,
+		HeaderRules:    hrs,
+		Restriction:    wr,
+		Rating:         m.Rating,
+		HashedPassword: m.HashedPassword,
+		Subtitles:      sbs,
+		VendorInfo:     m.VendorInfo,
+		IsFolder:       m.IsFolder,
+		ParentID:       m.ParentID,
+		Extensions:     m.Extensions.Clone(),
+	}
+}
+
+func (m *MovieBase) NeedPassword() bool {
+	return len(m.HashedPassword) != 0
+}
+
+func (m *MovieBase) CheckPassword(password string) bool {
+	return !m.NeedPassword() || bcrypt.CompareHashAndPassword(m.HashedPassword, stream.StringToBytes(password)) == nil
+}
+
+// HashPassword hashes a requested password into HashedPassword and reports
+// whether the password changed, a nil Password keeps current
+func (m *MovieBase) HashPassword(current []byte) (bool, error) {
+	if m.Password == nil {
+		m.HashedPassword = current
+		return false, nil
+	}
+	password := *m.Password
+	m.Password = nil
+	if password == "" {
+		m.HashedPassword = nil
+		return len(current) != 0, nil
+	}
+	hashed, err := bcrypt.GenerateFromPassword(stream.StringToBytes(password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	m.HashedPassword = hashed
+	return true, nil
+}
+
+// NeedHeaders reports whether the source is requested with headers browsers can not send
+func (m *MovieBase) NeedHeaders() bool {
+	return len(m.Headers) != 0 || len(m.HeaderRules) != 0
+}
+
+const maxWatchRestrictionUsers = 256
+
+// WatchRestriction limits playback of a movie to the listed members and roles,
+// the movie creator and the room creator can always watch.
+// others see the movie locked in the playlist
+type WatchRestriction struct {
+	UserIDs []string         `json:"userIds,omitempty"`
+	Roles   []RoomMemberRole `json:"roles,omitempty"`
+}
+
+func (w *WatchRestriction) Clone() *WatchRestriction {
+	return &WatchRestriction{
+		UserIDs: slices.Clone(w.UserIDs),
+		Roles:   slices.Clone(w.Roles),
+	}
+}
+
+func (w *WatchRestriction) Validate() error {
+	if w == nil {
+		return nil
+	}
+	if len(w.UserIDs) > maxWatchRestrictionUsers {
+		return fmt.Errorf("too many restricted users, max %d", maxWatchRestrictionUsers)
+	}
+	for _, id := range w.UserIDs {
+		if len(id) != 32 {
+			return fmt.Errorf("invalid user id: %q", id)
+		}
+	}
+	for _, r := range w.Roles {
+		if r != RoomMemberRoleMember && r != RoomMemberRoleAdmin {
+			return fmt.Errorf("invalid role: %d", r)
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether a member with the role may watch,
+// guests only match by user id
+func (w *WatchRestriction) Allowed(userID string, role RoomMemberRole, guest bool) bool {
+	if slices.Contains(w.UserIDs, userID) {
+		return true
+	}
+	if guest {
+		return false
+	}
+	for _, r := range w.Roles {
+		switch r {
+		case RoomMemberRoleMember:
+			if role.IsMember() {
+				return true
+			}
+		case RoomMemberRoleAdmin:
+			if role.IsAdmin() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type HeaderRuleAction string
+
+const (
+	// set the header to Value
+	HeaderRuleSet HeaderRuleAction = "set"
+	// remove the header, e.g. strip cookies
+	HeaderRuleStrip HeaderRuleAction = "strip"
+	// copy the header from the request of the viewer
+	HeaderRuleForward HeaderRuleAction = "forward"
+	// set the header to the token of the movie creator's vendor binding,
+	// {token} in Value is replaced with the token
+	HeaderRuleVendorToken HeaderRuleAction = "vendorToken"
+	// set the header to the secret named Secret in the movie creator's vault,
+	// {token} in Value is replaced with the secret
+	HeaderRuleSecret HeaderRuleAction = "secret"
+)
+
+const maxHeaderRules = 32
+
+// headers owned by the proxy
+var reservedHeaders = map[string]bool{
+	"Host":              true,
+	"Range":             true,
+	"Accept-Encoding":   true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Transfer-Encoding": true,
+}
+
+// headers carrying the credentials of the viewer with the server, they are
+// never forwarded to the source
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+}
+
+// HeaderRule rewrites a header of the requests the server sends to the source,
+// rules are applied in order after the headers map
+type HeaderRule struct {
+	Action HeaderRuleAction `json:"action"`
+	Name   string           `json:"name"`
+	Value  string           `json:"value,omitempty"`
+	// vendor and server id of the binding for vendorToken,
+	// bilibili has no server id
+	Vendor VendorName `json:"vendor,omitempty"`
+	Server string     `json:"server,omitempty"`
+	// name of the vault secret for secret
+	Secret string `json:"secret,omitempty"`
+}
+
+func (r *HeaderRule) Validate() error {
+	if !httpguts.ValidHeaderFieldName(r.Name) {
+		return fmt.Errorf("invalid header name: %q", r.Name)
+	}
+	r.Name = textproto.CanonicalMIMEHeaderKey(r.Name)
+	if reservedHeaders[r.Name] {
+		return fmt.Errorf("header %s can not be rewritten", r.Name)
+	}
+	if len(r.Value) > 4096 {
+		return fmt.Errorf("header %s value too long", r.Name)
+	}
+	switch r.Action {
+	case HeaderRuleSet:
+		if !httpguts.ValidHeaderFieldValue(r.Value) {
+			return fmt.Errorf("invalid header value of %s", r.Name)
+		}
+	case HeaderRuleStrip:
+	case HeaderRuleForward:
+		if credentialHeaders[r.Name] {
+			return fmt.Errorf("header %s can not be forwarded", r.Name)
+		}
+	case HeaderRuleVendorToken:
+		switch r.Vendor {
+		case VendorBilibili:
+		case VendorAlist, VendorEmby:
+			if r.Server == "" {
+				return fmt.Errorf("header %s needs the server of the %s binding", r.Name, r.Vendor)
+			}
+		default:
+			return fmt.Errorf("unknown vendor: %s", r.Vendor)
+		}
+	case HeaderRuleSecret:
+		if !secretNameRegexp.MatchString(r.Secret) {
+			return fmt.Errorf("header %s needs the name of a secret", r.Name)
+		}
+	default:
+		return fmt.Errorf("unknown header rule action: %s", r.Action)
 	}
+	return nil
+}
+
+type HeaderRules []*HeaderRule
+
+func (rs HeaderRules) Validate() error {
+	if len(rs) > maxHeaderRules {
+		return fmt.Errorf("too many header rules, max %d", maxHeaderRules)
+	}
+	for _, r := range rs {
+		if r == nil {
+			return fmt.Errorf("header rule is empty")
+		}
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply rewrites dst with the rules, client is the request of the viewer
+// and token resolves the vendor tokens and secrets
+func (rs HeaderRules) Apply(dst, client http.Header, token func(*HeaderRule) (string, error)) error {
+	for _, r := range rs {
+		switch r.Action {
+		case HeaderRuleSet:
+			dst.Set(r.Name, r.Value)
+		case HeaderRuleStrip:
+			dst.Del(r.Name)
+		case HeaderRuleForward:
+			if credentialHeaders[r.Name] {
+				continue
+			}
+			if v := client.Values(r.Name); len(v) != 0 {
+				dst[r.Name] = v
+			}
+		case HeaderRuleVendorToken, HeaderRuleSecret:
+			t, err := token(r)
+			if err != nil {
+				return fmt.Errorf("header %s: %w", r.Name, err)
+			}
+			if r.Value != "" {
+				t = strings.ReplaceAll(r.Value, "{token}", t)
+			}
+			dst.Set(r.Name, t)
+		}
+	}
+	return nil
+}
+
+// Credentials returns the rules sending the credentials of the movie creator
+func (rs HeaderRules) Credentials() HeaderRules {
+	var creds HeaderRules
+	for _, r := range rs {
+		if r.Action == HeaderRuleVendorToken || r.Action == HeaderRuleSecret {
+			creds = append(creds, r)
+		}
+	}
+	return creds
 }
 
 func (m *MovieBase) IsDynamicFolder() bool {
@@ -161,6 +455,11 @@ const (
 	VendorBilibili VendorName = "bilibili"
 	VendorAlist    VendorName = "alist"
 	VendorEmby     VendorName = "emby"
+	VendorDouyin   VendorName = "douyin"
+	VendorAcfun    VendorName = "acfun"
+	VendorYouku    VendorName = "youku"
+	// VendorPlugin movies are resolved by the vendor plugin of their name
+	VendorPlugin VendorName = "plugin"
 )
 
 type VendorInfo struct {
@@ -169,6 +468,10 @@ type VendorInfo struct {
 	Bilibili *BilibiliStreamingInfo `gorm:"embedded;embeddedPrefix:bilibili_" json:"bilibili,omitempty"`
 	Alist    *AlistStreamingInfo    `gorm:"embedded;embeddedPrefix:alist_" json:"alist,omitempty"`
 	Emby     *EmbyStreamingInfo     `gorm:"embedded;embeddedPrefix:emby_" json:"emby,omitempty"`
+	Douyin   *DouyinStreamingInfo   `gorm:"embedded;embeddedPrefix:douyin_" json:"douyin,omitempty"`
+	Acfun    *AcfunStreamingInfo    `gorm:"embedded;embeddedPrefix:acfun_" json:"acfun,omitempty"`
+	Youku    *YoukuStreamingInfo    `gorm:"embedded;embeddedPrefix:youku_" json:"youku,omitempty"`
+	Plugin   *PluginStreamingInfo   `gorm:"embedded;embeddedPrefix:plugin_" json:"plugin,omitempty"`
 }
 
 type BilibiliStreamingInfo struct {
@@ -177,9 +480,14 @@ type BilibiliStreamingInfo struct {
 	Epid    uint64 `json:"epid,omitempty"`
 	Quality uint64 `json:"quality,omitempty"`
 	Shared  bool   `json:"shared,omitempty"`
+	// relay the danmaku of live rooms into the room chat
+	Danmaku bool `json:"danmaku,omitempty"`
 }
 
 func (b *BilibiliStreamingInfo) Validate() error {
+	if b.Danmaku && (b.Epid != 0 || b.Bvid != "") {
+		return fmt.Errorf("danmaku is only available for live rooms")
+	}
 	switch {
 	// 先判断epid是否为0来确定是否是pgc
 	case b.Epid != 0:
@@ -272,6 +580,8 @@ type EmbyStreamingInfo struct {
 	// {/}serverId/ItemId
 	Path      string `gorm:"type:varchar(52)" json:"path,omitempty"`
 	Transcode bool   `json:"transcode,omitempty"`
+	// the item is a live tv channel, played as a live movie through the proxy
+	Channel bool `json:"channel,omitempty"`
 }
 
 func GetEmbyServerIdFromPath(path string) (serverID string, filePath string, err error) {
@@ -309,3 +619,80 @@ func (e *EmbyStreamingInfo) Validate() error {
 	}
 	return nil
 }
+
+const (
+	DouyinPlatformDouyin = "douyin"
+	DouyinPlatformTikTok = "tiktok"
+)
+
+var douyinIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.\-]{1,64}$`)
+
+// DouyinStreamingInfo is a video or, when the movie is live, a live room of
+// douyin or tiktok
+type DouyinStreamingInfo struct {
+	Platform string `gorm:"type:varchar(16)" json:"platform,omitempty"`
+	// video id, web rid of douyin live rooms or the unique id of tiktok users
+	ID string `gorm:"type:varchar(64)" json:"id,omitempty"`
+}
+
+func (d *DouyinStreamingInfo) Validate() error {
+	switch d.Platform {
+	case DouyinPlatformDouyin, DouyinPlatformTikTok:
+	default:
+		return fmt.Errorf("unknown platform: %s", d.Platform)
+	}
+	if !douyinIDRegexp.MatchString(d.ID) {
+		return fmt.Errorf("invalid %s id", d.Platform)
+	}
+	return nil
+}
+
+var acfunIDRegexp = regexp.MustCompile(`^ac\d{1,12}(_\d{1,4})?$`)
+
+// AcfunStreamingInfo is played with the cookies bound by the movie creator
+type AcfunStreamingInfo struct {
+	// ac id with the optional part, like ac12345_2
+	ID string `gorm:"type:varchar(32)" json:"id,omitempty"`
+}
+
+func (a *AcfunStreamingInfo) Validate() error {
+	if !acfunIDRegexp.MatchString(a.ID) {
+		return fmt.Errorf("invalid acfun id")
+	}
+	return nil
+}
+
+var youkuVidRegexp = regexp.MustCompile(`^[a-zA-Z0-9=]{1,32}$`)
+
+// YoukuStreamingInfo is played with the cookies bound by the movie creator
+type YoukuStreamingInfo struct {
+	Vid string `gorm:"type:varchar(32)" json:"vid,omitempty"`
+}
+
+func (y *YoukuStreamingInfo) Validate() error {
+	if !youkuVidRegexp.MatchString(y.Vid) {
+		return fmt.Errorf("invalid youku vid")
+	}
+	return nil
+}
+
+// PluginStreamingInfo is an item of a vendor plugin, the id is opaque to
+// synctv and only passed back to the plugin
+type PluginStreamingInfo struct {
+	Name string `gorm:"type:varchar(64)" json:"name,omitempty"`
+	ID   string `gorm:"type:varchar(256)" json:"id,omitempty"`
+}
+
+func (p *PluginStreamingInfo) Validate() error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("plugin name is empty")
+	case len(p.Name) > 64:
+		return fmt.Errorf("plugin name is too long")
+	case p.ID == "":
+		return fmt.Errorf("plugin item id is empty")
+	case len(p.ID) > 256:
+		return fmt.Errorf("plugin item id is too long")
+	}
+	return nil
+}
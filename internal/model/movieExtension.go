@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ExtensionNamespace groups the extension keys of an integration
+type ExtensionNamespace string
+
+const (
+	// scraped metadata, like plot or poster
+	ExtensionNamespaceMetadata ExtensionNamespace = "metadata"
+	// votes of the room members
+	ExtensionNamespaceVote ExtensionNamespace = "vote"
+	// ids of the movie in other sites, like imdb, tmdb or bvid
+	ExtensionNamespaceExternal ExtensionNamespace = "external"
+)
+
+var extensionNamespaces = []ExtensionNamespace{
+	ExtensionNamespaceMetadata,
+	ExtensionNamespaceVote,
+	ExtensionNamespaceExternal,
+}
+
+const (
+	maxMovieExtensions    = 64
+	maxMovieExtensionSize = 4096
+)
+
+var extensionNameRegexp = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// MovieExtensions is data attached to a movie by integrations, keyed by
+// namespace.name. it is read only for the playlist api
+type MovieExtensions map[string]json.RawMessage
+
+func ExtensionKey(ns ExtensionNamespace, name string) string {
+	return string(ns) + "." + name
+}
+
+func validateExtensionKey(key string) error {
+	ns, name, ok := strings.Cut(key, ".")
+	if !ok || !slices.Contains(extensionNamespaces, ExtensionNamespace(ns)) {
+		return fmt.Errorf("unknown extension namespace of %q", key)
+	}
+	if !extensionNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid extension name of %q", key)
+	}
+	return nil
+}
+
+func (e MovieExtensions) Validate() error {
+	if len(e) > maxMovieExtensions {
+		return fmt.Errorf("too many extensions, max %d", maxMovieExtensions)
+	}
+	for k, v := range e {
+		if err := validateExtensionKey(k); err != nil {
+			return err
+		}
+		if len(v) > maxMovieExtensionSize {
+			return fmt.Errorf("extension %s too large", k)
+		}
+		if !json.Valid(v) {
+			return fmt.Errorf("extension %s is not valid json", k)
+		}
+	}
+	return nil
+}
+
+func (e MovieExtensions) Clone() MovieExtensions {
+	if e == nil {
+		return nil
+	}
+	return maps.Clone(e)
+}
+
+// Get decodes the extension into v and reports whether it exists
+func (e MovieExtensions) Get(ns ExtensionNamespace, name string, v any) (bool, error) {
+	raw, ok := e[ExtensionKey(ns, name)]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Set encodes v into the extension, a nil v removes it
+func (e *MovieExtensions) Set(ns ExtensionNamespace, name string, v any) error {
+	key := ExtensionKey(ns, name)
+	if err := validateExtensionKey(key); err != nil {
+		return err
+	}
+	if v == nil {
+		delete(*e, key)
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(raw) > maxMovieExtensionSize {
+		return fmt.Errorf("extension %s too large", key)
+	}
+	if _, ok := (*e)[key]; !ok && len(*e) >= maxMovieExtensions {
+		return fmt.Errorf("too many extensions, max %d", maxMovieExtensions)
+	}
+	if *e == nil {
+		*e = make(MovieExtensions)
+	}
+	(*e)[key] = raw
+	return nil
+}
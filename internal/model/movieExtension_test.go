@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestMovieExtensionsSetGet(t *testing.T) {
+	var e model.MovieExtensions
+	if err := e.Set(model.ExtensionNamespaceExternal, "imdb", "tt0111161"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	var id string
+	ok, err := e.Get(model.ExtensionNamespaceExternal, "imdb", &id)
+	if err != nil || !ok || id != "tt0111161" {
+		t.Fatalf("Get() = %q, %v, %v, want tt0111161", id, ok, err)
+	}
+	if err := e.Set(model.ExtensionNamespaceExternal, "imdb", nil); err != nil {
+		t.Fatalf("Set(nil) error = %v", err)
+	}
+	if ok, _ := e.Get(model.ExtensionNamespaceExternal, "imdb", &id); ok {
+		t.Error("extension should be removed")
+	}
+	if err := e.Set("scraper", "plot", "x"); err == nil {
+		t.Error("unknown namespace should be rejected")
+	}
+	if err := e.Set(model.ExtensionNamespaceMetadata, "Plot", "x"); err == nil {
+		t.Error("upper case name should be rejected")
+	}
+}
+
+func TestMovieExtensionsValidate(t *testing.T) {
+	valid := model.MovieExtensions{
+		"vote.score": json.RawMessage(`4.5`),
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	invalid := model.MovieExtensions{
+		"vote.score": json.RawMessage(`{`),
+	}
+	if err := invalid.Validate(); err == nil {
+		t.Error("invalid json should be rejected")
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// TrashedMovie is a deleted movie kept until it expires. The movies removed
+// by one deletion, with the descendants of deleted folders, share a batch
+// and are restored together
+type TrashedMovie struct {
+	// the id of the movie, restored movies keep it
+	ID        string    `gorm:"primaryKey;type:char(32)"`
+	BatchID   string    `gorm:"not null;index;type:char(32)"`
+	RoomID    string    `gorm:"not null;index;type:char(32)"`
+	TrashedAt time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	TrashedBy string    `gorm:"type:char(32)"`
+
+	MovieCreatedAt time.Time
+	Position       uint   `gorm:"not null"`
+	CreatorID      string `gorm:"type:char(32)"`
+	Version        uint64 `gorm:"not null;default:1"`
+	MovieBase      `gorm:"embedded;embeddedPrefix:base_"`
+}
+
+func NewTrashedMovie(m *Movie, batchID, trashedBy string, trashedAt, expiresAt time.Time) *TrashedMovie {
+	return &TrashedMovie{
+		ID:             m.ID,
+		BatchID:        batchID,
+		RoomID:         m.RoomID,
+		TrashedAt:      trashedAt,
+		ExpiresAt:      expiresAt,
+		TrashedBy:      trashedBy,
+		MovieCreatedAt: m.CreatedAt,
+		Position:       m.Position,
+		CreatorID:      m.CreatorID,
+		Version:        m.Version,
+		MovieBase:      m.MovieBase,
+	}
+}
+
+// Movie returns the movie as it was before it was deleted
+func (t *TrashedMovie) Movie() *Movie {
+	return &Movie{
+		ID:        t.ID,
+		CreatedAt: t.MovieCreatedAt,
+		Position:  t.Position,
+		RoomID:    t.RoomID,
+		CreatorID: t.CreatorID,
+		Version:   t.Version,
+		MovieBase: t.MovieBase,
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type NotificationDriver string
+
+const (
+	NotificationDriverWebPush NotificationDriver = "webpush"
+	NotificationDriverGotify  NotificationDriver = "gotify"
+	NotificationDriverNtfy    NotificationDriver = "ntfy"
+)
+
+type NotificationSubscription struct {
+	ID        string `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt time.Time
+	UserID    string             `gorm:"not null;index;type:char(32)"`
+	Driver    NotificationDriver `gorm:"not null;type:varchar(16)"`
+	// webpush: push service endpoint
+	// gotify: server url
+	// ntfy: topic url
+	Endpoint string `gorm:"not null;type:varchar(1024)"`
+	// gotify: application token
+	// ntfy: access token
+	Token string `gorm:"type:varchar(256)"`
+	// webpush: client public key and auth secret
+	P256dh string `gorm:"type:varchar(128)"`
+	Auth   string `gorm:"type:varchar(64)"`
+}
+
+func (n *NotificationSubscription) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == "" {
+		n.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+type NotificationPreference struct {
+	UserID         string    `gorm:"primaryKey;type:char(32)" json:"-"`
+	UpdatedAt      time.Time `json:"-"`
+	RoomLive       bool      `gorm:"default:true" json:"room_live"`
+	ScheduledMovie bool      `gorm:"default:true" json:"scheduled_movie"`
+	MovieComment   bool      `gorm:"default:true" json:"movie_comment"`
+	NewLogin       bool      `gorm:"default:true" json:"new_login"`
+}
+
+func DefaultNotificationPreference() *NotificationPreference {
+	return &NotificationPreference{
+		RoomLive:       true,
+		ScheduledMovie: true,
+		MovieComment:   true,
+		NewLogin:       true,
+	}
+}
+
+type RoomFollow struct {
+	CreatedAt time.Time
+	UserID    string `gorm:"primarykey;type:char(32)"`
+	RoomID    string `gorm:"primarykey;type:char(32);index"`
+}
@@ -4,12 +4,75 @@ import (
 	"time"
 
 	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
 )
 
 type UserProvider struct {
-	Provider       provider.OAuth2Provider `gorm:"primarykey;type:varchar(32);uniqueIndex:idx_provider_user_id"`
+	Provider       provider.OAuth2Provider `gorm:"primarykey;type:varchar(64);uniqueIndex:idx_provider_user_id"`
 	ProviderUserID string                  `gorm:"primarykey;type:varchar(64)"`
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	UserID         string `gorm:"not null;type:char(32);uniqueIndex:idx_provider_user_id"`
+	// kept for providers checking memberships, encrypted with the provider
+	// token keyring or with the user id if it has no keys
+	AccessToken  string `gorm:"type:text"`
+	RefreshToken string `gorm:"type:text"`
+	TokenExpiry  time.Time
+	// id of the provider token key the tokens are encrypted with
+	TokenKeyID string `gorm:"index;type:varchar(16)"`
+}
+
+var ProviderTokenKeyring = NewKeyring("provider token")
+
+func (p *UserProvider) BeforeSave(tx *gorm.DB) error {
+	p.TokenKeyID = ProviderTokenKeyring.CurrentID()
+	var err error
+	if p.AccessToken, err = p.encrypt(p.AccessToken); err != nil {
+		return err
+	}
+	if p.RefreshToken, err = p.encrypt(p.RefreshToken); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *UserProvider) AfterSave(tx *gorm.DB) error {
+	var err error
+	if p.AccessToken, err = p.decrypt(p.AccessToken); err != nil {
+		return err
+	}
+	if p.RefreshToken, err = p.decrypt(p.RefreshToken); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *UserProvider) AfterFind(tx *gorm.DB) error {
+	return p.AfterSave(tx)
+}
+
+func (p *UserProvider) encrypt(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	if p.TokenKeyID != "" {
+		v, _, err := ProviderTokenKeyring.Encrypt(p.UserID, v)
+		return v, err
+	}
+	return utils.CryptoToBase64([]byte(v), utils.GenCryptoKey(p.UserID))
+}
+
+func (p *UserProvider) decrypt(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	if p.TokenKeyID != "" {
+		return ProviderTokenKeyring.Decrypt(p.UserID, p.TokenKeyID, v)
+	}
+	b, err := utils.DecryptoFromBase64(v, utils.GenCryptoKey(p.UserID))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
 }
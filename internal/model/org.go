@@ -0,0 +1,103 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// Organization hosts a community on the server, its rooms live in their own
+// name namespace and are only listed in its directory
+type Organization struct {
+	ID          string    `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Name        string    `gorm:"not null;uniqueIndex;type:varchar(32)" json:"name"`
+	DisplayName string    `gorm:"type:varchar(64)" json:"displayName"`
+	Description string    `gorm:"type:text" json:"description"`
+	LogoURL     string    `gorm:"type:varchar(512)" json:"logoUrl"`
+	AccentColor string    `gorm:"type:varchar(16)" json:"accentColor"`
+	// listed organizations show their rooms to anyone in the public directory
+	Listed bool `gorm:"not null;default:false" json:"listed"`
+	// 0 is unlimited
+	MaxRooms   int64                 `gorm:"not null;default:0" json:"maxRooms"`
+	MaxMembers int64                 `gorm:"not null;default:0" json:"maxMembers"`
+	InviteCode string                `gorm:"index;type:varchar(32)" json:"-"`
+	Members    []*OrganizationMember `gorm:"foreignKey:OrgID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	Provider   *OrgProvider          `gorm:"foreignKey:OrgID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+}
+
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = utils.SortUUID()
+	}
+	if o.InviteCode == "" {
+		o.InviteCode = utils.SortUUID()
+	}
+	return nil
+}
+
+type OrgRole uint8
+
+const (
+	OrgRoleUnknown OrgRole = iota
+	OrgRoleMember
+	OrgRoleAdmin
+)
+
+func (r OrgRole) String() string {
+	switch r {
+	case OrgRoleMember:
+		return "member"
+	case OrgRoleAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+func (r OrgRole) IsMember() bool {
+	return r == OrgRoleMember || r == OrgRoleAdmin
+}
+
+func (r OrgRole) IsAdmin() bool {
+	return r == OrgRoleAdmin
+}
+
+type OrganizationMember struct {
+	OrgID     string    `gorm:"primaryKey;type:char(32)" json:"orgId"`
+	UserID    string    `gorm:"primaryKey;index;type:char(32)" json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Role      OrgRole   `gorm:"not null;default:1" json:"role"`
+}
+
+// OrgProvider is the oauth2 or oidc identity provider of an organization
+type OrgProvider struct {
+	OrgID     string `gorm:"primaryKey;type:char(32)"`
+	UpdatedAt time.Time
+	Enabled   bool `gorm:"not null;default:false"`
+	// only users bound to the provider join rooms of the organization
+	RestrictRoomLogin bool   `gorm:"not null;default:false"`
+	ClientID          string `gorm:"type:varchar(256)"`
+	ClientSecret      string `gorm:"type:varchar(256)"`
+	RedirectURL       string `gorm:"type:varchar(1024)"`
+	AuthURL           string `gorm:"type:varchar(1024)"`
+	TokenURL          string `gorm:"type:varchar(1024)"`
+	UserInfoURL       string `gorm:"type:varchar(1024)"`
+	// comma separated
+	Scopes        string `gorm:"type:varchar(256)"`
+	IDField       string `gorm:"type:varchar(64)"`
+	UsernameField string `gorm:"type:varchar(64)"`
+}
+
+// NewOrgProvider returns the disabled provider of an organization that has
+// not configured one
+func NewOrgProvider(orgID string) *OrgProvider {
+	return &OrgProvider{
+		OrgID:         orgID,
+		Scopes:        "openid,profile",
+		IDField:       "sub",
+		UsernameField: "preferred_username",
+	}
+}
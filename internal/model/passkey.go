@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// UserPasskey is a webauthn credential the user signs in with
+type UserPasskey struct {
+	ID        string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
+	UserID    string `gorm:"not null;index;type:char(32)"`
+	// base64url of the sha256 of the credential id, credential ids are too
+	// long to index
+	CredentialHash string `gorm:"not null;uniqueIndex;type:char(43)"`
+	CredentialID   []byte `gorm:"not null"`
+	// cose key
+	PublicKey      []byte `gorm:"not null"`
+	SignCount      uint32
+	BackupEligible bool
+	Transports     string `gorm:"type:varchar(128)"`
+	Name           string `gorm:"not null;type:varchar(64)"`
+	LastUsedAt     *time.Time
+}
+
+func (p *UserPasskey) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = utils.SortUUID()
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// MoviePoll chooses the next movie from a shortlist with ranked choice
+// ballots, the winner is queued as the current movie when it closes
+type MoviePoll struct {
+	ID        string `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt time.Time
+	RoomID    string `gorm:"not null;index;type:char(32)"`
+	CreatorID string `gorm:"type:char(32)"`
+	Title     string `gorm:"not null;type:varchar(128)"`
+	// movie ids, the order breaks the last ties
+	Candidates []string  `gorm:"serializer:fastjson;type:text"`
+	CloseAt    time.Time `gorm:"not null;index"`
+	Closed     bool      `gorm:"not null;default:false;index"`
+	// empty if nobody voted
+	WinnerID string             `gorm:"type:char(32)"`
+	Ballots  []*MoviePollBallot `gorm:"foreignKey:PollID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+func (p *MoviePoll) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+func (p *MoviePoll) IsOpen(now time.Time) bool {
+	return !p.Closed && now.Before(p.CloseAt)
+}
+
+type MoviePollBallot struct {
+	PollID    string `gorm:"primaryKey;type:char(32)"`
+	UserID    string `gorm:"primaryKey;type:char(32)"`
+	UpdatedAt time.Time
+	// candidates from the most to the least preferred
+	Ranking []string `gorm:"serializer:fastjson;type:text"`
+}
+
+// RankingOf keeps the candidates of the ranking in order without duplicates
+func RankingOf(ranking, candidates []string) []string {
+	r := make([]string, 0, len(ranking))
+	for _, c := range ranking {
+		if slices.Contains(candidates, c) && !slices.Contains(r, c) {
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+type RankedChoiceRound struct {
+	// first preferences of the continuing candidates
+	Tally      map[string]int `json:"tally"`
+	Eliminated string         `json:"eliminated,omitempty"`
+}
+
+type RankedChoiceResult struct {
+	Winner string               `json:"winner"`
+	Rounds []*RankedChoiceRound `json:"rounds"`
+}
+
+// RankedChoice counts the ballots by instant runoff. each round the candidate
+// with the fewest first preferences is eliminated until one has a majority of
+// the continuing ballots. ties are broken by the borda score of the full
+// rankings, and then by the order of the candidates. the winner is empty
+// when there are no ballots
+func RankedChoice(candidates []string, ballots [][]string) *RankedChoiceResult {
+	result := &RankedChoiceResult{}
+	rankings := make([][]string, 0, len(ballots))
+	for _, b := range ballots {
+		if r := RankingOf(b, candidates); len(r) != 0 {
+			rankings = append(rankings, r)
+		}
+	}
+	if len(rankings) == 0 {
+		return result
+	}
+
+	borda := make(map[string]int, len(candidates))
+	for _, r := range rankings {
+		for i, c := range r {
+			borda[c] += len(candidates) - i
+		}
+	}
+	// whether a is ranked before b when breaking ties
+	before := func(a, b string) bool {
+		if borda[a] != borda[b] {
+			return borda[a] > borda[b]
+		}
+		return slices.Index(candidates, a) < slices.Index(candidates, b)
+	}
+
+	continuing := slices.Clone(candidates)
+	for {
+		round := &RankedChoiceRound{Tally: make(map[string]int, len(continuing))}
+		result.Rounds = append(result.Rounds, round)
+		for _, c := range continuing {
+			round.Tally[c] = 0
+		}
+		total := 0
+		for _, r := range rankings {
+			for _, c := range r {
+				if _, ok := round.Tally[c]; ok {
+					round.Tally[c]++
+					total++
+					break
+				}
+			}
+		}
+
+		var leader, loser string
+		for _, c := range continuing {
+			if leader == "" || round.Tally[c] > round.Tally[leader] ||
+				(round.Tally[c] == round.Tally[leader] && before(c, leader)) {
+				leader = c
+			}
+			if loser == "" || round.Tally[c] < round.Tally[loser] ||
+				(round.Tally[c] == round.Tally[loser] && before(loser, c)) {
+				loser = c
+			}
+		}
+		if len(continuing) == 1 || round.Tally[leader]*2 > total {
+			result.Winner = leader
+			return result
+		}
+		round.Eliminated = loser
+		continuing = slices.DeleteFunc(continuing, func(c string) bool {
+			return c == loser
+		})
+	}
+}
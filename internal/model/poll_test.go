@@ -0,0 +1,58 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestRankedChoice(t *testing.T) {
+	candidates := []string{"a", "b", "c"}
+	tests := []struct {
+		name    string
+		ballots [][]string
+		want    string
+	}{
+		{
+			name: "no ballots",
+			want: "",
+		},
+		{
+			name:    "first round majority",
+			ballots: [][]string{{"a"}, {"a", "b"}, {"b"}},
+			want:    "a",
+		},
+		{
+			name: "runoff transfers votes",
+			ballots: [][]string{
+				{"a"}, {"a"},
+				{"b", "c"}, {"b", "c"},
+				{"c", "b"},
+			},
+			want: "b",
+		},
+		{
+			name:    "elimination tie broken by borda score",
+			ballots: [][]string{{"a", "c"}, {"b", "c"}, {"c", "b"}},
+			want:    "c",
+		},
+		{
+			name:    "tie broken by candidate order",
+			ballots: [][]string{{"b"}, {"a"}},
+			want:    "a",
+		},
+		{
+			name:    "unknown and duplicate candidates are ignored",
+			ballots: [][]string{{"x", "b", "b"}, {"b"}, {"a"}},
+			want:    "b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := model.RankedChoice(candidates, tt.ballots)
+			if got.Winner != tt.want {
+				t.Errorf("RankedChoice() winner = %q, want %q", got.Winner, tt.want)
+			}
+		})
+	}
+}
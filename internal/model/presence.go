@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// PresencePreference controls what the rich presence document of a user
+// reveals to companion apps like a discord rich presence helper
+type PresencePreference struct {
+	UserID    string    `gorm:"primaryKey;type:char(32)" json:"-"`
+	UpdatedAt time.Time `json:"-"`
+	Enabled   bool      `gorm:"default:true" json:"enabled"`
+	ShowRoom  bool      `gorm:"default:true" json:"show_room"`
+	ShowMovie bool      `gorm:"default:true" json:"show_movie"`
+	// elapsed and total time of the movie
+	ShowProgress bool `gorm:"default:true" json:"show_progress"`
+	// show the room and movie of hidden or password protected rooms
+	ShowPrivateRooms bool `gorm:"default:false" json:"show_private_rooms"`
+}
+
+func DefaultPresencePreference() *PresencePreference {
+	return &PresencePreference{
+		Enabled:      true,
+		ShowRoom:     true,
+		ShowMovie:    true,
+		ShowProgress: true,
+	}
+}
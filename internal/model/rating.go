@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ContentRating is the audience rating of a movie, ordered from g to nc17.
+// the empty rating is unrated on movies and unlimited on maximum ratings
+type ContentRating string
+
+const (
+	ContentRatingUnrated ContentRating = ""
+	// all ages
+	ContentRatingG ContentRating = "g"
+	// parental guidance
+	ContentRatingPG ContentRating = "pg"
+	// 13 and older
+	ContentRatingPG13 ContentRating = "pg13"
+	// 17 and older, or with a parent
+	ContentRatingR ContentRating = "r"
+	// adults only
+	ContentRatingNC17 ContentRating = "nc17"
+)
+
+var contentRatingLevels = map[ContentRating]int{
+	ContentRatingG:    1,
+	ContentRatingPG:   2,
+	ContentRatingPG13: 3,
+	ContentRatingR:    4,
+	ContentRatingNC17: 5,
+}
+
+// Level orders the ratings, unrated is 0
+func (r ContentRating) Level() int {
+	return contentRatingLevels[r]
+}
+
+func (r ContentRating) Validate() error {
+	if r == ContentRatingUnrated {
+		return nil
+	}
+	if _, ok := contentRatingLevels[r]; !ok {
+		return fmt.Errorf("invalid content rating: %q", string(r))
+	}
+	return nil
+}
+
+// Allows reports whether a movie rated movie passes the maximum rating r,
+// unrated movies pass unless blockUnrated
+func (r ContentRating) Allows(movie ContentRating, blockUnrated bool) bool {
+	if r == ContentRatingUnrated {
+		return true
+	}
+	if movie == ContentRatingUnrated {
+		return !blockUnrated
+	}
+	return movie.Level() <= r.Level()
+}
+
+// Stricter returns the lower of the maximum ratings r and o
+func (r ContentRating) Stricter(o ContentRating) ContentRating {
+	switch {
+	case r == ContentRatingUnrated:
+		return o
+	case o == ContentRatingUnrated:
+		return r
+	case o.Level() < r.Level():
+		return o
+	default:
+		return r
+	}
+}
+
+var officialRatings = map[string]ContentRating{
+	"g":     ContentRatingG,
+	"tvy":   ContentRatingG,
+	"tvg":   ContentRatingG,
+	"u":     ContentRatingG,
+	"all":   ContentRatingG,
+	"pg":    ContentRatingPG,
+	"tvy7":  ContentRatingPG,
+	"tvpg":  ContentRatingPG,
+	"pg13":  ContentRatingPG13,
+	"tv14":  ContentRatingPG13,
+	"12a":   ContentRatingPG13,
+	"r":     ContentRatingR,
+	"tvma":  ContentRatingR,
+	"nc17":  ContentRatingNC17,
+	"x":     ContentRatingNC17,
+	"xxx":   ContentRatingNC17,
+	"adult": ContentRatingNC17,
+}
+
+// ParseContentRating maps an official rating as found in media metadata,
+// like PG-13, TV-MA, FSK 16, 18+ or US-R, to a content rating
+func ParseContentRating(s string) (ContentRating, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if r, ok := parseContentRating(s); ok {
+		return r, true
+	}
+	// country prefixed, like de-16
+	if len(s) > 3 && strings.ContainsRune("-/: ", rune(s[2])) {
+		return parseContentRating(s[3:])
+	}
+	return ContentRatingUnrated, false
+}
+
+var ratingReplacer = strings.NewReplacer("-", "", " ", "", "_", "", "+", "", "fsk", "", "usk", "", "pegi", "")
+
+func parseContentRating(s string) (ContentRating, bool) {
+	s = ratingReplacer.Replace(s)
+	if s == "" {
+		return ContentRatingUnrated, false
+	}
+	if r, ok := officialRatings[s]; ok {
+		return r, true
+	}
+	if r := ContentRating(s); r.Validate() == nil {
+		return r, true
+	}
+	// minimum ages
+	age, err := strconv.Atoi(s)
+	if err != nil || age < 0 {
+		return ContentRatingUnrated, false
+	}
+	switch {
+	case age < 7:
+		return ContentRatingG, true
+	case age < 13:
+		return ContentRatingPG, true
+	case age < 17:
+		return ContentRatingPG13, true
+	case age < 18:
+		return ContentRatingR, true
+	default:
+		return ContentRatingNC17, true
+	}
+}
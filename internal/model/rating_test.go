@@ -0,0 +1,52 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestParseContentRating(t *testing.T) {
+	tests := []struct {
+		in   string
+		want model.ContentRating
+		ok   bool
+	}{
+		{"G", model.ContentRatingG, true},
+		{"PG-13", model.ContentRatingPG13, true},
+		{"US-PG-13", model.ContentRatingPG13, true},
+		{"TV-MA", model.ContentRatingR, true},
+		{"TV-Y7", model.ContentRatingPG, true},
+		{"FSK 16", model.ContentRatingPG13, true},
+		{"DE-18", model.ContentRatingNC17, true},
+		{"18+", model.ContentRatingNC17, true},
+		{"nc17", model.ContentRatingNC17, true},
+		{"0", model.ContentRatingG, true},
+		{"", model.ContentRatingUnrated, false},
+		{"Not Rated", model.ContentRatingUnrated, false},
+	}
+	for _, tt := range tests {
+		got, ok := model.ParseContentRating(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseContentRating(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestContentRatingAllows(t *testing.T) {
+	if !model.ContentRatingUnrated.Allows(model.ContentRatingNC17, true) {
+		t.Error("unlimited maximum should allow every rating")
+	}
+	if !model.ContentRatingPG13.Allows(model.ContentRatingPG, false) {
+		t.Error("pg13 maximum should allow pg")
+	}
+	if model.ContentRatingPG13.Allows(model.ContentRatingR, false) {
+		t.Error("pg13 maximum should not allow r")
+	}
+	if model.ContentRatingPG.Allows(model.ContentRatingUnrated, true) {
+		t.Error("unrated should be blocked when blocking unrated")
+	}
+	if got := model.ContentRatingR.Stricter(model.ContentRatingPG); got != model.ContentRatingPG {
+		t.Errorf("Stricter() = %q, want pg", got)
+	}
+}
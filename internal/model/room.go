@@ -31,16 +31,29 @@ func (r RoomStatus) String() string {
 }
 
 type Room struct {
-	ID                 string `gorm:"primaryKey;type:char(32)" json:"id"`
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
-	Status             RoomStatus    `gorm:"not null;default:2"`
-	Name               string        `gorm:"not null;uniqueIndex;type:varchar(32)"`
+	ID        string `gorm:"primaryKey;type:char(32)" json:"id"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Status    RoomStatus `gorm:"not null;default:2"`
+	Name      string     `gorm:"not null;uniqueIndex:idx_rooms_org_name;type:varchar(32)"`
+	// rooms of an organization have their own name namespace, empty is the
+	// namespace of the server
+	OrgID              string        `gorm:"not null;default:'';uniqueIndex:idx_rooms_org_name;type:varchar(32)"`
 	Settings           *RoomSettings `gorm:"foreignKey:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"settings"`
 	CreatorID          string        `gorm:"index;type:char(32)"`
 	HashedPassword     []byte
-	GroupUserRelations []*RoomMember `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Movies             []*Movie      `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	GroupUserRelations []*RoomMember        `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Movies             []*Movie             `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	TrashedMovies      []*TrashedMovie      `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	PinnedChatMessages []*PinnedChatMessage `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Audits             []*RoomAudit         `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Followers          []*RoomFollow        `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Events             []*RoomEvent         `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	LiveSessions       []*LiveSession       `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Mirror             *RoomMirror          `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ActivityPubNotes   []*ActivityPubNote   `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	AlistWatches       []*AlistWatch        `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ArrWebhooks        []*ArrWebhook        `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 func (r *Room) BeforeCreate(tx *gorm.DB) error {
@@ -87,6 +100,32 @@ type RoomSettings struct {
 	CanSetCurrentMovie  bool `gorm:"default:true" json:"can_set_current_movie"`
 	CanSetCurrentStatus bool `gorm:"default:true" json:"can_set_current_status"`
 	CanSendChatMessage  bool `gorm:"default:true" json:"can_send_chat_message"`
+
+	DisableTypingIndicator bool `gorm:"default:false" json:"disable_typing_indicator"`
+	DisableReadReceipt     bool `gorm:"default:false" json:"disable_read_receipt"`
+
+	DisableSharePreview bool `gorm:"default:false" json:"disable_share_preview"`
+
+	// members other than the room creator and admins can not watch movies rated above
+	MaxContentRating ContentRating `gorm:"type:varchar(8);default:''" json:"max_content_rating"`
+
+	// only users bound to the provider who belong to the group can join,
+	// the group is a github org or a discord guild id
+	JoinProvider      string `gorm:"type:varchar(32);default:''" json:"join_provider"`
+	JoinProviderGroup string `gorm:"type:varchar(128);default:''" json:"join_provider_group"`
+
+	SyncProfile SyncProfile `gorm:"type:varchar(16);default:'balanced'" json:"sync_profile"`
+	// distribute media timestamp anchors to clients supporting them,
+	// for rooms needing sub 100ms sync like music videos
+	PreciseSync bool `gorm:"default:false" json:"precise_sync"`
+	// serve live movies as low latency hls by default, hls and flv stay
+	// available as more sources
+	LowLatencyLive bool `gorm:"default:false" json:"low_latency_live"`
+
+	// shown in room lists and searched with the room name
+	Description string `gorm:"type:text" json:"description"`
+	// comma separated, lowercase
+	Tags string `gorm:"type:varchar(512);default:''" json:"tags"`
 }
 
 func DefaultRoomSettings() *RoomSettings {
@@ -105,5 +144,14 @@ func DefaultRoomSettings() *RoomSettings {
 		CanSetCurrentMovie:  true,
 		CanSetCurrentStatus: true,
 		CanSendChatMessage:  true,
+
+		DisableTypingIndicator: false,
+		DisableReadReceipt:     false,
+
+		DisableSharePreview: false,
+
+		MaxContentRating: ContentRatingUnrated,
+
+		SyncProfile: SyncProfileBalanced,
 	}
 }
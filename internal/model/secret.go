@@ -0,0 +1,122 @@
+package model
+
+import (
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"golang.org/x/net/http/httpguts"
+	"gorm.io/gorm"
+)
+
+type UserSecretKind string
+
+const (
+	// referenced by header rules, {token} in the rule value is replaced with it
+	UserSecretToken UserSecretKind = "token"
+	// sent as the Cookie header
+	UserSecretCookie UserSecretKind = "cookie"
+	// sent as the header named Header
+	UserSecretHeader UserSecretKind = "header"
+)
+
+var secretNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\-.]{1,64}$`)
+
+// UserSecret is a named token, cookie or header in the vault of a user,
+// movies the user creates reference it by name so it is rotated in one place.
+// the value is encrypted at rest
+type UserSecret struct {
+	ID        string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    string         `gorm:"not null;uniqueIndex:idx_user_secret_name;type:char(32)"`
+	Name      string         `gorm:"not null;uniqueIndex:idx_user_secret_name;type:varchar(64)"`
+	Kind      UserSecretKind `gorm:"not null;type:varchar(16)"`
+	Header    string         `gorm:"type:varchar(256)"`
+	// cookie and header secrets are sent by default to sources on this host
+	DefaultHost string `gorm:"type:varchar(256)"`
+	Value       string `gorm:"not null;type:text"`
+}
+
+func (s *UserSecret) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+func (s *UserSecret) BeforeSave(tx *gorm.DB) error {
+	var err error
+	s.Value, err = utils.CryptoToBase64([]byte(s.Value), utils.GenCryptoKey(s.UserID))
+	return err
+}
+
+func (s *UserSecret) AfterSave(tx *gorm.DB) error {
+	v, err := utils.DecryptoFromBase64(s.Value, utils.GenCryptoKey(s.UserID))
+	if err != nil {
+		return err
+	}
+	s.Value = string(v)
+	return nil
+}
+
+func (s *UserSecret) AfterFind(tx *gorm.DB) error {
+	return s.AfterSave(tx)
+}
+
+// HeaderName returns the header the secret is sent as, empty for tokens
+func (s *UserSecret) HeaderName() string {
+	switch s.Kind {
+	case UserSecretCookie:
+		return "Cookie"
+	case UserSecretHeader:
+		return s.Header
+	default:
+		return ""
+	}
+}
+
+// IsDefaultFor reports whether the secret is sent to sources on host
+func (s *UserSecret) IsDefaultFor(host string) bool {
+	return s.DefaultHost != "" && s.HeaderName() != "" && strings.EqualFold(s.DefaultHost, host)
+}
+
+func (s *UserSecret) Validate() error {
+	if !secretNameRegexp.MatchString(s.Name) {
+		return fmt.Errorf("invalid secret name: %q", s.Name)
+	}
+	switch s.Kind {
+	case UserSecretToken, UserSecretCookie:
+		s.Header = ""
+	case UserSecretHeader:
+		if !httpguts.ValidHeaderFieldName(s.Header) {
+			return fmt.Errorf("invalid header name: %q", s.Header)
+		}
+		s.Header = textproto.CanonicalMIMEHeaderKey(s.Header)
+		if reservedHeaders[s.Header] {
+			return fmt.Errorf("header %s can not be set", s.Header)
+		}
+	default:
+		return fmt.Errorf("unknown secret kind: %s", s.Kind)
+	}
+	if s.Kind == UserSecretToken {
+		s.DefaultHost = ""
+	}
+	if len(s.DefaultHost) > 256 {
+		return fmt.Errorf("default host too long")
+	}
+	s.DefaultHost = strings.ToLower(s.DefaultHost)
+	if s.Value == "" {
+		return fmt.Errorf("secret value is empty")
+	}
+	if len(s.Value) > 4096 {
+		return fmt.Errorf("secret value too long")
+	}
+	if !httpguts.ValidHeaderFieldValue(s.Value) {
+		return fmt.Errorf("invalid secret value")
+	}
+	return nil
+}
@@ -14,14 +14,19 @@ const (
 type SettingGroup = string
 
 const (
-	SettingGroupRoom     SettingGroup = "room"
-	SettingGroupUser     SettingGroup = "user"
-	SettingGroupProxy    SettingGroup = "proxy"
-	SettingGroupRtmp     SettingGroup = "rtmp"
-	SettingGroupDatabase SettingGroup = "database"
-	SettingGroupServer   SettingGroup = "server"
-	SettingGroupOauth2   SettingGroup = "oauth2"
-	SettingGroupEmail    SettingGroup = "email"
+	SettingGroupRoom        SettingGroup = "room"
+	SettingGroupUser        SettingGroup = "user"
+	SettingGroupProxy       SettingGroup = "proxy"
+	SettingGroupRtmp        SettingGroup = "rtmp"
+	SettingGroupDatabase    SettingGroup = "database"
+	SettingGroupServer      SettingGroup = "server"
+	SettingGroupOauth2      SettingGroup = "oauth2"
+	SettingGroupEmail       SettingGroup = "email"
+	SettingGroupNotify      SettingGroup = "notify"
+	SettingGroupTranscode   SettingGroup = "transcode"
+	SettingGroupActivityPub SettingGroup = "activitypub"
+	SettingGroupMedia       SettingGroup = "media"
+	SettingGroupVendor      SettingGroup = "vendor"
 )
 
 type Setting struct {
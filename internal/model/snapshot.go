@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// RoomSnapshot is a read only record of a finished session, published so
+// people who missed it can replay it with the chat at the original offsets
+type RoomSnapshot struct {
+	ID        string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt time.Time
+	RoomID    string            `gorm:"not null;index;type:char(32)"`
+	CreatorID string            `gorm:"type:char(32)"`
+	Title     string            `gorm:"not null;type:varchar(128)"`
+	StartAt   time.Time         `gorm:"not null"`
+	EndAt     time.Time         `gorm:"not null"`
+	Playlist  []*SnapshotMovie  `gorm:"serializer:fastjson;type:text"`
+	Chat      []*SnapshotChat   `gorm:"serializer:fastjson;type:text"`
+	Timeline  []*SnapshotStatus `gorm:"serializer:fastjson;type:text"`
+	// id of the chat key the chat messages are encrypted with, empty if stored in plain
+	ChatKeyID string `gorm:"index;type:varchar(16)"`
+}
+
+func (r *RoomSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+func (r *RoomSnapshot) BeforeSave(tx *gorm.DB) error {
+	r.ChatKeyID = CurrentChatKeyID()
+	for _, c := range r.Chat {
+		var err error
+		if c.Message, _, err = EncryptChatMessage(r.RoomID, c.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RoomSnapshot) AfterSave(tx *gorm.DB) error {
+	for _, c := range r.Chat {
+		var err error
+		if c.Message, err = DecryptChatMessage(r.RoomID, r.ChatKeyID, c.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RoomSnapshot) AfterFind(tx *gorm.DB) error {
+	return r.AfterSave(tx)
+}
+
+type SnapshotMovie struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Live bool   `json:"live"`
+}
+
+type SnapshotChat struct {
+	// milliseconds since the start of the session
+	Offset     int64  `json:"offset"`
+	SenderName string `json:"senderName"`
+	Message    string `json:"message"`
+}
+
+// SnapshotStatus is a change of the current movie or its status
+type SnapshotStatus struct {
+	// milliseconds since the start of the session
+	Offset  int64   `json:"offset"`
+	MovieID string  `json:"movieId,omitempty"`
+	Playing bool    `json:"playing"`
+	Seek    float64 `json:"seek"`
+	Rate    float64 `json:"rate"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import "fmt"
+
+// SyncProfile tunes how closely the members of a room are kept in sync,
+// strict suits live sports and relaxed suits long movies on slow networks
+type SyncProfile string
+
+const (
+	SyncProfileStrict   SyncProfile = "strict"
+	SyncProfileBalanced SyncProfile = "balanced"
+	SyncProfileRelaxed  SyncProfile = "relaxed"
+)
+
+// SyncTuning are the thresholds of a profile, in seconds of drift
+type SyncTuning struct {
+	// drift below this is tolerated
+	MaxDrift float64 `json:"maxDrift"`
+	// drift above this is corrected by seeking, between MaxDrift and this
+	// the playback rate is adjusted instead
+	SnapThreshold float64 `json:"snapThreshold"`
+	// the fraction of the rate added or removed while catching up
+	CorrectionRate float64 `json:"correctionRate"`
+	// status checks of websocket clients drifting more than this are
+	// answered with too fast or too slow
+	CheckThreshold float64 `json:"checkThreshold"`
+}
+
+var syncTunings = map[SyncProfile]SyncTuning{
+	SyncProfileStrict: {
+		MaxDrift:       0.3,
+		SnapThreshold:  1,
+		CorrectionRate: 0.1,
+		CheckThreshold: 3,
+	},
+	SyncProfileBalanced: {
+		MaxDrift:       1,
+		SnapThreshold:  2,
+		CorrectionRate: 0.05,
+		CheckThreshold: 10,
+	},
+	SyncProfileRelaxed: {
+		MaxDrift:       3,
+		SnapThreshold:  8,
+		CorrectionRate: 0.02,
+		CheckThreshold: 20,
+	},
+}
+
+func (p SyncProfile) Validate() error {
+	if _, ok := syncTunings[p]; !ok {
+		return fmt.Errorf("unknown sync profile: %s", p)
+	}
+	return nil
+}
+
+// Tuning returns the thresholds of the profile, unknown profiles are balanced
+func (p SyncProfile) Tuning() SyncTuning {
+	if t, ok := syncTunings[p]; ok {
+		return t
+	}
+	return syncTunings[SyncProfileBalanced]
+}
@@ -11,6 +11,19 @@ import (
 	"gorm.io/gorm"
 )
 
+// UsernameCollision decides how a provider username that is already taken
+// by another user is changed on signup
+type UsernameCollision = string
+
+const (
+	// append the smallest free number, like name2
+	UsernameCollisionNumeric UsernameCollision = "numeric"
+	// append the provider, like name_github
+	UsernameCollisionProvider UsernameCollision = "provider"
+	// ask the user to pick another username
+	UsernameCollisionReject UsernameCollision = "reject"
+)
+
 type Role uint8
 
 const (
@@ -49,12 +62,27 @@ type User struct {
 	HashedPassword       []byte          `gorm:"not null"`
 	Email                EmptyNullString `gorm:"type:varchar(128);uniqueIndex"`
 	Role                 Role            `gorm:"not null;default:2"`
+	MaxContentRating     ContentRating   `gorm:"type:varchar(8);default:''"`
 	RoomMembers          []*RoomMember   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Rooms                []*Room         `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Movies               []*Movie        `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	BilibiliVendor       *BilibiliVendor `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	AlistVendor          []*AlistVendor  `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	EmbyVendor           []*EmbyVendor   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CookieVendors        []*CookieVendor `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	MediaFiles           []*MediaFile    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Secrets              []*UserSecret   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	NotificationSubscriptions []*NotificationSubscription `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	NotificationPreference    *NotificationPreference     `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	RoomFollows               []*RoomFollow               `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	PresencePreference        *PresencePreference         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Achievements              []*UserAchievement          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Stats                     *UserStats                  `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Deletion                  *UserDeletion               `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Logins                    []*UserLogin                `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	OrgMemberships            []*OrganizationMember       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Passkeys                  []*UserPasskey              `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 func (u *User) CheckPassword(password string) bool {
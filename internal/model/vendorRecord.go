@@ -14,12 +14,37 @@ type BilibiliVendor struct {
 	UserID    string            `gorm:"primaryKey;type:char(32)"`
 	Backend   string            `gorm:"type:varchar(64)"`
 	Cookies   map[string]string `gorm:"not null;serializer:fastjson;type:text"`
+	// the provider token key the cookies are encrypted with, empty if they
+	// are encrypted with the user id
+	KeyID string `gorm:"index;type:varchar(16)"`
+}
+
+// vendorEncrypt encrypts credentials of a vendor binding with the provider
+// token key of the id, or with the legacy key of the binding if it is empty
+func vendorEncrypt(keyID, scope string, legacy []byte, v string) (string, error) {
+	if keyID != "" {
+		v, _, err := ProviderTokenKeyring.Encrypt(scope, v)
+		return v, err
+	}
+	return utils.CryptoToBase64([]byte(v), legacy)
+}
+
+func vendorDecrypt(keyID, scope string, legacy []byte, v string) (string, error) {
+	if keyID != "" {
+		return ProviderTokenKeyring.Decrypt(scope, keyID, v)
+	}
+	b, err := utils.DecryptoFromBase64(v, legacy)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
 }
 
 func (b *BilibiliVendor) BeforeSave(tx *gorm.DB) error {
+	b.KeyID = ProviderTokenKeyring.CurrentID()
 	key := []byte(b.UserID)
 	for k, v := range b.Cookies {
-		value, err := utils.CryptoToBase64([]byte(v), key)
+		value, err := vendorEncrypt(b.KeyID, b.UserID, key, v)
 		if err != nil {
 			return err
 		}
@@ -31,11 +56,11 @@ func (b *BilibiliVendor) BeforeSave(tx *gorm.DB) error {
 func (b *BilibiliVendor) AfterSave(tx *gorm.DB) error {
 	key := []byte(b.UserID)
 	for k, v := range b.Cookies {
-		value, err := utils.DecryptoFromBase64(v, key)
+		value, err := vendorDecrypt(b.KeyID, b.UserID, key, v)
 		if err != nil {
 			return err
 		}
-		b.Cookies[k] = string(value)
+		b.Cookies[k] = value
 	}
 	return nil
 }
@@ -53,6 +78,7 @@ type AlistVendor struct {
 	Host           string `gorm:"not null;type:varchar(256)"`
 	Username       string `gorm:"type:varchar(256)"`
 	HashedPassword []byte
+	KeyID          string `gorm:"index;type:varchar(16)"`
 }
 
 func GenAlistServerID(a *AlistVendor) {
@@ -62,37 +88,45 @@ func GenAlistServerID(a *AlistVendor) {
 }
 
 func (a *AlistVendor) BeforeSave(tx *gorm.DB) error {
+	a.KeyID = ProviderTokenKeyring.CurrentID()
 	key := utils.GenCryptoKey(a.UserID)
 	var err error
-	if a.Host, err = utils.CryptoToBase64([]byte(a.Host), key); err != nil {
+	if a.Host, err = vendorEncrypt(a.KeyID, a.UserID, key, a.Host); err != nil {
+		return err
+	}
+	if a.Username, err = vendorEncrypt(a.KeyID, a.UserID, key, a.Username); err != nil {
 		return err
 	}
-	if a.Username, err = utils.CryptoToBase64([]byte(a.Username), key); err != nil {
+	if a.KeyID == "" {
+		a.HashedPassword, err = utils.Crypto(a.HashedPassword, key)
 		return err
 	}
-	if a.HashedPassword, err = utils.Crypto(a.HashedPassword, key); err != nil {
+	v, err := vendorEncrypt(a.KeyID, a.UserID, key, string(a.HashedPassword))
+	if err != nil {
 		return err
 	}
+	a.HashedPassword = []byte(v)
 	return nil
 }
 
 func (a *AlistVendor) AfterSave(tx *gorm.DB) error {
 	key := utils.GenCryptoKey(a.UserID)
-	if v, err := utils.DecryptoFromBase64(a.Host, key); err != nil {
+	var err error
+	if a.Host, err = vendorDecrypt(a.KeyID, a.UserID, key, a.Host); err != nil {
 		return err
-	} else {
-		a.Host = string(v)
 	}
-	if v, err := utils.DecryptoFromBase64(a.Username, key); err != nil {
+	if a.Username, err = vendorDecrypt(a.KeyID, a.UserID, key, a.Username); err != nil {
 		return err
-	} else {
-		a.Username = string(v)
 	}
-	if v, err := utils.Decrypto(a.HashedPassword, key); err != nil {
+	if a.KeyID == "" {
+		a.HashedPassword, err = utils.Decrypto(a.HashedPassword, key)
 		return err
-	} else {
-		a.HashedPassword = v
 	}
+	v, err := vendorDecrypt(a.KeyID, a.UserID, key, string(a.HashedPassword))
+	if err != nil {
+		return err
+	}
+	a.HashedPassword = []byte(v)
 	return nil
 }
 
@@ -109,15 +143,19 @@ type EmbyVendor struct {
 	Host       string `gorm:"not null;type:varchar(256)"`
 	ApiKey     string `gorm:"not null;type:varchar(256)"`
 	EmbyUserID string `gorm:"type:varchar(32)"`
+	// report playback in rooms back to the server
+	ReportPlayback bool   `gorm:"not null;default:false"`
+	KeyID          string `gorm:"index;type:varchar(16)"`
 }
 
 func (e *EmbyVendor) BeforeSave(tx *gorm.DB) error {
+	e.KeyID = ProviderTokenKeyring.CurrentID()
 	key := utils.GenCryptoKey(e.ServerID)
 	var err error
-	if e.Host, err = utils.CryptoToBase64([]byte(e.Host), key); err != nil {
+	if e.Host, err = vendorEncrypt(e.KeyID, e.UserID, key, e.Host); err != nil {
 		return err
 	}
-	if e.ApiKey, err = utils.CryptoToBase64([]byte(e.ApiKey), key); err != nil {
+	if e.ApiKey, err = vendorEncrypt(e.KeyID, e.UserID, key, e.ApiKey); err != nil {
 		return err
 	}
 	return nil
@@ -125,15 +163,12 @@ func (e *EmbyVendor) BeforeSave(tx *gorm.DB) error {
 
 func (e *EmbyVendor) AfterSave(tx *gorm.DB) error {
 	key := utils.GenCryptoKey(e.ServerID)
-	if v, err := utils.DecryptoFromBase64(e.Host, key); err != nil {
+	var err error
+	if e.Host, err = vendorDecrypt(e.KeyID, e.UserID, key, e.Host); err != nil {
 		return err
-	} else {
-		e.Host = string(v)
 	}
-	if v, err := utils.DecryptoFromBase64(e.ApiKey, key); err != nil {
+	if e.ApiKey, err = vendorDecrypt(e.KeyID, e.UserID, key, e.ApiKey); err != nil {
 		return err
-	} else {
-		e.ApiKey = string(v)
 	}
 	return nil
 }
@@ -141,3 +176,43 @@ func (e *EmbyVendor) AfterSave(tx *gorm.DB) error {
 func (e *EmbyVendor) AfterFind(tx *gorm.DB) error {
 	return e.AfterSave(tx)
 }
+
+// CookieVendor binds the cookies of a user on vendors which only need the
+// cookies of the site to play member-only content
+type CookieVendor struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    string            `gorm:"primaryKey;type:char(32)"`
+	Vendor    VendorName        `gorm:"primaryKey;type:varchar(32)"`
+	Cookies   map[string]string `gorm:"not null;serializer:fastjson;type:text"`
+	KeyID     string            `gorm:"index;type:varchar(16)"`
+}
+
+func (c *CookieVendor) BeforeSave(tx *gorm.DB) error {
+	c.KeyID = ProviderTokenKeyring.CurrentID()
+	key := utils.GenCryptoKey(c.UserID)
+	for k, v := range c.Cookies {
+		value, err := vendorEncrypt(c.KeyID, c.UserID, key, v)
+		if err != nil {
+			return err
+		}
+		c.Cookies[k] = value
+	}
+	return nil
+}
+
+func (c *CookieVendor) AfterSave(tx *gorm.DB) error {
+	key := utils.GenCryptoKey(c.UserID)
+	for k, v := range c.Cookies {
+		value, err := vendorDecrypt(c.KeyID, c.UserID, key, v)
+		if err != nil {
+			return err
+		}
+		c.Cookies[k] = value
+	}
+	return nil
+}
+
+func (c *CookieVendor) AfterFind(tx *gorm.DB) error {
+	return c.AfterSave(tx)
+}
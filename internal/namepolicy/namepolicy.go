@@ -0,0 +1,162 @@
+// Package namepolicy normalizes user and room names and checks them against
+// the policy of the server: reserved words, denied patterns, allowed scripts
+// and names looking like the names of admins.
+package namepolicy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type Kind string
+
+const (
+	KindUsername Kind = "username"
+	KindRoomName Kind = "room name"
+)
+
+type Rule string
+
+const (
+	RuleReserved      Rule = "reserved"
+	RulePattern       Rule = "pattern"
+	RuleScript        Rule = "script"
+	RuleImpersonation Rule = "impersonation"
+)
+
+// Violation is the rule a name breaks, it is sent to clients as the data of
+// the error response
+type Violation struct {
+	Kind   Kind   `json:"kind"`
+	Rule   Rule   `json:"rule"`
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s %q is not allowed: %s", v.Kind, v.Name, v.Detail)
+}
+
+func (v *Violation) ErrorData() any {
+	return v
+}
+
+type Policy struct {
+	// compared by their skeletons, so look-alike spellings are reserved too
+	Reserved []string
+	Patterns []*regexp.Regexp
+	// empty allows all scripts, common and inherited characters like digits
+	// and punctuation are always allowed
+	Scripts []string
+	// names others must not look like, e.g. the names of admins
+	Protected []string
+}
+
+func (p *Policy) Check(kind Kind, name string) error {
+	skeleton := Skeleton(name)
+	for _, r := range p.Reserved {
+		if skeleton == Skeleton(r) {
+			return &Violation{Kind: kind, Rule: RuleReserved, Name: name, Detail: fmt.Sprintf("%q is reserved", r)}
+		}
+	}
+	for _, re := range p.Patterns {
+		if re.MatchString(name) {
+			return &Violation{Kind: kind, Rule: RulePattern, Name: name, Detail: fmt.Sprintf("matches %s", re)}
+		}
+	}
+	if len(p.Scripts) != 0 {
+		for _, r := range name {
+			if !inScripts(r, p.Scripts) {
+				return &Violation{Kind: kind, Rule: RuleScript, Name: name, Detail: fmt.Sprintf("%q is not in the allowed scripts %s", r, strings.Join(p.Scripts, ", "))}
+			}
+		}
+	}
+	for _, protected := range p.Protected {
+		if skeleton == Skeleton(protected) {
+			return &Violation{Kind: kind, Rule: RuleImpersonation, Name: name, Detail: fmt.Sprintf("looks like %q", protected)}
+		}
+	}
+	return nil
+}
+
+func inScripts(r rune, scripts []string) bool {
+	if unicode.In(r, unicode.Common, unicode.Inherited) {
+		return true
+	}
+	for _, s := range scripts {
+		if unicode.Is(unicode.Scripts[s], r) {
+			return true
+		}
+	}
+	return false
+}
+
+// confusables maps characters to the latin letter they are mistaken for
+var confusables = map[rune]rune{
+	'0': 'o', '1': 'l', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '|': 'l', '!': 'i', '$': 's', '@': 'a',
+	'i': 'l', 'ı': 'l',
+	// cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'l', 'ј': 'j', 'ѕ': 's',
+	// greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'l', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// Skeleton folds the name to compare it with others by how it looks: case,
+// separators and characters looking alike are ignored
+func Skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsSpace(r) || r == '_' || r == '-' || r == '.' || unicode.In(r, unicode.Mn, unicode.Cf) {
+			continue
+		}
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	s = strings.ReplaceAll(s, "rn", "m")
+	s = strings.ReplaceAll(s, "vv", "w")
+	return s
+}
+
+// ParseList parses a comma or newline separated list
+func ParseList(s string) []string {
+	var list []string
+	for _, v := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// ParsePatterns parses newline separated regular expressions
+func ParsePatterns(s string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// ParseScripts parses a comma separated list of unicode script names, like
+// Latin or Han
+func ParseScripts(s string) ([]string, error) {
+	scripts := ParseList(s)
+	for _, script := range scripts {
+		if _, ok := unicode.Scripts[script]; !ok {
+			return nil, fmt.Errorf("unknown unicode script: %s", script)
+		}
+	}
+	return scripts, nil
+}
@@ -0,0 +1,70 @@
+package namepolicy
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p := Policy{
+		Reserved:  []string{"admin"},
+		Patterns:  []*regexp.Regexp{regexp.MustCompile(`(?i)^mod_`)},
+		Scripts:   []string{"Latin", "Han"},
+		Protected: []string{"alice"},
+	}
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"bob", ""},
+		{"鲍勃 2", ""},
+		{"Admin", RuleReserved},
+		{"adm1n", RuleReserved},
+		{"аdmin", RuleReserved},
+		{"MOD_bob", RulePattern},
+		{"боб", RuleScript},
+		{"a_l1ce", RuleImpersonation},
+	}
+	for _, tt := range tests {
+		err := p.Check(KindUsername, tt.name)
+		var v *Violation
+		if !errors.As(err, &v) {
+			if tt.rule != "" {
+				t.Errorf("%q: got %v, want %s", tt.name, err, tt.rule)
+			}
+			continue
+		}
+		if v.Rule != tt.rule {
+			t.Errorf("%q: got %s, want %s", tt.name, v.Rule, tt.rule)
+		}
+	}
+}
+
+func TestParseScripts(t *testing.T) {
+	if _, err := ParseScripts("Latin, Han"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseScripts("Latin,Klingon"); err == nil {
+		t.Fatal("want error for unknown script")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if got := Normalize(" a\u200bb\u202e "); got != "ab" {
+		t.Errorf("got %q, want %q", got, "ab")
+	}
+	if got := Normalize("e\u0301"); got != "\u00e9" {
+		t.Errorf("got %q, want composed e acute", got)
+	}
+	if w := DisplayWidth("鲍勃ab"); w != 6 {
+		t.Errorf("got width %d, want 6", w)
+	}
+	if TooLong(strings.Repeat("鲍", 16)) || !TooLong(strings.Repeat("鲍", 17)) {
+		t.Error("want 16 wide characters to fit and 17 not")
+	}
+	if got := Truncate(strings.Repeat("鲍", 16), "#2"); got != strings.Repeat("鲍", 15)+"#2" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package namepolicy
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+const (
+	// characters, the name columns are varchar(32)
+	MaxNameLength = 32
+	// columns the name takes when shown, wide characters like Han take two
+	MaxNameWidth = 32
+)
+
+// Normalize composes the name to NFC and removes format characters like
+// zero width spaces and bidi controls, which make names look the same as or
+// different from what they are
+func Normalize(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.TrimSpace(norm.NFC.String(name))
+}
+
+// DisplayWidth is the number of columns the name takes when shown
+func DisplayWidth(name string) int {
+	w := 0
+	for _, r := range name {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		case isWide(r):
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
+}
+
+func isWide(r rune) bool {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return true
+	}
+	return false
+}
+
+// TooLong reports whether the normalized name has more characters than the
+// name columns hold or is too wide to show
+func TooLong(name string) bool {
+	return utf8.RuneCountInString(name) > MaxNameLength || DisplayWidth(name) > MaxNameWidth
+}
+
+// Truncate cuts the name to fit the limits with the suffix appended, without
+// splitting a character
+func Truncate(name, suffix string) string {
+	runes := []rune(name)
+	for len(runes) > 0 && TooLong(string(runes)+suffix) {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + suffix
+}
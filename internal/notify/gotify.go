@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func init() {
+	RegisterDriver(model.NotificationDriverGotify, &gotify{})
+}
+
+type gotify struct{}
+
+type gotifyMessage struct {
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority"`
+	Extras   map[string]any `json:"extras,omitempty"`
+}
+
+func (g *gotify) Send(ctx context.Context, sub *model.NotificationSubscription, n *Notification) error {
+	u, err := url.Parse(strings.TrimRight(sub.Endpoint, "/") + "/message")
+	if err != nil {
+		return err
+	}
+	msg := gotifyMessage{
+		Title:    n.Title,
+		Message:  n.Message,
+		Priority: 5,
+	}
+	if n.URL != "" {
+		msg.Extras = map[string]any{
+			"client::notification": map[string]any{
+				"click": map[string]string{"url": n.URL},
+			},
+		}
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", sub.Token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w: gotify token is invalid", ErrSubscriptionGone)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("gotify response status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+)
+
+var (
+	EnableNotify = settings.NewBoolSetting("enable_notify", false, model.SettingGroupNotify)
+
+	ErrNotifyNotEnabled = errors.New("notify is not enabled")
+)
+
+type Event string
+
+const (
+	EventRoomLive       Event = "room_live"
+	EventScheduledMovie Event = "scheduled_movie"
+	EventMovieComment   Event = "movie_comment"
+	EventNewLogin       Event = "new_login"
+)
+
+type Notification struct {
+	Event   Event  `json:"event"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+}
+
+type Driver interface {
+	Send(ctx context.Context, sub *model.NotificationSubscription, n *Notification) error
+}
+
+// ErrSubscriptionGone is returned by drivers when the subscription
+// is no longer valid and should be deleted
+var ErrSubscriptionGone = errors.New("subscription gone")
+
+var drivers = make(map[model.NotificationDriver]Driver)
+
+func RegisterDriver(name model.NotificationDriver, d Driver) {
+	drivers[name] = d
+}
+
+func GetDriver(name model.NotificationDriver) (Driver, error) {
+	d, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("notify driver %s not found", name)
+	}
+	return d, nil
+}
+
+var ErrLocalEndpoint = errors.New("not allow notify to local")
+
+// isLocalIP reports whether the ip is of the server or its private network
+func isLocalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		utils.IsLocalIP(ip.String())
+}
+
+// CheckEndpoint checks the endpoint of a subscription is a http url not on
+// the network of the server
+func CheckEndpoint(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("endpoint must be a http or https url")
+	}
+	if settings.AllowProxyToLocal.Get() {
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if isLocalIP(ip.IP) {
+			return ErrLocalEndpoint
+		}
+	}
+	return nil
+}
+
+// dialControl checks the address again when connecting, the host of an
+// endpoint may resolve elsewhere since it was added
+func dialControl(network, address string, _ syscall.RawConn) error {
+	if settings.AllowProxyToLocal.Get() {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isLocalIP(ip) {
+		return ErrLocalEndpoint
+	}
+	return nil
+}
+
+var httpClient = &http.Client{
+	Timeout: time.Second * 10,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: time.Second * 5,
+			Control: dialControl,
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: time.Second * 5,
+	},
+}
+
+func wantEvent(p *model.NotificationPreference, e Event) bool {
+	switch e {
+	case EventRoomLive:
+		return p.RoomLive
+	case EventScheduledMovie:
+		return p.ScheduledMovie
+	case EventMovieComment:
+		return p.MovieComment
+	case EventNewLogin:
+		return p.NewLogin
+	default:
+		return false
+	}
+}
+
+// Notify sends the notification to all subscriptions of the users in the background
+func Notify(n *Notification, userIDs ...string) {
+	if !EnableNotify.Get() || len(userIDs) == 0 {
+		return
+	}
+	go func() {
+		for _, id := range userIDs {
+			if err := notifyUser(id, n); err != nil {
+				log.Errorf("notify user %s error: %v", id, err)
+			}
+		}
+	}()
+}
+
+func notifyUser(userID string, n *Notification) error {
+	p, err := db.GetOrCreateNotificationPreference(userID)
+	if err != nil {
+		return err
+	}
+	if !wantEvent(p, n.Event) {
+		return nil
+	}
+	subs, err := db.GetNotificationSubscriptions(userID)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if err := Send(context.Background(), sub, n); err != nil {
+			if errors.Is(err, ErrSubscriptionGone) {
+				_ = db.DeleteNotificationSubscriptionByID(sub.ID)
+				continue
+			}
+			log.Errorf("notify subscription %s error: %v", sub.ID, err)
+		}
+	}
+	return nil
+}
+
+func Send(ctx context.Context, sub *model.NotificationSubscription, n *Notification) error {
+	if !EnableNotify.Get() {
+		return ErrNotifyNotEnabled
+	}
+	d, err := GetDriver(sub.Driver)
+	if err != nil {
+		return err
+	}
+	return d.Send(ctx, sub, n)
+}
+
+func NotifyRoomFollowers(roomID string, n *Notification, excludeUserID ...string) {
+	if !EnableNotify.Get() {
+		return
+	}
+	ids, err := db.GetRoomFollowerIDs(roomID)
+	if err != nil {
+		log.Errorf("get room %s followers error: %v", roomID, err)
+		return
+	}
+	var userIDs []string
+	for _, id := range ids {
+		if !contains(excludeUserID, id) {
+			userIDs = append(userIDs, id)
+		}
+	}
+	Notify(n, userIDs...)
+}
+
+func contains(s []string, v string) bool {
+	for _, s2 := range s {
+		if s2 == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func init() {
+	RegisterDriver(model.NotificationDriverNtfy, &ntfy{})
+}
+
+type ntfy struct{}
+
+func (nt *ntfy) Send(ctx context.Context, sub *model.NotificationSubscription, n *Notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, strings.NewReader(n.Message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", n.Title)
+	req.Header.Set("Tags", string(n.Event))
+	if n.URL != "" {
+		req.Header.Set("Click", n.URL)
+	}
+	if sub.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+sub.Token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy response status: %s", resp.Status)
+	}
+	return nil
+}
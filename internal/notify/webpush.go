@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"golang.org/x/crypto/hkdf"
+)
+
+var (
+	WebPushVapidPrivateKey = settings.NewStringSetting("webpush_vapid_private_key", "", model.SettingGroupNotify)
+	WebPushVapidSubject    = settings.NewStringSetting("webpush_vapid_subject", "", model.SettingGroupNotify)
+)
+
+const (
+	webPushRecordSize = 4096
+	webPushTTL        = 60 * 60 * 24
+)
+
+func init() {
+	RegisterDriver(model.NotificationDriverWebPush, &webPush{})
+}
+
+type webPush struct{}
+
+var vapidLock sync.Mutex
+
+// VapidKey returns the vapid key pair, generates and saves a new one if not set
+func VapidKey() (*ecdsa.PrivateKey, error) {
+	vapidLock.Lock()
+	defer vapidLock.Unlock()
+	if s := WebPushVapidPrivateKey.Get(); s != "" {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		k, err := x509.ParsePKCS8PrivateKey(b)
+		if err != nil {
+			return nil, err
+		}
+		ek, ok := k.(*ecdsa.PrivateKey)
+		if !ok || ek.Curve != elliptic.P256() {
+			return nil, errors.New("vapid private key must be a p256 ecdsa key")
+		}
+		return ek, nil
+	}
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	b, err := x509.MarshalPKCS8PrivateKey(k)
+	if err != nil {
+		return nil, err
+	}
+	err = WebPushVapidPrivateKey.Set(base64.StdEncoding.EncodeToString(b))
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// VapidPublicKey returns the uncompressed vapid public key in base64 url encoding,
+// used as applicationServerKey by browsers
+func VapidPublicKey() (string, error) {
+	k, err := VapidKey()
+	if err != nil {
+		return "", err
+	}
+	pub, err := k.PublicKey.ECDH()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(pub.Bytes()), nil
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	if b, err := base64.URLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func hkdfExpand(secret, salt, info []byte, length int) ([]byte, error) {
+	b := make([]byte, length)
+	_, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), b)
+	return b, err
+}
+
+// encryptWebPush encrypts the payload with aes128gcm content encoding (RFC 8291)
+func encryptWebPush(sub *model.NotificationSubscription, payload []byte) ([]byte, error) {
+	p256dh, err := decodeBase64(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	authSecret, err := decodeBase64(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+	shared, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := make([]byte, 0, 14+len(p256dh)+len(asPublic))
+	keyInfo = append(keyInfo, "WebPush: info\x00"...)
+	keyInfo = append(keyInfo, p256dh...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := hkdfExpand(shared, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	cek, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload)+1+gcm.Overhead() > webPushRecordSize {
+		return nil, errors.New("web push payload too large")
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 21+len(asPublic)+len(payload)+1+gcm.Overhead()))
+	buf.Write(salt)
+	_ = binary.Write(buf, binary.BigEndian, uint32(webPushRecordSize))
+	buf.WriteByte(byte(len(asPublic)))
+	buf.Write(asPublic)
+	// last record delimiter
+	plaintext := append(payload, 0x02)
+	buf.Write(gcm.Seal(nil, nonce, plaintext, nil))
+	return buf.Bytes(), nil
+}
+
+func vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	k, err := VapidKey()
+	if err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"aud": fmt.Sprintf("%s://%s", u.Scheme, u.Host),
+		"exp": time.Now().Add(time.Hour * 12).Unix(),
+	}
+	if sub := WebPushVapidSubject.Get(); sub != "" {
+		claims["sub"] = sub
+	}
+	t, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(k)
+	if err != nil {
+		return "", err
+	}
+	pub, err := VapidPublicKey()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", t, pub), nil
+}
+
+func (w *webPush) Send(ctx context.Context, sub *model.NotificationSubscription, n *Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	body, err := encryptWebPush(sub, payload)
+	if err != nil {
+		return err
+	}
+	auth, err := vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(webPushTTL))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", auth)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("%w: web push endpoint returned %s", ErrSubscriptionGone, resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("web push response status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/jobs"
+	"github.com/synctv-org/synctv/internal/media"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+const eraseUserJobKind = "user.erase"
+
+type eraseUserJob struct {
+	UserID string `json:"userId"`
+}
+
+func init() {
+	jobs.Register(eraseUserJobKind, runEraseUserJob)
+}
+
+// RequestDeletion schedules the erasure of the account once the grace period
+// has passed, the user can cancel it until then
+func (u *User) RequestDeletion() (*model.UserDeletion, error) {
+	if u.IsGuest() {
+		return nil, errors.New("cannot delete guest user")
+	}
+	if u.IsRoot() {
+		return nil, errors.New("cannot delete root user")
+	}
+	at := time.Now().Add(time.Duration(settings.AccountDeletionGraceDays.Get()) * 24 * time.Hour)
+	d, err := db.ScheduleUserDeletion(u.ID, at)
+	if err != nil {
+		return nil, err
+	}
+	return d, jobs.EnqueueAt(eraseUserJobKind, &eraseUserJob{UserID: u.ID}, at)
+}
+
+func (u *User) CancelDeletion() error {
+	return db.DeleteUserDeletion(u.ID)
+}
+
+func runEraseUserJob(ctx context.Context, payload []byte) error {
+	var job eraseUserJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return jobs.Permanent(err)
+	}
+	d, err := db.GetUserDeletion(job.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("deletion request")) {
+			// canceled
+			return nil
+		}
+		return err
+	}
+	// requested again later, that request has its own job
+	if d.ScheduledAt.After(time.Now()) {
+		return nil
+	}
+	return EraseUser(job.UserID)
+}
+
+// EraseUser anonymizes the account right away. rooms it created pass to their
+// successor, see db.GetRoomSuccessor, rooms without other members are deleted.
+// uploaded media is deleted with its blobs and organization memberships end
+func EraseUser(id string) error {
+	if id == db.GuestUserID {
+		return errors.New("cannot erase guest user")
+	}
+	rooms, err := db.GetAllRoomsByUserID(id)
+	if err != nil {
+		return err
+	}
+	for _, r := range rooms {
+		m, err := db.GetRoomSuccessor(r.ID, id)
+		if err != nil {
+			if !errors.Is(err, db.ErrNotFound("room member")) {
+				return err
+			}
+			if err := DeleteRoomByID(r.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := db.TransferRoom(r.ID, m.UserID); err != nil {
+			return err
+		}
+		// reloaded with the new creator
+		if err := CloseRoomById(r.ID); err != nil {
+			return err
+		}
+	}
+	files, err := db.GetUserMediaFiles(id)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := media.Delete(id, f.ID); err != nil {
+			return err
+		}
+	}
+	orgs, err := db.GetUserOrganizations(id)
+	if err != nil {
+		return err
+	}
+	if err := db.AnonymizeUser(id, erasedUsername(id)); err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		forgetOrgRole(org.ID, id)
+	}
+	return CloseUserById(id)
+}
+
+func erasedUsername(id string) string {
+	return "deleted_" + id[:min(len(id), 24)]
+}
@@ -0,0 +1,91 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+// tag of the chat messages announcing achievements
+const achievementChatTag = "achievement"
+
+const (
+	achievementMovieNights  = 10
+	achievementChatMessages = 100
+	achievementStreak       = 7
+)
+
+func achievementsEnabled(u *User) bool {
+	return settings.EnableAchievements.Get() && !u.IsGuest()
+}
+
+// unlockAchievement announces the achievement in room if it is newly unlocked,
+// room can be nil
+func (u *User) unlockAchievement(room *Room, a model.Achievement) {
+	unlocked, err := db.CreateUserAchievement(u.ID, a)
+	if err != nil {
+		log.Errorf("unlock achievement %s of user %s error: %v", a, u.ID, err)
+		return
+	}
+	if !unlocked || room == nil || !settings.AnnounceAchievements.Get() {
+		return
+	}
+	info := model.GetAchievementInfo(a)
+	if info == nil {
+		return
+	}
+	err = room.RelayChatMessage(achievementChatTag, u.Username, fmt.Sprintf("unlocked the achievement %s: %s", info.Name, info.Description))
+	if err != nil {
+		log.Errorf("announce achievement error: %v", err)
+	}
+}
+
+func (u *User) recordRoomCreated() {
+	if !achievementsEnabled(u) {
+		return
+	}
+	go u.unlockAchievement(nil, model.AchievementFirstRoom)
+}
+
+func (u *User) recordChatMessage(room *Room) {
+	if !achievementsEnabled(u) {
+		return
+	}
+	go func() {
+		n, err := db.IncrUserChatMessages(u.ID)
+		if err != nil {
+			log.Errorf("record chat message of user %s error: %v", u.ID, err)
+			return
+		}
+		if n >= achievementChatMessages {
+			u.unlockAchievement(room, model.AchievementChatMessages100)
+		}
+	}()
+}
+
+// recordWatch counts today as a movie night if the room has a current movie
+func (u *User) recordWatch(room *Room) {
+	if !achievementsEnabled(u) || room.CurrentMovie().ID == "" {
+		return
+	}
+	go func() {
+		s, recorded, err := db.RecordUserWatchDay(u.ID, time.Now())
+		if err != nil {
+			log.Errorf("record watch day of user %s error: %v", u.ID, err)
+			return
+		}
+		if !recorded {
+			return
+		}
+		if s.MovieNights >= achievementMovieNights {
+			u.unlockAchievement(room, model.AchievementMovieNights10)
+		}
+		if s.Streak >= achievementStreak {
+			u.unlockAchievement(room, model.AchievementWeekStreak)
+		}
+	}()
+}
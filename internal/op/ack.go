@@ -0,0 +1,145 @@
+package op
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/synctv-org/synctv/proto/message"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ackRetransmitInterval = 2 * time.Second
+	// the client is closed when a message is still not acknowledged after this
+	// many retransmits, it resyncs on reconnect
+	ackMaxRetransmits = 3
+)
+
+const (
+	ackClassNone = iota
+	ackClassStatus
+	ackClassCurrent
+)
+
+// ackClass groups the critical messages, a newer message supersedes the
+// pending ones of its class since it carries the full state
+func ackClass(t pb.ElementMessageType) int {
+	switch t {
+	case pb.ElementMessageType_PLAY,
+		pb.ElementMessageType_PAUSE,
+		pb.ElementMessageType_CHANGE_RATE,
+		pb.ElementMessageType_CHANGE_SEEK:
+		return ackClassStatus
+	case pb.ElementMessageType_CURRENT_CHANGED:
+		return ackClassCurrent
+	default:
+		return ackClassNone
+	}
+}
+
+type pendingAck struct {
+	msg         *pb.ElementMessage
+	sentAt      time.Time
+	retransmits int
+}
+
+type ackState struct {
+	lock sync.Mutex
+	next uint64
+	// nil until the client enables acks
+	pending map[uint64]*pendingAck
+}
+
+// EnableAck makes the client acknowledge critical messages,
+// unacknowledged ones are retransmitted
+func (c *Client) EnableAck() {
+	c.ack.lock.Lock()
+	if c.ack.pending != nil {
+		c.ack.lock.Unlock()
+		return
+	}
+	c.ack.pending = make(map[uint64]*pendingAck)
+	c.ack.lock.Unlock()
+	go c.retransmit()
+}
+
+// Ack acknowledges the message of the id
+func (c *Client) Ack(id uint64) {
+	c.ack.lock.Lock()
+	defer c.ack.lock.Unlock()
+	delete(c.ack.pending, id)
+}
+
+// trackAck returns a copy of critical messages carrying an ack id
+func (c *Client) trackAck(msg Message) Message {
+	em, ok := msg.(*pb.ElementMessage)
+	if !ok {
+		return msg
+	}
+	class := ackClass(em.Type)
+	if class == ackClassNone {
+		return msg
+	}
+	c.ack.lock.Lock()
+	defer c.ack.lock.Unlock()
+	if c.ack.pending == nil {
+		return msg
+	}
+	for id, p := range c.ack.pending {
+		if ackClass(p.msg.Type) == class {
+			delete(c.ack.pending, id)
+		}
+	}
+	c.ack.next++
+	// broadcast messages are shared by all clients
+	em = proto.Clone(em).(*pb.ElementMessage)
+	em.AckId = c.ack.next
+	c.ack.pending[em.AckId] = &pendingAck{
+		msg:    em,
+		sentAt: time.Now(),
+	}
+	return em
+}
+
+// due returns the messages to retransmit in send order,
+// expired is true if one ran out of retransmits
+func (a *ackState) due(now time.Time) (msgs []*pb.ElementMessage, expired bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, p := range a.pending {
+		if now.Sub(p.sentAt) < ackRetransmitInterval {
+			continue
+		}
+		if p.retransmits >= ackMaxRetransmits {
+			return nil, true
+		}
+		p.retransmits++
+		p.sentAt = now
+		msgs = append(msgs, p.msg)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].AckId < msgs[j].AckId
+	})
+	return msgs, false
+}
+
+func (c *Client) retransmit() {
+	ticker := time.NewTicker(ackRetransmitInterval / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		if c.Closed() {
+			return
+		}
+		msgs, expired := c.ack.due(time.Now())
+		if expired {
+			c.Close()
+			return
+		}
+		for _, msg := range msgs {
+			if err := c.send(msg); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"path"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/vendor"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/synctv-org/vendors/api/alist"
+)
+
+const (
+	maxRoomAlistWatches = 10
+	// entries per list request and the most entries read from a directory
+	alistWatchPageSize = 500
+	alistWatchMaxFiles = 5000
+)
+
+// AddAlistWatch starts watching a directory, files already in it are queued
+// only if queueExisting
+func (u *User) AddAlistWatch(ctx context.Context, room *Room, watch *model.AlistWatch, queueExisting bool) error {
+	if !u.HasRoomPermission(room, model.PermissionAddMovie) {
+		return model.ErrNoPermission
+	}
+	if _, err := watch.Filter(); err != nil {
+		return err
+	}
+	watches, err := db.GetAlistWatchesByRoomID(room.ID)
+	if err != nil {
+		return err
+	}
+	if len(watches) >= maxRoomAlistWatches {
+		return errors.New("too many alist watches in the room")
+	}
+	if watch.ParentID != "" {
+		parent, err := room.GetMovieByID(watch.ParentID.String())
+		if err != nil {
+			return err
+		}
+		if !parent.IsFolder || parent.IsDynamicFolder() {
+			return errors.New("parent is not a static folder")
+		}
+	}
+	watch.ID = ""
+	watch.RoomID = room.ID
+	watch.CreatorID = u.ID
+	// the first listing checks the directory is readable
+	names, err := listAlistWatch(ctx, u, watch)
+	if err != nil {
+		return err
+	}
+	if !queueExisting {
+		watch.Known = names
+	}
+	watch.LastCheckAt = time.Now()
+	if err := db.CreateAlistWatch(watch); err != nil {
+		return err
+	}
+	if queueExisting {
+		return checkAlistWatch(ctx, watch)
+	}
+	return nil
+}
+
+// DeleteAlistWatch stops a watch, only its creator and room admins may
+func (u *User) DeleteAlistWatch(room *Room, id string) error {
+	watch, err := db.GetAlistWatch(room.ID, id)
+	if err != nil {
+		return err
+	}
+	if watch.CreatorID != u.ID && !u.IsAdmin() && !u.IsRoomAdmin(room) {
+		return model.ErrNoPermission
+	}
+	return db.DeleteAlistWatch(room.ID, id)
+}
+
+// StartAlistWatch checks the watched directories every AlistWatchInterval
+func StartAlistWatch(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			interval := time.Duration(settings.AlistWatchInterval.Get()) * time.Minute
+			watches, err := db.GetDueAlistWatches(time.Now().Add(-interval))
+			if err != nil {
+				log.Errorf("get due alist watches error: %v", err)
+				continue
+			}
+			for _, w := range watches {
+				if err := checkAlistWatch(ctx, w); err != nil {
+					log.Debugf("check alist watch %s of room %s error: %v", w.ID, w.RoomID, err)
+				}
+			}
+		}
+	}()
+}
+
+// checkAlistWatch queues the files that appeared since the last check
+func checkAlistWatch(ctx context.Context, w *model.AlistWatch) error {
+	err := queueAlistWatch(ctx, w)
+	var lastError string
+	if err != nil {
+		lastError = utils.TruncateByRune(err.Error(), 256)
+	}
+	if err := db.UpdateAlistWatchCheck(w.ID, w.Known, time.Now(), lastError); err != nil {
+		return err
+	}
+	return err
+}
+
+func queueAlistWatch(ctx context.Context, w *model.AlistWatch) error {
+	if err := settings.CheckVendorEnabled(model.VendorAlist); err != nil {
+		return err
+	}
+	roomE, err := LoadOrInitRoomByID(w.RoomID)
+	if err != nil {
+		return err
+	}
+	room := roomE.Value()
+	userE, err := LoadOrInitUserByID(w.CreatorID)
+	if err != nil {
+		return err
+	}
+	user := userE.Value()
+	names, err := listAlistWatch(ctx, user, w)
+	if err != nil {
+		return err
+	}
+	match, err := w.Filter()
+	if err != nil {
+		return err
+	}
+	serverID, dir, err := model.GetAlistServerIdFromPath(w.Path)
+	if err != nil {
+		return err
+	}
+	var movies []*model.MovieBase
+	for _, name := range names {
+		if slices.Contains(w.Known, name) || !match(name) {
+			continue
+		}
+		movies = append(movies, &model.MovieBase{
+			Name:     utils.TruncateByRune(name, 256),
+			ParentID: w.ParentID,
+			VendorInfo: model.VendorInfo{
+				Vendor:  model.VendorAlist,
+				Backend: w.Backend,
+				Alist: &model.AlistStreamingInfo{
+					Path:     model.FormatAlistPath(serverID, path.Join(dir, name)),
+					Password: w.Password,
+				},
+			},
+		})
+	}
+	if len(movies) != 0 {
+		if _, err := user.AddRoomMovies(room, movies); err != nil {
+			return err
+		}
+	}
+	// forget removed files so they are queued again if they come back
+	w.Known = names
+	return nil
+}
+
+// listAlistWatch returns the names of the files in the watched directory, sorted
+func listAlistWatch(ctx context.Context, user *User, w *model.AlistWatch) ([]string, error) {
+	serverID, dir, err := model.GetAlistServerIdFromPath(w.Path)
+	if err != nil {
+		return nil, err
+	}
+	aucd, err := user.AlistCache().LoadOrStore(ctx, serverID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("vendor")) {
+			return nil, errors.New("alist server not found")
+		}
+		return nil, err
+	}
+	cli := vendor.LoadAlistClient(w.Backend)
+	var names []string
+	for page := uint64(1); ; page++ {
+		data, err := cli.FsList(ctx, &alist.FsListReq{
+			Token:    aucd.Token,
+			Password: w.Password,
+			Path:     dir,
+			Host:     aucd.Host,
+			Refresh:  false,
+			Page:     page,
+			PerPage:  alistWatchPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range data.Content {
+			if !c.IsDir {
+				names = append(names, c.Name)
+			}
+		}
+		if len(data.Content) < alistWatchPageSize || page*alistWatchPageSize >= data.Total || page*alistWatchPageSize >= alistWatchMaxFiles {
+			break
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,106 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+const maxRoomArrWebhooks = 10
+
+// AddArrWebhook creates a webhook and returns its token, which is not stored
+func (u *User) AddArrWebhook(ctx context.Context, room *Room, hook *model.ArrWebhook) (string, error) {
+	if !u.HasRoomPermission(room, model.PermissionAddMovie) {
+		return "", model.ErrNoPermission
+	}
+	serverID, _, err := model.GetAlistServerIdFromPath(hook.TargetPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := u.AlistCache().LoadOrStore(ctx, serverID); err != nil {
+		if errors.Is(err, db.ErrNotFound("vendor")) {
+			return "", errors.New("alist server not found")
+		}
+		return "", err
+	}
+	hooks, err := db.GetArrWebhooksByRoomID(room.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(hooks) >= maxRoomArrWebhooks {
+		return "", errors.New("too many webhooks in the room")
+	}
+	if hook.ParentID != "" {
+		parent, err := room.GetMovieByID(hook.ParentID.String())
+		if err != nil {
+			return "", err
+		}
+		if !parent.IsFolder || parent.IsDynamicFolder() {
+			return "", errors.New("parent is not a static folder")
+		}
+	}
+	hook.ID = ""
+	hook.RoomID = room.ID
+	hook.CreatorID = u.ID
+	token := hook.GenToken()
+	return token, db.CreateArrWebhook(hook)
+}
+
+// DeleteArrWebhook removes a webhook, only its creator and room admins may
+func (u *User) DeleteArrWebhook(room *Room, id string) error {
+	hook, err := db.GetArrWebhook(id)
+	if err != nil {
+		return err
+	}
+	if hook.RoomID != room.ID {
+		return db.ErrNotFound("webhook")
+	}
+	if hook.CreatorID != u.ID && !u.IsAdmin() && !u.IsRoomAdmin(room) {
+		return model.ErrNoPermission
+	}
+	return db.DeleteArrWebhook(room.ID, id)
+}
+
+// ArrImport queues an imported file as the creator of the webhook
+func ArrImport(hook *model.ArrWebhook, name, file string) (*model.Movie, error) {
+	m, err := arrImport(hook, name, file)
+	var lastError string
+	if err != nil {
+		lastError = utils.TruncateByRune(err.Error(), 256)
+	}
+	if err := db.UpdateArrWebhookEvent(hook.ID, time.Now(), lastError); err != nil {
+		return nil, err
+	}
+	return m, err
+}
+
+func arrImport(hook *model.ArrWebhook, name, file string) (*model.Movie, error) {
+	alistPath, ok := hook.MapPath(file)
+	if !ok {
+		return nil, errors.New("file is not in the source path of the webhook")
+	}
+	roomE, err := LoadOrInitRoomByID(hook.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	userE, err := LoadOrInitUserByID(hook.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	return userE.Value().AddRoomMovie(roomE.Value(), &model.MovieBase{
+		Name:     utils.TruncateByRune(name, 256),
+		ParentID: hook.ParentID,
+		VendorInfo: model.VendorInfo{
+			Vendor:  model.VendorAlist,
+			Backend: hook.Backend,
+			Alist: &model.AlistStreamingInfo{
+				Path:     alistPath,
+				Password: hook.Password,
+			},
+		},
+	})
+}
@@ -0,0 +1,134 @@
+package op
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/synctv-org/synctv/internal/settings"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+const (
+	// websocket close code sent to evicted clients, the reason is one of the
+	// EvictReason codes
+	CloseCodeEvicted = 4008
+
+	EvictReasonSlowConsumer = "slow_consumer"
+)
+
+var ErrClientEvicted = errors.New("client evicted")
+
+// backpressure tracks a client whose send queues do not drain,
+// it is degraded to sync-only first and evicted if that does not help
+type backpressure struct {
+	lock          sync.Mutex
+	fullSince     time.Time
+	degradedSince time.Time
+	evicted       bool
+}
+
+type backpressureAction int
+
+const (
+	backpressureNone backpressureAction = iota
+	backpressureDrop
+	backpressureDegrade
+	backpressureRecover
+	backpressureEvict
+)
+
+// admit decides what to do with a message of the lane before it is queued
+func (b *backpressure) admit(c *Client, l lane, now time.Time) backpressureAction {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.degradedSince.IsZero() {
+		return backpressureNone
+	}
+	if c.queued() == 0 {
+		b.degradedSince = time.Time{}
+		b.fullSince = time.Time{}
+		return backpressureRecover
+	}
+	if now.Sub(b.degradedSince) >= time.Duration(settings.WsBackpressureEvictTime.Get())*time.Second {
+		b.evicted = true
+		return backpressureEvict
+	}
+	if l != laneControl {
+		return backpressureDrop
+	}
+	return backpressureNone
+}
+
+func (b *backpressure) sent() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.fullSince = time.Time{}
+}
+
+// full is called when a queue of the client is full, the message is dropped
+func (b *backpressure) full(now time.Time) backpressureAction {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.fullSince.IsZero() {
+		b.fullSince = now
+	}
+	full := now.Sub(b.fullSince)
+	if full >= time.Duration(settings.WsBackpressureEvictTime.Get())*time.Second {
+		b.evicted = true
+		return backpressureEvict
+	}
+	if b.degradedSince.IsZero() && full >= time.Duration(settings.WsBackpressureDegradeTime.Get())*time.Second {
+		b.degradedSince = now
+		return backpressureDegrade
+	}
+	return backpressureDrop
+}
+
+func (b *backpressure) isEvicted() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.evicted
+}
+
+func (c *Client) queued() (n int) {
+	for _, l := range c.lanes {
+		n += len(l)
+	}
+	return
+}
+
+func (c *Client) Degraded() bool {
+	c.bp.lock.Lock()
+	defer c.bp.lock.Unlock()
+	return !c.bp.degradedSince.IsZero()
+}
+
+// notifyDegraded tells the client it is sync-only or recovered, it must not
+// block since the client is already slow
+func (c *Client) notifyDegraded(degraded bool) {
+	select {
+	case c.lanes[laneControl] <- &pb.ElementMessage{
+		Type:     pb.ElementMessageType_BACKPRESSURE,
+		Time:     time.Now().UnixMilli(),
+		Degraded: degraded,
+	}:
+	default:
+	}
+}
+
+// Evict closes the client with the reason code in the websocket close frame
+func (c *Client) Evict(reason string) error {
+	c.bp.lock.Lock()
+	c.bp.evicted = true
+	c.bp.lock.Unlock()
+	if c.conn != nil {
+		_ = c.conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(CloseCodeEvicted, reason),
+			time.Now().Add(time.Second),
+		)
+	}
+	return c.Close()
+}
@@ -0,0 +1,136 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/vendor"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+const (
+	bilibiliLiveCheckInterval = 10 * time.Second
+	// the playlist is refreshed this long before the stream urls expire
+	bilibiliLiveRefreshAhead = time.Minute
+	// busy live rooms would flood the chat, the rest is dropped
+	bilibiliDanmakuPerSecond = 5
+	bilibiliDanmakuRetry     = 30 * time.Second
+)
+
+const ChatTagBilibili = "bilibili"
+
+type bilibiliDanmakuRelay struct {
+	movieID string
+	cancel  context.CancelFunc
+}
+
+// StartBilibiliLive refreshes the streams of bilibili live rooms playing in
+// rooms before they expire, and relays their danmaku when enabled
+func StartBilibiliLive(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(bilibiliLiveCheckInterval)
+		defer ticker.Stop()
+		relays := map[string]*bilibiliDanmakuRelay{}
+		for {
+			select {
+			case <-ctx.Done():
+				for _, r := range relays {
+					r.cancel()
+				}
+				return
+			case <-ticker.C:
+			}
+			seen := make(map[string]struct{}, len(relays))
+			RangeRoomCache(func(_ string, value *RoomEntry) bool {
+				checkRoomBilibiliLive(ctx, value.Value(), relays, seen)
+				return true
+			})
+			for roomID, r := range relays {
+				if _, ok := seen[roomID]; !ok {
+					r.cancel()
+					delete(relays, roomID)
+				}
+			}
+		}
+	}()
+}
+
+func checkRoomBilibiliLive(ctx context.Context, room *Room, relays map[string]*bilibiliDanmakuRelay, seen map[string]struct{}) {
+	current := room.Current()
+	if current.Movie.ID == "" || !current.Movie.IsLive {
+		return
+	}
+	movie, err := room.GetMovieByID(current.Movie.ID)
+	if err != nil ||
+		movie.VendorInfo.Vendor != model.VendorBilibili ||
+		movie.VendorInfo.Bilibili == nil {
+		return
+	}
+	refreshBilibiliLive(ctx, room, movie)
+	if !movie.VendorInfo.Bilibili.Danmaku {
+		return
+	}
+	seen[room.ID] = struct{}{}
+	if r, ok := relays[room.ID]; ok {
+		if r.movieID == movie.ID {
+			return
+		}
+		r.cancel()
+	}
+	relayCtx, cancel := context.WithCancel(ctx)
+	relays[room.ID] = &bilibiliDanmakuRelay{
+		movieID: movie.ID,
+		cancel:  cancel,
+	}
+	go relayBilibiliDanmaku(relayCtx, room, movie.VendorInfo.Bilibili.Cid)
+}
+
+// refreshBilibiliLive refreshes the playlist once anyone loaded it and it
+// is about to expire, clients then reload it
+func refreshBilibiliLive(ctx context.Context, room *Room, movie *Movie) {
+	live := movie.BilibiliCache().Live
+	last := live.Last()
+	if last == 0 || time.Duration(time.Now().UnixNano()-last) < time.Duration(live.MaxAge())-bilibiliLiveRefreshAhead {
+		return
+	}
+	if _, err := live.Refresh(ctx); err != nil {
+		log.Errorf("refresh bilibili live %d error: %v", movie.VendorInfo.Bilibili.Cid, err)
+		return
+	}
+	_ = room.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_CURRENT_EXPIRED,
+		Time: time.Now().UnixMilli(),
+	})
+}
+
+func relayBilibiliDanmaku(ctx context.Context, room *Room, liveRoomID uint64) {
+	var (
+		window time.Time
+		count  int
+	)
+	for {
+		err := vendor.BilibiliDanmakuRelay(ctx, liveRoomID, func(d *vendor.BilibiliDanmaku) {
+			now := time.Now()
+			if now.Sub(window) >= time.Second {
+				window = now
+				count = 0
+			}
+			if count >= bilibiliDanmakuPerSecond {
+				return
+			}
+			count++
+			_ = room.RelayChatMessage(ChatTagBilibili, d.Username, d.Message)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("bilibili danmaku relay of live room %d error: %v", liveRoomID, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(bilibiliDanmakuRetry):
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package op
+
+import (
+	"sort"
+	"time"
+)
+
+// receivers not reporting for this long are considered disconnected
+const castReceiverTimeout = time.Minute
+
+type CastReceiver struct {
+	ID        string
+	UserID    string
+	Name      string
+	MovieID   string
+	Position  float64
+	Playing   bool
+	State     string
+	UpdatedAt time.Time
+}
+
+func (r *Room) UpdateCastReceiver(receiver *CastReceiver) {
+	receiver.UpdatedAt = time.Now()
+	r.castReceivers.Store(receiver.UserID+":"+receiver.ID, receiver)
+}
+
+func (r *Room) RemoveCastReceiver(userID, id string) {
+	r.castReceivers.Delete(userID + ":" + id)
+}
+
+func (r *Room) CastReceivers() []*CastReceiver {
+	var receivers []*CastReceiver
+	r.castReceivers.Range(func(key string, value *CastReceiver) bool {
+		if time.Since(value.UpdatedAt) > castReceiverTimeout {
+			r.castReceivers.CompareAndDelete(key, value)
+			return true
+		}
+		receivers = append(receivers, value)
+		return true
+	})
+	sort.Slice(receivers, func(i, j int) bool {
+		return receivers[i].UpdatedAt.After(receivers[j].UpdatedAt)
+	})
+	return receivers
+}
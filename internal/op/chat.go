@@ -0,0 +1,193 @@
+package op
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// only the latest messages can be pinned, deleted or purged
+const maxChatHistory = 256
+
+type ChatMessage struct {
+	ID         string
+	SenderID   string
+	SenderName string
+	Message    string
+	SentAt     time.Time
+	// set on messages relayed from outside the room
+	Tag string
+}
+
+type chatHistory struct {
+	lock     sync.RWMutex
+	messages []*ChatMessage
+}
+
+func (c *chatHistory) add(msg *ChatMessage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.messages) >= maxChatHistory {
+		c.messages = c.messages[1:]
+	}
+	c.messages = append(c.messages, msg)
+}
+
+func (c *chatHistory) get(id string) (*ChatMessage, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, m := range c.messages {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// since returns up to limit of the latest messages sent after t, oldest first
+func (c *chatHistory) since(t time.Time, limit int) []*ChatMessage {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	i := len(c.messages)
+	for i > 0 && len(c.messages)-i < limit && c.messages[i-1].SentAt.After(t) {
+		i--
+	}
+	messages := make([]*ChatMessage, len(c.messages)-i)
+	copy(messages, c.messages[i:])
+	return messages
+}
+
+func (c *chatHistory) delete(id string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i, m := range c.messages {
+		if m.ID == id {
+			c.messages = append(c.messages[:i], c.messages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *chatHistory) deleteBySender(senderID string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var ids []string
+	messages := c.messages[:0]
+	for _, m := range c.messages {
+		if m.SenderID == senderID {
+			ids = append(ids, m.ID)
+			continue
+		}
+		messages = append(messages, m)
+	}
+	c.messages = messages
+	return ids
+}
+
+// RelayChatMessage broadcasts a message relayed from outside the room,
+// the sender is not a user of the room so only the name is kept
+func (r *Room) RelayChatMessage(tag, senderName, message string) error {
+	id := utils.SortUUID()
+	r.addChatMessage(&ChatMessage{
+		ID:         id,
+		SenderName: senderName,
+		Message:    message,
+		SentAt:     time.Now(),
+		Tag:        tag,
+	})
+	return r.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_CHAT_MESSAGE,
+		Time: time.Now().UnixMilli(),
+		ChatResp: &pb.ChatResp{
+			Id:      id,
+			Message: message,
+			Tag:     tag,
+			Sender: &pb.Sender{
+				Username: senderName,
+			},
+		},
+	})
+}
+
+// RecentChatMessages returns up to limit of the latest chat messages sent after t
+func (r *Room) RecentChatMessages(t time.Time, limit int) []*ChatMessage {
+	return r.chatHistory.since(t, limit)
+}
+
+var ErrTooManyPinnedChatMessages = errors.New("too many pinned chat messages")
+
+// DeleteChatMessage deletes the message from the history and the pins,
+// unpinned reports whether it was pinned
+func (r *Room) DeleteChatMessage(id string) (unpinned bool, err error) {
+	deleted := r.chatHistory.delete(id)
+	err = db.DeletePinnedChatMessage(r.ID, id)
+	if err == nil {
+		deleted, unpinned = true, true
+	} else if !errors.Is(err, db.ErrNotFound("pinned message")) {
+		return false, err
+	}
+	if !deleted {
+		return false, ErrChatMessageNotFound
+	}
+	return unpinned, nil
+}
+
+// PurgeChatMessages deletes the messages of the sender, it returns the ids
+// deleted from the history and whether any pin was removed
+func (r *Room) PurgeChatMessages(senderID string) ([]string, bool, error) {
+	ids := r.chatHistory.deleteBySender(senderID)
+	n, err := db.DeletePinnedChatMessagesBySender(r.ID, senderID)
+	return ids, n != 0, err
+}
+
+func (r *Room) PinChatMessage(id, pinnedBy string) error {
+	msg, ok := r.chatHistory.get(id)
+	if !ok {
+		return ErrChatMessageNotFound
+	}
+	count, err := db.GetPinnedChatMessagesCount(r.ID)
+	if err != nil {
+		return err
+	}
+	if count >= settings.RoomMaxPinnedChatMessages.Get() {
+		return ErrTooManyPinnedChatMessages
+	}
+	return db.CreatePinnedChatMessage(&model.PinnedChatMessage{
+		ID:         msg.ID,
+		RoomID:     r.ID,
+		SenderID:   msg.SenderID,
+		SenderName: msg.SenderName,
+		Message:    msg.Message,
+		SentAt:     msg.SentAt,
+		PinnedBy:   pinnedBy,
+	})
+}
+
+func (r *Room) UnpinChatMessage(id string) error {
+	return db.DeletePinnedChatMessage(r.ID, id)
+}
+
+func (r *Room) PinnedChatMessages() ([]*model.PinnedChatMessage, error) {
+	return db.GetPinnedChatMessages(r.ID)
+}
+
+func newChatDeletedMessage(operator *User, ids ...string) *pb.ElementMessage {
+	return &pb.ElementMessage{
+		Type: pb.ElementMessageType_CHAT_DELETED,
+		Time: time.Now().UnixMilli(),
+		ChatDeleted: &pb.ChatDeleted{
+			Sender: &pb.Sender{
+				Username: operator.Username,
+				Userid:   operator.ID,
+			},
+			MessageIds: ids,
+		},
+	}
+}
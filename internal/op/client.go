@@ -7,28 +7,51 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/synctv-org/synctv/internal/events"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/roomlog"
 	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/utils"
 )
 
 type Client struct {
 	u       *User
 	r       *Room
 	c       chan Message
+	lanes   [laneCount]chan Message
 	wg      sync.WaitGroup
 	conn    *websocket.Conn
 	timeOut time.Duration
 	closed  uint32
+	clock   clockSync
+	// listening in audio-only mode
+	audioOnly uint32
+	// token to resume the session after the connection drops
+	resumeToken string
+	// set once the connection dropped, messages are kept for the resumed session
+	resume atomic.Pointer[resumeBuffer]
+	ack    ackState
+	bp     backpressure
+	// plays from the anchors of precise sync mode
+	precise atomic.Bool
+	// set by Negotiate before the client is served
+	protocolVersion uint32
+	capabilities    Capability
 }
 
 func newClient(user *User, room *Room, conn *websocket.Conn) *Client {
-	return &Client{
+	c := &Client{
 		r:       room,
 		u:       user,
-		c:       make(chan Message, 128),
+		c:       make(chan Message),
+		lanes:   newLanes[Message](),
 		conn:    conn,
 		timeOut: 10 * time.Second,
+
+		resumeToken: utils.SortUUID(),
 	}
+	go pumpLanes(c.lanes, c.c)
+	return c
 }
 
 func (c *Client) User() *User {
@@ -47,10 +70,27 @@ func (c *Client) SendChatMessage(message string) error {
 	if !c.u.HasRoomPermission(c.r, model.PermissionSendChatMessage) {
 		return model.ErrNoPermission
 	}
+	id := utils.SortUUID()
+	c.r.addChatMessage(&ChatMessage{
+		ID:         id,
+		SenderID:   c.u.ID,
+		SenderName: c.u.Username,
+		Message:    message,
+		SentAt:     time.Now(),
+	})
+	if !c.r.Settings.DisableReadReceipt {
+		c.r.receipts.add(id, c.u.ID, c.r.hub.UserIDs())
+	}
+	events.Users.Publish(events.UserEvent{
+		Type:   events.UserChatMessage,
+		UserID: c.u.ID,
+		RoomID: c.r.ID,
+	})
 	return c.Broadcast(&pb.ElementMessage{
 		Type: pb.ElementMessageType_CHAT_MESSAGE,
 		Time: time.Now().UnixMilli(),
 		ChatResp: &pb.ChatResp{
+			Id:      id,
 			Message: message,
 			Sender: &pb.Sender{
 				Userid:   c.u.ID,
@@ -60,14 +100,136 @@ func (c *Client) SendChatMessage(message string) error {
 	})
 }
 
+func (c *Client) SetTyping(typing bool) error {
+	if c.r.Settings.DisableTypingIndicator {
+		return nil
+	}
+	if !c.u.HasRoomPermission(c.r, model.PermissionSendChatMessage) {
+		return model.ErrNoPermission
+	}
+	return c.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_TYPING,
+		Typing: &pb.Typing{
+			Typing: typing,
+			Sender: &pb.Sender{
+				Userid:   c.u.ID,
+				Username: c.u.Username,
+			},
+		},
+	}, WithIgnoreId(c.u.ID))
+}
+
+func (c *Client) ReadChatMessage(messageID string) error {
+	if c.r.Settings.DisableReadReceipt {
+		return nil
+	}
+	delivered, read, changed, err := c.r.receipts.read(messageID, c.u.ID)
+	if err != nil || !changed {
+		return err
+	}
+	return c.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_READ_RECEIPT,
+		ReadReceipt: &pb.ReadReceipt{
+			MessageId: messageID,
+			Delivered: delivered,
+			Read:      read,
+		},
+	})
+}
+
+func (c *Client) AudioOnly() bool {
+	return atomic.LoadUint32(&c.audioOnly) == 1
+}
+
+// SetAudioOnly toggles the audio-only listening mode of the client,
+// the mode is reflected in the presence of the user
+func (c *Client) SetAudioOnly(audioOnly bool) error {
+	var v uint32
+	if audioOnly {
+		v = 1
+	}
+	if atomic.SwapUint32(&c.audioOnly, v) == v {
+		return nil
+	}
+	return c.r.hub.updatePresence(c.u)
+}
+
+func (c *Client) ResumeToken() string {
+	return c.resumeToken
+}
+
+func (c *Client) SendResumeToken(resumed bool) error {
+	return c.Send(&pb.ElementMessage{
+		Type:        pb.ElementMessageType_RESUME_TOKEN,
+		ResumeToken: c.resumeToken,
+		Resumed:     resumed,
+	})
+}
+
 func (c *Client) Send(msg Message) error {
+	if b := c.resume.Load(); b != nil {
+		return b.push(msg)
+	}
+	return c.send(c.trackAck(msg))
+}
+
+func (c *Client) send(msg Message) error {
+	evict, err := c.enqueue(msg)
+	if evict {
+		_ = c.Evict(EvictReasonSlowConsumer)
+		return ErrClientEvicted
+	}
+	return err
+}
+
+// enqueue never blocks, a client whose queues stay full is degraded to
+// sync-only and then evicted
+func (c *Client) enqueue(msg Message) (evict bool, err error) {
 	c.wg.Add(1)
 	defer c.wg.Done()
 	if c.Closed() {
-		return ErrAlreadyClosed
+		return false, ErrAlreadyClosed
 	}
-	c.c <- msg
-	return nil
+	l := messageLane(msg)
+	now := time.Now()
+	switch c.bp.admit(c, l, now) {
+	case backpressureEvict:
+		c.logHub(l, "evicted while degraded")
+		return true, nil
+	case backpressureDrop:
+		c.logHub(l, "dropped message of degraded client")
+		return false, nil
+	case backpressureRecover:
+		c.logHub(l, "recovered from degraded")
+		c.notifyDegraded(false)
+	}
+	select {
+	case c.lanes[l] <- msg:
+		c.bp.sent()
+		return false, nil
+	default:
+	}
+	c.logHub(l, "dropped message, queue is full")
+	switch c.bp.full(now) {
+	case backpressureEvict:
+		c.logHub(l, "evicted, queue stayed full")
+		return true, nil
+	case backpressureDegrade:
+		c.logHub(l, "degraded to sync-only")
+		c.notifyDegraded(true)
+	}
+	return false, nil
+}
+
+func (c *Client) logHub(l lane, msg string) {
+	if !roomlog.Enabled(c.r.ID) {
+		return
+	}
+	roomlog.Log(c.r.ID, roomlog.KindHub, roomlog.Fields{
+		"user":   c.u.ID,
+		"lane":   l.String(),
+		"queued": c.queued(),
+	}, "%s", msg)
 }
 
 func (c *Client) Close() error {
@@ -75,14 +237,34 @@ func (c *Client) Close() error {
 		return ErrAlreadyClosed
 	}
 	c.wg.Wait()
-	close(c.c)
+	for _, l := range c.lanes {
+		close(l)
+	}
 	return nil
 }
 
+// Drop closes the client after its connection was lost, if resuming is
+// enabled the messages sent from now on are kept for the resumed session
+func (c *Client) Drop() error {
+	// evicted clients rejoin cold
+	if c.bp.isEvicted() {
+		return c.Close()
+	}
+	if b := newResumeBuffer(); b != nil {
+		c.resume.CompareAndSwap(nil, b)
+	}
+	return c.Close()
+}
+
+func (c *Client) Dropped() bool {
+	return c.resume.Load() != nil
+}
+
 func (c *Client) Closed() bool {
 	return atomic.LoadUint32(&c.closed) == 1
 }
 
+// GetReadChan returns the messages to write, higher priority lanes first
 func (c *Client) GetReadChan() <-chan Message {
 	return c.c
 }
@@ -91,14 +273,94 @@ func (c *Client) NextWriter(messageType int) (io.WriteCloser, error) {
 	return c.conn.NextWriter(messageType)
 }
 
+func (c *Client) WriteMessage(messageType int, data []byte) error {
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// EnableWriteCompression toggles compression of the next written messages,
+// it is a no-op when permessage-deflate was not negotiated
+func (c *Client) EnableWriteCompression(enable bool) {
+	c.conn.EnableWriteCompression(enable)
+}
+
 func (c *Client) NextReader() (int, io.Reader, error) {
 	return c.conn.NextReader()
 }
 
 func (c *Client) SetSeekRate(seek float64, rate float64, timeDiff float64) (*Status, error) {
-	return c.u.SetRoomCurrentSeekRate(c.r, seek, rate, timeDiff)
+	s, err := c.u.SetRoomCurrentSeekRate(c.r, seek, rate, timeDiff)
+	c.logSync("seek", roomlog.Fields{"seek": seek, "rate": rate, "timeDiff": timeDiff}, s, err)
+	return s, err
 }
 
 func (c *Client) SetStatus(playing bool, seek float64, rate float64, timeDiff float64) (*Status, error) {
-	return c.u.SetRoomCurrentStatus(c.r, playing, seek, rate, timeDiff)
+	s, err := c.u.SetRoomCurrentStatus(c.r, playing, seek, rate, timeDiff)
+	c.logSync("status", roomlog.Fields{"playing": playing, "seek": seek, "rate": rate, "timeDiff": timeDiff}, s, err)
+	return s, err
+}
+
+// logSync records the status the client asked for and the one the room
+// settled on
+func (c *Client) logSync(action string, fields roomlog.Fields, s *Status, err error) {
+	if !roomlog.Enabled(c.r.ID) {
+		return
+	}
+	fields["user"] = c.u.ID
+	fields["precise"] = c.PreciseSync()
+	if rtt, offset, ok := c.ClockSync(); ok {
+		fields["rtt"] = rtt.Milliseconds()
+		fields["clockOffset"] = offset.Milliseconds()
+	}
+	if err != nil {
+		roomlog.Log(c.r.ID, roomlog.KindSync, fields, "%s rejected: %v", action, err)
+		return
+	}
+	roomlog.Log(c.r.ID, roomlog.KindSync, fields, "%s set to playing=%t seek=%.3f rate=%.2f", action, s.Playing, s.Seek, s.Rate)
+}
+
+// TimeSync sends a clock sync probe to the client, the client should reply
+// with the received serverSendTime and its own receive and send time
+func (c *Client) TimeSync() error {
+	return c.Send(&pb.ElementMessage{
+		Type: pb.ElementMessageType_TIME_SYNC,
+		TimeSync: &pb.TimeSync{
+			ServerSendTime: time.Now().UnixMilli(),
+		},
+	})
+}
+
+func (c *Client) UpdateClockSync(ts *pb.TimeSync) (rtt, offset time.Duration) {
+	return c.clock.update(ts.ServerSendTime, ts.ClientReceiveTime, ts.ClientSendTime, time.Now().UnixMilli())
+}
+
+func (c *Client) ClockSync() (rtt, offset time.Duration, ok bool) {
+	return c.clock.get()
+}
+
+func (c *Client) ClockSkewed() bool {
+	_, offset, ok := c.clock.get()
+	return ok && (offset > maxClockSkew || offset < -maxClockSkew)
+}
+
+const maxTimeDiff = 1.5
+
+// TimeDiff returns the seconds elapsed since the client timestamp, in server time.
+// if the client clock is skewed, the timestamp is corrected with the measured offset,
+// otherwise the client timestamp is trusted.
+func (c *Client) TimeDiff(clientTime int64) float64 {
+	if clientTime == 0 {
+		return 0
+	}
+	t := time.UnixMilli(clientTime)
+	if c.ClockSkewed() {
+		_, offset, _ := c.clock.get()
+		t = t.Add(-offset)
+	}
+	timeDiff := time.Since(t).Seconds()
+	if timeDiff < 0 {
+		return 0
+	} else if timeDiff > maxTimeDiff {
+		return maxTimeDiff
+	}
+	return timeDiff
 }
@@ -0,0 +1,68 @@
+package op
+
+import (
+	"sync"
+	"time"
+)
+
+// if the measured offset between client and server clocks is larger than
+// this, the client timestamps are considered skewed and are corrected
+const maxClockSkew = time.Millisecond * 500
+
+// samples are rejected against the lowest rtt of the latest ones, so a
+// client whose rtt rose for good is accepted again once they are all higher
+const clockRTTWindow = 8
+
+type clockSync struct {
+	lock    sync.RWMutex
+	rtt     time.Duration
+	offset  time.Duration
+	samples int
+	// rtts of the latest samples, rejected ones included
+	recent [clockRTTWindow]time.Duration
+	seen   int
+}
+
+func (c *clockSync) minRTT() time.Duration {
+	n := min(c.seen, clockRTTWindow)
+	m := c.recent[0]
+	for _, rtt := range c.recent[1:n] {
+		m = min(m, rtt)
+	}
+	return m
+}
+
+// update uses the four ntp timestamps (all in unix milli) to calculate the
+// round trip time and the offset of the client clock relative to the server
+func (c *clockSync) update(serverSend, clientReceive, clientSend, serverReceive int64) (rtt, offset time.Duration) {
+	rtt = time.Duration((serverReceive-serverSend)-(clientSend-clientReceive)) * time.Millisecond
+	offset = time.Duration(((clientReceive-serverSend)+(clientSend-serverReceive))/2) * time.Millisecond
+	if rtt < 0 {
+		rtt = 0
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.recent[c.seen%clockRTTWindow] = rtt
+	c.seen++
+	// samples with a much larger rtt than the recent ones are mostly caused
+	// by network jitter, the offset calculated from them is not reliable
+	if c.samples != 0 && rtt > c.minRTT()*2+time.Millisecond*50 {
+		return c.rtt, c.offset
+	}
+	if c.samples == 0 {
+		c.rtt = rtt
+		c.offset = offset
+	} else {
+		c.rtt = (c.rtt*7 + rtt) / 8
+		c.offset = (c.offset*7 + offset) / 8
+	}
+	c.samples++
+	return c.rtt, c.offset
+}
+
+func (c *clockSync) get() (rtt, offset time.Duration, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.rtt, c.offset, c.samples != 0
+}
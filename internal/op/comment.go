@@ -0,0 +1,116 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/notify"
+)
+
+// distinct emojis a comment can be reacted with
+const maxCommentReactions = 20
+
+var ErrTooManyReactions = errors.New("too many reactions")
+
+// AddMovieComment adds a comment to the movie, a reply to a reply is added
+// to the thread of its root comment
+func (u *User) AddMovieComment(room *Room, movieID, parentID, content string) (*model.MovieComment, error) {
+	if u.IsGuest() || !u.HasRoomPermission(room, model.PermissionSendChatMessage) {
+		return nil, model.ErrNoPermission
+	}
+	movie, err := room.GetMovieByID(movieID)
+	if err != nil {
+		return nil, err
+	}
+	comment := &model.MovieComment{
+		RoomID:    room.ID,
+		MovieID:   movieID,
+		CreatorID: u.ID,
+		Content:   content,
+	}
+	// notify the author of the thread, or the movie creator for a new thread
+	notifyID := movie.CreatorID
+	if parentID != "" {
+		parent, err := db.GetMovieComment(room.ID, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.MovieID != movieID {
+			return nil, errors.New("parent comment is not of the movie")
+		}
+		if parent.ParentID != "" {
+			parent, err = db.GetMovieComment(room.ID, parent.ParentID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		comment.ParentID = parent.ID
+		notifyID = parent.CreatorID
+	}
+	if err := db.CreateMovieComment(comment); err != nil {
+		return nil, err
+	}
+	if notifyID != "" && notifyID != u.ID {
+		notify.Notify(&notify.Notification{
+			Event:   notify.EventMovieComment,
+			Title:   room.Name,
+			Message: fmt.Sprintf("%s commented on %s", u.Username, movie.Movie.MovieBase.Name),
+		}, notifyID)
+	}
+	return comment, nil
+}
+
+// EditMovieComment changes the content of a comment, only its author can edit it
+func (u *User) EditMovieComment(room *Room, id, content string) error {
+	comment, err := db.GetMovieComment(room.ID, id)
+	if err != nil {
+		return err
+	}
+	if comment.CreatorID != u.ID {
+		return model.ErrNoPermission
+	}
+	return db.UpdateMovieCommentContent(room.ID, id, content)
+}
+
+// DeleteMovieComment deletes a comment with its replies, admins that can
+// delete chat messages can delete the comments of others
+func (u *User) DeleteMovieComment(room *Room, id string) error {
+	comment, err := db.GetMovieComment(room.ID, id)
+	if err != nil {
+		return err
+	}
+	own := comment.CreatorID == u.ID
+	if !own && !u.HasRoomAdminPermission(room, model.PermissionDeleteChatMessage) {
+		return model.ErrNoPermission
+	}
+	if err := db.DeleteMovieComment(room.ID, id); err != nil {
+		return err
+	}
+	if !own {
+		u.roomAudit(room, model.RoomAuditActionDeleteComment, id)
+	}
+	return nil
+}
+
+func (u *User) ReactMovieComment(room *Room, id, emoji string, remove bool) error {
+	if u.IsGuest() || !u.HasRoomPermission(room, model.PermissionSendChatMessage) {
+		return model.ErrNoPermission
+	}
+	if _, err := db.GetMovieComment(room.ID, id); err != nil {
+		return err
+	}
+	if remove {
+		return db.RemoveMovieCommentReaction(id, u.ID, emoji)
+	}
+	emojis, err := db.GetMovieCommentReactionEmojis(id)
+	if err != nil {
+		return err
+	}
+	if len(emojis) >= maxCommentReactions && !slices.Contains(emojis, emoji) {
+		return ErrTooManyReactions
+	}
+	return db.AddMovieCommentReaction(id, u.ID, emoji)
+}
@@ -1,6 +1,7 @@
 package op
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -8,6 +9,12 @@ import (
 type current struct {
 	current Current
 	lock    sync.RWMutex
+	clock   Clock
+	// set while the room is recording a trace
+	trace *traceRecorder
+	// bumped on every movie or status change
+	version uint64
+	changed chan struct{}
 }
 
 type Current struct {
@@ -21,13 +28,48 @@ type CurrentMovie struct {
 }
 
 func newCurrent() *current {
+	return newCurrentWithClock(realClock{})
+}
+
+func newCurrentWithClock(clock Clock) *current {
 	return &current{
 		current: Current{
-			Status: newStatus(),
+			Status: newStatus(clock.Now()),
 		},
+		clock:   clock,
+		changed: make(chan struct{}),
 	}
 }
 
+func (c *current) notifyLocked() {
+	c.version++
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *current) Version() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.version
+}
+
+// Wait blocks until the version differs from the given one or ctx is done,
+// and returns the latest version
+func (c *current) Wait(ctx context.Context, version uint64) uint64 {
+	c.lock.RLock()
+	if c.version != version {
+		defer c.lock.RUnlock()
+		return c.version
+	}
+	changed := c.changed
+	c.lock.RUnlock()
+	select {
+	case <-changed:
+	case <-ctx.Done():
+	}
+	return c.Version()
+}
+
 type Status struct {
 	Seek       float64   `json:"seek"`
 	Rate       float64   `json:"rate"`
@@ -35,18 +77,30 @@ type Status struct {
 	lastUpdate time.Time `json:"-"`
 }
 
-func newStatus() Status {
+// UpdatedAt is the time the seek was last set or extrapolated to
+func (s Status) UpdatedAt() time.Time {
+	return s.lastUpdate
+}
+
+func newStatus(now time.Time) Status {
 	return Status{
 		Seek:       0,
 		Rate:       1.0,
-		lastUpdate: time.Now(),
+		lastUpdate: now,
 	}
 }
 
 func (c *current) Current() Current {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	c.current.UpdateStatus()
+	c.current.updateStatus(c.clock.Now())
+	return c.current
+}
+
+// Snapshot returns the current as last set, without extrapolating the seek
+func (c *current) Snapshot() Current {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 	return c.current
 }
 
@@ -55,14 +109,34 @@ func (c *current) SetMovie(movie CurrentMovie, play bool) {
 	defer c.lock.Unlock()
 
 	c.current.Movie = movie
-	c.current.SetSeek(0, 0)
+	c.current.setSeek(0, 0, c.clock.Now())
 	c.current.Status.Playing = play
+	c.notifyLocked()
+	if c.trace != nil {
+		c.trace.record(c.clock.Now(), TraceEvent{
+			Type:    TraceEventMovie,
+			Playing: play,
+			MovieID: movie.ID,
+			IsLive:  movie.IsLive,
+		}, c.current.Status)
+	}
+}
+
+// restore sets the movie and status recovered from the write-ahead log,
+// the seek is extrapolated from the time the status was logged
+func (c *current) restore(movie CurrentMovie, status Status) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.current.Movie = movie
+	c.current.Status = status
+	c.notifyLocked()
 }
 
 func (c *current) Status() Status {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	c.current.UpdateStatus()
+	c.current.updateStatus(c.clock.Now())
 	return c.current.Status
 }
 
@@ -70,7 +144,17 @@ func (c *current) SetStatus(playing bool, seek, rate, timeDiff float64) *Status
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	s := c.current.SetStatus(playing, seek, rate, timeDiff)
+	s := c.current.setStatus(playing, seek, rate, timeDiff, c.clock.Now())
+	c.notifyLocked()
+	if c.trace != nil {
+		c.trace.record(c.clock.Now(), TraceEvent{
+			Type:     TraceEventStatus,
+			Playing:  playing,
+			Seek:     seek,
+			Rate:     rate,
+			TimeDiff: timeDiff,
+		}, s)
+	}
 	return &s
 }
 
@@ -78,33 +162,50 @@ func (c *current) SetSeekRate(seek, rate, timeDiff float64) *Status {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	s := c.current.SetSeekRate(seek, rate, timeDiff)
+	s := c.current.setSeekRate(seek, rate, timeDiff, c.clock.Now())
+	c.notifyLocked()
+	if c.trace != nil {
+		c.trace.record(c.clock.Now(), TraceEvent{
+			Type:     TraceEventSeekRate,
+			Seek:     seek,
+			Rate:     rate,
+			TimeDiff: timeDiff,
+		}, s)
+	}
 	return &s
 }
 
 func (c *Current) UpdateStatus() Status {
+	return c.updateStatus(time.Now())
+}
+
+func (c *Current) updateStatus(now time.Time) Status {
 	if c.Movie.IsLive {
-		c.Status.lastUpdate = time.Now()
+		c.Status.lastUpdate = now
 		return c.Status
 	}
 	if c.Status.Playing {
-		c.Status.Seek += time.Since(c.Status.lastUpdate).Seconds() * c.Status.Rate
+		c.Status.Seek += now.Sub(c.Status.lastUpdate).Seconds() * c.Status.Rate
 	}
-	c.Status.lastUpdate = time.Now()
+	c.Status.lastUpdate = now
 	return c.Status
 }
 
-func (c *Current) setLiveStatus() Status {
+func (c *Current) setLiveStatus(now time.Time) Status {
 	c.Status.Playing = true
 	c.Status.Rate = 1.0
 	c.Status.Seek = 0
-	c.Status.lastUpdate = time.Now()
+	c.Status.lastUpdate = now
 	return c.Status
 }
 
 func (c *Current) SetStatus(playing bool, seek, rate, timeDiff float64) Status {
+	return c.setStatus(playing, seek, rate, timeDiff, time.Now())
+}
+
+func (c *Current) setStatus(playing bool, seek, rate, timeDiff float64, now time.Time) Status {
 	if c.Movie.IsLive {
-		return c.setLiveStatus()
+		return c.setLiveStatus(now)
 	}
 	c.Status.Playing = playing
 	c.Status.Rate = rate
@@ -113,13 +214,17 @@ func (c *Current) SetStatus(playing bool, seek, rate, timeDiff float64) Status {
 	} else {
 		c.Status.Seek = seek
 	}
-	c.Status.lastUpdate = time.Now()
+	c.Status.lastUpdate = now
 	return c.Status
 }
 
 func (c *Current) SetSeekRate(seek, rate, timeDiff float64) Status {
+	return c.setSeekRate(seek, rate, timeDiff, time.Now())
+}
+
+func (c *Current) setSeekRate(seek, rate, timeDiff float64, now time.Time) Status {
 	if c.Movie.IsLive {
-		return c.setLiveStatus()
+		return c.setLiveStatus(now)
 	}
 	if c.Status.Playing {
 		c.Status.Seek = seek + (timeDiff * rate)
@@ -127,19 +232,23 @@ func (c *Current) SetSeekRate(seek, rate, timeDiff float64) Status {
 		c.Status.Seek = seek
 	}
 	c.Status.Rate = rate
-	c.Status.lastUpdate = time.Now()
+	c.Status.lastUpdate = now
 	return c.Status
 }
 
 func (c *Current) SetSeek(seek, timeDiff float64) Status {
+	return c.setSeek(seek, timeDiff, time.Now())
+}
+
+func (c *Current) setSeek(seek, timeDiff float64, now time.Time) Status {
 	if c.Movie.IsLive {
-		return c.setLiveStatus()
+		return c.setLiveStatus(now)
 	}
 	if c.Status.Playing {
 		c.Status.Seek = seek + (timeDiff * c.Status.Rate)
 	} else {
 		c.Status.Seek = seek
 	}
-	c.Status.lastUpdate = time.Now()
+	c.Status.lastUpdate = now
 	return c.Status
 }
@@ -0,0 +1,137 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/vendor"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// emby and jellyfin clients report progress about every ten seconds
+const embyReportInterval = 10 * time.Second
+
+// embyPlayback is what an online room member watches on their own server
+type embyPlayback struct {
+	host          string
+	token         string
+	itemID        string
+	playSessionID string
+	positionTicks int64
+}
+
+type embyPlaybackKey struct {
+	roomID string
+	userID string
+}
+
+// StartEmbyPlaybackReport reports the playback of emby movies in rooms to
+// the servers of online members who bound the same server and enabled reports
+func StartEmbyPlaybackReport(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(embyReportInterval)
+		defer ticker.Stop()
+		sessions := map[embyPlaybackKey]*embyPlayback{}
+		for {
+			select {
+			case <-ctx.Done():
+				for _, p := range sessions {
+					reportEmbyPlayback(context.Background(), vendor.EmbyPlaybackStop, p, false)
+				}
+				return
+			case <-ticker.C:
+			}
+			seen := make(map[embyPlaybackKey]struct{}, len(sessions))
+			RangeRoomCache(func(_ string, value *RoomEntry) bool {
+				reportRoomEmbyPlayback(ctx, value.Value(), sessions, seen)
+				return true
+			})
+			for key, p := range sessions {
+				if _, ok := seen[key]; !ok {
+					reportEmbyPlayback(ctx, vendor.EmbyPlaybackStop, p, false)
+					delete(sessions, key)
+				}
+			}
+		}
+	}()
+}
+
+func reportRoomEmbyPlayback(ctx context.Context, room *Room, sessions map[embyPlaybackKey]*embyPlayback, seen map[embyPlaybackKey]struct{}) {
+	current := room.Current()
+	if current.Movie.ID == "" || current.Movie.IsLive {
+		return
+	}
+	movie, err := room.GetMovieByID(current.Movie.ID)
+	if err != nil || movie.VendorInfo.Vendor != model.VendorEmby || movie.VendorInfo.Emby.Channel {
+		return
+	}
+	serverID, itemID, err := movie.VendorInfo.Emby.ServerIDAndFilePath()
+	if err != nil {
+		return
+	}
+	if movie.IsFolder {
+		itemID = movie.SubPath()
+	}
+	if itemID == "" {
+		return
+	}
+	positionTicks := int64(current.Status.Seek * vendor.EmbyTicksPerSecond)
+	for _, p := range room.Presences() {
+		userE, err := LoadOrInitUserByID(p.UserID)
+		if err != nil {
+			continue
+		}
+		user := userE.Value()
+		if user.IsGuest() {
+			continue
+		}
+		data, err := user.EmbyCache().LoadOrStore(ctx, serverID)
+		if err != nil || !data.ReportPlayback {
+			continue
+		}
+		key := embyPlaybackKey{roomID: room.ID, userID: user.ID}
+		seen[key] = struct{}{}
+		session, ok := sessions[key]
+		if ok && session.itemID == itemID && session.host == data.Host {
+			session.positionTicks = positionTicks
+			reportEmbyPlayback(ctx, vendor.EmbyPlaybackProgress, session, !current.Status.Playing)
+			continue
+		}
+		if ok {
+			reportEmbyPlayback(ctx, vendor.EmbyPlaybackStop, session, false)
+		}
+		session = &embyPlayback{
+			host:          data.Host,
+			token:         data.ApiKey,
+			itemID:        itemID,
+			playSessionID: utils.SortUUID(),
+			positionTicks: positionTicks,
+		}
+		sessions[key] = session
+		reportEmbyPlayback(ctx, vendor.EmbyPlaybackStart, session, !current.Status.Playing)
+	}
+}
+
+func reportEmbyPlayback(ctx context.Context, event vendor.EmbyPlaybackEvent, p *embyPlayback, paused bool) {
+	ctx, cancel := context.WithTimeout(ctx, embyReportInterval/2)
+	defer cancel()
+	report := &vendor.EmbyPlaybackReport{
+		ItemID:        p.itemID,
+		PlaySessionID: p.playSessionID,
+		PositionTicks: p.positionTicks,
+		IsPaused:      paused,
+		CanSeek:       true,
+		PlayMethod:    "DirectStream",
+	}
+	if event == vendor.EmbyPlaybackProgress {
+		report.EventName = "TimeUpdate"
+		if paused {
+			report.EventName = "Pause"
+		}
+	}
+	if err := vendor.EmbyReportPlayback(ctx, p.host, p.token, event, report); err != nil {
+		log.Debugf("emby playback report %s of item %s error: %v", event, p.itemID, err)
+	}
+}
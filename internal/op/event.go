@@ -0,0 +1,62 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/notify"
+)
+
+func (u *User) CreateRoomEvent(room *Room, event *model.RoomEvent) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionManageRoomEvent) {
+		return model.ErrNoPermission
+	}
+	if event.MovieID != "" {
+		if _, err := room.GetMovieByID(event.MovieID); err != nil {
+			return err
+		}
+	}
+	event.RoomID = room.ID
+	event.CreatorID = u.ID
+	if err := db.CreateRoomEvent(event); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionCreateRoomEvent, event.ID)
+	if event.Type == model.RoomEventTypePlayback {
+		notify.NotifyRoomFollowers(room.ID, &notify.Notification{
+			Event:   notify.EventScheduledMovie,
+			Title:   room.Name,
+			Message: fmt.Sprintf("%s scheduled at %s", event.Title, event.StartAt.Format("2006-01-02 15:04 MST")),
+		}, u.ID)
+	}
+	return nil
+}
+
+func (u *User) EditRoomEvent(room *Room, event *model.RoomEvent) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionManageRoomEvent) {
+		return model.ErrNoPermission
+	}
+	if event.MovieID != "" {
+		if _, err := room.GetMovieByID(event.MovieID); err != nil {
+			return err
+		}
+	}
+	event.RoomID = room.ID
+	if err := db.SaveRoomEvent(event); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionEditRoomEvent, event.ID)
+	return nil
+}
+
+func (u *User) DeleteRoomEvent(room *Room, id string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionManageRoomEvent) {
+		return model.ErrNoPermission
+	}
+	if err := db.DeleteRoomEvent(room.ID, id); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionDeleteRoomEvent, id)
+	return nil
+}
@@ -3,6 +3,7 @@ package op
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,25 +16,54 @@ import (
 )
 
 type clients struct {
-	lock sync.RWMutex
-	m    map[*Client]struct{}
+	lock      sync.RWMutex
+	m         map[*Client]struct{}
+	presence  pb.PresenceState
+	audioOnly bool
+}
+
+// audioOnlyLocked reports whether any client of the user listens in audio-only mode
+func (c *clients) audioOnlyLocked() bool {
+	for cli := range c.m {
+		if cli.AudioOnly() {
+			return true
+		}
+	}
+	return false
 }
 
 type Hub struct {
 	id        string
 	clients   rwmap.RWMap[string, *clients]
-	broadcast chan *broadcastMessage
+	resumes   rwmap.RWMap[string, *resumeSession]
+	broadcast [laneCount]chan *broadcastMessage
 	exit      chan struct{}
 	closed    uint32
 	wg        sync.WaitGroup
+	// versions of the room state served to polling clients, they start over
+	// with every hub so the epoch tells them apart
+	epoch    int64
+	versions [resourceCount]atomic.Uint64
 
 	once utils.Once
 }
 
+// Resource is a part of the room state clients without a websocket poll
+type Resource int
+
+const (
+	ResourceMovies Resource = iota
+	ResourceMembers
+	ResourceSettings
+	resourceCount
+)
+
 type broadcastMessage struct {
 	data         Message
 	ignoreClient []*Client
 	ignoreId     []string
+	capability   Capability
+	fallback     Message
 }
 
 type BroadcastConf func(*broadcastMessage)
@@ -50,26 +80,54 @@ func WithIgnoreId(id ...string) BroadcastConf {
 	}
 }
 
+// WithFallback sends fallback to the clients without the capability
+func WithFallback(capability Capability, fallback Message) BroadcastConf {
+	return func(bm *broadcastMessage) {
+		bm.capability = capability
+		bm.fallback = fallback
+	}
+}
+
 func newHub(id string) *Hub {
 	return &Hub{
 		id:        id,
-		broadcast: make(chan *broadcastMessage, 128),
+		broadcast: newLanes[*broadcastMessage](),
 		exit:      make(chan struct{}),
+		epoch:     time.Now().UnixNano(),
+	}
+}
+
+func (h *Hub) bump(r Resource) {
+	h.versions[r].Add(1)
+}
+
+// Version identifies the state of the resources, it changes whenever one of
+// them changes
+func (h *Hub) Version(rs ...Resource) string {
+	b := strconv.AppendInt(nil, h.epoch, 36)
+	for _, r := range rs {
+		b = append(b, '.')
+		b = strconv.AppendUint(b, h.versions[r].Load(), 36)
 	}
+	return string(b)
 }
 
 func (h *Hub) Start() error {
 	h.once.Do(func() {
-		go h.serve()
+		for l := range h.broadcast {
+			go h.serve(lane(l))
+		}
 		go h.ping()
 	})
 	return nil
 }
 
-func (h *Hub) serve() error {
+// serve delivers the broadcasts of a lane, lanes are served independently
+// so a client slow to take chat never holds back control messages
+func (h *Hub) serve(l lane) error {
 	for {
 		select {
-		case message := <-h.broadcast:
+		case message := <-h.broadcast[l]:
 			h.devMessage(message.data)
 			h.clients.Range(func(id string, clients *clients) bool {
 				clients.lock.RLock()
@@ -81,7 +139,11 @@ func (h *Hub) serve() error {
 					if utils.In(message.ignoreClient, c) {
 						continue
 					}
-					if err := c.Send(message.data); err != nil {
+					data := message.data
+					if message.fallback != nil && !c.Capabilities().Has(message.capability) {
+						data = message.fallback
+					}
+					if err := c.Send(data); err != nil {
 						c.Close()
 					}
 				}
@@ -89,7 +151,7 @@ func (h *Hub) serve() error {
 				return true
 			})
 		case <-h.exit:
-			log.Debugf("hub: %s, closed", h.id)
+			log.Debugf("hub: %s, %s lane closed", h.id, l)
 			return nil
 		}
 	}
@@ -101,10 +163,16 @@ func (h *Hub) ping() {
 	var (
 		pre     int64 = 0
 		current int64
+		ticks   uint64
 	)
 	for {
 		select {
 		case <-ticker.C:
+			ticks++
+			if ticks%timeSyncTicks == 0 {
+				h.timeSync()
+			}
+			h.checkPresence()
 			current = h.PeopleNum()
 			if current != pre {
 				if err := h.Broadcast(&pb.ElementMessage{
@@ -125,6 +193,105 @@ func (h *Hub) ping() {
 	}
 }
 
+// every 30 seconds
+const timeSyncTicks = 6
+
+func (h *Hub) timeSync() {
+	var all []*Client
+	h.clients.Range(func(id string, clients *clients) bool {
+		clients.lock.RLock()
+		for c := range clients.m {
+			all = append(all, c)
+		}
+		clients.lock.RUnlock()
+		return true
+	})
+	for _, c := range all {
+		if err := c.TimeSync(); err != nil {
+			c.Close()
+		}
+	}
+}
+
+func (h *Hub) checkPresence() {
+	var changed []Message
+	defer func() {
+		for _, msg := range changed {
+			_ = h.Broadcast(msg)
+		}
+	}()
+	h.clients.Range(func(id string, clients *clients) bool {
+		clients.lock.Lock()
+		defer clients.lock.Unlock()
+		var u *User
+		for c := range clients.m {
+			u = c.u
+			break
+		}
+		if u == nil {
+			return true
+		}
+		lastAct := u.LastAct()
+		if idleTimeout(lastAct) {
+			log.Debugf("hub: %s, user %s idle timeout", h.id, id)
+			for c := range clients.m {
+				c.Close()
+			}
+			return true
+		}
+		state := presenceState(lastAct)
+		audioOnly := clients.audioOnlyLocked()
+		if state == clients.presence && audioOnly == clients.audioOnly {
+			return true
+		}
+		clients.presence = state
+		clients.audioOnly = audioOnly
+		changed = append(changed, newPresenceMessage(u, state, audioOnly))
+		return true
+	})
+}
+
+// updatePresence broadcasts the presence of the user if it changed
+func (h *Hub) updatePresence(u *User) error {
+	clients, ok := h.clients.Load(u.ID)
+	if !ok {
+		return nil
+	}
+	clients.lock.Lock()
+	state := presenceState(u.LastAct())
+	audioOnly := clients.audioOnlyLocked()
+	if state == clients.presence && audioOnly == clients.audioOnly {
+		clients.lock.Unlock()
+		return nil
+	}
+	clients.presence = state
+	clients.audioOnly = audioOnly
+	clients.lock.Unlock()
+	return h.Broadcast(newPresenceMessage(u, state, audioOnly))
+}
+
+func (h *Hub) Presences() []*Presence {
+	presences := make([]*Presence, 0, h.clients.Len())
+	h.clients.Range(func(id string, clients *clients) bool {
+		clients.lock.RLock()
+		defer clients.lock.RUnlock()
+		for c := range clients.m {
+			lastAct := c.u.LastAct()
+			presences = append(presences, &Presence{
+				UserID:      c.u.ID,
+				Username:    c.u.Username,
+				State:       presenceState(lastAct),
+				LastActive:  lastAct,
+				OnlineCount: len(clients.m),
+				AudioOnly:   clients.audioOnlyLocked(),
+			})
+			break
+		}
+		return true
+	})
+	return presences
+}
+
 func (h *Hub) devMessage(msg Message) {
 	switch msg.MessageType() {
 	case websocket.BinaryMessage:
@@ -154,7 +321,9 @@ func (h *Hub) Close() error {
 		return true
 	})
 	h.wg.Wait()
-	close(h.broadcast)
+	for _, l := range h.broadcast {
+		close(l)
+	}
 	return nil
 }
 
@@ -170,7 +339,7 @@ func (h *Hub) Broadcast(data Message, conf ...BroadcastConf) error {
 		c(msg)
 	}
 	select {
-	case h.broadcast <- msg:
+	case h.broadcast[messageLane(data)] <- msg:
 		return nil
 	case <-h.exit:
 		return ErrAlreadyClosed
@@ -224,6 +393,16 @@ func (h *Hub) PeopleNum() int64 {
 	return h.clients.Len()
 }
 
+// UserIDs returns the users with a client in the room
+func (h *Hub) UserIDs() []string {
+	ids := make([]string, 0, h.clients.Len())
+	h.clients.Range(func(id string, _ *clients) bool {
+		ids = append(ids, id)
+		return true
+	})
+	return ids
+}
+
 func (h *Hub) SendToUser(userID string, data Message) (err error) {
 	if h.Closed() {
 		return ErrAlreadyClosed
@@ -269,9 +448,16 @@ func (h *Hub) KickUser(userID string) error {
 		return nil
 	}
 	cli.lock.RLock()
-	defer cli.lock.RUnlock()
+	detached := make([]*Client, 0, len(cli.m))
 	for c := range cli.m {
+		if c.Dropped() {
+			detached = append(detached, c)
+		}
 		c.Close()
 	}
+	cli.lock.RUnlock()
+	for _, c := range detached {
+		h.cancelResume(c)
+	}
 	return nil
 }
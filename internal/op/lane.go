@@ -0,0 +1,121 @@
+package op
+
+import (
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+// lane is a priority class of the hub send path, each lane has its own queue
+// so a chat flood never delays a sync control message
+type lane int
+
+const (
+	// playback control and sync, highest priority
+	laneControl lane = iota
+	laneChat
+	// presence, typing, people count and pings, lowest priority
+	lanePresence
+
+	laneCount
+)
+
+var laneQueueSize = [laneCount]int{
+	laneControl:  128,
+	laneChat:     128,
+	lanePresence: 64,
+}
+
+func (l lane) String() string {
+	switch l {
+	case laneControl:
+		return "control"
+	case laneChat:
+		return "chat"
+	case lanePresence:
+		return "presence"
+	default:
+		return "unknown"
+	}
+}
+
+func messageLane(msg Message) lane {
+	em, ok := msg.(*pb.ElementMessage)
+	if !ok {
+		return lanePresence
+	}
+	switch em.Type {
+	case pb.ElementMessageType_CHAT_MESSAGE,
+		pb.ElementMessageType_CHAT_DELETED,
+		pb.ElementMessageType_PINS_CHANGED,
+		pb.ElementMessageType_READ_RECEIPT:
+		return laneChat
+	case pb.ElementMessageType_PRESENCE_CHANGED,
+		pb.ElementMessageType_TYPING,
+		pb.ElementMessageType_PEOPLE_CHANGED,
+		pb.ElementMessageType_AUDIO_ONLY:
+		return lanePresence
+	default:
+		return laneControl
+	}
+}
+
+func newLanes[T any]() (lanes [laneCount]chan T) {
+	for l := range lanes {
+		lanes[l] = make(chan T, laneQueueSize[l])
+	}
+	return
+}
+
+// pumpLanes forwards the lanes to out by priority until all lanes are closed,
+// then closes out
+func pumpLanes[T any](lanes [laneCount]chan T, out chan<- T) {
+	defer close(out)
+	open := laneCount
+	for open > 0 {
+		// take the highest priority lane with a queued message
+		var (
+			v     T
+			ok    bool
+			found bool
+		)
+		for l := range lanes {
+			if lanes[l] == nil {
+				continue
+			}
+			select {
+			case v, ok = <-lanes[l]:
+				found = true
+				if !ok {
+					lanes[l] = nil
+					open--
+				}
+			default:
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			// all lanes are empty, wait for any
+			select {
+			case v, ok = <-lanes[laneControl]:
+				if !ok {
+					lanes[laneControl] = nil
+					open--
+				}
+			case v, ok = <-lanes[laneChat]:
+				if !ok {
+					lanes[laneChat] = nil
+					open--
+				}
+			case v, ok = <-lanes[lanePresence]:
+				if !ok {
+					lanes[lanePresence] = nil
+					open--
+				}
+			}
+		}
+		if ok {
+			out <- v
+		}
+	}
+}
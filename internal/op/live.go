@@ -0,0 +1,65 @@
+package op
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	rtmps "github.com/zijiren233/livelib/server"
+)
+
+const (
+	liveSessionPollInterval = time.Second
+	// wait for the publisher to start pushing after auth
+	liveSessionStartTimeout = time.Second * 10
+	// sessions shorter than this are not recorded
+	liveSessionMinDuration = time.Second * 30
+)
+
+// PublishChannel returns the channel of the movie for a rtmp publisher,
+// and records the live session once the publisher stops pushing
+func (r *Room) PublishChannel(movieID string) (*rtmps.Channel, error) {
+	movie, err := r.GetMovieByID(movieID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := movie.Channel()
+	if err != nil {
+		return nil, err
+	}
+	go r.watchLiveSession(movie.Movie.Clone(), c)
+	return c, nil
+}
+
+func (r *Room) watchLiveSession(movie *model.Movie, c *rtmps.Channel) {
+	ticker := time.NewTicker(liveSessionPollInterval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(liveSessionStartTimeout)
+	for !c.InPublication() {
+		if c.Closed() || time.Now().After(deadline) {
+			return
+		}
+		<-ticker.C
+	}
+
+	session := &model.LiveSession{
+		RoomID:    r.ID,
+		MovieID:   movie.ID,
+		MovieName: movie.Name,
+		CreatorID: movie.CreatorID,
+		StartedAt: time.Now(),
+	}
+	for c.InPublication() && !c.Closed() {
+		<-ticker.C
+	}
+	session.EndedAt = time.Now()
+
+	if session.EndedAt.Sub(session.StartedAt) < liveSessionMinDuration {
+		return
+	}
+	if err := db.CreateLiveSession(session); err != nil {
+		log.Errorf("create live session error: %v", err)
+	}
+}
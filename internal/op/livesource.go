@@ -0,0 +1,180 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/go-uhc"
+	"github.com/zijiren233/livelib/av"
+	"github.com/zijiren233/livelib/container/flv"
+	"github.com/zijiren233/livelib/protocol/hls"
+	rtmpProto "github.com/zijiren233/livelib/protocol/rtmp"
+	"github.com/zijiren233/livelib/protocol/rtmp/core"
+	rtmps "github.com/zijiren233/livelib/server"
+)
+
+// proxied live movies share one pull and one channel per source, so rooms
+// playing the same stream do not each connect to it. a source is reference
+// counted by the movies using it and closed once unused for the idle timeout
+
+type liveSource struct {
+	channel *rtmps.Channel
+	refs    int
+	idle    *time.Timer
+}
+
+var liveSources = struct {
+	lock sync.Mutex
+	m    map[string]*liveSource
+}{
+	m: make(map[string]*liveSource),
+}
+
+// acquireLiveSource returns the channel of the source, a new channel is
+// pulled with pull. every acquire must be paired with a releaseLiveSource
+func acquireLiveSource(key string, pull func(c *rtmps.Channel)) (*rtmps.Channel, error) {
+	liveSources.lock.Lock()
+	defer liveSources.lock.Unlock()
+	if s, ok := liveSources.m[key]; ok && !s.channel.Closed() {
+		s.refs++
+		if s.idle != nil {
+			s.idle.Stop()
+			s.idle = nil
+		}
+		return s.channel, nil
+	}
+	c := rtmps.NewChannel()
+	if err := c.InitHlsPlayer(hls.WithGenTsNameFunc(genTsName)); err != nil {
+		return nil, fmt.Errorf("init live hls player error: %v", err)
+	}
+	liveSources.m[key] = &liveSource{channel: c, refs: 1}
+	go pull(c)
+	return c, nil
+}
+
+func releaseLiveSource(key string, c *rtmps.Channel) {
+	liveSources.lock.Lock()
+	defer liveSources.lock.Unlock()
+	s, ok := liveSources.m[key]
+	if !ok || s.channel != c {
+		closeLiveChannel(c)
+		return
+	}
+	s.refs--
+	if s.refs > 0 {
+		return
+	}
+	timeout := time.Duration(settings.LiveSourceIdleTimeout.Get()) * time.Second
+	if timeout <= 0 {
+		delete(liveSources.m, key)
+		closeLiveChannel(c)
+		return
+	}
+	s.idle = time.AfterFunc(timeout, func() {
+		liveSources.lock.Lock()
+		defer liveSources.lock.Unlock()
+		if liveSources.m[key] == s && s.refs == 0 {
+			delete(liveSources.m, key)
+			closeLiveChannel(s.channel)
+		}
+	})
+}
+
+func closeLiveChannel(c *rtmps.Channel) {
+	if err := c.Close(); err != nil {
+		log.Errorf("close live channel error: %v", err)
+	}
+}
+
+// LiveSourceCount is the number of live sources being pulled
+func LiveSourceCount() int {
+	liveSources.lock.Lock()
+	defer liveSources.lock.Unlock()
+	return len(liveSources.m)
+}
+
+// liveSourceKey identifies the source of a proxied live movie, http sources
+// are also keyed by the creator whose secrets authenticate the pull
+func (m *Movie) liveSourceKey() (string, error) {
+	u, err := url.Parse(m.Movie.MovieBase.Url)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "rtmp":
+		return m.Movie.MovieBase.Url, nil
+	case "http", "https":
+		return m.Movie.CreatorID + "@" + m.Movie.MovieBase.Url, nil
+	default:
+		return "", errors.New("unsupported scheme")
+	}
+}
+
+// pullLive pushes the source into c until c is closed
+func (m *Movie) pullLive(c *rtmps.Channel) {
+	u, err := url.Parse(m.Movie.MovieBase.Url)
+	if err != nil {
+		return
+	}
+	for !c.Closed() {
+		switch u.Scheme {
+		case "rtmp":
+			err = m.pullRtmp(c)
+		default:
+			err = m.pullHttp(c)
+		}
+		if err != nil && !c.Closed() {
+			log.Errorf("push live error: %v", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (m *Movie) pullRtmp(c *rtmps.Channel) error {
+	cli := core.NewConnClient()
+	defer cli.Close()
+	if err := cli.Start(m.Movie.MovieBase.Url, av.PLAY); err != nil {
+		return err
+	}
+	return c.PushStart(rtmpProto.NewReader(cli))
+}
+
+func (m *Movie) pullHttp(c *rtmps.Channel) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, m.Movie.MovieBase.Url, nil)
+	if err != nil {
+		return err
+	}
+	header, err := m.SourceHeader(req.Context(), nil)
+	if err != nil {
+		return fmt.Errorf("get live header error: %w", err)
+	}
+	req.Header = header
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", utils.UA)
+	}
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return c.PushStart(flv.NewReader(resp.Body))
+}
+
+// LiveKey identifies the live stream of the movie, movies proxying the
+// same source have the same key
+func (m *Movie) LiveKey() string {
+	if !m.Movie.MovieBase.RtmpSource {
+		if key, err := m.liveSourceKey(); err == nil {
+			return key
+		}
+	}
+	return m.Movie.ID
+}
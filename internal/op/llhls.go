@@ -0,0 +1,129 @@
+package op
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/llhls"
+	"github.com/synctv-org/synctv/internal/transcode"
+	"github.com/zijiren233/livelib/protocol/httpflv"
+)
+
+// low latency hls streams are packaged from a flv feed of the live channel,
+// the channel itself or a rendition transcoded from it, one per live key and
+// rendition, and torn down once no player requested them for llhlsIdleTimeout
+
+const llhlsIdleTimeout = 30 * time.Second
+
+type llhlsStream struct {
+	*llhls.Stream
+	lastAccess atomic.Int64
+}
+
+func (s *llhlsStream) touch() {
+	s.lastAccess.Store(time.Now().UnixNano())
+}
+
+var llhlsStreams = struct {
+	lock sync.Mutex
+	m    map[string]*llhlsStream
+}{
+	m: make(map[string]*llhlsStream),
+}
+
+// loadLLHls returns the stream of key, a new stream is packaged from the flv
+// feed writes to w until its context is done
+func loadLLHls(key string, feed func(ctx context.Context, w io.Writer) error) *llhls.Stream {
+	llhlsStreams.lock.Lock()
+	defer llhlsStreams.lock.Unlock()
+	if s, ok := llhlsStreams.m[key]; ok && !s.Closed() {
+		s.touch()
+		return s.Stream
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	s := &llhlsStream{Stream: llhls.NewStream(llhls.DefaultPartTarget, llhls.DefaultSegmentTarget)}
+	s.touch()
+	llhlsStreams.m[key] = s
+
+	go func() {
+		pw.CloseWithError(feed(ctx, pw))
+	}()
+	go func() {
+		if err := llhls.DemuxFLV(pr, s.Stream); err != nil && err != io.EOF && !s.Closed() {
+			log.Errorf("llhls demux error: %v", err)
+		}
+		s.Close()
+		cancel()
+		pr.Close()
+		llhlsStreams.lock.Lock()
+		if llhlsStreams.m[key] == s {
+			delete(llhlsStreams.m, key)
+		}
+		llhlsStreams.lock.Unlock()
+	}()
+	go func() {
+		ticker := time.NewTicker(llhlsIdleTimeout / 3)
+		defer ticker.Stop()
+		for range ticker.C {
+			if s.Closed() {
+				return
+			}
+			if time.Since(time.Unix(0, s.lastAccess.Load())) > llhlsIdleTimeout {
+				s.Close()
+				pr.CloseWithError(llhls.ErrStreamClosed)
+				return
+			}
+		}
+	}()
+	return s.Stream
+}
+
+// writeLiveFLV writes the live channel as flv to w until writing fails or
+// the channel is closed
+func (m *Movie) writeLiveFLV(w io.Writer) error {
+	channel, err := m.Channel()
+	if err != nil {
+		return err
+	}
+	fw := httpflv.NewHttpFLVWriter(w)
+	defer fw.Close()
+	if err := channel.AddPlayer(fw); err != nil {
+		return err
+	}
+	return fw.SendPacket()
+}
+
+// LLHls returns the low latency hls stream of the live movie
+func (m *Movie) LLHls() (*llhls.Stream, error) {
+	if _, err := m.Channel(); err != nil {
+		return nil, err
+	}
+	return loadLLHls(m.LiveKey(), func(ctx context.Context, w io.Writer) error {
+		return m.writeLiveFLV(w)
+	}), nil
+}
+
+// LiveRendition returns the low latency hls stream of the live movie
+// transcoded to the rendition of the ladder
+func (m *Movie) LiveRendition(r transcode.Rendition) (*llhls.Stream, error) {
+	if !transcode.EnableLiveLadder.Get() {
+		return nil, transcode.ErrLiveLadderDisabled
+	}
+	if _, err := m.Channel(); err != nil {
+		return nil, err
+	}
+	return loadLLHls(m.LiveKey()+"#"+r.Name, func(ctx context.Context, w io.Writer) error {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go func() {
+			pw.CloseWithError(m.writeLiveFLV(pw))
+		}()
+		return transcode.LiveRendition(ctx, &transcode.Input{Reader: pr, Format: "flv"}, r, w)
+	}), nil
+}
@@ -0,0 +1,121 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/notify"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+const (
+	loginRevokedTTL        = time.Minute * 5
+	loginHistoryCleanEvery = time.Hour
+)
+
+// revoked state of the logins tokens were checked for, keyed by login id
+var loginRevoked = synccache.NewSyncCache[string, bool](time.Minute)
+
+// RecordLogin records a login of the user, a login from a network and with a
+// device neither seen before is reported to the user
+func RecordLogin(u *User, ip, userAgent string) (*model.UserLogin, error) {
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	l := &model.UserLogin{
+		UserID:    u.ID,
+		IP:        ip,
+		UserAgent: userAgent,
+		Network:   model.LoginNetwork(ip),
+		Device:    model.LoginDevice(userAgent),
+	}
+	count, err := db.GetUserLoginsCount(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	// the first login has nothing to compare with
+	if count != 0 {
+		seen, err := db.HasUserLoginFrom(u.ID, l.Network, l.Device)
+		if err != nil {
+			return nil, err
+		}
+		l.Anomalous = !seen
+	}
+	if err := db.CreateUserLogin(l); err != nil {
+		return nil, err
+	}
+	if l.Anomalous {
+		notify.Notify(&notify.Notification{
+			Event: notify.EventNewLogin,
+			Title: "New login",
+			Message: fmt.Sprintf(
+				"%s logged in from a new location (%s). if it was not you, revoke login %s and change your password",
+				u.Username, ip, l.ID,
+			),
+		}, u.ID)
+	}
+	return l, nil
+}
+
+// IsLoginRevoked reports whether tokens of the login are signed out,
+// unknown logins count as revoked
+func IsLoginRevoked(id string) bool {
+	revoked, ok := loginRevoked.Load(id)
+	if ok {
+		return revoked.Value()
+	}
+	l, err := db.GetUserLogin(id)
+	if err != nil {
+		if !errors.Is(err, db.ErrNotFound("login")) {
+			log.Errorf("get login %s error: %v", id, err)
+			return true
+		}
+		// deleted from the login history
+		l = &model.UserLogin{Revoked: true}
+	}
+	revoked, _ = loginRevoked.LoadOrStore(id, l.Revoked, loginRevokedTTL)
+	return revoked.Value()
+}
+
+// RevokeLogins signs out the tokens of the logins, of all logins if ids is empty
+func (u *User) RevokeLogins(ids ...string) (int64, error) {
+	n, err := db.RevokeUserLogins(u.ID, ids...)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		loginRevoked.Range(func(key string, _ *synccache.Entry[bool]) bool {
+			loginRevoked.Delete(key)
+			return true
+		})
+	}
+	for _, id := range ids {
+		loginRevoked.Delete(id)
+	}
+	return n, nil
+}
+
+// StartLoginHistoryCleanup deletes logins older than the login history
+func StartLoginHistoryCleanup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(loginHistoryCleanEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			before := time.Now().Add(-time.Duration(settings.LoginHistoryDays.Get()) * 24 * time.Hour)
+			if _, err := db.DeleteUserLoginsBefore(before); err != nil {
+				log.Errorf("delete login history error: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,110 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/provider/providers"
+	"github.com/zijiren233/gencontainer/synccache"
+	"golang.org/x/oauth2"
+)
+
+const (
+	memberVerdictTTL    = 10 * time.Minute
+	nonMemberVerdictTTL = time.Minute
+)
+
+var ErrNotProviderMember = errors.New("not a member of the group required by the room")
+
+// verdicts of provider membership checks, keyed by user, provider and group
+var membershipVerdicts = synccache.NewSyncCache[string, bool](time.Minute * 5)
+
+// CheckRoomMembership checks the user belongs to the provider group the room
+// requires to join, and is bound to the provider of its organization if the
+// organization restricts its rooms to it
+func (u *User) CheckRoomMembership(ctx context.Context, room *Room) error {
+	if u.IsAdmin() || room.IsCreator(u.ID) || u.IsRoomAdmin(room) {
+		return nil
+	}
+	if err := u.checkOrgProvider(room); err != nil {
+		return err
+	}
+	p, group := room.Settings.JoinProvider, room.Settings.JoinProviderGroup
+	if p == "" || group == "" {
+		return nil
+	}
+	key := fmt.Sprintf("%s:%s:%s", u.ID, p, group)
+	member, ok := membershipVerdicts.Load(key)
+	if !ok {
+		isMember, err := checkProviderMembership(ctx, u.ID, p, group)
+		if err != nil {
+			return err
+		}
+		ttl := nonMemberVerdictTTL
+		if isMember {
+			ttl = memberVerdictTTL
+		}
+		member, _ = membershipVerdicts.LoadOrStore(key, isMember, ttl)
+	}
+	if !member.Value() {
+		return fmt.Errorf("%w: %s %s", ErrNotProviderMember, p, group)
+	}
+	return nil
+}
+
+// SaveProviderToken keeps the token of a provider checking memberships and
+// forgets the verdicts of the user, they are checked again with it
+func SaveProviderToken(userID string, p provider.OAuth2Provider, puid string, tk *oauth2.Token) error {
+	if err := db.SaveUserProviderToken(p, puid, tk); err != nil {
+		return err
+	}
+	prefix := fmt.Sprintf("%s:%s:", userID, p)
+	membershipVerdicts.Range(func(key string, _ *synccache.Entry[bool]) bool {
+		if strings.HasPrefix(key, prefix) {
+			membershipVerdicts.Delete(key)
+		}
+		return true
+	})
+	return nil
+}
+
+func checkProviderMembership(ctx context.Context, userID string, p provider.OAuth2Provider, group string) (bool, error) {
+	pi, err := providers.GetProvider(p)
+	if err != nil {
+		return false, err
+	}
+	mp, ok := pi.(provider.MembershipProvider)
+	if !ok {
+		return false, fmt.Errorf("%s can not check memberships", p)
+	}
+	up, err := db.GetUserProvider(userID, p)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("provider")) {
+			return false, fmt.Errorf("this room requires a bound %s account", p)
+		}
+		return false, err
+	}
+	if up.AccessToken == "" {
+		return false, fmt.Errorf("log in with %s again to join this room", p)
+	}
+	tk := &oauth2.Token{
+		AccessToken:  up.AccessToken,
+		RefreshToken: up.RefreshToken,
+		Expiry:       up.TokenExpiry,
+	}
+	if !tk.Valid() && tk.RefreshToken != "" {
+		tk, err = mp.RefreshToken(ctx, tk.RefreshToken)
+		if err != nil {
+			return false, fmt.Errorf("refresh %s token failed: %w", p, err)
+		}
+		if err := db.SaveUserProviderToken(p, up.ProviderUserID, tk); err != nil {
+			return false, err
+		}
+	}
+	return mp.IsMember(ctx, tk, group)
+}
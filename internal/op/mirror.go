@@ -0,0 +1,446 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	json "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/rwmap"
+	uhc "github.com/zijiren233/go-uhc"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	mirrorMinBackoff = 5 * time.Second
+	mirrorMaxBackoff = 2 * time.Minute
+)
+
+var mirrors rwmap.RWMap[string, *mirror]
+
+// mirror relays the playback of a remote room into a local room,
+// through a websocket client connection to the remote instance
+type mirror struct {
+	roomID    string
+	creatorID string
+	remote    *url.URL
+	token     string
+	cancel    context.CancelFunc
+
+	lock      sync.Mutex
+	connected bool
+	lastErr   error
+	since     time.Time
+	// remote movie id -> local movie id
+	movies map[string]string
+}
+
+type MirrorStatus struct {
+	RemoteURL string
+	Connected bool
+	Error     string
+	Since     time.Time
+}
+
+func checkMirrorURL(remoteURL string) (*url.URL, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("remote url scheme must be http or https")
+	}
+	if u.Host == "" {
+		return nil, errors.New("remote url host is empty")
+	}
+	if !settings.AllowProxyToLocal.Get() {
+		if l, err := utils.ParseURLIsLocalIP(remoteURL); err != nil {
+			return nil, fmt.Errorf("check url is local ip error: %w", err)
+		} else if l {
+			return nil, errors.New("not allow mirror local instance")
+		}
+	}
+	return u, nil
+}
+
+// StartRoomMirrors starts the enabled mirrors, called once at startup
+func StartRoomMirrors() error {
+	ms, err := db.GetEnabledRoomMirrors()
+	if err != nil {
+		return err
+	}
+	for _, m := range ms {
+		if err := startRoomMirror(m); err != nil {
+			log.Errorf("start room mirror %s error: %v", m.RoomID, err)
+		}
+	}
+	return nil
+}
+
+func startRoomMirror(m *model.RoomMirror) error {
+	stopRoomMirror(m.RoomID)
+	if !m.Enabled {
+		return nil
+	}
+	remote, err := checkMirrorURL(m.RemoteURL)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mi := &mirror{
+		roomID:    m.RoomID,
+		creatorID: m.CreatorID,
+		remote:    remote,
+		token:     m.Token,
+		cancel:    cancel,
+		since:     time.Now(),
+		movies:    make(map[string]string),
+	}
+	if old, loaded := mirrors.Swap(m.RoomID, mi); loaded {
+		old.cancel()
+	}
+	go mi.run(ctx)
+	return nil
+}
+
+func stopRoomMirror(roomID string) {
+	if m, loaded := mirrors.LoadAndDelete(roomID); loaded {
+		m.cancel()
+	}
+}
+
+func RoomMirrorStatus(roomID string) (*MirrorStatus, bool) {
+	m, ok := mirrors.Load(roomID)
+	if !ok {
+		return nil, false
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	s := &MirrorStatus{
+		RemoteURL: m.remote.String(),
+		Connected: m.connected,
+		Since:     m.since,
+	}
+	if m.lastErr != nil {
+		s.Error = m.lastErr.Error()
+	}
+	return s, true
+}
+
+func (u *User) SetRoomMirror(room *Room, remoteURL, token string, enabled bool) error {
+	if !u.IsRoomCreator(room) && !u.IsAdmin() {
+		return model.ErrNoPermission
+	}
+	if _, err := checkMirrorURL(remoteURL); err != nil {
+		return err
+	}
+	m := &model.RoomMirror{
+		RoomID:    room.ID,
+		CreatorID: u.ID,
+		RemoteURL: remoteURL,
+		Token:     token,
+		Enabled:   enabled,
+	}
+	if err := db.SaveRoomMirror(m); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionSetRoomMirror, remoteURL)
+	return startRoomMirror(m)
+}
+
+func (u *User) DeleteRoomMirror(room *Room) error {
+	if !u.IsRoomCreator(room) && !u.IsAdmin() {
+		return model.ErrNoPermission
+	}
+	if err := db.DeleteRoomMirror(room.ID); err != nil {
+		return err
+	}
+	stopRoomMirror(room.ID)
+	u.roomAudit(room, model.RoomAuditActionDeleteRoomMirror, "")
+	return nil
+}
+
+func (m *mirror) setState(connected bool, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.connected != connected {
+		m.since = time.Now()
+	}
+	m.connected = connected
+	m.lastErr = err
+}
+
+func (m *mirror) run(ctx context.Context) {
+	backoff := mirrorMinBackoff
+	for {
+		start := time.Now()
+		err := m.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("room mirror %s: disconnected: %v", m.roomID, err)
+		m.setState(false, err)
+		if time.Since(start) > mirrorMaxBackoff {
+			backoff = mirrorMinBackoff
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, mirrorMaxBackoff)
+	}
+}
+
+func (m *mirror) room() (*Room, error) {
+	r, err := LoadOrInitRoomByID(m.roomID)
+	if err != nil {
+		return nil, err
+	}
+	return r.Value(), nil
+}
+
+func (m *mirror) remoteURL(p string) *url.URL {
+	u := *m.remote
+	u.Path = strings.TrimSuffix(u.Path, "/") + p
+	u.RawQuery = ""
+	return &u
+}
+
+func (m *mirror) connect(ctx context.Context) error {
+	u := m.remoteURL("/api/room/ws")
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), http.Header{
+		"Sec-WebSocket-Protocol": []string{m.token},
+		"User-Agent":             []string{utils.UA},
+	})
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("dial remote: %w: %s", err, resp.Status)
+		}
+		return fmt.Errorf("dial remote: %w", err)
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if err := m.syncCurrent(ctx); err != nil {
+		return err
+	}
+	m.setState(true, nil)
+	log.Infof("room mirror %s: connected to %s", m.roomID, m.remote)
+
+	for {
+		t, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if t != websocket.BinaryMessage {
+			continue
+		}
+		var msg pb.ElementMessage
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("unmarshal remote message: %w", err)
+		}
+		if err := m.handle(ctx, &msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *mirror) handle(ctx context.Context, msg *pb.ElementMessage) error {
+	switch msg.Type {
+	case pb.ElementMessageType_PLAY,
+		pb.ElementMessageType_PAUSE,
+		pb.ElementMessageType_CHANGE_RATE,
+		pb.ElementMessageType_CHANGE_SEEK:
+		room, err := m.room()
+		if err != nil {
+			return err
+		}
+		st := msg.GetMovieStatusChanged().GetStatus()
+		status := room.SetCurrentStatus(st.GetPlaying(), st.GetSeek(), st.GetRate(), mirrorTimeDiff(msg.Time))
+		return room.Broadcast(&pb.ElementMessage{
+			Type: msg.Type,
+			Time: time.Now().UnixMilli(),
+			MovieStatusChanged: &pb.MovieStatusChanged{
+				Sender: &pb.Sender{
+					Username: msg.GetMovieStatusChanged().GetSender().GetUsername(),
+				},
+				Status: &pb.MovieStatus{
+					Playing: status.Playing,
+					Seek:    status.Seek,
+					Rate:    status.Rate,
+				},
+			},
+		})
+	case pb.ElementMessageType_CURRENT_CHANGED:
+		return m.syncCurrent(ctx)
+	case pb.ElementMessageType_ERROR:
+		log.Warnf("room mirror %s: remote error: %s", m.roomID, msg.Error)
+	}
+	return nil
+}
+
+// mirrorTimeDiff is the seconds elapsed since the remote sent the message
+func mirrorTimeDiff(t int64) float64 {
+	if t == 0 {
+		return 0
+	}
+	d := time.Since(time.UnixMilli(t)).Seconds()
+	if d < 0 {
+		return 0
+	}
+	return min(d, maxMirrorTimeDiff)
+}
+
+const maxMirrorTimeDiff = 1.5
+
+type mirrorCurrentResp struct {
+	Error string `json:"error"`
+	Data  struct {
+		Status struct {
+			Seek    float64 `json:"seek"`
+			Rate    float64 `json:"rate"`
+			Playing bool    `json:"playing"`
+		} `json:"status"`
+		Movie *struct {
+			ID   string          `json:"id"`
+			Base model.MovieBase `json:"base"`
+		} `json:"movie"`
+	} `json:"data"`
+	Time int64 `json:"time"`
+}
+
+func (m *mirror) fetchCurrent(ctx context.Context) (*mirrorCurrentResp, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.remoteURL("/api/movie/current").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", m.token)
+	req.Header.Set("User-Agent", utils.UA)
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var cr mirrorCurrentResp
+	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
+		return nil, fmt.Errorf("decode remote current: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote current: %s: %s", resp.Status, cr.Error)
+	}
+	return &cr, nil
+}
+
+// absolute makes urls served by the remote instance absolute
+func (m *mirror) absolute(u string) string {
+	if !strings.HasPrefix(u, "/") {
+		return u
+	}
+	ref, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	return m.remote.ResolveReference(ref).String()
+}
+
+func (m *mirror) localMovieBase(remote *model.MovieBase) *model.MovieBase {
+	base := remote.Clone()
+	base.Url = m.absolute(base.Url)
+	for _, ms := range base.MoreSources {
+		ms.Url = m.absolute(ms.Url)
+	}
+	for _, s := range base.Subtitles {
+		s.URL = m.absolute(s.URL)
+	}
+	// already resolved by the remote instance
+	base.VendorInfo = model.VendorInfo{}
+	base.RtmpSource = false
+	base.IsFolder = false
+	base.ParentID = ""
+	// browsers can not send custom headers, let the server proxy them
+	base.Proxy = !base.Live && len(base.Headers) != 0
+	return base
+}
+
+func (m *mirror) syncCurrent(ctx context.Context) error {
+	cr, err := m.fetchCurrent(ctx)
+	if err != nil {
+		return err
+	}
+	room, err := m.room()
+	if err != nil {
+		return err
+	}
+
+	var localID string
+	if cr.Data.Movie != nil && cr.Data.Movie.ID != "" {
+		m.lock.Lock()
+		localID = m.movies[cr.Data.Movie.ID]
+		m.lock.Unlock()
+		if localID != "" {
+			if _, err := room.GetMovieByID(localID); err != nil {
+				localID = ""
+			}
+		}
+		if localID == "" {
+			movie := &model.Movie{
+				MovieBase: *m.localMovieBase(&cr.Data.Movie.Base),
+				CreatorID: m.creatorID,
+			}
+			if err := room.AddMovie(movie); err != nil {
+				return fmt.Errorf("add mirrored movie: %w", err)
+			}
+			localID = movie.ID
+			m.lock.Lock()
+			m.movies[cr.Data.Movie.ID] = localID
+			m.lock.Unlock()
+			_ = room.Broadcast(&pb.ElementMessage{
+				Type: pb.ElementMessageType_MOVIES_CHANGED,
+				MoviesChanged: &pb.Sender{
+					Userid:   m.creatorID,
+					Username: GetUserName(m.creatorID),
+				},
+			})
+		}
+	}
+
+	if room.CurrentMovie().ID != localID {
+		if err := room.SetCurrentMovie(localID, "", cr.Data.Status.Playing); err != nil {
+			return fmt.Errorf("set mirrored current movie: %w", err)
+		}
+	}
+	room.SetCurrentStatus(cr.Data.Status.Playing, cr.Data.Status.Seek, cr.Data.Status.Rate, mirrorTimeDiff(cr.Time))
+	return room.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_CURRENT_CHANGED,
+		CurrentChanged: &pb.Sender{
+			Userid:   m.creatorID,
+			Username: GetUserName(m.creatorID),
+		},
+	})
+}
@@ -7,21 +7,19 @@ import (
 	"hash/crc32"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"time"
 
-	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/cache"
 	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/media"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/utils"
-	"github.com/zijiren233/go-uhc"
-	"github.com/zijiren233/livelib/av"
-	"github.com/zijiren233/livelib/container/flv"
 	"github.com/zijiren233/livelib/protocol/hls"
-	rtmpProto "github.com/zijiren233/livelib/protocol/rtmp"
-	"github.com/zijiren233/livelib/protocol/rtmp/core"
 	rtmps "github.com/zijiren233/livelib/server"
 )
 
@@ -31,7 +29,167 @@ type Movie struct {
 	alistCache    atomic.Pointer[cache.AlistMovieCache]
 	bilibiliCache atomic.Pointer[cache.BilibiliMovieCache]
 	embyCache     atomic.Pointer[cache.EmbyMovieCache]
-	subPath       string
+	douyinCache   atomic.Pointer[cache.DouyinMovieCache]
+	cookieCache   atomic.Pointer[cache.CookieVendorMovieCache]
+	pluginCache   atomic.Pointer[cache.PluginVendorMovieCache]
+	// set when the extensions change while the movie is cached
+	extensions atomic.Pointer[model.MovieExtensions]
+	subPath    string
+}
+
+// Extensions returns the latest extensions of the movie
+func (m *Movie) Extensions() model.MovieExtensions {
+	if e := m.extensions.Load(); e != nil {
+		return *e
+	}
+	return m.Movie.Extensions
+}
+
+// SourceHeader returns the headers to request the source with, the headers map
+// applied first and then the header rules. client is the request of the viewer
+// and may be nil
+func (m *Movie) SourceHeader(ctx context.Context, client http.Header) (http.Header, error) {
+	return SourceHeader(ctx, &m.Movie.MovieBase, m.Movie.CreatorID, client)
+}
+
+// SourceHeader is like Movie.SourceHeader for a movie not added yet,
+// vendor tokens and secrets are taken from creatorID, whose default
+// headers for the host of the source are applied before the headers map
+func SourceHeader(ctx context.Context, base *model.MovieBase, creatorID string, client http.Header) (http.Header, error) {
+	h := make(http.Header, len(base.Headers))
+	if err := defaultSecretHeaders(h, base.Url, creatorID); err != nil {
+		return nil, err
+	}
+	for k, v := range base.Headers {
+		h.Set(k, v)
+	}
+	if client == nil {
+		client = http.Header{}
+	}
+	err := base.HeaderRules.Apply(h, client, func(r *model.HeaderRule) (string, error) {
+		if r.Action == model.HeaderRuleSecret {
+			return userSecret(creatorID, r.Secret)
+		}
+		return vendorToken(ctx, creatorID, r)
+	})
+	return h, err
+}
+
+var ErrCreatorCredentials = errors.New("only the creator of the movie can change where their credentials are sent")
+
+func sameSources(a, b *model.MovieBase) bool {
+	return a.Url == b.Url && slices.EqualFunc(a.MoreSources, b.MoreSources, func(x, y *model.MoreSource) bool {
+		return x.Url == y.Url
+	})
+}
+
+// checkCredentialEdit keeps other editors from sending the vendor tokens and
+// secrets of the movie creator elsewhere, they can neither change the rules
+// resolving them nor the sources of a movie having such rules, nor point the
+// source at a host the creator has default secrets for
+func checkCredentialEdit(editorID string, before *model.Movie, after *model.MovieBase) error {
+	if editorID == before.CreatorID {
+		return nil
+	}
+	old, creds := before.MovieBase.HeaderRules.Credentials(), after.HeaderRules.Credentials()
+	if !slices.EqualFunc(old, creds, func(x, y *model.HeaderRule) bool {
+		return *x == *y
+	}) {
+		return ErrCreatorCredentials
+	}
+	if sameSources(&before.MovieBase, after) {
+		return nil
+	}
+	if len(creds) != 0 {
+		return ErrCreatorCredentials
+	}
+	h := http.Header{}
+	if err := defaultSecretHeaders(h, after.Url, before.CreatorID); err != nil {
+		return err
+	}
+	if len(h) != 0 {
+		return ErrCreatorCredentials
+	}
+	return nil
+}
+
+func defaultSecretHeaders(h http.Header, source, userID string) error {
+	if userID == "" {
+		return nil
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	userE, err := LoadOrInitUserByID(userID)
+	if err != nil {
+		return err
+	}
+	secrets, err := userE.Value().Secrets()
+	if err != nil {
+		return err
+	}
+	for _, s := range secrets {
+		if s.IsDefaultFor(u.Hostname()) {
+			h.Set(s.HeaderName(), s.Value)
+		}
+	}
+	return nil
+}
+
+func userSecret(userID, name string) (string, error) {
+	userE, err := LoadOrInitUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	secrets, err := userE.Value().Secrets()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range secrets {
+		if s.Name == name {
+			return s.Value, nil
+		}
+	}
+	return "", fmt.Errorf("secret %s not found", name)
+}
+
+// vendorToken returns the token of the user's vendor binding
+func vendorToken(ctx context.Context, userID string, r *model.HeaderRule) (string, error) {
+	userE, err := LoadOrInitUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	user := userE.Value()
+	switch r.Vendor {
+	case model.VendorEmby:
+		data, err := user.EmbyCache().LoadOrStore(ctx, r.Server)
+		if err != nil {
+			return "", err
+		}
+		return data.ApiKey, nil
+	case model.VendorAlist:
+		data, err := user.AlistCache().LoadOrStore(ctx, r.Server)
+		if err != nil {
+			return "", err
+		}
+		if data.Token == "" {
+			return "", errors.New("alist binding is anonymous and has no token")
+		}
+		return data.Token, nil
+	case model.VendorBilibili:
+		data, err := user.BilibiliCache().Get(ctx)
+		if err != nil {
+			return "", err
+		}
+		cookies := make([]string, len(data.Cookies))
+		for i, c := range data.Cookies {
+			cookies[i] = fmt.Sprintf("%s=%s", c.Name, c.Value)
+		}
+		return strings.Join(cookies, "; "), nil
+	default:
+		return "", fmt.Errorf("unknown vendor: %s", r.Vendor)
+	}
 }
 
 func (m *Movie) SubPath() string {
@@ -50,6 +208,8 @@ func (m *Movie) ExpireId() uint64 {
 		}
 	case m.Movie.MovieBase.Live && m.Movie.MovieBase.VendorInfo.Vendor == model.VendorBilibili:
 		return uint64(m.BilibiliCache().Live.Last())
+	case m.Movie.MovieBase.VendorInfo.Vendor == model.VendorDouyin:
+		return uint64(m.DouyinCache().Last())
 	}
 	return uint64(crc32.ChecksumIEEE([]byte(m.Movie.ID)))
 }
@@ -66,12 +226,17 @@ func (m *Movie) CheckExpired(expireId uint64) bool {
 		}
 	case m.Movie.MovieBase.Live && m.Movie.MovieBase.VendorInfo.Vendor == model.VendorBilibili:
 		return time.Now().UnixNano()-int64(expireId) > m.BilibiliCache().Live.MaxAge()
+	case m.Movie.MovieBase.VendorInfo.Vendor == model.VendorDouyin:
+		return time.Now().UnixNano()-int64(expireId) > m.DouyinCache().MaxAge()
 	}
 	return expireId != m.ExpireId()
 }
 
 func (m *Movie) ClearCache() error {
 	m.alistCache.Store(nil)
+	m.douyinCache.Store(nil)
+	m.cookieCache.Store(nil)
+	m.pluginCache.Store(nil)
 
 	bmc := m.bilibiliCache.Swap(nil)
 	if bmc != nil {
@@ -115,6 +280,39 @@ func (m *Movie) BilibiliCache() *cache.BilibiliMovieCache {
 	return c
 }
 
+func (m *Movie) DouyinCache() *cache.DouyinMovieCache {
+	c := m.douyinCache.Load()
+	if c == nil {
+		c = cache.NewDouyinMovieCache(m.Movie)
+		if !m.douyinCache.CompareAndSwap(nil, c) {
+			return m.DouyinCache()
+		}
+	}
+	return c
+}
+
+func (m *Movie) CookieVendorCache() *cache.CookieVendorMovieCache {
+	c := m.cookieCache.Load()
+	if c == nil {
+		c = cache.NewCookieVendorMovieCache(m.Movie)
+		if !m.cookieCache.CompareAndSwap(nil, c) {
+			return m.CookieVendorCache()
+		}
+	}
+	return c
+}
+
+func (m *Movie) PluginVendorCache() *cache.PluginVendorMovieCache {
+	c := m.pluginCache.Load()
+	if c == nil {
+		c = cache.NewPluginVendorMovieCache(m.Movie)
+		if !m.pluginCache.CompareAndSwap(nil, c) {
+			return m.PluginVendorCache()
+		}
+	}
+	return c
+}
+
 func (m *Movie) EmbyCache() *cache.EmbyMovieCache {
 	c := m.embyCache.Load()
 	if c == nil {
@@ -166,84 +364,22 @@ func (m *Movie) initChannel() (*rtmps.Channel, error) {
 		}
 		return c, nil
 	case m.Movie.MovieBase.Live && m.Movie.MovieBase.Proxy:
-		u, err := url.Parse(m.Movie.MovieBase.Url)
+		if c := m.channel.Load(); c != nil {
+			return c, nil
+		}
+		key, err := m.liveSourceKey()
 		if err != nil {
 			return nil, err
 		}
-		switch u.Scheme {
-		case "rtmp":
-			c, init := m.compareAndSwapInitChannel()
-			if !init {
-				return c, nil
-			}
-			err = c.InitHlsPlayer(hls.WithGenTsNameFunc(genTsName))
-			if err != nil {
-				return nil, fmt.Errorf("init rtmp hls player error: %v", err)
-			}
-			go func() {
-				for {
-					if c.Closed() {
-						return
-					}
-					cli := core.NewConnClient()
-					if err = cli.Start(m.Movie.MovieBase.Url, av.PLAY); err != nil {
-						log.Errorf("push live error: %v", err)
-						cli.Close()
-						time.Sleep(time.Second)
-						continue
-					}
-					if err := c.PushStart(rtmpProto.NewReader(cli)); err != nil {
-						log.Errorf("push live error: %v", err)
-						cli.Close()
-						time.Sleep(time.Second)
-					}
-				}
-			}()
-			return c, nil
-		case "http", "https":
-			c, init := m.compareAndSwapInitChannel()
-			if !init {
-				return c, nil
-			}
-			err := c.InitHlsPlayer(hls.WithGenTsNameFunc(genTsName))
-			if err != nil {
-				return nil, fmt.Errorf("init http hls player error: %v", err)
-			}
-			go func() {
-				for {
-					if c.Closed() {
-						return
-					}
-					req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, m.Movie.MovieBase.Url, nil)
-					if err != nil {
-						log.Errorf("get live error: %v", err)
-						time.Sleep(time.Second)
-						continue
-					}
-					for k, v := range m.Movie.MovieBase.Headers {
-						req.Header.Set(k, v)
-					}
-					if req.Header.Get("User-Agent") == "" {
-						req.Header.Set("User-Agent", utils.UA)
-					}
-					resp, err := uhc.Do(req)
-					if err != nil {
-						log.Errorf("get live error: %v", err)
-						resp.Body.Close()
-						time.Sleep(time.Second)
-						continue
-					}
-					if err := c.PushStart(flv.NewReader(resp.Body)); err != nil {
-						log.Errorf("push live error: %v", err)
-						resp.Body.Close()
-						time.Sleep(time.Second)
-					}
-				}
-			}()
-			return c, nil
-		default:
-			return nil, errors.New("unsupported scheme")
+		c, err := acquireLiveSource(key, m.pullLive)
+		if err != nil {
+			return nil, err
+		}
+		if !m.channel.CompareAndSwap(nil, c) {
+			releaseLiveSource(key, c)
+			return m.channel.Load(), nil
 		}
+		return c, nil
 	default:
 		return nil, errors.New("this movie not support channel")
 	}
@@ -260,6 +396,11 @@ func (movie *Movie) Validate() error {
 	if movie.IsFolder {
 		return nil
 	}
+	if m.VendorInfo.Vendor == "" {
+		if id, ok := model.ParseMediaURL(m.Url); ok {
+			return movie.validateMediaMovie(id)
+		}
+	}
 	switch {
 	case m.RtmpSource && m.Proxy:
 		return errors.New("rtmp source and proxy can't be true at the same time")
@@ -271,6 +412,9 @@ func (movie *Movie) Validate() error {
 		if !settings.LiveProxy.Get() {
 			return errors.New("live proxy is not enabled")
 		}
+		if m.VendorInfo.Vendor != "" {
+			return nil
+		}
 		u, err := url.Parse(m.Url)
 		if err != nil {
 			return err
@@ -319,6 +463,26 @@ func (movie *Movie) Validate() error {
 	return nil
 }
 
+// validateMediaMovie checks a movie of the local media library,
+// which is always served by the server itself
+func (movie *Movie) validateMediaMovie(fileID string) error {
+	m := movie.Movie.MovieBase
+	if m.Live || m.Proxy || m.RtmpSource {
+		return errors.New("media file can't be live or proxied")
+	}
+	file, err := db.GetMediaFile(fileID)
+	if err != nil {
+		return err
+	}
+	if file.UserID != movie.Movie.CreatorID {
+		return errors.New("media file is not owned by the movie creator")
+	}
+	if file.Status != model.MediaFileStatusReady {
+		return media.ErrNotReady
+	}
+	return nil
+}
+
 func (movie *Movie) validateVendorMovie() error {
 	switch movie.Movie.MovieBase.VendorInfo.Vendor {
 	case model.VendorBilibili:
@@ -331,8 +495,41 @@ func (movie *Movie) validateVendorMovie() error {
 		return movie.Movie.MovieBase.VendorInfo.Alist.Validate()
 
 	case model.VendorEmby:
+		if movie.Movie.MovieBase.VendorInfo.Emby.Channel {
+			if movie.IsFolder {
+				return errors.New("emby live tv channel can't be folder")
+			}
+			// the stream url carries the api key of the creator
+			if !movie.Movie.MovieBase.Live || !movie.Movie.MovieBase.Proxy {
+				return errors.New("emby live tv channel must be live and proxied")
+			}
+		}
 		return movie.Movie.MovieBase.VendorInfo.Emby.Validate()
 
+	case model.VendorDouyin:
+		if movie.IsFolder {
+			return errors.New("douyin folder not support")
+		}
+		return movie.Movie.MovieBase.VendorInfo.Douyin.Validate()
+
+	case model.VendorAcfun:
+		if movie.IsFolder {
+			return errors.New("acfun folder not support")
+		}
+		return movie.Movie.MovieBase.VendorInfo.Acfun.Validate()
+
+	case model.VendorYouku:
+		if movie.IsFolder {
+			return errors.New("youku folder not support")
+		}
+		return movie.Movie.MovieBase.VendorInfo.Youku.Validate()
+
+	case model.VendorPlugin:
+		if movie.IsFolder {
+			return errors.New("plugin folder not support")
+		}
+		return movie.Movie.MovieBase.VendorInfo.Plugin.Validate()
+
 	default:
 		return fmt.Errorf("vendor not implement validate")
 	}
@@ -343,13 +540,17 @@ func (m *Movie) Terminate() error {
 		return nil
 	}
 	c := m.channel.Swap(nil)
-	if c != nil {
-		err := c.Close()
-		if err != nil {
-			return err
+	if c == nil {
+		return nil
+	}
+	if m.Movie.MovieBase.Live && m.Movie.MovieBase.Proxy && !m.Movie.MovieBase.RtmpSource {
+		key, err := m.liveSourceKey()
+		if err == nil {
+			releaseLiveSource(key, c)
+			return nil
 		}
 	}
-	return nil
+	return c.Close()
 }
 
 func (m *Movie) Close() error {
@@ -0,0 +1,155 @@
+package op
+
+import (
+	"errors"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// MovieMigration is a movie copied or moved to another room
+type MovieMigration struct {
+	// the id of the movie in the room it came from
+	From  string
+	Movie *model.Movie
+}
+
+// MigrateMovie copies the movie with its descendants into the folder
+// parentID of dst, or moves it when move is set. Copies get new ids, moved
+// movies keep theirs along with their comments and unlocks. Creators are
+// kept so vendor accounts and uploaded media still resolve
+func (r *Room) MigrateMovie(movieID string, dst *Room, parentID string, move bool) ([]*MovieMigration, error) {
+	if dst.ID == r.ID {
+		return nil, errors.New("movie is already in the room")
+	}
+	if move {
+		if err := r.checkCanModifyMovie(movieID); err != nil {
+			return nil, err
+		}
+	}
+	if parentID != "" {
+		parent, err := dst.GetMovieByID(parentID)
+		if err != nil {
+			return nil, err
+		}
+		if !parent.IsFolder {
+			return nil, errors.New("parent is not a folder")
+		}
+		if parent.IsDynamicFolder() {
+			return nil, errors.New("parent is a dynamic folder, cannot add child")
+		}
+	}
+
+	tree, err := db.GetMovieTree(r.ID, movieID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range tree {
+		if err := (&Movie{Movie: m}).Validate(); err != nil {
+			return nil, err
+		}
+	}
+	position := uint(time.Now().UnixMilli())
+
+	migrations := make([]*MovieMigration, len(tree))
+	if move {
+		ids := make([]string, len(tree))
+		for i, m := range tree {
+			ids[i] = m.ID
+		}
+		err = db.MoveMovieTree(r.ID, dst.ID, movieID, parentID, position, ids[1:])
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if mv, ok := r.movies.cache.LoadAndDelete(id); ok {
+				_ = mv.Close()
+			}
+		}
+		for i, m := range tree {
+			m.RoomID = dst.ID
+			migrations[i] = &MovieMigration{From: m.ID, Movie: m}
+		}
+		tree[0].ParentID = model.EmptyNullString(parentID)
+		tree[0].Position = position
+		tree[0].Version++
+		r.changed(ResourceMovies)
+		if err := r.logWal(WalMoviesDeleted, WalMovieIDs{IDs: ids[:1]}); err != nil {
+			return nil, err
+		}
+		dst.changed(ResourceMovies)
+		return migrations, dst.logWalMovies(tree)
+	}
+
+	copies := copyMovieTree(tree, dst.ID)
+	copies[0].ParentID = model.EmptyNullString(parentID)
+	copies[0].Position = position
+	for i, m := range tree {
+		migrations[i] = &MovieMigration{From: m.ID, Movie: copies[i]}
+	}
+	if err := db.CreateMovieTree(copies); err != nil {
+		return nil, err
+	}
+	dst.changed(ResourceMovies)
+	return migrations, dst.logWalMovies(copies)
+}
+
+// copyMovieTree copies the movies into the room with new ids, parents must
+// come before their children. Movies whose parent is not copied are put at
+// the top level
+func copyMovieTree(tree []*model.Movie, roomID string) []*model.Movie {
+	copies := make([]*model.Movie, len(tree))
+	newIDs := make(map[model.EmptyNullString]string, len(tree))
+	for i, m := range tree {
+		c := &model.Movie{
+			ID:        utils.SortUUID(),
+			Position:  m.Position,
+			RoomID:    roomID,
+			CreatorID: m.CreatorID,
+			MovieBase: *m.MovieBase.Clone(),
+		}
+		c.ParentID = model.EmptyNullString(newIDs[m.ParentID])
+		newIDs[model.EmptyNullString(m.ID)] = c.ID
+		copies[i] = c
+	}
+	return copies
+}
+
+// MigrateRoomMovie copies or moves a movie to a room the user administers
+func (u *User) MigrateRoomMovie(src *Room, movieID string, dst *Room, parentID string, move bool) ([]*MovieMigration, error) {
+	if !u.IsRoomAdmin(dst) {
+		return nil, model.ErrNoPermission
+	}
+	m, err := src.GetMovieByID(movieID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Movie.CreatorID != u.ID {
+		if move && !u.HasRoomPermission(src, model.PermissionDeleteMovie) {
+			return nil, model.ErrNoPermission
+		}
+		if !move && !u.IsRoomAdmin(src) {
+			return nil, model.ErrNoPermission
+		}
+	}
+	migrations, err := src.MigrateMovie(movieID, dst, parentID, move)
+	if err != nil {
+		return nil, err
+	}
+	msg := &pb.ElementMessage{
+		Type: pb.ElementMessageType_MOVIES_CHANGED,
+		MoviesChanged: &pb.Sender{
+			Username: u.Username,
+			Userid:   u.ID,
+		},
+	}
+	if move {
+		if err := src.Broadcast(msg); err != nil {
+			return nil, err
+		}
+	}
+	return migrations, dst.Broadcast(msg)
+}
@@ -0,0 +1,131 @@
+package op
+
+import (
+	"fmt"
+	"slices"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/model"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+// MovieVersionConflictError is returned by edits made on the condition of a
+// version of a movie that was edited since
+type MovieVersionConflictError struct {
+	Version uint64 `json:"version"`
+}
+
+func (e *MovieVersionConflictError) Error() string {
+	return fmt.Sprintf("movie was edited by someone else, it is at version %d now", e.Version)
+}
+
+func (e *MovieVersionConflictError) ErrorData() any {
+	return e
+}
+
+// PatchMovie applies the json merge patch to the base of the movie if it is
+// still at version, and returns the patched movie and the changed fields
+func (r *Room) PatchMovie(movieID string, version uint64, patch []byte, check func(*model.MovieBase) error) (*model.Movie, *model.Movie, []string, error) {
+	err := r.checkCanModifyMovie(movieID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	before, after, changed, err := r.movies.Patch(movieID, version, patch, check)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	r.changed(ResourceMovies)
+	return before, after, changed, r.logWal(WalMovieUpdated, WalMovieIDs{IDs: []string{movieID}})
+}
+
+func (u *User) PatchRoomMovie(room *Room, movieID string, version uint64, patch []byte, check func(*model.MovieBase) error) (*model.Movie, error) {
+	if !u.HasRoomPermission(room, model.PermissionEditMovie) {
+		return nil, model.ErrNoPermission
+	}
+	current, err := room.GetMovieByID(movieID)
+	if err != nil {
+		return nil, err
+	}
+	before, after, changed, err := room.PatchMovie(movieID, version, patch, func(base *model.MovieBase) error {
+		if err := check(base); err != nil {
+			return err
+		}
+		return checkCredentialEdit(u.ID, current.Movie, base)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return after, nil
+	}
+	public, partial, err := publicMoviePatch(before, after, changed)
+	if err != nil {
+		return nil, err
+	}
+	sender := &pb.Sender{
+		Username: u.Username,
+		Userid:   u.ID,
+	}
+	return after, room.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_MOVIE_PATCHED,
+		MoviePatched: &pb.MoviePatch{
+			Sender:  sender,
+			MovieId: after.ID,
+			Version: after.Version,
+			Patch:   string(public),
+			Partial: partial,
+		},
+	}, WithFallback(CapabilityMoviePatch, &pb.ElementMessage{
+		Type:          pb.ElementMessageType_MOVIES_CHANGED,
+		MoviesChanged: sender,
+	}))
+}
+
+var (
+	// fields members see of movies they can not watch
+	publicMovieFields = []string{"name", "live", "isFolder", "parentId", "rating"}
+	// fields that lead to the source, hidden from members of proxied movies
+	sourceMovieFields = []string{"url", "moreSources", "headers", "headerRules"}
+)
+
+// publicMoviePatch is the merge patch of the changed fields of the movie every
+// member may see, partial is set when changed fields are left out
+func publicMoviePatch(before, after *model.Movie, changed []string) ([]byte, bool, error) {
+	doc, err := json.Marshal(&after.MovieBase)
+	if err != nil {
+		return nil, false, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return nil, false, err
+	}
+	lockable := func(m *model.Movie) bool {
+		return m.NeedPassword() || m.Restriction != nil || m.Rating != ""
+	}
+	hidden := func(field string) bool {
+		switch {
+		case field == "password" || field == "restriction" || field == "extensions":
+			return true
+		case lockable(before) || lockable(after):
+			return !slices.Contains(publicMovieFields, field)
+		case before.Proxy || after.Proxy:
+			return slices.Contains(sourceMovieFields, field)
+		}
+		return false
+	}
+	patch := make(map[string]json.RawMessage, len(changed))
+	partial := false
+	for _, field := range changed {
+		if hidden(field) {
+			partial = true
+			continue
+		}
+		if v, ok := fields[field]; ok {
+			patch[field] = v
+		} else {
+			patch[field] = json.RawMessage("null")
+		}
+	}
+	b, err := json.Marshal(patch)
+	return b, partial, err
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	json "github.com/json-iterator/go"
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
 	"github.com/zijiren233/gencontainer/rwmap"
 	rtmps "github.com/zijiren233/livelib/server"
 	"gorm.io/gorm"
@@ -28,6 +31,10 @@ func (m *movies) AddMovie(mo *model.Movie) error {
 		return err
 	}
 
+	if _, err := mo.MovieBase.HashPassword(nil); err != nil {
+		return err
+	}
+
 	err = db.CreateMovie(mo)
 	if err != nil {
 		return err
@@ -53,6 +60,10 @@ func (m *movies) AddMovies(mos []*model.Movie) error {
 			return err
 		}
 
+		if _, err := mo.MovieBase.HashPassword(nil); err != nil {
+			return err
+		}
+
 		inited = append(inited, movie)
 	}
 
@@ -87,20 +98,100 @@ func (m *movies) Update(movieId string, movie *model.MovieBase) error {
 	if err != nil {
 		return err
 	}
+	changed, err := movie.HashPassword(mv.HashedPassword)
+	if err != nil {
+		return err
+	}
+	movie.Extensions = mv.Extensions
 	mv.MovieBase = *movie
-	err = db.SaveMovie(mv)
+	err = db.SaveMovieIfVersion(mv, mv.Version)
 	if err != nil {
 		return err
 	}
+	if changed {
+		if err := db.DeleteMovieUnlocks(mv.ID); err != nil {
+			return err
+		}
+	}
+	mm, ok := m.cache.LoadOrStore(mv.ID, &Movie{Movie: mv})
+	if ok {
+		_ = mm.Close()
+	}
+	return nil
+}
+
+// Patch applies the json merge patch to the base of the movie if the movie is
+// still at version, check validates the patched base before it is saved. It
+// returns the movie before and after the patch and the changed fields
+func (m *movies) Patch(movieID string, version uint64, patch []byte, check func(*model.MovieBase) error) (*model.Movie, *model.Movie, []string, error) {
+	mv, err := db.GetMovieByID(m.roomID, movieID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if mv.Version != version {
+		return nil, nil, nil, &MovieVersionConflictError{Version: mv.Version}
+	}
+	before := mv.Clone()
+	doc, err := json.Marshal(&mv.MovieBase)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	doc, changed, err := utils.MergePatch(doc, patch)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var base model.MovieBase
+	if err := json.Unmarshal(doc, &base); err != nil {
+		return nil, nil, nil, err
+	}
+	base.HashedPassword = mv.HashedPassword
+	if err := check(&base); err != nil {
+		return nil, nil, nil, err
+	}
+	base.Extensions = mv.Extensions
+	passwordChanged, err := base.HashPassword(mv.HashedPassword)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	mv.MovieBase = base
+	err = db.SaveMovieIfVersion(mv, version)
+	if errors.Is(err, db.ErrMovieVersionConflict) {
+		if current, err := db.GetMovieByID(m.roomID, movieID); err == nil {
+			return nil, nil, nil, &MovieVersionConflictError{Version: current.Version}
+		}
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if passwordChanged {
+		if err := db.DeleteMovieUnlocks(mv.ID); err != nil {
+			return nil, nil, nil, err
+		}
+	}
 	mm, ok := m.cache.LoadOrStore(mv.ID, &Movie{Movie: mv})
 	if ok {
 		_ = mm.Close()
 	}
+	return before, mv, changed, nil
+}
+
+// SetExtension sets the extension of the movie, a nil v removes it
+func (m *movies) SetExtension(movieID string, ns model.ExtensionNamespace, name string, v any) error {
+	mv, err := db.UpdateMovieExtensions(m.roomID, movieID, func(e *model.MovieExtensions) error {
+		return e.Set(ns, name, v)
+	})
+	if err != nil {
+		return err
+	}
+	if mm, ok := m.cache.Load(movieID); ok {
+		mm.extensions.Store(&mv.Extensions)
+	}
 	return nil
 }
 
 func (m *movies) Clear() error {
-	return m.DeleteMovieByParentID("")
+	_, err := m.DeleteMovieByParentID("", "")
+	return err
 }
 
 func (m *movies) Close() error {
@@ -108,22 +199,27 @@ func (m *movies) Close() error {
 	return nil
 }
 
-func (m *movies) DeleteMovieByParentID(parentID string) error {
-	err := db.DeleteMoviesByRoomIDAndParentID(m.roomID, parentID)
+// delete moves the movies matched by the scopes to the trash and returns the
+// batch id, or deletes them when the trash is disabled
+func (m *movies) delete(trashedBy string, scopes ...func(*gorm.DB) *gorm.DB) (string, error) {
+	keep := time.Duration(settings.MovieTrashHours.Get()) * time.Hour
+	if keep <= 0 {
+		return "", db.DeleteMoviesByRoomID(m.roomID, scopes...)
+	}
+	return db.TrashMovies(m.roomID, trashedBy, keep, scopes...)
+}
+
+func (m *movies) DeleteMovieByParentID(parentID, trashedBy string) (string, error) {
+	batchID, err := m.delete(trashedBy, db.WithParentMovieID(parentID))
 	if err != nil {
-		return err
+		return "", err
 	}
 	m.DeleteMovieAndChiledCache("")
-	return nil
+	return batchID, nil
 }
 
-func (m *movies) DeleteMovieByID(id string) error {
-	err := db.DeleteMovieByID(m.roomID, id)
-	if err != nil {
-		return err
-	}
-	m.DeleteMovieAndChiledCache(id)
-	return nil
+func (m *movies) DeleteMovieByID(id, trashedBy string) (string, error) {
+	return m.DeleteMoviesByID([]string{id}, trashedBy)
 }
 
 func (m *movies) DeleteMovieAndChiledCache(id ...string) {
@@ -152,13 +248,33 @@ func (m *movies) deleteMovieAndChiledCache(ids map[model.EmptyNullString]struct{
 	}
 }
 
-func (m *movies) DeleteMoviesByID(ids []string) error {
-	err := db.DeleteMoviesByID(m.roomID, ids)
+func (m *movies) DeleteMoviesByID(ids []string, trashedBy string) (string, error) {
+	batchID, err := m.delete(trashedBy, db.WithMovieIDs(ids))
 	if err != nil {
-		return err
+		return "", err
+	}
+	for _, id := range ids {
+		if mv, ok := m.cache.LoadAndDelete(id); ok {
+			_ = mv.Close()
+		}
 	}
 	m.DeleteMovieAndChiledCache(ids...)
-	return nil
+	return batchID, nil
+}
+
+// Restore moves a batch of the trash back to the movies, the movies are
+// loaded again when used
+func (m *movies) Restore(batchID string) ([]*model.Movie, error) {
+	restored, err := db.RestoreTrashedMovies(m.roomID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	for _, mv := range restored {
+		if old, ok := m.cache.LoadAndDelete(mv.ID); ok {
+			_ = old.Close()
+		}
+	}
+	return restored, nil
 }
 
 func (m *movies) GetMovieByID(id string) (*Movie, error) {
@@ -182,15 +298,15 @@ func (m *movies) SwapMoviePositions(id1, id2 string) error {
 	return db.SwapMoviePositions(m.roomID, id1, id2)
 }
 
-func (m *movies) GetMoviesWithPage(page, pageSize int, parentID string) ([]*model.Movie, int64, error) {
-	scopes := []func(*gorm.DB) *gorm.DB{
-		db.WithParentMovieID(parentID),
-	}
-	count, err := db.GetMoviesCountByRoomID(m.roomID, append(scopes, db.Paginate(page, pageSize))...)
+// GetMoviesWithPage returns the page of the movies in the folder matching the
+// filters and the count of them
+func (m *movies) GetMoviesWithPage(parentID string, page func(*gorm.DB) *gorm.DB, filters ...func(*gorm.DB) *gorm.DB) ([]*model.Movie, int64, error) {
+	scopes := append(filters, db.WithParentMovieID(parentID))
+	count, err := db.GetMoviesCountByRoomID(m.roomID, scopes...)
 	if err != nil {
 		return nil, 0, err
 	}
-	movies, err := db.GetMoviesByRoomID(m.roomID, scopes...)
+	movies, err := db.GetMoviesByRoomID(m.roomID, append(scopes, page)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -0,0 +1,108 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"gorm.io/gorm"
+)
+
+const movieTrashCleanEvery = time.Hour
+
+// TrashedMovies returns the movies in the trash of the room, newest
+// deletions first
+func (r *Room) TrashedMovies(scopes ...func(*gorm.DB) *gorm.DB) ([]*model.TrashedMovie, error) {
+	return db.GetTrashedMoviesByRoomID(r.ID, scopes...)
+}
+
+// RestoreTrashedMovies restores a batch of deleted movies
+func (r *Room) RestoreTrashedMovies(batchID string) ([]*model.Movie, error) {
+	restored, err := r.movies.Restore(batchID)
+	if err != nil {
+		return nil, err
+	}
+	r.changed(ResourceMovies)
+	return restored, r.logWalMovies(restored)
+}
+
+// canManageTrashBatch reports whether the user may restore or purge the
+// batch, users can always undo their own deletions
+func (u *User) canManageTrashBatch(room *Room, batchID string) (bool, error) {
+	if u.HasRoomPermission(room, model.PermissionDeleteMovie) {
+		return true, nil
+	}
+	movies, err := room.TrashedMovies(db.WithTrashBatchID(batchID))
+	if err != nil {
+		return false, err
+	}
+	if len(movies) == 0 {
+		return false, db.ErrNotFound("trashed movies")
+	}
+	return movies[0].TrashedBy == u.ID, nil
+}
+
+// RoomTrashedMovies returns the trash of the room, users without the
+// permission to delete movies only see their own deletions
+func (u *User) RoomTrashedMovies(room *Room) ([]*model.TrashedMovie, error) {
+	if u.HasRoomPermission(room, model.PermissionDeleteMovie) {
+		return room.TrashedMovies()
+	}
+	return room.TrashedMovies(func(db *gorm.DB) *gorm.DB {
+		return db.Where("trashed_by = ?", u.ID)
+	})
+}
+
+func (u *User) RestoreRoomTrashedMovies(room *Room, batchID string) ([]*model.Movie, error) {
+	ok, err := u.canManageTrashBatch(room, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, model.ErrNoPermission
+	}
+	restored, err := room.RestoreTrashedMovies(batchID)
+	if err != nil {
+		return nil, err
+	}
+	return restored, room.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_MOVIES_CHANGED,
+		MoviesChanged: &pb.Sender{
+			Username: u.Username,
+			Userid:   u.ID,
+		},
+	})
+}
+
+// PurgeRoomTrashedMovies deletes a batch of the trash for good
+func (u *User) PurgeRoomTrashedMovies(room *Room, batchID string) error {
+	ok, err := u.canManageTrashBatch(room, batchID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return model.ErrNoPermission
+	}
+	return db.DeleteTrashedMovies(room.ID, batchID)
+}
+
+// StartMovieTrashCleanup deletes trashed movies once they expire
+func StartMovieTrashCleanup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(movieTrashCleanEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := db.DeleteExpiredTrashedMovies(); err != nil {
+				log.Errorf("delete expired trashed movies error: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package op
+
+import (
+	"errors"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/namepolicy"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+// CheckUsername checks the username against the name policy, userID is the
+// user taking the name so admins may keep looking like themselves
+func CheckUsername(username, userID string) error {
+	patterns, err := namepolicy.ParsePatterns(settings.UsernameDenyPatterns.Get())
+	if err != nil {
+		return err
+	}
+	scripts, err := namepolicy.ParseScripts(settings.UsernameScripts.Get())
+	if err != nil {
+		return err
+	}
+	policy := namepolicy.Policy{
+		Reserved: namepolicy.ParseList(settings.ReservedUsernames.Get()),
+		Patterns: patterns,
+		Scripts:  scripts,
+	}
+	if settings.ProtectAdminUsernames.Get() {
+		admins, err := db.GetAdminUsernames()
+		if err != nil {
+			return err
+		}
+		for id, name := range admins {
+			if id != userID {
+				policy.Protected = append(policy.Protected, name)
+			}
+		}
+	}
+	return policy.Check(namepolicy.KindUsername, username)
+}
+
+func CheckRoomName(name string) error {
+	patterns, err := namepolicy.ParsePatterns(settings.RoomNameDenyPatterns.Get())
+	if err != nil {
+		return err
+	}
+	scripts, err := namepolicy.ParseScripts(settings.RoomNameScripts.Get())
+	if err != nil {
+		return err
+	}
+	policy := namepolicy.Policy{
+		Reserved: namepolicy.ParseList(settings.ReservedRoomNames.Get()),
+		Patterns: patterns,
+		Scripts:  scripts,
+	}
+	return policy.Check(namepolicy.KindRoomName, name)
+}
+
+func isNameViolation(err error) bool {
+	var v *namepolicy.Violation
+	return errors.As(err, &v)
+}
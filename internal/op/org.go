@@ -0,0 +1,145 @@
+package op
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+const orgRoleTTL = 5 * time.Minute
+
+// roles of users in organizations, keyed by organization and user
+var orgRoles = synccache.NewSyncCache[string, model.OrgRole](time.Minute * 5)
+
+func orgRoleKey(orgID, userID string) string {
+	return orgID + ":" + userID
+}
+
+// OrgRole is the role of the user in the organization, OrgRoleUnknown if
+// the user is not a member
+func OrgRole(orgID, userID string) model.OrgRole {
+	if orgID == "" || userID == "" {
+		return model.OrgRoleUnknown
+	}
+	key := orgRoleKey(orgID, userID)
+	if role, ok := orgRoles.Load(key); ok {
+		return role.Value()
+	}
+	var role model.OrgRole
+	m, err := db.GetOrganizationMember(orgID, userID)
+	if err == nil {
+		role = m.Role
+	} else if !errors.Is(err, db.ErrNotFound("organization member")) {
+		log.Errorf("get organization member error: %v", err)
+		return model.OrgRoleUnknown
+	}
+	r, _ := orgRoles.LoadOrStore(key, role, orgRoleTTL)
+	return r.Value()
+}
+
+func forgetOrgRole(orgID, userID string) {
+	orgRoles.Delete(orgRoleKey(orgID, userID))
+}
+
+func (u *User) OrgRole(orgID string) model.OrgRole {
+	if u.IsGuest() {
+		return model.OrgRoleUnknown
+	}
+	return OrgRole(orgID, u.ID)
+}
+
+// IsOrgAdmin reports whether the user administers the organization, server
+// admins administer all organizations
+func (u *User) IsOrgAdmin(orgID string) bool {
+	if orgID == "" {
+		return false
+	}
+	return u.IsAdmin() || u.OrgRole(orgID).IsAdmin()
+}
+
+func (u *User) IsOrgMember(orgID string) bool {
+	if orgID == "" {
+		return false
+	}
+	return u.IsAdmin() || u.OrgRole(orgID).IsMember()
+}
+
+// CreateOrganization creates the organization with the user as its first admin
+func CreateOrganization(org *model.Organization, adminID string) error {
+	if err := db.CreateOrganization(org); err != nil {
+		return err
+	}
+	if adminID == "" {
+		return nil
+	}
+	return AddOrgMember(org.ID, adminID, model.OrgRoleAdmin)
+}
+
+// DeleteOrganization deletes the organization with its rooms
+func DeleteOrganization(orgID string) error {
+	ids, err := db.GetOrganizationRoomIDs(orgID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := DeleteRoomByID(id); err != nil {
+			return err
+		}
+	}
+	if err := db.DeleteOrganizationByID(orgID); err != nil {
+		return err
+	}
+	forgetOrgProvider(orgID)
+	prefix := orgRoleKey(orgID, "")
+	orgRoles.Range(func(key string, _ *synccache.Entry[model.OrgRole]) bool {
+		if strings.HasPrefix(key, prefix) {
+			orgRoles.Delete(key)
+		}
+		return true
+	})
+	return nil
+}
+
+func AddOrgMember(orgID, userID string, role model.OrgRole) error {
+	if _, err := db.AddOrganizationMember(orgID, userID, role); err != nil {
+		return err
+	}
+	forgetOrgRole(orgID, userID)
+	return nil
+}
+
+// JoinOrganization adds the user to the organization the invite code is of
+func (u *User) JoinOrganization(code string) (*model.Organization, error) {
+	if u.IsGuest() {
+		return nil, model.ErrNoPermission
+	}
+	org, err := db.GetOrganizationByInviteCode(code)
+	if err != nil {
+		return nil, errors.New("invalid invite code")
+	}
+	if err := AddOrgMember(org.ID, u.ID, model.OrgRoleMember); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func SetOrgMemberRole(orgID, userID string, role model.OrgRole) error {
+	if err := db.SetOrganizationMemberRole(orgID, userID, role); err != nil {
+		return err
+	}
+	forgetOrgRole(orgID, userID)
+	return nil
+}
+
+func RemoveOrgMember(orgID, userID string) error {
+	if err := db.DeleteOrganizationMember(orgID, userID); err != nil {
+		return err
+	}
+	forgetOrgRole(orgID, userID)
+	return nil
+}
@@ -0,0 +1,147 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/provider/providers"
+)
+
+// org providers are named by the organization, e.g. org_acme, so their
+// callback urls are /oauth2/callback/org_acme
+const orgProviderPrefix = "org_"
+
+var ErrOrgProviderDisabled = errors.New("organization login is disabled")
+
+func OrgProviderName(orgName string) provider.OAuth2Provider {
+	return orgProviderPrefix + orgName
+}
+
+// IsOrgProvider reports whether p is the provider of an organization and
+// the name of the organization
+func IsOrgProvider(p provider.OAuth2Provider) (string, bool) {
+	return strings.CutPrefix(p, orgProviderPrefix)
+}
+
+var (
+	orgProvidersLock sync.Mutex
+	// the providers are never modified once stored, updates replace them
+	orgProviders = make(map[string]*model.OrgProvider)
+)
+
+// LoadOrgProvider loads the identity provider of the organization
+func LoadOrgProvider(orgID string) (*model.OrgProvider, error) {
+	orgProvidersLock.Lock()
+	defer orgProvidersLock.Unlock()
+	if p, ok := orgProviders[orgID]; ok {
+		return p, nil
+	}
+	p, err := db.GetOrgProvider(orgID)
+	if err != nil {
+		return nil, err
+	}
+	orgProviders[orgID] = p
+	return p, nil
+}
+
+// SetOrgProvider saves the identity provider of an organization, logins
+// see either the old or the new provider
+func SetOrgProvider(p *model.OrgProvider) error {
+	orgProvidersLock.Lock()
+	defer orgProvidersLock.Unlock()
+	if err := db.SaveOrgProvider(p); err != nil {
+		return err
+	}
+	orgProviders[p.OrgID] = p
+	return nil
+}
+
+func forgetOrgProvider(orgID string) {
+	orgProvidersLock.Lock()
+	defer orgProvidersLock.Unlock()
+	delete(orgProviders, orgID)
+}
+
+// newOrgProvider builds the provider of the organization
+func newOrgProvider(org *model.Organization, p *model.OrgProvider) (provider.ProviderInterface, error) {
+	if !p.Enabled {
+		return nil, ErrOrgProviderDisabled
+	}
+	var scopes []string
+	for _, scope := range strings.Split(p.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	pi, err := providers.NewCustomProvider(providers.CustomOption{
+		Name:          OrgProviderName(org.Name),
+		AuthURL:       p.AuthURL,
+		TokenURL:      p.TokenURL,
+		UserInfoURL:   p.UserInfoURL,
+		Scopes:        scopes,
+		IDField:       p.IDField,
+		UsernameField: p.UsernameField,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pi.Init(provider.Oauth2Option{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  p.RedirectURL,
+	})
+	return pi, nil
+}
+
+// GetOrgProvider resolves the provider of the organization p is named by
+func GetOrgProvider(p provider.OAuth2Provider) (provider.ProviderInterface, *model.Organization, error) {
+	name, ok := IsOrgProvider(p)
+	if !ok {
+		return nil, nil, providers.FormatErrNotImplemented(p)
+	}
+	org, err := db.GetOrganizationByName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := LoadOrgProvider(org.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	pi, err := newOrgProvider(org, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pi, org, nil
+}
+
+// checkOrgProvider checks the user is bound to the provider of the
+// organization of the room, if the organization restricts its rooms to it
+func (u *User) checkOrgProvider(room *Room) error {
+	if room.OrgID == "" || u.IsOrgAdmin(room.OrgID) {
+		return nil
+	}
+	s, err := LoadOrgProvider(room.OrgID)
+	if err != nil {
+		return err
+	}
+	if !s.Enabled || !s.RestrictRoomLogin {
+		return nil
+	}
+	org, err := db.GetOrganizationByID(room.OrgID)
+	if err != nil {
+		return err
+	}
+	p := OrgProviderName(org.Name)
+	if _, err := db.GetUserProvider(u.ID, p); err != nil {
+		if errors.Is(err, db.ErrNotFound("provider")) {
+			return fmt.Errorf("this room requires logging in with %s", p)
+		}
+		return err
+	}
+	return nil
+}
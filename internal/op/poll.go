@@ -0,0 +1,163 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+const moviePollCheckInterval = time.Second * 10
+
+var ErrMoviePollClosed = errors.New("poll is closed")
+
+func (u *User) CreateMoviePoll(room *Room, poll *model.MoviePoll) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionManageMoviePoll) {
+		return model.ErrNoPermission
+	}
+	for _, id := range poll.Candidates {
+		m, err := room.GetMovieByID(id)
+		if err != nil {
+			return err
+		}
+		if m.IsFolder && !m.IsDynamicFolder() {
+			return errors.New("cannot vote for a static folder")
+		}
+	}
+	poll.RoomID = room.ID
+	poll.CreatorID = u.ID
+	if err := db.CreateMoviePoll(poll); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionCreateMoviePoll, poll.ID)
+	return nil
+}
+
+// VoteMoviePoll replaces the ballot of the user, ranking is from the most
+// to the least preferred candidate
+func (u *User) VoteMoviePoll(room *Room, id string, ranking []string) error {
+	if u.IsGuest() || !u.HasRoomPermission(room, model.PermissionGetMovieList) {
+		return model.ErrNoPermission
+	}
+	poll, err := db.GetMoviePoll(room.ID, id)
+	if err != nil {
+		return err
+	}
+	if !poll.IsOpen(time.Now()) {
+		return ErrMoviePollClosed
+	}
+	ranking = model.RankingOf(ranking, poll.Candidates)
+	if len(ranking) == 0 {
+		return errors.New("ranking has no candidate of the poll")
+	}
+	return db.SaveMoviePollBallot(&model.MoviePollBallot{
+		PollID:  poll.ID,
+		UserID:  u.ID,
+		Ranking: ranking,
+	})
+}
+
+// CloseMoviePoll closes the poll before its close time
+func (u *User) CloseMoviePoll(room *Room, id string) (*model.MoviePoll, error) {
+	if !u.HasRoomAdminPermission(room, model.PermissionManageMoviePoll) {
+		return nil, model.ErrNoPermission
+	}
+	poll, err := db.GetMoviePoll(room.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if poll.Closed {
+		return nil, ErrMoviePollClosed
+	}
+	if err := room.closeMoviePoll(poll); err != nil {
+		return nil, err
+	}
+	u.roomAudit(room, model.RoomAuditActionCloseMoviePoll, poll.ID)
+	return poll, nil
+}
+
+func (u *User) DeleteMoviePoll(room *Room, id string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionManageMoviePoll) {
+		return model.ErrNoPermission
+	}
+	if err := db.DeleteMoviePoll(room.ID, id); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionDeleteMoviePoll, id)
+	return nil
+}
+
+// MoviePollResult counts the ballots of the poll
+func MoviePollResult(poll *model.MoviePoll) (*model.RankedChoiceResult, int, error) {
+	ballots, err := db.GetMoviePollBallots(poll.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	rankings := make([][]string, len(ballots))
+	for i, b := range ballots {
+		rankings[i] = b.Ranking
+	}
+	return model.RankedChoice(poll.Candidates, rankings), len(ballots), nil
+}
+
+// closeMoviePoll counts the poll and queues the winner as the current movie,
+// paused so the room can start it together
+func (r *Room) closeMoviePoll(poll *model.MoviePoll) error {
+	result, _, err := MoviePollResult(poll)
+	if err != nil {
+		return err
+	}
+	closed, err := db.CloseMoviePoll(poll.ID, result.Winner)
+	if err != nil || !closed {
+		return err
+	}
+	poll.Closed = true
+	poll.WinnerID = result.Winner
+	if result.Winner == "" {
+		return nil
+	}
+	if err := r.SetCurrentMovie(result.Winner, "", false); err != nil {
+		return err
+	}
+	return r.Broadcast(&pb.ElementMessage{
+		Type: pb.ElementMessageType_CURRENT_CHANGED,
+		CurrentChanged: &pb.Sender{
+			Userid:   poll.CreatorID,
+			Username: GetUserName(poll.CreatorID),
+		},
+	})
+}
+
+// StartMoviePollCloser closes the polls past their close time
+func StartMoviePollCloser(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(moviePollCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			polls, err := db.GetDueMoviePolls(time.Now())
+			if err != nil {
+				log.Errorf("get due movie polls error: %v", err)
+				continue
+			}
+			for _, p := range polls {
+				room, err := LoadOrInitRoomByID(p.RoomID)
+				if err != nil {
+					log.Errorf("load room %s error: %v", p.RoomID, err)
+					continue
+				}
+				if err := room.Value().closeMoviePoll(p); err != nil {
+					log.Errorf("close movie poll %s error: %v", p.ID, err)
+				}
+			}
+		}
+	}()
+}
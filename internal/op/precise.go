@@ -0,0 +1,70 @@
+package op
+
+import (
+	"math"
+	"time"
+
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+// in precise sync mode the status is exchanged as an anchor, the media
+// timestamp at a wall clock time both in microseconds, instead of the
+// seek at the millisecond time of the message
+
+// EnablePreciseSync marks the client as able to play from anchors
+func (c *Client) EnablePreciseSync() {
+	c.precise.Store(true)
+}
+
+func (c *Client) PreciseSync() bool {
+	return c.precise.Load()
+}
+
+// PreciseSync reports whether the room distributes anchors for the current
+// movie, live movies have no stable media timestamps
+func (r *Room) PreciseSync() bool {
+	return r.Settings.PreciseSync && !r.current.Snapshot().Movie.IsLive
+}
+
+// Anchor returns the media timestamp and the server time of the status in microseconds
+func (s *Status) Anchor() (pts, at int64) {
+	return int64(math.Round(s.Seek * 1e6)), s.lastUpdate.UnixMicro()
+}
+
+// StatusMessage converts the status, with the anchor when the room is in precise sync mode
+func (r *Room) StatusMessage(s *Status) *pb.MovieStatus {
+	ms := &pb.MovieStatus{
+		Playing: s.Playing,
+		Seek:    s.Seek,
+		Rate:    s.Rate,
+	}
+	if r.PreciseSync() {
+		ms.AnchorPts, ms.AnchorTime = s.Anchor()
+	}
+	return ms
+}
+
+// StatusRequest returns the seek and the seconds elapsed since it of a status
+// sent by the client, anchors are only used from clients in precise sync mode
+func (c *Client) StatusRequest(req *pb.MovieStatus, clientTime int64) (seek, timeDiff float64) {
+	if req.GetAnchorTime() == 0 || !c.PreciseSync() || !c.r.PreciseSync() {
+		return req.GetSeek(), c.TimeDiff(clientTime)
+	}
+	return float64(req.GetAnchorPts()) / 1e6, c.anchorTimeDiff(req.GetAnchorTime())
+}
+
+// anchorTimeDiff is TimeDiff in microseconds, the measured clock offset is
+// always applied since even small offsets matter at this precision
+func (c *Client) anchorTimeDiff(anchorTime int64) float64 {
+	t := time.UnixMicro(anchorTime)
+	if _, offset, ok := c.clock.get(); ok {
+		t = t.Add(-offset)
+	}
+	timeDiff := time.Since(t).Seconds()
+	if timeDiff < 0 {
+		return 0
+	} else if timeDiff > maxTimeDiff {
+		return maxTimeDiff
+	}
+	return timeDiff
+}
@@ -0,0 +1,67 @@
+package op
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/settings"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+type Presence struct {
+	UserID      string
+	Username    string
+	State       pb.PresenceState
+	LastActive  time.Time
+	OnlineCount int
+	// any client of the user listens in audio-only mode
+	AudioOnly bool
+}
+
+func presenceState(lastAct time.Time) pb.PresenceState {
+	idle := time.Since(lastAct)
+	switch {
+	case idle >= time.Duration(settings.UserAwayTime.Get())*time.Minute:
+		return pb.PresenceState_PRESENCE_AWAY
+	case idle >= time.Duration(settings.UserIdleTime.Get())*time.Minute:
+		return pb.PresenceState_PRESENCE_IDLE
+	default:
+		return pb.PresenceState_PRESENCE_ONLINE
+	}
+}
+
+func idleTimeout(lastAct time.Time) bool {
+	t := settings.UserIdleDisconnectTime.Get()
+	return t > 0 && time.Since(lastAct) >= time.Duration(t)*time.Minute
+}
+
+func newPresenceMessage(u *User, state pb.PresenceState, audioOnly bool) *pb.ElementMessage {
+	return &pb.ElementMessage{
+		Type: pb.ElementMessageType_PRESENCE_CHANGED,
+		Time: time.Now().UnixMilli(),
+		PresenceChanged: &pb.Presence{
+			Sender: &pb.Sender{
+				Username: u.Username,
+				Userid:   u.ID,
+			},
+			State:      state,
+			LastActive: u.LastAct().UnixMilli(),
+			AudioOnly:  audioOnly,
+		},
+	}
+}
+
+// WatchingRoom returns the loaded room the user is online in, rooms that are
+// playing are preferred. nil if the user is in no room
+func (u *User) WatchingRoom() *Room {
+	var watching *Room
+	RangeRoomCache(func(_ string, e *synccache.Entry[*Room]) bool {
+		r := e.Value()
+		if r.hub == nil || !r.hub.IsOnline(u.ID) {
+			return true
+		}
+		watching = r
+		return !r.current.Current().Status.Playing
+	})
+	return watching
+}
@@ -0,0 +1,69 @@
+package op
+
+import (
+	"time"
+
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+// ProtocolVersion is the websocket protocol version of the server. clients
+// send theirs with the v query when connecting, clients without one speak
+// version 0 and only get the features they opted in with the legacy flags
+const ProtocolVersion uint32 = 1
+
+// Capability is a bit of an optional protocol feature, new features like
+// binary frames, deltas or danmaku get a bit so old clients keep working
+type Capability uint64
+
+const (
+	// acknowledge critical messages, see Client.EnableAck
+	CapabilityAck Capability = 1 << iota
+	// exchange anchors in precise sync mode, see Client.EnablePreciseSync
+	CapabilityPreciseSync
+	// reload rotated sources on SOURCE_UPDATED, clients without it are sent
+	// CURRENT_EXPIRED instead
+	CapabilitySourceUpdated
+	// merge MOVIE_PATCHED into the playlist, clients without it are sent
+	// MOVIES_CHANGED instead
+	CapabilityMoviePatch
+)
+
+// ServerCapabilities are the capabilities the server supports
+const ServerCapabilities = CapabilityAck | CapabilityPreciseSync | CapabilitySourceUpdated | CapabilityMoviePatch
+
+func (c Capability) Has(capability Capability) bool {
+	return c&capability == capability
+}
+
+// Negotiate enables the capabilities both the client and the server support,
+// clients of version 1 and later are sent a HELLO with the result
+func (c *Client) Negotiate(version uint32, capabilities Capability) (Capability, error) {
+	capabilities &= ServerCapabilities
+	c.protocolVersion = version
+	c.capabilities = capabilities
+	if capabilities.Has(CapabilityAck) {
+		c.EnableAck()
+	}
+	if capabilities.Has(CapabilityPreciseSync) {
+		c.EnablePreciseSync()
+	}
+	if version == 0 {
+		return capabilities, nil
+	}
+	return capabilities, c.Send(&pb.ElementMessage{
+		Type:            pb.ElementMessageType_HELLO,
+		Time:            time.Now().UnixMilli(),
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    uint64(capabilities),
+	})
+}
+
+// ProtocolVersion is the version the client connected with
+func (c *Client) ProtocolVersion() uint32 {
+	return c.protocolVersion
+}
+
+// Capabilities are the negotiated capabilities of the client
+func (c *Client) Capabilities() Capability {
+	return c.capabilities
+}
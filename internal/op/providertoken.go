@@ -0,0 +1,81 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/provider/providers"
+	"github.com/synctv-org/synctv/internal/settings"
+	"golang.org/x/oauth2"
+)
+
+const (
+	providerTokenRefreshInterval = time.Minute
+	providerTokenRefreshTimeout  = 30 * time.Second
+)
+
+// StartProviderTokenRefresh refreshes kept provider tokens before they expire,
+// so membership checks after a long idle time neither wait for a refresh nor
+// find the token expired
+func StartProviderTokenRefresh(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(providerTokenRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			ahead := settings.ProviderTokenRefreshAhead.Get()
+			if ahead == 0 {
+				continue
+			}
+			ups, err := db.GetUserProvidersExpiringBefore(time.Now().Add(time.Duration(ahead) * time.Minute))
+			if err != nil {
+				log.Errorf("get expiring provider tokens error: %v", err)
+				continue
+			}
+			for _, up := range ups {
+				refreshProviderToken(ctx, up.UserID, up.Provider, up.ProviderUserID, up.RefreshToken)
+			}
+		}
+	}()
+}
+
+func refreshProviderToken(ctx context.Context, userID string, p provider.OAuth2Provider, puid, refreshToken string) {
+	pi, err := providers.GetProvider(p)
+	if err != nil {
+		// the provider is no longer enabled
+		return
+	}
+	mp, ok := pi.(provider.MembershipProvider)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, providerTokenRefreshTimeout)
+	defer cancel()
+	tk, err := mp.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		var re *oauth2.RetrieveError
+		if errors.As(err, &re) && re.Response != nil &&
+			re.Response.StatusCode >= http.StatusBadRequest && re.Response.StatusCode < http.StatusInternalServerError {
+			// revoked, the user has to log in with the provider again
+			log.Warnf("refresh %s token of user %s rejected, forgetting it: %v", p, userID, err)
+			if err := db.ClearUserProviderToken(p, puid); err != nil {
+				log.Errorf("clear %s token of user %s error: %v", p, userID, err)
+			}
+			return
+		}
+		log.Errorf("refresh %s token of user %s error: %v", p, userID, err)
+		return
+	}
+	if err := db.SaveUserProviderToken(p, puid, tk); err != nil {
+		log.Errorf("save %s token of user %s error: %v", p, userID, err)
+	}
+}
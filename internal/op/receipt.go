@@ -0,0 +1,65 @@
+package op
+
+import (
+	"errors"
+	"sync"
+)
+
+// only the read receipts of the latest messages are kept
+const maxTrackedChatMessages = 128
+
+var ErrChatMessageNotFound = errors.New("chat message not found")
+
+type chatReceipt struct {
+	senderID string
+	// users in the room when the message was sent, only their reads count
+	recipients map[string]struct{}
+	readers    map[string]struct{}
+}
+
+type chatReceipts struct {
+	lock  sync.Mutex
+	order []string
+	m     map[string]*chatReceipt
+}
+
+func (c *chatReceipts) add(messageID, senderID string, userIDs []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.m == nil {
+		c.m = make(map[string]*chatReceipt, maxTrackedChatMessages)
+	}
+	if len(c.order) >= maxTrackedChatMessages {
+		delete(c.m, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.order = append(c.order, messageID)
+	recipients := make(map[string]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		if id != senderID {
+			recipients[id] = struct{}{}
+		}
+	}
+	c.m[messageID] = &chatReceipt{
+		senderID:   senderID,
+		recipients: recipients,
+		readers:    make(map[string]struct{}),
+	}
+}
+
+// read marks the message as read by the user, changed is false if the user
+// has already read the message or is not a recipient, like the sender
+func (c *chatReceipts) read(messageID, userID string) (delivered, read int64, changed bool, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	r, ok := c.m[messageID]
+	if !ok {
+		return 0, 0, false, ErrChatMessageNotFound
+	}
+	_, recipient := r.recipients[userID]
+	if _, ok := r.readers[userID]; recipient && userID != r.senderID && !ok {
+		r.readers[userID] = struct{}{}
+		changed = true
+	}
+	return int64(len(r.recipients)), int64(len(r.readers)), changed, nil
+}
@@ -0,0 +1,120 @@
+package op
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+var ErrResumeBufferFull = errors.New("resume buffer full")
+
+// resumeBuffer keeps the messages sent to a dropped client until it is resumed
+type resumeBuffer struct {
+	lock     sync.Mutex
+	msgs     []Message
+	max      int
+	overflow bool
+}
+
+func newResumeBuffer() *resumeBuffer {
+	if settings.WsResumeTimeout.Get() <= 0 {
+		return nil
+	}
+	return &resumeBuffer{max: int(settings.WsResumeBufferSize.Get())}
+}
+
+func (b *resumeBuffer) push(msg Message) error {
+	// pings mean nothing once replayed
+	if msg.MessageType() != websocket.BinaryMessage {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.overflow || len(b.msgs) >= b.max {
+		b.overflow = true
+		return ErrResumeBufferFull
+	}
+	b.msgs = append(b.msgs, msg)
+	return nil
+}
+
+// take returns the buffered messages, false if some were lost
+func (b *resumeBuffer) take() ([]Message, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	msgs := b.msgs
+	b.msgs = nil
+	return msgs, !b.overflow
+}
+
+type resumeSession struct {
+	client *Client
+	timer  *time.Timer
+}
+
+// detachClient keeps a dropped client registered until it is resumed or the
+// resume timeout passes, so the room sees no leave and join for short drops
+func (h *Hub) detachClient(cli *Client) error {
+	if cli.resume.Load() == nil || h.Closed() {
+		return h.UnRegClient(cli)
+	}
+	s := &resumeSession{client: cli}
+	h.resumes.Store(cli.resumeToken, s)
+	s.timer = time.AfterFunc(time.Duration(settings.WsResumeTimeout.Get())*time.Second, func() {
+		if h.resumes.CompareAndDelete(cli.resumeToken, s) {
+			_ = h.UnRegClient(cli)
+		}
+	})
+	return nil
+}
+
+// cancelResume unregisters a detached client before its timeout
+func (h *Hub) cancelResume(cli *Client) {
+	s, ok := h.resumes.Load(cli.resumeToken)
+	if !ok || s.client != cli {
+		return
+	}
+	if h.resumes.CompareAndDelete(cli.resumeToken, s) {
+		s.timer.Stop()
+		_ = h.UnRegClient(cli)
+	}
+}
+
+// resumeClient registers cli in place of the detached client of the token and
+// replays the messages it missed, if the session is gone cli joins cold
+func (h *Hub) resumeClient(token string, cli *Client) (bool, error) {
+	s, ok := h.resumes.Load(token)
+	if !ok || s.client.u.ID != cli.u.ID || !h.resumes.CompareAndDelete(token, s) {
+		return false, h.RegClient(cli)
+	}
+	s.timer.Stop()
+	old := s.client
+	msgs, complete := old.resume.Load().take()
+	if !complete {
+		_ = h.UnRegClient(old)
+		return false, h.RegClient(cli)
+	}
+	c, ok := h.clients.Load(cli.u.ID)
+	if !ok {
+		return false, h.RegClient(cli)
+	}
+	c.lock.Lock()
+	if _, ok := c.m[old]; !ok {
+		c.lock.Unlock()
+		return false, h.RegClient(cli)
+	}
+	delete(c.m, old)
+	c.m[cli] = struct{}{}
+	// broadcasts to the user wait for the lock, so the replay keeps the order
+	_ = cli.SendResumeToken(true)
+	for _, msg := range msgs {
+		if err := cli.Send(msg); err != nil {
+			break
+		}
+	}
+	c.lock.Unlock()
+	return true, nil
+}
@@ -1,6 +1,7 @@
 package op
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"hash/crc32"
@@ -9,13 +10,16 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/events"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/wal"
 	"github.com/synctv-org/synctv/utils"
 	"github.com/zijiren233/gencontainer/rwmap"
 	rtmps "github.com/zijiren233/livelib/server"
 	"github.com/zijiren233/stream"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type Room struct {
@@ -26,6 +30,12 @@ type Room struct {
 	hub      *Hub
 	movies   *movies
 	members  rwmap.RWMap[string, *model.RoomMember]
+	receipts chatReceipts
+	// recent chat messages, used by moderation
+	chatHistory   chatHistory
+	castReceivers rwmap.RWMap[string, *CastReceiver]
+	// set while a snapshot session is recorded
+	snapshot atomic.Pointer[snapshotSession]
 }
 
 func (r *Room) lazyInitHub() {
@@ -34,6 +44,38 @@ func (r *Room) lazyInitHub() {
 	})
 }
 
+// publishUpdated tells subscribers, like the caches of public listings,
+// that the room as listed changed
+func (r *Room) publishUpdated() {
+	events.Rooms.Publish(events.RoomEvent{
+		Type:     events.RoomUpdated,
+		RoomID:   r.ID,
+		RoomName: r.Name,
+		Public:   !r.NeedPassword() && !r.Settings.Hidden,
+	})
+}
+
+// changed bumps the versions of the resources after they are modified
+func (r *Room) changed(rs ...Resource) {
+	r.lazyInitHub()
+	for _, res := range rs {
+		r.hub.bump(res)
+	}
+}
+
+// ResourceVersion identifies the state of the resources of the room, for
+// conditional requests of polling clients
+func (r *Room) ResourceVersion(rs ...Resource) string {
+	r.lazyInitHub()
+	return r.hub.Version(rs...)
+}
+
+// forgetMember drops the cached member after the member is modified
+func (r *Room) forgetMember(userID string) {
+	r.members.Delete(userID)
+	r.changed(ResourceMembers)
+}
+
 func (r *Room) PeopleNum() int64 {
 	if r.hub == nil {
 		return 0
@@ -41,6 +83,13 @@ func (r *Room) PeopleNum() int64 {
 	return r.hub.PeopleNum()
 }
 
+func (r *Room) Presences() []*Presence {
+	if r.hub == nil {
+		return nil
+	}
+	return r.hub.Presences()
+}
+
 func (r *Room) KickUser(userID string) error {
 	if r.hub == nil {
 		return nil
@@ -71,6 +120,7 @@ func (r *Room) close() {
 		r.hub.Close()
 		r.movies.Close()
 	}
+	wal.Close(r.ID)
 }
 
 func (r *Room) Version() uint32 {
@@ -86,19 +136,44 @@ func (r *Room) UpdateMovie(movieId string, movie *model.MovieBase) error {
 	if err != nil {
 		return err
 	}
-	return r.movies.Update(movieId, movie)
+	err = r.movies.Update(movieId, movie)
+	if err != nil {
+		return err
+	}
+	r.changed(ResourceMovies)
+	return r.logWal(WalMovieUpdated, WalMovieIDs{IDs: []string{movieId}})
+}
+
+// SetMovieExtension is used by integrations to attach data to a movie,
+// unlike UpdateMovie the current movie can be changed
+func (r *Room) SetMovieExtension(movieID string, ns model.ExtensionNamespace, name string, v any) error {
+	if err := r.movies.SetExtension(movieID, ns, name, v); err != nil {
+		return err
+	}
+	r.changed(ResourceMovies)
+	return nil
 }
 
 func (r *Room) AddMovie(m *model.Movie) error {
 	m.RoomID = r.ID
-	return r.movies.AddMovie(m)
+	err := r.movies.AddMovie(m)
+	if err != nil {
+		return err
+	}
+	r.changed(ResourceMovies)
+	return r.logWalMovies([]*model.Movie{m})
 }
 
 func (r *Room) AddMovies(movies []*model.Movie) error {
 	for _, m := range movies {
 		m.RoomID = r.ID
 	}
-	return r.movies.AddMovies(movies)
+	err := r.movies.AddMovies(movies)
+	if err != nil {
+		return err
+	}
+	r.changed(ResourceMovies)
+	return r.logWalMovies(movies)
 }
 
 func (r *Room) UserRole(userID string) (model.RoomMemberRole, error) {
@@ -157,6 +232,20 @@ func (r *Room) HasPermission(userID string, permission model.RoomMemberPermissio
 	}
 }
 
+// CanWatchMovie reports whether the watch restriction of the movie allows the user
+func (r *Room) CanWatchMovie(userID string, movie *model.Movie) bool {
+	if movie.MovieBase.Restriction == nil ||
+		movie.CreatorID == userID ||
+		r.IsCreator(userID) {
+		return true
+	}
+	member, err := r.LoadRoomMember(userID)
+	if err != nil || !member.Status.IsActive() {
+		return false
+	}
+	return movie.MovieBase.Restriction.Allowed(userID, member.Role, r.IsGuest(userID))
+}
+
 func (r *Room) HasAdminPermission(userID string, permission model.RoomAdminPermission) bool {
 	if r.IsCreator(userID) {
 		return true
@@ -167,7 +256,13 @@ func (r *Room) HasAdminPermission(userID string, permission model.RoomAdminPermi
 		return false
 	}
 
-	return rur.HasAdminPermission(permission)
+	if !rur.HasAdminPermission(permission) {
+		return false
+	}
+	if rur.Trial && rur.TrialActions > 0 {
+		r.consumeTrialAdminAction(userID)
+	}
+	return true
 }
 
 func (r *Room) LoadOrCreateMemberStatus(userID string) (model.RoomMemberStatus, error) {
@@ -200,8 +295,10 @@ func (r *Room) LoadOrCreateRoomMember(userID string) (*model.RoomMember, error)
 		return nil, errors.New("guest is disabled")
 	}
 	member, ok := r.members.Load(userID)
-	if ok {
+	if ok && !member.TrialExpired() {
 		return member, nil
+	} else if ok {
+		r.revertTrialAdmin(userID, trialAdminRevertExpired)
 	}
 	var conf []db.CreateRoomMemberRelationConfig
 	if r.IsCreator(userID) {
@@ -238,6 +335,7 @@ func (r *Room) LoadOrCreateRoomMember(userID string) (*model.RoomMember, error)
 	if err != nil {
 		return nil, err
 	}
+	r.changed(ResourceMembers)
 	return r.storeMember(userID, member), nil
 }
 
@@ -246,8 +344,10 @@ func (r *Room) LoadRoomMember(userID string) (*model.RoomMember, error) {
 		return nil, errors.New("guest is disabled")
 	}
 	member, ok := r.members.Load(userID)
-	if ok {
+	if ok && !member.TrialExpired() {
 		return member, nil
+	} else if ok {
+		r.revertTrialAdmin(userID, trialAdminRevertExpired)
 	}
 	member, err := db.GetRoomMember(r.ID, userID)
 	if err != nil {
@@ -257,6 +357,13 @@ func (r *Room) LoadRoomMember(userID string) (*model.RoomMember, error) {
 }
 
 func (r *Room) storeMember(userID string, member *model.RoomMember) *model.RoomMember {
+	if member.TrialExpired() {
+		r.revertTrialAdmin(userID, trialAdminRevertExpired)
+		member.Role = model.RoomMemberRoleMember
+		member.Permissions = member.TrialPermissions
+		member.AdminPermissions = model.NoAdminPermission
+		member.Trial = false
+	}
 	if r.IsCreator(userID) {
 		member.Role = model.RoomMemberRoleCreator
 		member.Permissions = model.AllPermissions
@@ -316,7 +423,11 @@ func (r *Room) SetPassword(password string) error {
 		atomic.StoreUint32(&r.version, crc32.ChecksumIEEE(hashedPassword))
 	}
 	r.HashedPassword = hashedPassword
-	return db.SetRoomHashedPassword(r.ID, hashedPassword)
+	if err := db.SetRoomHashedPassword(r.ID, hashedPassword); err != nil {
+		return err
+	}
+	r.publishUpdated()
+	return nil
 }
 
 func (r *Room) checkCanModifyMovie(id string) error {
@@ -369,32 +480,49 @@ func (r *Room) checkCanModifyMovies(ids []string) error {
 	return nil
 }
 
-func (r *Room) DeleteMovieByID(id string) error {
+// DeleteMovieByID moves the movie to the trash and returns the batch it can
+// be restored with, the batch is empty when the trash is disabled
+func (r *Room) DeleteMovieByID(id, trashedBy string) (string, error) {
 	err := r.checkCanModifyMovie(id)
 	if err != nil {
-		return err
+		return "", err
+	}
+	batchID, err := r.movies.DeleteMovieByID(id, trashedBy)
+	if err != nil {
+		return "", err
 	}
-	return r.movies.DeleteMovieByID(id)
+	r.changed(ResourceMovies)
+	return batchID, r.logWal(WalMoviesDeleted, WalMovieIDs{IDs: []string{id}})
 }
 
-func (r *Room) DeleteMoviesByID(ids []string) error {
+func (r *Room) DeleteMoviesByID(ids []string, trashedBy string) (string, error) {
 	err := r.checkCanModifyMovies(ids)
 	if err != nil {
-		return err
+		return "", err
+	}
+	batchID, err := r.movies.DeleteMoviesByID(ids, trashedBy)
+	if err != nil {
+		return "", err
 	}
-	return r.movies.DeleteMoviesByID(ids)
+	r.changed(ResourceMovies)
+	return batchID, r.logWal(WalMoviesDeleted, WalMovieIDs{IDs: ids})
 }
 
-func (r *Room) ClearMovies() error {
-	return r.ClearMoviesByParentID("")
+func (r *Room) ClearMovies(trashedBy string) (string, error) {
+	return r.ClearMoviesByParentID("", trashedBy)
 }
 
-func (r *Room) ClearMoviesByParentID(parentID string) error {
+func (r *Room) ClearMoviesByParentID(parentID, trashedBy string) (string, error) {
 	err := r.checkCanModifyMovie(parentID)
 	if err != nil {
-		return err
+		return "", err
+	}
+	batchID, err := r.movies.DeleteMovieByParentID(parentID, trashedBy)
+	if err != nil {
+		return "", err
 	}
-	return r.movies.DeleteMovieByParentID(parentID)
+	r.changed(ResourceMovies)
+	return batchID, r.logWal(WalMoviesCleared, WalMoviesClear{ParentID: parentID})
 }
 
 func (r *Room) GetMovieByID(id string) (*Movie, error) {
@@ -406,6 +534,24 @@ func (r *Room) Current() *Current {
 	return &c
 }
 
+// CurrentSnapshot is the current without the seek extrapolated to now,
+// see Status.UpdatedAt
+func (r *Room) CurrentSnapshot() *Current {
+	c := r.current.Snapshot()
+	return &c
+}
+
+// CurrentVersion changes whenever the current movie or its status changes
+func (r *Room) CurrentVersion() uint64 {
+	return r.current.Version()
+}
+
+// WaitCurrentChanged blocks until the current version differs from version
+// or ctx is done, and returns the latest version
+func (r *Room) WaitCurrentChanged(ctx context.Context, version uint64) uint64 {
+	return r.current.Wait(ctx, version)
+}
+
 func (r *Room) CurrentMovie() CurrentMovie {
 	return r.current.current.Movie
 }
@@ -446,7 +592,7 @@ func (r *Room) SetCurrentMovie(movieID string, subPath string, play bool) error
 	}
 	if movieID == "" {
 		r.current.SetMovie(CurrentMovie{}, false)
-		return nil
+		return r.logWal(WalCurrent, WalCurrentMovie{})
 	}
 	m, err := r.GetMovieByID(movieID)
 	if err != nil {
@@ -460,15 +606,29 @@ func (r *Room) SetCurrentMovie(movieID string, subPath string, play bool) error
 		ID:     m.ID,
 		IsLive: m.Live,
 	}, play)
+	err = r.logWal(WalCurrent, WalCurrentMovie{
+		MovieID: m.ID,
+		SubPath: subPath,
+		IsLive:  m.Live,
+		Playing: play,
+	})
+	if err != nil {
+		return err
+	}
 	return m.ClearCache()
 }
 
 func (r *Room) SwapMoviePositions(id1, id2 string) error {
-	return r.movies.SwapMoviePositions(id1, id2)
+	err := r.movies.SwapMoviePositions(id1, id2)
+	if err != nil {
+		return err
+	}
+	r.changed(ResourceMovies)
+	return r.logWal(WalMoviesSwapped, WalMovieIDs{IDs: []string{id1, id2}})
 }
 
-func (r *Room) GetMoviesWithPage(page, pageSize int, parentID string) ([]*model.Movie, int64, error) {
-	return r.movies.GetMoviesWithPage(page, pageSize, parentID)
+func (r *Room) GetMoviesWithPage(parentID string, page func(*gorm.DB) *gorm.DB, filters ...func(*gorm.DB) *gorm.DB) ([]*model.Movie, int64, error) {
+	return r.movies.GetMoviesWithPage(parentID, page, filters...)
 }
 
 func (r *Room) NewClient(user *User, conn *websocket.Conn) (*Client, error) {
@@ -478,9 +638,33 @@ func (r *Room) NewClient(user *User, conn *websocket.Conn) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	r.publishJoined(user)
 	return cli, nil
 }
 
+// ResumeClient registers a new client taking over the dropped session of the
+// token, resumed is false when the session is gone and the client joined cold
+func (r *Room) ResumeClient(user *User, conn *websocket.Conn, token string) (cli *Client, resumed bool, err error) {
+	r.lazyInitHub()
+	cli = newClient(user, r, conn)
+	resumed, err = r.hub.resumeClient(token, cli)
+	if err != nil {
+		return nil, false, err
+	}
+	if !resumed {
+		r.publishJoined(user)
+	}
+	return cli, resumed, nil
+}
+
+func (r *Room) publishJoined(user *User) {
+	events.Users.Publish(events.UserEvent{
+		Type:   events.UserJoinedRoom,
+		UserID: user.ID,
+		RoomID: r.ID,
+	})
+}
+
 func (r *Room) RegClient(cli *Client) error {
 	r.lazyInitHub()
 	return r.hub.RegClient(cli)
@@ -491,6 +675,13 @@ func (r *Room) UnregisterClient(cli *Client) error {
 	return r.hub.UnRegClient(cli)
 }
 
+// DetachClient unregisters the client, or keeps it for a while to be resumed
+// if its connection dropped
+func (r *Room) DetachClient(cli *Client) error {
+	r.lazyInitHub()
+	return r.hub.detachClient(cli)
+}
+
 func (r *Room) UserIsOnline(userID string) bool {
 	r.lazyInitHub()
 	return r.hub.IsOnline(userID)
@@ -502,11 +693,15 @@ func (r *Room) UserOnlineCount(userID string) int {
 }
 
 func (r *Room) SetCurrentStatus(playing bool, seek float64, rate float64, timeDiff float64) *Status {
-	return r.current.SetStatus(playing, seek, rate, timeDiff)
+	s := r.current.SetStatus(playing, seek, rate, timeDiff)
+	r.logWalStatus(s)
+	return s
 }
 
 func (r *Room) SetCurrentSeekRate(seek float64, rate float64, timeDiff float64) *Status {
-	return r.current.SetSeekRate(seek, rate, timeDiff)
+	s := r.current.SetSeekRate(seek, rate, timeDiff)
+	r.logWalStatus(s)
+	return s
 }
 
 func (r *Room) SetSettings(settings *model.RoomSettings) error {
@@ -530,12 +725,22 @@ func (r *Room) afterUpdateSettings(rs *model.RoomSettings) error {
 		r.members.Delete(db.GuestUserID)
 	}
 	r.Settings = rs
+	r.changed(ResourceSettings)
+	r.publishUpdated()
+	if err := r.logWal(WalSettings, rs); err != nil {
+		return err
+	}
 	if rs.DisableGuest {
 		return r.KickUser(db.GuestUserID)
 	}
 	return nil
 }
 
+// SyncTuning returns the drift thresholds of the room sync profile
+func (r *Room) SyncTuning() model.SyncTuning {
+	return r.Settings.SyncProfile.Tuning()
+}
+
 func (r *Room) ResetMemberPermissions(userID string) error {
 	return r.SetMemberPermissions(userID, r.Settings.UserDefaultPermissions)
 }
@@ -553,7 +758,7 @@ func (r *Room) SetMemberPermissions(userID string, permissions model.RoomMemberP
 	if r.IsGuest(userID) {
 		return r.SetGuestPermissions(permissions)
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.SetMemberPermissions(r.ID, userID, permissions)
 }
 
@@ -564,7 +769,7 @@ func (r *Room) AddMemberPermissions(userID string, permissions model.RoomMemberP
 	if r.IsAdmin(userID) {
 		return errors.New("cannot add permissions to admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.AddMemberPermissions(r.ID, userID, permissions)
 }
 
@@ -575,7 +780,7 @@ func (r *Room) RemoveMemberPermissions(userID string, permissions model.RoomMemb
 	if r.IsAdmin(userID) {
 		return errors.New("cannot remove permissions from admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RemoveMemberPermissions(r.ID, userID, permissions)
 }
 
@@ -583,7 +788,7 @@ func (r *Room) ApprovePendingMember(userID string) error {
 	if r.IsCreator(userID) {
 		return errors.New("you are creator, cannot approve")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RoomApprovePendingMember(r.ID, userID)
 }
 
@@ -595,7 +800,7 @@ func (r *Room) BanMember(userID string) error {
 		return errors.New("please set whether to disable guest users in the room settings")
 	}
 	defer func() {
-		r.members.Delete(userID)
+		r.forgetMember(userID)
 		_ = r.KickUser(userID)
 	}()
 	return db.RoomBanMember(r.ID, userID)
@@ -608,7 +813,7 @@ func (r *Room) UnbanMember(userID string) error {
 	if r.IsGuest(userID) {
 		return errors.New("please set whether to enable guest users in the room settings")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RoomUnbanMember(r.ID, userID)
 }
 
@@ -628,7 +833,7 @@ func (r *Room) SetAdminPermissions(userID string, permissions model.RoomAdminPer
 	} else if !member.Role.IsAdmin() {
 		return errors.New("not admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RoomSetAdminPermissions(r.ID, userID, permissions)
 }
 
@@ -644,7 +849,7 @@ func (r *Room) AddAdminPermissions(userID string, permissions model.RoomAdminPer
 	} else if !member.Role.IsAdmin() {
 		return errors.New("not admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RoomSetAdminPermissions(r.ID, userID, permissions)
 }
 
@@ -660,7 +865,7 @@ func (r *Room) RemoveAdminPermissions(userID string, permissions model.RoomAdmin
 	} else if !member.Role.IsAdmin() {
 		return errors.New("not admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RoomSetAdminPermissions(r.ID, userID, 0)
 }
 
@@ -671,7 +876,7 @@ func (r *Room) SetAdmin(userID string, permissions model.RoomAdminPermission) er
 	if r.IsGuest(userID) {
 		return errors.New("cannot set guest as admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RoomSetAdmin(r.ID, userID, permissions)
 }
 
@@ -679,6 +884,6 @@ func (r *Room) SetMember(userID string, permissions model.RoomMemberPermission)
 	if r.IsCreator(userID) {
 		return errors.New("you are creator, cannot set member")
 	}
-	defer r.members.Delete(userID)
+	defer r.forgetMember(userID)
 	return db.RoomSetMember(r.ID, userID, permissions)
 }
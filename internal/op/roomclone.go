@@ -0,0 +1,55 @@
+package op
+
+import (
+	"errors"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
+)
+
+// CloneRoom creates a room owned by the user with the settings and the
+// playlist of the room, members and chat are not copied. The clone lives in
+// the namespace of the room
+func (u *User) CloneRoom(src *Room, name, password string) (*RoomEntry, error) {
+	if !u.IsRoomAdmin(src) {
+		return nil, model.ErrNoPermission
+	}
+	if src.OrgID != "" && !u.IsOrgMember(src.OrgID) {
+		return nil, model.ErrNoPermission
+	}
+	tree, err := db.GetRoomMovieTree(src.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := *src.Settings
+	rs.ID = ""
+	entry, err := u.CreateRoom(name, password, db.WithSetting(&rs), db.WithOrg(src.OrgID))
+	if errors.Is(err, ErrRoomPending) {
+		// the room is created but not loaded until it is approved
+		pending, gerr := db.GetRoomByName(src.OrgID, namepolicy.Normalize(name))
+		if gerr != nil {
+			return nil, gerr
+		}
+		if len(tree) != 0 {
+			if cerr := db.CreateMovieTree(copyMovieTree(tree, pending.ID)); cerr != nil {
+				return nil, cerr
+			}
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(tree) == 0 {
+		return entry, nil
+	}
+	dst := entry.Value()
+	copies := copyMovieTree(tree, dst.ID)
+	if err := db.CreateMovieTree(copies); err != nil {
+		return nil, err
+	}
+	dst.changed(ResourceMovies)
+	return entry, dst.logWalMovies(copies)
+}
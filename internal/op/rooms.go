@@ -6,9 +6,12 @@ import (
 	"hash/crc32"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/events"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/wal"
 	"github.com/zijiren233/gencontainer/synccache"
 )
 
@@ -63,12 +66,15 @@ func LoadOrInitRoom(room *model.Room) (*RoomEntry, error) {
 		return nil, err
 	}
 
-	i, _ := roomCache.LoadOrStore(room.ID, &Room{
+	i, loaded := roomCache.LoadOrStore(room.ID, &Room{
 		Room:    *room,
 		version: crc32.ChecksumIEEE(room.HashedPassword),
 		current: newCurrent(),
 		movies:  &movies{roomID: room.ID},
 	}, time.Duration(settings.RoomTTL.Get())*time.Hour)
+	if !loaded {
+		i.Value().recoverCurrent()
+	}
 	return i, nil
 }
 
@@ -77,6 +83,14 @@ func DeleteRoomByID(roomID string) error {
 	if err != nil {
 		return err
 	}
+	stopRoomMirror(roomID)
+	if err := wal.Remove(roomID); err != nil {
+		log.Errorf("remove write-ahead log of room %s error: %v", roomID, err)
+	}
+	events.Rooms.Publish(events.RoomEvent{
+		Type:   events.RoomDeleted,
+		RoomID: roomID,
+	})
 	return CloseRoomById(roomID)
 }
 
@@ -85,6 +99,15 @@ func CompareAndDeleteRoom(room *RoomEntry) error {
 	if err != nil {
 		return err
 	}
+	stopRoomMirror(room.Value().ID)
+	if err := wal.Remove(room.Value().ID); err != nil {
+		log.Errorf("remove write-ahead log of room %s error: %v", room.Value().ID, err)
+	}
+	events.Rooms.Publish(events.RoomEvent{
+		Type:     events.RoomDeleted,
+		RoomID:   room.Value().ID,
+		RoomName: room.Value().Name,
+	})
 	CompareAndCloseRoom(room)
 	return nil
 }
@@ -168,5 +191,9 @@ func SetRoomStatusByID(roomID string, status model.RoomStatus) error {
 	case model.RoomStatusBanned, model.RoomStatusPending:
 		roomCache.Delete(roomID)
 	}
+	events.Rooms.Publish(events.RoomEvent{
+		Type:   events.RoomUpdated,
+		RoomID: roomID,
+	})
 	return nil
 }
@@ -0,0 +1,56 @@
+package op
+
+import (
+	"errors"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+const maxUserSecrets = 64
+
+// Secrets returns the vault of the user, cached until it changes
+func (u *User) Secrets() ([]*model.UserSecret, error) {
+	if s := u.secrets.Load(); s != nil {
+		return *s, nil
+	}
+	secrets, err := db.GetUserSecrets(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.secrets.Store(&secrets)
+	return secrets, nil
+}
+
+// SaveSecret adds a secret to the vault or rotates the one with the same name
+func (u *User) SaveSecret(secret *model.UserSecret) error {
+	if u.IsGuest() {
+		return errors.New("guest can not use the secrets vault")
+	}
+	if err := secret.Validate(); err != nil {
+		return err
+	}
+	secrets, err := u.Secrets()
+	if err != nil {
+		return err
+	}
+	exists := false
+	for _, s := range secrets {
+		if s.Name == secret.Name {
+			exists = true
+			break
+		}
+	}
+	if !exists && len(secrets) >= maxUserSecrets {
+		return errors.New("too many secrets")
+	}
+	secret.ID = ""
+	secret.UserID = u.ID
+	defer u.secrets.Store(nil)
+	return db.SaveUserSecret(secret)
+}
+
+func (u *User) DeleteSecret(name string) error {
+	defer u.secrets.Store(nil)
+	return db.DeleteUserSecret(u.ID, name)
+}
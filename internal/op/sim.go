@@ -0,0 +1,178 @@
+package op
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Clock is the time source of the sync engine, simulations replace it with
+// a virtual clock so runs are deterministic
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// SimClock is a virtual clock that only moves when advanced
+type SimClock struct {
+	now time.Time
+}
+
+func NewSimClock(start time.Time) *SimClock {
+	return &SimClock{now: start}
+}
+
+func (c *SimClock) Now() time.Time {
+	return c.now
+}
+
+func (c *SimClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type TraceEventType string
+
+const (
+	TraceEventStatus   TraceEventType = "status"
+	TraceEventSeekRate TraceEventType = "seek_rate"
+	TraceEventMovie    TraceEventType = "movie"
+)
+
+// TraceEvent is a change of the room sync state, with the state it resulted in
+type TraceEvent struct {
+	// since the start of the recording
+	At       time.Duration  `json:"at"`
+	Type     TraceEventType `json:"type"`
+	Playing  bool           `json:"playing,omitempty"`
+	Seek     float64        `json:"seek,omitempty"`
+	Rate     float64        `json:"rate,omitempty"`
+	TimeDiff float64        `json:"timeDiff,omitempty"`
+	MovieID  string         `json:"movieId,omitempty"`
+	IsLive   bool           `json:"isLive,omitempty"`
+	// status after the event
+	Result Status `json:"result"`
+}
+
+type Trace struct {
+	Events []TraceEvent `json:"events"`
+}
+
+// MaxTraceEvents bounds a recording started by the admin api
+const MaxTraceEvents = 4096
+
+type traceRecorder struct {
+	start  time.Time
+	max    int
+	events []TraceEvent
+}
+
+func (t *traceRecorder) record(now time.Time, e TraceEvent, result Status) {
+	if len(t.events) >= t.max {
+		return
+	}
+	e.At = now.Sub(t.start)
+	e.Result = result
+	t.events = append(t.events, e)
+}
+
+// StartTrace records the next max sync state changes of the room
+func (r *Room) StartTrace(max int) {
+	r.current.lock.Lock()
+	defer r.current.lock.Unlock()
+	r.current.trace = &traceRecorder{
+		start: r.current.clock.Now(),
+		max:   max,
+	}
+}
+
+// StopTrace stops the recording and returns it, nil if none was started
+func (r *Room) StopTrace() *Trace {
+	r.current.lock.Lock()
+	defer r.current.lock.Unlock()
+	t := r.current.trace
+	if t == nil {
+		return nil
+	}
+	r.current.trace = nil
+	return &Trace{Events: t.events}
+}
+
+// Simulation runs the room sync engine on a virtual clock
+type Simulation struct {
+	clock   *SimClock
+	current *current
+}
+
+func NewSimulation() *Simulation {
+	clock := NewSimClock(time.Unix(0, 0))
+	return &Simulation{
+		clock:   clock,
+		current: newCurrentWithClock(clock),
+	}
+}
+
+func (s *Simulation) Clock() *SimClock {
+	return s.clock
+}
+
+func (s *Simulation) Status() Status {
+	return s.current.Status()
+}
+
+// Apply advances the clock to the event and applies it
+func (s *Simulation) Apply(start time.Time, e TraceEvent) Status {
+	if at := start.Add(e.At); at.After(s.clock.Now()) {
+		s.clock.Advance(at.Sub(s.clock.Now()))
+	}
+	switch e.Type {
+	case TraceEventStatus:
+		return *s.current.SetStatus(e.Playing, e.Seek, e.Rate, e.TimeDiff)
+	case TraceEventSeekRate:
+		return *s.current.SetSeekRate(e.Seek, e.Rate, e.TimeDiff)
+	case TraceEventMovie:
+		s.current.SetMovie(CurrentMovie{ID: e.MovieID, IsLive: e.IsLive}, e.Playing)
+		return s.current.Status()
+	default:
+		return s.current.Status()
+	}
+}
+
+// TraceMismatch is an event whose replayed result differs from the recording
+type TraceMismatch struct {
+	Index int
+	Event TraceEvent
+	Got   Status
+}
+
+func (m TraceMismatch) Error() string {
+	return fmt.Sprintf("event %d (%s at %s): got playing %v seek %.3f rate %.2f, recorded playing %v seek %.3f rate %.2f",
+		m.Index, m.Event.Type, m.Event.At,
+		m.Got.Playing, m.Got.Seek, m.Got.Rate,
+		m.Event.Result.Playing, m.Event.Result.Seek, m.Event.Result.Rate,
+	)
+}
+
+// Replay applies the trace from the start and returns the events whose result
+// differs from the recording by more than tolerance seconds of seek
+func (s *Simulation) Replay(trace *Trace, tolerance float64) []TraceMismatch {
+	var mismatches []TraceMismatch
+	start := s.clock.Now()
+	for i, e := range trace.Events {
+		got := s.Apply(start, e)
+		if got.Playing != e.Result.Playing ||
+			got.Rate != e.Result.Rate ||
+			math.Abs(got.Seek-e.Result.Seek) > tolerance {
+			mismatches = append(mismatches, TraceMismatch{
+				Index: i,
+				Event: e,
+				Got:   got,
+			})
+		}
+	}
+	return mismatches
+}
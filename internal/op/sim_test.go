@@ -0,0 +1,47 @@
+package op
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulationReplaysRecordedTrace(t *testing.T) {
+	clock := NewSimClock(time.Unix(1700000000, 0))
+	c := newCurrentWithClock(clock)
+	c.trace = &traceRecorder{start: clock.Now(), max: MaxTraceEvents}
+
+	c.SetMovie(CurrentMovie{ID: "movie"}, false)
+	clock.Advance(time.Second)
+	c.SetStatus(true, 10, 1, 0.5)
+	clock.Advance(3 * time.Second)
+	c.SetSeekRate(20, 2, 0.25)
+	clock.Advance(1500 * time.Millisecond)
+	c.SetStatus(false, 30, 2, 0)
+
+	trace := &Trace{Events: c.trace.events}
+	if len(trace.Events) != 4 {
+		t.Fatalf("recorded %d events, want 4", len(trace.Events))
+	}
+
+	sim := NewSimulation()
+	if mismatches := sim.Replay(trace, 0); len(mismatches) != 0 {
+		for _, m := range mismatches {
+			t.Error(m.Error())
+		}
+	}
+}
+
+func TestSimulationAdvancesPlayingSeek(t *testing.T) {
+	sim := NewSimulation()
+	sim.Apply(sim.Clock().Now(), TraceEvent{
+		Type:    TraceEventStatus,
+		Playing: true,
+		Seek:    5,
+		Rate:    1.5,
+	})
+	sim.Clock().Advance(4 * time.Second)
+	if seek := sim.Status().Seek; math.Abs(seek-11) > 1e-9 {
+		t.Fatalf("seek is %v, want 11", seek)
+	}
+}
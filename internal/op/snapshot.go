@@ -0,0 +1,128 @@
+package op
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// a session keeps at most this many chat messages, older ones are dropped
+const maxSnapshotChat = 4096
+
+var ErrSnapshotNotStarted = errors.New("snapshot session is not started")
+
+type snapshotSession struct {
+	lock  sync.Mutex
+	start time.Time
+	chat  []*model.SnapshotChat
+}
+
+func (s *snapshotSession) addChat(msg *ChatMessage) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.chat) >= maxSnapshotChat {
+		s.chat = s.chat[1:]
+	}
+	s.chat = append(s.chat, &model.SnapshotChat{
+		Offset:     msg.SentAt.Sub(s.start).Milliseconds(),
+		SenderName: msg.SenderName,
+		Message:    msg.Message,
+	})
+}
+
+func (r *Room) addChatMessage(msg *ChatMessage) {
+	r.chatHistory.add(msg)
+	if s := r.snapshot.Load(); s != nil {
+		s.addChat(msg)
+	}
+}
+
+// StartRoomSnapshot starts recording the chat and the playback of the room,
+// the status timeline is recorded with the room trace so a running trace is restarted
+func (u *User) StartRoomSnapshot(room *Room) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionPublishSnapshot) {
+		return model.ErrNoPermission
+	}
+	room.StartTrace(MaxTraceEvents)
+	room.snapshot.Store(&snapshotSession{
+		start: room.current.clock.Now(),
+	})
+	return nil
+}
+
+// PublishRoomSnapshot ends the session and publishes it with the current playlist
+func (u *User) PublishRoomSnapshot(room *Room, title string) (*model.RoomSnapshot, error) {
+	if !u.HasRoomAdminPermission(room, model.PermissionPublishSnapshot) {
+		return nil, model.ErrNoPermission
+	}
+	session := room.snapshot.Swap(nil)
+	if session == nil {
+		return nil, ErrSnapshotNotStarted
+	}
+	trace := room.StopTrace()
+
+	movies, err := db.GetMoviesByRoomID(room.ID, db.WithParentMovieID(""))
+	if err != nil {
+		return nil, err
+	}
+	playlist := make([]*model.SnapshotMovie, len(movies))
+	for i, m := range movies {
+		playlist[i] = &model.SnapshotMovie{
+			ID:   m.ID,
+			Name: m.Name,
+			Live: m.Live,
+		}
+	}
+
+	var timeline []*model.SnapshotStatus
+	if trace != nil {
+		movieID := ""
+		timeline = make([]*model.SnapshotStatus, len(trace.Events))
+		for i, e := range trace.Events {
+			if e.Type == TraceEventMovie {
+				movieID = e.MovieID
+			}
+			timeline[i] = &model.SnapshotStatus{
+				Offset:  e.At.Milliseconds(),
+				MovieID: movieID,
+				Playing: e.Result.Playing,
+				Seek:    e.Result.Seek,
+				Rate:    e.Result.Rate,
+			}
+		}
+	}
+
+	session.lock.Lock()
+	chat := session.chat
+	session.lock.Unlock()
+
+	snapshot := &model.RoomSnapshot{
+		RoomID:    room.ID,
+		CreatorID: u.ID,
+		Title:     title,
+		StartAt:   session.start,
+		EndAt:     time.Now(),
+		Playlist:  playlist,
+		Chat:      chat,
+		Timeline:  timeline,
+	}
+	if err := db.CreateRoomSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	u.roomAudit(room, model.RoomAuditActionPublishSnapshot, snapshot.ID)
+	return snapshot, nil
+}
+
+func (u *User) DeleteRoomSnapshot(room *Room, id string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionPublishSnapshot) {
+		return model.ErrNoPermission
+	}
+	if err := db.DeleteRoomSnapshot(room.ID, id); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionDeleteSnapshot, id)
+	return nil
+}
@@ -0,0 +1,148 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/cache"
+	"github.com/synctv-org/synctv/internal/model"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/utils"
+)
+
+const (
+	sourceRotationInterval = 30 * time.Second
+	// signed source urls are rotated this long before they expire, leaving
+	// clients time to reload them before playback hits a 403
+	sourceRotateAhead = 2 * time.Minute
+)
+
+// StartSourceRotation rotates the signed source urls of the movies playing
+// in rooms before they expire and tells the clients to reload them
+func StartSourceRotation(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(sourceRotationInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			RangeRoomCache(func(_ string, value *RoomEntry) bool {
+				rotateRoomSource(ctx, value.Value())
+				return true
+			})
+		}
+	}()
+}
+
+func rotateRoomSource(ctx context.Context, room *Room) {
+	if room.PeopleNum() == 0 {
+		return
+	}
+	current := room.Current()
+	if current.Movie.ID == "" || current.Movie.IsLive {
+		return
+	}
+	movie, err := room.GetMovieByID(current.Movie.ID)
+	if err != nil {
+		return
+	}
+	expireAt := movie.SourceExpireAt()
+	if expireAt.IsZero() || time.Until(expireAt) > sourceRotateAhead {
+		return
+	}
+	rotated, err := movie.rotateSource(ctx)
+	if err != nil {
+		log.Errorf("rotate source of movie %s error: %v", movie.ID, err)
+		return
+	}
+	// the upstream handed out the same url again
+	if !rotated.After(expireAt) || !movie.clientHoldsSource() {
+		return
+	}
+	_ = room.Broadcast(&pb.ElementMessage{
+		Type:     pb.ElementMessageType_SOURCE_UPDATED,
+		Time:     time.Now().UnixMilli(),
+		ExpireId: movie.ExpireId(),
+	}, WithFallback(CapabilitySourceUpdated, &pb.ElementMessage{
+		Type: pb.ElementMessageType_CURRENT_EXPIRED,
+		Time: time.Now().UnixMilli(),
+	}))
+}
+
+// SourceExpireAt is when the earliest signed source url of the movie
+// resolved so far expires, zero if none was resolved or it is unknown
+func (m *Movie) SourceExpireAt() time.Time {
+	switch m.VendorInfo.Vendor {
+	case model.VendorAlist:
+		if c := m.alistCache.Load(); c != nil {
+			if data, err := c.Raw(); err == nil && data != nil {
+				return data.ExpireAt
+			}
+		}
+	case model.VendorEmby:
+		if c := m.embyCache.Load(); c != nil {
+			if data, err := c.Raw(); err == nil && data != nil {
+				return data.ExpireAt
+			}
+		}
+	case model.VendorPlugin:
+		if c := m.pluginCache.Load(); c != nil {
+			if data, err := c.Raw(); err == nil && data != nil {
+				return data.ExpireAt
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// rotateSource resolves the sources again with the creator's binding and
+// returns when the new urls expire
+func (m *Movie) rotateSource(ctx context.Context) (time.Time, error) {
+	creator, err := LoadOrInitUserByID(m.CreatorID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch m.VendorInfo.Vendor {
+	case model.VendorAlist:
+		data, err := m.AlistCache().Refresh(ctx, &cache.AlistMovieCacheFuncArgs{
+			UserCache: creator.Value().AlistCache(),
+			UserAgent: utils.UA,
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+		return data.ExpireAt, nil
+	case model.VendorEmby:
+		data, err := m.EmbyCache().Refresh(ctx, creator.Value().EmbyCache())
+		if err != nil {
+			return time.Time{}, err
+		}
+		return data.ExpireAt, nil
+	case model.VendorPlugin:
+		data, err := m.PluginVendorCache().Refresh(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return data.ExpireAt, nil
+	}
+	return time.Time{}, nil
+}
+
+// clientHoldsSource reports whether clients play the signed urls directly,
+// proxied movies are resolved again by the server on each request
+func (m *Movie) clientHoldsSource() bool {
+	if !m.MovieBase.Proxy {
+		return true
+	}
+	// the playlist of aliyundrive lists the signed urls of the segments
+	if m.VendorInfo.Vendor == model.VendorAlist {
+		if data, err := m.AlistCache().Raw(); err == nil && data != nil {
+			return data.Ali != nil
+		}
+	}
+	return false
+}
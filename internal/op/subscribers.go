@@ -0,0 +1,88 @@
+package op
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/events"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/notify"
+)
+
+// subscribers of the integrations living in this package, the ones of other
+// packages subscribe in their own package
+func init() {
+	events.Rooms.Subscribe("audit", func(e events.RoomEvent) {
+		if e.Type != events.RoomModerated {
+			return
+		}
+		if err := db.CreateRoomAudit(e.RoomID, e.UserID, model.RoomAuditAction(e.Action), e.Target); err != nil {
+			log.Errorf("create room audit failed: %v", err)
+		}
+	})
+	events.Rooms.Subscribe("notify", func(e events.RoomEvent) {
+		if e.Type != events.RoomLiveStarted {
+			return
+		}
+		notify.NotifyRoomFollowers(e.RoomID, &notify.Notification{
+			Event:   notify.EventRoomLive,
+			Title:   e.RoomName,
+			Message: fmt.Sprintf("%s started a live stream in room %s", e.Username, e.RoomName),
+		}, e.UserID)
+	})
+	events.Rooms.Subscribe("achievements", func(e events.RoomEvent) {
+		if e.Type != events.RoomCreated {
+			return
+		}
+		if u, ok := eventUser(e.UserID); ok {
+			u.recordRoomCreated()
+		}
+	})
+	events.Users.Subscribe("achievements", func(e events.UserEvent) {
+		u, ok := eventUser(e.UserID)
+		if !ok {
+			return
+		}
+		room, ok := eventRoom(e.RoomID)
+		if !ok {
+			return
+		}
+		switch e.Type {
+		case events.UserJoinedRoom:
+			u.recordWatch(room)
+		case events.UserChatMessage:
+			u.recordChatMessage(room)
+		}
+	})
+	events.Playback.Subscribe("achievements", func(e events.PlaybackEvent) {
+		if e.Type != events.PlaybackMovieChanged {
+			return
+		}
+		u, ok := eventUser(e.UserID)
+		if !ok {
+			return
+		}
+		if room, ok := eventRoom(e.RoomID); ok {
+			u.recordWatch(room)
+		}
+	})
+}
+
+func eventUser(id string) (*User, bool) {
+	u, err := LoadOrInitUserByID(id)
+	if err != nil {
+		log.Errorf("events: load user %s error: %v", id, err)
+		return nil, false
+	}
+	return u.Value(), true
+}
+
+func eventRoom(id string) (*Room, bool) {
+	r, err := LoadOrInitRoomByID(id)
+	if err != nil {
+		log.Errorf("events: load room %s error: %v", id, err)
+		return nil, false
+	}
+	return r.Value(), true
+}
@@ -0,0 +1,121 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// audit targets of reverted trial admins
+const (
+	trialAdminRevertExpired = "expired"
+	trialAdminRevertActions = "actions used up"
+)
+
+const trialAdminCheckInterval = time.Minute
+
+// SetTrialAdmin makes a member admin for duration or for actions admin actions,
+// whichever ends first, a zero duration or actions is unlimited
+func (r *Room) SetTrialAdmin(userID string, permissions model.RoomAdminPermission, duration time.Duration, actions int64) error {
+	if r.IsCreator(userID) {
+		return errors.New("you are creator, cannot set trial admin")
+	}
+	if r.IsGuest(userID) {
+		return errors.New("cannot set guest as trial admin")
+	}
+	if duration <= 0 && actions <= 0 {
+		return errors.New("trial admin needs a duration or a number of actions")
+	}
+	member, err := r.LoadRoomMember(userID)
+	if err != nil {
+		return err
+	}
+	if !member.Status.IsActive() {
+		return errors.New("member is not active")
+	}
+	revert := member.Permissions
+	if member.Role.IsAdmin() {
+		if !member.Trial {
+			return errors.New("member is already admin")
+		}
+		// extending a trial keeps the permissions to revert to
+		revert = member.TrialPermissions
+	}
+	var expireAt *time.Time
+	if duration > 0 {
+		t := time.Now().Add(duration)
+		expireAt = &t
+	}
+	defer r.forgetMember(userID)
+	return db.RoomSetTrialAdmin(r.ID, userID, permissions, expireAt, actions, revert)
+}
+
+func (r *Room) revertTrialAdmin(userID, reason string) {
+	defer r.forgetMember(userID)
+	reverted, err := db.RoomRevertTrialAdmin(r.ID, userID)
+	if err != nil {
+		log.Errorf("revert trial admin %s of room %s error: %v", userID, r.ID, err)
+		return
+	}
+	if !reverted {
+		return
+	}
+	if err := db.CreateRoomAudit(r.ID, userID, model.RoomAuditActionRevertTrialAdmin, reason); err != nil {
+		log.Errorf("create room audit error: %v", err)
+	}
+}
+
+func (r *Room) consumeTrialAdminAction(userID string) {
+	defer r.forgetMember(userID)
+	left, err := db.ConsumeTrialAdminAction(r.ID, userID)
+	if err != nil {
+		log.Errorf("consume trial admin action error: %v", err)
+		return
+	}
+	if left == 0 {
+		r.revertTrialAdmin(userID, trialAdminRevertActions)
+	}
+}
+
+func (u *User) SetRoomTrialAdmin(room *Room, userID string, permissions model.RoomAdminPermission, duration time.Duration, actions int64) error {
+	if !u.IsRoomCreator(room) {
+		return model.ErrNoPermission
+	}
+	if err := room.SetTrialAdmin(userID, permissions, duration, actions); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionGrantTrialAdmin, userID)
+	return nil
+}
+
+// StartTrialAdminExpiry reverts expired trial admins of rooms nobody loads
+func StartTrialAdminExpiry(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(trialAdminCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			members, err := db.GetExpiredTrialAdmins(time.Now())
+			if err != nil {
+				log.Errorf("get expired trial admins error: %v", err)
+				continue
+			}
+			for _, m := range members {
+				room, err := LoadOrInitRoomByID(m.RoomID)
+				if err != nil {
+					log.Errorf("load room %s error: %v", m.RoomID, err)
+					continue
+				}
+				room.Value().revertTrialAdmin(m.UserID, trialAdminRevertExpired)
+			}
+		}
+	}()
+}
@@ -4,16 +4,21 @@ import (
 	"errors"
 	"hash/crc32"
 	"sync/atomic"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/cache"
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/email"
+	"github.com/synctv-org/synctv/internal/events"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/internal/settings"
 	pb "github.com/synctv-org/synctv/proto/message"
 	"github.com/zijiren233/stream"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -22,6 +27,16 @@ type User struct {
 	alistCache    atomic.Pointer[cache.AlistUserCache]
 	bilibiliCache atomic.Pointer[cache.BilibiliUserCache]
 	embyCache     atomic.Pointer[cache.EmbyUserCache]
+	secrets       atomic.Pointer[[]*model.UserSecret]
+	lastAct       int64
+}
+
+func (u *User) UpdateLastAct() {
+	atomic.StoreInt64(&u.lastAct, time.Now().UnixMilli())
+}
+
+func (u *User) LastAct() time.Time {
+	return time.UnixMilli(atomic.LoadInt64(&u.lastAct))
 }
 
 func (u *User) AlistCache() *cache.AlistUserCache {
@@ -82,9 +97,13 @@ func (u *User) SetPassword(password string) error {
 }
 
 func (u *User) CreateRoom(name, password string, conf ...db.CreateRoomConfig) (*RoomEntry, error) {
+	name = namepolicy.Normalize(name)
 	if u.IsAdmin() {
 		conf = append(conf, db.WithStatus(model.RoomStatusActive))
 	} else {
+		if err := CheckRoomName(name); err != nil {
+			return nil, err
+		}
 		if password == "" && settings.RoomMustNeedPwd.Get() {
 			return nil, errors.New("room must need password")
 		}
@@ -103,13 +122,27 @@ func (u *User) CreateRoom(name, password string, conf ...db.CreateRoomConfig) (*
 		maxCount = settings.UserMaxRoomCount.Get()
 	}
 
-	return CreateRoom(name, password, maxCount, append(conf, db.WithCreator(&u.User))...)
+	room, err := CreateRoom(name, password, maxCount, append(conf, db.WithCreator(&u.User))...)
+	if err != nil {
+		return nil, err
+	}
+	events.Rooms.Publish(events.RoomEvent{
+		Type:     events.RoomCreated,
+		RoomID:   room.Value().ID,
+		RoomName: room.Value().Name,
+		UserID:   u.ID,
+		Username: u.Username,
+	})
+	return room, nil
 }
 
 func (u *User) NewMovie(movie *model.MovieBase) (*model.Movie, error) {
 	if movie == nil {
 		return nil, errors.New("movie is nil")
 	}
+	if err := settings.CheckVendorEnabled(movie.VendorInfo.Vendor); err != nil {
+		return nil, err
+	}
 	switch movie.VendorInfo.Vendor {
 	case model.VendorBilibili:
 		if movie.VendorInfo.Bilibili == nil {
@@ -119,6 +152,22 @@ func (u *User) NewMovie(movie *model.MovieBase) (*model.Movie, error) {
 		if movie.VendorInfo.Alist == nil {
 			return nil, errors.New("alist payload is nil")
 		}
+	case model.VendorDouyin:
+		if movie.VendorInfo.Douyin == nil {
+			return nil, errors.New("douyin payload is nil")
+		}
+	case model.VendorAcfun:
+		if movie.VendorInfo.Acfun == nil {
+			return nil, errors.New("acfun payload is nil")
+		}
+	case model.VendorYouku:
+		if movie.VendorInfo.Youku == nil {
+			return nil, errors.New("youku payload is nil")
+		}
+	case model.VendorPlugin:
+		if movie.VendorInfo.Plugin == nil {
+			return nil, errors.New("plugin payload is nil")
+		}
 	}
 	return &model.Movie{
 		MovieBase: *movie,
@@ -207,6 +256,80 @@ func (u *User) HasRoomPermission(room *Room, permission model.RoomMemberPermissi
 	return room.HasPermission(u.ID, permission)
 }
 
+// MovieLock is why a user can not watch a movie
+type MovieLock string
+
+const (
+	MovieUnlocked            MovieLock = ""
+	MovieLockedByRestriction MovieLock = "restriction"
+	MovieLockedByRating      MovieLock = "rating"
+	// the user has not entered the password of the movie yet
+	MovieLockedByPassword MovieLock = "password"
+)
+
+func (u *User) MovieLock(room *Room, movie *model.Movie) MovieLock {
+	if !u.IsAdmin() && !room.CanWatchMovie(u.ID, movie) {
+		return MovieLockedByRestriction
+	}
+	if !u.ContentRatingLimit(room).Allows(movie.MovieBase.Rating, settings.BlockUnratedContent.Get()) {
+		return MovieLockedByRating
+	}
+	if !u.movieUnlocked(room, movie) {
+		return MovieLockedByPassword
+	}
+	return MovieUnlocked
+}
+
+func (u *User) movieUnlocked(room *Room, movie *model.Movie) bool {
+	if !movie.NeedPassword() || u.ID == movie.CreatorID || u.IsAdmin() || u.IsRoomCreator(room) {
+		return true
+	}
+	if u.IsGuest() {
+		return false
+	}
+	unlocked, err := db.IsMovieUnlocked(movie.ID, u.ID)
+	if err != nil {
+		log.Errorf("check movie unlock error: %v", err)
+		return false
+	}
+	return unlocked
+}
+
+var ErrMoviePassword = errors.New("movie password is incorrect")
+
+// UnlockMovie checks the password of a movie once, the user can watch it
+// without entering it again until the password changes
+func (u *User) UnlockMovie(room *Room, movie *model.Movie, password string) error {
+	if !movie.NeedPassword() {
+		return nil
+	}
+	if u.IsGuest() {
+		return errors.New("guests can not unlock password protected movies")
+	}
+	if !movie.CheckPassword(password) {
+		return ErrMoviePassword
+	}
+	if err := db.CreateMovieUnlock(movie.ID, u.ID); err != nil {
+		return err
+	}
+	room.changed(ResourceMovies)
+	return nil
+}
+
+func (u *User) CanWatchMovie(room *Room, movie *model.Movie) bool {
+	return u.MovieLock(room, movie) == MovieUnlocked
+}
+
+// ContentRatingLimit returns the maximum content rating of the user in the room,
+// the room maximum does not apply to site admins and room admins
+func (u *User) ContentRatingLimit(room *Room) model.ContentRating {
+	limit := u.MaxContentRating
+	if !u.IsAdmin() && !u.IsRoomAdmin(room) {
+		limit = limit.Stricter(room.Settings.MaxContentRating)
+	}
+	return limit
+}
+
 func (u *User) HasRoomAdminPermission(room *Room, permission model.RoomAdminPermission) bool {
 	if u.IsAdmin() {
 		return true
@@ -214,11 +337,15 @@ func (u *User) HasRoomAdminPermission(room *Room, permission model.RoomAdminPerm
 	if u.IsGuest() {
 		return false
 	}
+	// org admins administer all rooms of their organization
+	if u.IsOrgAdmin(room.OrgID) {
+		return true
+	}
 	return room.HasAdminPermission(u.ID, permission)
 }
 
 func (u *User) IsRoomAdmin(room *Room) bool {
-	return room.IsAdmin(u.ID)
+	return room.IsAdmin(u.ID) || u.IsOrgAdmin(room.OrgID)
 }
 
 func (u *User) IsRoomCreator(room *Room) bool {
@@ -300,6 +427,10 @@ func (u *User) Unban() error {
 }
 
 func (u *User) SetUsername(username string) error {
+	username = namepolicy.Normalize(username)
+	if err := CheckUsername(username, u.ID); err != nil {
+		return err
+	}
 	if err := db.SetUsernameByID(u.ID, username); err != nil {
 		return err
 	}
@@ -307,11 +438,26 @@ func (u *User) SetUsername(username string) error {
 	return nil
 }
 
+func (u *User) SetMaxContentRating(rating model.ContentRating) error {
+	if err := db.SetUserMaxContentRatingByID(u.ID, rating); err != nil {
+		return err
+	}
+	u.MaxContentRating = rating
+	return nil
+}
+
 func (u *User) UpdateRoomMovie(room *Room, movieID string, movie *model.MovieBase) error {
 	if !u.HasRoomPermission(room, model.PermissionEditMovie) {
 		return model.ErrNoPermission
 	}
-	err := room.UpdateMovie(movieID, movie)
+	current, err := room.GetMovieByID(movieID)
+	if err != nil {
+		return err
+	}
+	if err := checkCredentialEdit(u.ID, current.Movie, movie); err != nil {
+		return err
+	}
+	err = room.UpdateMovie(movieID, movie)
 	if err != nil {
 		return err
 	}
@@ -338,31 +484,34 @@ func (u *User) UpdateRoomSettings(room *Room, settings map[string]interface{}) e
 	return room.UpdateSettings(settings)
 }
 
-func (u *User) DeleteRoomMovieByID(room *Room, movieID string) error {
+// DeleteRoomMovieByID moves the movie to the trash of the room and returns
+// the batch it can be restored with
+func (u *User) DeleteRoomMovieByID(room *Room, movieID string) (string, error) {
 	m, err := room.GetMovieByID(movieID)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if m.Movie.CreatorID != u.ID && !u.HasRoomPermission(room, model.PermissionDeleteMovie) {
-		return model.ErrNoPermission
+		return "", model.ErrNoPermission
 	}
-	return room.DeleteMovieByID(movieID)
+	return room.DeleteMovieByID(movieID, u.ID)
 }
 
-func (u *User) DeleteRoomMoviesByID(room *Room, movieIDs []string) error {
+func (u *User) DeleteRoomMoviesByID(room *Room, movieIDs []string) (string, error) {
 	for _, id := range movieIDs {
 		m, err := room.GetMovieByID(id)
 		if err != nil {
-			return err
+			return "", err
 		}
 		if m.Movie.CreatorID != u.ID && !u.HasRoomPermission(room, model.PermissionDeleteMovie) {
-			return model.ErrNoPermission
+			return "", model.ErrNoPermission
 		}
 	}
-	if err := room.DeleteMoviesByID(movieIDs); err != nil {
-		return err
+	batchID, err := room.DeleteMoviesByID(movieIDs, u.ID)
+	if err != nil {
+		return "", err
 	}
-	return room.Broadcast(&pb.ElementMessage{
+	return batchID, room.Broadcast(&pb.ElementMessage{
 		Type: pb.ElementMessageType_MOVIES_CHANGED,
 		MoviesChanged: &pb.Sender{
 			Username: u.Username,
@@ -371,32 +520,19 @@ func (u *User) DeleteRoomMoviesByID(room *Room, movieIDs []string) error {
 	})
 }
 
-func (u *User) ClearRoomMovies(room *Room) error {
-	if !u.HasRoomPermission(room, model.PermissionDeleteMovie) {
-		return model.ErrNoPermission
-	}
-	err := room.ClearMovies()
-	if err != nil {
-		return err
-	}
-	return room.Broadcast(&pb.ElementMessage{
-		Type: pb.ElementMessageType_MOVIES_CHANGED,
-		MoviesChanged: &pb.Sender{
-			Username: u.Username,
-			Userid:   u.ID,
-		},
-	})
+func (u *User) ClearRoomMovies(room *Room) (string, error) {
+	return u.ClearRoomMoviesByParentID(room, "")
 }
 
-func (u *User) ClearRoomMoviesByParentID(room *Room, parentID string) error {
+func (u *User) ClearRoomMoviesByParentID(room *Room, parentID string) (string, error) {
 	if !u.HasRoomPermission(room, model.PermissionDeleteMovie) {
-		return model.ErrNoPermission
+		return "", model.ErrNoPermission
 	}
-	err := room.ClearMoviesByParentID(parentID)
+	batchID, err := room.ClearMoviesByParentID(parentID, u.ID)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return room.Broadcast(&pb.ElementMessage{
+	return batchID, room.Broadcast(&pb.ElementMessage{
 		Type: pb.ElementMessageType_MOVIES_CHANGED,
 		MoviesChanged: &pb.Sender{
 			Username: u.Username,
@@ -430,6 +566,24 @@ func (u *User) SetRoomCurrentMovie(room *Room, movieID string, subPath string, p
 	if err != nil {
 		return err
 	}
+	current := room.CurrentMovie()
+	events.Playback.Publish(events.PlaybackEvent{
+		Type:    events.PlaybackMovieChanged,
+		RoomID:  room.ID,
+		UserID:  u.ID,
+		MovieID: current.ID,
+		Live:    current.IsLive,
+	})
+	if current.IsLive {
+		events.Rooms.Publish(events.RoomEvent{
+			Type:     events.RoomLiveStarted,
+			RoomID:   room.ID,
+			RoomName: room.Name,
+			UserID:   u.ID,
+			Username: u.Username,
+			Public:   !room.NeedPassword() && !room.Settings.Hidden,
+		})
+	}
 	return room.Broadcast(&pb.ElementMessage{
 		Type: pb.ElementMessageType_CURRENT_CHANGED,
 		CurrentChanged: &pb.Sender{
@@ -439,6 +593,85 @@ func (u *User) SetRoomCurrentMovie(room *Room, movieID string, subPath string, p
 	})
 }
 
+func (u *User) DeleteRoomChatMessage(room *Room, messageID string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionDeleteChatMessage) {
+		return model.ErrNoPermission
+	}
+	unpinned, err := room.DeleteChatMessage(messageID)
+	if err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionDeleteChatMessage, messageID)
+	if unpinned {
+		_ = room.Broadcast(&pb.ElementMessage{
+			Type:        pb.ElementMessageType_PINS_CHANGED,
+			PinsChanged: &pb.Sender{Username: u.Username, Userid: u.ID},
+		})
+	}
+	return room.Broadcast(newChatDeletedMessage(u, messageID))
+}
+
+func (u *User) PurgeRoomChatMessages(room *Room, senderID string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionDeleteChatMessage) {
+		return model.ErrNoPermission
+	}
+	ids, unpinned, err := room.PurgeChatMessages(senderID)
+	if err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionPurgeChatMessages, senderID)
+	if unpinned {
+		_ = room.Broadcast(&pb.ElementMessage{
+			Type:        pb.ElementMessageType_PINS_CHANGED,
+			PinsChanged: &pb.Sender{Username: u.Username, Userid: u.ID},
+		})
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return room.Broadcast(newChatDeletedMessage(u, ids...))
+}
+
+func (u *User) PinRoomChatMessage(room *Room, messageID string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionPinChatMessage) {
+		return model.ErrNoPermission
+	}
+	if err := room.PinChatMessage(messageID, u.ID); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionPinChatMessage, messageID)
+	return room.Broadcast(&pb.ElementMessage{
+		Type:        pb.ElementMessageType_PINS_CHANGED,
+		PinsChanged: &pb.Sender{Username: u.Username, Userid: u.ID},
+	})
+}
+
+func (u *User) UnpinRoomChatMessage(room *Room, messageID string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionPinChatMessage) {
+		return model.ErrNoPermission
+	}
+	if err := room.UnpinChatMessage(messageID); err != nil {
+		return err
+	}
+	u.roomAudit(room, model.RoomAuditActionUnpinChatMessage, messageID)
+	return room.Broadcast(&pb.ElementMessage{
+		Type:        pb.ElementMessageType_PINS_CHANGED,
+		PinsChanged: &pb.Sender{Username: u.Username, Userid: u.ID},
+	})
+}
+
+func (u *User) roomAudit(room *Room, action model.RoomAuditAction, target string) {
+	events.Rooms.Publish(events.RoomEvent{
+		Type:     events.RoomModerated,
+		RoomID:   room.ID,
+		RoomName: room.Name,
+		UserID:   u.ID,
+		Username: u.Username,
+		Action:   string(action),
+		Target:   target,
+	})
+}
+
 func (u *User) BindProvider(p provider.OAuth2Provider, pid string) error {
 	err := db.BindProvider(u.ID, p, pid)
 	if err != nil {
@@ -498,11 +731,11 @@ func (u *User) VerifyRetrievePasswordCaptchaEmail(e, captcha string) (bool, erro
 	return email.VerifyRetrievePasswordCaptchaEmail(u.ID, e, captcha)
 }
 
-func (u *User) GetRoomMoviesWithPage(room *Room, page, pageSize int, parentID string) ([]*model.Movie, int64, error) {
+func (u *User) GetRoomMoviesWithPage(room *Room, parentID string, page func(*gorm.DB) *gorm.DB, filters ...func(*gorm.DB) *gorm.DB) ([]*model.Movie, int64, error) {
 	if !u.HasRoomPermission(room, model.PermissionGetMovieList) {
 		return nil, 0, model.ErrNoPermission
 	}
-	return room.GetMoviesWithPage(page, pageSize, parentID)
+	return room.GetMoviesWithPage(parentID, page, filters...)
 }
 
 func (u *User) SetRoomCurrentSeekRate(room *Room, seek, rate, timeDiff float64) (*Status, error) {
@@ -0,0 +1,67 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
+	"github.com/synctv-org/synctv/internal/provider"
+)
+
+const maxUsernameSuffix = 1000
+
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// ResolveUsername returns a free username for a user signing up with the
+// provider, changing the username by the strategy if it is taken. Names the
+// name policy does not allow are treated as taken
+func ResolveUsername(username string, p provider.OAuth2Provider, strategy model.UsernameCollision) (string, error) {
+	username = namepolicy.Truncate(namepolicy.Normalize(username), "")
+	taken, err := usernameTaken(username)
+	if err != nil {
+		return "", err
+	}
+	if !taken {
+		return username, nil
+	}
+	switch strategy {
+	case model.UsernameCollisionReject:
+		return "", ErrUsernameTaken
+	case model.UsernameCollisionProvider:
+		name := namepolicy.Truncate(username, fmt.Sprintf("_%s", p))
+		taken, err := usernameTaken(name)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return name, nil
+		}
+		// the provider suffixed name is taken too, fall back to numbers
+		username = name
+	}
+	// a deny pattern may match all suffixed names, let the user pick one then
+	for i := 2; i < maxUsernameSuffix; i++ {
+		name := namepolicy.Truncate(username, strconv.Itoa(i))
+		taken, err := usernameTaken(name)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return name, nil
+		}
+	}
+	return "", ErrUsernameTaken
+}
+
+func usernameTaken(username string) (bool, error) {
+	if err := CheckUsername(username, ""); err != nil {
+		if isNameViolation(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return db.UsernameExists(username)
+}
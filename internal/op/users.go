@@ -7,6 +7,7 @@ import (
 
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/zijiren233/gencontainer/synccache"
 )
@@ -24,6 +25,7 @@ func LoadOrInitUser(u *model.User) (*UserEntry, error) {
 	i, _ := userCache.LoadOrStore(u.ID, &User{
 		User:    *u,
 		version: crc32.ChecksumIEEE(u.HashedPassword),
+		lastAct: time.Now().UnixMilli(),
 	}, time.Hour)
 	return i, nil
 }
@@ -74,9 +76,13 @@ func CreateOrLoadUser(username string, password string, conf ...db.CreateUserCon
 }
 
 func CreateUser(username string, password string, conf ...db.CreateUserConfig) (*UserEntry, error) {
+	username = namepolicy.Normalize(username)
 	if username == "" {
 		return nil, errors.New("username cannot be empty")
 	}
+	if err := CheckUsername(username, ""); err != nil {
+		return nil, err
+	}
 	u, err := db.CreateUser(username, password, conf...)
 	if err != nil {
 		return nil, err
@@ -104,6 +110,10 @@ func CreateOrLoadUserWithEmail(username, password, email string, conf ...db.Crea
 }
 
 func CreateUserWithEmail(username, password, email string, conf ...db.CreateUserConfig) (*UserEntry, error) {
+	username = namepolicy.Normalize(username)
+	if err := CheckUsername(username, ""); err != nil {
+		return nil, err
+	}
 	u, err := db.CreateUserWithEmail(username, password, email, conf...)
 	if err != nil {
 		return nil, err
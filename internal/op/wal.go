@@ -0,0 +1,132 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	json "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/wal"
+)
+
+// types of the room mutations in the write-ahead log
+const (
+	WalMoviesAdded   = "movies_added"
+	WalMovieUpdated  = "movie_updated"
+	WalMoviesDeleted = "movies_deleted"
+	WalMoviesCleared = "movies_cleared"
+	WalMoviesSwapped = "movies_swapped"
+	WalSettings      = "settings"
+	WalCurrent       = "current"
+	WalStatus        = "status"
+)
+
+type WalMovie struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ParentID string `json:"parentId,omitempty"`
+}
+
+type WalMovies struct {
+	Movies []WalMovie `json:"movies"`
+}
+
+type WalMovieIDs struct {
+	IDs []string `json:"ids"`
+}
+
+type WalMoviesClear struct {
+	ParentID string `json:"parentId,omitempty"`
+}
+
+type WalCurrentMovie struct {
+	MovieID string `json:"movieId"`
+	SubPath string `json:"subPath,omitempty"`
+	IsLive  bool   `json:"isLive,omitempty"`
+	Playing bool   `json:"playing"`
+}
+
+type WalStatusChange struct {
+	Playing bool    `json:"playing"`
+	Seek    float64 `json:"seek"`
+	Rate    float64 `json:"rate"`
+}
+
+// logWal writes a mutation of the room to the write-ahead log, once it
+// returns the mutation survives a crash
+func (r *Room) logWal(typ string, data any) error {
+	if _, err := wal.Append(r.ID, typ, data); err != nil {
+		return fmt.Errorf("write-ahead log: %w", err)
+	}
+	return nil
+}
+
+func (r *Room) logWalMovies(movies []*model.Movie) error {
+	ms := make([]WalMovie, len(movies))
+	for i, m := range movies {
+		ms[i] = WalMovie{
+			ID:       m.ID,
+			Name:     m.MovieBase.Name,
+			ParentID: string(m.MovieBase.ParentID),
+		}
+	}
+	return r.logWal(WalMoviesAdded, WalMovies{Movies: ms})
+}
+
+// logWalStatus logs a status change from the websocket, which has no api
+// call to fail
+func (r *Room) logWalStatus(s *Status) {
+	err := r.logWal(WalStatus, WalStatusChange{
+		Playing: s.Playing,
+		Seek:    s.Seek,
+		Rate:    s.Rate,
+	})
+	if err != nil {
+		log.Errorf("room %s: %v", r.ID, err)
+	}
+}
+
+// recoverCurrent restores the current movie and status the room had when
+// it was dropped from memory or the server crashed
+func (r *Room) recoverCurrent() {
+	if !wal.Enabled() {
+		return
+	}
+	latest, err := wal.Latest(r.ID, WalCurrent, WalStatus)
+	if err != nil {
+		log.Errorf("room %s: read write-ahead log error: %v", r.ID, err)
+		return
+	}
+	ce, ok := latest[WalCurrent]
+	if !ok {
+		return
+	}
+	var c WalCurrentMovie
+	if err := json.Unmarshal(ce.Data, &c); err != nil || c.MovieID == "" {
+		return
+	}
+	m, err := r.GetMovieByID(c.MovieID)
+	if err != nil {
+		// deleted in the meantime
+		return
+	}
+	m.subPath = c.SubPath
+	status := Status{
+		Playing:    c.Playing,
+		Rate:       1.0,
+		lastUpdate: time.UnixMilli(ce.Time),
+	}
+	if se, ok := latest[WalStatus]; ok && se.Seq > ce.Seq {
+		var s WalStatusChange
+		if err := json.Unmarshal(se.Data, &s); err == nil {
+			status = Status{
+				Playing:    s.Playing,
+				Seek:       s.Seek,
+				Rate:       s.Rate,
+				lastUpdate: time.UnixMilli(se.Time),
+			}
+		}
+	}
+	r.current.restore(CurrentMovie{ID: c.MovieID, IsLive: c.IsLive}, status)
+}
@@ -0,0 +1,55 @@
+package provider
+
+import "fmt"
+
+type ErrorCode int32
+
+const (
+	ErrorCodeUnknown ErrorCode = iota
+	// the code or state of the callback is invalid or expired
+	ErrorCodeInvalidCode
+	// the user refused the login or is not allowed to log in
+	ErrorCodeAccessDenied
+	// the provider can not be reached
+	ErrorCodeUnavailable
+	ErrorCodeRateLimited
+	// the provider is misconfigured, e.g. wrong client secret
+	ErrorCodeInvalidConfig
+)
+
+func (c ErrorCode) String() string {
+	switch c {
+	case ErrorCodeInvalidCode:
+		return "invalid_code"
+	case ErrorCodeAccessDenied:
+		return "access_denied"
+	case ErrorCodeUnavailable:
+		return "unavailable"
+	case ErrorCodeRateLimited:
+		return "rate_limited"
+	case ErrorCodeInvalidConfig:
+		return "invalid_config"
+	default:
+		return "unknown"
+	}
+}
+
+// Error is returned by providers so the host can show the message to the
+// user and decide whether to retry, plugins pass it over grpc
+type Error struct {
+	Code      ErrorCode
+	Retryable bool
+	Message   string
+}
+
+func NewError(code ErrorCode, retryable bool, format string, a ...any) *Error {
+	return &Error{
+		Code:      code,
+		Retryable: retryable,
+		Message:   fmt.Sprintf(format, a...),
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/provider"
+	providerpb "github.com/synctv-org/synctv/proto/provider"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// optional features of plugins, a plugin reports the ones it supports so the
+// host can tell what works before calling it
+const (
+	// GetToken exchanges codes with a pkce verifier and extra params
+	CapabilityTokenOption = "token_option"
+	// GetUserInfo fills claims and groups
+	CapabilityClaims = "claims"
+	// failed rpcs carry an Error detail
+	CapabilityErrorDetails = "error_details"
+)
+
+// the newest protocol version of this host
+const currentProtocolVersion = ProtocolVersionV2
+
+// what plugins built before the Capabilities rpc support, v1 plugins could
+// already serve GetToken but may answer it with Unimplemented
+var baseCapabilities = map[uint32][]string{
+	ProtocolVersionV1: {CapabilityTokenOption},
+	ProtocolVersionV2: {CapabilityTokenOption, CapabilityClaims, CapabilityErrorDetails},
+}
+
+const capabilitiesTimeout = time.Second * 5
+
+// Capabilities is what a plugin reported when it was started
+type Capabilities struct {
+	ProtocolVersion uint32
+	Capabilities    []string
+	PluginVersion   string
+}
+
+func (c *Capabilities) Has(capability string) bool {
+	return slices.Contains(c.Capabilities, capability)
+}
+
+func (c *Capabilities) String() string {
+	v := c.PluginVersion
+	if v == "" {
+		v = "unknown"
+	}
+	return fmt.Sprintf("protocol v%d, version %s, capabilities %v", c.ProtocolVersion, v, c.Capabilities)
+}
+
+// negotiate asks the plugin what it supports, plugins older than the
+// Capabilities rpc are assumed to support what every plugin of the protocol
+// they dispensed does
+func (c *GRPCClient) negotiate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), capabilitiesTimeout)
+	defer cancel()
+	resp, err := c.client.Capabilities(ctx, &providerpb.Empty{})
+	if status.Code(err) == codes.Unimplemented {
+		c.caps = &Capabilities{
+			ProtocolVersion: c.protocol,
+			Capabilities:    baseCapabilities[c.protocol],
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.caps = &Capabilities{
+		ProtocolVersion: resp.ProtocolVersion,
+		Capabilities:    resp.Capabilities,
+		PluginVersion:   resp.PluginVersion,
+	}
+	return nil
+}
+
+// Capabilities returns what the plugin reported, nil before negotiation
+func (c *GRPCClient) Capabilities() *Capabilities {
+	return c.caps
+}
+
+// PluginVersioner is optionally implemented by providers served as plugins,
+// the version is logged by the host
+type PluginVersioner interface {
+	PluginVersion() string
+}
+
+func (s *GRPCServer) Capabilities(ctx context.Context, req *providerpb.Empty) (*providerpb.CapabilitiesResp, error) {
+	resp := &providerpb.CapabilitiesResp{
+		ProtocolVersion: currentProtocolVersion,
+		Capabilities:    []string{CapabilityClaims, CapabilityErrorDetails},
+	}
+	if _, ok := s.Impl.(provider.TokenProvider); ok {
+		resp.Capabilities = append(resp.Capabilities, CapabilityTokenOption)
+	}
+	if v, ok := s.Impl.(PluginVersioner); ok {
+		resp.PluginVersion = v.PluginVersion()
+	}
+	return resp, nil
+}
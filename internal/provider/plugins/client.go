@@ -2,26 +2,39 @@ package plugins
 
 import (
 	"context"
+	"time"
 
 	"github.com/synctv-org/synctv/internal/provider"
 	providerpb "github.com/synctv-org/synctv/proto/provider"
+	"golang.org/x/oauth2"
 )
 
-type GRPCClient struct{ client providerpb.Oauth2PluginClient }
+type GRPCClient struct {
+	client providerpb.Oauth2PluginV2Client
+	// the protocol version picked by the handshake
+	protocol uint32
+	caps     *Capabilities
+}
 
-var _ provider.ProviderInterface = (*GRPCClient)(nil)
+var (
+	_ provider.ProviderInterface = (*GRPCClient)(nil)
+	_ provider.TokenProvider     = (*GRPCClient)(nil)
+)
 
 func (c *GRPCClient) Init(o provider.Oauth2Option) {
 	opt := providerpb.InitReq{
 		ClientId:     o.ClientID,
 		ClientSecret: o.ClientSecret,
 		RedirectUrl:  o.RedirectURL,
+		Scopes:       o.Scopes,
+		AuthUrl:      o.AuthURL,
+		TokenUrl:     o.TokenURL,
 	}
 	c.client.Init(context.Background(), &opt)
 }
 
 func (c *GRPCClient) Provider() provider.OAuth2Provider {
-	resp, err := c.client.Provider(context.Background(), &providerpb.Enpty{})
+	resp, err := c.client.Provider(context.Background(), &providerpb.Empty{})
 	if err != nil {
 		return ""
 	}
@@ -29,7 +42,11 @@ func (c *GRPCClient) Provider() provider.OAuth2Provider {
 }
 
 func (c *GRPCClient) NewAuthURL(ctx context.Context, state string) (string, error) {
-	resp, err := c.client.NewAuthURL(ctx, &providerpb.NewAuthURLReq{State: state})
+	var resp *providerpb.NewAuthURLResp
+	err := withRetry(ctx, func() (err error) {
+		resp, err = c.client.NewAuthURL(ctx, &providerpb.NewAuthURLReq{State: state})
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -37,8 +54,12 @@ func (c *GRPCClient) NewAuthURL(ctx context.Context, state string) (string, erro
 }
 
 func (c *GRPCClient) GetUserInfo(ctx context.Context, code string) (*provider.UserInfo, error) {
-	resp, err := c.client.GetUserInfo(ctx, &providerpb.GetUserInfoReq{
-		Code: code,
+	var resp *providerpb.GetUserInfoResp
+	err := withRetry(ctx, func() (err error) {
+		resp, err = c.client.GetUserInfo(ctx, &providerpb.GetUserInfoReq{
+			Code: code,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -46,5 +67,34 @@ func (c *GRPCClient) GetUserInfo(ctx context.Context, code string) (*provider.Us
 	return &provider.UserInfo{
 		Username:       resp.Username,
 		ProviderUserID: resp.ProviderUserId,
+		Claims:         resp.Claims,
+		Groups:         resp.Groups,
 	}, nil
 }
+
+func (c *GRPCClient) GetTokenWithOption(ctx context.Context, code string, opt provider.TokenOption) (*oauth2.Token, error) {
+	if c.caps != nil && !c.caps.Has(CapabilityTokenOption) {
+		return nil, provider.NewError(provider.ErrorCodeInvalidConfig, false, "plugin does not support exchanging codes with options")
+	}
+	var resp *providerpb.GetTokenResp
+	err := withRetry(ctx, func() (err error) {
+		resp, err = c.client.GetToken(ctx, &providerpb.GetTokenReq{
+			Code:         code,
+			CodeVerifier: opt.CodeVerifier,
+			ExtraParams:  opt.ExtraParams,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	tk := &oauth2.Token{
+		AccessToken:  resp.AccessToken,
+		TokenType:    resp.TokenType,
+		RefreshToken: resp.RefreshToken,
+	}
+	if resp.Expiry != 0 {
+		tk.Expiry = time.Unix(resp.Expiry, 0)
+	}
+	return tk, nil
+}
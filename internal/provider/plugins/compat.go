@@ -0,0 +1,77 @@
+package plugins
+
+import (
+	"context"
+
+	providerpb "github.com/synctv-org/synctv/proto/provider"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the first plugin protocol only differs by the misspelled Enpty message,
+// these shims serve it on both sides so old plugins and hosts keep working
+
+// v1Client calls plugins speaking the first protocol
+type v1Client struct{ client providerpb.Oauth2PluginClient }
+
+var _ providerpb.Oauth2PluginV2Client = (*v1Client)(nil)
+
+func (c *v1Client) Init(ctx context.Context, in *providerpb.InitReq, opts ...grpc.CallOption) (*providerpb.Empty, error) {
+	_, err := c.client.Init(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &providerpb.Empty{}, nil
+}
+
+func (c *v1Client) Provider(ctx context.Context, in *providerpb.Empty, opts ...grpc.CallOption) (*providerpb.ProviderResp, error) {
+	return c.client.Provider(ctx, &providerpb.Enpty{}, opts...)
+}
+
+func (c *v1Client) NewAuthURL(ctx context.Context, in *providerpb.NewAuthURLReq, opts ...grpc.CallOption) (*providerpb.NewAuthURLResp, error) {
+	return c.client.NewAuthURL(ctx, in, opts...)
+}
+
+func (c *v1Client) GetUserInfo(ctx context.Context, in *providerpb.GetUserInfoReq, opts ...grpc.CallOption) (*providerpb.GetUserInfoResp, error) {
+	return c.client.GetUserInfo(ctx, in, opts...)
+}
+
+func (c *v1Client) GetToken(ctx context.Context, in *providerpb.GetTokenReq, opts ...grpc.CallOption) (*providerpb.GetTokenResp, error) {
+	return c.client.GetToken(ctx, in, opts...)
+}
+
+// Capabilities was added after the first protocol
+func (c *v1Client) Capabilities(ctx context.Context, in *providerpb.Empty, opts ...grpc.CallOption) (*providerpb.CapabilitiesResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Capabilities not implemented")
+}
+
+// v1Server serves hosts speaking the first protocol
+type v1Server struct {
+	providerpb.UnimplementedOauth2PluginServer
+	s *GRPCServer
+}
+
+func (s *v1Server) Init(ctx context.Context, req *providerpb.InitReq) (*providerpb.Enpty, error) {
+	_, err := s.s.Init(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &providerpb.Enpty{}, nil
+}
+
+func (s *v1Server) Provider(ctx context.Context, req *providerpb.Enpty) (*providerpb.ProviderResp, error) {
+	return s.s.Provider(ctx, &providerpb.Empty{})
+}
+
+func (s *v1Server) NewAuthURL(ctx context.Context, req *providerpb.NewAuthURLReq) (*providerpb.NewAuthURLResp, error) {
+	return s.s.NewAuthURL(ctx, req)
+}
+
+func (s *v1Server) GetUserInfo(ctx context.Context, req *providerpb.GetUserInfoReq) (*providerpb.GetUserInfoResp, error) {
+	return s.s.GetUserInfo(ctx, req)
+}
+
+func (s *v1Server) GetToken(ctx context.Context, req *providerpb.GetTokenReq) (*providerpb.GetTokenResp, error) {
+	return s.s.GetToken(ctx, req)
+}
@@ -0,0 +1,92 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/provider"
+	providerpb "github.com/synctv-org/synctv/proto/provider"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxRetries = 3
+	retryDelay = 500 * time.Millisecond
+)
+
+var grpcCodes = map[provider.ErrorCode]codes.Code{
+	provider.ErrorCodeInvalidCode:   codes.InvalidArgument,
+	provider.ErrorCodeAccessDenied:  codes.PermissionDenied,
+	provider.ErrorCodeUnavailable:   codes.Unavailable,
+	provider.ErrorCodeRateLimited:   codes.ResourceExhausted,
+	provider.ErrorCodeInvalidConfig: codes.FailedPrecondition,
+}
+
+// toStatus attaches provider errors to the grpc status as a detail
+func toStatus(err error) error {
+	var pe *provider.Error
+	if !errors.As(err, &pe) {
+		return err
+	}
+	c, ok := grpcCodes[pe.Code]
+	if !ok {
+		c = codes.Unknown
+	}
+	st, e := status.New(c, pe.Message).WithDetails(&providerpb.Error{
+		Code:      providerpb.ErrorCode(pe.Code),
+		Retryable: pe.Retryable,
+		Message:   pe.Message,
+	})
+	if e != nil {
+		return err
+	}
+	return st.Err()
+}
+
+// fromStatus restores the provider error of a failed rpc, errors of plugins
+// without details are unknown and only retried when the plugin is unreachable
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, d := range st.Details() {
+		if pe, ok := d.(*providerpb.Error); ok {
+			return &provider.Error{
+				Code:      provider.ErrorCode(pe.Code),
+				Retryable: pe.Retryable,
+				Message:   pe.Message,
+			}
+		}
+	}
+	if st.Code() == codes.Unavailable {
+		return &provider.Error{
+			Code:      provider.ErrorCodeUnavailable,
+			Retryable: true,
+			Message:   st.Message(),
+		}
+	}
+	return err
+}
+
+// withRetry calls fn again while it fails with a retryable provider error
+func withRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(retryDelay * time.Duration(i)):
+			}
+		}
+		err = fromStatus(fn())
+		var pe *provider.Error
+		if err == nil || !errors.As(err, &pe) || !pe.Retryable {
+			return err
+		}
+	}
+	return err
+}
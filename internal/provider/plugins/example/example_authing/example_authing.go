@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"os"
 
-	plugin "github.com/hashicorp/go-plugin"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/internal/provider/plugins"
 	"golang.org/x/oauth2"
@@ -90,12 +89,5 @@ type AuthingUserInfo struct {
 
 func main() {
 	args := os.Args
-	var pluginMap = map[string]plugin.Plugin{
-		"Provider": &plugins.ProviderPlugin{Impl: newAuthingProvider(args[1])},
-	}
-	plugin.Serve(&plugin.ServeConfig{
-		HandshakeConfig: plugins.HandshakeConfig,
-		Plugins:         pluginMap,
-		GRPCServer:      plugin.DefaultGRPCServer,
-	})
+	plugins.Serve(newAuthingProvider(args[1]))
 }
@@ -8,7 +8,6 @@ import (
 
 	"net/http"
 
-	plugin "github.com/hashicorp/go-plugin"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/internal/provider/plugins"
 	"golang.org/x/oauth2"
@@ -101,12 +100,5 @@ type FeishuSSOUserInfo struct {
 
 func main() {
 	args := os.Args
-	var pluginMap = map[string]plugin.Plugin{
-		"Provider": &plugins.ProviderPlugin{Impl: newFeishuSSOProvider(args[1])},
-	}
-	plugin.Serve(&plugin.ServeConfig{
-		HandshakeConfig: plugins.HandshakeConfig,
-		Plugins:         pluginMap,
-		GRPCServer:      plugin.DefaultGRPCServer,
-	})
+	plugins.Serve(newFeishuSSOProvider(args[1]))
 }
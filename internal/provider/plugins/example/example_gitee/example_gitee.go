@@ -6,9 +6,7 @@ import (
 	"net/http"
 	"strconv"
 
-	plugin "github.com/hashicorp/go-plugin"
-	"github.com/synctv-org/synctv/internal/provider"
-	"github.com/synctv-org/synctv/internal/provider/plugins"
+	"github.com/synctv-org/synctv/plugin/oauth2plugin"
 	"golang.org/x/oauth2"
 )
 
@@ -24,7 +22,7 @@ type GiteeProvider struct {
 	config oauth2.Config
 }
 
-func newGiteeProvider() provider.ProviderInterface {
+func newGiteeProvider() oauth2plugin.Provider {
 	return &GiteeProvider{
 		config: oauth2.Config{
 			Scopes: []string{"user_info"},
@@ -36,13 +34,22 @@ func newGiteeProvider() provider.ProviderInterface {
 	}
 }
 
-func (p *GiteeProvider) Init(c provider.Oauth2Option) {
+func (p *GiteeProvider) Init(c oauth2plugin.Option) {
 	p.config.ClientID = c.ClientID
 	p.config.ClientSecret = c.ClientSecret
 	p.config.RedirectURL = c.RedirectURL
+	if len(c.Scopes) != 0 {
+		p.config.Scopes = c.Scopes
+	}
+	if c.AuthURL != "" {
+		p.config.Endpoint.AuthURL = c.AuthURL
+	}
+	if c.TokenURL != "" {
+		p.config.Endpoint.TokenURL = c.TokenURL
+	}
 }
 
-func (p *GiteeProvider) Provider() provider.OAuth2Provider {
+func (p *GiteeProvider) Provider() oauth2plugin.OAuth2Provider {
 	return "gitee"
 }
 
@@ -54,14 +61,19 @@ func (p *GiteeProvider) GetToken(ctx context.Context, code string) (*oauth2.Toke
 	return p.config.Exchange(ctx, code)
 }
 
+func (p *GiteeProvider) GetTokenWithOption(ctx context.Context, code string, opt oauth2plugin.TokenOption) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code, opt.AuthCodeOptions()...)
+}
+
 func (p *GiteeProvider) RefreshToken(ctx context.Context, tk string) (*oauth2.Token, error) {
 	return p.config.TokenSource(ctx, &oauth2.Token{RefreshToken: tk}).Token()
 }
 
-func (p *GiteeProvider) GetUserInfo(ctx context.Context, code string) (*provider.UserInfo, error) {
+func (p *GiteeProvider) GetUserInfo(ctx context.Context, code string) (*oauth2plugin.UserInfo, error) {
 	tk, err := p.GetToken(ctx, code)
 	if err != nil {
-		return nil, err
+		// the host shows the message instead of an opaque rpc error
+		return nil, oauth2plugin.NewError(oauth2plugin.ErrorCodeInvalidCode, false, "gitee login expired, please try again")
 	}
 	client := p.config.Client(ctx, tk)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://gitee.com/api/v5/user", nil)
@@ -70,7 +82,7 @@ func (p *GiteeProvider) GetUserInfo(ctx context.Context, code string) (*provider
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, oauth2plugin.NewError(oauth2plugin.ErrorCodeUnavailable, true, "gitee is unreachable: %v", err)
 	}
 	defer resp.Body.Close()
 	ui := giteeUserInfo{}
@@ -78,24 +90,25 @@ func (p *GiteeProvider) GetUserInfo(ctx context.Context, code string) (*provider
 	if err != nil {
 		return nil, err
 	}
-	return &provider.UserInfo{
+	return &oauth2plugin.UserInfo{
 		Username:       ui.Login,
 		ProviderUserID: strconv.FormatUint(ui.ID, 10),
+		Claims: map[string]string{
+			oauth2plugin.ClaimEmail:       ui.Email,
+			oauth2plugin.ClaimAvatar:      ui.AvatarURL,
+			oauth2plugin.ClaimDisplayName: ui.Name,
+		},
 	}, nil
 }
 
 type giteeUserInfo struct {
-	ID    uint64 `json:"id"`
-	Login string `json:"login"`
+	ID        uint64 `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
 }
 
 func main() {
-	var pluginMap = map[string]plugin.Plugin{
-		"Provider": &plugins.ProviderPlugin{Impl: newGiteeProvider()},
-	}
-	plugin.Serve(&plugin.ServeConfig{
-		HandshakeConfig: plugins.HandshakeConfig,
-		Plugins:         pluginMap,
-		GRPCServer:      plugin.DefaultGRPCServer,
-	})
+	oauth2plugin.Serve(newGiteeProvider())
 }
@@ -0,0 +1,233 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/provider"
+	"golang.org/x/oauth2"
+)
+
+// pluginCheckEvery is how often plugins are pinged and their binaries
+// checked for changes
+const pluginCheckEvery = 15 * time.Second
+
+// pluginProvider is registered in place of the plugin, it forwards to the
+// running plugin process and starts it again when it crashes or its binary
+// changes
+type pluginProvider struct {
+	file   string
+	arg    []string
+	logger hclog.Logger
+	// the name reported by the first process, reloads must keep it
+	name provider.OAuth2Provider
+
+	// held while a new process is started
+	restartLock sync.Mutex
+
+	lock    sync.RWMutex
+	client  *plugin.Client
+	impl    *GRPCClient
+	opt     *provider.Oauth2Option
+	modTime time.Time
+}
+
+var (
+	_ provider.ProviderInterface = (*pluginProvider)(nil)
+	_ provider.TokenProvider     = (*pluginProvider)(nil)
+)
+
+func newPluginProvider(file string, arg []string, logger hclog.Logger) (*pluginProvider, error) {
+	p := &pluginProvider{
+		file:   file,
+		arg:    arg,
+		logger: logger,
+	}
+	client, impl, modTime, err := p.start()
+	if err != nil {
+		return nil, err
+	}
+	p.client, p.impl, p.modTime = client, impl, modTime
+	p.name = impl.Provider()
+	if p.name == "" {
+		client.Kill()
+		return nil, fmt.Errorf("plugin %s returned an empty provider name", file)
+	}
+	return p, nil
+}
+
+func (p *pluginProvider) start() (*plugin.Client, *GRPCClient, time.Time, error) {
+	fi, err := os.Stat(p.file)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	client := NewProviderPlugin(p.file, p.arg, p.logger)
+	c, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, nil, time.Time{}, err
+	}
+	i, err := c.Dispense("Provider")
+	if err != nil {
+		client.Kill()
+		return nil, nil, time.Time{}, err
+	}
+	impl, ok := i.(*GRPCClient)
+	if !ok {
+		client.Kill()
+		return nil, nil, time.Time{}, fmt.Errorf("%s not implement ProviderInterface", p.file)
+	}
+	if err := impl.negotiate(); err != nil {
+		client.Kill()
+		return nil, nil, time.Time{}, fmt.Errorf("get capabilities of plugin %s error: %w", p.file, err)
+	}
+	caps := impl.Capabilities()
+	if caps.ProtocolVersion > currentProtocolVersion {
+		log.Warnf("oauth2 plugin %s speaks protocol v%d, newer than v%d of this host, only v%d features are used", p.file, caps.ProtocolVersion, currentProtocolVersion, impl.protocol)
+	}
+	log.Infof("oauth2 plugin %s: %s", p.file, caps)
+	return client, impl, fi.ModTime(), nil
+}
+
+// restart replaces the process of the plugin, old is the client seen by the
+// caller, nothing is done when another caller already replaced it
+func (p *pluginProvider) restart(old *plugin.Client) error {
+	p.restartLock.Lock()
+	defer p.restartLock.Unlock()
+
+	p.lock.RLock()
+	current := p.client
+	p.lock.RUnlock()
+	if old != nil && current != old {
+		return nil
+	}
+
+	client, impl, modTime, err := p.start()
+	if err != nil {
+		return err
+	}
+	if name := impl.Provider(); name != p.name {
+		client.Kill()
+		return fmt.Errorf("plugin %s changed its provider from %s to %s", p.file, p.name, name)
+	}
+	p.lock.RLock()
+	opt := p.opt
+	p.lock.RUnlock()
+	if opt != nil {
+		impl.Init(*opt)
+	}
+
+	p.lock.Lock()
+	p.client, p.impl, p.modTime = client, impl, modTime
+	p.lock.Unlock()
+	current.Kill()
+	return nil
+}
+
+// Reload starts the plugin again from its binary
+func (p *pluginProvider) Reload() error {
+	return p.restart(nil)
+}
+
+// get returns the running plugin, a crashed one is started again first
+func (p *pluginProvider) get() *GRPCClient {
+	p.lock.RLock()
+	client, impl := p.client, p.impl
+	p.lock.RUnlock()
+	if !client.Exited() {
+		return impl
+	}
+	log.Warnf("oauth2 plugin %s exited, restarting", p.file)
+	if err := p.restart(client); err != nil {
+		log.Errorf("restart oauth2 plugin %s error: %v", p.file, err)
+		return impl
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.impl
+}
+
+// check pings the plugin and reloads it when it does not answer or its
+// binary changed
+func (p *pluginProvider) check() {
+	p.lock.RLock()
+	client, modTime := p.client, p.modTime
+	p.lock.RUnlock()
+
+	if fi, err := os.Stat(p.file); err == nil && !fi.ModTime().Equal(modTime) {
+		log.Infof("oauth2 plugin %s changed, reloading", p.file)
+		if err := p.restart(client); err != nil {
+			log.Errorf("reload oauth2 plugin %s error: %v", p.file, err)
+		}
+		return
+	}
+
+	err := errors.New("plugin exited")
+	if !client.Exited() {
+		var c plugin.ClientProtocol
+		c, err = client.Client()
+		if err == nil {
+			err = c.Ping()
+		}
+	}
+	if err == nil {
+		return
+	}
+	log.Warnf("oauth2 plugin %s is unhealthy, restarting: %v", p.file, err)
+	if err := p.restart(client); err != nil {
+		log.Errorf("restart oauth2 plugin %s error: %v", p.file, err)
+	}
+}
+
+// watch checks the plugin until the context is done
+func (p *pluginProvider) watch(ctx context.Context) {
+	ticker := time.NewTicker(pluginCheckEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.check()
+	}
+}
+
+// Kill stops the running plugin process
+func (p *pluginProvider) Kill() {
+	p.restartLock.Lock()
+	defer p.restartLock.Unlock()
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	p.client.Kill()
+}
+
+func (p *pluginProvider) Init(o provider.Oauth2Option) {
+	p.lock.Lock()
+	p.opt = &o
+	p.lock.Unlock()
+	p.get().Init(o)
+}
+
+func (p *pluginProvider) Provider() provider.OAuth2Provider {
+	return p.name
+}
+
+func (p *pluginProvider) NewAuthURL(ctx context.Context, state string) (string, error) {
+	return p.get().NewAuthURL(ctx, state)
+}
+
+func (p *pluginProvider) GetUserInfo(ctx context.Context, code string) (*provider.UserInfo, error) {
+	return p.get().GetUserInfo(ctx, code)
+}
+
+func (p *pluginProvider) GetTokenWithOption(ctx context.Context, code string, opt provider.TokenOption) (*oauth2.Token, error) {
+	return p.get().GetTokenWithOption(ctx, code, opt)
+}
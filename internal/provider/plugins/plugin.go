@@ -2,11 +2,11 @@ package plugins
 
 import (
 	"context"
-	"fmt"
 	"os/exec"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
+	"github.com/synctv-org/synctv/internal/chaos"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/internal/provider/providers"
 	sysnotify "github.com/synctv-org/synctv/internal/sysNotify"
@@ -14,59 +14,103 @@ import (
 	"google.golang.org/grpc"
 )
 
-func InitProviderPlugins(name string, arg []string, Logger hclog.Logger) error {
-	client := NewProviderPlugin(name, arg, Logger)
-	sysnotify.RegisterSysNotifyTask(0, sysnotify.NewSysNotifyTask("plugin", sysnotify.NotifyTypeEXIT, func() error {
-		client.Kill()
-		return nil
-	}))
-	c, err := client.Client()
+// InitProviderPlugins starts the plugin and registers its provider. The
+// plugin is pinged until the context is done, it is started again when it
+// crashes, when its binary changes and on reload signals
+func InitProviderPlugins(ctx context.Context, name string, arg []string, Logger hclog.Logger) error {
+	p, err := newPluginProvider(name, arg, Logger)
 	if err != nil {
 		return err
 	}
-	i, err := c.Dispense("Provider")
-	if err != nil {
-		return err
-	}
-	provider, ok := i.(provider.ProviderInterface)
-	if !ok {
-		return fmt.Errorf("%s not implement ProviderInterface", name)
-	}
-	providers.RegisterProvider(provider)
+	sysnotify.RegisterSysNotifyTask(0, sysnotify.NewSysNotifyTask("plugin", sysnotify.NotifyTypeEXIT, func() error {
+		p.Kill()
+		return nil
+	}))
+	sysnotify.RegisterSysNotifyTask(0, sysnotify.NewSysNotifyTask("plugin", sysnotify.NotifyTypeRELOAD, p.Reload))
+	go p.watch(ctx)
+	providers.RegisterProviderWithSource(providers.ProviderSourcePlugin, p)
 	return nil
 }
 
+const (
+	// ProtocolVersionV1 is the first protocol with the misspelled Enpty
+	ProtocolVersionV1 = 1
+	ProtocolVersionV2 = 2
+)
+
+// HandshakeConfig keeps the first protocol version, plugins built against
+// it still pass the handshake, the version is negotiated from the plugin sets
 var HandshakeConfig = plugin.HandshakeConfig{
-	ProtocolVersion:  1,
+	ProtocolVersion:  ProtocolVersionV1,
 	MagicCookieKey:   "BASIC_PLUGIN",
 	MagicCookieValue: "hello",
 }
 
-var pluginMap = map[string]plugin.Plugin{
-	"Provider": &ProviderPlugin{},
+var versionedPlugins = map[int]plugin.PluginSet{
+	ProtocolVersionV1: {"Provider": &ProviderPlugin{}},
+	ProtocolVersionV2: {"Provider": &ProviderPluginV2{}},
 }
 
+// ProviderPlugin speaks the first protocol.
+//
+// Deprecated: serve plugins with Serve, which also speaks the current protocol
 type ProviderPlugin struct {
 	plugin.Plugin
 	Impl provider.ProviderInterface
 }
 
 func (p *ProviderPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
-	providerpb.RegisterOauth2PluginServer(s, &GRPCServer{Impl: p.Impl})
+	providerpb.RegisterOauth2PluginServer(s, &v1Server{s: &GRPCServer{Impl: p.Impl}})
 	return nil
 }
 
 func (p *ProviderPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
-	return &GRPCClient{client: providerpb.NewOauth2PluginClient(c)}, nil
+	return &GRPCClient{
+		client:   &v1Client{client: providerpb.NewOauth2PluginClient(c)},
+		protocol: ProtocolVersionV1,
+	}, nil
+}
+
+type ProviderPluginV2 struct {
+	plugin.Plugin
+	Impl provider.ProviderInterface
+}
+
+func (p *ProviderPluginV2) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	providerpb.RegisterOauth2PluginV2Server(s, &GRPCServer{Impl: p.Impl})
+	return nil
+}
+
+func (p *ProviderPluginV2) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{
+		client:   providerpb.NewOauth2PluginV2Client(c),
+		protocol: ProtocolVersionV2,
+	}, nil
 }
 
 func NewProviderPlugin(name string, arg []string, Logger hclog.Logger) *plugin.Client {
 	return plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig: HandshakeConfig,
-		Plugins:         pluginMap,
-		Cmd:             exec.Command(name, arg...),
+		HandshakeConfig:  HandshakeConfig,
+		VersionedPlugins: versionedPlugins,
+		Cmd:              exec.Command(name, arg...),
 		AllowedProtocols: []plugin.Protocol{
 			plugin.ProtocolGRPC},
 		Logger: Logger,
+		GRPCDialOptions: []grpc.DialOption{
+			grpc.WithChainUnaryInterceptor(chaos.UnaryClientInterceptor(chaos.TargetOauth2Plugin + name)),
+		},
+	})
+}
+
+// Serve serves the provider to hosts speaking any plugin protocol, it is
+// called from the main of plugins
+func Serve(impl provider.ProviderInterface) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: HandshakeConfig,
+		VersionedPlugins: map[int]plugin.PluginSet{
+			ProtocolVersionV1: {"Provider": &ProviderPlugin{Impl: impl}},
+			ProtocolVersionV2: {"Provider": &ProviderPluginV2{Impl: impl}},
+		},
+		GRPCServer: plugin.DefaultGRPCServer,
 	})
 }
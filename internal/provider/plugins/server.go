@@ -5,31 +5,36 @@ import (
 
 	"github.com/synctv-org/synctv/internal/provider"
 	providerpb "github.com/synctv-org/synctv/proto/provider"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type GRPCServer struct {
-	providerpb.UnimplementedOauth2PluginServer
+	providerpb.UnimplementedOauth2PluginV2Server
 	Impl provider.ProviderInterface
 }
 
-func (s *GRPCServer) Init(ctx context.Context, req *providerpb.InitReq) (*providerpb.Enpty, error) {
+func (s *GRPCServer) Init(ctx context.Context, req *providerpb.InitReq) (*providerpb.Empty, error) {
 	opt := provider.Oauth2Option{
 		ClientID:     req.ClientId,
 		ClientSecret: req.ClientSecret,
 		RedirectURL:  req.RedirectUrl,
+		Scopes:       req.Scopes,
+		AuthURL:      req.AuthUrl,
+		TokenURL:     req.TokenUrl,
 	}
 	s.Impl.Init(opt)
-	return &providerpb.Enpty{}, nil
+	return &providerpb.Empty{}, nil
 }
 
-func (s *GRPCServer) Provider(ctx context.Context, req *providerpb.Enpty) (*providerpb.ProviderResp, error) {
+func (s *GRPCServer) Provider(ctx context.Context, req *providerpb.Empty) (*providerpb.ProviderResp, error) {
 	return &providerpb.ProviderResp{Name: string(s.Impl.Provider())}, nil
 }
 
 func (s *GRPCServer) NewAuthURL(ctx context.Context, req *providerpb.NewAuthURLReq) (*providerpb.NewAuthURLResp, error) {
 	s2, err := s.Impl.NewAuthURL(ctx, req.State)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return &providerpb.NewAuthURLResp{Url: s2}, nil
 }
@@ -37,11 +42,36 @@ func (s *GRPCServer) NewAuthURL(ctx context.Context, req *providerpb.NewAuthURLR
 func (s *GRPCServer) GetUserInfo(ctx context.Context, req *providerpb.GetUserInfoReq) (*providerpb.GetUserInfoResp, error) {
 	userInfo, err := s.Impl.GetUserInfo(ctx, req.Code)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	resp := &providerpb.GetUserInfoResp{
 		Username:       userInfo.Username,
 		ProviderUserId: userInfo.ProviderUserID,
+		Claims:         userInfo.Claims,
+		Groups:         userInfo.Groups,
+	}
+	return resp, nil
+}
+
+func (s *GRPCServer) GetToken(ctx context.Context, req *providerpb.GetTokenReq) (*providerpb.GetTokenResp, error) {
+	tp, ok := s.Impl.(provider.TokenProvider)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "method GetToken not implemented")
+	}
+	tk, err := tp.GetTokenWithOption(ctx, req.Code, provider.TokenOption{
+		CodeVerifier: req.CodeVerifier,
+		ExtraParams:  req.ExtraParams,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &providerpb.GetTokenResp{
+		AccessToken:  tk.AccessToken,
+		TokenType:    tk.TokenType,
+		RefreshToken: tk.RefreshToken,
+	}
+	if !tk.Expiry.IsZero() {
+		resp.Expiry = tk.Expiry.Unix()
 	}
 	return resp, nil
 }
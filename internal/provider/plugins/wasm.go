@@ -0,0 +1,413 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	json "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/provider/providers"
+	providerpb "github.com/synctv-org/synctv/proto/provider"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"golang.org/x/oauth2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// WebAssembly plugins are modules exporting the calls of the Oauth2Plugin
+// service, they run in process and need no subprocess.
+//
+// The module exports:
+//
+//	malloc(size u32) u32
+//	free(ptr u32)                        optional
+//	provider() u64                       -> ProviderResp
+//	init(ptr, len u32) u64               InitReq -> Empty
+//	new_auth_url(ptr, len u32) u64       NewAuthURLReq -> NewAuthURLResp
+//	get_user_info(ptr, len u32) u64      GetUserInfoReq -> GetUserInfoResp
+//	get_token(ptr, len u32) u64          GetTokenReq -> GetTokenResp, optional
+//
+// Requests and responses are the messages of the service encoded as json in
+// the memory of the module. Results pack the pointer of the response in the
+// high 32 bits and its length in the low ones, 0 means the call failed and
+// the module called set_error before returning. Requests are allocated with
+// malloc and owned by the module, responses are freed by the host.
+//
+// The host module "synctv" provides:
+//
+//	set_error(ptr, len u32)              Error
+//	http_request(ptr, len u32) u64       {method, url, header, body}
+//	                                     -> {status, header, body, error}
+//	log(level, ptr, len u32)             level 0 debug, 1 info, 2 warn, 3 error
+//
+// Modules compiled as wasi reactors have their _initialize called once.
+
+const (
+	wasmHostModule = "synctv"
+	// calls of the module and its http requests must finish in this time
+	wasmCallTimeout = 30 * time.Second
+	// responses of http requests larger than this fail
+	wasmMaxHTTPBody = 4 << 20
+)
+
+// bodies of http requests and responses are base64 encoded in json
+type wasmHTTPRequest struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+}
+
+type wasmHTTPResponse struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+	// set when the request could not be sent, status is 0
+	Error string `json:"error,omitempty"`
+}
+
+// WasmProvider runs a WebAssembly plugin, calls are serialized since the
+// module has a single memory
+type WasmProvider struct {
+	file string
+	name provider.OAuth2Provider
+
+	lock    sync.Mutex
+	runtime wazero.Runtime
+	module  api.Module
+	// set by the module during the running call
+	callErr *providerpb.Error
+}
+
+var (
+	_ provider.ProviderInterface = (*WasmProvider)(nil)
+	_ provider.TokenProvider     = (*WasmProvider)(nil)
+)
+
+var wasmHTTPClient = &http.Client{
+	Timeout: wasmCallTimeout,
+}
+
+// NewWasmProvider compiles and instantiates the module, args are passed to
+// it as wasi arguments
+func NewWasmProvider(ctx context.Context, file string, args []string) (*WasmProvider, error) {
+	bin, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p := &WasmProvider{
+		file:    file,
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true)),
+	}
+	if err := p.instantiate(ctx, bin, args); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, err
+	}
+	resp := &providerpb.ProviderResp{}
+	if err := p.call(ctx, "provider", nil, resp); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, err
+	}
+	if resp.Name == "" {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("plugin %s returned an empty provider name", file)
+	}
+	p.name = provider.OAuth2Provider(resp.Name)
+	return p, nil
+}
+
+func (p *WasmProvider) instantiate(ctx context.Context, bin []byte, args []string) error {
+	wasi_snapshot_preview1.MustInstantiate(ctx, p.runtime)
+	_, err := p.runtime.NewHostModuleBuilder(wasmHostModule).
+		NewFunctionBuilder().WithFunc(p.setError).Export("set_error").
+		NewFunctionBuilder().WithFunc(p.httpRequest).Export("http_request").
+		NewFunctionBuilder().WithFunc(p.logMessage).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return err
+	}
+	compiled, err := p.runtime.CompileModule(ctx, bin)
+	if err != nil {
+		return err
+	}
+	out := log.StandardLogger().Writer()
+	p.module, err = p.runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		WithName(p.file).
+		WithArgs(append([]string{p.file}, args...)...).
+		WithStdout(out).
+		WithStderr(out).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader).
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return err
+	}
+	if p.module.ExportedFunction("malloc") == nil {
+		return fmt.Errorf("plugin %s does not export malloc", p.file)
+	}
+	return nil
+}
+
+// write copies b to memory allocated by the module
+func (p *WasmProvider) write(ctx context.Context, m api.Module, b []byte) (uint32, error) {
+	res, err := m.ExportedFunction("malloc").Call(ctx, uint64(len(b)))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(res[0])
+	if !m.Memory().Write(ptr, b) {
+		return 0, errors.New("malloc returned memory out of range")
+	}
+	return ptr, nil
+}
+
+// read copies the packed response out of the memory of the module and
+// frees it
+func (p *WasmProvider) read(ctx context.Context, m api.Module, packed uint64) ([]byte, error) {
+	ptr, size := uint32(packed>>32), uint32(packed)
+	b, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		return nil, errors.New("response out of memory range")
+	}
+	b = bytes.Clone(b)
+	if free := m.ExportedFunction("free"); free != nil {
+		if _, err := free.Call(ctx, uint64(ptr)); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// call runs the export with the json encoded req, a nil req calls it without
+// arguments
+func (p *WasmProvider) call(ctx context.Context, name string, req, resp proto.Message) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	fn := p.module.ExportedFunction(name)
+	if fn == nil {
+		return provider.NewError(provider.ErrorCodeUnknown, false, "plugin %s does not export %s", p.file, name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, wasmCallTimeout)
+	defer cancel()
+
+	var params []uint64
+	if req != nil {
+		b, err := protojson.Marshal(req)
+		if err != nil {
+			return err
+		}
+		ptr, err := p.write(ctx, p.module, b)
+		if err != nil {
+			return err
+		}
+		params = []uint64{uint64(ptr), uint64(len(b))}
+	}
+
+	p.callErr = nil
+	res, err := fn.Call(ctx, params...)
+	if err != nil {
+		return fmt.Errorf("call %s of plugin %s error: %w", name, p.file, err)
+	}
+	if res[0] == 0 {
+		pe := p.callErr
+		if pe == nil {
+			return provider.NewError(provider.ErrorCodeUnknown, false, "call %s of plugin %s failed", name, p.file)
+		}
+		return &provider.Error{
+			Code:      provider.ErrorCode(pe.Code),
+			Retryable: pe.Retryable,
+			Message:   pe.Message,
+		}
+	}
+	b, err := p.read(ctx, p.module, res[0])
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, resp)
+}
+
+func (p *WasmProvider) setError(ctx context.Context, m api.Module, ptr, size uint32) {
+	b, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		return
+	}
+	pe := &providerpb.Error{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, pe); err != nil {
+		pe.Message = string(b)
+	}
+	p.callErr = pe
+}
+
+func (p *WasmProvider) httpRequest(ctx context.Context, m api.Module, ptr, size uint32) uint64 {
+	var resp wasmHTTPResponse
+	b, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		resp.Error = "request out of memory range"
+	} else {
+		resp = p.doHTTPRequest(ctx, b)
+	}
+	out, err := json.Marshal(&resp)
+	if err != nil {
+		return 0
+	}
+	outPtr, err := p.write(ctx, m, out)
+	if err != nil {
+		return 0
+	}
+	return uint64(outPtr)<<32 | uint64(len(out))
+}
+
+func (p *WasmProvider) doHTTPRequest(ctx context.Context, b []byte) wasmHTTPResponse {
+	var req wasmHTTPRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		return wasmHTTPResponse{Error: err.Error()}
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	r, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return wasmHTTPResponse{Error: err.Error()}
+	}
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	resp, err := wasmHTTPClient.Do(r)
+	if err != nil {
+		return wasmHTTPResponse{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, wasmMaxHTTPBody+1))
+	if err != nil {
+		return wasmHTTPResponse{Error: err.Error()}
+	}
+	if len(body) > wasmMaxHTTPBody {
+		return wasmHTTPResponse{Error: "response body too large"}
+	}
+	return wasmHTTPResponse{
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   body,
+	}
+}
+
+func (p *WasmProvider) logMessage(ctx context.Context, m api.Module, level, ptr, size uint32) {
+	b, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		return
+	}
+	l := log.WithField("plugin", p.file)
+	switch level {
+	case 0:
+		l.Debug(string(b))
+	case 1:
+		l.Info(string(b))
+	case 2:
+		l.Warn(string(b))
+	default:
+		l.Error(string(b))
+	}
+}
+
+// Close releases the runtime of the module
+func (p *WasmProvider) Close(ctx context.Context) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.runtime.Close(ctx)
+}
+
+func (p *WasmProvider) Init(o provider.Oauth2Option) {
+	err := p.call(context.Background(), "init", &providerpb.InitReq{
+		ClientId:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		RedirectUrl:  o.RedirectURL,
+		Scopes:       o.Scopes,
+		AuthUrl:      o.AuthURL,
+		TokenUrl:     o.TokenURL,
+	}, &providerpb.Empty{})
+	if err != nil {
+		log.Errorf("init oauth2 plugin %s error: %v", p.file, err)
+	}
+}
+
+func (p *WasmProvider) Provider() provider.OAuth2Provider {
+	return p.name
+}
+
+func (p *WasmProvider) NewAuthURL(ctx context.Context, state string) (string, error) {
+	resp := &providerpb.NewAuthURLResp{}
+	err := withRetry(ctx, func() error {
+		return p.call(ctx, "new_auth_url", &providerpb.NewAuthURLReq{State: state}, resp)
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Url, nil
+}
+
+func (p *WasmProvider) GetUserInfo(ctx context.Context, code string) (*provider.UserInfo, error) {
+	resp := &providerpb.GetUserInfoResp{}
+	err := withRetry(ctx, func() error {
+		return p.call(ctx, "get_user_info", &providerpb.GetUserInfoReq{Code: code}, resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &provider.UserInfo{
+		Username:       resp.Username,
+		ProviderUserID: resp.ProviderUserId,
+		Claims:         resp.Claims,
+		Groups:         resp.Groups,
+	}, nil
+}
+
+func (p *WasmProvider) GetTokenWithOption(ctx context.Context, code string, opt provider.TokenOption) (*oauth2.Token, error) {
+	resp := &providerpb.GetTokenResp{}
+	err := withRetry(ctx, func() error {
+		return p.call(ctx, "get_token", &providerpb.GetTokenReq{
+			Code:         code,
+			CodeVerifier: opt.CodeVerifier,
+			ExtraParams:  opt.ExtraParams,
+		}, resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tk := &oauth2.Token{
+		AccessToken:  resp.AccessToken,
+		TokenType:    resp.TokenType,
+		RefreshToken: resp.RefreshToken,
+	}
+	if resp.Expiry != 0 {
+		tk.Expiry = time.Unix(resp.Expiry, 0)
+	}
+	return tk, nil
+}
+
+// InitWasmProviderPlugin loads the WebAssembly plugin and registers its
+// provider, the module is closed with the context
+func InitWasmProviderPlugin(ctx context.Context, file string, args []string) error {
+	p, err := NewWasmProvider(ctx, file, args)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = p.Close(context.Background())
+	}()
+	providers.RegisterProviderWithSource(providers.ProviderSourcePlugin, p)
+	return nil
+}
@@ -2,6 +2,8 @@ package provider
 
 import (
 	"context"
+
+	"golang.org/x/oauth2"
 )
 
 type OAuth2Provider = string
@@ -9,12 +11,37 @@ type OAuth2Provider = string
 type UserInfo struct {
 	Username       string
 	ProviderUserID string
+	// extra claims of the identity, see the Claim keys
+	Claims map[string]string
+	Groups []string
+	// only set by providers that check memberships, it is kept to check
+	// them later
+	Token *oauth2.Token
 }
 
+// well known keys of UserInfo.Claims
+const (
+	ClaimEmail         = "email"
+	ClaimEmailVerified = "email_verified"
+	ClaimAvatar        = "avatar"
+	ClaimDisplayName   = "display_name"
+)
+
 type Oauth2Option struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// overrides of the provider defaults, only plugins honor them
+	Scopes   []string
+	AuthURL  string
+	TokenURL string
+}
+
+type TokenOption struct {
+	// pkce verifier of the code challenge sent in the auth url
+	CodeVerifier string
+	// extra form params sent to the token endpoint
+	ExtraParams map[string]string
 }
 
 type Provider interface {
@@ -27,3 +54,29 @@ type ProviderInterface interface {
 	NewAuthURL(context.Context, string) (string, error)
 	GetUserInfo(context.Context, string) (*UserInfo, error)
 }
+
+// MembershipProvider is implemented by providers that can tell whether a
+// user belongs to an organization or guild, rooms can be limited to them
+type MembershipProvider interface {
+	ProviderInterface
+	RefreshToken(context.Context, string) (*oauth2.Token, error)
+	IsMember(ctx context.Context, tk *oauth2.Token, group string) (bool, error)
+}
+
+// AuthCodeOptions converts the option for oauth2.Config.Exchange
+func (o TokenOption) AuthCodeOptions() []oauth2.AuthCodeOption {
+	opts := make([]oauth2.AuthCodeOption, 0, len(o.ExtraParams)+1)
+	if o.CodeVerifier != "" {
+		opts = append(opts, oauth2.VerifierOption(o.CodeVerifier))
+	}
+	for k, v := range o.ExtraParams {
+		opts = append(opts, oauth2.SetAuthURLParam(k, v))
+	}
+	return opts
+}
+
+// TokenProvider is implemented by providers that exchange codes with pkce
+// or extra params
+type TokenProvider interface {
+	GetTokenWithOption(ctx context.Context, code string, opt TokenOption) (*oauth2.Token, error)
+}
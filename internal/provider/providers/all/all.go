@@ -0,0 +1,11 @@
+// Package all compiles the oauth2 providers into the binary.
+//
+// Forks add providers by importing their packages here, the packages
+// register themselves in init with providers.RegisterProvider and are
+// listed as compiled in by the admin api, unlike external plugins.
+package all
+
+import (
+	// builtin providers
+	_ "github.com/synctv-org/synctv/internal/provider/providers"
+)
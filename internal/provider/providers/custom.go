@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/provider"
+	"golang.org/x/oauth2"
+)
+
+// CustomOption describes an oauth2 provider that is only configured, for
+// small self-hosted identity providers
+type CustomOption struct {
+	Name        string
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	Scopes      []string
+	// dot separated paths of the fields in the userinfo json
+	IDField       string
+	UsernameField string
+}
+
+type CustomProvider struct {
+	name          string
+	userInfoURL   string
+	idField       string
+	usernameField string
+	config        oauth2.Config
+}
+
+func NewCustomProvider(opt CustomOption) (provider.ProviderInterface, error) {
+	if opt.Name == "" {
+		return nil, errors.New("custom oauth2 provider name is empty")
+	}
+	if opt.AuthURL == "" || opt.TokenURL == "" || opt.UserInfoURL == "" {
+		return nil, fmt.Errorf("custom oauth2 provider %s: auth, token and userinfo url are required", opt.Name)
+	}
+	if opt.IDField == "" {
+		opt.IDField = "id"
+	}
+	if opt.UsernameField == "" {
+		opt.UsernameField = "username"
+	}
+	return &CustomProvider{
+		name:          opt.Name,
+		userInfoURL:   opt.UserInfoURL,
+		idField:       opt.IDField,
+		usernameField: opt.UsernameField,
+		config: oauth2.Config{
+			Scopes: opt.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  opt.AuthURL,
+				TokenURL: opt.TokenURL,
+			},
+		},
+	}, nil
+}
+
+func (p *CustomProvider) Init(c provider.Oauth2Option) {
+	p.config.ClientID = c.ClientID
+	p.config.ClientSecret = c.ClientSecret
+	p.config.RedirectURL = c.RedirectURL
+}
+
+func (p *CustomProvider) Provider() provider.OAuth2Provider {
+	return p.name
+}
+
+func (p *CustomProvider) NewAuthURL(ctx context.Context, state string) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOnline), nil
+}
+
+func (p *CustomProvider) GetToken(ctx context.Context, code string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code)
+}
+
+func (p *CustomProvider) RefreshToken(ctx context.Context, tk string) (*oauth2.Token, error) {
+	return p.config.TokenSource(ctx, &oauth2.Token{RefreshToken: tk}).Token()
+}
+
+func (p *CustomProvider) GetUserInfo(ctx context.Context, code string) (*provider.UserInfo, error) {
+	tk, err := p.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	client := p.config.Client(ctx, tk)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s userinfo: unexpected status code %d", p.name, resp.StatusCode)
+	}
+	var ui map[string]any
+	dec := json.NewDecoder(resp.Body)
+	// keep large numeric ids exact
+	dec.UseNumber()
+	if err := dec.Decode(&ui); err != nil {
+		return nil, err
+	}
+	id, ok := customField(ui, p.idField)
+	if !ok {
+		return nil, fmt.Errorf("%s userinfo has no %s", p.name, p.idField)
+	}
+	username, ok := customField(ui, p.usernameField)
+	if !ok {
+		return nil, fmt.Errorf("%s userinfo has no %s", p.name, p.usernameField)
+	}
+	return &provider.UserInfo{
+		Username:       username,
+		ProviderUserID: id,
+	}, nil
+}
+
+// customField looks up a dot separated path in the userinfo json
+func customField(v map[string]any, path string) (string, bool) {
+	var cur any = v
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		cur, ok = m[key]
+		if !ok {
+			return "", false
+		}
+	}
+	switch cur := cur.(type) {
+	case string:
+		return cur, cur != ""
+	case json.Number:
+		return cur.String(), true
+	default:
+		return "", false
+	}
+}
@@ -17,7 +17,7 @@ type DiscordProvider struct {
 func newDiscordProvider() provider.ProviderInterface {
 	return &DiscordProvider{
 		config: oauth2.Config{
-			Scopes: []string{"identify"},
+			Scopes: []string{"identify", "guilds"},
 			Endpoint: oauth2.Endpoint{
 				AuthURL:  "https://discord.com/oauth2/authorize",
 				TokenURL: "https://discord.com/api/oauth2/token",
@@ -71,9 +71,39 @@ func (p *DiscordProvider) GetUserInfo(ctx context.Context, code string) (*provid
 	return &provider.UserInfo{
 		Username:       ui.Data.Name,
 		ProviderUserID: ui.Data.Id,
+		Token:          tk,
 	}, nil
 }
 
+// IsMember reports whether the user has joined the guild
+func (p *DiscordProvider) IsMember(ctx context.Context, tk *oauth2.Token, guildID string) (bool, error) {
+	client := p.config.Client(ctx, tk)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://discord.com/api/v10/users/@me/guilds", nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("discord guilds: unexpected status code %d", resp.StatusCode)
+	}
+	var guilds []struct {
+		Id string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&guilds); err != nil {
+		return false, err
+	}
+	for _, g := range guilds {
+		if g.Id == guildID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 type discordUserInfo struct {
 	Data struct {
 		Id   string `json:"id"`
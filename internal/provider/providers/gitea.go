@@ -0,0 +1,99 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/provider"
+	"golang.org/x/oauth2"
+)
+
+const DefaultGiteaBaseURL = "https://gitea.com"
+
+// GiteaProvider also works with forgejo, which keeps the gitea api
+type GiteaProvider struct {
+	baseURL string
+	config  oauth2.Config
+}
+
+func newGiteaProvider() provider.ProviderInterface {
+	p := &GiteaProvider{
+		config: oauth2.Config{
+			Scopes: []string{"read:user"},
+		},
+	}
+	p.SetBaseURL(DefaultGiteaBaseURL)
+	return p
+}
+
+func (p *GiteaProvider) SetBaseURL(baseURL string) {
+	p.baseURL = strings.TrimRight(baseURL, "/")
+	p.config.Endpoint = oauth2.Endpoint{
+		AuthURL:  fmt.Sprintf("%s/login/oauth/authorize", p.baseURL),
+		TokenURL: fmt.Sprintf("%s/login/oauth/access_token", p.baseURL),
+	}
+}
+
+func (p *GiteaProvider) Init(c provider.Oauth2Option) {
+	p.config.ClientID = c.ClientID
+	p.config.ClientSecret = c.ClientSecret
+	p.config.RedirectURL = c.RedirectURL
+}
+
+func (p *GiteaProvider) Provider() provider.OAuth2Provider {
+	return "gitea"
+}
+
+func (p *GiteaProvider) NewAuthURL(ctx context.Context, state string) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOnline), nil
+}
+
+func (p *GiteaProvider) GetToken(ctx context.Context, code string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code)
+}
+
+func (p *GiteaProvider) RefreshToken(ctx context.Context, tk string) (*oauth2.Token, error) {
+	return p.config.TokenSource(ctx, &oauth2.Token{RefreshToken: tk}).Token()
+}
+
+func (p *GiteaProvider) GetUserInfo(ctx context.Context, code string) (*provider.UserInfo, error) {
+	tk, err := p.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	client := p.config.Client(ctx, tk)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/v1/user", p.baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gitea user api: unexpected status code %d", resp.StatusCode)
+	}
+	ui := giteaUserInfo{}
+	err = json.NewDecoder(resp.Body).Decode(&ui)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.UserInfo{
+		Username:       ui.Login,
+		ProviderUserID: strconv.FormatUint(ui.ID, 10),
+	}, nil
+}
+
+type giteaUserInfo struct {
+	Login string `json:"login"`
+	ID    uint64 `json:"id"`
+}
+
+func init() {
+	RegisterProvider(newGiteaProvider())
+}
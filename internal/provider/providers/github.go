@@ -2,7 +2,9 @@ package providers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	json "github.com/json-iterator/go"
@@ -18,7 +20,7 @@ type GithubProvider struct {
 func newGithubProvider() provider.ProviderInterface {
 	return &GithubProvider{
 		config: oauth2.Config{
-			Scopes:   []string{"user"},
+			Scopes:   []string{"user", "read:org"},
 			Endpoint: github.Endpoint,
 		},
 	}
@@ -69,9 +71,38 @@ func (p *GithubProvider) GetUserInfo(ctx context.Context, code string) (*provide
 	return &provider.UserInfo{
 		Username:       ui.Login,
 		ProviderUserID: strconv.FormatUint(ui.ID, 10),
+		Token:          tk,
 	}, nil
 }
 
+// IsMember reports whether the user is an active member of the org
+func (p *GithubProvider) IsMember(ctx context.Context, tk *oauth2.Token, org string) (bool, error) {
+	client := p.config.Client(ctx, tk)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://api.github.com/user/memberships/orgs/%s", url.PathEscape(org)), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusForbidden:
+		return false, nil
+	default:
+		return false, fmt.Errorf("github org membership: unexpected status code %d", resp.StatusCode)
+	}
+	m := struct {
+		State string `json:"state"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return false, err
+	}
+	return m.State == "active", nil
+}
+
 type githubUserInfo struct {
 	Login string `json:"login"`
 	ID    uint64 `json:"id"`
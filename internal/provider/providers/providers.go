@@ -7,9 +7,22 @@ import (
 	"github.com/zijiren233/gencontainer/rwmap"
 )
 
+// ProviderSource tells how a provider got into the binary
+type ProviderSource string
+
+const (
+	// compiled in, registered in init of an imported package
+	ProviderSourceCompiled ProviderSource = "compiled"
+	// loaded from an external plugin
+	ProviderSourcePlugin ProviderSource = "plugin"
+	// defined in oauth2_customs of the config
+	ProviderSourceConfig ProviderSource = "config"
+)
+
 var (
 	enabledProviders rwmap.RWMap[provider.OAuth2Provider, struct{}]
 	allProviders     rwmap.RWMap[provider.OAuth2Provider, provider.ProviderInterface]
+	providerSources  rwmap.RWMap[provider.OAuth2Provider, ProviderSource]
 )
 
 func InitProvider(p provider.OAuth2Provider, c provider.Oauth2Option) (provider.ProviderInterface, error) {
@@ -21,12 +34,23 @@ func InitProvider(p provider.OAuth2Provider, c provider.Oauth2Option) (provider.
 	return pi, nil
 }
 
+// RegisterProvider registers compiled in providers, packages of providers
+// call it in init and are imported by the all package
 func RegisterProvider(ps ...provider.ProviderInterface) {
+	RegisterProviderWithSource(ProviderSourceCompiled, ps...)
+}
+
+func RegisterProviderWithSource(source ProviderSource, ps ...provider.ProviderInterface) {
 	for _, p := range ps {
 		allProviders.Store(p.Provider(), p)
+		providerSources.Store(p.Provider(), source)
 	}
 }
 
+func GetProviderSource(p provider.OAuth2Provider) (ProviderSource, bool) {
+	return providerSources.Load(p)
+}
+
 func GetProvider(p provider.OAuth2Provider) (provider.ProviderInterface, error) {
 	_, ok := enabledProviders.Load(p)
 	if !ok {
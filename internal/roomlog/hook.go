@@ -0,0 +1,54 @@
+package roomlog
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// fields of logrus entries routed into the room log by Hook
+const (
+	FieldRoom = "roomlog_room"
+	FieldKind = "roomlog_kind"
+)
+
+// With returns the fields routing the warnings and errors of an entry into
+// the room log, nothing is added when the room is not logging
+func With(entry *logrus.Entry, roomID string, kind Kind) *logrus.Entry {
+	if !Enabled(roomID) {
+		return entry
+	}
+	return entry.WithFields(logrus.Fields{
+		FieldRoom: roomID,
+		FieldKind: kind,
+	})
+}
+
+// Hook records the warnings and errors logged With a room into the room log,
+// so existing log calls are routed without changing them
+type Hook struct{}
+
+var _ logrus.Hook = Hook{}
+
+func (Hook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel}
+}
+
+func (Hook) Fire(e *logrus.Entry) error {
+	roomID, _ := e.Data[FieldRoom].(string)
+	if roomID == "" {
+		return nil
+	}
+	kind, _ := e.Data[FieldKind].(Kind)
+	fields := make(Fields, len(e.Data))
+	for k, v := range e.Data {
+		if k == FieldRoom || k == FieldKind {
+			continue
+		}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[k] = v
+	}
+	fields["level"] = e.Level.String()
+	Log(roomID, kind, fields, "%s", e.Message)
+	return nil
+}
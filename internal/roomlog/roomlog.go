@@ -0,0 +1,226 @@
+// Package roomlog keeps detailed debug logs of the rooms an operator enabled
+// it for, like sync decisions, hub drops and proxy errors. Each room logs to
+// an in-memory ring viewable from the admin api or to its own file, logging
+// is toggled at runtime and costs nothing for other rooms
+package roomlog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/natefinch/lumberjack"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/events"
+	"github.com/synctv-org/synctv/utils"
+)
+
+type Sink string
+
+const (
+	SinkRing Sink = "ring"
+	SinkFile Sink = "file"
+)
+
+type Kind string
+
+const (
+	KindSync  Kind = "sync"
+	KindHub   Kind = "hub"
+	KindProxy Kind = "proxy"
+)
+
+// entries kept by the ring of a room, older ones are dropped
+const ringSize = 1000
+
+var (
+	ErrNotEnabled  = errors.New("room logging is not enabled")
+	ErrUnknownSink = errors.New("unknown log sink")
+)
+
+type Entry struct {
+	Time    int64          `json:"time"`
+	RoomID  string         `json:"roomId"`
+	Kind    Kind           `json:"kind"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+type Fields = map[string]any
+
+type roomLog struct {
+	sink      Sink
+	enabledAt time.Time
+
+	lock sync.Mutex
+	// ring sink
+	ring []Entry
+	next int
+	full bool
+	// file sink
+	file *lumberjack.Logger
+	path string
+}
+
+var rooms sync.Map // room id -> *roomLog
+
+func init() {
+	events.Rooms.Subscribe("roomlog", func(e events.RoomEvent) {
+		if e.Type == events.RoomDeleted {
+			Disable(e.RoomID)
+		}
+	})
+}
+
+func filePath(roomID string) (string, error) {
+	return utils.OptFilePath(filepath.Join(filepath.Dir(conf.Conf.Log.FilePath), "rooms", roomID+".log"))
+}
+
+// Enable starts logging the room to the sink, a room already logging is
+// switched to the sink
+func Enable(roomID string, sink Sink) error {
+	l := &roomLog{
+		sink:      sink,
+		enabledAt: time.Now(),
+	}
+	switch sink {
+	case SinkRing:
+		l.ring = make([]Entry, ringSize)
+	case SinkFile:
+		path, err := filePath(roomID)
+		if err != nil {
+			return err
+		}
+		l.path = path
+		l.file = &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    conf.Conf.Log.MaxSize,
+			MaxBackups: conf.Conf.Log.MaxBackups,
+			MaxAge:     conf.Conf.Log.MaxAge,
+			Compress:   conf.Conf.Log.Compress,
+		}
+	default:
+		return ErrUnknownSink
+	}
+	if old, ok := rooms.Swap(roomID, l); ok {
+		old.(*roomLog).close()
+	}
+	return nil
+}
+
+// Disable stops logging the room, the entries of its ring are dropped
+func Disable(roomID string) {
+	if old, ok := rooms.LoadAndDelete(roomID); ok {
+		old.(*roomLog).close()
+	}
+}
+
+func Enabled(roomID string) bool {
+	_, ok := rooms.Load(roomID)
+	return ok
+}
+
+type State struct {
+	RoomID    string `json:"roomId"`
+	Sink      Sink   `json:"sink"`
+	EnabledAt int64  `json:"enabledAt"`
+	// the log file of the file sink
+	Path string `json:"path,omitempty"`
+}
+
+// States returns the rooms logging
+func States() []State {
+	var states []State
+	rooms.Range(func(key, value any) bool {
+		l := value.(*roomLog)
+		states = append(states, State{
+			RoomID:    key.(string),
+			Sink:      l.sink,
+			EnabledAt: l.enabledAt.UnixMilli(),
+			Path:      l.path,
+		})
+		return true
+	})
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].EnabledAt < states[j].EnabledAt
+	})
+	return states
+}
+
+// Entries returns the ring of the room from the oldest entry
+func Entries(roomID string) ([]Entry, error) {
+	v, ok := rooms.Load(roomID)
+	if !ok {
+		return nil, ErrNotEnabled
+	}
+	l := v.(*roomLog)
+	if l.sink != SinkRing {
+		return nil, fmt.Errorf("room logs to %s, not to the ring", l.sink)
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if !l.full {
+		return append([]Entry(nil), l.ring[:l.next]...), nil
+	}
+	entries := make([]Entry, 0, len(l.ring))
+	entries = append(entries, l.ring[l.next:]...)
+	return append(entries, l.ring[:l.next]...), nil
+}
+
+// Log records the entry if the room is logging, the message is only
+// formatted then
+func Log(roomID string, kind Kind, fields Fields, format string, args ...any) {
+	v, ok := rooms.Load(roomID)
+	if !ok {
+		return
+	}
+	v.(*roomLog).write(Entry{
+		Time:    time.Now().UnixMilli(),
+		RoomID:  roomID,
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+		Fields:  fields,
+	})
+}
+
+func (l *roomLog) write(e Entry) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	switch l.sink {
+	case SinkRing:
+		l.ring[l.next] = e
+		l.next++
+		if l.next == len(l.ring) {
+			l.next = 0
+			l.full = true
+		}
+	case SinkFile:
+		if l.file == nil {
+			return
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			log.Errorf("roomlog: marshal entry error: %v", err)
+			return
+		}
+		if _, err := l.file.Write(append(b, '\n')); err != nil {
+			log.Errorf("roomlog: write %s error: %v", l.path, err)
+		}
+	}
+}
+
+func (l *roomLog) close() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file != nil {
+		if err := l.file.Close(); err != nil {
+			log.Errorf("roomlog: close %s error: %v", l.path, err)
+		}
+		l.file = nil
+	}
+}
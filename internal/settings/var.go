@@ -2,9 +2,11 @@ package settings
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
 )
 
 var (
@@ -14,6 +16,24 @@ var (
 	CreateRoomNeedReview = NewBoolSetting("create_room_need_review", false, model.SettingGroupRoom)
 	// 48 hours
 	RoomTTL = NewInt64Setting("room_ttl", 48, model.SettingGroupRoom)
+	// max pinned chat messages per room
+	RoomMaxPinnedChatMessages = NewInt64Setting("room_max_pinned_chat_messages", 5, model.SettingGroupRoom)
+	// block unrated movies for users with a maximum content rating
+	BlockUnratedContent = NewBoolSetting("block_unrated_content", false, model.SettingGroupRoom)
+	// hours deleted movies are kept in the trash of the room, 0 deletes them at once
+	MovieTrashHours = NewInt64Setting("movie_trash_hours", 24, model.SettingGroupRoom, WithValidatorInt64(func(i int64) error {
+		if i < 0 {
+			return errors.New("movie trash hours must be greater than or equal to 0")
+		}
+		return nil
+	}))
+	// minutes between checks of watched alist directories
+	AlistWatchInterval = NewInt64Setting("alist_watch_interval", 5, model.SettingGroupRoom, WithValidatorInt64(func(i int64) error {
+		if i < 1 {
+			return errors.New("alist watch interval must be at least 1 minute")
+		}
+		return nil
+	}))
 )
 
 func init() {
@@ -46,12 +66,119 @@ var (
 	SignupNeedReview  = NewBoolSetting("signup_need_review", false, model.SettingGroupUser)
 	UserMaxRoomCount  = NewInt64Setting("user_max_room_count", 3, model.SettingGroupUser)
 	EnableGuest       = NewBoolSetting("enable_guest", true, model.SettingGroupUser)
+	// minutes without activity before a user is shown as idle
+	UserIdleTime = NewInt64Setting("user_idle_time", 5, model.SettingGroupUser)
+	// minutes without activity before a user is shown as away
+	UserAwayTime = NewInt64Setting("user_away_time", 15, model.SettingGroupUser)
+	// minutes without activity before a user is disconnected, 0 means never
+	UserIdleDisconnectTime = NewInt64Setting("user_idle_disconnect_time", 0, model.SettingGroupUser)
+	// track watch streaks and unlock achievements
+	EnableAchievements = NewBoolSetting("enable_achievements", false, model.SettingGroupUser)
+	// announce unlocked achievements in the chat of the room
+	AnnounceAchievements = NewBoolSetting("announce_achievements", true, model.SettingGroupUser)
+	// sign in with passkeys registered by users
+	EnablePasskeyLogin = NewBoolSetting("enable_passkey_login", true, model.SettingGroupUser)
+	// host name passkeys are bound to, the host of the HOST setting when empty,
+	// passkeys stop working when it changes
+	PasskeyRPID = NewStringSetting("passkey_rp_id", "", model.SettingGroupUser)
+	// comma separated origins passkeys may be used on, the origin of the HOST setting when empty
+	PasskeyOrigins = NewStringSetting("passkey_origins", "", model.SettingGroupUser)
+	// days a requested account deletion can be canceled before the account is erased
+	// days logins are kept, logins from networks and devices not seen in them are reported to the user
+	LoginHistoryDays = NewInt64Setting("login_history_days", 90, model.SettingGroupUser, WithValidatorInt64(func(i int64) error {
+		if i < 1 {
+			return errors.New("login history days must be greater than 0")
+		}
+		return nil
+	}))
+	AccountDeletionGraceDays = NewInt64Setting("account_deletion_grace_days", 7, model.SettingGroupUser, WithValidatorInt64(func(i int64) error {
+		if i < 0 {
+			return errors.New("account deletion grace days must not be negative")
+		}
+		return nil
+	}))
 )
 
+var (
+	// seconds a dropped websocket session can be resumed, 0 disables resuming
+	WsResumeTimeout = NewInt64Setting("ws_resume_timeout", 15, model.SettingGroupServer)
+	// messages kept for a dropped websocket session until it is resumed
+	WsResumeBufferSize = NewInt64Setting("ws_resume_buffer_size", 64, model.SettingGroupServer, WithValidatorInt64(func(i int64) error {
+		if i < 1 || i > 64 {
+			return errors.New("ws resume buffer size must be between 1 and 64")
+		}
+		return nil
+	}))
+	// seconds a client send queue stays full before the client only gets sync messages
+	WsBackpressureDegradeTime = NewInt64Setting("ws_backpressure_degrade_time", 3, model.SettingGroupServer)
+	// seconds a client stays degraded, or its sync queue stays full, before it is evicted
+	WsBackpressureEvictTime = NewInt64Setting("ws_backpressure_evict_time", 30, model.SettingGroupServer)
+)
+
+// name policy, lists are comma or newline separated, patterns are newline
+// separated regular expressions and scripts are unicode script names like
+// Latin or Han, empty scripts allow all
+var (
+	ReservedUsernames     = NewStringSetting("reserved_usernames", "admin,administrator,root,system,synctv,guest,moderator,support,official", model.SettingGroupUser)
+	UsernameDenyPatterns  = NewStringSetting("username_deny_patterns", "", model.SettingGroupUser, WithValidatorString(validatePatterns))
+	UsernameScripts       = NewStringSetting("username_scripts", "", model.SettingGroupUser, WithValidatorString(validateScripts))
+	ProtectAdminUsernames = NewBoolSetting("protect_admin_usernames", true, model.SettingGroupUser)
+	ReservedRoomNames     = NewStringSetting("reserved_room_names", "", model.SettingGroupRoom)
+	RoomNameDenyPatterns  = NewStringSetting("room_name_deny_patterns", "", model.SettingGroupRoom, WithValidatorString(validatePatterns))
+	RoomNameScripts       = NewStringSetting("room_name_scripts", "", model.SettingGroupRoom, WithValidatorString(validateScripts))
+)
+
+func validatePatterns(s string) error {
+	_, err := namepolicy.ParsePatterns(s)
+	return err
+}
+
+func validateScripts(s string) error {
+	_, err := namepolicy.ParseScripts(s)
+	return err
+}
+
+var OAuth2UsernameCollision = NewStringSetting("oauth2_username_collision", model.UsernameCollisionNumeric, model.SettingGroupOauth2, WithValidatorString(ValidateUsernameCollision))
+
+// minutes before their expiry kept provider tokens are refreshed in the
+// background, 0 only refreshes them when they are used
+var ProviderTokenRefreshAhead = NewInt64Setting("provider_token_refresh_ahead", 10, model.SettingGroupOauth2, WithValidatorInt64(func(i int64) error {
+	if i < 0 {
+		return errors.New("provider token refresh ahead must be greater than or equal to 0")
+	}
+	return nil
+}))
+
+func ValidateUsernameCollision(s string) error {
+	switch s {
+	case model.UsernameCollisionNumeric, model.UsernameCollisionProvider, model.UsernameCollisionReject:
+		return nil
+	default:
+		return fmt.Errorf("invalid username collision strategy: %s", s)
+	}
+}
+
 var (
 	MovieProxy        = NewBoolSetting("movie_proxy", true, model.SettingGroupProxy)
 	LiveProxy         = NewBoolSetting("live_proxy", true, model.SettingGroupProxy)
 	AllowProxyToLocal = NewBoolSetting("allow_proxy_to_local", false, model.SettingGroupProxy)
+	// seconds a proxied live source nobody uses keeps being pulled, so it is
+	// reused when the movie is played again shortly after
+	LiveSourceIdleTimeout = NewInt64Setting("live_source_idle_timeout", 30, model.SettingGroupProxy, WithValidatorInt64(func(i int64) error {
+		if i < 0 {
+			return errors.New("live source idle timeout must be greater than or equal to 0")
+		}
+		return nil
+	}))
+	// wrap direct sources in a single-rendition hls playlist for airplay
+	AirPlayHlsWrap = NewBoolSetting("airplay_hls_wrap", false, model.SettingGroupProxy)
+	// KiB per byte range segment of the airplay hls playlist
+	AirPlayHlsSegmentSize = NewInt64Setting("airplay_hls_segment_size", 4096, model.SettingGroupProxy, WithValidatorInt64(func(i int64) error {
+		if i < 64 {
+			return errors.New("airplay hls segment size must be at least 64 KiB")
+		}
+		return nil
+	}))
 )
 
 var (
@@ -0,0 +1,68 @@
+package settings
+
+import (
+	"fmt"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// VendorSetting turns a vendor off at runtime, e.g. when its upstream api
+// breaks, the reason is shown to users
+type VendorSetting struct {
+	Enabled        BoolSetting
+	DisabledReason StringSetting
+}
+
+var VendorSettings = map[model.VendorName]*VendorSetting{}
+
+func init() {
+	for _, v := range []model.VendorName{
+		model.VendorBilibili,
+		model.VendorAlist,
+		model.VendorEmby,
+		model.VendorDouyin,
+		model.VendorAcfun,
+		model.VendorYouku,
+		model.VendorPlugin,
+	} {
+		VendorSettings[v] = &VendorSetting{
+			Enabled:        NewBoolSetting(fmt.Sprintf("vendor_%s_enabled", v), true, model.SettingGroupVendor),
+			DisabledReason: NewStringSetting(fmt.Sprintf("vendor_%s_disabled_reason", v), "", model.SettingGroupVendor),
+		}
+	}
+}
+
+type VendorDisabledError struct {
+	Vendor model.VendorName
+	Reason string
+}
+
+func (e *VendorDisabledError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s is disabled", e.Vendor)
+	}
+	return fmt.Sprintf("%s is disabled: %s", e.Vendor, e.Reason)
+}
+
+// CheckVendorEnabled returns a *VendorDisabledError when the vendor is off
+func CheckVendorEnabled(vendor model.VendorName) error {
+	s, ok := VendorSettings[vendor]
+	if !ok || s.Enabled.Get() {
+		return nil
+	}
+	return &VendorDisabledError{
+		Vendor: vendor,
+		Reason: s.DisabledReason.Get(),
+	}
+}
+
+// DisabledVendors returns the reasons of the disabled vendors
+func DisabledVendors() map[model.VendorName]string {
+	m := make(map[model.VendorName]string)
+	for v, s := range VendorSettings {
+		if !s.Enabled.Get() {
+			m[v] = s.DisabledReason.Get()
+		}
+	}
+	return m
+}
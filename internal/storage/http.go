@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpObject reads a remote object with range requests, a request is only
+// sent on read so seeking around like http.ServeContent does is free
+type httpObject struct {
+	ctx    context.Context
+	info   *ObjectInfo
+	get    func(ctx context.Context, offset int64) (*http.Response, error)
+	offset int64
+	body   io.ReadCloser
+}
+
+func (o *httpObject) Info() *ObjectInfo {
+	return o.info
+}
+
+func (o *httpObject) Read(p []byte) (int, error) {
+	if o.offset >= o.info.Size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		resp, err := o.get(o.ctx, o.offset)
+		if err != nil {
+			return 0, err
+		}
+		// servers ignoring the range send the whole object
+		if o.offset != 0 && resp.StatusCode != http.StatusPartialContent {
+			resp.Body.Close()
+			return 0, errors.New("storage server does not support range requests")
+		}
+		o.body = resp.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *httpObject) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.info.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *httpObject) Close() error {
+	if o.body != nil {
+		return o.body.Close()
+	}
+	return nil
+}
+
+func setRange(h http.Header, offset int64) {
+	if offset > 0 {
+		h.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+}
+
+// checkResponse closes the body of failed responses, ok lists the accepted
+// status codes besides 2xx
+func checkResponse(resp *http.Response, ok ...int) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotExist
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("storage: %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, msg)
+}
+
+// headInfo reads the object info of a head response
+func headInfo(key string, resp *http.Response) *ObjectInfo {
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	return &ObjectInfo{Key: key, Size: size, ModTime: modTime}
+}
@@ -0,0 +1,52 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/storage"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "blobs/a", strings.NewReader("hello"), 5); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	o, err := s.Open(ctx, "blobs/a")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := o.Seek(1, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(o)
+	o.Close()
+	if string(b) != "ello" || o.Info().Size != 5 {
+		t.Errorf("read %q of size %d, want ello of size 5", b, o.Info().Size)
+	}
+
+	infos, err := s.List(ctx, "blobs/")
+	if err != nil || len(infos) != 1 || infos[0].Key != "blobs/a" {
+		t.Errorf("List() = %v, %v, want blobs/a", infos, err)
+	}
+
+	if err := s.Delete(ctx, "blobs/a"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Stat(ctx, "blobs/a"); !errors.Is(err, storage.ErrNotExist) {
+		t.Errorf("Stat() error = %v, want %v", err, storage.ErrNotExist)
+	}
+	if err := s.Delete(ctx, "blobs/a"); err != nil {
+		t.Errorf("Delete() of missing object error = %v", err)
+	}
+	if err := s.Put(ctx, "../escape", strings.NewReader(""), 0); !errors.Is(err, storage.ErrInvalidKey) {
+		t.Errorf("Put() error = %v, want %v", err, storage.ErrInvalidKey)
+	}
+}
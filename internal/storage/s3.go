@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/conf"
+)
+
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3 stores files in a bucket of amazon s3 or a s3 compatible object store,
+// requests are signed with aws signature version 4
+type S3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	prefix    string
+	pathStyle bool
+	client    *http.Client
+}
+
+func NewS3(c conf.S3StorageConfig) (*S3, error) {
+	if c.Endpoint == "" || c.Bucket == "" {
+		return nil, errors.New("s3 storage needs an endpoint and a bucket")
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	region := c.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3{
+		endpoint:  u,
+		region:    region,
+		bucket:    c.Bucket,
+		accessKey: c.AccessKeyID,
+		secretKey: c.SecretAccessKey,
+		prefix:    c.Prefix,
+		pathStyle: c.PathStyle,
+		client:    &http.Client{},
+	}, nil
+}
+
+func (s *S3) bucketURL() *url.URL {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	return &u
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := s.bucketURL()
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.prefix + key
+	return u
+}
+
+func (s *S3) do(ctx context.Context, method string, u *url.URL, body io.Reader, size int64, header func(h http.Header)) (*http.Response, error) {
+	u.RawPath = s3Escape(u.Path, true)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if header != nil {
+		header(req.Header)
+	}
+	s.sign(req, time.Now())
+	return s.client.Do(req)
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPut, s.objectURL(key), r, size, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *S3) Open(ctx context.Context, key string) (Object, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &httpObject{
+		ctx:  ctx,
+		info: info,
+		get: func(ctx context.Context, offset int64) (*http.Response, error) {
+			resp, err := s.do(ctx, http.MethodGet, s.objectURL(key), nil, 0, func(h http.Header) {
+				setRange(h, offset)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp, checkResponse(resp)
+		},
+	}, nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, s.objectURL(key), nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return headInfo(key, resp), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key), nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp); err != nil && !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	var (
+		infos []*ObjectInfo
+		token string
+	)
+	for {
+		u := s.bucketURL()
+		q := url.Values{}
+		q.Set("list-type", "2")
+		q.Set("prefix", s.prefix+prefix)
+		q.Set("delimiter", "/")
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = s3Query(q)
+		resp, err := s.do(ctx, http.MethodGet, u, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkResponse(resp); err != nil {
+			return nil, err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range result.Contents {
+			infos = append(infos, &ObjectInfo{
+				Key:     strings.TrimPrefix(c.Key, s.prefix),
+				Size:    c.Size,
+				ModTime: c.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return infos, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Range") != "" {
+		signed = append(signed, "range")
+		sort.Strings(signed)
+	}
+	var headers strings.Builder
+	for _, h := range signed {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		headers.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3Query(req.URL.Query()),
+		headers.String(),
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Query encodes the query sorted by key as the canonical request needs
+func s3Query(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range q[k] {
+			if b.Len() != 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(s3Escape(k, false) + "=" + s3Escape(v, false))
+		}
+	}
+	return b.String()
+}
+
+// s3Escape percent encodes everything except the unreserved characters
+func s3Escape(s string, path bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || path && c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/conf"
+)
+
+var (
+	ErrNotExist   = errors.New("storage object does not exist")
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Object is the content of a stored object, it is seekable so it can be
+// served with range requests
+type Object interface {
+	io.ReadSeekCloser
+	Info() *ObjectInfo
+}
+
+// Storage stores files by slash separated keys, like blobs/<checksum>
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Open(ctx context.Context, key string) (Object, error)
+	// Stat returns ErrNotExist if the object does not exist
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete does not fail if the object does not exist
+	Delete(ctx context.Context, key string) error
+	// List returns the objects directly under the prefix directory
+	List(ctx context.Context, prefix string) ([]*ObjectInfo, error)
+}
+
+// fileMover is implemented by drivers that can take over a local file
+// cheaper than copying it
+type fileMover interface {
+	MoveFile(ctx context.Context, key, file string) error
+}
+
+var std Storage
+
+// Init creates the configured storage, the local driver stores files in localRoot
+func Init(c conf.StorageConfig, localRoot string) error {
+	s, err := New(c, localRoot)
+	if err != nil {
+		return err
+	}
+	std = s
+	return nil
+}
+
+func New(c conf.StorageConfig, localRoot string) (Storage, error) {
+	switch c.Driver {
+	case conf.StorageDriverLocal, "":
+		return NewLocal(localRoot)
+	case conf.StorageDriverS3:
+		return NewS3(c.S3)
+	case conf.StorageDriverWebDAV:
+		return NewWebDAV(c.WebDAV)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", c.Driver)
+	}
+}
+
+// Default returns the storage set by Init
+func Default() Storage {
+	return std
+}
+
+// PutFile stores the local file and removes it
+func PutFile(ctx context.Context, s Storage, key, file string) error {
+	if m, ok := s.(fileMover); ok {
+		return m.MoveFile(ctx, key, file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, key, f, stat.Size()); err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(file)
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
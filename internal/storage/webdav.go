@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/conf"
+)
+
+// WebDAV stores files in a directory of a webdav server
+type WebDAV struct {
+	base     *url.URL
+	username string
+	password string
+	client   *http.Client
+}
+
+func NewWebDAV(c conf.WebDAVStorageConfig) (*WebDAV, error) {
+	if c.URL == "" {
+		return nil, errors.New("webdav storage needs an url")
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webdav url: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/"
+	return &WebDAV{
+		base:     u,
+		username: c.Username,
+		password: c.Password,
+		client:   &http.Client{},
+	}, nil
+}
+
+func (w *WebDAV) url(key string) string {
+	u := *w.base
+	u.Path += key
+	return u.String()
+}
+
+func (w *WebDAV) do(ctx context.Context, method, key string, body io.Reader, header func(h http.Header)) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, w.url(key), body)
+	if err != nil {
+		return nil, err
+	}
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	if header != nil {
+		header(req.Header)
+	}
+	return w.client.Do(req)
+}
+
+// mkdirAll creates the parent collections of the key
+func (w *WebDAV) mkdirAll(ctx context.Context, key string) error {
+	dir := path.Dir(key)
+	if dir == "." {
+		return nil
+	}
+	parts := strings.Split(dir, "/")
+	for i := range parts {
+		resp, err := w.do(ctx, "MKCOL", strings.Join(parts[:i+1], "/")+"/", nil, nil)
+		if err != nil {
+			return err
+		}
+		// 405 is returned if the collection exists
+		if err := checkResponse(resp, http.StatusMethodNotAllowed); err != nil {
+			return err
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (w *WebDAV) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := w.mkdirAll(ctx, key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, w.url(key), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (w *WebDAV) Open(ctx context.Context, key string) (Object, error) {
+	info, err := w.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &httpObject{
+		ctx:  ctx,
+		info: info,
+		get: func(ctx context.Context, offset int64) (*http.Response, error) {
+			resp, err := w.do(ctx, http.MethodGet, key, nil, func(h http.Header) {
+				setRange(h, offset)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp, checkResponse(resp)
+		},
+	}, nil
+}
+
+func (w *WebDAV) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := w.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return headInfo(key, resp), nil
+}
+
+func (w *WebDAV) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	resp, err := w.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp); err != nil && !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+type webdavMultistatus struct {
+	Responses []struct {
+		Href string `xml:"href"`
+		Prop struct {
+			ContentLength int64  `xml:"getcontentlength"`
+			LastModified  string `xml:"getlastmodified"`
+			ResourceType  struct {
+				Collection *struct{} `xml:"collection"`
+			} `xml:"resourcetype"`
+		} `xml:"propstat>prop"`
+	} `xml:"response"`
+}
+
+const webdavPropfind = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><getcontentlength/><getlastmodified/><resourcetype/></prop></propfind>`
+
+func (w *WebDAV) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	dir := strings.TrimSuffix(prefix, "/")
+	if err := validateKey(dir); err != nil {
+		return nil, err
+	}
+	resp, err := w.do(ctx, "PROPFIND", dir+"/", strings.NewReader(webdavPropfind), func(h http.Header) {
+		h.Set("Depth", "1")
+		h.Set("Content-Type", "application/xml; charset=utf-8")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var ms webdavMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+	infos := make([]*ObjectInfo, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		if r.Prop.ResourceType.Collection != nil {
+			continue
+		}
+		href, err := url.PathUnescape(r.Href)
+		if err != nil {
+			continue
+		}
+		modTime, _ := http.ParseTime(r.Prop.LastModified)
+		if modTime.IsZero() {
+			modTime = time.Now()
+		}
+		infos = append(infos, &ObjectInfo{
+			Key:     dir + "/" + path.Base(href),
+			Size:    r.Prop.ContentLength,
+			ModTime: modTime,
+		})
+	}
+	return infos, nil
+}
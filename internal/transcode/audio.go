@@ -0,0 +1,132 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+)
+
+var (
+	EnableAudioOnly = settings.NewBoolSetting("enable_audio_only", false, model.SettingGroupTranscode)
+	FfmpegPath      = settings.NewStringSetting("ffmpeg_path", "ffmpeg", model.SettingGroupTranscode)
+	// kbps of the downmixed stereo aac stream
+	AudioOnlyBitrate = settings.NewInt64Setting("audio_only_bitrate", 64, model.SettingGroupTranscode, settings.WithValidatorInt64(func(i int64) error {
+		if i < 16 || i > 320 {
+			return errors.New("audio only bitrate must be between 16 and 320 kbps")
+		}
+		return nil
+	}))
+)
+
+const AudioOnlyContentType = "audio/aac"
+
+var (
+	ErrAudioOnlyDisabled = errors.New("audio only mode is not enabled")
+	ErrUnsupportedSource = errors.New("only http and https sources are supported")
+)
+
+// protocols ffmpeg may open for url inputs, keeps sources from reading
+// local files or other protocols
+const protocolWhitelist = "http,https,tcp,tls"
+
+type Input struct {
+	// url of the source, ignored if Reader is set
+	URL     string
+	Headers http.Header
+	// stream the source from the reader instead, e.g. a live flv stream
+	Reader io.Reader
+	// format of the reader
+	Format string
+	// seconds to skip at the start of the source
+	Start float64
+}
+
+func (i *Input) args() ([]string, error) {
+	args := []string{}
+	if i.Reader != nil {
+		if i.Format != "" {
+			args = append(args, "-f", i.Format)
+		}
+		return append(args, "-i", "pipe:0"), nil
+	}
+	u, err := url.Parse(i.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsupportedSource
+	}
+	args = append(args, "-protocol_whitelist", protocolWhitelist)
+	ua := utils.UA
+	var headers strings.Builder
+	for k, vs := range i.Headers {
+		if strings.EqualFold(k, "User-Agent") {
+			ua = i.Headers.Get(k)
+			continue
+		}
+		for _, v := range vs {
+			headers.WriteString(k)
+			headers.WriteString(": ")
+			headers.WriteString(v)
+			headers.WriteString("\r\n")
+		}
+	}
+	args = append(args, "-user_agent", ua)
+	if headers.Len() != 0 {
+		args = append(args, "-headers", headers.String())
+	}
+	if i.Start > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(i.Start, 'f', 3, 64))
+	}
+	return append(args, "-i", i.URL), nil
+}
+
+// AudioOnly extracts the audio of the input, downmixed to a stereo aac (adts) stream written to w
+func AudioOnly(ctx context.Context, input *Input, w io.Writer) error {
+	if !EnableAudioOnly.Get() {
+		return ErrAudioOnlyDisabled
+	}
+	args := []string{"-hide_banner", "-loglevel", "error", "-nostdin"}
+	if input.Reader != nil {
+		// stdin is the source
+		args = args[:len(args)-1]
+	}
+	inputArgs, err := input.args()
+	if err != nil {
+		return err
+	}
+	args = append(args, inputArgs...)
+	args = append(args,
+		"-map", "0:a:0",
+		"-vn", "-sn", "-dn",
+		"-ac", "2",
+		"-c:a", "aac",
+		"-b:a", fmt.Sprintf("%dk", AudioOnlyBitrate.Get()),
+		"-f", "adts",
+		"pipe:1",
+	)
+
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, FfmpegPath.Get(), args...)
+	cmd.Stdin = input.Reader
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil && ctx.Err() == nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %w: %s", err, msg)
+		}
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+var (
+	EnableLiveLadder = settings.NewBoolSetting("enable_live_ladder", false, model.SettingGroupTranscode)
+	// comma separated heights of the renditions, like 1080,720,480
+	LiveLadder = settings.NewStringSetting("live_ladder", "1080,720,480", model.SettingGroupTranscode, settings.WithValidatorString(func(s string) error {
+		_, err := ParseLadder(s)
+		return err
+	}))
+)
+
+var ErrLiveLadderDisabled = errors.New("live ladder is not enabled")
+
+// Rendition is a variant of the ladder, bitrates are in kbps
+type Rendition struct {
+	Name         string
+	Height       int
+	VideoBitrate int
+	AudioBitrate int
+}
+
+// renditions supported in a ladder, highest first
+var renditions = []Rendition{
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 128},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "480p", Height: 480, VideoBitrate: 1200, AudioBitrate: 96},
+	{Name: "360p", Height: 360, VideoBitrate: 700, AudioBitrate: 96},
+	{Name: "240p", Height: 240, VideoBitrate: 400, AudioBitrate: 64},
+}
+
+// Bandwidth is the peak bits per second of the rendition for the master playlist
+func (r Rendition) Bandwidth() int {
+	return (r.VideoBitrate*3/2 + r.AudioBitrate) * 1000
+}
+
+// ParseLadder parses the comma separated heights into renditions, highest first
+func ParseLadder(s string) ([]Rendition, error) {
+	var ladder []Rendition
+	for _, v := range strings.Split(s, ",") {
+		height, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ladder height %q", v)
+		}
+		i := slices.IndexFunc(renditions, func(r Rendition) bool { return r.Height == height })
+		if i < 0 {
+			return nil, fmt.Errorf("unsupported ladder height %d", height)
+		}
+		if !slices.Contains(ladder, renditions[i]) {
+			ladder = append(ladder, renditions[i])
+		}
+	}
+	if len(ladder) == 0 {
+		return nil, errors.New("ladder is empty")
+	}
+	slices.SortFunc(ladder, func(a, b Rendition) int { return b.Height - a.Height })
+	return ladder, nil
+}
+
+// Ladder returns the configured renditions
+func Ladder() []Rendition {
+	ladder, err := ParseLadder(LiveLadder.Get())
+	if err != nil {
+		return renditions[:3]
+	}
+	return ladder
+}
+
+// LadderRendition returns the configured rendition of the name
+func LadderRendition(name string) (Rendition, bool) {
+	ladder := Ladder()
+	i := slices.IndexFunc(ladder, func(r Rendition) bool { return r.Name == name })
+	if i < 0 {
+		return Rendition{}, false
+	}
+	return ladder[i], true
+}
+
+// MasterPlaylist lists the renditions of the ladder as hls variant streams,
+// uri maps a rendition to the url of its media playlist
+func MasterPlaylist(ladder []Rendition, uri func(r Rendition) string) []byte {
+	b := &strings.Builder{}
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:9\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for _, r := range ladder {
+		fmt.Fprintf(b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AVERAGE-BANDWIDTH=%d,CODECS=\"avc1.4d4028,mp4a.40.2\",NAME=\"%s\"\n%s\n",
+			r.Bandwidth(),
+			(r.VideoBitrate+r.AudioBitrate)*1000,
+			r.Name,
+			uri(r),
+		)
+	}
+	return []byte(b.String())
+}
+
+// LiveRendition transcodes the live input to the rendition as a flv stream
+// written to w, with a keyframe every two seconds for segmenting
+func LiveRendition(ctx context.Context, input *Input, r Rendition, w io.Writer) error {
+	if !EnableLiveLadder.Get() {
+		return ErrLiveLadderDisabled
+	}
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	inputArgs, err := input.args()
+	if err != nil {
+		return err
+	}
+	args = append(args, inputArgs...)
+	args = append(args,
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+		"-sn", "-dn",
+		"-vf", fmt.Sprintf("scale=-2:'min(%d,ih)'", r.Height),
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-tune", "zerolatency",
+		"-profile:v", "main",
+		"-level", "4.0",
+		"-b:v", fmt.Sprintf("%dk", r.VideoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+		"-bufsize", fmt.Sprintf("%dk", r.VideoBitrate*2),
+		"-force_key_frames", "expr:gte(t,n_forced*2)",
+		"-sc_threshold", "0",
+		"-c:a", "aac",
+		"-ac", "2",
+		"-b:a", fmt.Sprintf("%dk", r.AudioBitrate),
+		"-f", "flv",
+		"pipe:1",
+	)
+
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, FfmpegPath.Get(), args...)
+	cmd.Stdin = input.Reader
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil && ctx.Err() == nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %w: %s", err, msg)
+		}
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	return nil
+}
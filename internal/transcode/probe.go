@@ -0,0 +1,84 @@
+package transcode
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+var FfprobePath = settings.NewStringSetting("ffprobe_path", "ffprobe", model.SettingGroupTranscode)
+
+type ProbeStream struct {
+	CodecType string `json:"codec_type"`
+	CodecName string `json:"codec_name"`
+	Profile   string `json:"profile"`
+	PixFmt    string `json:"pix_fmt"`
+}
+
+type ProbeResult struct {
+	FormatName string
+	Duration   float64
+	Streams    []*ProbeStream
+}
+
+// Stream returns the first stream of the codec type (video, audio, subtitle), or nil
+func (p *ProbeResult) Stream(codecType string) *ProbeStream {
+	for _, s := range p.Streams {
+		if s.CodecType == codecType {
+			return s
+		}
+	}
+	return nil
+}
+
+// ProbeAvailable reports whether ffprobe can be found
+func ProbeAvailable() bool {
+	_, err := exec.LookPath(FfprobePath.Get())
+	return err == nil
+}
+
+// Probe reads the container and the streams of the input with ffprobe
+func Probe(ctx context.Context, input *Input) (*ProbeResult, error) {
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	inputArgs, err := input.args()
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, inputArgs...)
+	args = append(args, "-print_format", "json", "-show_format", "-show_streams")
+
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, FfprobePath.Get(), args...)
+	cmd.Stdin = input.Reader
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("ffprobe: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+
+	var data struct {
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+		} `json:"format"`
+		Streams []*ProbeStream `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &data); err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	result := &ProbeResult{
+		FormatName: data.Format.FormatName,
+		Streams:    data.Streams,
+	}
+	result.Duration, _ = strconv.ParseFloat(data.Format.Duration, 64)
+	return result, nil
+}
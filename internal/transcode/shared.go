@@ -0,0 +1,157 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// chunks buffered for a viewer, a viewer falling further behind is dropped
+const sharedViewerBuffer = 256
+
+// how long a transcoder without viewers keeps running for a viewer to rejoin
+const sharedIdleTimeout = time.Second * 10
+
+var ErrViewerTooSlow = errors.New("viewer is too slow")
+
+// Shared runs one transcoder per source and fans its output out to all
+// viewers of the source, instead of a transcoder per viewer. the transcoder
+// is started for the first viewer and stopped once it had no viewers for a while
+type Shared struct {
+	lock sync.Mutex
+	runs map[string]*sharedRun
+}
+
+func NewShared() *Shared {
+	return &Shared{
+		runs: make(map[string]*sharedRun),
+	}
+}
+
+// LiveAudio shares the audio only transcoders of live sources
+var LiveAudio = NewShared()
+
+type sharedRun struct {
+	viewers map[chan []byte]struct{}
+	cancel  context.CancelFunc
+	idle    *time.Timer
+	done    chan struct{}
+	err     error
+}
+
+func (r *sharedRun) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+	for v := range r.viewers {
+		select {
+		case v <- b:
+		default:
+			delete(r.viewers, v)
+			close(v)
+		}
+	}
+	return len(p), nil
+}
+
+type lockedWriter struct {
+	lock *sync.Mutex
+	w    io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.w.Write(p)
+}
+
+// Join writes the output of the transcoder of key to w until ctx is done or
+// the transcoder exits. start runs the transcoder writing to its writer until
+// its context is done, it is only called when no transcoder of key is running
+func (s *Shared) Join(ctx context.Context, key string, start func(ctx context.Context, w io.Writer) error, w io.Writer) error {
+	v := make(chan []byte, sharedViewerBuffer)
+	r := s.join(key, start, v)
+	defer s.leave(key, r, v)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case b, ok := <-v:
+			if !ok {
+				select {
+				case <-r.done:
+					return r.err
+				default:
+					return ErrViewerTooSlow
+				}
+			}
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Shared) join(key string, start func(ctx context.Context, w io.Writer) error, v chan []byte) *sharedRun {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if r, ok := s.runs[key]; ok {
+		if r.idle != nil {
+			r.idle.Stop()
+			r.idle = nil
+		}
+		r.viewers[v] = struct{}{}
+		return r
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &sharedRun{
+		viewers: map[chan []byte]struct{}{v: {}},
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	s.runs[key] = r
+	go func() {
+		err := start(ctx, &lockedWriter{lock: &s.lock, w: r})
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		r.err = err
+		close(r.done)
+		if s.runs[key] == r {
+			delete(s.runs, key)
+		}
+		for v := range r.viewers {
+			delete(r.viewers, v)
+			close(v)
+		}
+		cancel()
+	}()
+	return r
+}
+
+func (s *Shared) leave(key string, r *sharedRun, v chan []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := r.viewers[v]; ok {
+		delete(r.viewers, v)
+		close(v)
+	}
+	if len(r.viewers) != 0 || s.runs[key] != r {
+		return
+	}
+	r.idle = time.AfterFunc(sharedIdleTimeout, func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if len(r.viewers) == 0 && s.runs[key] == r {
+			delete(s.runs, key)
+			r.cancel()
+		}
+	})
+}
+
+// Running is the number of running transcoders
+func (s *Shared) Running() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.runs)
+}
@@ -0,0 +1,389 @@
+// Package wal keeps a crash safe write-ahead log of the mutations of each
+// room. Entries are synced to disk before the call making them returns, so
+// an acknowledged api call survives a crash. The log restores the state the
+// server only keeps in memory and is the source of the room event replay.
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	json "github.com/json-iterator/go"
+)
+
+const (
+	// a log is compacted to the latest keepEntries once it holds maxEntries,
+	// the latest entry of each type is always kept
+	maxEntries  = 4096
+	keepEntries = 1024
+)
+
+var ErrDisabled = errors.New("write-ahead log is disabled")
+
+type Entry struct {
+	Seq uint64 `json:"seq"`
+	// unix milliseconds
+	Time int64           `json:"time"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+var (
+	lock sync.Mutex
+	dir  string
+	logs = make(map[string]*roomLog)
+)
+
+// Init enables the log, storing the log of each room in path
+func Init(path string) error {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	dir = path
+	return nil
+}
+
+func Enabled() bool {
+	lock.Lock()
+	defer lock.Unlock()
+	return dir != ""
+}
+
+type roomLog struct {
+	lock    sync.Mutex
+	path    string
+	f       *os.File
+	size    int64
+	seq     uint64
+	entries int
+}
+
+func load(roomID string) (*roomLog, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if dir == "" {
+		return nil, ErrDisabled
+	}
+	if l, ok := logs[roomID]; ok {
+		return l, nil
+	}
+	l := &roomLog{path: filepath.Join(dir, roomID+".wal")}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	logs[roomID] = l
+	return l, nil
+}
+
+// open drops a record torn by a crash at the end of the log
+func (l *roomLog) open() error {
+	entries, size, err := readEntries(l.path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return err
+	}
+	l.f = f
+	l.size = size
+	l.entries = len(entries)
+	if len(entries) != 0 {
+		l.seq = entries[len(entries)-1].Seq
+	}
+	return nil
+}
+
+// readEntries reads the valid records of the log and the size they take
+func readEntries(path string) ([]*Entry, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	defer f.Close()
+	var (
+		entries []*Entry
+		size    int64
+		r       = bufio.NewReader(f)
+	)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, size, nil
+			}
+			return nil, 0, err
+		}
+		e, ok := decodeRecord(line)
+		if !ok {
+			return entries, size, nil
+		}
+		entries = append(entries, e)
+		size += int64(len(line))
+	}
+}
+
+// a record is the crc32 of the entry in hex, a space and the entry as json
+func encodeRecord(e *Entry) ([]byte, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(b), b)), nil
+}
+
+func decodeRecord(line []byte) (*Entry, bool) {
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	sum, b, ok := bytes.Cut(line, []byte{' '})
+	if !ok || string(sum) != fmt.Sprintf("%08x", crc32.ChecksumIEEE(b)) {
+		return nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
+// Append logs an entry of the room and syncs it to disk, it does nothing
+// if the log is disabled
+func Append(roomID, typ string, data any) (*Entry, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		l, err := load(roomID)
+		if err != nil {
+			if errors.Is(err, ErrDisabled) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		l.lock.Lock()
+		// closed after it was loaded, the next load opens it again
+		if l.f == nil {
+			l.lock.Unlock()
+			continue
+		}
+		e, err := l.append(typ, b)
+		l.lock.Unlock()
+		return e, err
+	}
+}
+
+func (l *roomLog) append(typ string, data json.RawMessage) (*Entry, error) {
+	e := &Entry{
+		Seq:  l.seq + 1,
+		Time: time.Now().UnixMilli(),
+		Type: typ,
+		Data: data,
+	}
+	rec, err := encodeRecord(e)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := l.f.Write(rec); err != nil {
+		// never leave a partial record before the next one
+		_ = l.f.Truncate(l.size)
+		return nil, err
+	}
+	if err := l.f.Sync(); err != nil {
+		_ = l.f.Truncate(l.size)
+		return nil, err
+	}
+	l.size += int64(len(rec))
+	l.seq = e.Seq
+	l.entries++
+	if l.entries >= maxEntries {
+		if err := l.compact(); err != nil {
+			return e, fmt.Errorf("compact: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// compact rewrites the log aside and swaps it in, so a crash leaves either
+// the old or the new log
+func (l *roomLog) compact() error {
+	entries, _, err := readEntries(l.path)
+	if err != nil {
+		return err
+	}
+	entries = compacted(entries)
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	w := bufio.NewWriter(f)
+	var size int64
+	for _, e := range entries {
+		rec, err := encodeRecord(e)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := w.Write(rec); err != nil {
+			f.Close()
+			return err
+		}
+		size += int64(len(rec))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(l.path))
+	l.f.Close()
+	l.f, err = os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		l.f = nil
+		return err
+	}
+	l.size = size
+	l.entries = len(entries)
+	return nil
+}
+
+// compacted keeps the latest keepEntries and the latest entry of each type
+func compacted(entries []*Entry) []*Entry {
+	if len(entries) <= keepEntries {
+		return entries
+	}
+	cut := len(entries) - keepEntries
+	kept := entries[cut:]
+	seen := make(map[string]struct{})
+	for _, e := range kept {
+		seen[e.Type] = struct{}{}
+	}
+	var latest []*Entry
+	for i := cut - 1; i >= 0; i-- {
+		if _, ok := seen[entries[i].Type]; ok {
+			continue
+		}
+		seen[entries[i].Type] = struct{}{}
+		latest = append(latest, entries[i])
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].Seq < latest[j].Seq })
+	return append(latest, kept...)
+}
+
+func syncDir(path string) {
+	d, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
+
+// Read returns up to limit entries of the room after the seq, oldest first
+func Read(roomID string, after uint64, limit int) ([]*Entry, error) {
+	l, err := load(roomID)
+	if err != nil {
+		return nil, err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entries, _, err := readEntries(l.path)
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Seq > after })
+	entries = entries[i:]
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// Latest returns the latest entry of each of the types in the log of the room
+func Latest(roomID string, types ...string) (map[string]*Entry, error) {
+	l, err := load(roomID)
+	if err != nil {
+		return nil, err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entries, _, err := readEntries(l.path)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*Entry, len(types))
+	for i := len(entries) - 1; i >= 0 && len(latest) < len(types); i-- {
+		e := entries[i]
+		if _, ok := latest[e.Type]; ok {
+			continue
+		}
+		for _, t := range types {
+			if e.Type == t {
+				latest[t] = e
+				break
+			}
+		}
+	}
+	return latest, nil
+}
+
+// Close closes the log of the room until it is written again
+func Close(roomID string) {
+	lock.Lock()
+	l, ok := logs[roomID]
+	delete(logs, roomID)
+	lock.Unlock()
+	if !ok {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.f != nil {
+		l.f.Close()
+		l.f = nil
+	}
+}
+
+// Remove deletes the log of the room
+func Remove(roomID string) error {
+	Close(roomID)
+	lock.Lock()
+	d := dir
+	lock.Unlock()
+	if d == "" {
+		return nil
+	}
+	err := os.Remove(filepath.Join(d, roomID+".wal"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
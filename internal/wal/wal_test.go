@@ -0,0 +1,74 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTornRecordIsDropped(t *testing.T) {
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close("room") })
+	for i := 0; i < 3; i++ {
+		if _, err := Append("room", "status", map[string]int{"i": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Close("room")
+
+	// a crash in the middle of the fourth record
+	f, err := os.OpenFile(filepath.Join(dir, "room.wal"), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`0badc0de {"seq":4,"ty`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	e, err := Append("room", "current", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Seq != 4 {
+		t.Fatalf("seq after recovery is %d, want 4", e.Seq)
+	}
+	entries, err := Read("room", 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Seq != 3 || entries[1].Type != "current" {
+		t.Fatalf("unexpected entries after recovery: %+v", entries)
+	}
+}
+
+func TestCompactKeepsLatestOfEachType(t *testing.T) {
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close("room") })
+	if _, err := Append("room", "current", "movie"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < maxEntries; i++ {
+		if _, err := Append("room", "status", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := Read("room", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != keepEntries+1 {
+		t.Fatalf("compacted log has %d entries, want %d", len(entries), keepEntries+1)
+	}
+	latest, err := Latest("room", "current", "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest["current"] == nil || latest["current"].Seq != 1 || latest["status"].Seq != maxEntries {
+		t.Fatalf("unexpected latest entries: %+v", latest)
+	}
+}
@@ -0,0 +1,120 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var errCBOR = errors.New("invalid cbor")
+
+// maxCBORDepth bounds the nesting of decoded items, authenticators send
+// shallow maps
+const maxCBORDepth = 16
+
+// decodeCBOR decodes the first item of b and returns the bytes after it.
+// It decodes the subset of cbor authenticators send: integers as int64, byte
+// and text strings, arrays as []any, maps as map[any]any and simple values,
+// tags are skipped and indefinite lengths are not supported
+func decodeCBOR(b []byte) (any, []byte, error) {
+	return decodeCBORItem(b, 0)
+}
+
+func decodeCBORItem(b []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, fmt.Errorf("%w: nested too deep", errCBOR)
+	}
+	if len(b) == 0 {
+		return nil, nil, fmt.Errorf("%w: unexpected end", errCBOR)
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	b = b[1:]
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, b, nil
+		case 21:
+			return true, b, nil
+		case 22, 23:
+			return nil, b, nil
+		}
+		return nil, nil, fmt.Errorf("%w: unsupported simple value %d", errCBOR, info)
+	}
+	n, b, err := cborArgument(info, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case 0:
+		if n > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return int64(n), b, nil
+	case 1:
+		if n > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return -1 - int64(n), b, nil
+	case 2, 3:
+		if n > uint64(len(b)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end", errCBOR)
+		}
+		if major == 3 {
+			return string(b[:n]), b[n:], nil
+		}
+		return b[:n:n], b[n:], nil
+	case 4:
+		if n > uint64(len(b)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end", errCBOR)
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+		}
+		return items, b, nil
+	case 5:
+		if n > uint64(len(b)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end", errCBOR)
+		}
+		m := make(map[any]any, n)
+		for i := uint64(0); i < n; i++ {
+			var k, v any
+			if k, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("%w: unsupported map key", errCBOR)
+			}
+			if v, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			m[k] = v
+		}
+		return m, b, nil
+	case 6:
+		return decodeCBORItem(b, depth+1)
+	}
+	return nil, nil, errCBOR
+}
+
+func cborArgument(info byte, b []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), b, nil
+	case info == 24 && len(b) >= 1:
+		return uint64(b[0]), b[1:], nil
+	case info == 25 && len(b) >= 2:
+		return uint64(binary.BigEndian.Uint16(b)), b[2:], nil
+	case info == 26 && len(b) >= 4:
+		return uint64(binary.BigEndian.Uint32(b)), b[4:], nil
+	case info == 27 && len(b) >= 8:
+		return binary.BigEndian.Uint64(b), b[8:], nil
+	case info > 27:
+		return 0, nil, fmt.Errorf("%w: unsupported length", errCBOR)
+	}
+	return 0, nil, fmt.Errorf("%w: unexpected end", errCBOR)
+}
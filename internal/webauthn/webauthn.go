@@ -0,0 +1,344 @@
+// Package webauthn verifies the registration and assertion ceremonies of
+// passkeys. Attestation statements are not verified, the server asks for no
+// attestation and trusts the authenticator the user picked
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidClientData  = errors.New("invalid client data")
+	ErrChallengeMismatch  = errors.New("challenge mismatch")
+	ErrOriginMismatch     = errors.New("origin not allowed")
+	ErrRPIDMismatch       = errors.New("credential is for another site")
+	ErrUserNotPresent     = errors.New("user presence was not verified")
+	ErrUserNotVerified    = errors.New("user verification is required")
+	ErrInvalidAuthData    = errors.New("invalid authenticator data")
+	ErrInvalidSignature   = errors.New("invalid signature")
+	ErrSignCountRegressed = errors.New("signature counter went backwards, the authenticator may be cloned")
+)
+
+// ChallengeSize is the number of random bytes of a challenge
+const ChallengeSize = 32
+
+// Timeout is how long clients wait for the user, in milliseconds
+const Timeout = 5 * 60 * 1000
+
+func NewChallenge() ([]byte, error) {
+	b := make([]byte, ChallengeSize)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// Base64URL is binary data sent as base64url without padding, padded and
+// standard base64 is accepted from clients too
+type Base64URL []byte
+
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	s = strings.TrimRight(s, "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	v, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// RelyingParty is the site credentials are scoped to
+type RelyingParty struct {
+	// ID is the host name credentials are bound to
+	ID   string
+	Name string
+	// Origins are the origins the ceremonies may run on
+	Origins []string
+	// RequireUserVerification requires a pin or biometrics, not only presence
+	RequireUserVerification bool
+}
+
+type rpEntity struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are the options of navigator.credentials.create
+type CreationOptions struct {
+	Challenge              Base64URL              `json:"challenge"`
+	RP                     rpEntity               `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	Attestation            string                 `json:"attestation"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+}
+
+// RequestOptions are the options of navigator.credentials.get
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int                    `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+func (rp *RelyingParty) userVerification() string {
+	if rp.RequireUserVerification {
+		return "required"
+	}
+	return "preferred"
+}
+
+// CreationOptions asks for a discoverable credential of the user, the
+// credentials in exclude are not registered again
+func (rp *RelyingParty) CreationOptions(challenge, userID []byte, name, displayName string, exclude []CredentialDescriptor) *CreationOptions {
+	params := make([]credentialParameter, len(supportedAlgs))
+	for i, alg := range supportedAlgs {
+		params[i] = credentialParameter{Type: "public-key", Alg: alg}
+	}
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return &CreationOptions{
+		Challenge:          challenge,
+		RP:                 rpEntity{ID: rp.ID, Name: rp.Name},
+		User:               userEntity{ID: userID, Name: name, DisplayName: displayName},
+		PubKeyCredParams:   params,
+		Timeout:            Timeout,
+		Attestation:        "none",
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.userVerification(),
+		},
+	}
+}
+
+// RequestOptions asks for one of the allowed credentials, or any
+// discoverable credential of the site when allow is empty
+func (rp *RelyingParty) RequestOptions(challenge []byte, allow []CredentialDescriptor) *RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		Timeout:          Timeout,
+		AllowCredentials: allow,
+		UserVerification: rp.userVerification(),
+	}
+}
+
+// AttestationResponse is the response of navigator.credentials.create
+type AttestationResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AttestationObject Base64URL `json:"attestationObject"`
+	Transports        []string  `json:"transports,omitempty"`
+}
+
+// AssertionResponse is the response of navigator.credentials.get
+type AssertionResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AuthenticatorData Base64URL `json:"authenticatorData"`
+	Signature         Base64URL `json:"signature"`
+	UserHandle        Base64URL `json:"userHandle,omitempty"`
+}
+
+// Credential is a registered public key credential
+type Credential struct {
+	ID []byte
+	// cose key
+	PublicKey []byte
+	SignCount uint32
+	// the credential may be synced to the other devices of the user
+	BackupEligible bool
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, typ string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return ErrInvalidClientData
+	}
+	if cd.Type != typ {
+		return fmt.Errorf("%w: type %q", ErrInvalidClientData, cd.Type)
+	}
+	got, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return ErrChallengeMismatch
+	}
+	if cd.CrossOrigin || !slices.Contains(rp.Origins, cd.Origin) {
+		return fmt.Errorf("%w: %s", ErrOriginMismatch, cd.Origin)
+	}
+	return nil
+}
+
+// authenticator data flags
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagBackupEligible   = 0x08
+	flagAttestedCredData = 0x40
+)
+
+type authenticatorData struct {
+	flags     byte
+	signCount uint32
+	// set when flagAttestedCredData is
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (rp *RelyingParty) parseAuthenticatorData(b []byte) (*authenticatorData, error) {
+	if len(b) < 37 {
+		return nil, ErrInvalidAuthData
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(b[:32], rpIDHash[:]) {
+		return nil, ErrRPIDMismatch
+	}
+	ad := &authenticatorData{
+		flags:     b[32],
+		signCount: binary.BigEndian.Uint32(b[33:37]),
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return nil, ErrUserNotPresent
+	}
+	if rp.RequireUserVerification && ad.flags&flagUserVerified == 0 {
+		return nil, ErrUserNotVerified
+	}
+	if ad.flags&flagAttestedCredData == 0 {
+		return ad, nil
+	}
+	// aaguid, then the length of the credential id
+	rest := b[37:]
+	if len(rest) < 18 {
+		return nil, ErrInvalidAuthData
+	}
+	n := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if n == 0 || n > 1023 || len(rest) < n {
+		return nil, ErrInvalidAuthData
+	}
+	ad.credentialID = rest[:n]
+	rest = rest[n:]
+	// the public key is followed by extensions
+	_, after, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAuthData, err)
+	}
+	ad.publicKey = rest[:len(rest)-len(after)]
+	return ad, nil
+}
+
+// VerifyRegistration checks the response of navigator.credentials.create to
+// the challenge and returns the new credential
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, resp *AttestationResponse) (*Credential, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+	v, _, err := decodeCBOR(resp.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(map[any]any)
+	if !ok {
+		return nil, errors.New("invalid attestation object")
+	}
+	authData, ok := obj["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("invalid attestation object")
+	}
+	ad, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if ad.credentialID == nil {
+		return nil, fmt.Errorf("%w: no credential", ErrInvalidAuthData)
+	}
+	if _, err := parsePublicKey(ad.publicKey); err != nil {
+		return nil, err
+	}
+	return &Credential{
+		ID:             ad.credentialID,
+		PublicKey:      ad.publicKey,
+		SignCount:      ad.signCount,
+		BackupEligible: ad.flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// VerifyAssertion checks the response of navigator.credentials.get to the
+// challenge was signed by the credential, and returns the new signature
+// counter of the credential
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, cred *Credential, resp *AssertionResponse) (uint32, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	ad, err := rp.parseAuthenticatorData(resp.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	key, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	signed := make([]byte, 0, len(resp.AuthenticatorData)+len(clientDataHash))
+	signed = append(signed, resp.AuthenticatorData...)
+	signed = append(signed, clientDataHash[:]...)
+	if !key.verify(signed, resp.Signature) {
+		return 0, ErrInvalidSignature
+	}
+	// authenticators without a counter always send zero
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return 0, ErrSignCountRegressed
+	}
+	return ad.signCount, nil
+}
@@ -0,0 +1,138 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// cborHead encodes the head of a cbor item with a small argument
+func cborHead(major, n byte) []byte {
+	if n < 24 {
+		return []byte{major<<5 | n}
+	}
+	return []byte{major<<5 | 24, n}
+}
+
+func cborBytes(b []byte) []byte {
+	var h []byte
+	switch {
+	case len(b) < 256:
+		h = cborHead(2, byte(len(b)))
+	default:
+		h = binary.BigEndian.AppendUint16([]byte{2<<5 | 25}, uint16(len(b)))
+	}
+	return append(h, b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, byte(len(s))), s...)
+}
+
+func cborInt(i int) []byte {
+	if i < 0 {
+		return cborHead(1, byte(-1-i))
+	}
+	return cborHead(0, byte(i))
+}
+
+func es256Key(k *ecdsa.PrivateKey) []byte {
+	b := cborHead(5, 5)
+	b = append(b, cborInt(coseKty)...)
+	b = append(b, cborInt(coseKtyEC2)...)
+	b = append(b, cborInt(coseAlg)...)
+	b = append(b, cborInt(AlgES256)...)
+	b = append(b, cborInt(coseCrv)...)
+	b = append(b, cborInt(coseCrvP256)...)
+	b = append(b, cborInt(coseX)...)
+	b = append(b, cborBytes(k.X.FillBytes(make([]byte, 32)))...)
+	b = append(b, cborInt(coseY)...)
+	b = append(b, cborBytes(k.Y.FillBytes(make([]byte, 32)))...)
+	return b
+}
+
+func authData(rpID string, flags byte, signCount uint32, attested []byte) []byte {
+	h := sha256.Sum256([]byte(rpID))
+	b := append(h[:], flags)
+	b = binary.BigEndian.AppendUint32(b, signCount)
+	return append(b, attested...)
+}
+
+func clientDataJSON(typ string, challenge []byte, origin string) []byte {
+	return []byte(`{"type":"` + typ + `","challenge":"` + base64.RawURLEncoding.EncodeToString(challenge) + `","origin":"` + origin + `"}`)
+}
+
+func TestCeremonies(t *testing.T) {
+	rp := &RelyingParty{ID: "synctv.example", Name: "SyncTV", Origins: []string{"https://synctv.example"}}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credID := []byte("credential-id")
+
+	challenge, _ := NewChallenge()
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(credID)))
+	attested = append(attested, credID...)
+	attested = append(attested, es256Key(key)...)
+	obj := cborHead(5, 3)
+	obj = append(obj, cborText("fmt")...)
+	obj = append(obj, cborText("none")...)
+	obj = append(obj, cborText("attStmt")...)
+	obj = append(obj, cborHead(5, 0)...)
+	obj = append(obj, cborText("authData")...)
+	obj = append(obj, cborBytes(authData(rp.ID, flagUserPresent|flagAttestedCredData, 0, attested))...)
+
+	cred, err := rp.VerifyRegistration(challenge, &AttestationResponse{
+		ClientDataJSON:    clientDataJSON("webauthn.create", challenge, "https://synctv.example"),
+		AttestationObject: obj,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cred.ID) != string(credID) {
+		t.Fatalf("got credential id %q", cred.ID)
+	}
+
+	_, err = rp.VerifyRegistration(challenge, &AttestationResponse{
+		ClientDataJSON:    clientDataJSON("webauthn.create", challenge, "https://evil.example"),
+		AttestationObject: obj,
+	})
+	if !errors.Is(err, ErrOriginMismatch) {
+		t.Fatalf("got %v, want origin mismatch", err)
+	}
+
+	sign := func(challenge []byte, signCount uint32) *AssertionResponse {
+		resp := &AssertionResponse{
+			ClientDataJSON:    clientDataJSON("webauthn.get", challenge, "https://synctv.example"),
+			AuthenticatorData: authData(rp.ID, flagUserPresent, signCount, nil),
+		}
+		h := sha256.Sum256(resp.ClientDataJSON)
+		digest := sha256.Sum256(append(append([]byte{}, resp.AuthenticatorData...), h[:]...))
+		resp.Signature, _ = ecdsa.SignASN1(rand.Reader, key, digest[:])
+		return resp
+	}
+	challenge, _ = NewChallenge()
+	count, err := rp.VerifyAssertion(challenge, cred, sign(challenge, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred.SignCount = count
+	if _, err := rp.VerifyAssertion(challenge, cred, sign(challenge, 1)); !errors.Is(err, ErrSignCountRegressed) {
+		t.Fatalf("got %v, want sign count regressed", err)
+	}
+	other, _ := NewChallenge()
+	if _, err := rp.VerifyAssertion(challenge, cred, sign(other, 2)); !errors.Is(err, ErrChallengeMismatch) {
+		t.Fatalf("got %v, want challenge mismatch", err)
+	}
+	resp := sign(challenge, 2)
+	resp.Signature[len(resp.Signature)-1] ^= 1
+	if _, err := rp.VerifyAssertion(challenge, cred, resp); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("got %v, want invalid signature", err)
+	}
+}
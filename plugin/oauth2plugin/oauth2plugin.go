@@ -0,0 +1,70 @@
+// Package oauth2plugin is used to write oauth2 provider plugins outside of
+// synctv, the types are the ones the host uses.
+//
+//	func main() {
+//		oauth2plugin.Serve(newMyProvider())
+//	}
+//
+// config.yaml:
+//
+//	oauth2_plugins:
+//	  - plugin_file: plugins/oauth2/my-provider
+package oauth2plugin
+
+import (
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/provider/plugins"
+)
+
+type (
+	// Provider must be implemented by plugins
+	Provider = provider.ProviderInterface
+	// TokenProvider is optional, it exchanges codes with pkce or extra params
+	TokenProvider = provider.TokenProvider
+	// PluginVersioner is optional, the version is logged by the host
+	PluginVersioner = plugins.PluginVersioner
+
+	// OAuth2Provider is the name of the provider
+	OAuth2Provider = provider.OAuth2Provider
+	Option         = provider.Oauth2Option
+	TokenOption    = provider.TokenOption
+	UserInfo       = provider.UserInfo
+
+	// Error is shown to the user by the host instead of an opaque rpc error
+	Error     = provider.Error
+	ErrorCode = provider.ErrorCode
+)
+
+const (
+	ErrorCodeUnknown       = provider.ErrorCodeUnknown
+	ErrorCodeInvalidCode   = provider.ErrorCodeInvalidCode
+	ErrorCodeAccessDenied  = provider.ErrorCodeAccessDenied
+	ErrorCodeUnavailable   = provider.ErrorCodeUnavailable
+	ErrorCodeRateLimited   = provider.ErrorCodeRateLimited
+	ErrorCodeInvalidConfig = provider.ErrorCodeInvalidConfig
+)
+
+// well known keys of UserInfo.Claims
+const (
+	ClaimEmail         = provider.ClaimEmail
+	ClaimEmailVerified = provider.ClaimEmailVerified
+	ClaimAvatar        = provider.ClaimAvatar
+	ClaimDisplayName   = provider.ClaimDisplayName
+)
+
+// capabilities reported to the host
+const (
+	CapabilityTokenOption  = plugins.CapabilityTokenOption
+	CapabilityClaims       = plugins.CapabilityClaims
+	CapabilityErrorDetails = plugins.CapabilityErrorDetails
+)
+
+func NewError(code ErrorCode, retryable bool, format string, a ...any) *Error {
+	return provider.NewError(code, retryable, format, a...)
+}
+
+// Serve serves the provider to hosts speaking the current plugin protocol
+// and to older hosts speaking the first one
+func Serve(p Provider) {
+	plugins.Serve(p)
+}
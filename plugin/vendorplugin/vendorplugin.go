@@ -0,0 +1,34 @@
+// Package vendorplugin is used to write media vendor plugins outside of
+// synctv, movies of the vendor are added with the vendor plugin and the
+// name returned by Info.
+//
+//	type myVendor struct {
+//		vendorplugin.UnimplementedVendorPluginServer
+//	}
+//
+//	func main() {
+//		vendorplugin.Serve(&myVendor{})
+//	}
+//
+// config.yaml:
+//
+//	vendor_plugins:
+//	  - plugin_file: plugins/vendor/my-vendor
+package vendorplugin
+
+import (
+	"github.com/synctv-org/synctv/internal/vendor"
+	vendorpb "github.com/synctv-org/synctv/proto/vendor"
+)
+
+type (
+	// VendorPluginServer must be implemented by plugins
+	VendorPluginServer = vendorpb.VendorPluginServer
+	// UnimplementedVendorPluginServer must be embedded by implementations
+	UnimplementedVendorPluginServer = vendorpb.UnimplementedVendorPluginServer
+)
+
+// Serve serves the vendor to the host
+func Serve(v VendorPluginServer) {
+	vendor.ServeVendorPlugin(v)
+}
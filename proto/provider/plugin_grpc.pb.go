@@ -23,6 +23,7 @@ const (
 	Oauth2Plugin_Provider_FullMethodName    = "/proto.Oauth2Plugin/Provider"
 	Oauth2Plugin_NewAuthURL_FullMethodName  = "/proto.Oauth2Plugin/NewAuthURL"
 	Oauth2Plugin_GetUserInfo_FullMethodName = "/proto.Oauth2Plugin/GetUserInfo"
+	Oauth2Plugin_GetToken_FullMethodName    = "/proto.Oauth2Plugin/GetToken"
 )
 
 // Oauth2PluginClient is the client API for Oauth2Plugin service.
@@ -33,6 +34,7 @@ type Oauth2PluginClient interface {
 	Provider(ctx context.Context, in *Enpty, opts ...grpc.CallOption) (*ProviderResp, error)
 	NewAuthURL(ctx context.Context, in *NewAuthURLReq, opts ...grpc.CallOption) (*NewAuthURLResp, error)
 	GetUserInfo(ctx context.Context, in *GetUserInfoReq, opts ...grpc.CallOption) (*GetUserInfoResp, error)
+	GetToken(ctx context.Context, in *GetTokenReq, opts ...grpc.CallOption) (*GetTokenResp, error)
 }
 
 type oauth2PluginClient struct {
@@ -79,6 +81,15 @@ func (c *oauth2PluginClient) GetUserInfo(ctx context.Context, in *GetUserInfoReq
 	return out, nil
 }
 
+func (c *oauth2PluginClient) GetToken(ctx context.Context, in *GetTokenReq, opts ...grpc.CallOption) (*GetTokenResp, error) {
+	out := new(GetTokenResp)
+	err := c.cc.Invoke(ctx, Oauth2Plugin_GetToken_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Oauth2PluginServer is the server API for Oauth2Plugin service.
 // All implementations must embed UnimplementedOauth2PluginServer
 // for forward compatibility
@@ -87,6 +98,7 @@ type Oauth2PluginServer interface {
 	Provider(context.Context, *Enpty) (*ProviderResp, error)
 	NewAuthURL(context.Context, *NewAuthURLReq) (*NewAuthURLResp, error)
 	GetUserInfo(context.Context, *GetUserInfoReq) (*GetUserInfoResp, error)
+	GetToken(context.Context, *GetTokenReq) (*GetTokenResp, error)
 	mustEmbedUnimplementedOauth2PluginServer()
 }
 
@@ -106,6 +118,9 @@ func (UnimplementedOauth2PluginServer) NewAuthURL(context.Context, *NewAuthURLRe
 func (UnimplementedOauth2PluginServer) GetUserInfo(context.Context, *GetUserInfoReq) (*GetUserInfoResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserInfo not implemented")
 }
+func (UnimplementedOauth2PluginServer) GetToken(context.Context, *GetTokenReq) (*GetTokenResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetToken not implemented")
+}
 func (UnimplementedOauth2PluginServer) mustEmbedUnimplementedOauth2PluginServer() {}
 
 // UnsafeOauth2PluginServer may be embedded to opt out of forward compatibility for this service.
@@ -191,6 +206,24 @@ func _Oauth2Plugin_GetUserInfo_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Oauth2Plugin_GetToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTokenReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Oauth2PluginServer).GetToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Oauth2Plugin_GetToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Oauth2PluginServer).GetToken(ctx, req.(*GetTokenReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Oauth2Plugin_ServiceDesc is the grpc.ServiceDesc for Oauth2Plugin service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -214,6 +247,289 @@ var Oauth2Plugin_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserInfo",
 			Handler:    _Oauth2Plugin_GetUserInfo_Handler,
 		},
+		{
+			MethodName: "GetToken",
+			Handler:    _Oauth2Plugin_GetToken_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/provider/plugin.proto",
+}
+
+const (
+	Oauth2PluginV2_Init_FullMethodName         = "/proto.Oauth2PluginV2/Init"
+	Oauth2PluginV2_Provider_FullMethodName     = "/proto.Oauth2PluginV2/Provider"
+	Oauth2PluginV2_NewAuthURL_FullMethodName   = "/proto.Oauth2PluginV2/NewAuthURL"
+	Oauth2PluginV2_GetUserInfo_FullMethodName  = "/proto.Oauth2PluginV2/GetUserInfo"
+	Oauth2PluginV2_GetToken_FullMethodName     = "/proto.Oauth2PluginV2/GetToken"
+	Oauth2PluginV2_Capabilities_FullMethodName = "/proto.Oauth2PluginV2/Capabilities"
+)
+
+// Oauth2PluginV2Client is the client API for Oauth2PluginV2 service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type Oauth2PluginV2Client interface {
+	Init(ctx context.Context, in *InitReq, opts ...grpc.CallOption) (*Empty, error)
+	Provider(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ProviderResp, error)
+	NewAuthURL(ctx context.Context, in *NewAuthURLReq, opts ...grpc.CallOption) (*NewAuthURLResp, error)
+	GetUserInfo(ctx context.Context, in *GetUserInfoReq, opts ...grpc.CallOption) (*GetUserInfoResp, error)
+	GetToken(ctx context.Context, in *GetTokenReq, opts ...grpc.CallOption) (*GetTokenResp, error)
+	// added after the first release of the protocol, older plugins answer
+	// Unimplemented
+	Capabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*CapabilitiesResp, error)
+}
+
+type oauth2PluginV2Client struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOauth2PluginV2Client(cc grpc.ClientConnInterface) Oauth2PluginV2Client {
+	return &oauth2PluginV2Client{cc}
+}
+
+func (c *oauth2PluginV2Client) Init(ctx context.Context, in *InitReq, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Oauth2PluginV2_Init_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oauth2PluginV2Client) Provider(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ProviderResp, error) {
+	out := new(ProviderResp)
+	err := c.cc.Invoke(ctx, Oauth2PluginV2_Provider_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oauth2PluginV2Client) NewAuthURL(ctx context.Context, in *NewAuthURLReq, opts ...grpc.CallOption) (*NewAuthURLResp, error) {
+	out := new(NewAuthURLResp)
+	err := c.cc.Invoke(ctx, Oauth2PluginV2_NewAuthURL_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oauth2PluginV2Client) GetUserInfo(ctx context.Context, in *GetUserInfoReq, opts ...grpc.CallOption) (*GetUserInfoResp, error) {
+	out := new(GetUserInfoResp)
+	err := c.cc.Invoke(ctx, Oauth2PluginV2_GetUserInfo_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oauth2PluginV2Client) GetToken(ctx context.Context, in *GetTokenReq, opts ...grpc.CallOption) (*GetTokenResp, error) {
+	out := new(GetTokenResp)
+	err := c.cc.Invoke(ctx, Oauth2PluginV2_GetToken_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oauth2PluginV2Client) Capabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*CapabilitiesResp, error) {
+	out := new(CapabilitiesResp)
+	err := c.cc.Invoke(ctx, Oauth2PluginV2_Capabilities_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Oauth2PluginV2Server is the server API for Oauth2PluginV2 service.
+// All implementations must embed UnimplementedOauth2PluginV2Server
+// for forward compatibility
+type Oauth2PluginV2Server interface {
+	Init(context.Context, *InitReq) (*Empty, error)
+	Provider(context.Context, *Empty) (*ProviderResp, error)
+	NewAuthURL(context.Context, *NewAuthURLReq) (*NewAuthURLResp, error)
+	GetUserInfo(context.Context, *GetUserInfoReq) (*GetUserInfoResp, error)
+	GetToken(context.Context, *GetTokenReq) (*GetTokenResp, error)
+	// added after the first release of the protocol, older plugins answer
+	// Unimplemented
+	Capabilities(context.Context, *Empty) (*CapabilitiesResp, error)
+	mustEmbedUnimplementedOauth2PluginV2Server()
+}
+
+// UnimplementedOauth2PluginV2Server must be embedded to have forward compatible implementations.
+type UnimplementedOauth2PluginV2Server struct {
+}
+
+func (UnimplementedOauth2PluginV2Server) Init(context.Context, *InitReq) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Init not implemented")
+}
+func (UnimplementedOauth2PluginV2Server) Provider(context.Context, *Empty) (*ProviderResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Provider not implemented")
+}
+func (UnimplementedOauth2PluginV2Server) NewAuthURL(context.Context, *NewAuthURLReq) (*NewAuthURLResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewAuthURL not implemented")
+}
+func (UnimplementedOauth2PluginV2Server) GetUserInfo(context.Context, *GetUserInfoReq) (*GetUserInfoResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserInfo not implemented")
+}
+func (UnimplementedOauth2PluginV2Server) GetToken(context.Context, *GetTokenReq) (*GetTokenResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetToken not implemented")
+}
+func (UnimplementedOauth2PluginV2Server) Capabilities(context.Context, *Empty) (*CapabilitiesResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Capabilities not implemented")
+}
+func (UnimplementedOauth2PluginV2Server) mustEmbedUnimplementedOauth2PluginV2Server() {}
+
+// UnsafeOauth2PluginV2Server may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to Oauth2PluginV2Server will
+// result in compilation errors.
+type UnsafeOauth2PluginV2Server interface {
+	mustEmbedUnimplementedOauth2PluginV2Server()
+}
+
+func RegisterOauth2PluginV2Server(s grpc.ServiceRegistrar, srv Oauth2PluginV2Server) {
+	s.RegisterService(&Oauth2PluginV2_ServiceDesc, srv)
+}
+
+func _Oauth2PluginV2_Init_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Oauth2PluginV2Server).Init(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Oauth2PluginV2_Init_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Oauth2PluginV2Server).Init(ctx, req.(*InitReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Oauth2PluginV2_Provider_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Oauth2PluginV2Server).Provider(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Oauth2PluginV2_Provider_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Oauth2PluginV2Server).Provider(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Oauth2PluginV2_NewAuthURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewAuthURLReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Oauth2PluginV2Server).NewAuthURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Oauth2PluginV2_NewAuthURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Oauth2PluginV2Server).NewAuthURL(ctx, req.(*NewAuthURLReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Oauth2PluginV2_GetUserInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserInfoReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Oauth2PluginV2Server).GetUserInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Oauth2PluginV2_GetUserInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Oauth2PluginV2Server).GetUserInfo(ctx, req.(*GetUserInfoReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Oauth2PluginV2_GetToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTokenReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Oauth2PluginV2Server).GetToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Oauth2PluginV2_GetToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Oauth2PluginV2Server).GetToken(ctx, req.(*GetTokenReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Oauth2PluginV2_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Oauth2PluginV2Server).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Oauth2PluginV2_Capabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Oauth2PluginV2Server).Capabilities(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Oauth2PluginV2_ServiceDesc is the grpc.ServiceDesc for Oauth2PluginV2 service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Oauth2PluginV2_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.Oauth2PluginV2",
+	HandlerType: (*Oauth2PluginV2Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Init",
+			Handler:    _Oauth2PluginV2_Init_Handler,
+		},
+		{
+			MethodName: "Provider",
+			Handler:    _Oauth2PluginV2_Provider_Handler,
+		},
+		{
+			MethodName: "NewAuthURL",
+			Handler:    _Oauth2PluginV2_NewAuthURL_Handler,
+		},
+		{
+			MethodName: "GetUserInfo",
+			Handler:    _Oauth2PluginV2_GetUserInfo_Handler,
+		},
+		{
+			MethodName: "GetToken",
+			Handler:    _Oauth2PluginV2_GetToken_Handler,
+		},
+		{
+			MethodName: "Capabilities",
+			Handler:    _Oauth2PluginV2_Capabilities_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/provider/plugin.proto",
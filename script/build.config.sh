@@ -52,6 +52,21 @@ function initDepPlatforms() {
     addAllowedPlatforms "${GOHOSTOS}/${GOHOSTARCH}"
 }
 
+# precompressWeb writes .br and .gz variants next to the text assets of the
+# web, the server sends them to clients accepting the encoding
+function precompressWeb() {
+    local dir="$1"
+    local file
+    while IFS= read -r -d '' file; do
+        if command -v brotli >/dev/null 2>&1; then
+            brotli -f -q 11 -o "${file}.br" "${file}"
+        fi
+        if command -v gzip >/dev/null 2>&1; then
+            gzip -f -k -9 -n "${file}"
+        fi
+    done < <(find "${dir}" -type f \( -name '*.html' -o -name '*.js' -o -name '*.mjs' -o -name '*.css' -o -name '*.svg' -o -name '*.json' -o -name '*.wasm' -o -name '*.txt' \) -print0)
+}
+
 function initDep() {
     setDefault "VERSION" "dev"
     VERSION="$(echo "$VERSION" | sed 's/ //g' | sed 's/"//g' | sed 's/\n//g')"
@@ -79,6 +94,7 @@ function initDep() {
         echo -e "${COLOR_LIGHT_BLUE}Web repository:${COLOR_RESET} ${COLOR_LIGHT_CYAN}${WEB_REPO}${COLOR_RESET}"
         echo -e "${COLOR_LIGHT_BLUE}Web version:${COLOR_RESET} ${COLOR_LIGHT_CYAN}${WEB_VERSION}${COLOR_RESET}"
         downloadAndUnzip "https://github.com/${WEB_REPO}/releases/download/${WEB_VERSION}/dist.tar.gz" "${source_dir}/public/dist"
+        precompressWeb "${source_dir}/public/dist"
     fi
 
     addTags "jsoniter"
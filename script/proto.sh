@@ -1,3 +1,4 @@
 #!/bin/bash
 protoc --go_out=./proto/message ./proto/message/*.proto
 protoc --go_out=./proto/provider --go-grpc_out=./proto/provider ./proto/provider/*.proto
+protoc --go_out=./proto/vendor --go-grpc_out=./proto/vendor ./proto/vendor/*.proto
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+func UserExport(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	export, err := exportUser(user)
+	if err != nil {
+		log.Errorf("export user failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="synctv-export-%s.json"`, user.ID))
+	ctx.JSON(http.StatusOK, export)
+}
+
+func exportUser(user *op.User) (*model.UserExport, error) {
+	export := &model.UserExport{
+		ExportedAt: time.Now().UnixMilli(),
+		Profile: &model.UserInfoResp{
+			ID:               user.ID,
+			Username:         user.Username,
+			Role:             user.Role,
+			CreatedAt:        user.CreatedAt.UnixMilli(),
+			Email:            user.Email.String(),
+			MaxContentRating: user.MaxContentRating,
+		},
+	}
+
+	providers, err := db.GetBindProviders(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Providers = make([]string, len(providers))
+	for i, p := range providers {
+		export.Providers[i] = p.Provider
+	}
+
+	rooms, err := db.GetAllRoomsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Rooms = make([]*model.UserExportRoom, len(rooms))
+	for i, r := range rooms {
+		export.Rooms[i] = &model.UserExportRoom{
+			ID:        r.ID,
+			Name:      r.Name,
+			CreatedAt: r.CreatedAt.UnixMilli(),
+		}
+	}
+
+	members, err := db.GetRoomMembersByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Memberships = make([]*model.UserExportMembership, len(members))
+	for i, m := range members {
+		export.Memberships[i] = &model.UserExportMembership{
+			RoomID:   m.RoomID,
+			Role:     m.Role.String(),
+			Status:   m.Status.String(),
+			JoinedAt: m.CreatedAt.UnixMilli(),
+		}
+	}
+
+	if export.FollowedRooms, err = db.GetFollowedRoomIDs(user.ID); err != nil {
+		return nil, err
+	}
+
+	movies, err := db.GetMoviesByCreator(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Movies = make([]*model.UserExportMovie, len(movies))
+	for i, m := range movies {
+		export.Movies[i] = &model.UserExportMovie{
+			ID:        m.ID,
+			RoomID:    m.RoomID,
+			Name:      m.MovieBase.Name,
+			URL:       m.MovieBase.Url,
+			CreatedAt: m.CreatedAt.UnixMilli(),
+		}
+	}
+
+	comments, err := db.GetMovieCommentsByCreator(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Comments = make([]*model.UserExportComment, len(comments))
+	for i, c := range comments {
+		export.Comments[i] = &model.UserExportComment{
+			ID:        c.ID,
+			RoomID:    c.RoomID,
+			MovieID:   c.MovieID,
+			Content:   c.Content,
+			CreatedAt: c.CreatedAt.UnixMilli(),
+		}
+	}
+
+	messages, err := db.GetPinnedChatMessagesBySender(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.ChatMessages = make([]*model.UserExportChatMessage, len(messages))
+	for i, m := range messages {
+		export.ChatMessages[i] = &model.UserExportChatMessage{
+			RoomID:  m.RoomID,
+			Message: m.Message,
+			SentAt:  m.SentAt.UnixMilli(),
+		}
+	}
+
+	files, err := db.GetUserMediaFiles(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.MediaFiles = make([]*model.UserExportMediaFile, len(files))
+	for i, f := range files {
+		export.MediaFiles[i] = &model.UserExportMediaFile{
+			ID:         f.ID,
+			Name:       f.Name,
+			Size:       f.Size,
+			UploadedAt: f.CreatedAt.UnixMilli(),
+		}
+	}
+
+	if export.Stats, err = db.GetOrCreateUserStats(user.ID); err != nil {
+		return nil, err
+	}
+	achievements, err := db.GetUserAchievements(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Achievements = make([]*model.UserExportAchievement, len(achievements))
+	for i, a := range achievements {
+		export.Achievements[i] = &model.UserExportAchievement{
+			Achievement: a.Achievement,
+			UnlockedAt:  a.CreatedAt.UnixMilli(),
+		}
+	}
+
+	return export, nil
+}
+
+func UserDeletion(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	d, err := db.GetUserDeletion(user.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("deletion request")) {
+			ctx.JSON(http.StatusOK, model.NewApiDataResp(nil))
+			return
+		}
+		log.Errorf("get user deletion failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(model.NewUserDeletionResp(d)))
+}
+
+func RequestUserDeletion(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	d, err := user.RequestDeletion()
+	if err != nil {
+		log.Errorf("request user deletion failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(model.NewUserDeletionResp(d)))
+}
+
+func CancelUserDeletion(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if err := user.CancelDeletion(); err != nil {
+		log.Errorf("cancel user deletion failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func AdminUserDeletions(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("failed to get page and max: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	total, err := db.GetUserDeletionsCount()
+	if err != nil {
+		log.Errorf("get user deletions count failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	deletions, err := db.GetUserDeletions(db.OrderByAsc("scheduled_at"), db.Paginate(page, pageSize))
+	if err != nil {
+		log.Errorf("get user deletions failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	list := make([]*model.UserDeletionResp, len(deletions))
+	for i, d := range deletions {
+		list[i] = model.NewUserDeletionResp(d)
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total": total,
+		"list":  list,
+	}))
+}
+
+// AdminEraseUser erases the account right away, without the grace period
+func AdminEraseUser(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.UserIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	u, err := op.LoadOrInitUserByID(req.ID)
+	if err != nil {
+		log.WithError(err).Error("load or init user by id error")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if u.Value().ID == user.ID {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("cannot erase yourself"))
+		return
+	}
+	if u.Value().IsRoot() {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("cannot erase root"))
+		return
+	}
+	if u.Value().IsAdmin() && !user.IsRoot() {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("cannot erase admin"))
+		return
+	}
+
+	if err := op.EraseUser(req.ID); err != nil {
+		log.WithError(err).Error("erase user error")
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func AdminCancelUserDeletion(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.UserIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := db.DeleteUserDeletion(req.ID); err != nil {
+		log.Errorf("cancel user deletion failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
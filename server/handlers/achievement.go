@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func UserAchievements(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	stats, err := db.GetOrCreateUserStats(user.ID)
+	if err != nil {
+		log.Errorf("get user stats failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	// a broken streak is only reset when the next day is recorded
+	stats.Streak = stats.CurrentStreak(time.Now())
+
+	unlocked, err := db.GetUserAchievements(user.ID)
+	if err != nil {
+		log.Errorf("get user achievements failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	unlockedAt := make(map[dbModel.Achievement]time.Time, len(unlocked))
+	for _, a := range unlocked {
+		unlockedAt[a.Achievement] = a.CreatedAt
+	}
+
+	resp := &model.UserAchievementsResp{
+		Enabled:      settings.EnableAchievements.Get(),
+		Stats:        stats,
+		Achievements: make([]*model.AchievementResp, len(dbModel.Achievements)),
+	}
+	for i, info := range dbModel.Achievements {
+		resp.Achievements[i] = &model.AchievementResp{AchievementInfo: info}
+		if t, ok := unlockedAt[info.ID]; ok {
+			resp.Achievements[i].Unlocked = true
+			resp.Achievements[i].UnlockedAt = t.UnixMilli()
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
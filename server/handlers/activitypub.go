@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/activitypub"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+const maxInboxBodySize = 1024 * 1024
+
+func writeActivityJSON(ctx *gin.Context, contentType string, o activitypub.Object) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	ctx.Data(http.StatusOK, contentType+"; charset=utf-8", b)
+}
+
+func writeActivity(ctx *gin.Context, o activitypub.Object) {
+	writeActivityJSON(ctx, activitypub.ContentType, o)
+}
+
+func activityPubEnabled(ctx *gin.Context) bool {
+	if !activitypub.Enabled() {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(activitypub.ErrDisabled))
+		return false
+	}
+	return true
+}
+
+func WebFinger(ctx *gin.Context) {
+	if !activityPubEnabled(ctx) {
+		return
+	}
+	jrd, ok := activitypub.WebFinger(ctx.Query("resource"))
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp("resource not found"))
+		return
+	}
+	ctx.Header("Access-Control-Allow-Origin", "*")
+	writeActivityJSON(ctx, "application/jrd+json", jrd)
+}
+
+func ActivityPubActor(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+	if !activityPubEnabled(ctx) {
+		return
+	}
+	actor, err := activitypub.Actor()
+	if err != nil {
+		log.Errorf("activitypub actor error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	writeActivity(ctx, actor)
+}
+
+func ActivityPubOutbox(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+	if !activityPubEnabled(ctx) {
+		return
+	}
+	outbox, err := activitypub.Outbox()
+	if err != nil {
+		log.Errorf("activitypub outbox error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	writeActivity(ctx, outbox)
+}
+
+func ActivityPubFollowers(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+	if !activityPubEnabled(ctx) {
+		return
+	}
+	followers, err := activitypub.Followers()
+	if err != nil {
+		log.Errorf("activitypub followers error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	writeActivity(ctx, followers)
+}
+
+func ActivityPubNote(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+	if !activityPubEnabled(ctx) {
+		return
+	}
+	note, err := db.GetActivityPubNote(ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("note")) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+		log.Errorf("activitypub note error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	if !activitypub.IsActivityPubContentType(ctx.GetHeader("Accept")) {
+		ctx.Redirect(http.StatusFound, activitypub.RoomURL(note.RoomID))
+		return
+	}
+	writeActivity(ctx, activitypub.NoteObject(note))
+}
+
+func ActivityPubInbox(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+	if !activityPubEnabled(ctx) {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxInboxBodySize))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	actor, err := activitypub.Verify(ctx, ctx.Request, body)
+	if err != nil {
+		log.Warnf("activitypub inbox: verify signature error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
+		return
+	}
+	if err := activitypub.HandleInbox(ctx, actor, body); err != nil {
+		log.Warnf("activitypub inbox: handle activity error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
@@ -14,9 +14,13 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/email"
+	"github.com/synctv-org/synctv/internal/janitor"
+	"github.com/synctv-org/synctv/internal/jobs"
 	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/roomlog"
 	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/transcode"
 	"github.com/synctv-org/synctv/internal/vendor"
 	"github.com/synctv-org/synctv/server/model"
 	"github.com/synctv-org/synctv/utils"
@@ -107,7 +111,7 @@ func Users(ctx *gin.Context) {
 	// user := ctx.MustGet("user").(*op.UserEntry)
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	page, err := newUserCursorList(ctx, userListSorts)
 	if err != nil {
 		log.WithError(err).Error("get page and max error")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -160,36 +164,18 @@ func Users(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "createdAt":
-		if desc {
-			scopes = append(scopes, db.OrderByCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("username"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("username"))
-		}
-	default:
-		log.Error("not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := db.GetAllUsers(append(scopes, db.Paginate(page, pageSize))...)
+	list, err := db.GetAllUsers(append(scopes, page.scope)...)
 	if err != nil {
 		log.WithError(err).Error("get all users error")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
 		return
 	}
+	list, next := page.next(list)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  genUserListResp(list),
+		"total":      total,
+		"list":       genUserListResp(list),
+		"nextCursor": next,
 	}))
 }
 
@@ -201,6 +187,8 @@ func genUserListResp(us []*dbModel.User) []*model.UserInfoResp {
 			Username:  v.Username,
 			Role:      v.Role,
 			CreatedAt: v.CreatedAt.UnixMilli(),
+
+			MaxContentRating: v.MaxContentRating,
 		}
 	}
 	return resp
@@ -210,7 +198,7 @@ func AdminGetRoomMembers(ctx *gin.Context) {
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	page, err := newUserCursorList(ctx, roomMemberListSorts)
 	if err != nil {
 		log.Errorf("get room users failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -275,36 +263,18 @@ func AdminGetRoomMembers(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "join":
-		if desc {
-			scopes = append(scopes, db.OrderByUsersCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByUsersCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("username"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("username"))
-		}
-	default:
-		log.Errorf("get room users failed: not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := db.GetAllUsers(append(scopes, db.Paginate(page, pageSize))...)
+	list, err := db.GetAllUsers(append(scopes, page.scope)...)
 	if err != nil {
 		log.Errorf("get room users failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
+	list, next := page.next(list)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  genRoomMemberListResp(list, room),
+		"total":      total,
+		"list":       genRoomMemberListResp(list, room),
+		"nextCursor": next,
 	}))
 }
 
@@ -326,6 +296,7 @@ func genRoomMemberListResp(us []*dbModel.User, room *op.Room) []*model.RoomMembe
 			Permissions:      permissions,
 			AdminPermissions: v.RoomMembers[0].AdminPermissions,
 		}
+		setTrialResp(resp[i], v.RoomMembers[0])
 	}
 	return resp
 }
@@ -445,7 +416,7 @@ func Rooms(ctx *gin.Context) {
 	// user := ctx.MustGet("user").(*op.UserEntry)
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	page, err := newRoomCursorList(ctx, "desc")
 	if err != nil {
 		log.WithError(err).Error("get page and max error")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -498,36 +469,18 @@ func Rooms(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "createdAt":
-		if desc {
-			scopes = append(scopes, db.OrderByCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("name"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("name"))
-		}
-	default:
-		log.Error("not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := genRoomListResp(append(scopes, db.Paginate(page, pageSize))...)
+	rooms, err := db.GetAllRooms(append(scopes, page.scope)...)
 	if err != nil {
 		log.WithError(err).Error("gen room list resp error")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
 		return
 	}
+	rooms, next := page.next(rooms)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  list,
+		"total":      total,
+		"list":       newRoomListResp(rooms),
+		"nextCursor": next,
 	}))
 }
 
@@ -540,7 +493,7 @@ func GetUserRooms(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("user id error"))
 		return
 	}
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	page, err := newRoomCursorList(ctx, "desc")
 	if err != nil {
 		log.WithError(err).Error("get page and max error")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -579,37 +532,19 @@ func GetUserRooms(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "createdAt":
-		if desc {
-			scopes = append(scopes, db.OrderByCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("name"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("name"))
-		}
-	default:
-		log.Error("not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := genRoomListResp(append(scopes, db.Paginate(page, pageSize))...)
+	rooms, err := db.GetAllRooms(append(scopes, page.scope)...)
 	if err != nil {
 		log.WithError(err).Error("gen room list resp error")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
 		return
 
 	}
+	rooms, next := page.next(rooms)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  list,
+		"total":      total,
+		"list":       newRoomListResp(rooms),
+		"nextCursor": next,
 	}))
 }
 
@@ -917,7 +852,39 @@ func AdminUsername(ctx *gin.Context) {
 
 	if err := u.Value().SetUsername(req.Username); err != nil {
 		log.WithError(err).Error("set username error")
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorStringResp(err.Error()))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func AdminUserContentRating(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.AdminContentRatingReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	u, err := op.LoadOrInitUserByID(req.ID)
+	if err != nil {
+		log.WithError(err).Error("load or init user by id error")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("user not found"))
+		return
+	}
+
+	if u.Value().ID != user.ID && u.Value().IsAdmin() && !user.IsRoot() {
+		log.Error("cannot change admin content rating")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("cannot change admin content rating"))
+		return
+	}
+
+	if err := u.Value().SetMaxContentRating(req.Rating); err != nil {
+		log.WithError(err).Error("set max content rating error")
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
 
@@ -1172,3 +1139,217 @@ func SendTestEmail(ctx *gin.Context) {
 
 	ctx.Status(http.StatusNoContent)
 }
+
+// AdminJanitorReport reports the reclaimable artifacts without removing them
+func AdminJanitorReport(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	report, err := janitor.Run(ctx, true)
+	if err != nil {
+		log.Errorf("failed to scan janitor artifacts: %v", err)
+		if errors.Is(err, janitor.ErrRunning) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"report": report,
+		"last":   janitor.LastReport(),
+	}))
+}
+
+func AdminRunJanitor(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	report, err := janitor.Run(ctx, false)
+	if err != nil {
+		log.Errorf("failed to run janitor: %v", err)
+		if errors.Is(err, janitor.ErrRunning) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(report))
+}
+
+// AdminStartRoomTrace starts recording the sync state changes of a loaded room
+func AdminStartRoomTrace(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.RoomIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	room, err := op.LoadOrInitRoomByID(req.Id)
+	if err != nil {
+		log.WithError(err).Error("get room by id error")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	room.Value().StartTrace(op.MaxTraceEvents)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// AdminStopRoomTrace stops the recording and returns the trace,
+// it can be replayed with op.Simulation
+func AdminStopRoomTrace(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.RoomIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	room, err := op.LoadRoomByID(req.Id)
+	if err != nil {
+		log.WithError(err).Error("get room by id error")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	trace := room.Value().StopTrace()
+	if trace == nil {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp("room is not recording a trace"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(trace))
+}
+
+// AdminRoomLogs lists the rooms with detailed logging enabled
+func AdminRoomLogs(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(roomlog.States()))
+}
+
+// AdminRoomLogEntries returns the ring of a room logging to it
+func AdminRoomLogEntries(ctx *gin.Context) {
+	entries, err := roomlog.Entries(ctx.Query("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(entries))
+}
+
+// AdminEnableRoomLog starts detailed logging of a room, or switches its sink
+func AdminEnableRoomLog(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.AdminRoomLogReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if _, err := op.LoadOrInitRoomByID(req.ID); err != nil {
+		log.WithError(err).Error("get room by id error")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	sink := roomlog.SinkRing
+	if req.Sink != "" {
+		sink = roomlog.Sink(req.Sink)
+	}
+	if err := roomlog.Enable(req.ID, sink); err != nil {
+		log.WithError(err).Error("enable room log error")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func AdminDisableRoomLog(ctx *gin.Context) {
+	req := model.RoomIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	roomlog.Disable(req.Id)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// AdminLiveStatus reports the shared live pulls and audio transcoders
+func AdminLiveStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"sources":          op.LiveSourceCount(),
+		"audioTranscoders": transcode.LiveAudio.Running(),
+	}))
+}
+
+// AdminJobs lists the background jobs, filtered by the status and kind queries
+func AdminJobs(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	scopes := []func(db *gorm.DB) *gorm.DB{}
+	switch status := dbModel.JobStatus(ctx.Query("status")); status {
+	case "":
+	case dbModel.JobStatusPending, dbModel.JobStatusRunning, dbModel.JobStatusSucceeded, dbModel.JobStatusFailed:
+		scopes = append(scopes, db.WhereEqual("status", status))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support status"))
+		return
+	}
+	if kind := ctx.Query("kind"); kind != "" {
+		scopes = append(scopes, db.WhereEqual("kind", kind))
+	}
+
+	total, err := db.GetJobsCount(scopes...)
+	if err != nil {
+		log.Errorf("get jobs failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	list, err := db.GetJobs(append(scopes, db.OrderByCreatedAtDesc, db.Paginate(page, pageSize))...)
+	if err != nil {
+		log.Errorf("get jobs failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total":   total,
+		"list":    list,
+		"running": jobs.Running(),
+	}))
+}
+
+// AdminRetryJob queues a failed job again
+func AdminRetryJob(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.IdReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := jobs.Retry(req.Id); err != nil {
+		log.Errorf("retry job failed: %v", err)
+		if errors.Is(err, db.ErrNotFound("failed job")) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
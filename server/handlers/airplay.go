@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/synccache"
+	uhc "github.com/zijiren233/go-uhc"
+	"github.com/zijiren233/livelib/protocol/hls"
+)
+
+// airPlaySegmentAlign is the byte alignment of segments for each wrappable type,
+// mpeg-ts must be cut at packet boundaries, packed audio resyncs on frame headers
+var airPlaySegmentAlign = map[string]int64{
+	"ts":   188,
+	"m2ts": 188,
+	"mts":  188,
+	"aac":  1,
+	"mp3":  1,
+}
+
+// used when the client does not tell the duration of the source
+const airPlayDefaultSegmentDuration = 10.0
+
+var airPlaySizeCache = synccache.NewSyncCache[string, int64](time.Minute * 5)
+
+func airPlayMovieType(movie *dbModel.Movie) string {
+	if movie.MovieBase.Type != "" {
+		return strings.ToLower(movie.MovieBase.Type)
+	}
+	return utils.GetUrlExtension(movie.MovieBase.Url)
+}
+
+// airPlayWrappable reports whether the movie is a proxied direct source that can be
+// served to airplay targets as a byte range hls playlist
+func airPlayWrappable(movie *dbModel.Movie) bool {
+	if !settings.AirPlayHlsWrap.Get() || !settings.MovieProxy.Get() {
+		return false
+	}
+	// only sources the creator let go through the server
+	if !movie.MovieBase.Proxy ||
+		movie.MovieBase.VendorInfo.Vendor != "" ||
+		movie.MovieBase.IsFolder ||
+		movie.MovieBase.Live ||
+		movie.MovieBase.RtmpSource ||
+		movie.MovieBase.Url == "" ||
+		isMediaURL(movie.MovieBase.Url) {
+		return false
+	}
+	_, ok := airPlaySegmentAlign[airPlayMovieType(movie)]
+	return ok
+}
+
+func airPlaySourceURL(movie *dbModel.Movie, token string) string {
+	return fmt.Sprintf("/api/movie/airplay/%s/index.m3u8?token=%s", movie.ID, token)
+}
+
+// sourceSize returns the content length of the source by requesting the first byte
+func sourceSize(ctx context.Context, u string, headers http.Header) (int64, error) {
+	if !settings.AllowProxyToLocal.Get() {
+		if l, err := utils.ParseURLIsLocalIP(u); err != nil {
+			return 0, fmt.Errorf("check url is local ip error: %w", err)
+		} else if l {
+			return 0, errors.New("not allow proxy to local")
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", utils.UA)
+	}
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// bytes 0-0/12345
+		_, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/")
+		if !ok || total == "*" {
+			return 0, errors.New("source does not report its size")
+		}
+		return strconv.ParseInt(total, 10, 64)
+	case http.StatusOK:
+		return 0, errors.New("source does not support byte ranges")
+	default:
+		return 0, fmt.Errorf("probe source status: %s", resp.Status)
+	}
+}
+
+func genAirPlayPlaylist(size, segmentSize, align int64, duration float64, mediaURL string) string {
+	segmentSize -= segmentSize % align
+	count := (size + segmentSize - 1) / segmentSize
+
+	durations := make([]float64, count)
+	var target float64
+	for i := range durations {
+		length := min(segmentSize, size-int64(i)*segmentSize)
+		if duration > 0 {
+			durations[i] = duration * float64(length) / float64(size)
+		} else {
+			durations[i] = airPlayDefaultSegmentDuration * float64(length) / float64(segmentSize)
+		}
+		target = max(target, durations[i])
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-MEDIA-SEQUENCE:0\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(target)))
+	for i, d := range durations {
+		offset := int64(i) * segmentSize
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n#EXT-X-BYTERANGE:%d@%d\n%s\n", d, min(segmentSize, size-offset), offset, mediaURL)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
+
+func loadAirPlayMovie(ctx *gin.Context) (*op.Movie, bool) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !settings.AirPlayHlsWrap.Get() || !settings.MovieProxy.Get() {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("airplay hls wrap is not enabled"))
+		return nil, false
+	}
+
+	m, err := room.GetMovieByID(ctx.Param("movieId"))
+	if err != nil {
+		log.Errorf("get movie by id error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return nil, false
+	}
+	if !checkWatchMovie(ctx, user, room, m.Movie) {
+		return nil, false
+	}
+	if !airPlayWrappable(m.Movie) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("movie can not be wrapped for airplay"))
+		return nil, false
+	}
+	return m, true
+}
+
+// AirPlayPlaylist serves a single-rendition hls playlist whose segments are
+// byte ranges of the proxied source, optional query duration is the source duration in seconds
+func AirPlayPlaylist(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	m, ok := loadAirPlayMovie(ctx)
+	if !ok {
+		return
+	}
+
+	var duration float64
+	if d := ctx.Query("duration"); d != "" {
+		var err error
+		duration, err = strconv.ParseFloat(d, 64)
+		if err != nil || duration < 0 || math.IsInf(duration, 0) || math.IsNaN(duration) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid duration"))
+			return
+		}
+	}
+
+	var size int64
+	key := m.ID + m.Movie.MovieBase.Url
+	if e, ok := airPlaySizeCache.Load(key); ok {
+		size = e.Value()
+	} else {
+		var err error
+		var header http.Header
+		header, err = m.SourceHeader(ctx, nil)
+		if err == nil {
+			size, err = sourceSize(ctx, m.Movie.MovieBase.Url, header)
+		}
+		if err != nil {
+			log.Errorf("probe airplay source size error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+			return
+		}
+		airPlaySizeCache.Store(key, size, time.Minute*10)
+	}
+	if size <= 0 {
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorStringResp("source is empty"))
+		return
+	}
+
+	mediaURL := fmt.Sprintf("media?%s", url.Values{"token": []string{ctx.MustGet("token").(string)}}.Encode())
+	playlist := genAirPlayPlaylist(
+		size,
+		settings.AirPlayHlsSegmentSize.Get()*1024,
+		airPlaySegmentAlign[airPlayMovieType(m.Movie)],
+		duration,
+		mediaURL,
+	)
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Data(http.StatusOK, hls.M3U8ContentType, []byte(playlist))
+}
+
+// AirPlayMedia proxies the byte ranges referenced by the airplay playlist
+func AirPlayMedia(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	m, ok := loadAirPlayMovie(ctx)
+	if !ok {
+		return
+	}
+
+	header, err := m.SourceHeader(ctx, ctx.Request.Header)
+	if err != nil {
+		log.Errorf("airplay media header error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+		return
+	}
+	err = proxyURL(ctx, m.Movie.MovieBase.Url, header)
+	if err != nil {
+		log.Errorf("proxy airplay media error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func AlistWatches(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	watches, err := db.GetAlistWatchesByRoomID(room.ID)
+	if err != nil {
+		log.Errorf("get alist watches error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.AlistWatchResp, len(watches))
+	for i, w := range watches {
+		resp[i] = &model.AlistWatchResp{
+			ID:          w.ID,
+			Path:        w.Path,
+			ParentID:    w.ParentID.String(),
+			Include:     w.Include,
+			Exclude:     w.Exclude,
+			Creator:     op.GetUserName(w.CreatorID),
+			CreatorID:   w.CreatorID,
+			CreatedAt:   w.CreatedAt.UnixMilli(),
+			LastCheckAt: w.LastCheckAt.UnixMilli(),
+			LastError:   w.LastError,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func AddAlistWatch(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.AddAlistWatchReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("add alist watch error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	watch := &dbModel.AlistWatch{
+		Path:     req.Path,
+		Password: req.Password,
+		Backend:  req.Backend,
+		ParentID: dbModel.EmptyNullString(req.ParentID),
+		Include:  req.Include,
+		Exclude:  req.Exclude,
+	}
+	if err := user.AddAlistWatch(ctx, room, watch, req.QueueExisting); err != nil {
+		log.Errorf("add alist watch error: %v", err)
+		if errors.Is(err, dbModel.ErrNoPermission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"id": watch.ID,
+	}))
+}
+
+func DeleteAlistWatch(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("delete alist watch error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteAlistWatch(room, req.Id); err != nil {
+		log.Errorf("delete alist watch error: %v", err)
+		switch {
+		case errors.Is(err, dbModel.ErrNoPermission):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		case errors.Is(err, db.ErrNotFound("alist watch")):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func ArrWebhooks(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	hooks, err := db.GetArrWebhooksByRoomID(room.ID)
+	if err != nil {
+		log.Errorf("get arr webhooks error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.ArrWebhookResp, len(hooks))
+	for i, h := range hooks {
+		resp[i] = &model.ArrWebhookResp{
+			ID:          h.ID,
+			SourcePath:  h.SourcePath,
+			TargetPath:  h.TargetPath,
+			ParentID:    h.ParentID.String(),
+			Creator:     op.GetUserName(h.CreatorID),
+			CreatorID:   h.CreatorID,
+			CreatedAt:   h.CreatedAt.UnixMilli(),
+			LastEventAt: h.LastEventAt.UnixMilli(),
+			LastError:   h.LastError,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+// AddArrWebhook returns the webhook url with its token, the token is not shown again
+func AddArrWebhook(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.AddArrWebhookReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("add arr webhook error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	hook := &dbModel.ArrWebhook{
+		SourcePath: req.SourcePath,
+		TargetPath: req.TargetPath,
+		Password:   req.Password,
+		Backend:    req.Backend,
+		ParentID:   dbModel.EmptyNullString(req.ParentID),
+	}
+	token, err := user.AddArrWebhook(ctx, room, hook)
+	if err != nil {
+		log.Errorf("add arr webhook error: %v", err)
+		if errors.Is(err, dbModel.ErrNoPermission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"id":    hook.ID,
+		"token": token,
+		"url":   "/api/webhook/arr/" + hook.ID + "?token=" + token,
+	}))
+}
+
+func DeleteArrWebhook(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("delete arr webhook error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteArrWebhook(room, req.Id); err != nil {
+		log.Errorf("delete arr webhook error: %v", err)
+		switch {
+		case errors.Is(err, dbModel.ErrNoPermission):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		case errors.Is(err, db.ErrNotFound("webhook")):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ArrWebhook receives the notifications of sonarr and radarr, the token is
+// passed in the query or as the basic auth password
+func ArrWebhook(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	hook, err := db.GetArrWebhook(ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("webhook")) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	token := ctx.Query("token")
+	if token == "" {
+		_, token, _ = ctx.Request.BasicAuth()
+	}
+	if !hook.CheckToken(token) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorStringResp("invalid token"))
+		return
+	}
+
+	var payload model.ArrWebhookPayload
+	if err := model.Decode(ctx, &payload); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	// test notifications and other events are accepted and ignored
+	if !payload.IsImport() {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+	name, file, err := payload.Imported()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	m, err := op.ArrImport(hook, name, file)
+	if err != nil {
+		log.Errorf("arr webhook %s import error: %v", hook.ID, err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"id": m.ID,
+	}))
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/transcode"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/livelib/protocol/httpflv"
+)
+
+// audioOnlyAvailable reports whether the server can extract the audio of the movie,
+// only sources already going through the server are supported
+func audioOnlyAvailable(movie *dbModel.Movie) bool {
+	if !transcode.EnableAudioOnly.Get() || movie.MovieBase.IsFolder {
+		return false
+	}
+	switch {
+	case movie.MovieBase.RtmpSource:
+		return conf.Conf.Server.Rtmp.Enable
+	case movie.MovieBase.Live:
+		return movie.MovieBase.Proxy && settings.LiveProxy.Get()
+	case movie.MovieBase.VendorInfo.Vendor != "":
+		return true
+	default:
+		return movie.MovieBase.Proxy && settings.MovieProxy.Get()
+	}
+}
+
+// loopbackHost is the base url the server reaches itself at, unlike
+// requestHost it never comes from the request
+func loopbackHost() string {
+	scheme := "http"
+	if conf.Conf.Server.Http.CertPath != "" && conf.Conf.Server.Http.KeyPath != "" {
+		scheme = "https"
+	}
+	host := conf.Conf.Server.Http.Listen
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return (&url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(int(conf.Conf.Server.Http.Port))),
+	}).String()
+}
+
+func audioOnlySourceURL(movie *dbModel.Movie, token string) string {
+	return fmt.Sprintf("/api/movie/audio/%s?token=%s", movie.ID, token)
+}
+
+// AudioOnlyMovie streams the audio of the movie downmixed to stereo aac,
+// optional query start is the position in seconds to start from
+func AudioOnlyMovie(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	m, err := room.GetMovieByID(ctx.Param("movieId"))
+	if err != nil {
+		log.Errorf("get movie by id error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	if !checkWatchMovie(ctx, user, room, m.Movie) {
+		return
+	}
+	if !audioOnlyAvailable(m.Movie) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("audio only is not available for this movie"))
+		return
+	}
+
+	input := &transcode.Input{}
+	if s := ctx.Query("start"); s != "" && !m.Movie.MovieBase.Live {
+		input.Start, err = strconv.ParseFloat(s, 64)
+		if err != nil || input.Start < 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid start"))
+			return
+		}
+	}
+
+	switch {
+	case m.Movie.MovieBase.Live:
+		channel, err := m.Channel()
+		if err != nil {
+			log.Errorf("get live channel error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+		// viewers of the same live source share one transcoder
+		ctx.Header("Cache-Control", "no-store")
+		ctx.Header("Content-Type", transcode.AudioOnlyContentType)
+		ctx.Status(http.StatusOK)
+		err = transcode.LiveAudio.Join(ctx.Request.Context(), m.LiveKey(), func(tctx context.Context, w io.Writer) error {
+			pr, pw := io.Pipe()
+			defer pr.Close()
+			fw := httpflv.NewHttpFLVWriter(pw)
+			defer fw.Close()
+			if err := channel.AddPlayer(fw); err != nil {
+				return err
+			}
+			go func() {
+				pw.CloseWithError(fw.SendPacket())
+			}()
+			return transcode.AudioOnly(tctx, &transcode.Input{Reader: pr, Format: "flv"}, w)
+		}, ctx.Writer)
+		if err != nil {
+			log.Errorf("audio only error: %v", err)
+		}
+		return
+	case m.Movie.MovieBase.VendorInfo.Vendor != "":
+		movie, err := genMovieInfo(ctx, user, m, ctx.GetHeader("User-Agent"), ctx.MustGet("token").(string))
+		if err != nil {
+			log.Errorf("gen movie info error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		if strings.HasPrefix(movie.Base.Url, "/") {
+			// proxied by ourselves
+			input.URL = loopbackHost() + movie.Base.Url
+		} else {
+			input.URL = movie.Base.Url
+			input.Headers = make(http.Header, len(movie.Base.Headers))
+			for k, v := range movie.Base.Headers {
+				input.Headers.Set(k, v)
+			}
+		}
+	default:
+		if !settings.AllowProxyToLocal.Get() {
+			if l, err := utils.ParseURLIsLocalIP(m.Movie.MovieBase.Url); err != nil || l {
+				if err == nil {
+					err = errors.New("not allow proxy to local")
+				}
+				log.Errorf("audio only error: %v", err)
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+				return
+			}
+		}
+		header, err := m.SourceHeader(ctx, nil)
+		if err != nil {
+			log.Errorf("audio only header error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+			return
+		}
+		input.URL = m.Movie.MovieBase.Url
+		input.Headers = header
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Type", transcode.AudioOnlyContentType)
+	ctx.Status(http.StatusOK)
+	if err := transcode.AudioOnly(ctx, input, ctx.Writer); err != nil {
+		log.Errorf("audio only error: %v", err)
+	}
+}
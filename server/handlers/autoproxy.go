@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// applyAutoProxy decides Proxy of movies in auto mode and records the reason,
+// origin is the site the movie is played on
+func applyAutoProxy(ctx *gin.Context, origin string, movie *dbModel.MovieBase, creatorID string) {
+	if !movie.AutoProxy {
+		movie.ProxyReason = ""
+		return
+	}
+	proxy, reason := decideProxy(ctx, origin, movie, creatorID)
+	enabled := settings.MovieProxy.Get()
+	if movie.Live {
+		enabled = settings.LiveProxy.Get()
+	}
+	if proxy && !enabled {
+		proxy = false
+		reason += ", but the proxy is disabled"
+	}
+	movie.Proxy = proxy
+	movie.ProxyReason = utils.TruncateByRune(reason, 256)
+}
+
+func decideProxy(ctx *gin.Context, origin string, movie *dbModel.MovieBase, creatorID string) (bool, string) {
+	switch {
+	case movie.IsFolder:
+		return false, "folders are not played"
+	case movie.RtmpSource:
+		return false, "the stream is published to synctv"
+	case movie.VendorInfo.Vendor == dbModel.VendorBilibili:
+		return true, "bilibili sources check the referer"
+	case movie.VendorInfo.Vendor == dbModel.VendorDouyin:
+		return true, "douyin and tiktok sources check the referer"
+	case movie.VendorInfo.Vendor != "":
+		return false, fmt.Sprintf("%s serves the source directly", movie.VendorInfo.Vendor)
+	case isMediaURL(movie.Url):
+		return false, "media library files are served by synctv"
+	}
+
+	u, err := url.Parse(movie.Url)
+	if err != nil {
+		return false, fmt.Sprintf("invalid url: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "rtmp":
+		return movie.Live, "browsers can not play rtmp streams"
+	default:
+		return false, fmt.Sprintf("unsupported url scheme: %s", u.Scheme)
+	}
+	if !settings.AllowProxyToLocal.Get() {
+		if l, err := utils.ParseURLIsLocalIP(movie.Url); err != nil || l {
+			return false, "the proxy can not reach local addresses"
+		}
+	}
+	if movie.NeedHeaders() {
+		return true, "the source needs custom headers"
+	}
+	if u.Scheme == "http" && strings.HasPrefix(origin, "https://") {
+		return true, "http source on an https site"
+	}
+
+	header, err := op.SourceHeader(ctx, movie, creatorID, ctx.Request.Header)
+	if err != nil {
+		return false, fmt.Sprintf("resolve headers failed: %v", err)
+	}
+	probe, err := probeSource(ctx, origin, movie.Url, header)
+	if err != nil {
+		if probe == nil ||
+			(probe.StatusCode != http.StatusForbidden && probe.StatusCode != http.StatusUnauthorized) {
+			return false, fmt.Sprintf("probe failed: %v", err)
+		}
+		// retry without origin and referer, like the proxy requests it
+		if _, err2 := probeSource(ctx, "", movie.Url, header); err2 == nil {
+			return true, "the source rejects requests from this site"
+		}
+		return false, fmt.Sprintf("probe failed: %v", err)
+	}
+	container := utils.SniffContainer(probe.Head, probe.ContentType, utils.GetUrlExtension(movie.Url))
+	if container == "" {
+		container = strings.ToLower(movie.Type)
+	}
+	if !probe.Cors && (container == "m3u8" || container == "flv") {
+		return true, fmt.Sprintf("%s needs cors headers the source does not send", container)
+	}
+	return false, "plays directly"
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	uhc "github.com/zijiren233/go-uhc"
+)
+
+const (
+	// max subtitle size fetched for conversion
+	castMaxSubtitleSize = 10 * 1024 * 1024
+)
+
+var castContentTypes = map[string]string{
+	"m3u8": "application/x-mpegURL",
+	"mpd":  "application/dash+xml",
+	"mp4":  "video/mp4",
+	"m4v":  "video/mp4",
+	"webm": "video/webm",
+	"mkv":  "video/x-matroska",
+	"flv":  "video/x-flv",
+	"ts":   "video/mp2t",
+	"mp3":  "audio/mpeg",
+	"m4a":  "audio/mp4",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"ogg":  "audio/ogg",
+}
+
+func castContentType(t string) string {
+	if ct, ok := castContentTypes[strings.ToLower(t)]; ok {
+		return ct
+	}
+	return "video/mp4"
+}
+
+func absoluteURL(host, u string) string {
+	if strings.HasPrefix(u, "/") {
+		return host + u
+	}
+	return u
+}
+
+// castMovie returns the requested movie, or the current movie if id is empty
+func castMovie(user *op.User, room *op.Room, id string) (*op.Movie, error) {
+	if id == "" {
+		id = room.CurrentMovie().ID
+		if id == "" {
+			return nil, op.ErrNoCurrentMovie
+		}
+	} else if id != room.CurrentMovie().ID && !user.HasRoomPermission(room, dbModel.PermissionGetMovieList) {
+		return nil, dbModel.ErrNoPermission
+	}
+	m, err := room.GetMovieByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if lock := user.MovieLock(room, m.Movie); lock != op.MovieUnlocked {
+		return nil, movieLockError(lock)
+	}
+	return m, nil
+}
+
+func castErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission), errors.Is(err, ErrMovieLocked), errors.Is(err, ErrMovieRatingLocked):
+		return http.StatusForbidden
+	case errors.Is(err, op.ErrNoCurrentMovie):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func CastManifest(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	opMovie, err := castMovie(user, room, ctx.Query("id"))
+	if err != nil {
+		log.Errorf("get cast movie failed: %v", err)
+		ctx.AbortWithStatusJSON(castErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+	if opMovie.IsFolder {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("folder can not be casted"))
+		return
+	}
+
+	token := ctx.MustGet("token").(string)
+	movie, err := genMovieInfo(ctx, user, opMovie, ctx.GetHeader("User-Agent"), token)
+	if err != nil {
+		log.Errorf("gen movie info failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	host := requestHost(ctx)
+	resp := &model.CastManifestResp{
+		MovieID:     movie.Id,
+		Title:       movie.Base.Name,
+		URL:         absoluteURL(host, movie.Base.Url),
+		ContentType: castContentType(movie.Base.Type),
+		Live:        movie.Base.Live,
+		Subtitles:   make([]*model.CastSubtitle, 0, len(movie.Base.Subtitles)),
+		NeedHeaders: movie.Base.NeedHeaders(),
+	}
+	for name := range movie.Base.Subtitles {
+		resp.Subtitles = append(resp.Subtitles, &model.CastSubtitle{
+			Name: name,
+			URL: fmt.Sprintf("%s/api/movie/cast/subtitle/%s?%s", host, movie.Id, url.Values{
+				"name":  []string{name},
+				"token": []string{token},
+			}.Encode()),
+		})
+	}
+	if opMovie.ID == room.CurrentMovie().ID {
+		resp.Status = room.Current().Status
+	}
+	resp.Time = time.Now().UnixMilli()
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+// fetchSubtitle fetches the subtitle, own is set for urls of this server
+// which are never checked against local addresses
+func fetchSubtitle(ctx context.Context, u string, headers map[string]string, own bool) ([]byte, error) {
+	if !own && !settings.AllowProxyToLocal.Get() {
+		if l, err := utils.ParseURLIsLocalIP(u); err != nil {
+			return nil, fmt.Errorf("check url is local ip error: %w", err)
+		} else if l {
+			return nil, errors.New("not allow proxy to local")
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", utils.UA)
+	}
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch subtitle status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, castMaxSubtitleSize))
+}
+
+// CastSubtitle serves the movie subtitle as sidecar WebVTT
+func CastSubtitle(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	opMovie, err := castMovie(user, room, ctx.Param("movieId"))
+	if err != nil {
+		log.Errorf("get cast movie failed: %v", err)
+		ctx.AbortWithStatusJSON(castErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	movie, err := genMovieInfo(ctx, user, opMovie, ctx.GetHeader("User-Agent"), ctx.MustGet("token").(string))
+	if err != nil {
+		log.Errorf("gen movie info failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	subtitle, ok := movie.Base.Subtitles[ctx.Query("name")]
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp("subtitle not found"))
+		return
+	}
+
+	var headers map[string]string
+	u := subtitle.URL
+	own := strings.HasPrefix(u, "/")
+	if own {
+		// served by ourselves, e.g. vendor subtitles, never at the host
+		// of the request which the client chooses
+		base := HOST.Get()
+		if base == "" {
+			base = loopbackHost()
+		}
+		u = base + u
+	} else {
+		headers = opMovie.Movie.MovieBase.Headers
+	}
+	data, err := fetchSubtitle(ctx, u, headers, own)
+	if err != nil {
+		log.Errorf("fetch subtitle failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+		return
+	}
+
+	// cast receivers load tracks cross-origin
+	ctx.Header("Access-Control-Allow-Origin", "*")
+	ctx.Data(http.StatusOK, "text/vtt; charset=utf-8", utils.SrtToVtt(data))
+}
+
+// CastStatus receives the status of a cast receiver and returns how to resync it
+func CastStatus(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.CastStatusReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if req.State == "stopped" {
+		room.RemoveCastReceiver(user.ID, req.ReceiverID)
+	} else {
+		room.UpdateCastReceiver(&op.CastReceiver{
+			ID:       req.ReceiverID,
+			UserID:   user.ID,
+			Name:     req.Name,
+			MovieID:  req.MovieID,
+			Position: req.Position,
+			Playing:  req.Playing,
+			State:    req.State,
+		})
+	}
+
+	current := room.Current()
+	resp := &model.CastStatusResp{
+		MovieID: current.Movie.ID,
+		Status:  current.Status,
+		Time:    time.Now().UnixMilli(),
+		Reload:  current.Movie.ID != req.MovieID,
+	}
+	if !resp.Reload && !current.Movie.IsLive {
+		resp.Drift = req.Position - current.Status.Seek
+		tuning := room.SyncTuning()
+		switch drift := math.Abs(resp.Drift); {
+		case drift > tuning.SnapThreshold:
+			resp.Seek = true
+		case drift > tuning.MaxDrift && current.Status.Playing:
+			// slow down when ahead, speed up when behind
+			correction := tuning.CorrectionRate
+			if resp.Drift > 0 {
+				correction = -correction
+			}
+			resp.Rate = current.Status.Rate * (1 + correction)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func CastReceivers(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+
+	receivers := room.CastReceivers()
+	resp := make([]*model.CastReceiverResp, len(receivers))
+	for i, r := range receivers {
+		resp[i] = &model.CastReceiverResp{
+			ID:        r.ID,
+			UserID:    r.UserID,
+			Username:  op.GetUserName(r.UserID),
+			Name:      r.Name,
+			MovieID:   r.MovieID,
+			Position:  r.Position,
+			Playing:   r.Playing,
+			State:     r.State,
+			UpdatedAt: r.UpdatedAt.UnixMilli(),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
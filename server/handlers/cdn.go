@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/roomlog"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/zijiren233/stream"
+)
+
+// in cdn mode proxied media is served at urls without the user token, they
+// are signed instead and only change every half of the signature lifetime,
+// so a cdn caches them once for every user
+
+const defaultCdnSignExpire = 6 * time.Hour
+
+func cdnEnabled() bool {
+	return conf.Conf.Server.Cdn.Enable
+}
+
+var cdnSignExpire = sync.OnceValue(func() time.Duration {
+	d, err := time.ParseDuration(conf.Conf.Server.Cdn.SignExpire)
+	if err != nil || d <= 0 {
+		return defaultCdnSignExpire
+	}
+	return d
+})
+
+func cdnSign(path string, exp int64) string {
+	h := hmac.New(sha256.New, stream.StringToBytes(conf.Conf.Jwt.Secret))
+	h.Write([]byte("cdn " + path + " " + strconv.FormatInt(exp, 10)))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// cdnURL signs the path, the url is the same for all requests in the same
+// half of the signature lifetime
+func cdnURL(path string) string {
+	expire := cdnSignExpire()
+	exp := time.Now().Truncate(expire / 2).Add(expire).Unix()
+	return fmt.Sprintf("%s?exp=%d&sig=%s", path, exp, cdnSign(path, exp))
+}
+
+// cdnSourceVersion identifies the source of a proxied movie, it is part of
+// the proxy url so cdns never serve the old media after the source changed
+func cdnSourceVersion(m *dbModel.MovieBase) string {
+	h := sha256.New()
+	h.Write([]byte(m.Url + "\n" + m.Type))
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		h.Write([]byte("\n" + k + ": " + m.Headers[k]))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func cdnProxyURL(m *dbModel.Movie) string {
+	return cdnURL(fmt.Sprintf("/api/movie/cdn/proxy/%s/%s/%s", m.RoomID, m.ID, cdnSourceVersion(&m.MovieBase)))
+}
+
+func cdnCacheControl(immutable bool) string {
+	if immutable {
+		return fmt.Sprintf("public, max-age=%d, immutable", conf.Conf.Server.Cdn.MaxAge)
+	}
+	return fmt.Sprintf("public, max-age=%d", conf.Conf.Server.Cdn.MaxAge)
+}
+
+// CdnSignatureMiddleware rejects cdn urls with a wrong or expired signature
+func CdnSignatureMiddleware(ctx *gin.Context) {
+	if !cdnEnabled() {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp("cdn mode is not enabled"))
+		return
+	}
+	exp, err := strconv.ParseInt(ctx.Query("exp"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("invalid signature"))
+		return
+	}
+	if time.Now().Unix() > exp {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("signature expired"))
+		return
+	}
+	if !hmac.Equal([]byte(ctx.Query("sig")), []byte(cdnSign(ctx.Request.URL.Path, exp))) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("invalid signature"))
+		return
+	}
+	ctx.Next()
+}
+
+func cdnMovie(ctx *gin.Context, log *logrus.Entry) (*op.Movie, bool) {
+	room, err := op.LoadOrInitRoomByID(ctx.Param("roomId"))
+	if err != nil {
+		log.Errorf("cdn load room error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return nil, false
+	}
+	m, err := room.Value().GetMovieByID(ctx.Param("movieId"))
+	if err != nil {
+		log.Errorf("cdn get movie error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return nil, false
+	}
+	return m, true
+}
+
+// CdnProxyMovie proxies the source of a movie at its signed url, vendor
+// movies are always proxied with the user token. urls of a replaced source
+// are not found, so the new source is never cached under them
+func CdnProxyMovie(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !settings.MovieProxy.Get() {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("movie proxy is not enabled"))
+		return
+	}
+	m, ok := cdnMovie(ctx, log)
+	if !ok {
+		return
+	}
+	if m.Movie.MovieBase.VendorInfo.Vendor != "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support movie proxy"))
+		return
+	}
+	if ctx.Param("version") != cdnSourceVersion(&m.Movie.MovieBase) {
+		ctx.Header("Cache-Control", "no-store")
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp("movie source changed"))
+		return
+	}
+	ctx.Header("Cache-Control", cdnCacheControl(false))
+	proxyMovie(ctx, roomlog.With(log, m.RoomID, roomlog.KindProxy), m)
+}
+
+// CdnServeHlsLive serves a segment of the hls live at its signed url,
+// segment names are never reused so they are immutable
+func CdnServeHlsLive(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	ctx.Header("Cache-Control", "no-store")
+	m, ok := cdnMovie(ctx, log)
+	if !ok {
+		return
+	}
+	serveHlsLiveData(ctx, roomlog.With(log, m.RoomID, roomlog.KindProxy), m, cdnCacheControl(true))
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+	"gorm.io/gorm"
+)
+
+func RoomPinnedChatMessages(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	messages, err := room.PinnedChatMessages()
+	if err != nil {
+		log.Errorf("get pinned chat messages failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.PinnedChatMessageResp, len(messages))
+	for i, m := range messages {
+		resp[i] = &model.PinnedChatMessageResp{
+			ID:         m.ID,
+			SenderID:   m.SenderID,
+			SenderName: m.SenderName,
+			Message:    m.Message,
+			SentAt:     m.SentAt.UnixMilli(),
+			PinnedBy:   m.PinnedBy,
+			PinnedAt:   m.CreatedAt.UnixMilli(),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func chatModerationStatus(err error) int {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		return http.StatusForbidden
+	case errors.Is(err, op.ErrChatMessageNotFound), errors.Is(err, db.ErrNotFound("pinned message")):
+		return http.StatusNotFound
+	case errors.Is(err, op.ErrTooManyPinnedChatMessages):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func RoomAdminDeleteChatMessage(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.ChatMessageIDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode delete chat message req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteRoomChatMessage(room, req.ID); err != nil {
+		log.Errorf("delete chat message failed: %v", err)
+		ctx.AbortWithStatusJSON(chatModerationStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminPurgeChatMessages(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.RoomPurgeChatMessagesReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode purge chat messages req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.PurgeRoomChatMessages(room, req.ID); err != nil {
+		log.Errorf("purge chat messages failed: %v", err)
+		ctx.AbortWithStatusJSON(chatModerationStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminPinChatMessage(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.ChatMessageIDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode pin chat message req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.PinRoomChatMessage(room, req.ID); err != nil {
+		log.Errorf("pin chat message failed: %v", err)
+		ctx.AbortWithStatusJSON(chatModerationStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminUnpinChatMessage(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.ChatMessageIDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode unpin chat message req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.UnpinRoomChatMessage(room, req.ID); err != nil {
+		log.Errorf("unpin chat message failed: %v", err)
+		ctx.AbortWithStatusJSON(chatModerationStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+var roomAuditListSorts = map[string]listSort[*dbModel.RoomAudit]{
+	"createdAt": {
+		column: db.SortColumn{Column: "created_at", Time: true},
+		value:  func(a *dbModel.RoomAudit) any { return a.CreatedAt },
+	},
+}
+
+func RoomAdminAudits(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, err := newCursorList(ctx, roomAuditListSorts, "createdAt", "desc", "id", func(a *dbModel.RoomAudit) string {
+		return a.ID
+	})
+	if err != nil {
+		log.Errorf("get room audits failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	var filters []func(*gorm.DB) *gorm.DB
+	if action := ctx.Query("action"); action != "" {
+		filters = append(filters, db.WhereRoomAuditAction(dbModel.RoomAuditAction(action)))
+	}
+	if userID := ctx.Query("userId"); userID != "" {
+		filters = append(filters, db.WhereRoomAuditUserID(userID))
+	}
+
+	total, err := db.GetRoomAuditsCount(room.ID, filters...)
+	if err != nil {
+		log.Errorf("get room audits failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	audits, err := db.GetRoomAudits(room.ID, append(filters, page.scope)...)
+	if err != nil {
+		log.Errorf("get room audits failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	audits, next := page.next(audits)
+
+	resp := make([]*model.RoomAuditResp, len(audits))
+	for i, a := range audits {
+		resp[i] = &model.RoomAuditResp{
+			ID:        a.ID,
+			UserID:    a.UserID,
+			Username:  op.GetUserName(a.UserID),
+			Action:    a.Action,
+			Target:    a.Target,
+			CreatedAt: a.CreatedAt.UnixMilli(),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total":      total,
+		"list":       resp,
+		"nextCursor": next,
+	}))
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+func genMovieCommentResp(c *dbModel.MovieComment, userID string) *model.MovieCommentResp {
+	resp := &model.MovieCommentResp{
+		ID:        c.ID,
+		MovieID:   c.MovieID,
+		ParentID:  c.ParentID,
+		CreatorID: c.CreatorID,
+		Creator:   op.GetUserName(c.CreatorID),
+		Content:   c.Content,
+		CreatedAt: c.CreatedAt.UnixMilli(),
+		UpdatedAt: c.UpdatedAt.UnixMilli(),
+		Reactions: []*model.CommentReactionResp{},
+	}
+	// reactions are grouped by emoji in the order they were first used
+	reactions := make(map[string]*model.CommentReactionResp)
+	for _, r := range c.Reactions {
+		rr, ok := reactions[r.Emoji]
+		if !ok {
+			rr = &model.CommentReactionResp{Emoji: r.Emoji}
+			reactions[r.Emoji] = rr
+			resp.Reactions = append(resp.Reactions, rr)
+		}
+		rr.Count++
+		if r.UserID == userID {
+			rr.Reacted = true
+		}
+	}
+	if len(c.Replies) != 0 {
+		resp.Replies = make([]*model.MovieCommentResp, len(c.Replies))
+		for i, r := range c.Replies {
+			resp.Replies[i] = genMovieCommentResp(r, userID)
+		}
+	}
+	return resp
+}
+
+func movieCommentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrNotFound("comment")):
+		return http.StatusNotFound
+	case errors.Is(err, op.ErrTooManyReactions):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func MovieComments(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !user.HasRoomPermission(room, dbModel.PermissionGetMovieList) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(dbModel.ErrNoPermission))
+		return
+	}
+
+	id := ctx.Query("id")
+	if len(id) != 32 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(model.ErrId))
+		return
+	}
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("failed to get page and max: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	total, err := db.GetMovieCommentsCount(room.ID, id)
+	if err != nil {
+		log.Errorf("get movie comments count failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	comments, err := db.GetMovieComments(room.ID, id, db.OrderByCreatedAtAsc, db.Paginate(page, pageSize))
+	if err != nil {
+		log.Errorf("get movie comments failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	list := make([]*model.MovieCommentResp, len(comments))
+	for i, c := range comments {
+		list[i] = genMovieCommentResp(c, user.ID)
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total": total,
+		"list":  list,
+	}))
+}
+
+func AddMovieComment(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.AddMovieCommentReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	comment, err := user.AddMovieComment(room, req.MovieID, req.ParentID, req.Content)
+	if err != nil {
+		log.Errorf("add movie comment failed: %v", err)
+		ctx.AbortWithStatusJSON(movieCommentErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(genMovieCommentResp(comment, user.ID)))
+}
+
+func EditMovieComment(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.EditMovieCommentReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.EditMovieComment(room, req.Id, req.Content); err != nil {
+		log.Errorf("edit movie comment failed: %v", err)
+		ctx.AbortWithStatusJSON(movieCommentErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func DeleteMovieComment(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteMovieComment(room, req.Id); err != nil {
+		log.Errorf("delete movie comment failed: %v", err)
+		ctx.AbortWithStatusJSON(movieCommentErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func ReactMovieComment(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.ReactMovieCommentReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.ReactMovieComment(room, req.Id, req.Emoji, req.Remove); err != nil {
+		log.Errorf("react movie comment failed: %v", err)
+		ctx.AbortWithStatusJSON(movieCommentErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+var embedTemplate = template.Must(template.ParseFS(templates, "templates/embed.html"))
+
+const (
+	oEmbedDefaultWidth  = 640
+	oEmbedDefaultHeight = 360
+)
+
+var ErrRoomNotEmbeddable = errors.New("room is not embeddable")
+
+func roomEmbeddable(room *op.Room) bool {
+	return isPublicRoom(room) &&
+		settings.EnableGuest.Get() &&
+		!room.Settings.DisableGuest
+}
+
+func loadEmbeddableRoom(roomID string) (*op.Room, error) {
+	roomE, err := op.LoadOrInitRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+	room := roomE.Value()
+	if !roomEmbeddable(room) {
+		return nil, ErrRoomNotEmbeddable
+	}
+	return room, nil
+}
+
+func EmbedRoom(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	room, err := loadEmbeddableRoom(ctx.Param("roomId"))
+	if err != nil {
+		log.Errorf("load embeddable room failed: %v", err)
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	host := requestHost(ctx)
+	theme := ""
+	if ctx.Query("theme") == "dark" {
+		theme = "dark"
+	}
+
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	err = embedTemplate.Execute(ctx.Writer, map[string]string{
+		"Title":  room.Name,
+		"RoomID": room.ID,
+		"Theme":  theme,
+		"OEmbedURL": fmt.Sprintf("%s/api/oembed?%s", host, url.Values{
+			"url":    []string{fmt.Sprintf("%s/embed/room/%s", host, room.ID)},
+			"format": []string{"json"},
+		}.Encode()),
+	})
+	if err != nil {
+		log.Errorf("render embed page failed: %v", err)
+	}
+}
+
+// roomIDFromURL accepts both the embed page url and the web room url
+func roomIDFromURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	p := strings.Trim(u.Path, "/")
+	for _, prefix := range []string{"embed/room/", "web/cinema/"} {
+		if id, ok := strings.CutPrefix(p, prefix); ok && len(id) == 32 {
+			return id, nil
+		}
+	}
+	return "", errors.New("invalid room url")
+}
+
+func oEmbedSize(ctx *gin.Context) (int, int) {
+	width, height := oEmbedDefaultWidth, oEmbedDefaultHeight
+	if mw, err := strconv.Atoi(ctx.Query("maxwidth")); err == nil && mw > 0 && mw < width {
+		height = height * mw / width
+		width = mw
+	}
+	if mh, err := strconv.Atoi(ctx.Query("maxheight")); err == nil && mh > 0 && mh < height {
+		width = width * mh / height
+		height = mh
+	}
+	return width, height
+}
+
+// OEmbed implements the oEmbed json endpoint, https://oembed.com
+func OEmbed(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if f := ctx.DefaultQuery("format", "json"); f != "json" {
+		ctx.AbortWithStatusJSON(http.StatusNotImplemented, model.NewApiErrorStringResp("only json format is supported"))
+		return
+	}
+
+	roomID, err := roomIDFromURL(ctx.Query("url"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	room, err := loadEmbeddableRoom(roomID)
+	if err != nil {
+		log.Errorf("load embeddable room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	host := requestHost(ctx)
+	width, height := oEmbedSize(ctx)
+	src := fmt.Sprintf("%s/embed/room/%s", host, room.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"version":       "1.0",
+		"type":          "video",
+		"provider_name": "SyncTV",
+		"provider_url":  host,
+		"title":         room.Name,
+		"author_name":   op.GetUserName(room.CreatorID),
+		"width":         width,
+		"height":        height,
+		"html": fmt.Sprintf(
+			`<iframe src="%s" width="%d" height="%d" frameborder="0" allow="autoplay; fullscreen" allowfullscreen></iframe>`,
+			html.EscapeString(src), width, height,
+		),
+	})
+}
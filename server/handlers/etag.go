@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+// notModified tags the response with the versions of the room resources it
+// is built from and answers 304 when the client already has it, so clients
+// polling without a websocket do not get the same payload again. The tag
+// depends on the user and the query, since both change the response
+func notModified(ctx *gin.Context, room *op.Room, user *op.User, rs ...op.Resource) bool {
+	h := sha256.New()
+	for _, s := range []string{room.ID, room.ResourceVersion(rs...), user.ID, ctx.Request.URL.RawQuery} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	etag := `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "private, no-cache")
+	if etagMatch(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.AbortWithStatus(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatch is the weak comparison of If-None-Match
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/ical"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// past events older than this are not included in calendar feeds
+const calendarFeedHistory = time.Hour * 24 * 30
+
+func genRoomEventResp(e *dbModel.RoomEvent) *model.RoomEventResp {
+	resp := &model.RoomEventResp{
+		ID:          e.ID,
+		CreatorID:   e.CreatorID,
+		Creator:     op.GetUserName(e.CreatorID),
+		Type:        e.Type,
+		Title:       e.Title,
+		Description: e.Description,
+		StartAt:     e.StartAt.UnixMilli(),
+		MovieID:     e.MovieID,
+		CreatedAt:   e.CreatedAt.UnixMilli(),
+	}
+	if !e.EndAt.IsZero() {
+		resp.EndAt = e.EndAt.UnixMilli()
+	}
+	return resp
+}
+
+func RoomEvents(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("failed to get page and max: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	scopes := []func(*gorm.DB) *gorm.DB{}
+	if ctx.DefaultQuery("upcoming", "true") == "true" {
+		scopes = append(scopes, db.WhereRoomEventStartAfter(time.Now()))
+	}
+
+	total, err := db.GetRoomEventsCount(room.ID, scopes...)
+	if err != nil {
+		log.Errorf("get room events count failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	events, err := db.GetRoomEvents(room.ID, append(scopes, db.OrderByAsc("start_at"), db.Paginate(page, pageSize))...)
+	if err != nil {
+		log.Errorf("get room events failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	list := make([]*model.RoomEventResp, len(events))
+	for i, e := range events {
+		list[i] = genRoomEventResp(e)
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total": total,
+		"list":  list,
+	}))
+}
+
+func roomEventErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrNotFound("event")):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func RoomAdminAddEvent(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.RoomEventReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	event := &dbModel.RoomEvent{}
+	req.Apply(event)
+	if err := user.CreateRoomEvent(room, event); err != nil {
+		log.Errorf("create room event failed: %v", err)
+		ctx.AbortWithStatusJSON(roomEventErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(genRoomEventResp(event)))
+}
+
+func RoomAdminEditEvent(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.EditRoomEventReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	event, err := db.GetRoomEvent(room.ID, req.Id)
+	if err != nil {
+		log.Errorf("get room event failed: %v", err)
+		ctx.AbortWithStatusJSON(roomEventErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+	req.Apply(event)
+	if err := user.EditRoomEvent(room, event); err != nil {
+		log.Errorf("edit room event failed: %v", err)
+		ctx.AbortWithStatusJSON(roomEventErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(genRoomEventResp(event)))
+}
+
+func RoomAdminDeleteEvent(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteRoomEvent(room, req.Id); err != nil {
+		log.Errorf("delete room event failed: %v", err)
+		ctx.AbortWithStatusJSON(roomEventErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func requestHost(ctx *gin.Context) string {
+	if host := HOST.Get(); host != "" {
+		return host
+	}
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	return (&url.URL{
+		Scheme: scheme,
+		Host:   ctx.Request.Host,
+	}).String()
+}
+
+func isPublicRoom(room *op.Room) bool {
+	return !room.NeedPassword() && !room.Settings.Hidden
+}
+
+func RoomCalendarFeed(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	u, err := url.Parse(requestHost(ctx))
+	if err != nil {
+		log.Errorf("parse host failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	u = u.JoinPath("/api/calendar/room", room.ID)
+	if !isPublicRoom(room) {
+		token, err := middlewares.NewCalendarToken(user, room)
+		if err != nil {
+			log.Errorf("new calendar token failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		u.RawQuery = url.Values{"token": []string{token}}.Encode()
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(&model.CalendarFeedResp{
+		URL: u.String(),
+	}))
+}
+
+func UserCalendarFeed(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	u, err := url.Parse(requestHost(ctx))
+	if err != nil {
+		log.Errorf("parse host failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	token, err := middlewares.NewCalendarToken(user, nil)
+	if err != nil {
+		log.Errorf("new calendar token failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	u = u.JoinPath("/api/calendar/user", user.ID)
+	u.RawQuery = url.Values{"token": []string{token}}.Encode()
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(&model.CalendarFeedResp{
+		URL: u.String(),
+	}))
+}
+
+func writeCalendar(ctx *gin.Context, name string, events []*dbModel.RoomEvent) {
+	host := requestHost(ctx)
+	hostname := host
+	if u, err := url.Parse(host); err == nil {
+		hostname = u.Hostname()
+	}
+	cal := &ical.Calendar{
+		ProdID: "-//synctv//calendar//EN",
+		Name:   name,
+		Events: make([]*ical.Event, len(events)),
+	}
+	for i, e := range events {
+		end := e.EndAt
+		if end.IsZero() && e.Type == dbModel.RoomEventTypePlayback {
+			end = e.StartAt.Add(time.Hour * 2)
+		}
+		cal.Events[i] = &ical.Event{
+			UID:         fmt.Sprintf("%s@%s", e.ID, hostname),
+			Created:     e.CreatedAt,
+			LastMod:     e.UpdatedAt,
+			Start:       e.StartAt,
+			End:         end,
+			Summary:     e.Title,
+			Description: e.Description,
+			URL:         fmt.Sprintf("%s/web/cinema/%s", host, e.RoomID),
+			Categories:  []string{string(e.Type)},
+		}
+	}
+	ctx.Header("Content-Type", "text/calendar; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	_ = cal.Encode(ctx.Writer)
+}
+
+func RoomCalendar(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	roomE, err := op.LoadOrInitRoomByID(ctx.Param("roomId"))
+	if err != nil {
+		log.Errorf("load room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	room := roomE.Value()
+
+	if !isPublicRoom(room) {
+		if _, err := middlewares.AuthCalendar(ctx.Query("token"), room.ID); err != nil {
+			log.Errorf("auth calendar failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
+			return
+		}
+	}
+
+	events, err := db.GetRoomEvents(room.ID,
+		db.WhereRoomEventStartAfter(time.Now().Add(-calendarFeedHistory)),
+		db.OrderByAsc("start_at"),
+	)
+	if err != nil {
+		log.Errorf("get room events failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	writeCalendar(ctx, room.Name, events)
+}
+
+func UserCalendar(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	userE, err := middlewares.AuthCalendar(ctx.Query("token"), "")
+	if err != nil {
+		log.Errorf("auth calendar failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
+		return
+	}
+	user := userE.Value()
+	if user.ID != ctx.Param("userId") {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(middlewares.ErrAuthFailed))
+		return
+	}
+
+	events, err := db.GetUserRoomEvents(user.ID,
+		db.WhereRoomEventStartAfter(time.Now().Add(-calendarFeedHistory)),
+		db.OrderByAsc("start_at"),
+	)
+	if err != nil {
+		log.Errorf("get user room events failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	writeCalendar(ctx, user.Username, events)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/provider/providers"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+// AdminFeatures lists the vendors and oauth2 providers which can be turned
+// off at runtime
+func AdminFeatures(ctx *gin.Context) {
+	vendors := make([]*model.AdminFeatureResp, 0, len(settings.VendorSettings))
+	for name, s := range settings.VendorSettings {
+		vendors = append(vendors, &model.AdminFeatureResp{
+			Name:    name,
+			Enabled: s.Enabled.Get(),
+			Reason:  s.DisabledReason.Get(),
+		})
+	}
+	oauth2 := make([]*model.AdminFeatureResp, 0, len(bootstrap.ProviderGroupSettings))
+	for _, s := range bootstrap.ProviderGroupSettings {
+		oauth2 = append(oauth2, &model.AdminFeatureResp{
+			Name:    s.Provider,
+			Enabled: s.Enabled.Get(),
+			Reason:  s.DisabledReason.Get(),
+		})
+	}
+	for _, list := range [][]*model.AdminFeatureResp{vendors, oauth2} {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Name < list[j].Name
+		})
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"vendors": vendors,
+		"oauth2":  oauth2,
+	}))
+}
+
+// AdminOAuth2Providers lists the providers compiled into the binary and
+// the ones loaded from plugins or the config
+func AdminOAuth2Providers(ctx *gin.Context) {
+	enabled := providers.EnabledProvider()
+	all := providers.AllProvider()
+	resp := make([]*model.AdminOAuth2ProviderResp, 0, len(all))
+	for name := range all {
+		source, _ := providers.GetProviderSource(name)
+		_, ok := enabled.Load(name)
+		resp = append(resp, &model.AdminOAuth2ProviderResp{
+			Name:    name,
+			Source:  string(source),
+			Enabled: ok,
+		})
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].Name < resp[j].Name
+	})
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func AdminSetVendorFeature(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.AdminSetFeatureReq
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	s, ok := settings.VendorSettings[req.Name]
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp(fmt.Sprintf("vendor %s not found", req.Name)))
+		return
+	}
+	if err := setFeature(s.Enabled, s.DisabledReason, &req); err != nil {
+		log.Errorf("set vendor feature error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func AdminSetOAuth2Feature(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.AdminSetFeatureReq
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	s, ok := bootstrap.ProviderGroupSettings[dbModel.SettingGroup(fmt.Sprintf("%s_%s", dbModel.SettingGroupOauth2, req.Name))]
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp(fmt.Sprintf("provider %s not found", req.Name)))
+		return
+	}
+	if err := setFeature(s.Enabled, s.DisabledReason, &req); err != nil {
+		log.Errorf("set oauth2 feature error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// setFeature stores the reason before turning the feature off, so users
+// never see it disabled without one, and clears it when turned on
+func setFeature(enabled settings.BoolSetting, reason settings.StringSetting, req *model.AdminSetFeatureReq) error {
+	if req.Enabled {
+		if err := enabled.Set(true); err != nil {
+			return err
+		}
+		return reason.Set("")
+	}
+	if err := reason.Set(req.Reason); err != nil {
+		return err
+	}
+	return enabled.Set(false)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+const roomFeedMaxItems = 50
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	LastBuildDate string      `xml:"lastBuildDate,omitempty"`
+	Items         []*rssItem  `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description,omitempty"`
+	Author      string  `xml:"author,omitempty"`
+	Category    string  `xml:"category,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+
+	time time.Time
+}
+
+func RoomRssFeed(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	roomE, err := op.LoadOrInitRoomByID(ctx.Param("roomId"))
+	if err != nil {
+		log.Errorf("load room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	room := roomE.Value()
+
+	if !isPublicRoom(room) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("room is not public"))
+		return
+	}
+
+	host := requestHost(ctx)
+	roomLink := fmt.Sprintf("%s/web/cinema/%s", host, room.ID)
+	items := make([]*rssItem, 0, roomFeedMaxItems*2)
+
+	if room.Settings.CanGetMovieList {
+		movies, err := db.GetRecentMoviesByRoomID(room.ID, roomFeedMaxItems)
+		if err != nil {
+			log.Errorf("get recent movies failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		for _, m := range movies {
+			items = append(items, &rssItem{
+				Title:    m.Name,
+				Link:     roomLink,
+				Author:   op.GetUserName(m.CreatorID),
+				Category: "movie",
+				GUID:     rssGUID{Value: "movie:" + m.ID},
+				PubDate:  m.CreatedAt.UTC().Format(time.RFC1123Z),
+				time:     m.CreatedAt,
+			})
+		}
+	}
+
+	sessions, err := db.GetLiveSessions(room.ID,
+		db.OrderByDesc("ended_at"),
+		db.Paginate(1, roomFeedMaxItems),
+	)
+	if err != nil {
+		log.Errorf("get live sessions failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	for _, s := range sessions {
+		items = append(items, &rssItem{
+			Title: fmt.Sprintf("Live: %s", s.MovieName),
+			Link:  roomLink,
+			Description: fmt.Sprintf("Live session lasted %s",
+				s.EndedAt.Sub(s.StartedAt).Round(time.Second)),
+			Author:   op.GetUserName(s.CreatorID),
+			Category: "live",
+			GUID:     rssGUID{Value: "live:" + s.ID},
+			PubDate:  s.EndedAt.UTC().Format(time.RFC1123Z),
+			time:     s.EndedAt,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].time.After(items[j].time)
+	})
+	if len(items) > roomFeedMaxItems {
+		items = items[:roomFeedMaxItems]
+	}
+
+	self, err := url.JoinPath(host, "/api/feed/room", room.ID)
+	if err != nil {
+		log.Errorf("join feed url failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	feed := &rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       room.Name,
+			Link:        roomLink,
+			Description: fmt.Sprintf("Library updates of room %s", room.Name),
+			AtomLink: rssAtomLink{
+				Href: self,
+				Rel:  "self",
+				Type: "application/rss+xml",
+			},
+			Items: items,
+		},
+	}
+	if len(items) != 0 {
+		feed.Channel.LastBuildDate = items[0].PubDate
+	}
+
+	ctx.Header("Content-Type", "application/rss+xml; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	_, _ = ctx.Writer.WriteString(xml.Header)
+	if err := xml.NewEncoder(ctx.Writer).Encode(feed); err != nil {
+		log.Errorf("encode rss feed failed: %v", err)
+	}
+}
@@ -6,12 +6,16 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/conf"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/server/handlers/vendors"
 	"github.com/synctv-org/synctv/server/handlers/vendors/vendorAlist"
 	"github.com/synctv-org/synctv/server/handlers/vendors/vendorBilibili"
+	"github.com/synctv-org/synctv/server/handlers/vendors/vendorCookie"
+	"github.com/synctv-org/synctv/server/handlers/vendors/vendorDouyin"
 	"github.com/synctv-org/synctv/server/handlers/vendors/vendorEmby"
+	"github.com/synctv-org/synctv/server/handlers/vendors/vendorPlugin"
 	"github.com/synctv-org/synctv/server/middlewares"
 	"github.com/synctv-org/synctv/utils"
 )
@@ -35,6 +39,28 @@ var (
 )
 
 func Init(e *gin.Engine) {
+	e.GET("/embed/room/:roomId", EmbedRoom)
+
+	e.GET("/share/room/:roomId", ShareRoom)
+
+	e.GET("/overlay/room/:roomId", OverlayRoom)
+
+	e.GET("/.well-known/webfinger", WebFinger)
+
+	{
+		ap := e.Group("/ap")
+
+		ap.GET("/actor", ActivityPubActor)
+
+		ap.POST("/inbox", ActivityPubInbox)
+
+		ap.GET("/outbox", ActivityPubOutbox)
+
+		ap.GET("/followers", ActivityPubFollowers)
+
+		ap.GET("/notes/:id", ActivityPubNote)
+	}
+
 	api := e.Group("/api")
 
 	needAuthUserApi := api.Group("", middlewares.AuthUserMiddleware)
@@ -47,8 +73,18 @@ func Init(e *gin.Engine) {
 		public := api.Group("/public")
 
 		public.GET("/settings", Settings)
+
+		public.GET("/webpush/vapid", WebPushVapidPublicKey)
+
+		public.GET("/snapshot/:id", publicCache.Handler(publicSnapshotTTL, snapshotCacheTags), PublicRoomSnapshot)
+
+		public.GET("/org/:name", publicCache.Handler(publicOrgCacheTTL, cacheTags(cacheTagOrgs)), PublicOrg)
 	}
 
+	api.GET("/oembed", publicCache.Handler(oEmbedCacheTTL, oEmbedCacheTags), OEmbed)
+
+	api.GET("/search", Search)
+
 	{
 		admin := api.Group("/admin")
 		root := api.Group("/admin")
@@ -58,6 +94,12 @@ func Init(e *gin.Engine) {
 		initAdmin(admin, root)
 	}
 
+	{
+		needAuthOrg := needAuthUserApi.Group("/org")
+
+		initOrg(needAuthOrg)
+	}
+
 	{
 		room := api.Group("/room")
 		needAuthUser := needAuthUserApi.Group("/room")
@@ -67,6 +109,37 @@ func Init(e *gin.Engine) {
 		initRoom(room, needAuthUser, needAuthRoom, needAuthRoomWithoutGuest)
 	}
 
+	{
+		calendar := api.Group("/calendar")
+
+		calendar.GET("/room/:roomId", RoomCalendar)
+
+		calendar.GET("/user/:userId", UserCalendar)
+	}
+
+	{
+		webhook := api.Group("/webhook")
+
+		webhook.POST("/arr/:id", ArrWebhook)
+	}
+
+	{
+		feed := api.Group("/feed")
+
+		feed.GET("/room/:roomId", RoomRssFeed)
+	}
+
+	{
+		kodi := api.Group("/kodi")
+		needAuthKodi := needAuthRoomApi.Group("/kodi")
+
+		kodi.GET("/rooms", KodiRooms)
+
+		needAuthKodi.GET("/current", KodiCurrent)
+
+		needAuthKodi.GET("/sync", KodiSync)
+	}
+
 	{
 		movie := api.Group("/movie")
 		needAuthMovie := needAuthRoomApi.Group("/movie")
@@ -98,6 +171,16 @@ func initAdmin(admin *gin.RouterGroup, root *gin.RouterGroup) {
 
 		admin.POST("/email/test", SendTestEmail)
 
+		admin.GET("/janitor", AdminJanitorReport)
+
+		admin.POST("/janitor/run", AdminRunJanitor)
+
+		admin.GET("/jobs", AdminJobs)
+
+		admin.POST("/jobs/retry", AdminRetryJob)
+
+		admin.GET("/live", AdminLiveStatus)
+
 		admin.GET("/vendors", AdminGetVendorBackends)
 
 		admin.POST("/vendors/add", AdminAddVendorBackend)
@@ -112,6 +195,14 @@ func initAdmin(admin *gin.RouterGroup, root *gin.RouterGroup) {
 
 		admin.POST("/vendors/disable", AdminDisableVendorBackends)
 
+		admin.GET("/features", AdminFeatures)
+
+		admin.POST("/features/vendor", AdminSetVendorFeature)
+
+		admin.POST("/features/oauth2", AdminSetOAuth2Feature)
+
+		admin.GET("/oauth2/providers", AdminOAuth2Providers)
+
 		{
 			user := admin.Group("/user")
 
@@ -123,6 +214,8 @@ func initAdmin(admin *gin.RouterGroup, root *gin.RouterGroup) {
 
 			user.POST("/username", AdminUsername)
 
+			user.POST("/rating", AdminUserContentRating)
+
 			// 查找用户
 			user.GET("/list", Users)
 
@@ -134,6 +227,12 @@ func initAdmin(admin *gin.RouterGroup, root *gin.RouterGroup) {
 
 			// 查找某个用户的房间
 			user.GET("/rooms", GetUserRooms)
+
+			user.GET("/deletions", AdminUserDeletions)
+
+			user.POST("/deletion/cancel", AdminCancelUserDeletion)
+
+			user.POST("/erase", AdminEraseUser)
 		}
 
 		{
@@ -153,6 +252,30 @@ func initAdmin(admin *gin.RouterGroup, root *gin.RouterGroup) {
 			room.POST("/delete", AdminDeleteRoom)
 
 			room.GET("/members", AdminGetRoomMembers)
+
+			room.POST("/trace/start", AdminStartRoomTrace)
+
+			room.POST("/trace/stop", AdminStopRoomTrace)
+
+			room.GET("/logs", AdminRoomLogs)
+
+			room.GET("/logs/entries", AdminRoomLogEntries)
+
+			room.POST("/logs/enable", AdminEnableRoomLog)
+
+			room.POST("/logs/disable", AdminDisableRoomLog)
+		}
+
+		{
+			org := admin.Group("/org")
+
+			org.GET("/list", AdminOrgs)
+
+			org.POST("/create", AdminCreateOrg)
+
+			org.POST("/quota", AdminSetOrgQuota)
+
+			org.POST("/delete", AdminDeleteOrg)
 		}
 	}
 
@@ -163,14 +286,51 @@ func initAdmin(admin *gin.RouterGroup, root *gin.RouterGroup) {
 	}
 }
 
+func newWebSocketServer() *utils.WebSocket {
+	var opts []utils.WebSocketConfig
+	if c := conf.Conf.Server.Ws; c.Compression {
+		opts = append(opts, utils.WithCompression(c.CompressionLevel, c.CompressionThreshold))
+	}
+	return utils.NewWebSocketServer(opts...)
+}
+
+func initOrg(needAuthOrg *gin.RouterGroup) {
+	needAuthOrg.GET("/list", UserOrgs)
+
+	needAuthOrg.POST("/join", JoinOrg)
+
+	needAuthOrg.POST("/leave", LeaveOrg)
+
+	// org admin
+	needAuthOrg.GET("/members", OrgMembers)
+
+	needAuthOrg.POST("/members/add", AddOrgMember)
+
+	needAuthOrg.POST("/members/remove", RemoveOrgMember)
+
+	needAuthOrg.POST("/branding", SetOrgBranding)
+
+	needAuthOrg.GET("/invite", OrgInvite)
+
+	needAuthOrg.POST("/invite/reset", ResetOrgInvite)
+
+	needAuthOrg.GET("/rooms", OrgRooms)
+
+	needAuthOrg.POST("/rooms/delete", OrgDeleteRoom)
+
+	needAuthOrg.GET("/oauth2", OrgProvider)
+
+	needAuthOrg.POST("/oauth2", SetOrgProvider)
+}
+
 func initRoom(room *gin.RouterGroup, needAuthUser *gin.RouterGroup, needAuthRoom *gin.RouterGroup, needAuthWithoutGuestRoom *gin.RouterGroup) {
-	room.GET("/ws", NewWebSocketHandler(utils.NewWebSocketServer()))
+	room.GET("/ws", NewWebSocketHandler(newWebSocketServer()))
 
 	room.GET("/check", CheckRoom)
 
-	room.GET("/hot", RoomHotList)
+	room.GET("/hot", publicCache.Handler(roomHotCacheTTL, cacheTags(cacheTagRooms)), RoomHotList)
 
-	room.GET("/list", RoomList)
+	room.GET("/list", publicCache.Handler(roomDirectoryCacheTTL, cacheTags(cacheTagRooms)), RoomList)
 
 	room.POST("/guest", GuestJoinRoom)
 
@@ -178,12 +338,46 @@ func initRoom(room *gin.RouterGroup, needAuthUser *gin.RouterGroup, needAuthRoom
 
 	needAuthUser.POST("/login", LoginRoom)
 
+	needAuthUser.POST("/quickjoin", QuickJoinRoom)
+
+	needAuthWithoutGuestRoom.POST("/clone", CloneRoom)
+
 	needAuthRoom.GET("/me", RoomMe)
 
+	needAuthRoom.GET("/current", RoomCurrent)
+
+	needAuthRoom.GET("/overlay", RoomOverlay)
+
+	needAuthRoom.GET("/sync/profile", RoomSyncProfile)
+
 	needAuthWithoutGuestRoom.GET("/settings", RoomPiblicSettings)
 
 	needAuthWithoutGuestRoom.GET("/members", RoomMembers)
 
+	needAuthWithoutGuestRoom.GET("/members/presence", RoomMembersPresence)
+
+	needAuthRoom.GET("/chat/pins", RoomPinnedChatMessages)
+
+	needAuthWithoutGuestRoom.GET("/follow", RoomFollowStatus)
+
+	needAuthWithoutGuestRoom.POST("/follow", FollowRoom)
+
+	needAuthWithoutGuestRoom.POST("/unfollow", UnfollowRoom)
+
+	needAuthRoom.GET("/events", RoomEvents)
+
+	needAuthRoom.GET("/snapshots", RoomSnapshots)
+
+	needAuthRoom.GET("/polls", MoviePolls)
+
+	needAuthWithoutGuestRoom.POST("/polls/vote", VoteMoviePoll)
+
+	needAuthWithoutGuestRoom.GET("/calendar", RoomCalendarFeed)
+
+	needAuthWithoutGuestRoom.GET("/quickjoin", RoomQuickJoinURL)
+
+	needAuthWithoutGuestRoom.GET("/quickjoin/qrcode", RoomQuickJoinQRCode)
+
 	{
 		needAuthRoomAdmin := needAuthRoom.Group("/admin", middlewares.AuthRoomAdminMiddleware)
 		needAuthRoomCreator := needAuthRoom.Group("/admin", middlewares.AuthRoomCreatorMiddleware)
@@ -204,6 +398,36 @@ func initRoom(room *gin.RouterGroup, needAuthUser *gin.RouterGroup, needAuthRoom
 
 		needAuthRoomAdmin.POST("/members/unban", RoomAdminUnbanMember)
 
+		needAuthRoomAdmin.POST("/chat/delete", RoomAdminDeleteChatMessage)
+
+		needAuthRoomAdmin.POST("/chat/purge", RoomAdminPurgeChatMessages)
+
+		needAuthRoomAdmin.POST("/chat/pin", RoomAdminPinChatMessage)
+
+		needAuthRoomAdmin.POST("/chat/unpin", RoomAdminUnpinChatMessage)
+
+		needAuthRoomAdmin.GET("/audits", RoomAdminAudits)
+
+		needAuthRoomAdmin.GET("/mutations", RoomAdminMutations)
+
+		needAuthRoomAdmin.POST("/events/add", RoomAdminAddEvent)
+
+		needAuthRoomAdmin.POST("/events/edit", RoomAdminEditEvent)
+
+		needAuthRoomAdmin.POST("/events/delete", RoomAdminDeleteEvent)
+
+		needAuthRoomAdmin.POST("/snapshot/start", RoomAdminStartSnapshot)
+
+		needAuthRoomAdmin.POST("/snapshot/publish", RoomAdminPublishSnapshot)
+
+		needAuthRoomAdmin.POST("/snapshot/delete", RoomAdminDeleteSnapshot)
+
+		needAuthRoomAdmin.POST("/polls/add", RoomAdminCreateMoviePoll)
+
+		needAuthRoomAdmin.POST("/polls/close", RoomAdminCloseMoviePoll)
+
+		needAuthRoomAdmin.POST("/polls/delete", RoomAdminDeleteMoviePoll)
+
 		needAuthRoomCreator.POST("/members/member", RoomSetMember)
 
 		needAuthRoomCreator.POST("/members/member/permissions", RoomSetMemberPermissions)
@@ -211,6 +435,14 @@ func initRoom(room *gin.RouterGroup, needAuthUser *gin.RouterGroup, needAuthRoom
 		needAuthRoomCreator.POST("/members/admin", RoomSetAdmin)
 
 		needAuthRoomCreator.POST("/members/admin/permissions", RoomSetAdminPermissions)
+
+		needAuthRoomCreator.POST("/members/admin/trial", RoomSetTrialAdmin)
+
+		needAuthRoomCreator.GET("/mirror", RoomAdminMirror)
+
+		needAuthRoomCreator.POST("/mirror", RoomAdminSetMirror)
+
+		needAuthRoomCreator.POST("/mirror/delete", RoomAdminDeleteMirror)
 	}
 }
 
@@ -225,20 +457,90 @@ func initMovie(movie *gin.RouterGroup, needAuthMovie *gin.RouterGroup) {
 
 	needAuthMovie.POST("/push", PushMovie)
 
+	needAuthMovie.POST("/preflight", MoviePreflight)
+
 	needAuthMovie.POST("/pushs", PushMovies)
 
 	needAuthMovie.POST("/edit", EditMovie)
 
+	needAuthMovie.POST("/patch", PatchMovie)
+
+	needAuthMovie.POST("/unlock", UnlockMovie)
+
+	needAuthMovie.GET("/comments", MovieComments)
+
+	needAuthMovie.POST("/comments/add", AddMovieComment)
+
+	needAuthMovie.POST("/comments/edit", EditMovieComment)
+
+	needAuthMovie.POST("/comments/delete", DeleteMovieComment)
+
+	needAuthMovie.POST("/comments/react", ReactMovieComment)
+
+	needAuthMovie.GET("/alist/watches", AlistWatches)
+
+	needAuthMovie.POST("/alist/watches/add", AddAlistWatch)
+
+	needAuthMovie.POST("/alist/watches/delete", DeleteAlistWatch)
+
+	needAuthMovie.GET("/webhooks/arr", ArrWebhooks)
+
+	needAuthMovie.POST("/webhooks/arr/add", AddArrWebhook)
+
+	needAuthMovie.POST("/webhooks/arr/delete", DeleteArrWebhook)
+
 	needAuthMovie.POST("/swap", SwapMovie)
 
+	needAuthMovie.POST("/migrate", MigrateMovie)
+
 	needAuthMovie.POST("/delete", DelMovie)
 
 	needAuthMovie.POST("/clear", ClearMovies)
 
+	needAuthMovie.GET("/trash", TrashedMovies)
+
+	needAuthMovie.POST("/trash/restore", RestoreTrashedMovies)
+
+	needAuthMovie.POST("/trash/delete", PurgeTrashedMovies)
+
 	needAuthMovie.HEAD("/proxy/:roomId/:movieId", ProxyMovie)
 
 	needAuthMovie.GET("/proxy/:roomId/:movieId", ProxyMovie)
 
+	needAuthMovie.HEAD("/media/:movieId", MediaMovie)
+
+	needAuthMovie.GET("/media/:movieId", MediaMovie)
+
+	needAuthMovie.GET("/audio/:movieId", AudioOnlyMovie)
+
+	needAuthMovie.GET("/airplay/:movieId/index.m3u8", AirPlayPlaylist)
+
+	needAuthMovie.HEAD("/airplay/:movieId/media", AirPlayMedia)
+
+	needAuthMovie.GET("/airplay/:movieId/media", AirPlayMedia)
+
+	{
+		cdn := movie.Group("/cdn", CdnSignatureMiddleware)
+
+		cdn.HEAD("/proxy/:roomId/:movieId/:version", CdnProxyMovie)
+
+		cdn.GET("/proxy/:roomId/:movieId/:version", CdnProxyMovie)
+
+		cdn.GET("/hls/:roomId/:movieId/:dataId", CdnServeHlsLive)
+	}
+
+	{
+		needAuthCast := needAuthMovie.Group("/cast")
+
+		needAuthCast.GET("", CastManifest)
+
+		needAuthCast.GET("/subtitle/:movieId", CastSubtitle)
+
+		needAuthCast.POST("/status", CastStatus)
+
+		needAuthCast.GET("/receivers", CastReceivers)
+	}
+
 	{
 		needAuthLive := needAuthMovie.Group("/live")
 
@@ -251,12 +553,22 @@ func initMovie(movie *gin.RouterGroup, needAuthMovie *gin.RouterGroup) {
 		needAuthLive.GET("/hls/list/:movieId", JoinHlsLive)
 
 		needAuthLive.GET("/hls/data/:roomId/:movieId/:dataId", ServeHlsLive)
+
+		needAuthLive.GET("/llhls/:movieId/:file", LLHlsLive)
+
+		needAuthLive.GET("/abr/:movieId/:rendition", AbrLiveMaster)
+
+		needAuthLive.GET("/abr/:movieId/:rendition/:file", AbrLive)
 	}
 }
 
 func initUser(user *gin.RouterGroup, needAuthUser *gin.RouterGroup) {
 	user.POST("/login", LoginUser)
 
+	user.POST("/passkey/login/begin", BeginPasskeyLogin)
+
+	user.POST("/passkey/login/finish", FinishPasskeyLogin)
+
 	user.GET("/signup/email/captcha", GetUserSignupEmailStep1Captcha)
 
 	user.POST("/signup/email/captcha", SendUserSignupEmailCaptcha)
@@ -279,8 +591,36 @@ func initUser(user *gin.RouterGroup, needAuthUser *gin.RouterGroup) {
 
 	needAuthUser.POST("/password", SetUserPassword)
 
+	needAuthUser.POST("/rating", SetUserContentRating)
+
+	needAuthUser.GET("/export", UserExport)
+
+	needAuthUser.GET("/deletion", UserDeletion)
+
+	needAuthUser.POST("/deletion", RequestUserDeletion)
+
+	needAuthUser.POST("/deletion/cancel", CancelUserDeletion)
+
+	needAuthUser.GET("/logins", UserLogins)
+
+	needAuthUser.POST("/logins/revoke", RevokeUserLogins)
+
 	needAuthUser.GET("/providers", UserBindProviders)
 
+	{
+		passkey := needAuthUser.Group("/passkeys")
+
+		passkey.GET("", UserPasskeys)
+
+		passkey.POST("/register/begin", BeginRegisterUserPasskey)
+
+		passkey.POST("/register/finish", FinishRegisterUserPasskey)
+
+		passkey.POST("/delete", DeleteUserPasskey)
+	}
+
+	needAuthUser.GET("/calendar", UserCalendarFeed)
+
 	needAuthUser.GET("/bind/email/captcha", GetUserBindEmailStep1Captcha)
 
 	needAuthUser.POST("/bind/email/captcha", SendUserBindEmailCaptcha)
@@ -294,13 +634,65 @@ func initUser(user *gin.RouterGroup, needAuthUser *gin.RouterGroup) {
 
 		room.POST("/delete", UserDeleteRoom)
 	}
+
+	{
+		notify := needAuthUser.Group("/notify")
+
+		notify.GET("/subscriptions", UserNotificationSubscriptions)
+
+		notify.POST("/subscriptions/add", AddUserNotificationSubscription)
+
+		notify.POST("/subscriptions/delete", DeleteUserNotificationSubscription)
+
+		notify.GET("/preference", UserNotificationPreference)
+
+		notify.POST("/preference", SetUserNotificationPreference)
+	}
+
+	needAuthUser.GET("/achievements", UserAchievements)
+
+	{
+		presence := needAuthUser.Group("/presence")
+
+		presence.GET("", UserPresence)
+
+		presence.GET("/preference", UserPresencePreference)
+
+		presence.POST("/preference", SetUserPresencePreference)
+	}
+
+	{
+		media := needAuthUser.Group("/media")
+
+		media.OPTIONS("", MediaUploadOptions)
+
+		media.GET("", UserMediaFiles)
+
+		media.POST("", CreateMediaUpload)
+
+		media.HEAD("/:id", MediaUploadOffset)
+
+		media.PATCH("/:id", MediaUploadPatch)
+
+		media.DELETE("/:id", DeleteMediaFile)
+	}
+
+	{
+		secrets := needAuthUser.Group("/secrets")
+
+		secrets.GET("", UserSecrets)
+
+		secrets.POST("", SaveUserSecret)
+
+		secrets.POST("/delete", DeleteUserSecret)
+	}
 }
 
 func initVendor(vendor *gin.RouterGroup) {
 	vendor.GET("/backends/:vendor", vendors.Backends)
 
 	{
-		bilibili := vendor.Group("/bilibili")
+		bilibili := vendor.Group("/bilibili", middlewares.VendorEnabled(model.VendorBilibili))
 
 		login := bilibili.Group("/login")
 
@@ -322,7 +714,7 @@ func initVendor(vendor *gin.RouterGroup) {
 	}
 
 	{
-		alist := vendor.Group("/alist")
+		alist := vendor.Group("/alist", middlewares.VendorEnabled(model.VendorAlist))
 
 		alist.POST("/login", vendorAlist.Login)
 
@@ -336,7 +728,7 @@ func initVendor(vendor *gin.RouterGroup) {
 	}
 
 	{
-		emby := vendor.Group("/emby")
+		emby := vendor.Group("/emby", middlewares.VendorEnabled(model.VendorEmby))
 
 		emby.POST("/login", vendorEmby.Login)
 
@@ -344,8 +736,50 @@ func initVendor(vendor *gin.RouterGroup) {
 
 		emby.POST("/list", vendorEmby.List)
 
+		emby.POST("/livetv/channels", vendorEmby.LiveTvChannels)
+
+		emby.POST("/livetv/programs", vendorEmby.LiveTvPrograms)
+
 		emby.GET("/me", vendorEmby.Me)
 
 		emby.GET("/binds", vendorEmby.Binds)
+
+		emby.POST("/report", vendorEmby.ReportPlayback)
+	}
+
+	{
+		douyin := vendor.Group("/douyin", middlewares.VendorEnabled(model.VendorDouyin))
+
+		douyin.POST("/parse", vendorDouyin.Parse)
+	}
+
+	{
+		acfun := vendor.Group("/acfun", middlewares.VendorEnabled(model.VendorAcfun))
+
+		acfun.POST("/login", vendorCookie.Login(model.VendorAcfun))
+
+		acfun.POST("/logout", vendorCookie.Logout(model.VendorAcfun))
+
+		acfun.GET("/me", vendorCookie.Me(model.VendorAcfun))
+
+		acfun.POST("/parse", vendorCookie.Parse(model.VendorAcfun))
+	}
+
+	{
+		youku := vendor.Group("/youku", middlewares.VendorEnabled(model.VendorYouku))
+
+		youku.POST("/login", vendorCookie.Login(model.VendorYouku))
+
+		youku.POST("/logout", vendorCookie.Logout(model.VendorYouku))
+
+		youku.GET("/me", vendorCookie.Me(model.VendorYouku))
+
+		youku.POST("/parse", vendorCookie.Parse(model.VendorYouku))
+	}
+
+	{
+		plugin := vendor.Group("/plugin", middlewares.VendorEnabled(model.VendorPlugin))
+
+		plugin.POST("/list", vendorPlugin.List)
 	}
 }
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+const (
+	kodiDefaultPollTimeout = 25 * time.Second
+	kodiMaxPollTimeout     = 60 * time.Second
+)
+
+// KodiRooms lists the active public rooms as kodi list items, most watched first
+func KodiRooms(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("get kodi rooms failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	rooms := make([]*model.KodiRoomResp, 0)
+	op.RangeRoomCache(func(key string, value *synccache.Entry[*op.Room]) bool {
+		r := value.Value()
+		// only public rooms of the main directory, the current movie is shown
+		if !isPublicRoom(r) || r.OrgID != "" || r.IsBanned() || r.IsPending() {
+			return true
+		}
+		item := &model.KodiRoomResp{
+			RoomID:       r.ID,
+			PeopleNum:    r.PeopleNum(),
+			NeedPassword: r.NeedPassword(),
+			Label2:       op.GetUserName(r.CreatorID),
+		}
+		item.Label = fmt.Sprintf("%s (%d)", r.Name, item.PeopleNum)
+		if m, err := r.LoadCurrentMovie(); err == nil {
+			item.Playing = m.Movie.MovieBase.Name
+		}
+		rooms = append(rooms, item)
+		return true
+	})
+	sort.SliceStable(rooms, func(i, j int) bool {
+		if rooms[i].PeopleNum == rooms[j].PeopleNum {
+			return rooms[i].Label < rooms[j].Label
+		}
+		return rooms[i].PeopleNum > rooms[j].PeopleNum
+	})
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total": len(rooms),
+		"list":  utils.GetPageItems(rooms, page, pageSize),
+	}))
+}
+
+// kodiPath appends the headers to the url in kodi's url|Header=value format
+func kodiPath(u string, headers map[string]string) string {
+	if len(headers) == 0 {
+		return u
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	params := make([]string, len(keys))
+	for i, k := range keys {
+		params[i] = k + "=" + url.QueryEscape(headers[k])
+	}
+	return u + "|" + strings.Join(params, "&")
+}
+
+func kodiMovie(room *op.Room, current *op.Current) model.KodiMovie {
+	movie := model.KodiMovie{
+		ID:   current.Movie.ID,
+		Live: current.Movie.IsLive,
+	}
+	if current.Movie.ID != "" {
+		if m, err := room.GetMovieByID(current.Movie.ID); err == nil {
+			movie.Name = m.Movie.MovieBase.Name
+		}
+	}
+	return movie
+}
+
+// KodiCurrent resolves the current movie to a url playable by kodi, with the headers inline
+func KodiCurrent(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	version := room.CurrentVersion()
+	current := room.Current()
+	resp := &model.KodiCurrentResp{
+		Version:   version,
+		Movie:     kodiMovie(room, current),
+		Subtitles: []*model.CastSubtitle{},
+		Status:    current.Status,
+		Time:      time.Now().UnixMilli(),
+	}
+	if current.Movie.ID == "" {
+		ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+		return
+	}
+
+	opMovie, err := room.GetMovieByID(current.Movie.ID)
+	if err != nil {
+		log.Errorf("get current movie failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	token := ctx.MustGet("token").(string)
+	movie, err := genMovieInfo(ctx, user, opMovie, ctx.GetHeader("User-Agent"), token)
+	if err != nil {
+		log.Errorf("gen movie info failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	host := requestHost(ctx)
+	resp.URL = absoluteURL(host, movie.Base.Url)
+	resp.Headers = movie.Base.Headers
+	if resp.Headers == nil {
+		resp.Headers = map[string]string{}
+	}
+	resp.Path = kodiPath(resp.URL, resp.Headers)
+	resp.ContentType = castContentType(movie.Base.Type)
+	for name := range movie.Base.Subtitles {
+		resp.Subtitles = append(resp.Subtitles, &model.CastSubtitle{
+			Name: name,
+			URL: fmt.Sprintf("%s/api/movie/cast/subtitle/%s?%s", host, movie.Id, url.Values{
+				"name":  []string{name},
+				"token": []string{token},
+			}.Encode()),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+// KodiSync long-polls the sync state, it returns once the state version differs
+// from query version, or after query timeout seconds. Without a version it returns at once.
+func KodiSync(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+	timeout := kodiDefaultPollTimeout
+	if t := ctx.Query("timeout"); t != "" {
+		sec, err := strconv.Atoi(t)
+		if err != nil || sec < 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid timeout"))
+			return
+		}
+		timeout = min(time.Duration(sec)*time.Second, kodiMaxPollTimeout)
+	}
+
+	user.UpdateLastAct()
+	version := room.CurrentVersion()
+	if v := ctx.Query("version"); v != "" {
+		since, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid version"))
+			return
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		version = room.WaitCurrentChanged(waitCtx, since)
+	}
+
+	current := room.Current()
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(&model.KodiSyncResp{
+		Version: version,
+		Movie:   kodiMovie(room, current),
+		Status:  current.Status,
+		Time:    time.Now().UnixMilli(),
+	}))
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/llhls"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/transcode"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+const llhlsPlaylistName = "index.m3u8"
+
+// lowLatencyLive reports whether the room serves live movies as low latency hls
+func lowLatencyLive(roomID string) bool {
+	room, err := op.LoadOrInitRoomByID(roomID)
+	if err != nil {
+		return false
+	}
+	return room.Value().Settings.LowLatencyLive
+}
+
+func llhlsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, llhls.ErrFutureMSN):
+		return http.StatusBadRequest
+	case errors.Is(err, llhls.ErrBlockTimeout):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusNotFound
+	}
+}
+
+// llhlsLiveMovie loads the live movie of the movieId param and checks it
+// can be watched
+func llhlsLiveMovie(ctx *gin.Context) (*op.Movie, bool) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	m, err := room.GetMovieByID(ctx.Param("movieId"))
+	if err != nil {
+		log.Errorf("llhls live error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return nil, false
+	}
+	if !checkWatchMovie(ctx, user, room, m.Movie) {
+		return nil, false
+	}
+	if !m.Movie.MovieBase.Live {
+		log.Error("llhls live error: live is not enabled")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("live is not enabled"))
+		return nil, false
+	}
+	if m.Movie.MovieBase.RtmpSource {
+		if !conf.Conf.Server.Rtmp.Enable {
+			log.Error("llhls live error: rtmp is not enabled")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("rtmp is not enabled"))
+			return nil, false
+		}
+	} else if !settings.LiveProxy.Get() {
+		log.Error("llhls live error: live proxy is not enabled")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("live proxy is not enabled"))
+		return nil, false
+	}
+	return m, true
+}
+
+// serveLLHls serves the playlist or a segment or part of the stream, the
+// playlist supports blocking reload with the _HLS_msn and _HLS_part queries.
+// uri maps a file name of the stream to its url
+func serveLLHls(ctx *gin.Context, stream *llhls.Stream, file string, uri func(name string) string) {
+	if file != llhlsPlaylistName {
+		b, err := stream.File(ctx.Request.Context(), file)
+		if err != nil {
+			ctx.AbortWithStatusJSON(llhlsErrorStatus(err), model.NewApiErrorResp(err))
+			return
+		}
+		ctx.Header("Cache-Control", "public, max-age=60")
+		ctx.Data(http.StatusOK, llhls.TSContentType, b)
+		return
+	}
+
+	var err error
+	msn, part := -1, -1
+	if v := ctx.Query("_HLS_msn"); v != "" {
+		if msn, err = strconv.Atoi(v); err != nil || msn < 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid _HLS_msn"))
+			return
+		}
+	}
+	if v := ctx.Query("_HLS_part"); v != "" {
+		if part, err = strconv.Atoi(v); err != nil || part < 0 || msn < 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid _HLS_part"))
+			return
+		}
+	}
+	b, err := stream.Playlist(ctx.Request.Context(), msn, part, uri)
+	if err != nil {
+		ctx.AbortWithStatusJSON(llhlsErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+	ctx.Data(http.StatusOK, llhls.PlaylistContentType, b)
+}
+
+// LLHlsLive serves the low latency hls stream of the live movie
+func LLHlsLive(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+	token := ctx.MustGet("token").(string)
+
+	ctx.Header("Cache-Control", "no-store")
+	m, ok := llhlsLiveMovie(ctx)
+	if !ok {
+		return
+	}
+	stream, err := m.LLHls()
+	if err != nil {
+		log.Errorf("llhls live error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	serveLLHls(ctx, stream, ctx.Param("file"), func(name string) string {
+		return fmt.Sprintf("/api/movie/live/llhls/%s/%s?token=%s", m.ID, name, token)
+	})
+}
+
+// AbrLiveMaster serves the master playlist of the renditions of the live movie
+func AbrLiveMaster(ctx *gin.Context) {
+	token := ctx.MustGet("token").(string)
+
+	ctx.Header("Cache-Control", "no-store")
+	if ctx.Param("rendition") != llhlsPlaylistName {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp("not found"))
+		return
+	}
+	if !transcode.EnableLiveLadder.Get() {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(transcode.ErrLiveLadderDisabled))
+		return
+	}
+	m, ok := llhlsLiveMovie(ctx)
+	if !ok {
+		return
+	}
+	ctx.Data(http.StatusOK, llhls.PlaylistContentType, transcode.MasterPlaylist(transcode.Ladder(), func(r transcode.Rendition) string {
+		return fmt.Sprintf("/api/movie/live/abr/%s/%s/%s?token=%s", m.ID, r.Name, llhlsPlaylistName, token)
+	}))
+}
+
+// AbrLive serves the low latency hls stream of a rendition of the live movie
+func AbrLive(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+	token := ctx.MustGet("token").(string)
+
+	ctx.Header("Cache-Control", "no-store")
+	r, ok := transcode.LadderRendition(ctx.Param("rendition"))
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorStringResp("rendition not found"))
+		return
+	}
+	m, ok := llhlsLiveMovie(ctx)
+	if !ok {
+		return
+	}
+	stream, err := m.LiveRendition(r)
+	if err != nil {
+		log.Errorf("abr live error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	serveLLHls(ctx, stream, ctx.Param("file"), func(name string) string {
+		return fmt.Sprintf("/api/movie/live/abr/%s/%s/%s?token=%s", m.ID, r.Name, name, token)
+	})
+}
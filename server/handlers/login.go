@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+var userLoginListSorts = map[string]listSort[*dbModel.UserLogin]{
+	"createdAt": {
+		column: db.SortColumn{Column: "created_at", Time: true},
+		value:  func(l *dbModel.UserLogin) any { return l.CreatedAt },
+	},
+}
+
+func UserLogins(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, err := newCursorList(ctx, userLoginListSorts, "createdAt", "desc", "id", func(l *dbModel.UserLogin) string {
+		return l.ID
+	})
+	if err != nil {
+		log.Errorf("failed to get page: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	total, err := db.GetUserLoginsCount(user.ID)
+	if err != nil {
+		log.Errorf("get user logins count failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	logins, err := db.GetUserLogins(user.ID, page.scope)
+	if err != nil {
+		log.Errorf("get user logins failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	logins, next := page.next(logins)
+
+	current := ctx.GetString("login")
+	list := make([]*model.UserLoginResp, len(logins))
+	for i, l := range logins {
+		list[i] = &model.UserLoginResp{
+			ID:        l.ID,
+			CreatedAt: l.CreatedAt.UnixMilli(),
+			IP:        l.IP,
+			UserAgent: l.UserAgent,
+			Anomalous: l.Anomalous,
+			Revoked:   l.Revoked,
+			Current:   l.ID == current,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total":      total,
+		"list":       list,
+		"nextCursor": next,
+	}))
+}
+
+func RevokeUserLogins(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.RevokeUserLoginsReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	n, err := user.RevokeLogins(req.IDs...)
+	if err != nil {
+		log.Errorf("revoke user logins failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"revoked": n,
+	}))
+}
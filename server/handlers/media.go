@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/media"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// uploads follow the tus 1.0.0 core protocol with the creation and
+// termination extensions, https://tus.io/protocols/resumable-upload
+
+const tusVersion = "1.0.0"
+
+func setTusHeaders(ctx *gin.Context) {
+	ctx.Header("Tus-Resumable", tusVersion)
+	ctx.Header("Cache-Control", "no-store")
+}
+
+func MediaUploadOptions(ctx *gin.Context) {
+	setTusHeaders(ctx)
+	ctx.Header("Tus-Version", tusVersion)
+	ctx.Header("Tus-Extension", "creation,termination")
+	if max := media.MaxFileSize.Get(); max != 0 {
+		ctx.Header("Tus-Max-Size", strconv.FormatInt(max*1024*1024, 10))
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func UserMediaFiles(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("failed to get page and max: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	var scopes []func(db *gorm.DB) *gorm.DB
+	if status := ctx.Query("status"); status != "" {
+		scopes = append(scopes, db.WhereMediaFileStatus(dbModel.MediaFileStatus(status)))
+	}
+
+	total, err := db.GetUserMediaFilesCount(user.ID, scopes...)
+	if err != nil {
+		log.Errorf("failed to get media files count: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	files, err := db.GetUserMediaFiles(user.ID, append(scopes, db.OrderByCreatedAtDesc, db.Paginate(page, pageSize))...)
+	if err != nil {
+		log.Errorf("failed to get media files: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	usage, err := db.GetUserMediaUsage(user.ID)
+	if err != nil {
+		log.Errorf("failed to get media usage: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	list := make([]*model.MediaFileResp, len(files))
+	for i, f := range files {
+		list[i] = model.NewMediaFileResp(f)
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total": total,
+		"list":  list,
+		"usage": usage,
+		// bytes, 0 is unlimited
+		"quota": media.UserQuota.Get() * 1024 * 1024,
+	}))
+}
+
+func CreateMediaUpload(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	setTusHeaders(ctx)
+
+	size, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size <= 0 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid Upload-Length"))
+		return
+	}
+
+	meta := utils.ParseTusMetadata(ctx.GetHeader("Upload-Metadata"))
+	name := meta["filename"]
+	if name == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("missing filename metadata"))
+		return
+	}
+	if len(name) > 256 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("filename too long"))
+		return
+	}
+	contentType := meta["filetype"]
+	if len(contentType) > 128 {
+		contentType = ""
+	}
+
+	file := &dbModel.MediaFile{
+		UserID:      user.ID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+	}
+	if err := media.Create(file); err != nil {
+		log.Errorf("failed to create media upload: %v", err)
+		switch {
+		case errors.Is(err, media.ErrUploadDisabled):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		case errors.Is(err, media.ErrFileTooLarge), errors.Is(err, media.ErrQuotaExceeded):
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/api/user/media/%s", file.ID))
+	ctx.JSON(http.StatusCreated, model.NewApiDataResp(model.NewMediaFileResp(file)))
+}
+
+func getUserMediaFile(ctx *gin.Context) (*dbModel.MediaFile, bool) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	file, err := db.GetUserMediaFile(user.ID, ctx.Param("id"))
+	if err != nil {
+		log.Errorf("failed to get media file: %v", err)
+		if errors.Is(err, db.ErrNotFound("media file")) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return nil, false
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return nil, false
+	}
+	return file, true
+}
+
+func MediaUploadOffset(ctx *gin.Context) {
+	setTusHeaders(ctx)
+
+	file, ok := getUserMediaFile(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Header("Upload-Offset", strconv.FormatInt(file.Uploaded, 10))
+	ctx.Header("Upload-Length", strconv.FormatInt(file.Size, 10))
+	ctx.Status(http.StatusOK)
+}
+
+func MediaUploadPatch(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	setTusHeaders(ctx)
+
+	if ctx.ContentType() != "application/offset+octet-stream" {
+		ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, model.NewApiErrorStringResp("content type must be application/offset+octet-stream"))
+		return
+	}
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid Upload-Offset"))
+		return
+	}
+
+	file, ok := getUserMediaFile(ctx)
+	if !ok {
+		return
+	}
+
+	uploaded, err := media.Write(file, offset, ctx.Request.Body)
+	ctx.Header("Upload-Offset", strconv.FormatInt(uploaded, 10))
+	if err != nil {
+		log.Errorf("failed to write media upload: %v", err)
+		switch {
+		case errors.Is(err, media.ErrOffsetMismatch):
+			ctx.AbortWithStatusJSON(http.StatusConflict, model.NewApiErrorResp(err))
+		case errors.Is(err, media.ErrUploadBusy):
+			ctx.AbortWithStatusJSON(http.StatusLocked, model.NewApiErrorResp(err))
+		case errors.Is(err, media.ErrNotUploading):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func DeleteMediaFile(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	setTusHeaders(ctx)
+
+	if err := media.Delete(user.ID, ctx.Param("id")); err != nil {
+		log.Errorf("failed to delete media file: %v", err)
+		switch {
+		case errors.Is(err, db.ErrNotFound("media file")):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		case errors.Is(err, media.ErrUploadBusy):
+			ctx.AbortWithStatusJSON(http.StatusLocked, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func isMediaURL(u string) bool {
+	_, ok := dbModel.ParseMediaURL(u)
+	return ok
+}
+
+// MediaMovie serves a movie of the local media library with range support
+func MediaMovie(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	m, err := room.GetMovieByID(ctx.Param("movieId"))
+	if err != nil {
+		log.Errorf("get movie by id error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	if !checkWatchMovie(ctx, user, room, m.Movie) {
+		return
+	}
+	fileID, ok := dbModel.ParseMediaURL(m.Movie.MovieBase.Url)
+	if !ok || m.Movie.MovieBase.VendorInfo.Vendor != "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("movie is not a media file"))
+		return
+	}
+
+	file, err := db.GetMediaFile(fileID)
+	if err != nil {
+		log.Errorf("get media file error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	// the file may have been replaced after the movie was added
+	if file.UserID != m.Movie.CreatorID {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("media file is not owned by the movie creator"))
+		return
+	}
+	f, err := media.Open(ctx.Request.Context(), file)
+	if err != nil {
+		log.Errorf("open media file error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	defer f.Close()
+
+	if file.ContentType != "" {
+		ctx.Header("Content-Type", file.ContentType)
+	}
+	ctx.Header("Cache-Control", "private, max-age=86400")
+	http.ServeContent(ctx.Writer, ctx.Request, file.Name, file.UpdatedAt, f)
+}
@@ -2,22 +2,49 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/db"
 	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
+	pb "github.com/synctv-org/synctv/proto/message"
 	"github.com/synctv-org/synctv/server/model"
-	"github.com/synctv-org/synctv/utils"
 	"gorm.io/gorm"
 )
 
+var (
+	userListSorts = map[string]listSort[*dbModel.User]{
+		"createdAt": {
+			column: db.SortColumn{Column: "users.created_at", Time: true},
+			value:  func(u *dbModel.User) any { return u.CreatedAt },
+		},
+		"name": {
+			column: db.SortColumn{Column: "users.username"},
+			value:  func(u *dbModel.User) any { return u.Username },
+		},
+	}
+	roomMemberListSorts = map[string]listSort[*dbModel.User]{
+		"join": userListSorts["createdAt"],
+		"name": userListSorts["name"],
+	}
+)
+
+func newUserCursorList(ctx *gin.Context, sorts map[string]listSort[*dbModel.User]) (*cursorList[*dbModel.User], error) {
+	return newCursorList(ctx, sorts, "name", "desc", "users.id", func(u *dbModel.User) string { return u.ID })
+}
+
 func RoomMembers(ctx *gin.Context) {
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if notModified(ctx, room, user, op.ResourceMembers, op.ResourceSettings) {
+		return
+	}
+
+	page, err := newUserCursorList(ctx, roomMemberListSorts)
 	if err != nil {
 		log.Errorf("get room users failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -73,44 +100,63 @@ func RoomMembers(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "join":
-		if desc {
-			scopes = append(scopes, db.OrderByUsersCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByUsersCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("username"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("username"))
-		}
-	default:
-		log.Errorf("get room users failed: not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := db.GetAllUsers(append(scopes, db.Paginate(page, pageSize))...)
+	list, err := db.GetAllUsers(append(scopes, page.scope)...)
 	if err != nil {
 		log.Errorf("get room users failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
+	list, next := page.next(list)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  genRoomMemberListResp(list, room),
+		"total":      total,
+		"list":       genRoomMemberListResp(list, room),
+		"nextCursor": next,
 	}))
 }
 
+func RoomMembersPresence(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+
+	presences := room.Presences()
+	resp := make([]*model.RoomMemberPresenceResp, len(presences))
+	for i, p := range presences {
+		resp[i] = &model.RoomMemberPresenceResp{
+			UserID:       p.UserID,
+			Username:     p.Username,
+			State:        presenceStateString(p.State),
+			LastActiveAt: p.LastActive.UnixMilli(),
+			OnlineCount:  p.OnlineCount,
+			AudioOnly:    p.AudioOnly,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func presenceStateString(state pb.PresenceState) string {
+	switch state {
+	case pb.PresenceState_PRESENCE_ONLINE:
+		return "online"
+	case pb.PresenceState_PRESENCE_IDLE:
+		return "idle"
+	case pb.PresenceState_PRESENCE_AWAY:
+		return "away"
+	default:
+		return "unknown"
+	}
+}
+
 func RoomAdminMembers(ctx *gin.Context) {
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if notModified(ctx, room, user, op.ResourceMembers, op.ResourceSettings) {
+		return
+	}
+
+	page, err := newUserCursorList(ctx, roomMemberListSorts)
 	if err != nil {
 		log.Errorf("get room users failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -175,36 +221,18 @@ func RoomAdminMembers(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "join":
-		if desc {
-			scopes = append(scopes, db.OrderByUsersCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByUsersCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("username"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("username"))
-		}
-	default:
-		log.Errorf("get room users failed: not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := db.GetAllUsers(append(scopes, db.Paginate(page, pageSize))...)
+	list, err := db.GetAllUsers(append(scopes, page.scope)...)
 	if err != nil {
 		log.Errorf("get room users failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
+	list, next := page.next(list)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  genRoomMemberListResp(list, room),
+		"total":      total,
+		"list":       genRoomMemberListResp(list, room),
+		"nextCursor": next,
 	}))
 }
 
@@ -318,6 +346,39 @@ func RoomSetAdmin(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+func RoomSetTrialAdmin(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.RoomSetTrialAdminReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode room set trial admin req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	err := user.SetRoomTrialAdmin(room, req.ID, req.AdminPermissions, time.Duration(req.Duration)*time.Second, req.Actions)
+	if err != nil {
+		log.Errorf("set room trial admin failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func setTrialResp(resp *model.RoomMembersResp, member *dbModel.RoomMember) {
+	if !member.Trial {
+		return
+	}
+	resp.Trial = true
+	resp.TrialActions = member.TrialActions
+	if member.TrialExpireAt != nil {
+		resp.TrialExpireAt = member.TrialExpireAt.UnixMilli()
+	}
+}
+
 func RoomSetMember(ctx *gin.Context) {
 	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
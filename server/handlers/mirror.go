@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func RoomAdminMirror(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	m, err := db.GetRoomMirror(room.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("room mirror")) {
+			ctx.JSON(http.StatusOK, model.NewApiDataResp(nil))
+			return
+		}
+		log.Errorf("get room mirror failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := &model.RoomMirrorResp{
+		RemoteURL: m.RemoteURL,
+		Enabled:   m.Enabled,
+		UpdatedAt: m.UpdatedAt.UnixMilli(),
+	}
+	if s, ok := op.RoomMirrorStatus(room.ID); ok {
+		resp.Connected = s.Connected
+		resp.Error = s.Error
+		resp.Since = s.Since.UnixMilli()
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func RoomAdminSetMirror(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.RoomMirrorReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode room mirror req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.SetRoomMirror(room, req.RemoteURL, req.Token, req.Enabled); err != nil {
+		log.Errorf("set room mirror failed: %v", err)
+		if errors.Is(err, dbModel.ErrNoPermission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminDeleteMirror(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if err := user.DeleteRoomMirror(room); err != nil {
+		log.Errorf("delete room mirror failed: %v", err)
+		switch {
+		case errors.Is(err, dbModel.ErrNoPermission):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		case errors.Is(err, db.ErrNotFound("room mirror")):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -25,8 +25,10 @@ import (
 	"github.com/synctv-org/synctv/internal/db"
 	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/roomlog"
 	"github.com/synctv-org/synctv/internal/rtmp"
 	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/transcode"
 	"github.com/synctv-org/synctv/internal/vendor"
 	"github.com/synctv-org/synctv/server/model"
 	"github.com/synctv-org/synctv/utils"
@@ -36,7 +38,7 @@ import (
 	"github.com/zijiren233/livelib/protocol/hls"
 	"github.com/zijiren233/livelib/protocol/httpflv"
 	"github.com/zijiren233/stream"
-	"golang.org/x/exp/maps"
+	"gorm.io/gorm"
 )
 
 func GetPageItems[T any](ctx *gin.Context, items []T) ([]T, error) {
@@ -104,6 +106,20 @@ func genMovieInfo(
 			return nil, errors.New("movie is static folder, can't get movie info")
 		}
 	}
+	if lock, err := movieLock(user, opMovie.Movie); err != nil {
+		return nil, err
+	} else if lock != op.MovieUnlocked {
+		resp := &model.Movie{
+			Id:           opMovie.ID,
+			CreatedAt:    opMovie.CreatedAt.UnixMilli(),
+			Base:         opMovie.MovieBase,
+			Creator:      op.GetUserName(opMovie.CreatorID),
+			CreatorId:    opMovie.CreatorID,
+			NeedPassword: opMovie.NeedPassword(),
+		}
+		lockMovie(resp, lock)
+		return resp, nil
+	}
 	var movie = opMovie.Movie.Clone()
 	if movie.MovieBase.VendorInfo.Vendor != "" {
 		vendorMovie, err := genVendorMovie(ctx, user, opMovie, userAgent, userToken)
@@ -114,15 +130,61 @@ func genMovieInfo(
 	} else if movie.MovieBase.RtmpSource || movie.MovieBase.Live && movie.MovieBase.Proxy {
 		movie.MovieBase.Url = fmt.Sprintf("/api/movie/live/hls/list/%s.m3u8?token=%s", movie.ID, userToken)
 		movie.MovieBase.Type = "m3u8"
+		if lowLatencyLive(movie.RoomID) {
+			movie.MoreSources = append(movie.MoreSources, &dbModel.MoreSource{
+				Name: "hls",
+				Url:  movie.MovieBase.Url,
+				Type: "m3u8",
+			})
+			movie.MovieBase.Url = fmt.Sprintf("/api/movie/live/llhls/%s/index.m3u8?token=%s", movie.ID, userToken)
+		}
+		if transcode.EnableLiveLadder.Get() {
+			movie.MoreSources = append(movie.MoreSources, &dbModel.MoreSource{
+				Name: "abr",
+				Url:  fmt.Sprintf("/api/movie/live/abr/%s/index.m3u8?token=%s", movie.ID, userToken),
+				Type: "m3u8",
+			})
+		}
 		movie.MoreSources = append(movie.MoreSources, &dbModel.MoreSource{
 			Name: "flv",
 			Url:  fmt.Sprintf("/api/movie/live/flv/%s.flv?token=%s", movie.ID, userToken),
 			Type: "flv",
 		})
 		movie.MovieBase.Headers = nil
-	} else if movie.MovieBase.Proxy {
-		movie.MovieBase.Url = fmt.Sprintf("/api/movie/proxy/%s/%s?token=%s", movie.RoomID, movie.ID, userToken)
+		movie.MovieBase.HeaderRules = nil
+	} else if fileID, ok := dbModel.ParseMediaURL(movie.MovieBase.Url); ok {
+		if movie.MovieBase.Type == "" {
+			if file, err := db.GetMediaFile(fileID); err == nil {
+				movie.MovieBase.Type = utils.GetUrlExtension(file.Name)
+			}
+		}
+		movie.MovieBase.Url = fmt.Sprintf("/api/movie/media/%s?token=%s", movie.ID, userToken)
 		movie.MovieBase.Headers = nil
+		movie.MovieBase.HeaderRules = nil
+	} else {
+		if airPlayWrappable(movie) {
+			movie.MoreSources = append(movie.MoreSources, &dbModel.MoreSource{
+				Name: "airplay",
+				Url:  airPlaySourceURL(movie, userToken),
+				Type: "m3u8",
+			})
+		}
+		if movie.MovieBase.Proxy {
+			if cdnEnabled() {
+				movie.MovieBase.Url = cdnProxyURL(movie)
+			} else {
+				movie.MovieBase.Url = fmt.Sprintf("/api/movie/proxy/%s/%s?token=%s", movie.RoomID, movie.ID, userToken)
+			}
+			movie.MovieBase.Headers = nil
+			movie.MovieBase.HeaderRules = nil
+		}
+	}
+	if audioOnlyAvailable(opMovie.Movie) {
+		movie.MoreSources = append(movie.MoreSources, &dbModel.MoreSource{
+			Name: "audio",
+			Url:  audioOnlySourceURL(movie, userToken),
+			Type: "aac",
+		})
 	}
 	if movie.MovieBase.Type == "" && movie.MovieBase.Url != "" {
 		movie.MovieBase.Type = utils.GetUrlExtension(movie.MovieBase.Url)
@@ -133,13 +195,15 @@ func genMovieInfo(
 		}
 	}
 	resp := &model.Movie{
-		Id:        movie.ID,
-		CreatedAt: movie.CreatedAt.UnixMilli(),
-		Base:      movie.MovieBase,
-		Creator:   op.GetUserName(movie.CreatorID),
-		CreatorId: movie.CreatorID,
-		SubPath:   opMovie.SubPath(),
-	}
+		Id:           movie.ID,
+		CreatedAt:    movie.CreatedAt.UnixMilli(),
+		Base:         movie.MovieBase,
+		Creator:      op.GetUserName(movie.CreatorID),
+		CreatorId:    movie.CreatorID,
+		SubPath:      opMovie.SubPath(),
+		NeedPassword: opMovie.NeedPassword(),
+	}
+	resp.Base.Extensions = opMovie.Extensions()
 	return resp, nil
 }
 
@@ -181,6 +245,21 @@ func CurrentMovie(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(currentResp))
 }
 
+var movieListSorts = map[string]listSort[*dbModel.Movie]{
+	"position": {
+		column: db.SortColumn{Column: "position"},
+		value:  func(m *dbModel.Movie) any { return m.Position },
+	},
+	"createdAt": {
+		column: db.SortColumn{Column: "created_at", Time: true},
+		value:  func(m *dbModel.Movie) any { return m.CreatedAt },
+	},
+	"name": {
+		column: db.SortColumn{Column: "base_name"},
+		value:  func(m *dbModel.Movie) any { return m.MovieBase.Name },
+	},
+}
+
 func Movies(ctx *gin.Context) {
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
 	user := ctx.MustGet("user").(*op.UserEntry).Value()
@@ -197,13 +276,6 @@ func Movies(ctx *gin.Context) {
 		return
 	}
 
-	page, max, err := utils.GetPageAndMax(ctx)
-	if err != nil {
-		log.Errorf("get page and max error: %v", err)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
-		return
-	}
-
 	if id != "" {
 		mv, err := room.GetMovieByID(id)
 		if err != nil {
@@ -216,6 +288,12 @@ func Movies(ctx *gin.Context) {
 			return
 		}
 		if mv.IsDynamicFolder() {
+			page, max, err := utils.GetPageAndMax(ctx)
+			if err != nil {
+				log.Errorf("get page and max error: %v", err)
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+				return
+			}
 			resp, err := listVendorDynamicMovie(ctx, user, room, mv.Movie, ctx.Query("subPath"), page, max)
 			if err != nil {
 				log.Errorf("vendor dynamic movie list error: %v", err)
@@ -227,7 +305,26 @@ func Movies(ctx *gin.Context) {
 		}
 	}
 
-	m, total, err := user.GetRoomMoviesWithPage(room, page, max, id)
+	// the locks and restrictions of the movies depend on the members and settings
+	if notModified(ctx, room, user, op.ResourceMovies, op.ResourceMembers, op.ResourceSettings) {
+		return
+	}
+
+	page, err := newCursorList(ctx, movieListSorts, "position", "asc", "id", func(m *dbModel.Movie) string {
+		return m.ID
+	})
+	if err != nil {
+		log.Errorf("get movie list page error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	var filters []func(*gorm.DB) *gorm.DB
+	if keyword := ctx.Query("keyword"); keyword != "" {
+		filters = append(filters, db.WhereMovieNameLike(keyword))
+	}
+
+	m, total, err := user.GetRoomMoviesWithPage(room, id, page.scope, filters...)
 	if err != nil {
 		log.Errorf("get room movies with page error: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -241,24 +338,34 @@ func Movies(ctx *gin.Context) {
 		return
 	}
 
+	m, next := page.next(m)
 	resp := &model.MoviesResp{
-		Total:  total,
-		Movies: make([]*model.Movie, len(m)),
-		Paths:  paths,
+		Total:      total,
+		Movies:     make([]*model.Movie, len(m)),
+		Paths:      paths,
+		NextCursor: next,
 	}
 
 	for i, v := range m {
 		resp.Movies[i] = &model.Movie{
-			Id:        v.ID,
-			CreatedAt: v.CreatedAt.UnixMilli(),
-			Base:      v.MovieBase,
-			Creator:   op.GetUserName(v.CreatorID),
-			CreatorId: v.CreatorID,
+			Id:           v.ID,
+			CreatedAt:    v.CreatedAt.UnixMilli(),
+			Base:         v.MovieBase,
+			Creator:      op.GetUserName(v.CreatorID),
+			CreatorId:    v.CreatorID,
+			Version:      v.Version,
+			NeedPassword: v.NeedPassword(),
 		}
 		// hide url and headers when proxy
 		if user.ID != v.CreatorID && v.MovieBase.Proxy {
 			resp.Movies[i].Base.Url = ""
 			resp.Movies[i].Base.Headers = nil
+			resp.Movies[i].Base.HeaderRules = nil
+		}
+		if lock := user.MovieLock(room, v); lock != op.MovieUnlocked {
+			lockMovie(resp.Movies[i], lock)
+		} else if !canRestrictMovie(user, room, v) {
+			resp.Movies[i].Base.Restriction = nil
 		}
 	}
 
@@ -435,6 +542,11 @@ func PushMovie(ctx *gin.Context) {
 		return
 	}
 
+	// only probe sources for users who may add them
+	if user.HasRoomPermission(room, dbModel.PermissionAddMovie) {
+		applyAutoProxy(ctx, requestHost(ctx), (*dbModel.MovieBase)(&req), user.ID)
+	}
+
 	m, err := user.AddRoomMovie(room, (*dbModel.MovieBase)(&req))
 	if err != nil {
 		log.Errorf("push movie error: %v", err)
@@ -467,8 +579,12 @@ func PushMovies(ctx *gin.Context) {
 
 	var ms []*dbModel.MovieBase = make([]*dbModel.MovieBase, len(req))
 
+	canAdd := user.HasRoomPermission(room, dbModel.PermissionAddMovie)
 	for i, v := range req {
 		ms[i] = (*dbModel.MovieBase)(v)
+		if canAdd {
+			applyAutoProxy(ctx, requestHost(ctx), ms[i], user.ID)
+		}
 	}
 
 	m, err := user.AddRoomMovies(room, ms)
@@ -539,6 +655,15 @@ func NewPublishKey(ctx *gin.Context) {
 		return
 	}
 
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"host":  publishHost(ctx),
+		"app":   room.ID,
+		"token": token,
+	}))
+}
+
+// publishHost is the host rtmp sources are published to
+func publishHost(ctx *gin.Context) string {
 	host := settings.CustomPublishHost.Get()
 	if host == "" {
 		host = HOST.Get()
@@ -546,12 +671,39 @@ func NewPublishKey(ctx *gin.Context) {
 	if host == "" {
 		host = ctx.Request.Host
 	}
+	return host
+}
 
-	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"host":  host,
-		"app":   room.ID,
-		"token": token,
-	}))
+func UnlockMovie(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.UnlockMovieReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("unlock movie error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	m, err := room.GetMovieByID(req.Id)
+	if err != nil {
+		log.Errorf("unlock movie error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.UnlockMovie(room, m.Movie, req.Password); err != nil {
+		log.Errorf("unlock movie error: %v", err)
+		if errors.Is(err, op.ErrMoviePassword) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
 }
 
 func EditMovie(ctx *gin.Context) {
@@ -566,9 +718,22 @@ func EditMovie(ctx *gin.Context) {
 		return
 	}
 
+	if user.HasRoomPermission(room, dbModel.PermissionEditMovie) {
+		// vendor tokens are resolved from the bindings of the movie creator
+		creatorID := user.ID
+		if m, err := room.GetMovieByID(req.Id); err == nil {
+			creatorID = m.Movie.CreatorID
+			// only the movie creator restricts who can watch
+			if !canRestrictMovie(user, room, m.Movie) {
+				req.Restriction = m.Movie.MovieBase.Restriction
+			}
+		}
+		applyAutoProxy(ctx, requestHost(ctx), (*dbModel.MovieBase)(&req.PushMovieReq), creatorID)
+	}
+
 	if err := user.UpdateRoomMovie(room, req.Id, (*dbModel.MovieBase)(&req.PushMovieReq)); err != nil {
 		log.Errorf("edit movie error: %v", err)
-		if errors.Is(err, dbModel.ErrNoPermission) {
+		if errors.Is(err, dbModel.ErrNoPermission) || errors.Is(err, op.ErrCreatorCredentials) {
 			ctx.AbortWithStatusJSON(
 				http.StatusForbidden,
 				model.NewApiErrorResp(
@@ -584,6 +749,114 @@ func EditMovie(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// PatchMovie edits only the fields in the json merge patch of the request,
+// edits based on an outdated version of the movie are answered with 409
+func PatchMovie(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.PatchMovieReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("patch movie error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	m, err := room.GetMovieByID(req.Id)
+	if err != nil {
+		log.Errorf("patch movie error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	mv, err := user.PatchRoomMovie(room, req.Id, req.Version, req.Patch, func(base *dbModel.MovieBase) error {
+		if err := (*model.PushMovieReq)(base).Validate(); err != nil {
+			return err
+		}
+		// only the movie creator restricts who can watch
+		if !canRestrictMovie(user, room, m.Movie) {
+			base.Restriction = m.Movie.MovieBase.Restriction
+		}
+		// vendor tokens are resolved from the bindings of the movie creator
+		applyAutoProxy(ctx, requestHost(ctx), base, m.Movie.CreatorID)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("patch movie error: %v", err)
+		var conflict *op.MovieVersionConflictError
+		switch {
+		case errors.Is(err, dbModel.ErrNoPermission), errors.Is(err, op.ErrCreatorCredentials):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(fmt.Errorf("patch movie error: %w", err)))
+		case errors.As(err, &conflict):
+			ctx.AbortWithStatusJSON(http.StatusConflict, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"version": mv.Version,
+	}))
+}
+
+// MigrateMovie copies or moves a movie to another room the user administers
+func MigrateMovie(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.MigrateMovieReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("migrate movie error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	dst, err := op.LoadOrInitRoomByID(req.RoomId)
+	if err != nil {
+		log.Errorf("migrate movie error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	migrations, err := user.MigrateRoomMovie(room, req.Id, dst.Value(), req.ParentId, req.Move)
+	if err != nil {
+		log.Errorf("migrate movie error: %v", err)
+		if errors.Is(err, dbModel.ErrNoPermission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.MigratedMovieResp, len(migrations))
+	for i, m := range migrations {
+		resp[i] = &model.MigratedMovieResp{
+			From: m.From,
+			ID:   m.Movie.ID,
+			Name: m.Movie.Name,
+		}
+		if !m.Movie.RtmpSource || m.Movie.CreatorID != user.ID || !conf.Conf.Server.Rtmp.Enable {
+			continue
+		}
+		token, err := rtmp.NewRtmpAuthorization(m.Movie.ID)
+		if err != nil {
+			log.Errorf("new publish key error: %v", err)
+			continue
+		}
+		resp[i].PublishKey = &model.PublishKeyResp{
+			Host:  publishHost(ctx),
+			App:   dst.Value().ID,
+			Token: token,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
 func DelMovie(ctx *gin.Context) {
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
 	user := ctx.MustGet("user").(*op.UserEntry).Value()
@@ -596,7 +869,7 @@ func DelMovie(ctx *gin.Context) {
 		return
 	}
 
-	err := user.DeleteRoomMoviesByID(room, req.Ids)
+	batchID, err := user.DeleteRoomMoviesByID(room, req.Ids)
 	if err != nil {
 		log.Errorf("del movie error: %v", err)
 		if errors.Is(err, dbModel.ErrNoPermission) {
@@ -612,7 +885,7 @@ func DelMovie(ctx *gin.Context) {
 		return
 	}
 
-	ctx.Status(http.StatusNoContent)
+	respTrashed(ctx, batchID)
 }
 
 func ClearMovies(ctx *gin.Context) {
@@ -625,7 +898,8 @@ func ClearMovies(ctx *gin.Context) {
 		return
 	}
 
-	if err := user.ClearRoomMoviesByParentID(room, req.ParentId); err != nil {
+	batchID, err := user.ClearRoomMoviesByParentID(room, req.ParentId)
+	if err != nil {
 		if errors.Is(err, dbModel.ErrNoPermission) {
 			ctx.AbortWithStatusJSON(
 				http.StatusForbidden,
@@ -639,7 +913,7 @@ func ClearMovies(ctx *gin.Context) {
 		return
 	}
 
-	ctx.Status(http.StatusNoContent)
+	respTrashed(ctx, batchID)
 }
 
 func SwapMovie(ctx *gin.Context) {
@@ -692,6 +966,7 @@ func ChangeCurrentMovie(ctx *gin.Context) {
 }
 
 func ProxyMovie(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
 	if !settings.MovieProxy.Get() {
@@ -720,11 +995,20 @@ func ProxyMovie(ctx *gin.Context) {
 		return
 	}
 
+	if !checkWatchMovie(ctx, user, room.Value(), m.Movie) {
+		return
+	}
+
 	if m.Movie.MovieBase.VendorInfo.Vendor != "" {
 		proxyVendorMovie(ctx, m)
 		return
 	}
 
+	proxyMovie(ctx, roomlog.With(log, m.RoomID, roomlog.KindProxy), m)
+}
+
+// proxyMovie proxies the source of a movie added with proxy enabled
+func proxyMovie(ctx *gin.Context, log *logrus.Entry, m *op.Movie) {
 	if !m.Movie.MovieBase.Proxy || m.Movie.MovieBase.Live || m.Movie.MovieBase.RtmpSource {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support movie proxy"))
 		return
@@ -735,7 +1019,13 @@ func ProxyMovie(ctx *gin.Context) {
 		// TODO: cache mpd file
 		fallthrough
 	default:
-		err = proxyURL(ctx, m.Movie.MovieBase.Url, m.Movie.MovieBase.Headers)
+		header, err := m.SourceHeader(ctx, ctx.Request.Header)
+		if err != nil {
+			log.Errorf("proxy movie header error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+			return
+		}
+		err = proxyURL(ctx, m.Movie.MovieBase.Url, header)
 		if err != nil {
 			log.Errorf("proxy movie error: %v", err)
 			return
@@ -782,7 +1072,7 @@ func ProxyMovie(ctx *gin.Context) {
 // 	}
 // }
 
-func proxyURL(ctx *gin.Context, u string, headers map[string]string) error {
+func proxyURL(ctx *gin.Context, u string, headers http.Header) error {
 	if utils.GetUrlExtension(u) == "m3u8" {
 		ctx.Redirect(http.StatusFound, u)
 		return nil
@@ -801,7 +1091,7 @@ func proxyURL(ctx *gin.Context, u string, headers map[string]string) error {
 		return fmt.Errorf("new request error: %w", err)
 	}
 	for k, v := range headers {
-		req.Header.Set(k, v)
+		req.Header[k] = v
 	}
 	req.Header.Set("Range", ctx.GetHeader("Range"))
 	req.Header.Set("Accept-Encoding", ctx.GetHeader("Accept-Encoding"))
@@ -812,7 +1102,7 @@ func proxyURL(ctx *gin.Context, u string, headers map[string]string) error {
 	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 		req.Header.Del("Referer")
 		for k, v := range headers {
-			req.Header.Set(k, v)
+			req.Header[k] = v
 		}
 		req.Header.Set("Range", ctx.GetHeader("Range"))
 		req.Header.Set("Accept-Encoding", ctx.GetHeader("Accept-Encoding"))
@@ -828,7 +1118,10 @@ func proxyURL(ctx *gin.Context, u string, headers map[string]string) error {
 	defer resp.Body.Close()
 	ctx.Status(resp.StatusCode)
 	ctx.Header("Accept-Ranges", resp.Header.Get("Accept-Ranges"))
-	ctx.Header("Cache-Control", resp.Header.Get("Cache-Control"))
+	// cdn urls set their own
+	if ctx.Writer.Header().Get("Cache-Control") == "" {
+		ctx.Header("Cache-Control", resp.Header.Get("Cache-Control"))
+	}
 	ctx.Header("Content-Length", resp.Header.Get("Content-Length"))
 	ctx.Header("Content-Range", resp.Header.Get("Content-Range"))
 	ctx.Header("Content-Type", resp.Header.Get("Content-Type"))
@@ -919,12 +1212,21 @@ func JoinLive(ctx *gin.Context) {
 }
 
 func JoinFlvLive(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
 	ctx.Header("Cache-Control", "no-store")
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
 	movieId := strings.TrimSuffix(strings.Trim(ctx.Param("movieId"), "/"), ".flv")
 	m, err := room.GetMovieByID(movieId)
+	if err != nil {
+		log.Errorf("join flv live error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	if !checkWatchMovie(ctx, user, room, m.Movie) {
+		return
+	}
 	if !m.Movie.MovieBase.Live {
 		log.Error("join hls live error: live is not enabled")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("live is not enabled"))
@@ -964,6 +1266,7 @@ func JoinFlvLive(ctx *gin.Context) {
 }
 
 func JoinHlsLive(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 	token := ctx.MustGet("token").(string)
 
@@ -976,6 +1279,9 @@ func JoinHlsLive(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
 		return
 	}
+	if !checkWatchMovie(ctx, user, room, m.Movie) {
+		return
+	}
 	if !m.Movie.MovieBase.Live {
 		log.Error("join hls live error: live is not enabled")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("live is not enabled"))
@@ -1004,6 +1310,9 @@ func JoinHlsLive(ctx *gin.Context) {
 		if settings.TsDisguisedAsPng.Get() {
 			ext = "png"
 		}
+		if cdnEnabled() {
+			return cdnURL(fmt.Sprintf("/api/movie/cdn/hls/%s/%s/%s.%s", room.ID, movieId, tsName, ext))
+		}
 		return fmt.Sprintf("/api/movie/live/hls/data/%s/%s/%s.%s?token=%s", room.ID, movieId, tsName, ext, token)
 	})
 	if err != nil {
@@ -1015,6 +1324,7 @@ func JoinHlsLive(ctx *gin.Context) {
 }
 
 func ServeHlsLive(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
 	ctx.Header("Cache-Control", "no-store")
@@ -1033,6 +1343,15 @@ func ServeHlsLive(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
 		return
 	}
+	if !checkWatchMovie(ctx, user, room, m.Movie) {
+		return
+	}
+	serveHlsLiveData(ctx, roomlog.With(log, m.RoomID, roomlog.KindProxy), m, "public, max-age=90")
+}
+
+// serveHlsLiveData serves a segment of the hls live, cacheControl is set on
+// segments
+func serveHlsLiveData(ctx *gin.Context, log *logrus.Entry, m *op.Movie, cacheControl string) {
 	if !m.Movie.MovieBase.Live {
 		log.Error("join hls live error: live is not enabled")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("live is not enabled"))
@@ -1070,7 +1389,7 @@ func ServeHlsLive(ctx *gin.Context) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
 			return
 		}
-		ctx.Header("Cache-Control", "public, max-age=90")
+		ctx.Header("Cache-Control", cacheControl)
 		ctx.Data(http.StatusOK, hls.TSContentType, b)
 	case ".png":
 		if !settings.TsDisguisedAsPng.Get() {
@@ -1084,7 +1403,7 @@ func ServeHlsLive(ctx *gin.Context) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
 			return
 		}
-		ctx.Header("Cache-Control", "public, max-age=90")
+		ctx.Header("Cache-Control", cacheControl)
 		img := image.NewGray(image.Rect(0, 0, 1, 1))
 		img.Set(1, 1, color.Gray{uint8(rand.Intn(255))})
 		cache := bytes.NewBuffer(make([]byte, 0, 71))
@@ -1103,7 +1422,12 @@ func ServeHlsLive(ctx *gin.Context) {
 }
 
 func proxyVendorMovie(ctx *gin.Context, movie *op.Movie) {
-	log := ctx.MustGet("log").(*logrus.Entry)
+	log := roomlog.With(ctx.MustGet("log").(*logrus.Entry), movie.RoomID, roomlog.KindProxy)
+
+	if err := settings.CheckVendorEnabled(movie.Movie.MovieBase.VendorInfo.Vendor); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, model.NewApiErrorResp(err))
+		return
+	}
 
 	switch movie.Movie.MovieBase.VendorInfo.Vendor {
 	case dbModel.VendorBilibili:
@@ -1173,16 +1497,14 @@ func proxyVendorMovie(ctx *gin.Context, movie *op.Movie) {
 						ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("stream id out of range"))
 						return
 					}
-					headers := maps.Clone(movie.Movie.MovieBase.Headers)
-					if headers == nil {
-						headers = map[string]string{
-							"Referer":    "https://www.bilibili.com",
-							"User-Agent": utils.UA,
-						}
-					} else {
-						headers["Referer"] = "https://www.bilibili.com"
-						headers["User-Agent"] = utils.UA
+					headers, err := movie.SourceHeader(ctx, ctx.Request.Header)
+					if err != nil {
+						log.Errorf("proxy vendor movie header error: %v", err)
+						ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+						return
 					}
+					headers.Set("Referer", "https://www.bilibili.com")
+					headers.Set("User-Agent", utils.UA)
 					err = proxyURL(ctx, mpdC.Urls[streamId], headers)
 					if err != nil {
 						log.Errorf("proxy vendor movie [%s] error: %v", mpdC.Urls[streamId], err)
@@ -1395,6 +1717,78 @@ func proxyVendorMovie(ctx *gin.Context, movie *op.Movie) {
 			return
 		}
 
+	case dbModel.VendorDouyin:
+		if !movie.Movie.MovieBase.Proxy {
+			log.Errorf("proxy vendor movie error: %v", "not support movie proxy")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support movie proxy"))
+			return
+		}
+		data, err := movie.DouyinCache().Get(ctx)
+		if err != nil {
+			log.Errorf("proxy vendor movie error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+			return
+		}
+		header := make(http.Header, len(data.Headers))
+		for k, v := range data.Headers {
+			header.Set(k, v)
+		}
+		err = proxyURL(ctx, data.URL, header)
+		if err != nil {
+			log.Errorf("proxy vendor movie error: %v", err)
+		}
+		return
+
+	case dbModel.VendorAcfun, dbModel.VendorYouku:
+		data, err := movie.CookieVendorCache().Get(ctx)
+		if err != nil {
+			log.Errorf("proxy vendor movie error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+			return
+		}
+		source, err := strconv.Atoi(ctx.DefaultQuery("source", "0"))
+		if err != nil {
+			log.Errorf("proxy vendor movie error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			return
+		}
+		if source < 0 || source >= len(data.Streams) {
+			log.Errorf("proxy vendor movie error: %v", "source out of range")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("source out of range"))
+			return
+		}
+		ctx.Redirect(http.StatusFound, data.Streams[source].URL)
+		return
+
+	case dbModel.VendorPlugin:
+		data, err := movie.PluginVendorCache().Get(ctx)
+		if err != nil {
+			log.Errorf("proxy vendor movie error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadGateway, model.NewApiErrorResp(err))
+			return
+		}
+		source, err := strconv.Atoi(ctx.DefaultQuery("source", "0"))
+		if err != nil {
+			log.Errorf("proxy vendor movie error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			return
+		}
+		if source < 0 || source >= len(data.Streams) {
+			log.Errorf("proxy vendor movie error: %v", "source out of range")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("source out of range"))
+			return
+		}
+		stream := data.Streams[source]
+		header := make(http.Header, len(stream.Headers))
+		for k, v := range stream.Headers {
+			header.Set(k, v)
+		}
+		err = proxyURL(ctx, stream.Url, header)
+		if err != nil {
+			log.Errorf("proxy vendor movie error: %v", err)
+		}
+		return
+
 	default:
 		log.Errorf("proxy vendor movie error: %v", "vendor not support proxy")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("vendor not support proxy"))
@@ -1404,6 +1798,9 @@ func proxyVendorMovie(ctx *gin.Context, movie *op.Movie) {
 
 // user is the api requester
 func genVendorMovie(ctx context.Context, user *op.User, opMovie *op.Movie, userAgent, userToken string) (*dbModel.Movie, error) {
+	if err := settings.CheckVendorEnabled(opMovie.Movie.MovieBase.VendorInfo.Vendor); err != nil {
+		return nil, err
+	}
 	movie := *opMovie.Movie
 	var err error
 	switch movie.MovieBase.VendorInfo.Vendor {
@@ -1642,6 +2039,90 @@ func genVendorMovie(ctx context.Context, user *op.User, opMovie *op.Movie, userA
 
 		return &movie, nil
 
+	case dbModel.VendorDouyin:
+		if movie.IsFolder {
+			return nil, fmt.Errorf("douyin folder not support")
+		}
+		// live rooms are pulled as flv, videos are mp4
+		movie.MovieBase.Type = "mp4"
+		if movie.MovieBase.Live {
+			movie.MovieBase.Type = "flv"
+		}
+		if movie.MovieBase.Proxy {
+			movie.MovieBase.Url = fmt.Sprintf("/api/movie/proxy/%s/%s?token=%s", movie.RoomID, movie.ID, userToken)
+			return &movie, nil
+		}
+		data, err := opMovie.DouyinCache().Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		movie.MovieBase.Url = data.URL
+		return &movie, nil
+
+	case dbModel.VendorAcfun, dbModel.VendorYouku:
+		if movie.IsFolder {
+			return nil, fmt.Errorf("%s folder not support", movie.MovieBase.VendorInfo.Vendor)
+		}
+		data, err := opMovie.CookieVendorCache().Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// the proxy redirects to the latest signed url of the stream
+		for i, s := range data.Streams {
+			u := fmt.Sprintf("/api/movie/proxy/%s/%s?source=%d&token=%s", movie.RoomID, movie.ID, i, userToken)
+			if i == 0 {
+				movie.MovieBase.Url = u
+				movie.MovieBase.Type = "m3u8"
+				continue
+			}
+			movie.MovieBase.MoreSources = append(movie.MovieBase.MoreSources, &dbModel.MoreSource{
+				Name: s.Name,
+				Type: "m3u8",
+				Url:  u,
+			})
+		}
+		return &movie, nil
+
+	case dbModel.VendorPlugin:
+		if movie.IsFolder {
+			return nil, fmt.Errorf("plugin folder not support")
+		}
+		data, err := opMovie.PluginVendorCache().Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range data.Streams {
+			u := s.Url
+			// browsers can not send the headers the plugin asks for
+			if movie.MovieBase.Proxy || len(s.Headers) != 0 {
+				u = fmt.Sprintf("/api/movie/proxy/%s/%s?source=%d&token=%s", movie.RoomID, movie.ID, i, userToken)
+			}
+			if i == 0 {
+				movie.MovieBase.Url = u
+				movie.MovieBase.Type = s.Type
+				continue
+			}
+			movie.MovieBase.MoreSources = append(movie.MovieBase.MoreSources, &dbModel.MoreSource{
+				Name: s.Name,
+				Type: s.Type,
+				Url:  u,
+			})
+		}
+		if len(data.Subtitles) != 0 {
+			subtitles := make(map[string]*dbModel.Subtitle, len(movie.MovieBase.Subtitles)+len(data.Subtitles))
+			for k, v := range movie.MovieBase.Subtitles {
+				subtitles[k] = v
+			}
+			movie.MovieBase.Subtitles = subtitles
+			for _, s := range data.Subtitles {
+				subtitles[s.Name] = &dbModel.Subtitle{
+					URL:  s.Url,
+					Type: s.Type,
+				}
+			}
+		}
+		return &movie, nil
+
 	default:
 		return nil, fmt.Errorf("vendor not implement gen movie url")
 	}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+// respTrashed answers a deletion with the trash batch that undoes it
+func respTrashed(ctx *gin.Context, batchID string) {
+	if batchID == "" {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"trashBatchId": batchID,
+	}))
+}
+
+func trashBatches(movies []*dbModel.TrashedMovie) []*model.TrashBatchResp {
+	ids := make(map[dbModel.EmptyNullString]struct{}, len(movies))
+	for _, m := range movies {
+		ids[dbModel.EmptyNullString(m.ID)] = struct{}{}
+	}
+	batches := []*model.TrashBatchResp{}
+	index := make(map[string]*model.TrashBatchResp)
+	for _, m := range movies {
+		b, ok := index[m.BatchID]
+		if !ok {
+			b = &model.TrashBatchResp{
+				ID:            m.BatchID,
+				TrashedAt:     m.TrashedAt.UnixMilli(),
+				ExpiresAt:     m.ExpiresAt.UnixMilli(),
+				TrashedBy:     m.TrashedBy,
+				TrashedByName: op.GetUserName(m.TrashedBy),
+				Movies:        []*model.TrashedMovieResp{},
+			}
+			index[m.BatchID] = b
+			batches = append(batches, b)
+		}
+		b.Count++
+		if _, ok := ids[m.ParentID]; ok {
+			continue
+		}
+		b.Movies = append(b.Movies, &model.TrashedMovieResp{
+			ID:       m.ID,
+			Name:     m.Name,
+			IsFolder: m.IsFolder,
+			ParentID: m.ParentID.String(),
+		})
+	}
+	return batches
+}
+
+func TrashedMovies(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	movies, err := user.RoomTrashedMovies(room)
+	if err != nil {
+		log.Errorf("get trashed movies error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(trashBatches(movies)))
+}
+
+func RestoreTrashedMovies(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.TrashBatchReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("restore trashed movies error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	restored, err := user.RestoreRoomTrashedMovies(room, req.BatchID)
+	if err != nil {
+		log.Errorf("restore trashed movies error: %v", err)
+		switch {
+		case errors.Is(err, dbModel.ErrNoPermission):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		case errors.Is(err, db.ErrNotFound("trashed movies")):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ids := make([]string, len(restored))
+	for i, m := range restored {
+		ids[i] = m.ID
+	}
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"ids": ids,
+	}))
+}
+
+func PurgeTrashedMovies(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.TrashBatchReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("purge trashed movies error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.PurgeRoomTrashedMovies(room, req.BatchID); err != nil {
+		log.Errorf("purge trashed movies error: %v", err)
+		switch {
+		case errors.Is(err, dbModel.ErrNoPermission):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		case errors.Is(err, db.ErrNotFound("trashed movies")):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/notify"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func WebPushVapidPublicKey(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !notify.EnableNotify.Get() {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(notify.ErrNotifyNotEnabled))
+		return
+	}
+
+	key, err := notify.VapidPublicKey()
+	if err != nil {
+		log.Errorf("get vapid public key failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"publicKey": key,
+	}))
+}
+
+func UserNotificationSubscriptions(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	subs, err := db.GetNotificationSubscriptions(user.ID)
+	if err != nil {
+		log.Errorf("get notification subscriptions failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.NotificationSubscriptionResp, len(subs))
+	for i, s := range subs {
+		resp[i] = &model.NotificationSubscriptionResp{
+			ID:        s.ID,
+			Driver:    s.Driver,
+			Endpoint:  s.Endpoint,
+			CreatedAt: s.CreatedAt.UnixMilli(),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func AddUserNotificationSubscription(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !notify.EnableNotify.Get() {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(notify.ErrNotifyNotEnabled))
+		return
+	}
+
+	var req model.AddNotificationSubscriptionReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	if err := notify.CheckEndpoint(ctx, req.Endpoint); err != nil {
+		log.Errorf("check notification endpoint failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	sub := &dbModel.NotificationSubscription{
+		UserID:   user.ID,
+		Driver:   req.Driver,
+		Endpoint: req.Endpoint,
+		Token:    req.Token,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}
+	if err := db.CreateNotificationSubscription(sub); err != nil {
+		log.Errorf("create notification subscription failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(&model.NotificationSubscriptionResp{
+		ID:        sub.ID,
+		Driver:    sub.Driver,
+		Endpoint:  sub.Endpoint,
+		CreatedAt: sub.CreatedAt.UnixMilli(),
+	}))
+}
+
+func DeleteUserNotificationSubscription(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.NotificationSubscriptionIDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := db.DeleteNotificationSubscription(user.ID, req.ID); err != nil {
+		log.Errorf("delete notification subscription failed: %v", err)
+		if errors.Is(err, db.ErrNotFound("subscription")) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func UserNotificationPreference(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	p, err := db.GetOrCreateNotificationPreference(user.ID)
+	if err != nil {
+		log.Errorf("get notification preference failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(p))
+}
+
+func SetUserNotificationPreference(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.SetNotificationPreferenceReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	p, err := db.UpdateNotificationPreference(user.ID, req)
+	if err != nil {
+		log.Errorf("update notification preference failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(p))
+}
+
+func RoomFollowStatus(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	following, err := db.IsFollowingRoom(user.ID, room.ID)
+	if err != nil {
+		log.Errorf("get room follow status failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(&model.RoomFollowResp{
+		Following: following,
+	}))
+}
+
+func FollowRoom(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if err := db.FollowRoom(user.ID, room.ID); err != nil {
+		log.Errorf("follow room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func UnfollowRoom(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if err := db.UnfollowRoom(user.ID, room.ID); err != nil {
+		log.Errorf("unfollow room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,610 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+var ErrOrgNotListed = errors.New("organization is not listed")
+
+// listingOrgID is the organization whose rooms the directory request lists,
+// rooms of unlisted organizations are only listed to their members
+func listingOrgID(ctx *gin.Context) (string, int, error) {
+	name := ctx.Query("org")
+	if name == "" {
+		return "", http.StatusOK, nil
+	}
+	org, err := db.GetOrganizationByName(name)
+	if err != nil {
+		return "", http.StatusNotFound, err
+	}
+	if org.Listed {
+		return org.ID, http.StatusOK, nil
+	}
+	token, err := middlewares.GetAuthorizationTokenFromContext(ctx)
+	if err != nil {
+		return "", http.StatusForbidden, ErrOrgNotListed
+	}
+	userE, err := middlewares.AuthUser(token)
+	if err != nil || !userE.Value().IsOrgMember(org.ID) {
+		return "", http.StatusForbidden, ErrOrgNotListed
+	}
+	return org.ID, http.StatusOK, nil
+}
+
+func PublicOrg(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	org, err := db.GetOrganizationByName(ctx.Param("name"))
+	if err != nil {
+		log.Errorf("get organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(model.NewOrgResp(org)))
+}
+
+func UserOrgs(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	orgs, err := db.GetUserOrganizations(user.ID)
+	if err != nil {
+		log.Errorf("get user organizations failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.UserOrgResp, len(orgs))
+	for i, o := range orgs {
+		resp[i] = &model.UserOrgResp{
+			OrgResp: model.NewOrgResp(o),
+			Role:    user.OrgRole(o.ID),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func JoinOrg(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.JoinOrgReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("join organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	org, err := user.JoinOrganization(req.Code)
+	if err != nil {
+		log.Errorf("join organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(model.NewOrgResp(org)))
+}
+
+func LeaveOrg(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.IdReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("leave organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := op.RemoveOrgMember(req.Id, user.ID); err != nil {
+		log.Errorf("leave organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// orgAdminCheck aborts the request unless the user administers the organization
+func orgAdminCheck(ctx *gin.Context, orgID string) bool {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	if len(orgID) != 32 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(model.ErrId))
+		return false
+	}
+	if !user.IsOrgAdmin(orgID) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(dbModel.ErrNoPermission))
+		return false
+	}
+	return true
+}
+
+func OrgMembers(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	orgID := ctx.Query("id")
+	if !orgAdminCheck(ctx, orgID) {
+		return
+	}
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("get organization members failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	total, err := db.GetOrganizationMembersCount(orgID)
+	if err != nil {
+		log.Errorf("get organization members failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	members, err := db.GetOrganizationMembers(orgID, db.OrderByCreatedAtAsc, db.Paginate(page, pageSize))
+	if err != nil {
+		log.Errorf("get organization members failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	list := make([]*model.OrgMemberResp, len(members))
+	for i, m := range members {
+		list[i] = &model.OrgMemberResp{
+			UserID:   m.UserID,
+			Username: op.GetUserName(m.UserID),
+			Role:     m.Role,
+			JoinAt:   m.CreatedAt.UnixMilli(),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total": total,
+		"list":  list,
+	}))
+}
+
+// AddOrgMember adds an existing user to the organization or changes its role
+func AddOrgMember(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.OrgMemberReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("add organization member failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	if !orgAdminCheck(ctx, req.ID) {
+		return
+	}
+	if req.Role == dbModel.OrgRoleUnknown {
+		req.Role = dbModel.OrgRoleMember
+	}
+
+	if _, err := op.LoadOrInitUserByID(req.UserID); err != nil {
+		log.Errorf("add organization member failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := op.AddOrgMember(req.ID, req.UserID, req.Role); err != nil {
+		log.Errorf("add organization member failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	if err := op.SetOrgMemberRole(req.ID, req.UserID, req.Role); err != nil {
+		log.Errorf("add organization member failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RemoveOrgMember(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.OrgMemberReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("remove organization member failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	if !orgAdminCheck(ctx, req.ID) {
+		return
+	}
+
+	if err := op.RemoveOrgMember(req.ID, req.UserID); err != nil {
+		log.Errorf("remove organization member failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func SetOrgBranding(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.OrgBrandingReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("set organization branding failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	if !orgAdminCheck(ctx, req.ID) {
+		return
+	}
+
+	org, err := db.UpdateOrganization(req.ID, map[string]any{
+		"display_name": req.DisplayName,
+		"description":  req.Description,
+		"logo_url":     req.LogoURL,
+		"accent_color": req.AccentColor,
+		"listed":       req.Listed,
+	})
+	if err != nil {
+		log.Errorf("set organization branding failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	// listing the organization changes which room directories are public
+	publicCache.Purge(cacheTagOrgs, cacheTagRooms)
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(model.NewOrgResp(org)))
+}
+
+func OrgInvite(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	orgID := ctx.Query("id")
+	if !orgAdminCheck(ctx, orgID) {
+		return
+	}
+
+	org, err := db.GetOrganizationByID(orgID)
+	if err != nil {
+		log.Errorf("get organization invite failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"code": org.InviteCode,
+	}))
+}
+
+func ResetOrgInvite(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.IdReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("reset organization invite failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	if !orgAdminCheck(ctx, req.Id) {
+		return
+	}
+
+	code, err := db.ResetOrganizationInviteCode(req.Id)
+	if err != nil {
+		log.Errorf("reset organization invite failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"code": code,
+	}))
+}
+
+// OrgRooms lists all rooms of the organization to its admins, hidden and
+// pending rooms included
+func OrgRooms(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	orgID := ctx.Query("id")
+	if !orgAdminCheck(ctx, orgID) {
+		return
+	}
+
+	page, err := newRoomCursorList(ctx, "asc")
+	if err != nil {
+		log.Errorf("get organization rooms failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	scopes := []func(db *gorm.DB) *gorm.DB{
+		db.WhereOrgID(orgID),
+	}
+	if keyword := ctx.Query("keyword"); keyword != "" {
+		scopes = append(scopes, db.WhereRoomNameLike(keyword))
+	}
+
+	total, err := db.GetAllRoomsCount(scopes...)
+	if err != nil {
+		log.Errorf("get organization rooms failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	rooms, err := db.GetAllRooms(append(scopes, page.scope)...)
+	if err != nil {
+		log.Errorf("get organization rooms failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	rooms, next := page.next(rooms)
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total":      total,
+		"list":       newRoomListResp(rooms),
+		"nextCursor": next,
+	}))
+}
+
+// OrgDeleteRoom lets org admins delete rooms of their organization
+func OrgDeleteRoom(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.IdReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("delete organization room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	r, err := db.GetRoomByID(req.Id)
+	if err != nil {
+		log.Errorf("delete organization room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return
+	}
+	if r.OrgID == "" {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(dbModel.ErrNoPermission))
+		return
+	}
+	if !orgAdminCheck(ctx, r.OrgID) {
+		return
+	}
+
+	if err := op.DeleteRoomByID(r.ID); err != nil {
+		log.Errorf("delete organization room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func AdminOrgs(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("get organizations failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	var scopes []func(db *gorm.DB) *gorm.DB
+	if keyword := ctx.Query("keyword"); keyword != "" {
+		scopes = append(scopes, db.WhereLike("name", keyword))
+	}
+
+	total, err := db.GetOrganizationsCount(scopes...)
+	if err != nil {
+		log.Errorf("get organizations failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	orgs, err := db.GetOrganizations(append(scopes, db.OrderByAsc("name"), db.Paginate(page, pageSize))...)
+	if err != nil {
+		log.Errorf("get organizations failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	list := make([]*model.AdminOrgResp, len(orgs))
+	for i, o := range orgs {
+		list[i] = &model.AdminOrgResp{
+			OrgResp:    model.NewOrgResp(o),
+			CreatedAt:  o.CreatedAt.UnixMilli(),
+			MaxRooms:   o.MaxRooms,
+			MaxMembers: o.MaxMembers,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"total": total,
+		"list":  list,
+	}))
+}
+
+func AdminCreateOrg(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.CreateOrgReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("create organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if req.AdminID != "" {
+		if _, err := op.LoadOrInitUserByID(req.AdminID); err != nil {
+			log.Errorf("create organization failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+	}
+
+	org := &dbModel.Organization{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		LogoURL:     req.LogoURL,
+		AccentColor: req.AccentColor,
+		Listed:      req.Listed,
+		MaxRooms:    req.MaxRooms,
+		MaxMembers:  req.MaxMembers,
+	}
+	if err := op.CreateOrganization(org, req.AdminID); err != nil {
+		log.Errorf("create organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, model.NewApiDataResp(model.NewOrgResp(org)))
+}
+
+func AdminSetOrgQuota(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.OrgQuotaReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("set organization quota failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if _, err := db.UpdateOrganization(req.ID, map[string]any{
+		"max_rooms":   req.MaxRooms,
+		"max_members": req.MaxMembers,
+	}); err != nil {
+		log.Errorf("set organization quota failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func AdminDeleteOrg(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.IdReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("delete organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := op.DeleteOrganization(req.Id); err != nil {
+		log.Errorf("delete organization failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	publicCache.Purge(cacheTagOrgs, cacheTagRooms)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func orgProvider(ctx *gin.Context, orgID string) (*dbModel.Organization, *dbModel.OrgProvider, bool) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	org, err := db.GetOrganizationByID(orgID)
+	if err != nil {
+		log.Errorf("get organization provider failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		return nil, nil, false
+	}
+	p, err := op.LoadOrgProvider(org.ID)
+	if err != nil {
+		log.Errorf("get organization provider failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return nil, nil, false
+	}
+	return org, p, true
+}
+
+func newOrgProviderResp(org *dbModel.Organization, p *dbModel.OrgProvider) *model.OrgProviderResp {
+	return &model.OrgProviderResp{
+		Provider:          op.OrgProviderName(org.Name),
+		Enabled:           p.Enabled,
+		RestrictRoomLogin: p.RestrictRoomLogin,
+		ClientID:          p.ClientID,
+		HasClientSecret:   p.ClientSecret != "",
+		RedirectURL:       p.RedirectURL,
+		AuthURL:           p.AuthURL,
+		TokenURL:          p.TokenURL,
+		UserInfoURL:       p.UserInfoURL,
+		Scopes:            p.Scopes,
+		IDField:           p.IDField,
+		UsernameField:     p.UsernameField,
+	}
+}
+
+func OrgProvider(ctx *gin.Context) {
+	orgID := ctx.Query("id")
+	if !orgAdminCheck(ctx, orgID) {
+		return
+	}
+
+	org, p, ok := orgProvider(ctx, orgID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(newOrgProviderResp(org, p)))
+}
+
+func SetOrgProvider(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.OrgProviderReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("set organization provider failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	if !orgAdminCheck(ctx, req.ID) {
+		return
+	}
+
+	org, current, ok := orgProvider(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	p := &dbModel.OrgProvider{
+		OrgID:             org.ID,
+		Enabled:           req.Enabled,
+		RestrictRoomLogin: req.RestrictRoomLogin,
+		ClientID:          req.ClientID,
+		ClientSecret:      req.ClientSecret,
+		RedirectURL:       req.RedirectURL,
+		AuthURL:           req.AuthURL,
+		TokenURL:          req.TokenURL,
+		UserInfoURL:       req.UserInfoURL,
+		Scopes:            req.Scopes,
+		IDField:           req.IDField,
+		UsernameField:     req.UsernameField,
+	}
+	if p.ClientSecret == "" {
+		p.ClientSecret = current.ClientSecret
+	}
+	if err := op.SetOrgProvider(p); err != nil {
+		log.Errorf("set organization provider failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(newOrgProviderResp(org, p)))
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+var overlayTemplate = template.Must(template.ParseFS(templates, "templates/overlay.html"))
+
+const (
+	overlayMaxChat      = 50
+	overlayPollInterval = 2 * time.Second
+)
+
+var (
+	overlayWidgets     = []string{"title", "viewers", "chat"}
+	overlayColorRegexp = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]{1,20}|transparent)$`)
+	overlayFontRegexp  = regexp.MustCompile(`^[a-zA-Z0-9 \-]{1,64}$`)
+)
+
+type overlayTheme struct {
+	Widgets    map[string]bool
+	Color      string
+	Background string
+	Font       string
+	Size       int
+	ChatLines  int
+	Align      string
+}
+
+// parseOverlayTheme reads the theming query parameters of the overlay page,
+// invalid values fall back to the defaults
+func parseOverlayTheme(ctx *gin.Context) *overlayTheme {
+	t := &overlayTheme{
+		Widgets:    make(map[string]bool, len(overlayWidgets)),
+		Color:      "#ffffff",
+		Background: "transparent",
+		Font:       "sans-serif",
+		Size:       24,
+		ChatLines:  8,
+		Align:      "left",
+	}
+	if w := ctx.Query("widgets"); w != "" {
+		for _, name := range strings.Split(w, ",") {
+			t.Widgets[strings.TrimSpace(name)] = true
+		}
+	} else {
+		for _, name := range overlayWidgets {
+			t.Widgets[name] = true
+		}
+	}
+	if c := ctx.Query("color"); overlayColorRegexp.MatchString(c) {
+		t.Color = c
+	}
+	if c := ctx.Query("bg"); overlayColorRegexp.MatchString(c) {
+		t.Background = c
+	}
+	if f := ctx.Query("font"); overlayFontRegexp.MatchString(f) {
+		t.Font = f
+	}
+	if s, err := strconv.Atoi(ctx.Query("size")); err == nil && s >= 8 && s <= 128 {
+		t.Size = s
+	}
+	if l, err := strconv.Atoi(ctx.Query("chatLines")); err == nil && l >= 1 && l <= overlayMaxChat {
+		t.ChatLines = l
+	}
+	switch a := ctx.Query("align"); a {
+	case "left", "center", "right":
+		t.Align = a
+	}
+	return t
+}
+
+// OverlayRoom renders a browser source page for streaming software, the room
+// token is passed with the token query, without it the page joins as guest
+func OverlayRoom(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	roomE, err := op.LoadOrInitRoomByID(ctx.Param("roomId"))
+	if err != nil {
+		log.Errorf("load room failed: %v", err)
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	err = overlayTemplate.Execute(ctx.Writer, map[string]any{
+		"RoomID":       roomE.Value().ID,
+		"Theme":        parseOverlayTheme(ctx),
+		"PollInterval": overlayPollInterval.Milliseconds(),
+	})
+	if err != nil {
+		log.Errorf("render overlay page failed: %v", err)
+	}
+}
+
+// RoomOverlay is polled by the overlay page, since is the server time of the
+// previous poll in unix milli
+func RoomOverlay(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+
+	now := time.Now()
+	since := now.Add(-time.Minute)
+	if ms, err := strconv.ParseInt(ctx.Query("since"), 10, 64); err == nil && ms > 0 {
+		since = time.UnixMilli(ms)
+	}
+
+	current := room.Current()
+	resp := &model.RoomOverlayResp{
+		Movie: model.RoomCurrentMovie{
+			ID:   current.Movie.ID,
+			Live: current.Movie.IsLive,
+		},
+		Viewers:    room.PeopleNum(),
+		ServerTime: now.UnixMilli(),
+	}
+	if current.Movie.ID != "" {
+		if m, err := room.GetMovieByID(current.Movie.ID); err == nil {
+			resp.Movie.Name = m.Movie.MovieBase.Name
+		}
+	}
+	messages := room.RecentChatMessages(since, overlayMaxChat)
+	resp.Chat = make([]*model.OverlayChatMessage, len(messages))
+	for i, m := range messages {
+		resp.Chat[i] = &model.OverlayChatMessage{
+			ID:         m.ID,
+			SenderName: m.SenderName,
+			Message:    m.Message,
+			SentAt:     m.SentAt.UnixMilli(),
+			Tag:        m.Tag,
+		}
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
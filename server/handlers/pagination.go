@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// listSort is a way a list of T can be sorted, value is the sorted column
+// of a row
+type listSort[T any] struct {
+	column db.SortColumn
+	value  func(T) any
+}
+
+// cursorList is the requested page of a list of T. Clients page with the
+// cursor of the previous page, or with page for older clients, and pick the
+// sort with sort and order
+type cursorList[T any] struct {
+	page  *db.CursorPage
+	value func(T) any
+	id    func(T) string
+	scope func(*gorm.DB) *gorm.DB
+}
+
+func newCursorList[T any](
+	ctx *gin.Context,
+	sorts map[string]listSort[T],
+	defaultSort string,
+	defaultOrder string,
+	idColumn string,
+	id func(T) string,
+) (*cursorList[T], error) {
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort, ok := sorts[ctx.DefaultQuery("sort", defaultSort)]
+	if !ok {
+		return nil, errors.New("not support sort")
+	}
+	l := &cursorList[T]{
+		page: &db.CursorPage{
+			Sort:     sort.column,
+			Desc:     ctx.DefaultQuery("order", defaultOrder) == "desc",
+			IDColumn: idColumn,
+			Cursor:   ctx.Query("cursor"),
+			Offset:   (page - 1) * pageSize,
+			Limit:    pageSize,
+		},
+		value: sort.value,
+		id:    id,
+	}
+	l.scope, err = l.page.Scope()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// next trims the extra row of the page and returns the cursor of the next page
+func (l *cursorList[T]) next(rows []T) ([]T, string) {
+	return db.NextCursor(l.page, rows, func(r T) (any, string) {
+		return l.value(r), l.id(r)
+	})
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/webauthn"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+var (
+	ErrPasskeyLoginDisabled = errors.New("passkey login is disabled")
+	ErrPasskeyNotConfigured = errors.New("passkeys need the host or the passkey rp id and origins to be set")
+)
+
+// passkeySession is a challenge waiting for the response of the authenticator
+type passkeySession struct {
+	challenge []byte
+	register  bool
+	// the user registering a passkey, or the user signing in when known
+	userID string
+}
+
+var passkeySessions = synccache.NewSyncCache[string, *passkeySession](time.Minute * 10)
+
+// passkeyRelyingParty is configured by the HOST setting or the passkey
+// settings, never by the host of the request which the client chooses
+func passkeyRelyingParty() (*webauthn.RelyingParty, error) {
+	rp := &webauthn.RelyingParty{
+		ID:   settings.PasskeyRPID.Get(),
+		Name: "SyncTV",
+	}
+	for _, origin := range strings.Split(settings.PasskeyOrigins.Get(), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			rp.Origins = append(rp.Origins, origin)
+		}
+	}
+	if host := HOST.Get(); host != "" {
+		u, err := url.Parse(host)
+		if err != nil {
+			return nil, err
+		}
+		if rp.ID == "" {
+			rp.ID = u.Hostname()
+		}
+		if len(rp.Origins) == 0 {
+			rp.Origins = []string{u.Scheme + "://" + u.Host}
+		}
+	}
+	if rp.ID == "" || len(rp.Origins) == 0 {
+		return nil, ErrPasskeyNotConfigured
+	}
+	return rp, nil
+}
+
+func newPasskeySession(session *passkeySession) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+	session.challenge = challenge
+	id := utils.RandString(32)
+	passkeySessions.Store(id, session, webauthn.Timeout*time.Millisecond)
+	return id, nil
+}
+
+func passkeyDescriptors(passkeys []*dbModel.UserPasskey) []webauthn.CredentialDescriptor {
+	descriptors := make([]webauthn.CredentialDescriptor, len(passkeys))
+	for i, p := range passkeys {
+		descriptors[i] = webauthn.CredentialDescriptor{
+			Type: "public-key",
+			ID:   p.CredentialID,
+		}
+		if p.Transports != "" {
+			descriptors[i].Transports = strings.Split(p.Transports, ",")
+		}
+	}
+	return descriptors
+}
+
+func UserPasskeys(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	passkeys, err := db.GetUserPasskeys(user.ID)
+	if err != nil {
+		log.Errorf("get user passkeys failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.PasskeyResp, len(passkeys))
+	for i, p := range passkeys {
+		resp[i] = &model.PasskeyResp{
+			ID:             p.ID,
+			Name:           p.Name,
+			CreatedAt:      p.CreatedAt.UnixMilli(),
+			BackupEligible: p.BackupEligible,
+			Transports:     []string{},
+		}
+		if p.LastUsedAt != nil {
+			resp[i].LastUsedAt = p.LastUsedAt.UnixMilli()
+		}
+		if p.Transports != "" {
+			resp[i].Transports = strings.Split(p.Transports, ",")
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func BeginRegisterUserPasskey(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !settings.EnablePasskeyLogin.Get() {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(ErrPasskeyLoginDisabled))
+		return
+	}
+
+	rp, err := passkeyRelyingParty()
+	if err != nil {
+		log.Errorf("get passkey relying party failed: %v", err)
+		if errors.Is(err, ErrPasskeyNotConfigured) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	passkeys, err := db.GetUserPasskeys(user.ID)
+	if err != nil {
+		log.Errorf("get user passkeys failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	if len(passkeys) >= db.MaxUserPasskeys {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("passkey count is over limit"))
+		return
+	}
+
+	session := &passkeySession{register: true, userID: user.ID}
+	id, err := newPasskeySession(session)
+	if err != nil {
+		log.Errorf("new passkey session failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"sessionId": id,
+		"publicKey": rp.CreationOptions(session.challenge, []byte(user.ID), user.Username, user.Username, passkeyDescriptors(passkeys)),
+	}))
+}
+
+func FinishRegisterUserPasskey(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.PasskeyRegisterFinishReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	session, loaded := passkeySessions.LoadAndDelete(req.SessionID)
+	if !loaded || !session.Value().register || session.Value().userID != user.ID {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid or expired passkey session"))
+		return
+	}
+
+	rp, err := passkeyRelyingParty()
+	if err != nil {
+		log.Errorf("get passkey relying party failed: %v", err)
+		if errors.Is(err, ErrPasskeyNotConfigured) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	cred, err := rp.VerifyRegistration(session.Value().challenge, &req.Credential.Response)
+	if err != nil {
+		log.Errorf("verify passkey registration failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	passkey := &dbModel.UserPasskey{
+		UserID:         user.ID,
+		CredentialID:   cred.ID,
+		PublicKey:      cred.PublicKey,
+		SignCount:      cred.SignCount,
+		BackupEligible: cred.BackupEligible,
+		Name:           req.Name,
+	}
+	if transports := strings.Join(req.Credential.Response.Transports, ","); len(transports) <= 128 {
+		passkey.Transports = transports
+	}
+	if err := db.CreateUserPasskey(passkey); err != nil {
+		log.Errorf("create user passkey failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"id": passkey.ID,
+	}))
+}
+
+func DeleteUserPasskey(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := db.DeleteUserPasskey(user.ID, req.Id); err != nil {
+		log.Errorf("delete user passkey failed: %v", err)
+		if errors.Is(err, db.ErrNotFound("passkey")) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func BeginPasskeyLogin(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !settings.EnablePasskeyLogin.Get() {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(ErrPasskeyLoginDisabled))
+		return
+	}
+
+	var req model.PasskeyLoginBeginReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	rp, err := passkeyRelyingParty()
+	if err != nil {
+		log.Errorf("get passkey relying party failed: %v", err)
+		if errors.Is(err, ErrPasskeyNotConfigured) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	// unknown usernames fall back to discoverable passkeys, so the
+	// response does not tell which users exist
+	session := &passkeySession{}
+	var allow []webauthn.CredentialDescriptor
+	if req.Username != "" {
+		u, err := db.GetUserByUsername(req.Username)
+		if err != nil && !errors.Is(err, db.ErrNotFound("user")) {
+			log.Errorf("get user failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		if err == nil {
+			passkeys, err := db.GetUserPasskeys(u.ID)
+			if err != nil {
+				log.Errorf("get user passkeys failed: %v", err)
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+				return
+			}
+			if len(passkeys) != 0 {
+				session.userID = u.ID
+				allow = passkeyDescriptors(passkeys)
+			}
+		}
+	}
+
+	id, err := newPasskeySession(session)
+	if err != nil {
+		log.Errorf("new passkey session failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"sessionId": id,
+		"publicKey": rp.RequestOptions(session.challenge, allow),
+	}))
+}
+
+func FinishPasskeyLogin(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !settings.EnablePasskeyLogin.Get() {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(ErrPasskeyLoginDisabled))
+		return
+	}
+
+	var req model.PasskeyLoginFinishReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	session, loaded := passkeySessions.LoadAndDelete(req.SessionID)
+	if !loaded || session.Value().register {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid or expired passkey session"))
+		return
+	}
+
+	passkey, err := db.GetPasskeyByCredentialID(req.Credential.RawID)
+	if err != nil {
+		log.Errorf("get passkey failed: %v", err)
+		if errors.Is(err, db.ErrNotFound("passkey")) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorStringResp("passkey is not registered"))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	if (session.Value().userID != "" && session.Value().userID != passkey.UserID) ||
+		(len(req.Credential.Response.UserHandle) != 0 && string(req.Credential.Response.UserHandle) != passkey.UserID) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorStringResp("passkey does not belong to the user"))
+		return
+	}
+
+	rp, err := passkeyRelyingParty()
+	if err != nil {
+		log.Errorf("get passkey relying party failed: %v", err)
+		if errors.Is(err, ErrPasskeyNotConfigured) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	signCount, err := rp.VerifyAssertion(session.Value().challenge, &webauthn.Credential{
+		ID:        passkey.CredentialID,
+		PublicKey: passkey.PublicKey,
+		SignCount: passkey.SignCount,
+	}, &req.Credential.Response)
+	if err != nil {
+		log.Errorf("verify passkey assertion failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
+		return
+	}
+	if err := db.UsePasskey(passkey.ID, signCount); err != nil {
+		log.Errorf("use passkey failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
+		return
+	}
+
+	user, err := op.LoadOrInitUserByID(passkey.UserID)
+	if err != nil {
+		log.Errorf("failed to load user: %v", err)
+		if err == op.ErrUserBanned || err == op.ErrUserPending {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	token, err := middlewares.NewLoginToken(ctx, user.Value())
+	if err != nil {
+		log.Errorf("failed to generate token: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"token": token,
+	}))
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func genMoviePollResp(room *op.Room, p *dbModel.MoviePoll, userID string) (*model.MoviePollResp, error) {
+	resp := &model.MoviePollResp{
+		ID:         p.ID,
+		CreatorID:  p.CreatorID,
+		Creator:    op.GetUserName(p.CreatorID),
+		Title:      p.Title,
+		Candidates: make([]*model.MoviePollCandidate, len(p.Candidates)),
+		CloseAt:    p.CloseAt.UnixMilli(),
+		Closed:     p.Closed,
+		WinnerID:   p.WinnerID,
+		CreatedAt:  p.CreatedAt.UnixMilli(),
+	}
+	for i, id := range p.Candidates {
+		resp.Candidates[i] = &model.MoviePollCandidate{ID: id}
+		if m, err := room.GetMovieByID(id); err == nil {
+			resp.Candidates[i].Name = m.Movie.MovieBase.Name
+		}
+	}
+	result, ballots, err := op.MoviePollResult(p)
+	if err != nil {
+		return nil, err
+	}
+	resp.Ballots = ballots
+	if p.Closed {
+		resp.Result = result
+	}
+	if b, err := db.GetMoviePollBallot(p.ID, userID); err == nil {
+		resp.Ranking = b.Ranking
+	} else if !errors.Is(err, db.ErrNotFound("ballot")) {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func moviePollErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrNotFound("poll")):
+		return http.StatusNotFound
+	case errors.Is(err, op.ErrMoviePollClosed):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func MoviePolls(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	polls, err := db.GetMoviePolls(room.ID)
+	if err != nil {
+		log.Errorf("get movie polls failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	list := make([]*model.MoviePollResp, len(polls))
+	for i, p := range polls {
+		list[i], err = genMoviePollResp(room, p, user.ID)
+		if err != nil {
+			log.Errorf("get movie poll result failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(list))
+}
+
+func VoteMoviePoll(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.VoteMoviePollReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.VoteMoviePoll(room, req.Id, req.Ranking); err != nil {
+		log.Errorf("vote movie poll failed: %v", err)
+		ctx.AbortWithStatusJSON(moviePollErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminCreateMoviePoll(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.CreateMoviePollReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	poll := &dbModel.MoviePoll{
+		Title:      req.Title,
+		Candidates: req.Candidates,
+		CloseAt:    time.UnixMilli(req.CloseAt),
+	}
+	if err := user.CreateMoviePoll(room, poll); err != nil {
+		log.Errorf("create movie poll failed: %v", err)
+		ctx.AbortWithStatusJSON(moviePollErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	resp, err := genMoviePollResp(room, poll, user.ID)
+	if err != nil {
+		log.Errorf("get movie poll result failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func RoomAdminCloseMoviePoll(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	poll, err := user.CloseMoviePoll(room, req.Id)
+	if err != nil {
+		log.Errorf("close movie poll failed: %v", err)
+		ctx.AbortWithStatusJSON(moviePollErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	resp, err := genMoviePollResp(room, poll, user.ID)
+	if err != nil {
+		log.Errorf("get movie poll result failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+func RoomAdminDeleteMoviePoll(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteMoviePoll(room, req.Id); err != nil {
+		log.Errorf("delete movie poll failed: %v", err)
+		ctx.AbortWithStatusJSON(moviePollErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
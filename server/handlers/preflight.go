@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/transcode"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/go-uhc"
+)
+
+const (
+	preflightTimeout  = 20 * time.Second
+	preflightHeadSize = 4096
+)
+
+var (
+	// containers browsers play natively or through hls.js / flv.js
+	browserContainers = map[string]bool{
+		"mp4": true, "webm": true, "m3u8": true, "flv": true,
+		"mp3": true, "aac": true, "ogg": true, "wav": true,
+	}
+	browserVideoCodecs = map[string]bool{
+		"h264": true, "vp8": true, "vp9": true, "av1": true,
+	}
+	browserAudioCodecs = map[string]bool{
+		"aac": true, "mp3": true, "opus": true, "vorbis": true, "flac": true,
+		"pcm_s16le": true, "pcm_f32le": true,
+	}
+)
+
+// MoviePreflight checks whether a movie about to be added will play in browsers
+func MoviePreflight(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !user.HasRoomPermission(room, dbModel.PermissionAddMovie) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(dbModel.ErrNoPermission))
+		return
+	}
+
+	req := model.PushMovieReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("movie preflight error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	c, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	header, err := op.SourceHeader(c, (*dbModel.MovieBase)(&req), user.ID, ctx.Request.Header)
+	if err != nil {
+		log.Errorf("movie preflight error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(preflight(c, requestHost(ctx), (*dbModel.MovieBase)(&req), header)))
+}
+
+// preflight probes the source of movie, requested with header
+func preflight(ctx context.Context, origin string, movie *dbModel.MovieBase, header http.Header) *model.PreflightResp {
+	resp := &model.PreflightResp{
+		Compatibility: model.PreflightDirect,
+		ProxyEnabled:  settings.MovieProxy.Get(),
+	}
+	if movie.Live {
+		resp.ProxyEnabled = settings.LiveProxy.Get()
+	}
+	switch {
+	case movie.IsFolder:
+		resp.AddReason("folders are not played")
+		return resp
+	case movie.VendorInfo.Vendor != "":
+		resp.AddReason("the source is resolved by the vendor at playback")
+		return resp
+	case movie.RtmpSource:
+		resp.AddReason("the stream is published to and served by synctv")
+		return resp
+	case isMediaURL(movie.Url):
+		resp.AddReason("the file is in the media library and served by synctv")
+		return resp
+	}
+
+	u, err := url.Parse(movie.Url)
+	if err != nil {
+		resp.Compatibility = model.PreflightUnreachable
+		resp.AddReason(fmt.Sprintf("invalid url: %v", err))
+		return resp
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "rtmp":
+		if movie.Live {
+			resp.Compatibility = model.PreflightProxy
+			resp.AddReason("rtmp streams are played through the live proxy")
+			if !resp.ProxyEnabled {
+				resp.AddReason("the live proxy is disabled on this server")
+			}
+			return resp
+		}
+		fallthrough
+	default:
+		resp.Compatibility = model.PreflightUnreachable
+		resp.AddReason(fmt.Sprintf("unsupported url scheme: %s", u.Scheme))
+		return resp
+	}
+	if !settings.AllowProxyToLocal.Get() {
+		if l, err := utils.ParseURLIsLocalIP(movie.Url); err != nil || l {
+			resp.Compatibility = model.PreflightUnknown
+			resp.AddReason("the source is on a local address and was not probed")
+			return resp
+		}
+	}
+
+	probe, err := probeSource(ctx, origin, movie.Url, header)
+	if probe != nil {
+		resp.StatusCode = probe.StatusCode
+		resp.ContentType = probe.ContentType
+		resp.AcceptRanges = probe.AcceptRanges
+		resp.Cors = probe.Cors
+	}
+	if err != nil {
+		resp.Compatibility = model.PreflightUnreachable
+		resp.AddReason(err.Error())
+		return resp
+	}
+	head := probe.Head
+
+	var needProxy, needTranscode bool
+	resp.Container = utils.SniffContainer(head, resp.ContentType, utils.GetUrlExtension(movie.Url))
+	if resp.Container == "" {
+		resp.Container = strings.ToLower(movie.Type)
+	}
+	switch {
+	case resp.Container == "":
+		resp.AddReason("the container is unknown")
+	case !browserContainers[resp.Container]:
+		needTranscode = true
+		resp.AddReason(fmt.Sprintf("browsers can not play %s files", resp.Container))
+	}
+
+	if movie.NeedHeaders() {
+		needProxy = true
+		resp.AddReason("the source needs custom headers which browsers can not send")
+	}
+	if u.Scheme == "http" && strings.HasPrefix(origin, "https://") {
+		needProxy = true
+		resp.AddReason("browsers block http sources on an https site")
+	}
+	if !resp.Cors && (resp.Container == "m3u8" || resp.Container == "flv") {
+		needProxy = true
+		resp.AddReason(fmt.Sprintf("%s is loaded with fetch, which needs cors headers the source does not send", resp.Container))
+	}
+	if !resp.AcceptRanges && !movie.Live && resp.Container != "m3u8" {
+		resp.AddReason("the source does not support byte ranges, seeking may not work")
+	}
+
+	if transcode.ProbeAvailable() {
+		if preflightCodecs(ctx, movie.Url, header, resp) {
+			needTranscode = true
+		}
+	} else {
+		resp.AddReason("codecs were not checked, ffprobe is not available")
+	}
+
+	switch {
+	case needTranscode:
+		resp.Compatibility = model.PreflightTranscode
+	case needProxy:
+		resp.Compatibility = model.PreflightProxy
+		if !resp.ProxyEnabled {
+			resp.AddReason("the proxy is disabled on this server")
+		}
+	case resp.Container == "" && resp.VideoCodec == "" && resp.AudioCodec == "":
+		resp.Compatibility = model.PreflightUnknown
+	}
+	return resp
+}
+
+type sourceProbe struct {
+	StatusCode   int
+	ContentType  string
+	AcceptRanges bool
+	Cors         bool
+	Head         []byte
+}
+
+// probeSource requests the first bytes of the source as a browser on origin
+// would, or as the proxy would if origin is empty
+func probeSource(ctx context.Context, origin, u string, header http.Header) (*sourceProbe, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", preflightHeadSize-1))
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Referer", origin+"/")
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", utils.UA)
+	}
+	r, err := uhc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request source failed: %w", err)
+	}
+	defer r.Body.Close()
+
+	p := &sourceProbe{
+		StatusCode:  r.StatusCode,
+		ContentType: r.Header.Get("Content-Type"),
+		AcceptRanges: r.StatusCode == http.StatusPartialContent ||
+			strings.EqualFold(r.Header.Get("Accept-Ranges"), "bytes"),
+	}
+	if r.StatusCode >= http.StatusBadRequest {
+		return p, fmt.Errorf("source responded %s", r.Status)
+	}
+	acao := r.Header.Get("Access-Control-Allow-Origin")
+	p.Cors = acao == "*" || (origin != "" && acao == origin)
+	p.Head, err = io.ReadAll(io.LimitReader(r.Body, preflightHeadSize))
+	return p, err
+}
+
+// preflightCodecs fills the codecs of the source, it returns true if browsers
+// can not decode them
+func preflightCodecs(ctx context.Context, u string, header http.Header, resp *model.PreflightResp) bool {
+	result, err := transcode.Probe(ctx, &transcode.Input{
+		URL:     u,
+		Headers: header,
+	})
+	if err != nil {
+		resp.AddReason(fmt.Sprintf("probe codecs failed: %v", err))
+		return false
+	}
+	var unsupported bool
+	if s := result.Stream("video"); s != nil {
+		resp.VideoCodec = s.CodecName
+		switch {
+		case s.CodecName == "hevc":
+			unsupported = true
+			resp.AddReason("h265/hevc video only plays in some browsers")
+		case !browserVideoCodecs[s.CodecName]:
+			unsupported = true
+			resp.AddReason(fmt.Sprintf("browsers can not decode %s video", s.CodecName))
+		}
+	}
+	if s := result.Stream("audio"); s != nil {
+		resp.AudioCodec = s.CodecName
+		if !browserAudioCodecs[s.CodecName] {
+			unsupported = true
+			resp.AddReason(fmt.Sprintf("browsers can not decode %s audio", s.CodecName))
+		}
+	}
+	return unsupported
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+// UserPresence returns the rich presence document of the user, it is polled
+// by companion apps like a discord rich presence helper
+func UserPresence(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	p, err := db.GetOrCreatePresencePreference(user.ID)
+	if err != nil {
+		log.Errorf("get presence preference failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(genUserPresenceResp(user, p)))
+}
+
+func genUserPresenceResp(user *op.User, p *dbModel.PresencePreference) *model.UserPresenceResp {
+	resp := &model.UserPresenceResp{}
+	if !p.Enabled {
+		return resp
+	}
+	room := user.WatchingRoom()
+	if room == nil {
+		return resp
+	}
+	resp.Active = true
+	resp.Details = "Watching on SyncTV"
+	if isPublicRoom(room) || p.ShowPrivateRooms {
+		if p.ShowRoom {
+			resp.RoomID = room.ID
+			resp.RoomName = room.Name
+			resp.State = "in " + room.Name
+		}
+		if p.ShowMovie {
+			genPresenceMovie(resp, room, p.ShowProgress)
+		}
+	}
+	return resp
+}
+
+func genPresenceMovie(resp *model.UserPresenceResp, room *op.Room, showProgress bool) {
+	current := room.Current()
+	if current.Movie.ID == "" {
+		return
+	}
+	m, err := room.GetMovieByID(current.Movie.ID)
+	if err != nil {
+		return
+	}
+	resp.MovieTitle = m.Movie.MovieBase.Name
+	resp.Live = current.Movie.IsLive
+	resp.Playing = current.Status.Playing
+	resp.Details = "Watching " + resp.MovieTitle
+	if !showProgress || resp.Live {
+		return
+	}
+	resp.Elapsed = current.Status.Seek
+	var total float64
+	if ok, _ := m.Extensions().Get(dbModel.ExtensionNamespaceMetadata, "duration", &total); ok && total > 0 {
+		resp.Total = total
+	}
+	// discord counts the timestamps in real time, so only set them while playing
+	if resp.Playing && current.Status.Rate > 0 {
+		now := time.Now()
+		start := now.Add(-time.Duration(resp.Elapsed / current.Status.Rate * float64(time.Second)))
+		resp.StartTimestamp = start.UnixMilli()
+		if resp.Total > resp.Elapsed {
+			end := now.Add(time.Duration((resp.Total - resp.Elapsed) / current.Status.Rate * float64(time.Second)))
+			resp.EndTimestamp = end.UnixMilli()
+		}
+	}
+}
+
+func UserPresencePreference(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	p, err := db.GetOrCreatePresencePreference(user.ID)
+	if err != nil {
+		log.Errorf("get presence preference failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(p))
+}
+
+func SetUserPresencePreference(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.SetPresencePreferenceReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	p, err := db.UpdatePresencePreference(user.ID, req)
+	if err != nil {
+		log.Errorf("update presence preference failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(p))
+}
@@ -16,6 +16,9 @@ type publicSettings struct {
 	EmailWhitelist         []string `json:"emailWhitelist,omitempty"`
 
 	GuestEnable bool `json:"guestEnable"`
+
+	// vendors turned off by the admin with the reason
+	DisabledVendors map[string]string `json:"disabledVendors,omitempty"`
 }
 
 func Settings(ctx *gin.Context) {
@@ -27,6 +30,8 @@ func Settings(ctx *gin.Context) {
 			EmailWhitelist:         strings.Split(email.EmailSignupWhiteList.Get(), ","),
 
 			GuestEnable: settings.EnableGuest.Get(),
+
+			DisabledVendors: settings.DisabledVendors(),
 		},
 	))
 }
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/events"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+// publicCache caches the anonymous responses of the public directory and
+// metadata endpoints, they are hit by everyone following a link shared to a
+// large community
+var publicCache = middlewares.NewResponseCache()
+
+const (
+	roomDirectoryCacheTTL = time.Second * 30
+	roomHotCacheTTL       = time.Second * 10
+	publicOrgCacheTTL     = time.Minute
+	publicSnapshotTTL     = time.Minute * 10
+	oEmbedCacheTTL        = time.Minute
+)
+
+const (
+	// the room directories and everything listing rooms
+	cacheTagRooms = "rooms"
+	cacheTagOrgs  = "orgs"
+)
+
+func cacheTagRoom(roomID string) string {
+	return "room:" + roomID
+}
+
+func cacheTagSnapshot(id string) string {
+	return "snapshot:" + id
+}
+
+func cacheTags(tags ...string) func(*gin.Context) []string {
+	return func(*gin.Context) []string {
+		return tags
+	}
+}
+
+func oEmbedCacheTags(ctx *gin.Context) []string {
+	roomID, err := roomIDFromURL(ctx.Query("url"))
+	if err != nil {
+		return nil
+	}
+	return []string{cacheTagRoom(roomID)}
+}
+
+func snapshotCacheTags(ctx *gin.Context) []string {
+	return []string{cacheTagSnapshot(ctx.Param("id"))}
+}
+
+func init() {
+	events.Rooms.Subscribe("public-cache", func(e events.RoomEvent) {
+		switch e.Type {
+		case events.RoomCreated, events.RoomUpdated, events.RoomDeleted:
+		default:
+			return
+		}
+		publicCache.Purge(cacheTagRooms, cacheTagRoom(e.RoomID))
+		purgeSharePreview(e.RoomID)
+	})
+}
+
+// purgeSharePreview drops the share previews of the room of every host
+func purgeSharePreview(roomID string) {
+	suffix := "|" + roomID
+	shareCache.Range(func(key string, _ *synccache.Entry[*sharePreview]) bool {
+		if strings.HasSuffix(key, suffix) {
+			shareCache.Delete(key)
+		}
+		return true
+	})
+}
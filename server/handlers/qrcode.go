@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils/qrcode"
+)
+
+const (
+	quickJoinTokenTTL = time.Minute * 2
+	// refresh the code before the token expires so scanning a displayed code always works
+	quickJoinRefresh = time.Minute
+	qrcodeMaxScale   = 32
+)
+
+var ErrQuickJoinNeedAdmin = errors.New("only room admins can share password protected rooms")
+
+func newQuickJoinURL(ctx *gin.Context, user *op.User, room *op.Room) (*model.QuickJoinResp, error) {
+	if room.NeedPassword() && !user.IsRoomAdmin(room) {
+		return nil, ErrQuickJoinNeedAdmin
+	}
+	token, expiresAt, err := middlewares.NewQuickJoinToken(room, quickJoinTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(requestHost(ctx))
+	if err != nil {
+		return nil, err
+	}
+	u = u.JoinPath("/web/cinema", room.ID)
+	u.RawQuery = url.Values{"quickjoin": []string{token}}.Encode()
+	return &model.QuickJoinResp{
+		URL:       u.String(),
+		ExpiresAt: expiresAt.UnixMilli(),
+		RefreshIn: int64(quickJoinRefresh.Seconds()),
+	}, nil
+}
+
+func RoomQuickJoinURL(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	resp, err := newQuickJoinURL(ctx, user, room)
+	if err != nil {
+		log.Errorf("new quick join url failed: %v", err)
+		if errors.Is(err, ErrQuickJoinNeedAdmin) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+// RoomQuickJoinQRCode renders the quick join url as png or svg,
+// the Refresh header tells clients when to fetch a new code
+func RoomQuickJoinQRCode(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	resp, err := newQuickJoinURL(ctx, user, room)
+	if err != nil {
+		log.Errorf("new quick join url failed: %v", err)
+		if errors.Is(err, ErrQuickJoinNeedAdmin) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	code, err := qrcode.Encode([]byte(resp.URL), qrcode.Medium)
+	if err != nil {
+		log.Errorf("encode qrcode failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Refresh", strconv.FormatInt(resp.RefreshIn, 10))
+	ctx.Header("X-Quick-Join-Expires-At", strconv.FormatInt(resp.ExpiresAt, 10))
+
+	switch ctx.DefaultQuery("format", "png") {
+	case "svg":
+		ctx.Data(http.StatusOK, "image/svg+xml", []byte(code.SVG()))
+	case "png":
+		scale, err := strconv.Atoi(ctx.DefaultQuery("scale", "8"))
+		if err != nil || scale < 1 || scale > qrcodeMaxScale {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp(fmt.Sprintf("scale must be between 1 and %d", qrcodeMaxScale)))
+			return
+		}
+		buf := bytes.NewBuffer(nil)
+		if err := code.WritePNG(buf, scale); err != nil {
+			log.Errorf("encode png failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.Data(http.StatusOK, "image/png", buf.Bytes())
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("format must be png or svg"))
+	}
+}
+
+func QuickJoinRoom(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.QuickJoinRoomReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("quick join room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	roomE, err := middlewares.AuthQuickJoin(req.Token)
+	if err != nil {
+		log.Errorf("quick join room failed: %v", err)
+		switch {
+		case errors.Is(err, op.ErrRoomBanned), errors.Is(err, op.ErrRoomPending):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		case errors.Is(err, db.ErrNotFound("room")):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
+		}
+		return
+	}
+	room := roomE.Value()
+
+	if err := user.CheckRoomMembership(ctx, room); err != nil {
+		log.Warnf("quick join room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		return
+	}
+
+	token, err := middlewares.NewAuthRoomToken(user, room, ctx.GetString("login"))
+	if err != nil {
+		log.Errorf("quick join room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"roomId": room.ID,
+		"token":  token,
+	}))
+}
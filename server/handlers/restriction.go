@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+var (
+	ErrMovieLocked         = errors.New("movie is restricted to selected members")
+	ErrMovieRatingLocked   = errors.New("movie rating is above your maximum content rating")
+	ErrMoviePasswordLocked = errors.New("movie is password protected, unlock it first")
+)
+
+func movieLockError(lock op.MovieLock) error {
+	switch lock {
+	case op.MovieLockedByRating:
+		return ErrMovieRatingLocked
+	case op.MovieLockedByPassword:
+		return ErrMoviePasswordLocked
+	default:
+		return ErrMovieLocked
+	}
+}
+
+func canRestrictMovie(user *op.User, room *op.Room, movie *dbModel.Movie) bool {
+	return user.ID == movie.CreatorID || user.IsAdmin() || user.IsRoomCreator(room)
+}
+
+// lockMovie strips everything that leads to the source of a locked movie,
+// clients blur the artwork of movies locked by rating
+func lockMovie(m *model.Movie, lock op.MovieLock) {
+	m.Locked = true
+	m.LockReason = lock
+	m.Base = dbModel.MovieBase{
+		Name:     m.Base.Name,
+		Live:     m.Base.Live,
+		IsFolder: m.Base.IsFolder,
+		ParentID: m.Base.ParentID,
+		Rating:   m.Base.Rating,
+		VendorInfo: dbModel.VendorInfo{
+			Vendor: m.Base.VendorInfo.Vendor,
+		},
+	}
+}
+
+// checkWatchMovie aborts with 403 when the movie is locked for the user
+func checkWatchMovie(ctx *gin.Context, user *op.User, room *op.Room, movie *dbModel.Movie) bool {
+	lock := user.MovieLock(room, movie)
+	if lock == op.MovieUnlocked {
+		return true
+	}
+	ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(movieLockError(lock)))
+	return false
+}
+
+func movieLock(user *op.User, movie *dbModel.Movie) (op.MovieLock, error) {
+	room, err := op.LoadOrInitRoomByID(movie.RoomID)
+	if err != nil {
+		return op.MovieUnlocked, err
+	}
+	return user.MovieLock(room.Value(), movie), nil
+}
@@ -51,6 +51,51 @@ func RoomMe(ctx *gin.Context) {
 	}))
 }
 
+// RoomCurrent returns the current movie and status for integrations without
+// a websocket, like overlays and bots. with extrapolate=true the seek is
+// extrapolated to the server time, otherwise it is the seek as last set
+func RoomCurrent(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+
+	version := room.CurrentVersion()
+	extrapolate := ctx.Query("extrapolate") == "true"
+	var current *op.Current
+	if extrapolate {
+		current = room.Current()
+	} else {
+		current = room.CurrentSnapshot()
+	}
+	resp := &model.RoomCurrentResp{
+		Version: version,
+		Movie: model.RoomCurrentMovie{
+			ID:   current.Movie.ID,
+			Live: current.Movie.IsLive,
+		},
+		Status:       current.Status,
+		Extrapolated: extrapolate,
+		UpdatedAt:    current.Status.UpdatedAt().UnixMilli(),
+		ServerTime:   time.Now().UnixMilli(),
+	}
+	if current.Movie.ID != "" {
+		if m, err := room.GetMovieByID(current.Movie.ID); err == nil {
+			resp.Movie.Name = m.Movie.MovieBase.Name
+		}
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+// RoomSyncProfile returns the sync profile of the room with its thresholds,
+// clients correct their drift with them
+func RoomSyncProfile(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(&model.RoomSyncProfileResp{
+		Profile: room.Settings.SyncProfile,
+		Tuning:  room.SyncTuning(),
+	}))
+}
+
 func RoomPiblicSettings(ctx *gin.Context) {
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(room.Settings))
@@ -73,14 +118,30 @@ func CreateRoom(ctx *gin.Context) {
 		return
 	}
 
-	room, err := user.CreateRoom(req.RoomName, req.Password, db.WithSettingHidden(req.Settings.Hidden))
+	opts := []db.CreateRoomConfig{db.WithSettingHidden(req.Settings.Hidden)}
+	if req.Org != "" {
+		org, err := db.GetOrganizationByName(req.Org)
+		if err != nil {
+			log.Errorf("create room failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(err))
+			return
+		}
+		if !user.IsOrgMember(org.ID) {
+			log.Errorf("create room failed: not a member of organization %s", org.Name)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(dbModel.ErrNoPermission))
+			return
+		}
+		opts = append(opts, db.WithOrg(org.ID))
+	}
+
+	room, err := user.CreateRoom(req.RoomName, req.Password, opts...)
 	if err != nil {
 		log.Errorf("create room failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
 		return
 	}
 
-	token, err := middlewares.NewAuthRoomToken(user, room.Value())
+	token, err := middlewares.NewAuthRoomToken(user, room.Value(), ctx.GetString("login"))
 	if err != nil {
 		log.Errorf("create room failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -93,6 +154,48 @@ func CreateRoom(ctx *gin.Context) {
 	}))
 }
 
+func CloneRoom(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if settings.DisableCreateRoom.Get() && !user.IsAdmin() {
+		log.Error("create room is disabled")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("create room is disabled"))
+		return
+	}
+
+	req := model.CloneRoomReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("clone room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	clone, err := user.CloneRoom(room, req.RoomName, req.Password)
+	if err != nil {
+		log.Errorf("clone room failed: %v", err)
+		if errors.Is(err, dbModel.ErrNoPermission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	token, err := middlewares.NewAuthRoomToken(user, clone.Value(), ctx.GetString("login"))
+	if err != nil {
+		log.Errorf("clone room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, model.NewApiDataResp(gin.H{
+		"roomId": clone.Value().ID,
+		"token":  token,
+	}))
+}
+
 var roomHotCache = refreshcache.NewRefreshCache(func(context.Context, ...any) ([]*model.RoomListResp, error) {
 	rooms := make([]*model.RoomListResp, 0)
 	op.RangeRoomCache(func(key string, value *synccache.Entry[*op.Room]) bool {
@@ -105,6 +208,7 @@ var roomHotCache = refreshcache.NewRefreshCache(func(context.Context, ...any) ([
 				NeedPassword: v.NeedPassword(),
 				Creator:      op.GetUserName(v.CreatorID),
 				CreatedAt:    v.CreatedAt.UnixMilli(),
+				OrgID:        v.OrgID,
 			})
 		}
 		return true
@@ -140,12 +244,25 @@ func RoomHotList(ctx *gin.Context) {
 		return
 	}
 
-	r, err := roomHotCache.Get(ctx)
+	orgID, code, err := listingOrgID(ctx)
+	if err != nil {
+		log.Errorf("get room hot list failed: %v", err)
+		ctx.AbortWithStatusJSON(code, model.NewApiErrorResp(err))
+		return
+	}
+
+	all, err := roomHotCache.Get(ctx)
 	if err != nil {
 		log.Errorf("get room hot list failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
+	r := make([]*model.RoomListResp, 0, len(all))
+	for _, v := range all {
+		if v.OrgID == orgID {
+			r = append(r, v)
+		}
+	}
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
 		"total": len(r),
@@ -156,19 +273,27 @@ func RoomHotList(ctx *gin.Context) {
 func RoomList(ctx *gin.Context) {
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	page, err := newRoomCursorList(ctx, "desc")
 	if err != nil {
 		log.Errorf("get room list failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
 		return
 	}
 
+	orgID, code, err := listingOrgID(ctx)
+	if err != nil {
+		log.Errorf("get room list failed: %v", err)
+		ctx.AbortWithStatusJSON(code, model.NewApiErrorResp(err))
+		return
+	}
+
 	scopes := []func(db *gorm.DB) *gorm.DB{
 		func(db *gorm.DB) *gorm.DB {
 			return db.InnerJoins("JOIN room_settings ON rooms.id = room_settings.id")
 		},
 		db.WhereRoomSettingWithoutHidden(),
 		db.WhereStatus(dbModel.RoomStatusActive),
+		db.WhereOrgID(orgID),
 	}
 
 	if keyword := ctx.Query("keyword"); keyword != "" {
@@ -204,44 +329,37 @@ func RoomList(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "createdAt":
-		if desc {
-			scopes = append(scopes, db.OrderByCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("name"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("name"))
-		}
-	default:
-		log.Errorf("get room list failed: not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := genRoomListResp(append(scopes, db.Paginate(page, pageSize))...)
+	rooms, err := db.GetAllRooms(append(scopes, page.scope)...)
 	if err != nil {
 		log.Errorf("get room list failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
+	rooms, next := page.next(rooms)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  list,
+		"total":      total,
+		"list":       newRoomListResp(rooms),
+		"nextCursor": next,
 	}))
 }
 
-func genRoomListResp(scopes ...func(db *gorm.DB) *gorm.DB) ([]*model.RoomListResp, error) {
-	rs, err := db.GetAllRooms(scopes...)
-	if err != nil {
-		return nil, err
-	}
+var roomListSorts = map[string]listSort[*dbModel.Room]{
+	"createdAt": {
+		column: db.SortColumn{Column: "rooms.created_at", Time: true},
+		value:  func(r *dbModel.Room) any { return r.CreatedAt },
+	},
+	"name": {
+		column: db.SortColumn{Column: "rooms.name"},
+		value:  func(r *dbModel.Room) any { return r.Name },
+	},
+}
+
+func newRoomCursorList(ctx *gin.Context, defaultOrder string) (*cursorList[*dbModel.Room], error) {
+	return newCursorList(ctx, roomListSorts, "name", defaultOrder, "rooms.id", func(r *dbModel.Room) string { return r.ID })
+}
+
+func newRoomListResp(rs []*dbModel.Room) []*model.RoomListResp {
 	resp := make([]*model.RoomListResp, len(rs))
 	for i, r := range rs {
 		resp[i] = &model.RoomListResp{
@@ -253,9 +371,10 @@ func genRoomListResp(scopes ...func(db *gorm.DB) *gorm.DB) ([]*model.RoomListRes
 			Creator:      op.GetUserName(r.CreatorID),
 			CreatedAt:    r.CreatedAt.UnixMilli(),
 			Status:       r.Status,
+			OrgID:        r.OrgID,
 		}
 	}
-	return resp, nil
+	return resp
 }
 
 func CheckRoom(ctx *gin.Context) {
@@ -311,7 +430,13 @@ func GuestJoinRoom(ctx *gin.Context) {
 		return
 	}
 
-	token, err := middlewares.NewAuthRoomToken(user, room)
+	if err := user.CheckRoomMembership(ctx, room); err != nil {
+		log.Warnf("guest join room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		return
+	}
+
+	token, err := middlewares.NewAuthRoomToken(user, room, ctx.GetString("login"))
 	if err != nil {
 		log.Errorf("guest join room failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -353,7 +478,13 @@ func LoginRoom(ctx *gin.Context) {
 		return
 	}
 
-	token, err := middlewares.NewAuthRoomToken(user, room)
+	if err := user.CheckRoomMembership(ctx, room); err != nil {
+		log.Warnf("login room failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorResp(err))
+		return
+	}
+
+	token, err := middlewares.NewAuthRoomToken(user, room, ctx.GetString("login"))
 	if err != nil {
 		log.Errorf("login room failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -416,7 +547,7 @@ func SetRoomPassword(ctx *gin.Context) {
 		return
 	}
 
-	token, err := middlewares.NewAuthRoomToken(user, room)
+	token, err := middlewares.NewAuthRoomToken(user, room, ctx.GetString("login"))
 	if err != nil {
 		log.Errorf("set room password failed: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -431,7 +562,11 @@ func SetRoomPassword(ctx *gin.Context) {
 
 func RoomSetting(ctx *gin.Context) {
 	room := ctx.MustGet("room").(*op.RoomEntry).Value()
-	// user := ctx.MustGet("user").(*op.UserEntry)
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+	if notModified(ctx, room, user, op.ResourceSettings) {
+		return
+	}
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(room.Settings))
 }
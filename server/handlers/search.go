@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+const maxSearchQueryLength = 128
+
+// Search searches the names, descriptions and tags of listed rooms, or the
+// playlists of listed rooms with type=movie
+func Search(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	query := strings.TrimSpace(ctx.Query("q"))
+	if query == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("query is empty"))
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("query too long"))
+		return
+	}
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("search failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	orgID, code, err := listingOrgID(ctx)
+	if err != nil {
+		log.Errorf("search failed: %v", err)
+		ctx.AbortWithStatusJSON(code, model.NewApiErrorResp(err))
+		return
+	}
+
+	switch ctx.DefaultQuery("type", "room") {
+	case "room":
+		rooms, total, err := db.SearchRooms(query, orgID, page, pageSize)
+		if err != nil {
+			log.Errorf("search rooms failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		list := make([]*model.SearchRoomResp, len(rooms))
+		for i, r := range newRoomListResp(rooms) {
+			list[i] = &model.SearchRoomResp{RoomListResp: r, Tags: []string{}}
+			if s := rooms[i].Settings; s != nil {
+				list[i].Description = s.Description
+				if s.Tags != "" {
+					list[i].Tags = strings.Split(s.Tags, ",")
+				}
+			}
+		}
+		ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+			"total": total,
+			"list":  list,
+		}))
+	case "movie":
+		movies, total, err := db.SearchMovies(query, orgID, page, pageSize)
+		if err != nil {
+			log.Errorf("search movies failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		list := make([]*model.SearchMovieResp, len(movies))
+		for i, m := range movies {
+			list[i] = &model.SearchMovieResp{
+				ID:       m.ID,
+				Name:     m.Name,
+				RoomID:   m.RoomID,
+				RoomName: m.RoomName,
+			}
+		}
+		ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+			"total": total,
+			"list":  list,
+		}))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("type must be room or movie"))
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+var (
+	// og:image of share previews, no image if empty
+	ShareImage = settings.NewStringSetting(
+		"share_image",
+		"",
+		model.SettingGroupServer,
+		settings.WithValidatorString(func(s string) error {
+			if s == "" {
+				return nil
+			}
+			_, err := url.ParseRequestURI(s)
+			return err
+		}),
+	)
+
+	shareTemplate = template.Must(template.ParseFS(templates, "templates/share.html"))
+	shareCache    = synccache.NewSyncCache[string, *sharePreview](time.Minute * 5)
+)
+
+const shareCacheTTL = time.Minute
+
+type sharePreview struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+	OEmbedURL   string
+}
+
+// genSharePreview hides everything but a generic title for hidden rooms,
+// and the current movie and viewers for password protected rooms
+func genSharePreview(host string, room *op.Room) *sharePreview {
+	p := &sharePreview{
+		Title:       "SyncTV",
+		Description: "Watch together on SyncTV",
+		Image:       ShareImage.Get(),
+		URL:         fmt.Sprintf("%s/web/cinema/%s", host, room.ID),
+	}
+	if room.Settings.Hidden || room.Settings.DisableSharePreview {
+		return p
+	}
+	p.Title = room.Name
+	if room.NeedPassword() {
+		p.Description = fmt.Sprintf("Join room %s on SyncTV", room.Name)
+		return p
+	}
+	viewers := op.PeopleNum(room.ID)
+	if movie, err := room.LoadCurrentMovie(); err == nil {
+		if movie.Live {
+			p.Description = fmt.Sprintf("Live now: %s · %d watching", movie.Name, viewers)
+		} else {
+			p.Description = fmt.Sprintf("Now playing: %s · %d watching", movie.Name, viewers)
+		}
+		// the poster of the movie if it has one, else the share image
+		var poster string
+		if ok, _ := movie.Extensions().Get(model.ExtensionNamespaceMetadata, "poster", &poster); ok {
+			if u, err := url.ParseRequestURI(poster); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+				p.Image = poster
+			}
+		}
+	} else {
+		p.Description = fmt.Sprintf("%d watching", viewers)
+	}
+	if roomEmbeddable(room) {
+		p.OEmbedURL = fmt.Sprintf("%s/api/oembed?%s", host, url.Values{
+			"url":    []string{p.URL},
+			"format": []string{"json"},
+		}.Encode())
+	}
+	return p
+}
+
+func ShareRoom(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	roomE, err := op.LoadOrInitRoomByID(ctx.Param("roomId"))
+	if err != nil {
+		log.Errorf("load room failed: %v", err)
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	}
+	room := roomE.Value()
+
+	host := requestHost(ctx)
+	key := host + "|" + room.ID
+	var p *sharePreview
+	if e, ok := shareCache.Load(key); ok {
+		p = e.Value()
+	} else {
+		p = genSharePreview(host, room)
+		shareCache.Store(key, p, shareCacheTTL)
+	}
+
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(shareCacheTTL.Seconds())))
+	if err := shareTemplate.Execute(ctx.Writer, p); err != nil {
+		log.Errorf("render share page failed: %v", err)
+	}
+}
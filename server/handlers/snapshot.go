@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func genRoomSnapshotResp(ctx *gin.Context, s *dbModel.RoomSnapshot) *model.RoomSnapshotResp {
+	return &model.RoomSnapshotResp{
+		ID:        s.ID,
+		RoomID:    s.RoomID,
+		Creator:   op.GetUserName(s.CreatorID),
+		Title:     s.Title,
+		StartAt:   s.StartAt.UnixMilli(),
+		EndAt:     s.EndAt.UnixMilli(),
+		CreatedAt: s.CreatedAt.UnixMilli(),
+		URL:       requestHost(ctx) + "/api/public/snapshot/" + s.ID,
+		Playlist:  s.Playlist,
+		Chat:      s.Chat,
+		Timeline:  s.Timeline,
+	}
+}
+
+func roomSnapshotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		return http.StatusForbidden
+	case errors.Is(err, op.ErrSnapshotNotStarted):
+		return http.StatusBadRequest
+	case errors.Is(err, db.ErrNotFound("snapshot")):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func RoomSnapshots(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	snapshots, err := db.GetRoomSnapshots(room.ID)
+	if err != nil {
+		log.Errorf("get room snapshots failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	list := make([]*model.RoomSnapshotResp, len(snapshots))
+	for i, s := range snapshots {
+		list[i] = genRoomSnapshotResp(ctx, s)
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(list))
+}
+
+// PublicRoomSnapshot returns the full snapshot, it needs no auth so the
+// replay can be shared with people outside the room
+func PublicRoomSnapshot(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	snapshot, err := db.GetRoomSnapshot(ctx.Param("id"))
+	if err != nil {
+		log.Errorf("get room snapshot failed: %v", err)
+		ctx.AbortWithStatusJSON(roomSnapshotErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(genRoomSnapshotResp(ctx, snapshot)))
+}
+
+func RoomAdminStartSnapshot(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if err := user.StartRoomSnapshot(room); err != nil {
+		log.Errorf("start room snapshot failed: %v", err)
+		ctx.AbortWithStatusJSON(roomSnapshotErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminPublishSnapshot(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.PublishSnapshotReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	snapshot, err := user.PublishRoomSnapshot(room, req.Title)
+	if err != nil {
+		log.Errorf("publish room snapshot failed: %v", err)
+		ctx.AbortWithStatusJSON(roomSnapshotErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(genRoomSnapshotResp(ctx, snapshot)))
+}
+
+func RoomAdminDeleteSnapshot(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.IdReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteRoomSnapshot(room, req.Id); err != nil {
+		log.Errorf("delete room snapshot failed: %v", err)
+		ctx.AbortWithStatusJSON(roomSnapshotErrorStatus(err), model.NewApiErrorResp(err))
+		return
+	}
+	publicCache.Purge(cacheTagSnapshot(req.Id))
+
+	ctx.Status(http.StatusNoContent)
+}
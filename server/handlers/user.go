@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"math/rand"
 	"net/http"
 	"net/url"
@@ -13,13 +14,13 @@ import (
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/email"
 	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
 	"github.com/synctv-org/synctv/internal/op"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/internal/provider/providers"
 	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/server/middlewares"
 	"github.com/synctv-org/synctv/server/model"
-	"github.com/synctv-org/synctv/utils"
 	"golang.org/x/exp/slices"
 	"gorm.io/gorm"
 )
@@ -33,6 +34,8 @@ func Me(ctx *gin.Context) {
 		Role:      user.Role,
 		CreatedAt: user.CreatedAt.UnixMilli(),
 		Email:     user.Email.String(),
+
+		MaxContentRating: user.MaxContentRating,
 	}))
 }
 
@@ -63,7 +66,7 @@ func LoginUser(ctx *gin.Context) {
 		return
 	}
 
-	token, err := middlewares.NewAuthUserToken(user.Value())
+	token, err := middlewares.NewLoginToken(ctx, user.Value())
 	if err != nil {
 		log.Errorf("failed to generate token: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -93,7 +96,7 @@ func UserRooms(ctx *gin.Context) {
 	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	page, pageSize, err := utils.GetPageAndMax(ctx)
+	page, err := newRoomCursorList(ctx, "desc")
 	if err != nil {
 		log.Errorf("failed to get page and max: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -138,36 +141,18 @@ func UserRooms(ctx *gin.Context) {
 		return
 	}
 
-	var desc = ctx.DefaultQuery("order", "desc") == "desc"
-	switch ctx.DefaultQuery("sort", "name") {
-	case "createdAt":
-		if desc {
-			scopes = append(scopes, db.OrderByCreatedAtDesc)
-		} else {
-			scopes = append(scopes, db.OrderByCreatedAtAsc)
-		}
-	case "name":
-		if desc {
-			scopes = append(scopes, db.OrderByDesc("name"))
-		} else {
-			scopes = append(scopes, db.OrderByAsc("name"))
-		}
-	default:
-		log.Errorf("not support sort")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("not support sort"))
-		return
-	}
-
-	list, err := genRoomListResp(append(scopes, db.Paginate(page, pageSize))...)
+	rooms, err := db.GetAllRooms(append(scopes, page.scope)...)
 	if err != nil {
 		log.Errorf("failed to get all rooms: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
+	rooms, next := page.next(rooms)
 
 	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
-		"total": total,
-		"list":  list,
+		"total":      total,
+		"list":       newRoomListResp(rooms),
+		"nextCursor": next,
 	}))
 }
 
@@ -185,6 +170,11 @@ func SetUsername(ctx *gin.Context) {
 	err := user.SetUsername(req.Username)
 	if err != nil {
 		log.Errorf("failed to set username: %v", err)
+		var v *namepolicy.Violation
+		if errors.As(err, &v) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
@@ -210,7 +200,7 @@ func SetUserPassword(ctx *gin.Context) {
 		return
 	}
 
-	token, err := middlewares.NewAuthUserToken(user)
+	token, err := middlewares.NewAuthUserToken(user, ctx.GetString("login"))
 	if err != nil {
 		log.Errorf("failed to generate token: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -479,7 +469,7 @@ func UserSignupEmail(ctx *gin.Context) {
 		return
 	}
 
-	token, err := middlewares.NewAuthUserToken(user.Value())
+	token, err := middlewares.NewLoginToken(ctx, user.Value())
 	if err != nil {
 		log.Errorf("failed to generate token: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -588,7 +578,7 @@ func UserRetrievePasswordEmail(ctx *gin.Context) {
 		return
 	}
 
-	token, err := middlewares.NewAuthUserToken(user)
+	token, err := middlewares.NewLoginToken(ctx, user)
 	if err != nil {
 		log.Errorf("failed to generate token: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
@@ -633,3 +623,30 @@ func UserDeleteRoom(ctx *gin.Context) {
 
 	ctx.Status(http.StatusNoContent)
 }
+
+// SetUserContentRating lets users lower their own maximum content rating,
+// raising it is left to admins
+func SetUserContentRating(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	var req model.SetContentRatingReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if !user.IsAdmin() && user.MaxContentRating.Stricter(req.Rating) != req.Rating {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewApiErrorStringResp("only admins can raise the maximum content rating"))
+		return
+	}
+
+	if err := user.SetMaxContentRating(req.Rating); err != nil {
+		log.Errorf("failed to set max content rating: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
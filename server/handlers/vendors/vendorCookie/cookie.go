@@ -0,0 +1,196 @@
+// Package vendorCookie binds the cookies of vendors without a vendor service,
+// the handlers are shared by acfun and youku
+package vendorCookie
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/vendor"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+type LoginReq struct {
+	// the cookie header copied from the site
+	Cookies string `json:"cookies"`
+}
+
+func (r *LoginReq) Validate() error {
+	if r.Cookies == "" {
+		return errors.New("cookies is empty")
+	}
+	if len(r.Cookies) > 8192 {
+		return errors.New("cookies too long")
+	}
+	return nil
+}
+
+func (r *LoginReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func Login(vendorName dbModel.VendorName) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+		req := LoginReq{}
+		if err := model.Decode(ctx, &req); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			return
+		}
+		cookies := vendor.ParseCookieHeader(req.Cookies)
+		if len(cookies) == 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("no valid cookie"))
+			return
+		}
+
+		_, err := db.CreateOrSaveCookieVendor(&dbModel.CookieVendor{
+			UserID:  user.ID,
+			Vendor:  vendorName,
+			Cookies: cookies,
+		})
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func Logout(vendorName dbModel.VendorName) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet("user").(*op.UserEntry).Value()
+		if err := db.DeleteCookieVendor(user.ID, vendorName); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+type CookieVendorInfo struct {
+	// names of the bound cookies, values are never returned
+	Cookies   []string `json:"cookies"`
+	UpdatedAt int64    `json:"updatedAt"`
+}
+
+type MeResp = model.VendorMeResp[*CookieVendorInfo]
+
+func Me(vendorName dbModel.VendorName) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+		v, err := db.GetCookieVendor(user.ID, vendorName)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound("vendor")) {
+				ctx.JSON(http.StatusOK, model.NewApiDataResp(&MeResp{
+					IsLogin: false,
+				}))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+		names := make([]string, 0, len(v.Cookies))
+		for k := range v.Cookies {
+			names = append(names, k)
+		}
+		ctx.JSON(http.StatusOK, model.NewApiDataResp(&MeResp{
+			IsLogin: true,
+			Info: &CookieVendorInfo{
+				Cookies:   names,
+				UpdatedAt: v.UpdatedAt.UnixMilli(),
+			},
+		}))
+	}
+}
+
+type ParseReq struct {
+	URL string `json:"url"`
+}
+
+func (r *ParseReq) Validate() error {
+	if r.URL == "" {
+		return errors.New("url is empty")
+	}
+	if len(r.URL) > 4096 {
+		return model.ErrUrlTooLong
+	}
+	return nil
+}
+
+func (r *ParseReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+type StreamResp struct {
+	Name string `json:"name"`
+}
+
+type ParseResp struct {
+	ID      string        `json:"id"`
+	Title   string        `json:"title"`
+	Streams []*StreamResp `json:"streams"`
+}
+
+// Parse resolves a link with the cookies of the requester, the returned id
+// is the vendor info of the movie
+func Parse(vendorName dbModel.VendorName) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+		req := ParseReq{}
+		if err := model.Decode(ctx, &req); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			return
+		}
+
+		var cookies map[string]string
+		v, err := db.GetCookieVendor(user.ID, vendorName)
+		switch {
+		case err == nil:
+			cookies = v.Cookies
+		case !errors.Is(err, db.ErrNotFound("vendor")):
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+			return
+		}
+
+		var (
+			id      string
+			streams *vendor.VendorStreams
+		)
+		switch vendorName {
+		case dbModel.VendorAcfun:
+			id, err = vendor.ParseAcfunURL(req.URL)
+			if err == nil {
+				streams, err = vendor.AcfunResolve(ctx, id, cookies)
+			}
+		case dbModel.VendorYouku:
+			id, err = vendor.ParseYoukuURL(req.URL)
+			if err == nil {
+				streams, err = vendor.YoukuResolve(ctx, id, cookies)
+			}
+		default:
+			err = errors.New("invalid vendor name")
+		}
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			return
+		}
+
+		resp := &ParseResp{
+			ID:      id,
+			Title:   streams.Title,
+			Streams: make([]*StreamResp, len(streams.Streams)),
+		}
+		for i, s := range streams.Streams {
+			resp.Streams[i] = &StreamResp{Name: s.Name}
+		}
+		ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+	}
+}
@@ -0,0 +1,64 @@
+package vendorDouyin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/vendor"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+type ParseReq struct {
+	URL string `json:"url"`
+}
+
+func (r *ParseReq) Validate() error {
+	if r.URL == "" {
+		return errors.New("url is empty")
+	}
+	if len(r.URL) > 4096 {
+		return model.ErrUrlTooLong
+	}
+	return nil
+}
+
+func (r *ParseReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+type ParseResp struct {
+	Platform string `json:"platform"`
+	ID       string `json:"id"`
+	Live     bool   `json:"live"`
+	Title    string `json:"title"`
+}
+
+// Parse resolves a douyin or tiktok link into the vendor info of a movie,
+// the stream is resolved once to check it is playable
+func Parse(ctx *gin.Context) {
+	req := ParseReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	info, live, err := vendor.ParseDouyinURL(ctx, req.URL)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	stream, err := vendor.DouyinResolve(ctx, info, live)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(&ParseResp{
+		Platform: info.Platform,
+		ID:       info.ID,
+		Live:     live,
+		Title:    stream.Title,
+	}))
+}
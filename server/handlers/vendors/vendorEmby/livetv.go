@@ -0,0 +1,157 @@
+package vendorEmby
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/vendor"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+type LiveTvChannelItem struct {
+	*model.Item
+	Number         string                    `json:"number,omitempty"`
+	CurrentProgram *vendor.EmbyLiveTvProgram `json:"currentProgram,omitempty"`
+}
+
+type LiveTvChannelsResp = model.VendorFSListResp[*LiveTvChannelItem]
+
+// LiveTvChannels lists the channels of a server, push a channel as a live,
+// proxied movie with its path and vendorInfo.emby.channel set
+func LiveTvChannels(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+	req := model.ServerIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	page, size, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	aucd, err := user.EmbyCache().LoadOrStore(ctx, req.ServerID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("vendor")) {
+			ctx.JSON(http.StatusBadRequest, model.NewApiErrorStringResp("emby server not found"))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	data, err := vendor.EmbyLiveTvChannels(ctx, &vendor.EmbyLiveTvChannelsReq{
+		Host:       aucd.Host,
+		Token:      aucd.ApiKey,
+		UserID:     aucd.UserID,
+		StartIndex: uint64((page - 1) * size),
+		Limit:      uint64(size),
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(fmt.Errorf("emby live tv channels error: %w", err)))
+		return
+	}
+
+	resp := LiveTvChannelsResp{
+		Paths: []*model.Path{
+			{},
+			{
+				Name: aucd.Host,
+				Path: aucd.ServerID + "/",
+			},
+		},
+		Items: make([]*LiveTvChannelItem, len(data.Channels)),
+		Total: data.Total,
+	}
+	for i, c := range data.Channels {
+		resp.Items[i] = &LiveTvChannelItem{
+			Item: &model.Item{
+				Name: c.Name,
+				Path: fmt.Sprintf("%s/%s", aucd.ServerID, c.ID),
+			},
+			Number:         c.Number,
+			CurrentProgram: c.CurrentProgram,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
+
+const maxGuideHours = 7 * 24
+
+type LiveTvProgramsReq struct {
+	model.ServerIDReq
+	ChannelIDs []string `json:"channelIds"`
+	// hours of guide data from now, default 24
+	Hours int `json:"hours"`
+}
+
+func (r *LiveTvProgramsReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *LiveTvProgramsReq) Validate() error {
+	if err := r.ServerIDReq.Validate(); err != nil {
+		return err
+	}
+	if len(r.ChannelIDs) == 0 {
+		return errors.New("channelIds is required")
+	}
+	if len(r.ChannelIDs) > 100 {
+		return errors.New("too many channels")
+	}
+	if r.Hours == 0 {
+		r.Hours = 24
+	}
+	if r.Hours < 0 || r.Hours > maxGuideHours {
+		return fmt.Errorf("hours must be between 1 and %d", maxGuideHours)
+	}
+	return nil
+}
+
+// LiveTvPrograms returns the guide data of channels
+func LiveTvPrograms(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+	req := LiveTvProgramsReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	aucd, err := user.EmbyCache().LoadOrStore(ctx, req.ServerID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound("vendor")) {
+			ctx.JSON(http.StatusBadRequest, model.NewApiErrorStringResp("emby server not found"))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+
+	now := time.Now()
+	programs, err := vendor.EmbyLiveTvPrograms(ctx, &vendor.EmbyLiveTvProgramsReq{
+		Host:       aucd.Host,
+		Token:      aucd.ApiKey,
+		UserID:     aucd.UserID,
+		ChannelIDs: req.ChannelIDs,
+		From:       now,
+		To:         now.Add(time.Duration(req.Hours) * time.Hour),
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(fmt.Errorf("emby live tv programs error: %w", err)))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(programs))
+}
@@ -87,15 +87,8 @@ func Login(ctx *gin.Context) {
 		return
 	}
 
-	_, err = user.EmbyCache().StoreOrRefreshWithDynamicFunc(ctx, data.ServerId, func(ctx context.Context, key string, args ...struct{}) (*cache.EmbyUserCacheData, error) {
-		return &cache.EmbyUserCacheData{
-			Host:     req.Host,
-			ServerID: key,
-			ApiKey:   data.Token,
-			Backend:  backend,
-			UserID:   data.UserId,
-		}, nil
-	})
+	// reload from the record, which keeps the playback report preference
+	_, err = user.EmbyCache().StoreOrRefresh(ctx, data.ServerId)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
@@ -50,8 +50,9 @@ func Me(ctx *gin.Context) {
 }
 
 type EmbyBindsResp []*struct {
-	ServerID string `json:"serverID"`
-	Host     string `json:"host"`
+	ServerID       string `json:"serverID"`
+	Host           string `json:"host"`
+	ReportPlayback bool   `json:"reportPlayback"`
 }
 
 func Binds(ctx *gin.Context) {
@@ -72,11 +73,13 @@ func Binds(ctx *gin.Context) {
 	var resp EmbyBindsResp = make(EmbyBindsResp, len(ev))
 	for i, v := range ev {
 		resp[i] = &struct {
-			ServerID string "json:\"serverID\""
-			Host     string "json:\"host\""
+			ServerID       string "json:\"serverID\""
+			Host           string "json:\"host\""
+			ReportPlayback bool   "json:\"reportPlayback\""
 		}{
-			ServerID: v.ServerID,
-			Host:     v.Host,
+			ServerID:       v.ServerID,
+			Host:           v.Host,
+			ReportPlayback: v.ReportPlayback,
 		}
 	}
 
@@ -0,0 +1,45 @@
+package vendorEmby
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+type ReportPlaybackReq struct {
+	model.ServerIDReq
+	Enable bool `json:"enable"`
+}
+
+func (r *ReportPlaybackReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+// ReportPlayback enables reporting what the user watches in rooms back to
+// their emby server, so watched status and resume points stay accurate
+func ReportPlayback(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*op.UserEntry).Value()
+
+	req := ReportPlaybackReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	if err := db.SetEmbyVendorReportPlayback(user.ID, req.ServerID, req.Enable); err != nil {
+		if errors.Is(err, db.ErrNotFound("vendor")) {
+			ctx.JSON(http.StatusBadRequest, model.NewApiErrorStringResp("emby server not found"))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	user.EmbyCache().Delete(req.ServerID)
+
+	ctx.Status(http.StatusNoContent)
+}
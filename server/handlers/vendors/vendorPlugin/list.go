@@ -0,0 +1,101 @@
+package vendorPlugin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/vendor"
+	vendorpb "github.com/synctv-org/synctv/proto/vendor"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+type ListReq struct {
+	Plugin   string `json:"plugin"`
+	Path     string `json:"path"`
+	Keywords string `json:"keywords"`
+}
+
+func (r *ListReq) Validate() error {
+	if r.Plugin == "" {
+		return errors.New("plugin is empty")
+	}
+	return nil
+}
+
+func (r *ListReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+type PluginFileItem struct {
+	*model.Item
+	Live      bool   `json:"live"`
+	Size      uint64 `json:"size"`
+	Modified  int64  `json:"modified"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+type PluginFSListResp = model.VendorFSListResp[*PluginFileItem]
+
+// List browses the items of a vendor plugin, the path of items is their id
+// and is used as the plugin vendor info of movies
+func List(ctx *gin.Context) {
+	req := ListReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	page, size, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	p, err := vendor.LoadPlugin(req.Plugin)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	data, err := p.List(ctx, &vendorpb.ListReq{
+		Path:    req.Path,
+		Keyword: req.Keywords,
+		Page:    uint64(page),
+		PerPage: uint64(size),
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(fmt.Errorf("%s list error: %w", req.Plugin, err)))
+		return
+	}
+
+	resp := PluginFSListResp{
+		Paths: []*model.Path{
+			{},
+		},
+		Total: data.Total,
+	}
+	if req.Path != "" {
+		resp.Paths = append(resp.Paths, &model.Path{
+			Path: req.Path,
+		})
+	}
+	for _, i := range data.Items {
+		resp.Items = append(resp.Items, &PluginFileItem{
+			Item: &model.Item{
+				Name:  i.Name,
+				Path:  i.Id,
+				IsDir: i.IsFolder,
+			},
+			Live:      i.Live,
+			Size:      i.Size,
+			Modified:  i.Modified,
+			Thumbnail: i.Thumbnail,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(resp))
+}
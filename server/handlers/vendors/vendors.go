@@ -19,6 +19,9 @@ func Backends(ctx *gin.Context) {
 		backends = maps.Keys(vendor.LoadClients().AlistClients())
 	case dbModel.VendorEmby:
 		backends = maps.Keys(vendor.LoadClients().EmbyClients())
+	case dbModel.VendorPlugin:
+		// plugins have no backends, the loaded plugins are listed instead
+		backends = vendor.PluginNames()
 	default:
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid vendor name"))
 		return
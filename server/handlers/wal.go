@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/wal"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// RoomAdminMutations replays the mutations of the room from the write-ahead
+// log, the seq of the last entry is passed as after to get the next ones.
+// entries older than the log keeps are gone, the first seq shows the gap
+func RoomAdminMutations(ctx *gin.Context) {
+	room := ctx.MustGet("room").(*op.RoomEntry).Value()
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	if !wal.Enabled() {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewApiErrorResp(wal.ErrDisabled))
+		return
+	}
+	after, err := strconv.ParseUint(ctx.DefaultQuery("after", "0"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(errors.New("after must be a number")))
+		return
+	}
+	_, max, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	entries, err := wal.Read(room.ID, after, max)
+	if err != nil {
+		log.Errorf("read room mutations failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	if entries == nil {
+		entries = []*wal.Entry{}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"list": entries,
+	}))
+}
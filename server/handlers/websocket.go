@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,8 +22,6 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-const maxInterval = 10
-
 func NewWebSocketHandler(wss *utils.WebSocket) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token := ctx.GetHeader("Sec-WebSocket-Protocol")
@@ -40,13 +40,43 @@ func NewWebSocketHandler(wss *utils.WebSocket) gin.HandlerFunc {
 			"uro": user.Role.String(),
 		})
 
-		_ = wss.Server(ctx.Writer, ctx.Request, []string{token}, NewWSMessageHandler(user, room, entry))
+		version, capabilities := wsProtocol(ctx)
+		_ = wss.Server(ctx.Writer, ctx.Request, []string{token}, NewWSMessageHandler(wss, user, room, entry, ctx.Query("resume"), version, capabilities))
+	}
+}
+
+// wsProtocol reads the protocol version and the capability bits the client
+// asks for, the ack and precise flags of version 0 clients are kept as bits
+func wsProtocol(ctx *gin.Context) (uint32, op.Capability) {
+	var version uint32
+	if v, err := strconv.ParseUint(ctx.Query("v"), 10, 32); err == nil {
+		version = uint32(v)
+	}
+	var capabilities op.Capability
+	if c, err := strconv.ParseUint(ctx.Query("caps"), 10, 64); err == nil {
+		capabilities = op.Capability(c)
+	}
+	if ctx.Query("ack") == "true" {
+		capabilities |= op.CapabilityAck
 	}
+	if ctx.Query("precise") == "true" {
+		capabilities |= op.CapabilityPreciseSync
+	}
+	return version, capabilities
 }
 
-func NewWSMessageHandler(u *op.User, r *op.Room, l *logrus.Entry) func(c *websocket.Conn) error {
+func NewWSMessageHandler(wss *utils.WebSocket, u *op.User, r *op.Room, l *logrus.Entry, resumeToken string, version uint32, capabilities op.Capability) func(c *websocket.Conn) error {
 	return func(c *websocket.Conn) error {
-		client, err := r.NewClient(u, c)
+		var (
+			client  *op.Client
+			resumed bool
+			err     error
+		)
+		if resumeToken != "" {
+			client, resumed, err = r.ResumeClient(u, c, resumeToken)
+		} else {
+			client, err = r.NewClient(u, c)
+		}
 		if err != nil {
 			log.Errorf("ws: register client error: %v", err)
 			wc, err2 := c.NextWriter(websocket.BinaryMessage)
@@ -60,12 +90,28 @@ func NewWSMessageHandler(u *op.User, r *op.Room, l *logrus.Entry) func(c *websoc
 			}
 			return em.Encode(wc)
 		}
-		l.Info("ws: connected")
+		if resumed {
+			l.Info("ws: resumed")
+		} else {
+			l.Info("ws: connected")
+		}
+		u.UpdateLastAct()
 		defer func() {
-			_ = r.UnregisterClient(client)
 			client.Close()
+			_ = r.DetachClient(client)
 			l.Info("ws: disconnected")
 		}()
+		if _, err := client.Negotiate(version, capabilities); err != nil {
+			l.Errorf("ws: send hello error: %v", err)
+			return err
+		}
+		// a resumed client got its token ahead of the replayed messages
+		if !resumed {
+			if err := client.SendResumeToken(false); err != nil {
+				l.Errorf("ws: send resume token error: %v", err)
+				return err
+			}
+		}
 		if err := client.Send(&pb.ElementMessage{
 			Type:          pb.ElementMessageType_PEOPLE_CHANGED,
 			PeopleChanged: r.PeopleNum(),
@@ -73,13 +119,32 @@ func NewWSMessageHandler(u *op.User, r *op.Room, l *logrus.Entry) func(c *websoc
 			l.Errorf("ws: send people changed error: %v", err)
 			return err
 		}
+		if err := client.TimeSync(); err != nil {
+			l.Errorf("ws: send time sync error: %v", err)
+			return err
+		}
 		go handleReaderMessage(client, l)
-		return handleWriterMessage(client, l)
+		return handleWriterMessage(wss, client, l)
 	}
 }
 
-func handleWriterMessage(c *op.Client, l *logrus.Entry) error {
+func handleWriterMessage(wss *utils.WebSocket, c *op.Client, l *logrus.Entry) error {
+	var buf bytes.Buffer
 	for v := range c.GetReadChan() {
+		if wss.Compression && v.MessageType() == websocket.BinaryMessage {
+			buf.Reset()
+			if err := v.Encode(&buf); err != nil {
+				l.Errorf("ws: encode message error: %v", err)
+				return err
+			}
+			c.EnableWriteCompression(wss.CompressMessage(buf.Len()))
+			if err := c.WriteMessage(websocket.BinaryMessage, buf.Bytes()); err != nil {
+				l.Errorf("ws: write message error: %v", err)
+				return err
+			}
+			continue
+		}
+
 		wc, err := c.NextWriter(v.MessageType())
 		if err != nil {
 			l.Errorf("ws: get next writer error: %v", err)
@@ -110,6 +175,8 @@ func handleReaderMessage(c *op.Client, l *logrus.Entry) error {
 		t, rd, err := c.NextReader()
 		if err != nil {
 			l.Errorf("ws: get next reader error: %v", err)
+			// the connection is lost, keep the session for a reconnect
+			c.Drop()
 			return err
 		}
 		l.Debugf("ws: receive message type: %d", t)
@@ -152,19 +219,53 @@ func handleReaderMessage(c *op.Client, l *logrus.Entry) error {
 
 const MaxChatMessageLength = 4096
 
-func handleElementMsg(cli *op.Client, msg *pb.ElementMessage) error {
-	var timeDiff float64
-	if msg.Time != 0 {
-		timeDiff = time.Since(time.UnixMilli(msg.Time)).Seconds()
-	} else {
-		timeDiff = 0.0
+// userActivity reports whether the message is sent by the user, the others
+// like time syncs, acks and status checks are sent by the client on its own
+func userActivity(t pb.ElementMessageType) bool {
+	switch t {
+	case pb.ElementMessageType_CHAT_MESSAGE,
+		pb.ElementMessageType_TYPING,
+		pb.ElementMessageType_PLAY,
+		pb.ElementMessageType_PAUSE,
+		pb.ElementMessageType_CHANGE_RATE,
+		pb.ElementMessageType_CHANGE_SEEK:
+		return true
+	default:
+		return false
 	}
-	if timeDiff < 0 {
-		timeDiff = 0
-	} else if timeDiff > 1.5 {
-		timeDiff = 1.5
+}
+
+func handleElementMsg(cli *op.Client, msg *pb.ElementMessage) error {
+	receiveTime := time.Now().UnixMilli()
+	if userActivity(msg.Type) {
+		cli.User().UpdateLastAct()
 	}
 	switch msg.Type {
+	case pb.ElementMessageType_ACK:
+		cli.Ack(msg.AckId)
+		return nil
+	case pb.ElementMessageType_TIME_SYNC:
+		ts := msg.GetTimeSync()
+		if ts == nil {
+			return cli.Send(&pb.ElementMessage{
+				Type:  pb.ElementMessageType_ERROR,
+				Error: "time sync is empty",
+			})
+		}
+		// reply of the server probe
+		if ts.ServerSendTime != 0 && ts.ClientReceiveTime != 0 {
+			cli.UpdateClockSync(ts)
+			return nil
+		}
+		// the client measures its own offset
+		return cli.Send(&pb.ElementMessage{
+			Type: pb.ElementMessageType_TIME_SYNC,
+			TimeSync: &pb.TimeSync{
+				ClientSendTime:    ts.ClientSendTime,
+				ServerReceiveTime: receiveTime,
+				ServerSendTime:    time.Now().UnixMilli(),
+			},
+		})
 	case pb.ElementMessageType_CHAT_MESSAGE:
 		message := msg.GetChatReq()
 		if len(message) > MaxChatMessageLength {
@@ -181,10 +282,28 @@ func handleElementMsg(cli *op.Client, msg *pb.ElementMessage) error {
 			})
 		}
 		return err
+	case pb.ElementMessageType_TYPING:
+		err := cli.SetTyping(msg.GetTyping().GetTyping())
+		if err != nil && errors.Is(err, dbModel.ErrNoPermission) {
+			return cli.Send(&pb.ElementMessage{
+				Type:  pb.ElementMessageType_ERROR,
+				Error: fmt.Sprintf("set typing error: %v", err),
+			})
+		}
+		return err
+	case pb.ElementMessageType_READ_RECEIPT:
+		err := cli.ReadChatMessage(msg.GetReadReceipt().GetMessageId())
+		if err != nil && errors.Is(err, op.ErrChatMessageNotFound) {
+			return nil
+		}
+		return err
+	case pb.ElementMessageType_AUDIO_ONLY:
+		return cli.SetAudioOnly(msg.GetAudioOnly())
 	case pb.ElementMessageType_PLAY,
 		pb.ElementMessageType_PAUSE,
 		pb.ElementMessageType_CHANGE_RATE:
-		status, err := cli.SetStatus(msg.ChangeMovieStatusReq.Playing, msg.ChangeMovieStatusReq.Seek, msg.ChangeMovieStatusReq.Rate, timeDiff)
+		seek, timeDiff := cli.StatusRequest(msg.ChangeMovieStatusReq, msg.Time)
+		status, err := cli.SetStatus(msg.ChangeMovieStatusReq.Playing, seek, msg.ChangeMovieStatusReq.Rate, timeDiff)
 		if err != nil {
 			return cli.Send(&pb.ElementMessage{
 				Type:  pb.ElementMessageType_ERROR,
@@ -193,20 +312,18 @@ func handleElementMsg(cli *op.Client, msg *pb.ElementMessage) error {
 		}
 		return cli.Broadcast(&pb.ElementMessage{
 			Type: msg.Type,
+			Time: time.Now().UnixMilli(),
 			MovieStatusChanged: &pb.MovieStatusChanged{
 				Sender: &pb.Sender{
 					Username: cli.User().Username,
 					Userid:   cli.User().ID,
 				},
-				Status: &pb.MovieStatus{
-					Playing: status.Playing,
-					Seek:    status.Seek,
-					Rate:    status.Rate,
-				},
+				Status: cli.Room().StatusMessage(status),
 			},
 		}, op.WithIgnoreClient(cli))
 	case pb.ElementMessageType_CHANGE_SEEK:
-		status, err := cli.SetSeekRate(msg.ChangeMovieStatusReq.Seek, msg.ChangeMovieStatusReq.Rate, timeDiff)
+		seek, timeDiff := cli.StatusRequest(msg.ChangeMovieStatusReq, msg.Time)
+		status, err := cli.SetSeekRate(seek, msg.ChangeMovieStatusReq.Rate, timeDiff)
 		if err != nil {
 			return cli.Send(&pb.ElementMessage{
 				Type:  pb.ElementMessageType_ERROR,
@@ -215,32 +332,26 @@ func handleElementMsg(cli *op.Client, msg *pb.ElementMessage) error {
 		}
 		return cli.Broadcast(&pb.ElementMessage{
 			Type: msg.Type,
+			Time: time.Now().UnixMilli(),
 			MovieStatusChanged: &pb.MovieStatusChanged{
 				Sender: &pb.Sender{
 					Username: cli.User().Username,
 					Userid:   cli.User().ID,
 				},
-				Status: &pb.MovieStatus{
-					Playing: status.Playing,
-					Seek:    status.Seek,
-					Rate:    status.Rate,
-				},
+				Status: cli.Room().StatusMessage(status),
 			},
 		}, op.WithIgnoreClient(cli))
 	case pb.ElementMessageType_SYNC_MOVIE_STATUS:
 		status := cli.Room().Current().Status
 		return cli.Send(&pb.ElementMessage{
 			Type: pb.ElementMessageType_SYNC_MOVIE_STATUS,
+			Time: time.Now().UnixMilli(),
 			MovieStatusChanged: &pb.MovieStatusChanged{
 				Sender: &pb.Sender{
 					Username: cli.User().Username,
 					Userid:   cli.User().ID,
 				},
-				Status: &pb.MovieStatus{
-					Playing: status.Playing,
-					Seek:    status.Seek,
-					Rate:    status.Rate,
-				},
+				Status: cli.Room().StatusMessage(&status),
 			},
 		})
 	case pb.ElementMessageType_CHECK_EXPIRED:
@@ -262,26 +373,22 @@ func handleElementMsg(cli *op.Client, msg *pb.ElementMessage) error {
 	case pb.ElementMessageType_CHECK_STATUS:
 		current := cli.Room().Current()
 		status := current.Status
-		if status.Seek+maxInterval < msg.CheckStatusReq.Seek+timeDiff {
+		maxInterval := cli.Room().SyncTuning().CheckThreshold
+		seek, timeDiff := cli.StatusRequest(msg.CheckStatusReq, msg.Time)
+		if status.Seek+maxInterval < seek+timeDiff {
 			return cli.Send(&pb.ElementMessage{
 				Type: pb.ElementMessageType_TOO_FAST,
+				Time: time.Now().UnixMilli(),
 				MovieStatusChanged: &pb.MovieStatusChanged{
-					Status: &pb.MovieStatus{
-						Playing: status.Playing,
-						Seek:    status.Seek,
-						Rate:    status.Rate,
-					},
+					Status: cli.Room().StatusMessage(&status),
 				},
 			})
-		} else if status.Seek-maxInterval > msg.CheckStatusReq.Seek+timeDiff {
+		} else if status.Seek-maxInterval > seek+timeDiff {
 			return cli.Send(&pb.ElementMessage{
 				Type: pb.ElementMessageType_TOO_SLOW,
+				Time: time.Now().UnixMilli(),
 				MovieStatusChanged: &pb.MovieStatusChanged{
-					Status: &pb.MovieStatus{
-						Playing: status.Playing,
-						Seek:    status.Seek,
-						Rate:    status.Rate,
-					},
+					Status: cli.Room().StatusMessage(&status),
 				},
 			})
 		}
@@ -27,9 +27,18 @@ var (
 type AuthClaims struct {
 	UserId      string `json:"u"`
 	UserVersion uint32 `json:"uv"`
+	// the login the token was issued by, revoking it expires the token
+	LoginId string `json:"l,omitempty"`
 	jwt.RegisteredClaims
 }
 
+func (c *AuthClaims) checkLogin() error {
+	if c.LoginId != "" && op.IsLoginRevoked(c.LoginId) {
+		return ErrAuthExpired
+	}
+	return nil
+}
+
 type AuthRoomClaims struct {
 	AuthClaims
 	RoomId      string `json:"r"`
@@ -65,90 +74,132 @@ func authUser(Authorization string) (*AuthClaims, error) {
 }
 
 func AuthRoom(Authorization string) (*op.UserEntry, *op.RoomEntry, error) {
+	userE, roomE, _, err := authRoomEntries(Authorization)
+	return userE, roomE, err
+}
+
+func authRoomEntries(Authorization string) (*op.UserEntry, *op.RoomEntry, *AuthRoomClaims, error) {
 	claims, err := authRoom(Authorization)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	if len(claims.RoomId) != 32 {
-		return nil, nil, ErrAuthFailed
+		return nil, nil, nil, ErrAuthFailed
 	}
 
 	if len(claims.UserId) != 32 {
-		return nil, nil, ErrAuthFailed
+		return nil, nil, nil, ErrAuthFailed
 	}
 
 	userE, err := op.LoadOrInitUserByID(claims.UserId)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	user := userE.Value()
 
 	if !user.CheckVersion(claims.UserVersion) {
-		return nil, nil, ErrAuthExpired
+		return nil, nil, nil, ErrAuthExpired
+	}
+	if err := claims.checkLogin(); err != nil {
+		return nil, nil, nil, err
 	}
 
 	roomE, err := op.LoadOrInitRoomByID(claims.RoomId)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	room := roomE.Value()
 
 	if !room.CheckVersion(claims.RoomVersion) {
-		return nil, nil, ErrAuthExpired
+		return nil, nil, nil, ErrAuthExpired
 	}
 
 	rus, err := room.LoadOrCreateMemberStatus(user.ID)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if !rus.IsActive() {
 		if rus.IsPending() {
-			return nil, nil, fmt.Errorf("user is pending, need admin to approve")
+			return nil, nil, nil, fmt.Errorf("user is pending, need admin to approve")
 		}
-		return nil, nil, fmt.Errorf("user is banned")
+		return nil, nil, nil, fmt.Errorf("user is banned")
 	}
 
-	return userE, roomE, nil
+	return userE, roomE, claims, nil
 }
 
 func AuthUser(Authorization string) (*op.UserEntry, error) {
+	userE, _, err := authUserEntry(Authorization)
+	return userE, err
+}
+
+func authUserEntry(Authorization string) (*op.UserEntry, *AuthClaims, error) {
 	claims, err := authUser(Authorization)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if len(claims.UserId) != 32 {
-		return nil, ErrAuthFailed
+		return nil, nil, ErrAuthFailed
 	}
 
 	userE, err := op.LoadOrInitUserByID(claims.UserId)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	user := userE.Value()
 
 	if user.IsGuest() {
-		return nil, errors.New("user is guest, can not login")
+		return nil, nil, errors.New("user is guest, can not login")
 	}
 
 	if !user.CheckVersion(claims.UserVersion) {
-		return nil, ErrAuthExpired
+		return nil, nil, ErrAuthExpired
+	}
+	if err := claims.checkLogin(); err != nil {
+		return nil, nil, err
+	}
+
+	return userE, claims, nil
+}
+
+// NewLoginToken records a login of the user by the request and issues a
+// token of it
+func NewLoginToken(ctx *gin.Context, user *op.User) (string, error) {
+	if err := checkUserLogin(user); err != nil {
+		return "", err
 	}
+	login, err := op.RecordLogin(user, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		return "", fmt.Errorf("record login failed: %w", err)
+	}
+	return newAuthUserToken(user, login.ID)
+}
 
-	return userE, nil
+// NewAuthUserToken issues a token of an existing login, like a token
+// replacing one expired by a password change
+func NewAuthUserToken(user *op.User, loginID string) (string, error) {
+	if err := checkUserLogin(user); err != nil {
+		return "", err
+	}
+	return newAuthUserToken(user, loginID)
 }
 
-func NewAuthUserToken(user *op.User) (string, error) {
+func checkUserLogin(user *op.User) error {
 	if user.IsBanned() {
-		return "", errors.New("user banned")
+		return errors.New("user banned")
 	}
 	if user.IsPending() {
-		return "", errors.New("user is pending, need admin to approve")
+		return errors.New("user is pending, need admin to approve")
 	}
 	if user.IsGuest() {
-		return "", errors.New("user is guest, can not login")
+		return errors.New("user is guest, can not login")
 	}
+	return nil
+}
+
+func newAuthUserToken(user *op.User, loginID string) (string, error) {
 	t, err := time.ParseDuration(conf.Conf.Jwt.Expire)
 	if err != nil {
 		return "", err
@@ -156,6 +207,7 @@ func NewAuthUserToken(user *op.User) (string, error) {
 	claims := &AuthClaims{
 		UserId:      user.ID,
 		UserVersion: user.Version(),
+		LoginId:     loginID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(t)),
@@ -164,7 +216,9 @@ func NewAuthUserToken(user *op.User) (string, error) {
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(stream.StringToBytes(conf.Conf.Jwt.Secret))
 }
 
-func NewAuthRoomToken(user *op.User, room *op.Room) (string, error) {
+// NewAuthRoomToken issues a room token, loginID is the login of the user token
+// it was requested with so revoking the login expires it too
+func NewAuthRoomToken(user *op.User, room *op.Room, loginID string) (string, error) {
 	if user.IsBanned() {
 		return "", errors.New("user banned")
 	}
@@ -205,6 +259,7 @@ func NewAuthRoomToken(user *op.User, room *op.Room) (string, error) {
 		AuthClaims: AuthClaims{
 			UserId:      user.ID,
 			UserVersion: user.Version(),
+			LoginId:     loginID,
 			RegisteredClaims: jwt.RegisteredClaims{
 				NotBefore: jwt.NewNumericDate(time.Now()),
 				ExpiresAt: jwt.NewNumericDate(time.Now().Add(t)),
@@ -222,7 +277,7 @@ func AuthUserMiddleware(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
 		return
 	}
-	userE, err := AuthUser(token)
+	userE, claims, err := authUserEntry(token)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
 		return
@@ -238,6 +293,7 @@ func AuthUserMiddleware(ctx *gin.Context) {
 	}
 
 	ctx.Set("user", userE)
+	ctx.Set("login", claims.LoginId)
 	log := ctx.MustGet("log").(*logrus.Entry)
 	if log.Data == nil {
 		log.Data = make(logrus.Fields, 3)
@@ -253,7 +309,7 @@ func AuthRoomMiddleware(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
 		return
 	}
-	userE, roomE, err := AuthRoom(token)
+	userE, roomE, claims, err := authRoomEntries(token)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewApiErrorResp(err))
 		return
@@ -281,6 +337,7 @@ func AuthRoomMiddleware(ctx *gin.Context) {
 
 	ctx.Set("user", userE)
 	ctx.Set("room", roomE)
+	ctx.Set("login", claims.LoginId)
 	log := ctx.MustGet("log").(*logrus.Entry)
 	if log.Data == nil {
 		log.Data = make(logrus.Fields, 5)
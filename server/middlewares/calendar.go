@@ -0,0 +1,111 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/zijiren233/stream"
+)
+
+// calendar feeds are fetched by calendar apps which can not refresh tokens,
+// so the token lives long and is also invalidated by the user or room version.
+// It is signed with its own key so it can never be used as a session token
+const calendarTokenTTL = 180 * 24 * time.Hour
+
+func calendarKey() []byte {
+	h := hmac.New(sha256.New, stream.StringToBytes(conf.Conf.Jwt.Secret))
+	h.Write([]byte("synctv calendar token"))
+	return h.Sum(nil)
+}
+
+type AuthCalendarClaims struct {
+	UserId      string `json:"u"`
+	UserVersion uint32 `json:"uv"`
+	RoomId      string `json:"r,omitempty"`
+	RoomVersion uint32 `json:"rv,omitempty"`
+	Calendar    bool   `json:"cal"`
+	jwt.RegisteredClaims
+}
+
+func NewCalendarToken(user *op.User, room *op.Room) (string, error) {
+	if user.IsGuest() {
+		return "", errors.New("guest can not subscribe calendar")
+	}
+	claims := &AuthCalendarClaims{
+		UserId:      user.ID,
+		UserVersion: user.Version(),
+		Calendar:    true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(calendarTokenTTL)),
+		},
+	}
+	if room != nil {
+		claims.RoomId = room.ID
+		claims.RoomVersion = room.Version()
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(calendarKey())
+}
+
+func authCalendar(token string) (*AuthCalendarClaims, error) {
+	t, err := jwt.ParseWithClaims(token, &AuthCalendarClaims{}, func(token *jwt.Token) (any, error) {
+		return calendarKey(), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	claims, ok := t.Claims.(*AuthCalendarClaims)
+	if !ok || !t.Valid || !claims.Calendar {
+		return nil, ErrAuthFailed
+	}
+	return claims, nil
+}
+
+// AuthCalendar checks the calendar token, roomID is empty for user feeds
+func AuthCalendar(token, roomID string) (*op.UserEntry, error) {
+	claims, err := authCalendar(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims.UserId) != 32 || claims.RoomId != roomID {
+		return nil, ErrAuthFailed
+	}
+
+	userE, err := op.LoadOrInitUserByID(claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	user := userE.Value()
+	if !user.CheckVersion(claims.UserVersion) {
+		return nil, ErrAuthExpired
+	}
+	if user.IsBanned() || user.IsPending() {
+		return nil, ErrAuthFailed
+	}
+
+	if roomID == "" {
+		return userE, nil
+	}
+
+	roomE, err := op.LoadOrInitRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+	room := roomE.Value()
+	if !room.CheckVersion(claims.RoomVersion) {
+		return nil, ErrAuthExpired
+	}
+	status, err := room.LoadMemberStatus(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !status.IsActive() {
+		return nil, ErrAuthFailed
+	}
+	return userE, nil
+}
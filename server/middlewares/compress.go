@@ -0,0 +1,204 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// brotli levels above 5 cost much more cpu than they save for responses
+// compressed on the fly
+const brotliLevel = 4
+
+var (
+	gzipWriterPool = sync.Pool{
+		New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+			return w
+		},
+	}
+	brotliWriterPool = sync.Pool{
+		New: func() any {
+			return brotli.NewWriterLevel(io.Discard, brotliLevel)
+		},
+	}
+)
+
+// AcceptedEncodings returns the encodings of br and gzip the client accepts,
+// the preferred one first
+func AcceptedEncodings(acceptEncoding string) []string {
+	var br, gz float64
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "br":
+			br = q
+		case "gzip":
+			gz = q
+		}
+	}
+	encodings := make([]string, 0, 2)
+	switch {
+	case br > 0 && br >= gz:
+		encodings = append(encodings, "br")
+		if gz > 0 {
+			encodings = append(encodings, "gzip")
+		}
+	case gz > 0:
+		encodings = append(encodings, "gzip")
+		if br > 0 {
+			encodings = append(encodings, "br")
+		}
+	}
+	return encodings
+}
+
+// compressible reports whether responses of the content type shrink when
+// compressed, media and images are already compressed
+func compressible(contentType string) bool {
+	ct, _, _ := strings.Cut(contentType, ";")
+	switch ct = strings.ToLower(strings.TrimSpace(ct)); ct {
+	case "text/event-stream":
+		return false
+	case "application/json",
+		"application/javascript",
+		"application/xml",
+		"application/wasm",
+		"application/manifest+json",
+		"application/vnd.apple.mpegurl",
+		"application/x-mpegurl",
+		"application/dash+xml",
+		"image/svg+xml":
+		return true
+	}
+	return strings.HasPrefix(ct, "text/")
+}
+
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	decided bool
+	w       io.WriteCloser
+}
+
+// decide compresses the response when it is worth it, it is called before
+// the first byte of the body is written
+func (c *compressWriter) decide() {
+	if c.decided {
+		return
+	}
+	c.decided = true
+	h := c.Header()
+	status := c.Status()
+	if status < http.StatusOK ||
+		status == http.StatusNoContent ||
+		status == http.StatusPartialContent ||
+		status == http.StatusNotModified ||
+		h.Get("Content-Encoding") != "" ||
+		!compressible(h.Get("Content-Type")) {
+		return
+	}
+	h.Add("Vary", "Accept-Encoding")
+	if cl := h.Get("Content-Length"); cl != "" {
+		if n, err := strconv.Atoi(cl); err == nil && n < c.minSize {
+			return
+		}
+	}
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", c.encoding)
+	// strong validators are bound to the bytes sent
+	if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+		h.Set("ETag", "W/"+etag)
+	}
+	switch c.encoding {
+	case "br":
+		w := brotliWriterPool.Get().(*brotli.Writer)
+		w.Reset(c.ResponseWriter)
+		c.w = w
+	default:
+		w := gzipWriterPool.Get().(*gzip.Writer)
+		w.Reset(c.ResponseWriter)
+		c.w = w
+	}
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	c.decide()
+	if c.w == nil {
+		return c.ResponseWriter.Write(b)
+	}
+	return c.w.Write(b)
+}
+
+func (c *compressWriter) WriteString(s string) (int, error) {
+	return c.Write([]byte(s))
+}
+
+func (c *compressWriter) Flush() {
+	c.decide()
+	switch w := c.w.(type) {
+	case *gzip.Writer:
+		_ = w.Flush()
+	case *brotli.Writer:
+		_ = w.Flush()
+	}
+	c.ResponseWriter.Flush()
+}
+
+func (c *compressWriter) close() {
+	switch w := c.w.(type) {
+	case *gzip.Writer:
+		_ = w.Close()
+		w.Reset(io.Discard)
+		gzipWriterPool.Put(w)
+	case *brotli.Writer:
+		_ = w.Close()
+		w.Reset(io.Discard)
+		brotliWriterPool.Put(w)
+	}
+	c.w = nil
+}
+
+// NewCompress compresses responses with brotli or gzip as negotiated with
+// the client. Websocket upgrades, media and responses already encoded, like
+// pre-compressed web assets, are sent as they are
+func NewCompress(minSize int) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == http.MethodHead || ctx.GetHeader("Upgrade") != "" {
+			ctx.Next()
+			return
+		}
+		encodings := AcceptedEncodings(ctx.GetHeader("Accept-Encoding"))
+		if len(encodings) == 0 {
+			ctx.Next()
+			return
+		}
+		cw := &compressWriter{
+			ResponseWriter: ctx.Writer,
+			encoding:       encodings[0],
+			minSize:        minSize,
+		}
+		ctx.Writer = cw
+		defer func() {
+			cw.close()
+			ctx.Writer = cw.ResponseWriter
+		}()
+		ctx.Next()
+	}
+}
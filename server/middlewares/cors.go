@@ -10,5 +10,14 @@ func NewCors() gin.HandlerFunc {
 	config.AllowAllOrigins = true
 	config.AllowHeaders = []string{"*"}
 	config.AllowMethods = []string{"*"}
+	config.ExposeHeaders = []string{
+		"Location",
+		"Upload-Offset",
+		"Upload-Length",
+		"Tus-Resumable",
+		"Tus-Version",
+		"Tus-Extension",
+		"Tus-Max-Size",
+	}
 	return cors.New(config)
 }
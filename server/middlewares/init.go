@@ -6,29 +6,40 @@ import (
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/conf"
-	limiter "github.com/ulule/limiter/v3"
 )
 
 func Init(e *gin.Engine) {
+	initClientIP(e)
 	w := log.StandardLogger().Writer()
 	e.
 		Use(NewLog(log.StandardLogger())).
 		Use(gin.RecoveryWithWriter(w)).
 		Use(NewCors())
+	if conf.Conf.Server.Http.Compression {
+		e.Use(NewCompress(conf.Conf.Server.Http.CompressionMinSize))
+	}
 	if conf.Conf.RateLimit.Enable {
 		d, err := time.ParseDuration(conf.Conf.RateLimit.Period)
 		if err != nil {
 			log.Fatal(err)
 		}
-		options := []limiter.Option{
-			limiter.WithTrustForwardHeader(conf.Conf.RateLimit.TrustForwardHeader),
-		}
-		if conf.Conf.RateLimit.TrustedClientIPHeader != "" {
-			options = append(options, limiter.WithClientIPHeader(conf.Conf.RateLimit.TrustedClientIPHeader))
-		}
-		e.Use(NewLimiter(d, conf.Conf.RateLimit.Limit, options...))
+		e.Use(NewLimiter(d, conf.Conf.RateLimit.Limit))
 	}
 	if conf.Conf.Server.Http.Quic && conf.Conf.Server.Http.CertPath != "" && conf.Conf.Server.Http.KeyPath != "" {
 		e.Use(NewQuic())
 	}
 }
+
+// initClientIP makes ctx.ClientIP only honor forwarded headers from the
+// trusted proxies, gin trusts every peer by default
+func initClientIP(e *gin.Engine) {
+	if err := e.SetTrustedProxies(conf.Conf.Server.Http.TrustedProxies); err != nil {
+		log.Fatalf("invalid trusted proxies: %v", err)
+	}
+	// like X-Forwarded-For, custom headers are only honored from trusted proxies
+	for _, h := range []string{conf.Conf.RateLimit.TrustedClientIPHeader, conf.Conf.Server.Cdn.ClientIPHeader} {
+		if h != "" {
+			e.RemoteIPHeaders = append([]string{h}, e.RemoteIPHeaders...)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/zijiren233/stream"
+)
+
+// quick join tokens are signed with their own key so they can never be used
+// as a session token
+func quickJoinKey() []byte {
+	h := hmac.New(sha256.New, stream.StringToBytes(conf.Conf.Jwt.Secret))
+	h.Write([]byte("synctv quick join token"))
+	return h.Sum(nil)
+}
+
+type QuickJoinClaims struct {
+	RoomId      string `json:"r"`
+	RoomVersion uint32 `json:"rv"`
+	QuickJoin   bool   `json:"qj"`
+	jwt.RegisteredClaims
+}
+
+// NewQuickJoinToken returns a short-lived token which allows joining the room without password
+func NewQuickJoinToken(room *op.Room, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := &QuickJoinClaims{
+		RoomId:      room.ID,
+		RoomVersion: room.Version(),
+		QuickJoin:   true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(quickJoinKey())
+	return token, expiresAt, err
+}
+
+func AuthQuickJoin(token string) (*op.RoomEntry, error) {
+	t, err := jwt.ParseWithClaims(token, &QuickJoinClaims{}, func(token *jwt.Token) (any, error) {
+		return quickJoinKey(), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrAuthExpired
+		}
+		return nil, ErrAuthFailed
+	}
+	claims, ok := t.Claims.(*QuickJoinClaims)
+	if !ok || !t.Valid || !claims.QuickJoin || len(claims.RoomId) != 32 {
+		return nil, ErrAuthFailed
+	}
+	roomE, err := op.LoadOrInitRoomByID(claims.RoomId)
+	if err != nil {
+		return nil, err
+	}
+	if !roomE.Value().CheckVersion(claims.RoomVersion) {
+		return nil, ErrAuthExpired
+	}
+	return roomE, nil
+}
@@ -16,7 +16,13 @@ func NewLimiter(Period time.Duration, Limit int64, options ...limiter.Option) gi
 		Period: Period,
 		Limit:  Limit,
 	}, options...)
-	return mgin.NewMiddleware(limit, mgin.WithLimitReachedHandler(func(c *gin.Context) {
-		c.JSON(http.StatusTooManyRequests, model.NewApiErrorStringResp("too many requests"))
-	}))
+	return mgin.NewMiddleware(limit,
+		mgin.WithLimitReachedHandler(func(c *gin.Context) {
+			c.JSON(http.StatusTooManyRequests, model.NewApiErrorStringResp("too many requests"))
+		}),
+		// the ip resolved with the trusted proxies, not the one of the proxy
+		mgin.WithKeyGetter(func(c *gin.Context) string {
+			return c.ClientIP()
+		}),
+	)
 }
@@ -0,0 +1,151 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zijiren233/gencontainer/synccache"
+	"golang.org/x/sync/singleflight"
+)
+
+// responses larger than this are not cached
+const maxCachedResponseSize = 1 << 20
+
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+	tags   []string
+	at     time.Time
+}
+
+// ResponseCache caches the responses of anonymous GET requests in process,
+// each route has its own ttl and tags, purging a tag drops the responses
+// stored with it before their ttl
+type ResponseCache struct {
+	entries *synccache.SyncCache[string, *cachedResponse]
+	flights singleflight.Group
+}
+
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{
+		entries: synccache.NewSyncCache[string, *cachedResponse](time.Minute),
+	}
+}
+
+// Purge drops the cached responses stored with any of the tags
+func (c *ResponseCache) Purge(tags ...string) {
+	c.entries.Range(func(key string, e *synccache.Entry[*cachedResponse]) bool {
+		for _, t := range tags {
+			if slices.Contains(e.Value().tags, t) {
+				c.entries.CompareAndDelete(key, e)
+				break
+			}
+		}
+		return true
+	})
+}
+
+// PurgeAll drops every cached response
+func (c *ResponseCache) PurgeAll() {
+	c.entries.Clear()
+}
+
+type recordWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (w *recordWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.buf.Len()+len(b) > maxCachedResponseSize {
+			w.overflow = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Handler caches the responses of the route for ttl, tags returns the tags
+// the response is stored with. Requests carrying a token are never cached
+// since what they see depends on the user
+func (c *ResponseCache) Handler(ttl time.Duration, tags func(ctx *gin.Context) []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet ||
+			ctx.GetHeader("Authorization") != "" ||
+			ctx.Query("token") != "" {
+			ctx.Next()
+			return
+		}
+		key := ctx.Request.Host + ctx.Request.URL.RequestURI()
+		if e, ok := c.entries.Load(key); ok {
+			c.serve(ctx, e.Value())
+			return
+		}
+
+		// the first request fills the entry, concurrent ones wait for it
+		var leader bool
+		v, _, _ := c.flights.Do(key, func() (any, error) {
+			leader = true
+			ctx.Header("X-Cache", "MISS")
+			w := &recordWriter{ResponseWriter: ctx.Writer}
+			ctx.Writer = w
+			ctx.Next()
+			ctx.Writer = w.ResponseWriter
+			if ctx.IsAborted() || w.Status() != http.StatusOK || w.overflow {
+				return nil, nil
+			}
+			header := w.Header().Clone()
+			// the body is recorded before compression, which is negotiated
+			// again for each request
+			header.Del("Content-Encoding")
+			header.Del("Content-Length")
+			header.Del("X-Cache")
+			r := &cachedResponse{
+				status: w.Status(),
+				header: header,
+				body:   w.buf.Bytes(),
+				at:     time.Now(),
+			}
+			if tags != nil {
+				r.tags = tags(ctx)
+			}
+			c.entries.Store(key, r, ttl)
+			return r, nil
+		})
+		if leader {
+			return
+		}
+		if r, ok := v.(*cachedResponse); ok {
+			c.serve(ctx, r)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func (c *ResponseCache) serve(ctx *gin.Context, r *cachedResponse) {
+	h := ctx.Writer.Header()
+	for k, v := range r.header {
+		// headers of the middlewares, like the request id, belong to this request
+		if _, ok := h[k]; !ok {
+			h[k] = v
+		}
+	}
+	h.Set("X-Cache", "HIT")
+	h.Set("Age", strconv.Itoa(int(time.Since(r.at).Seconds())))
+	ctx.Status(r.status)
+	_, _ = ctx.Writer.Write(r.body)
+	ctx.Abort()
+}
@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+// VendorEnabled rejects the requests of a vendor turned off by the admin
+func VendorEnabled(vendor dbModel.VendorName) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := settings.CheckVendorEnabled(vendor); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, model.NewApiErrorResp(err))
+			return
+		}
+		ctx.Next()
+	}
+}
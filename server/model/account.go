@@ -0,0 +1,81 @@
+package model
+
+import dbModel "github.com/synctv-org/synctv/internal/model"
+
+// UserExport is the archive of the data of a user, times are unix milli
+type UserExport struct {
+	ExportedAt    int64                    `json:"exportedAt"`
+	Profile       *UserInfoResp            `json:"profile"`
+	Providers     []string                 `json:"providers"`
+	Rooms         []*UserExportRoom        `json:"rooms"`
+	Memberships   []*UserExportMembership  `json:"memberships"`
+	FollowedRooms []string                 `json:"followedRooms"`
+	Movies        []*UserExportMovie       `json:"movies"`
+	Comments      []*UserExportComment     `json:"comments"`
+	ChatMessages  []*UserExportChatMessage `json:"chatMessages"`
+	MediaFiles    []*UserExportMediaFile   `json:"mediaFiles"`
+	Stats         *dbModel.UserStats       `json:"stats"`
+	Achievements  []*UserExportAchievement `json:"achievements"`
+}
+
+type UserExportRoom struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type UserExportMembership struct {
+	RoomID   string `json:"roomId"`
+	Role     string `json:"role"`
+	Status   string `json:"status"`
+	JoinedAt int64  `json:"joinedAt"`
+}
+
+type UserExportMovie struct {
+	ID        string `json:"id"`
+	RoomID    string `json:"roomId"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type UserExportComment struct {
+	ID        string `json:"id"`
+	RoomID    string `json:"roomId"`
+	MovieID   string `json:"movieId"`
+	Content   string `json:"content"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// UserExportChatMessage is a pinned chat message, other chat is not persisted
+type UserExportChatMessage struct {
+	RoomID  string `json:"roomId"`
+	Message string `json:"message"`
+	SentAt  int64  `json:"sentAt"`
+}
+
+type UserExportMediaFile struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	UploadedAt int64  `json:"uploadedAt"`
+}
+
+type UserExportAchievement struct {
+	Achievement dbModel.Achievement `json:"achievement"`
+	UnlockedAt  int64               `json:"unlockedAt"`
+}
+
+type UserDeletionResp struct {
+	UserID      string `json:"userId"`
+	RequestedAt int64  `json:"requestedAt"`
+	ScheduledAt int64  `json:"scheduledAt"`
+}
+
+func NewUserDeletionResp(d *dbModel.UserDeletion) *UserDeletionResp {
+	return &UserDeletionResp{
+		UserID:      d.UserID,
+		RequestedAt: d.CreatedAt.UnixMilli(),
+		ScheduledAt: d.ScheduledAt.UnixMilli(),
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import dbModel "github.com/synctv-org/synctv/internal/model"
+
+type AchievementResp struct {
+	*dbModel.AchievementInfo
+	Unlocked bool `json:"unlocked"`
+	// unix milli
+	UnlockedAt int64 `json:"unlockedAt,omitempty"`
+}
+
+type UserAchievementsResp struct {
+	Enabled      bool               `json:"enabled"`
+	Stats        *dbModel.UserStats `json:"stats"`
+	Achievements []*AchievementResp `json:"achievements"`
+}
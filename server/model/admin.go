@@ -33,12 +33,8 @@ type AddUserReq struct {
 }
 
 func (aur *AddUserReq) Validate() error {
-	if aur.Username == "" {
-		return errors.New("username is empty")
-	} else if len(aur.Username) > 32 {
-		return ErrUsernameTooLong
-	} else if !alnumPrintHanReg.MatchString(aur.Username) {
-		return ErrUsernameHasInvalidChar
+	if err := validateUsername(&aur.Username); err != nil {
+		return err
 	}
 
 	if aur.Password == "" {
@@ -81,6 +77,22 @@ func (aur *AdminUserPasswordReq) Decode(ctx *gin.Context) error {
 	return json.NewDecoder(ctx.Request.Body).Decode(aur)
 }
 
+type AdminContentRatingReq struct {
+	ID     string                `json:"id"`
+	Rating dbModel.ContentRating `json:"rating"`
+}
+
+func (a *AdminContentRatingReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(a)
+}
+
+func (a *AdminContentRatingReq) Validate() error {
+	if a.ID == "" {
+		return ErrInvalidID
+	}
+	return normalizeContentRating(&a.Rating)
+}
+
 type AdminUsernameReq struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
@@ -91,12 +103,8 @@ func (aur *AdminUsernameReq) Validate() error {
 		return ErrInvalidID
 	}
 
-	if aur.Username == "" {
-		return errors.New("username is empty")
-	} else if len(aur.Username) > 32 {
-		return ErrUsernameTooLong
-	} else if !alnumPrintHanReg.MatchString(aur.Username) {
-		return ErrUsernameHasInvalidChar
+	if err := validateUsername(&aur.Username); err != nil {
+		return err
 	}
 
 	return nil
@@ -190,3 +198,54 @@ func (ster *SendTestEmailReq) Validate() error {
 func (ster *SendTestEmailReq) Decode(ctx *gin.Context) error {
 	return json.NewDecoder(ctx.Request.Body).Decode(ster)
 }
+
+type AdminFeatureResp struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type AdminOAuth2ProviderResp struct {
+	Name string `json:"name"`
+	// compiled, plugin or config
+	Source  string `json:"source"`
+	Enabled bool   `json:"enabled"`
+}
+
+type AdminSetFeatureReq struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// shown to users while the feature is disabled
+	Reason string `json:"reason"`
+}
+
+func (r *AdminSetFeatureReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *AdminSetFeatureReq) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Reason) > 256 {
+		return errors.New("reason is too long")
+	}
+	return nil
+}
+
+type AdminRoomLogReq struct {
+	ID string `json:"id"`
+	// ring or file, defaults to ring
+	Sink string `json:"sink"`
+}
+
+func (r *AdminRoomLogReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *AdminRoomLogReq) Validate() error {
+	if r.ID == "" {
+		return ErrInvalidID
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type AlistWatchResp struct {
+	ID          string `json:"id"`
+	Path        string `json:"path"`
+	ParentID    string `json:"parentId"`
+	Include     string `json:"include,omitempty"`
+	Exclude     string `json:"exclude,omitempty"`
+	Creator     string `json:"creator"`
+	CreatorID   string `json:"creatorId"`
+	CreatedAt   int64  `json:"createdAt"`
+	LastCheckAt int64  `json:"lastCheckAt"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+type AddAlistWatchReq struct {
+	// serverId/path of the directory, as listed by the alist vendor
+	Path     string `json:"path"`
+	Password string `json:"password"`
+	Backend  string `json:"backend"`
+	ParentID string `json:"parentId"`
+	Include  string `json:"include"`
+	Exclude  string `json:"exclude"`
+	// queue the files already in the directory too
+	QueueExisting bool `json:"queueExisting"`
+}
+
+func (r *AddAlistWatchReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *AddAlistWatchReq) Validate() error {
+	if _, _, err := dbModel.GetAlistServerIdFromPath(r.Path); err != nil {
+		return err
+	}
+	if len(r.Path) > 4096 {
+		return ErrUrlTooLong
+	}
+	if len(r.Password) > 256 {
+		return ErrPasswordTooLong
+	}
+	if r.ParentID != "" && len(r.ParentID) != 32 {
+		return ErrId
+	}
+	if len(r.Include) > 256 || len(r.Exclude) > 256 {
+		return errors.New("filter too long")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"regexp"
 	"time"
 )
@@ -26,11 +27,22 @@ func (ar *ApiResp) SetDate(data any) {
 	ar.Data = data
 }
 
+// dataError is an error with details for clients, like the rule a name breaks
+type dataError interface {
+	error
+	ErrorData() any
+}
+
 func NewApiErrorResp(err error) *ApiResp {
-	return &ApiResp{
+	resp := &ApiResp{
 		Time:  time.Now().UnixMicro(),
 		Error: err.Error(),
 	}
+	var de dataError
+	if errors.As(err, &de) {
+		resp.Data = de.ErrorData()
+	}
+	return resp
 }
 
 func NewApiErrorStringResp(err string) *ApiResp {
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type ArrWebhookResp struct {
+	ID          string `json:"id"`
+	SourcePath  string `json:"sourcePath"`
+	TargetPath  string `json:"targetPath"`
+	ParentID    string `json:"parentId"`
+	Creator     string `json:"creator"`
+	CreatorID   string `json:"creatorId"`
+	CreatedAt   int64  `json:"createdAt"`
+	LastEventAt int64  `json:"lastEventAt"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+type AddArrWebhookReq struct {
+	SourcePath string `json:"sourcePath"`
+	// serverId/path of the same directory on alist
+	TargetPath string `json:"targetPath"`
+	Password   string `json:"password"`
+	Backend    string `json:"backend"`
+	ParentID   string `json:"parentId"`
+}
+
+func (r *AddArrWebhookReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *AddArrWebhookReq) Validate() error {
+	if strings.Trim(r.SourcePath, `/\`) == "" {
+		return errors.New("source path is required")
+	}
+	if _, _, err := dbModel.GetAlistServerIdFromPath(r.TargetPath); err != nil {
+		return err
+	}
+	if len(r.SourcePath) > 4096 || len(r.TargetPath) > 4096 {
+		return ErrUrlTooLong
+	}
+	if len(r.Password) > 256 {
+		return ErrPasswordTooLong
+	}
+	if r.ParentID != "" && len(r.ParentID) != 32 {
+		return ErrId
+	}
+	return nil
+}
+
+// ArrWebhookPayload is the webhook notification of sonarr and radarr,
+// only the fields of import notifications are decoded
+type ArrWebhookPayload struct {
+	EventType string `json:"eventType"`
+	IsUpgrade bool   `json:"isUpgrade"`
+
+	// sonarr
+	Series *struct {
+		Title string `json:"title"`
+		Path  string `json:"path"`
+	} `json:"series"`
+	Episodes []*struct {
+		SeasonNumber  int    `json:"seasonNumber"`
+		EpisodeNumber int    `json:"episodeNumber"`
+		Title         string `json:"title"`
+	} `json:"episodes"`
+	EpisodeFile *ArrWebhookFile `json:"episodeFile"`
+
+	// radarr
+	Movie *struct {
+		Title      string `json:"title"`
+		Year       int    `json:"year"`
+		FolderPath string `json:"folderPath"`
+	} `json:"movie"`
+	MovieFile *ArrWebhookFile `json:"movieFile"`
+}
+
+type ArrWebhookFile struct {
+	RelativePath string `json:"relativePath"`
+	Path         string `json:"path"`
+}
+
+func (p *ArrWebhookPayload) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(p)
+}
+
+func (p *ArrWebhookPayload) Validate() error {
+	if p.EventType == "" {
+		return errors.New("event type is required")
+	}
+	return nil
+}
+
+// IsImport reports whether the notification is a new import
+func (p *ArrWebhookPayload) IsImport() bool {
+	return p.EventType == "Download" && !p.IsUpgrade
+}
+
+// Imported returns the playlist name and full path of the imported file
+func (p *ArrWebhookPayload) Imported() (name, file string, err error) {
+	switch {
+	case p.Series != nil && p.EpisodeFile != nil:
+		file = p.EpisodeFile.Path
+		if file == "" {
+			file = path.Join(p.Series.Path, p.EpisodeFile.RelativePath)
+		}
+		name = p.Series.Title
+		if len(p.Episodes) != 0 {
+			e := p.Episodes[0]
+			name = fmt.Sprintf("%s - S%02dE%02d", p.Series.Title, e.SeasonNumber, e.EpisodeNumber)
+			if e.Title != "" {
+				name = fmt.Sprintf("%s - %s", name, e.Title)
+			}
+		}
+	case p.Movie != nil && p.MovieFile != nil:
+		file = p.MovieFile.Path
+		if file == "" {
+			file = path.Join(p.Movie.FolderPath, p.MovieFile.RelativePath)
+		}
+		name = p.Movie.Title
+		if p.Movie.Year != 0 {
+			name = fmt.Sprintf("%s (%d)", name, p.Movie.Year)
+		}
+	default:
+		return "", "", errors.New("no imported file in the notification")
+	}
+	if file == "" {
+		return "", "", errors.New("imported file has no path")
+	}
+	if name == "" {
+		name = path.Base(strings.ReplaceAll(file, `\`, "/"))
+	}
+	return name, file, nil
+}
@@ -42,3 +42,29 @@ func (o *OAuth2Req) Validate() error {
 func (o *OAuth2Req) Decode(ctx *gin.Context) error {
 	return json.NewDecoder(ctx.Request.Body).Decode(o)
 }
+
+type OAuth2SignupReq struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+}
+
+func (o *OAuth2SignupReq) Validate() error {
+	if o.Token == "" {
+		return errors.New("signup token is empty")
+	}
+	return validateUsername(&o.Username)
+}
+
+func (o *OAuth2SignupReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(o)
+}
+
+type OAuth2UsernameTakenResp struct {
+	SignupToken string `json:"signupToken"`
+	Username    string `json:"username"`
+}
+
+type OAuth2ErrorResp struct {
+	Code      string `json:"code"`
+	Retryable bool   `json:"retryable"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+type CastSubtitle struct {
+	Name string `json:"name"`
+	// always WebVTT, served with CORS headers for cast receivers
+	URL string `json:"url"`
+}
+
+type CastManifestResp struct {
+	MovieID     string          `json:"movieId"`
+	Title       string          `json:"title"`
+	URL         string          `json:"url"`
+	ContentType string          `json:"contentType"`
+	Live        bool            `json:"live"`
+	Subtitles   []*CastSubtitle `json:"subtitles"`
+	Status      op.Status       `json:"status"`
+	// unix milli of the status
+	Time int64 `json:"time"`
+	// the source needs custom headers which cast receivers can not send
+	NeedHeaders bool `json:"needHeaders"`
+}
+
+type CastStatusReq struct {
+	ReceiverID string  `json:"receiverId"`
+	Name       string  `json:"name"`
+	MovieID    string  `json:"movieId"`
+	Position   float64 `json:"position"`
+	Playing    bool    `json:"playing"`
+	// idle, buffering, playing, paused, stopped
+	State string `json:"state"`
+}
+
+func (c *CastStatusReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(c)
+}
+
+func (c *CastStatusReq) Validate() error {
+	if c.ReceiverID == "" || len(c.ReceiverID) > 64 {
+		return errors.New("invalid receiver id")
+	}
+	if len(c.Name) > 64 {
+		return errors.New("receiver name is too long")
+	}
+	if c.Position < 0 {
+		return errors.New("position must be positive")
+	}
+	return nil
+}
+
+type CastStatusResp struct {
+	MovieID string    `json:"movieId"`
+	Status  op.Status `json:"status"`
+	Time    int64     `json:"time"`
+	// receiver position minus room position, in seconds
+	Drift float64 `json:"drift"`
+	// the receiver should seek to status.seek
+	Seek bool `json:"seek"`
+	// the receiver should play at this rate until the drift is tolerated,
+	// zero if no correction is needed
+	Rate float64 `json:"rate,omitempty"`
+	// the receiver should load the new movie from the cast manifest
+	Reload bool `json:"reload"`
+}
+
+type CastReceiverResp struct {
+	ID        string  `json:"id"`
+	UserID    string  `json:"userId"`
+	Username  string  `json:"username"`
+	Name      string  `json:"name"`
+	MovieID   string  `json:"movieId"`
+	Position  float64 `json:"position"`
+	Playing   bool    `json:"playing"`
+	State     string  `json:"state"`
+	UpdatedAt int64   `json:"updatedAt"`
+}
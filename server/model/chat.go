@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type ChatMessageIDReq struct {
+	ID string `json:"id"`
+}
+
+func (c *ChatMessageIDReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(c)
+}
+
+func (c *ChatMessageIDReq) Validate() error {
+	if len(c.ID) != 32 {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+type RoomPurgeChatMessagesReq = UserIDReq
+
+type PinnedChatMessageResp struct {
+	ID         string `json:"id"`
+	SenderID   string `json:"senderId"`
+	SenderName string `json:"senderName"`
+	Message    string `json:"message"`
+	SentAt     int64  `json:"sentAt"`
+	PinnedBy   string `json:"pinnedBy"`
+	PinnedAt   int64  `json:"pinnedAt"`
+}
+
+type RoomAuditResp struct {
+	ID        string                  `json:"id"`
+	UserID    string                  `json:"userId"`
+	Username  string                  `json:"username"`
+	Action    dbModel.RoomAuditAction `json:"action"`
+	Target    string                  `json:"target"`
+	CreatedAt int64                   `json:"createdAt"`
+}
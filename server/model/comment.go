@@ -0,0 +1,104 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+)
+
+const maxCommentLength = 4096
+
+func validateCommentContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return errors.New("content is empty")
+	}
+	if len(content) > maxCommentLength {
+		return errors.New("content is too long")
+	}
+	return nil
+}
+
+type AddMovieCommentReq struct {
+	MovieID string `json:"movieId"`
+	// reply to the comment
+	ParentID string `json:"parentId"`
+	// markdown
+	Content string `json:"content"`
+}
+
+func (r *AddMovieCommentReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *AddMovieCommentReq) Validate() error {
+	if len(r.MovieID) != 32 {
+		return ErrId
+	}
+	if r.ParentID != "" && len(r.ParentID) != 32 {
+		return ErrId
+	}
+	return validateCommentContent(r.Content)
+}
+
+type EditMovieCommentReq struct {
+	IdReq
+	Content string `json:"content"`
+}
+
+func (r *EditMovieCommentReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *EditMovieCommentReq) Validate() error {
+	if err := r.IdReq.Validate(); err != nil {
+		return err
+	}
+	return validateCommentContent(r.Content)
+}
+
+type ReactMovieCommentReq struct {
+	IdReq
+	Emoji  string `json:"emoji"`
+	Remove bool   `json:"remove"`
+}
+
+func (r *ReactMovieCommentReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *ReactMovieCommentReq) Validate() error {
+	if err := r.IdReq.Validate(); err != nil {
+		return err
+	}
+	if r.Emoji == "" || len(r.Emoji) > 32 || !utf8.ValidString(r.Emoji) {
+		return errors.New("emoji is invalid")
+	}
+	if strings.IndexFunc(r.Emoji, unicode.IsSpace) != -1 {
+		return errors.New("emoji is invalid")
+	}
+	return nil
+}
+
+type CommentReactionResp struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+	// whether the requesting user reacted with it
+	Reacted bool `json:"reacted"`
+}
+
+type MovieCommentResp struct {
+	ID        string                 `json:"id"`
+	MovieID   string                 `json:"movieId"`
+	ParentID  string                 `json:"parentId,omitempty"`
+	CreatorID string                 `json:"creatorId"`
+	Creator   string                 `json:"creator"`
+	Content   string                 `json:"content"`
+	CreatedAt int64                  `json:"createdAt"`
+	UpdatedAt int64                  `json:"updatedAt"`
+	Reactions []*CommentReactionResp `json:"reactions"`
+	Replies   []*MovieCommentResp    `json:"replies,omitempty"`
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type RoomEventReq struct {
+	Type        dbModel.RoomEventType `json:"type"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	// unix milli
+	StartAt int64  `json:"startAt"`
+	EndAt   int64  `json:"endAt"`
+	MovieID string `json:"movieId"`
+}
+
+func (r *RoomEventReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *RoomEventReq) Validate() error {
+	switch r.Type {
+	case dbModel.RoomEventTypePlayback, dbModel.RoomEventTypeAnnouncement:
+	case "":
+		r.Type = dbModel.RoomEventTypePlayback
+	default:
+		return fmt.Errorf("unknown event type: %s", r.Type)
+	}
+	if r.Title == "" {
+		return errors.New("title is empty")
+	}
+	if len(r.Title) > 128 {
+		return errors.New("title is too long")
+	}
+	if len(r.Description) > 4096 {
+		return errors.New("description is too long")
+	}
+	if r.StartAt <= 0 {
+		return errors.New("start time is required")
+	}
+	if r.EndAt != 0 && r.EndAt < r.StartAt {
+		return errors.New("end time must be after start time")
+	}
+	if r.MovieID != "" && len(r.MovieID) != 32 {
+		return errors.New("movie id is invalid")
+	}
+	return nil
+}
+
+func (r *RoomEventReq) Apply(e *dbModel.RoomEvent) {
+	e.Type = r.Type
+	e.Title = r.Title
+	e.Description = r.Description
+	e.StartAt = time.UnixMilli(r.StartAt)
+	if r.EndAt != 0 {
+		e.EndAt = time.UnixMilli(r.EndAt)
+	} else {
+		e.EndAt = time.Time{}
+	}
+	e.MovieID = r.MovieID
+}
+
+type EditRoomEventReq struct {
+	IdReq
+	RoomEventReq
+}
+
+func (e *EditRoomEventReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(e)
+}
+
+func (e *EditRoomEventReq) Validate() error {
+	if err := e.IdReq.Validate(); err != nil {
+		return err
+	}
+	return e.RoomEventReq.Validate()
+}
+
+type RoomEventResp struct {
+	ID          string                `json:"id"`
+	CreatorID   string                `json:"creatorId"`
+	Creator     string                `json:"creator"`
+	Type        dbModel.RoomEventType `json:"type"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	StartAt     int64                 `json:"startAt"`
+	EndAt       int64                 `json:"endAt"`
+	MovieID     string                `json:"movieId,omitempty"`
+	CreatedAt   int64                 `json:"createdAt"`
+}
+
+type CalendarFeedResp struct {
+	URL string `json:"url"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import "github.com/synctv-org/synctv/internal/op"
+
+type KodiRoomResp struct {
+	RoomID string `json:"roomId"`
+	// list item label
+	Label string `json:"label"`
+	// list item second label, the creator
+	Label2       string `json:"label2"`
+	PeopleNum    int64  `json:"peopleNum"`
+	NeedPassword bool   `json:"needPassword"`
+	// name of the playing movie, empty for password protected rooms
+	Playing string `json:"playing"`
+}
+
+type KodiMovie struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Live bool   `json:"live"`
+}
+
+type KodiCurrentResp struct {
+	Version uint64    `json:"version"`
+	Movie   KodiMovie `json:"movie"`
+	// absolute url of the source
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// url with the headers appended in kodi's url|Header=value format,
+	// can be passed to xbmcgui.ListItem directly
+	Path        string          `json:"path"`
+	ContentType string          `json:"contentType"`
+	Subtitles   []*CastSubtitle `json:"subtitles"`
+	Status      op.Status       `json:"status"`
+	Time        int64           `json:"time"`
+}
+
+type KodiSyncResp struct {
+	Version uint64    `json:"version"`
+	Movie   KodiMovie `json:"movie"`
+	Status  op.Status `json:"status"`
+	Time    int64     `json:"time"`
+}
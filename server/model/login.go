@@ -0,0 +1,44 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+)
+
+type UserLoginResp struct {
+	ID        string `json:"id"`
+	CreatedAt int64  `json:"createdAt"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	// from a network and a device not seen before
+	Anomalous bool `json:"anomalous"`
+	Revoked   bool `json:"revoked"`
+	// the login of the token of the request
+	Current bool `json:"current"`
+}
+
+type RevokeUserLoginsReq struct {
+	IDs []string `json:"ids"`
+	// revoke all logins, including the current one
+	All bool `json:"all"`
+}
+
+func (r *RevokeUserLoginsReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *RevokeUserLoginsReq) Validate() error {
+	if r.All {
+		r.IDs = nil
+		return nil
+	}
+	if len(r.IDs) == 0 {
+		return errors.New("ids is required")
+	}
+	if len(r.IDs) > 100 {
+		return errors.New("too many ids")
+	}
+	return nil
+}
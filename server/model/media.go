@@ -0,0 +1,32 @@
+package model
+
+import (
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type MediaFileResp struct {
+	ID          string                  `json:"id"`
+	CreatedAt   int64                   `json:"createdAt"`
+	Name        string                  `json:"name"`
+	ContentType string                  `json:"contentType"`
+	Size        int64                   `json:"size"`
+	Uploaded    int64                   `json:"uploaded"`
+	Checksum    string                  `json:"checksum"`
+	Status      dbModel.MediaFileStatus `json:"status"`
+	// url to add the file as a movie
+	URL string `json:"url"`
+}
+
+func NewMediaFileResp(f *dbModel.MediaFile) *MediaFileResp {
+	return &MediaFileResp{
+		ID:          f.ID,
+		CreatedAt:   f.CreatedAt.UnixMilli(),
+		Name:        f.Name,
+		ContentType: f.ContentType,
+		Size:        f.Size,
+		Uploaded:    f.Uploaded,
+		Checksum:    f.Checksum,
+		Status:      f.Status,
+		URL:         dbModel.MediaURL(f.ID),
+	}
+}
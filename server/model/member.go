@@ -1,6 +1,8 @@
 package model
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	json "github.com/json-iterator/go"
 	dbModel "github.com/synctv-org/synctv/internal/model"
@@ -16,6 +18,20 @@ type RoomMembersResp struct {
 	RoomID           string                       `json:"roomId"`
 	Permissions      dbModel.RoomMemberPermission `json:"permissions"`
 	AdminPermissions dbModel.RoomAdminPermission  `json:"adminPermissions"`
+	Trial            bool                         `json:"trial,omitempty"`
+	// unix milli, 0 if the trial does not expire by time
+	TrialExpireAt int64 `json:"trialExpireAt,omitempty"`
+	// admin actions left, 0 if the trial is not limited by actions
+	TrialActions int64 `json:"trialActions,omitempty"`
+}
+
+type RoomMemberPresenceResp struct {
+	UserID       string `json:"userId"`
+	Username     string `json:"username"`
+	State        string `json:"state"`
+	LastActiveAt int64  `json:"lastActiveAt"`
+	OnlineCount  int    `json:"onlineCount"`
+	AudioOnly    bool   `json:"audioOnly"`
 }
 
 type RoomApproveMemberReq = UserIDReq
@@ -49,6 +65,32 @@ func (r *RoomSetAdminReq) Decode(ctx *gin.Context) error {
 	return json.NewDecoder(ctx.Request.Body).Decode(r)
 }
 
+type RoomSetTrialAdminReq struct {
+	UserIDReq
+	AdminPermissions dbModel.RoomAdminPermission `json:"adminPermissions"`
+	// seconds, 0 does not expire by time
+	Duration int64 `json:"duration"`
+	// admin actions, 0 does not expire by actions
+	Actions int64 `json:"actions"`
+}
+
+func (r *RoomSetTrialAdminReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *RoomSetTrialAdminReq) Validate() error {
+	if err := r.UserIDReq.Validate(); err != nil {
+		return err
+	}
+	if r.Duration < 0 || r.Actions < 0 {
+		return errors.New("duration and actions must be greater than or equal to 0")
+	}
+	if r.Duration == 0 && r.Actions == 0 {
+		return errors.New("duration or actions is required")
+	}
+	return nil
+}
+
 type RoomSetMemberReq struct {
 	UserIDReq
 	Permissions dbModel.RoomMemberPermission `json:"permissions"`
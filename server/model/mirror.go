@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+)
+
+type RoomMirrorReq struct {
+	// base url of the remote synctv instance
+	RemoteURL string `json:"remoteUrl"`
+	// room token of the remote room, from its /api/room/login
+	Token   string `json:"token"`
+	Enabled bool   `json:"enabled"`
+}
+
+func (r *RoomMirrorReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *RoomMirrorReq) Validate() error {
+	if r.RemoteURL == "" {
+		return errors.New("remote url is empty")
+	}
+	if len(r.RemoteURL) > 512 {
+		return errors.New("remote url is too long")
+	}
+	if r.Token == "" {
+		return errors.New("token is empty")
+	}
+	return nil
+}
+
+type RoomMirrorResp struct {
+	RemoteURL string `json:"remoteUrl"`
+	Enabled   bool   `json:"enabled"`
+	Connected bool   `json:"connected"`
+	Error     string `json:"error"`
+	Since     int64  `json:"since"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
@@ -44,6 +44,25 @@ func (p *PushMovieReq) Validate() error {
 		return ErrTypeTooLong
 	}
 
+	if err := p.HeaderRules.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Restriction.Validate(); err != nil {
+		return err
+	}
+
+	if err := normalizeContentRating(&p.Rating); err != nil {
+		return err
+	}
+
+	if p.Password != nil && len(*p.Password) > 32 {
+		return ErrPasswordTooLong
+	}
+
+	// extensions are read only
+	p.Extensions = nil
+
 	return nil
 }
 
@@ -77,6 +96,15 @@ func (i *IdReq) Validate() error {
 	return nil
 }
 
+type UnlockMovieReq struct {
+	IdReq
+	Password string `json:"password"`
+}
+
+func (u *UnlockMovieReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(u)
+}
+
 type IdCanEmptyReq struct {
 	Id string `json:"id"`
 }
@@ -120,6 +148,35 @@ func (e *EditMovieReq) Validate() error {
 	return nil
 }
 
+// PatchMovieReq edits the fields of the movie in the json merge patch, on the
+// condition that the movie is still at version
+type PatchMovieReq struct {
+	IdReq
+	Version uint64          `json:"version"`
+	Patch   json.RawMessage `json:"patch"`
+}
+
+func (p *PatchMovieReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(p)
+}
+
+func (p *PatchMovieReq) Validate() error {
+	if err := p.IdReq.Validate(); err != nil {
+		return err
+	}
+	if p.Version == 0 {
+		return errors.New("version is required")
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(p.Patch, &fields); err != nil || fields == nil {
+		return utils.ErrPatchNotObject
+	}
+	if _, ok := fields["extensions"]; ok {
+		return errors.New("extensions are read only")
+	}
+	return nil
+}
+
 type IdsReq struct {
 	Ids []string `json:"ids"`
 }
@@ -194,6 +251,8 @@ type MoviesResp struct {
 	Movies  []*Movie     `json:"movies"`
 	Total   int64        `json:"total"`
 	Dynamic bool         `json:"dynamic"`
+	// cursor of the next page, empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type Movie struct {
@@ -203,6 +262,13 @@ type Movie struct {
 	Creator   string          `json:"creator"`
 	CreatorId string          `json:"creatorId"`
 	SubPath   string          `json:"subPath"`
+	// edits can be made on the condition of the version, see PatchMovieReq
+	Version uint64 `json:"version"`
+	// the movie is password protected
+	NeedPassword bool `json:"needPassword,omitempty"`
+	// the user can not watch the movie, see LockReason
+	Locked     bool         `json:"locked,omitempty"`
+	LockReason op.MovieLock `json:"lockReason,omitempty"`
 }
 
 type CurrentMovieResp struct {
@@ -225,3 +291,79 @@ func (c *ClearMoviesReq) Validate() error {
 	}
 	return nil
 }
+
+type TrashBatchReq struct {
+	BatchID string `json:"batchId"`
+}
+
+func (t *TrashBatchReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(t)
+}
+
+func (t *TrashBatchReq) Validate() error {
+	if len(t.BatchID) != 32 {
+		return ErrId
+	}
+	return nil
+}
+
+type TrashedMovieResp struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsFolder bool   `json:"isFolder"`
+	ParentID string `json:"parentId"`
+}
+
+// TrashBatchResp is the movies removed by one deletion, only the movies
+// that were deleted are listed, not the content of deleted folders
+type TrashBatchResp struct {
+	ID            string              `json:"id"`
+	TrashedAt     int64               `json:"trashedAt"`
+	ExpiresAt     int64               `json:"expiresAt"`
+	TrashedBy     string              `json:"trashedBy"`
+	TrashedByName string              `json:"trashedByName"`
+	Count         int                 `json:"count"`
+	Movies        []*TrashedMovieResp `json:"movies"`
+}
+
+type MigrateMovieReq struct {
+	IdReq
+	// the room the movie is copied or moved to
+	RoomId string `json:"roomId"`
+	// the folder of the room, empty is the top level
+	ParentId string `json:"parentId"`
+	// move the movie instead of copying it
+	Move bool `json:"move"`
+}
+
+func (m *MigrateMovieReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(m)
+}
+
+func (m *MigrateMovieReq) Validate() error {
+	if err := m.IdReq.Validate(); err != nil {
+		return err
+	}
+	if len(m.RoomId) != 32 {
+		return errors.New("invalid room id")
+	}
+	if m.ParentId != "" && len(m.ParentId) != 32 {
+		return ErrId
+	}
+	return nil
+}
+
+type PublishKeyResp struct {
+	Host  string `json:"host"`
+	App   string `json:"app"`
+	Token string `json:"token"`
+}
+
+type MigratedMovieResp struct {
+	From string `json:"from"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// a new key to publish rtmp sources created by the user with, keys of
+	// the room the movie came from no longer work
+	PublishKey *PublishKeyResp `json:"publishKey,omitempty"`
+}
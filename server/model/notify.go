@@ -0,0 +1,108 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type AddNotificationSubscriptionReq struct {
+	Driver   dbModel.NotificationDriver `json:"driver"`
+	Endpoint string                     `json:"endpoint"`
+	Token    string                     `json:"token"`
+	// webpush only
+	Keys struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+func (a *AddNotificationSubscriptionReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(a)
+}
+
+func (a *AddNotificationSubscriptionReq) Validate() error {
+	if a.Endpoint == "" {
+		return errors.New("endpoint is empty")
+	}
+	if len(a.Endpoint) > 1024 {
+		return errors.New("endpoint is too long")
+	}
+	u, err := url.Parse(a.Endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("endpoint must be a http or https url")
+	}
+	switch a.Driver {
+	case dbModel.NotificationDriverWebPush:
+		if a.Keys.P256dh == "" || a.Keys.Auth == "" {
+			return errors.New("webpush keys are required")
+		}
+		if len(a.Keys.P256dh) > 128 || len(a.Keys.Auth) > 64 {
+			return errors.New("webpush keys are too long")
+		}
+	case dbModel.NotificationDriverGotify:
+		if a.Token == "" {
+			return errors.New("gotify token is required")
+		}
+	case dbModel.NotificationDriverNtfy:
+	default:
+		return fmt.Errorf("unknown notification driver: %s", a.Driver)
+	}
+	if len(a.Token) > 256 {
+		return errors.New("token is too long")
+	}
+	return nil
+}
+
+type NotificationSubscriptionIDReq struct {
+	ID string `json:"id"`
+}
+
+func (n *NotificationSubscriptionIDReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(n)
+}
+
+func (n *NotificationSubscriptionIDReq) Validate() error {
+	if len(n.ID) != 32 {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+type NotificationSubscriptionResp struct {
+	ID        string                     `json:"id"`
+	Driver    dbModel.NotificationDriver `json:"driver"`
+	Endpoint  string                     `json:"endpoint"`
+	CreatedAt int64                      `json:"createdAt"`
+}
+
+type SetNotificationPreferenceReq map[string]any
+
+func (s *SetNotificationPreferenceReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(s)
+}
+
+func (s *SetNotificationPreferenceReq) Validate() error {
+	for k, v := range *s {
+		switch k {
+		case "room_live", "scheduled_movie", "movie_comment", "new_login":
+			if _, ok := v.(bool); !ok {
+				return fmt.Errorf("%s must be a bool", k)
+			}
+		default:
+			return fmt.Errorf("unknown notification preference: %s", k)
+		}
+	}
+	return nil
+}
+
+type RoomFollowResp struct {
+	Following bool `json:"following"`
+}
@@ -0,0 +1,259 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+)
+
+var (
+	orgNameReg     = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,31}$`)
+	accentColorReg = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+	ErrInvalidOrgName = errors.New("organization name must be 2 to 32 lowercase letters, digits, _ or -")
+)
+
+// OrgResp is the public branding of an organization
+type OrgResp struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	LogoURL     string `json:"logoUrl"`
+	AccentColor string `json:"accentColor"`
+	Listed      bool   `json:"listed"`
+}
+
+func NewOrgResp(o *dbModel.Organization) *OrgResp {
+	return &OrgResp{
+		ID:          o.ID,
+		Name:        o.Name,
+		DisplayName: o.DisplayName,
+		Description: o.Description,
+		LogoURL:     o.LogoURL,
+		AccentColor: o.AccentColor,
+		Listed:      o.Listed,
+	}
+}
+
+type UserOrgResp struct {
+	*OrgResp
+	Role dbModel.OrgRole `json:"role"`
+}
+
+type AdminOrgResp struct {
+	*OrgResp
+	CreatedAt  int64 `json:"createdAt"`
+	MaxRooms   int64 `json:"maxRooms"`
+	MaxMembers int64 `json:"maxMembers"`
+}
+
+type OrgMemberResp struct {
+	UserID   string          `json:"userId"`
+	Username string          `json:"username"`
+	Role     dbModel.OrgRole `json:"role"`
+	JoinAt   int64           `json:"joinAt"`
+}
+
+type OrgBrandingReq struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	LogoURL     string `json:"logoUrl"`
+	AccentColor string `json:"accentColor"`
+	Listed      bool   `json:"listed"`
+}
+
+func (r *OrgBrandingReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *OrgBrandingReq) Validate() error {
+	if len(r.ID) != 32 {
+		return ErrId
+	}
+	return validateOrgBranding(r.DisplayName, r.Description, r.LogoURL, r.AccentColor)
+}
+
+func validateOrgBranding(displayName, description, logoURL, accentColor string) error {
+	if len(displayName) > 64 {
+		return errors.New("display name too long")
+	}
+	if len(description) > 4096 {
+		return errors.New("description too long")
+	}
+	if len(logoURL) > 512 {
+		return ErrUrlTooLong
+	}
+	if accentColor != "" && !accentColorReg.MatchString(accentColor) {
+		return errors.New("accent color must be like #rrggbb")
+	}
+	return nil
+}
+
+type CreateOrgReq struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	LogoURL     string `json:"logoUrl"`
+	AccentColor string `json:"accentColor"`
+	Listed      bool   `json:"listed"`
+	MaxRooms    int64  `json:"maxRooms"`
+	MaxMembers  int64  `json:"maxMembers"`
+	// the first admin of the organization, optional
+	AdminID string `json:"adminId"`
+}
+
+func (r *CreateOrgReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *CreateOrgReq) Validate() error {
+	if !orgNameReg.MatchString(r.Name) {
+		return ErrInvalidOrgName
+	}
+	if r.MaxRooms < 0 || r.MaxMembers < 0 {
+		return errors.New("quota can not be negative")
+	}
+	if r.AdminID != "" && len(r.AdminID) != 32 {
+		return ErrId
+	}
+	return validateOrgBranding(r.DisplayName, r.Description, r.LogoURL, r.AccentColor)
+}
+
+type OrgQuotaReq struct {
+	ID         string `json:"id"`
+	MaxRooms   int64  `json:"maxRooms"`
+	MaxMembers int64  `json:"maxMembers"`
+}
+
+func (r *OrgQuotaReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *OrgQuotaReq) Validate() error {
+	if len(r.ID) != 32 {
+		return ErrId
+	}
+	if r.MaxRooms < 0 || r.MaxMembers < 0 {
+		return errors.New("quota can not be negative")
+	}
+	return nil
+}
+
+type OrgMemberReq struct {
+	ID     string          `json:"id"`
+	UserID string          `json:"userId"`
+	Role   dbModel.OrgRole `json:"role"`
+}
+
+func (r *OrgMemberReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *OrgMemberReq) Validate() error {
+	if len(r.ID) != 32 || len(r.UserID) != 32 {
+		return ErrId
+	}
+	if r.Role != dbModel.OrgRoleUnknown && !r.Role.IsMember() {
+		return errors.New("invalid organization role")
+	}
+	return nil
+}
+
+type JoinOrgReq struct {
+	Code string `json:"code"`
+}
+
+func (r *JoinOrgReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *JoinOrgReq) Validate() error {
+	if r.Code == "" || len(r.Code) > 32 {
+		return errors.New("invalid invite code")
+	}
+	return nil
+}
+
+type OrgProviderResp struct {
+	Provider          string `json:"provider"`
+	Enabled           bool   `json:"enabled"`
+	RestrictRoomLogin bool   `json:"restrictRoomLogin"`
+	ClientID          string `json:"clientId"`
+	// the secret is never sent back
+	HasClientSecret bool   `json:"hasClientSecret"`
+	RedirectURL     string `json:"redirectUrl"`
+	AuthURL         string `json:"authUrl"`
+	TokenURL        string `json:"tokenUrl"`
+	UserInfoURL     string `json:"userInfoUrl"`
+	Scopes          string `json:"scopes"`
+	IDField         string `json:"idField"`
+	UsernameField   string `json:"usernameField"`
+}
+
+type OrgProviderReq struct {
+	ID                string `json:"id"`
+	Enabled           bool   `json:"enabled"`
+	RestrictRoomLogin bool   `json:"restrictRoomLogin"`
+	ClientID          string `json:"clientId"`
+	// empty keeps the current secret
+	ClientSecret  string `json:"clientSecret"`
+	RedirectURL   string `json:"redirectUrl"`
+	AuthURL       string `json:"authUrl"`
+	TokenURL      string `json:"tokenUrl"`
+	UserInfoURL   string `json:"userInfoUrl"`
+	Scopes        string `json:"scopes"`
+	IDField       string `json:"idField"`
+	UsernameField string `json:"usernameField"`
+}
+
+func (r *OrgProviderReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *OrgProviderReq) Validate() error {
+	if len(r.ID) != 32 {
+		return ErrId
+	}
+	for _, u := range []string{r.RedirectURL, r.AuthURL, r.TokenURL, r.UserInfoURL} {
+		if len(u) > 1024 {
+			return ErrUrlTooLong
+		}
+		if u != "" {
+			if _, err := url.ParseRequestURI(u); err != nil {
+				return err
+			}
+		}
+	}
+	if !settings.AllowProxyToLocal.Get() {
+		for _, u := range []string{r.AuthURL, r.TokenURL, r.UserInfoURL} {
+			if u == "" {
+				continue
+			}
+			if l, err := utils.ParseURLIsLocalIP(u); err != nil || l {
+				if err == nil {
+					err = errors.New("not allow provider urls to local")
+				}
+				return err
+			}
+		}
+	}
+	if r.Enabled && (r.ClientID == "" || r.AuthURL == "" || r.TokenURL == "" || r.UserInfoURL == "" || r.RedirectURL == "") {
+		return errors.New("client id, redirect, auth, token and userinfo url are required to enable the provider")
+	}
+	if r.RestrictRoomLogin && !r.Enabled {
+		return errors.New("rooms can only be restricted to an enabled provider")
+	}
+	if len(r.ClientID) > 256 || len(r.ClientSecret) > 256 || len(r.Scopes) > 256 ||
+		len(r.IDField) > 64 || len(r.UsernameField) > 64 {
+		return errors.New("value too long")
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package model
+
+type OverlayChatMessage struct {
+	ID         string `json:"id"`
+	SenderName string `json:"senderName"`
+	Message    string `json:"message"`
+	SentAt     int64  `json:"sentAt"`
+	Tag        string `json:"tag,omitempty"`
+}
+
+type RoomOverlayResp struct {
+	Movie   RoomCurrentMovie `json:"movie"`
+	Viewers int64            `json:"viewers"`
+	// chat messages sent after the since query, oldest first
+	Chat []*OverlayChatMessage `json:"chat"`
+	// unix milli, pass as since of the next poll
+	ServerTime int64 `json:"serverTime"`
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/webauthn"
+)
+
+var ErrEmptyPasskeySession = errors.New("empty passkey session id")
+
+type PasskeyResp struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	CreatedAt      int64    `json:"createdAt"`
+	LastUsedAt     int64    `json:"lastUsedAt"`
+	BackupEligible bool     `json:"backupEligible"`
+	Transports     []string `json:"transports"`
+}
+
+type PasskeyRegisterFinishReq struct {
+	SessionID  string `json:"sessionId"`
+	Name       string `json:"name"`
+	Credential struct {
+		RawID    webauthn.Base64URL           `json:"rawId"`
+		Type     string                       `json:"type"`
+		Response webauthn.AttestationResponse `json:"response"`
+	} `json:"credential"`
+}
+
+func (r *PasskeyRegisterFinishReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *PasskeyRegisterFinishReq) Validate() error {
+	if r.SessionID == "" {
+		return ErrEmptyPasskeySession
+	}
+	if r.Name == "" {
+		r.Name = "Passkey"
+	} else if utf8.RuneCountInString(r.Name) > 64 {
+		return errors.New("passkey name too long")
+	}
+	if r.Credential.Type != "public-key" {
+		return errors.New("invalid credential type")
+	}
+	if len(r.Credential.Response.ClientDataJSON) == 0 || len(r.Credential.Response.AttestationObject) == 0 {
+		return errors.New("invalid credential response")
+	}
+	return nil
+}
+
+type PasskeyLoginBeginReq struct {
+	// optional, any passkey of the site is accepted when empty
+	Username string `json:"username"`
+}
+
+func (r *PasskeyLoginBeginReq) Decode(ctx *gin.Context) error {
+	err := json.NewDecoder(ctx.Request.Body).Decode(r)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func (r *PasskeyLoginBeginReq) Validate() error {
+	if r.Username == "" {
+		return nil
+	}
+	return validateUsername(&r.Username)
+}
+
+type PasskeyLoginFinishReq struct {
+	SessionID  string `json:"sessionId"`
+	Credential struct {
+		RawID    webauthn.Base64URL         `json:"rawId"`
+		Type     string                     `json:"type"`
+		Response webauthn.AssertionResponse `json:"response"`
+	} `json:"credential"`
+}
+
+func (r *PasskeyLoginFinishReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *PasskeyLoginFinishReq) Validate() error {
+	if r.SessionID == "" {
+		return ErrEmptyPasskeySession
+	}
+	if r.Credential.Type != "public-key" || len(r.Credential.RawID) == 0 {
+		return errors.New("invalid credential")
+	}
+	if len(r.Credential.Response.ClientDataJSON) == 0 ||
+		len(r.Credential.Response.AuthenticatorData) == 0 ||
+		len(r.Credential.Response.Signature) == 0 {
+		return errors.New("invalid credential response")
+	}
+	return nil
+}
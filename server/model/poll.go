@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+const (
+	maxPollCandidates = 10
+	maxPollDuration   = time.Hour * 24 * 30
+)
+
+type CreateMoviePollReq struct {
+	Title      string   `json:"title"`
+	Candidates []string `json:"candidates"`
+	// unix milli
+	CloseAt int64 `json:"closeAt"`
+}
+
+func (r *CreateMoviePollReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *CreateMoviePollReq) Validate() error {
+	if r.Title == "" {
+		return errors.New("title is empty")
+	}
+	if len(r.Title) > 128 {
+		return errors.New("title is too long")
+	}
+	if len(r.Candidates) < 2 || len(r.Candidates) > maxPollCandidates {
+		return errors.New("poll needs 2 to 10 candidates")
+	}
+	for i, id := range r.Candidates {
+		if len(id) != 32 {
+			return ErrId
+		}
+		if slices.Contains(r.Candidates[:i], id) {
+			return errors.New("duplicate candidate")
+		}
+	}
+	closeAt := time.UnixMilli(r.CloseAt)
+	if !closeAt.After(time.Now()) {
+		return errors.New("close time must be in the future")
+	}
+	if time.Until(closeAt) > maxPollDuration {
+		return errors.New("close time is too far")
+	}
+	return nil
+}
+
+type VoteMoviePollReq struct {
+	IdReq
+	// candidates from the most to the least preferred
+	Ranking []string `json:"ranking"`
+}
+
+func (r *VoteMoviePollReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *VoteMoviePollReq) Validate() error {
+	if err := r.IdReq.Validate(); err != nil {
+		return err
+	}
+	if len(r.Ranking) == 0 {
+		return errors.New("ranking is empty")
+	}
+	if len(r.Ranking) > maxPollCandidates {
+		return errors.New("ranking is too long")
+	}
+	return nil
+}
+
+type MoviePollCandidate struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type MoviePollResp struct {
+	ID         string                `json:"id"`
+	CreatorID  string                `json:"creatorId"`
+	Creator    string                `json:"creator"`
+	Title      string                `json:"title"`
+	Candidates []*MoviePollCandidate `json:"candidates"`
+	CloseAt    int64                 `json:"closeAt"`
+	Closed     bool                  `json:"closed"`
+	WinnerID   string                `json:"winnerId,omitempty"`
+	Ballots    int                   `json:"ballots"`
+	// the ballot of the requesting user
+	Ranking []string `json:"ranking,omitempty"`
+	// the rounds of the count, only after the poll is closed
+	Result    *dbModel.RankedChoiceResult `json:"result,omitempty"`
+	CreatedAt int64                       `json:"createdAt"`
+}
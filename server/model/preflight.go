@@ -0,0 +1,32 @@
+package model
+
+type PreflightCompatibility string
+
+const (
+	// plays in browsers straight from the source
+	PreflightDirect PreflightCompatibility = "direct"
+	// plays in browsers through the movie proxy
+	PreflightProxy PreflightCompatibility = "proxy"
+	// browsers can not decode the container or codecs
+	PreflightTranscode   PreflightCompatibility = "transcode"
+	PreflightUnreachable PreflightCompatibility = "unreachable"
+	PreflightUnknown     PreflightCompatibility = "unknown"
+)
+
+type PreflightResp struct {
+	Compatibility PreflightCompatibility `json:"compatibility"`
+	// human readable findings behind the compatibility
+	Reasons      []string `json:"reasons"`
+	StatusCode   int      `json:"statusCode,omitempty"`
+	ContentType  string   `json:"contentType,omitempty"`
+	Container    string   `json:"container,omitempty"`
+	VideoCodec   string   `json:"videoCodec,omitempty"`
+	AudioCodec   string   `json:"audioCodec,omitempty"`
+	Cors         bool     `json:"cors"`
+	AcceptRanges bool     `json:"acceptRanges"`
+	ProxyEnabled bool     `json:"proxyEnabled"`
+}
+
+func (p *PreflightResp) AddReason(reason string) {
+	p.Reasons = append(p.Reasons, reason)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+)
+
+// UserPresenceResp is the rich presence document of a user, fields hidden by
+// the presence preference are left empty
+type UserPresenceResp struct {
+	// whether the user is watching in a room
+	Active     bool   `json:"active"`
+	RoomID     string `json:"roomId,omitempty"`
+	RoomName   string `json:"roomName,omitempty"`
+	MovieTitle string `json:"movieTitle,omitempty"`
+	Live       bool   `json:"live"`
+	Playing    bool   `json:"playing"`
+	// seconds
+	Elapsed float64 `json:"elapsed,omitempty"`
+	// seconds, only known when the movie has the metadata.duration extension
+	Total float64 `json:"total,omitempty"`
+	// ready to use discord activity fields, timestamps are unix milli
+	Details        string `json:"details,omitempty"`
+	State          string `json:"state,omitempty"`
+	StartTimestamp int64  `json:"startTimestamp,omitempty"`
+	EndTimestamp   int64  `json:"endTimestamp,omitempty"`
+}
+
+type SetPresencePreferenceReq map[string]any
+
+func (s *SetPresencePreferenceReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(s)
+}
+
+func (s *SetPresencePreferenceReq) Validate() error {
+	for k, v := range *s {
+		switch k {
+		case "enabled", "show_room", "show_movie", "show_progress", "show_private_rooms":
+			if _, ok := v.(bool); !ok {
+				return fmt.Errorf("%s must be a bool", k)
+			}
+		default:
+			return fmt.Errorf("unknown presence preference: %s", k)
+		}
+	}
+	return nil
+}
@@ -3,11 +3,18 @@ package model
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	json "github.com/json-iterator/go"
 
 	"github.com/gin-gonic/gin"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/provider/providers"
 )
 
 var (
@@ -28,6 +35,8 @@ func (f FormatEmptyPasswordError) Error() string {
 type CreateRoomReq struct {
 	RoomName string `json:"roomName"`
 	Password string `json:"password"`
+	// name of the organization to create the room in, empty for the server
+	Org      string `json:"org"`
 	Settings struct {
 		Hidden bool `json:"hidden"`
 	} `json:"settings"`
@@ -38,9 +47,10 @@ func (c *CreateRoomReq) Decode(ctx *gin.Context) error {
 }
 
 func (c *CreateRoomReq) Validate() error {
+	c.RoomName = namepolicy.Normalize(c.RoomName)
 	if c.RoomName == "" {
 		return ErrEmptyRoomName
-	} else if len(c.RoomName) > 32 {
+	} else if namepolicy.TooLong(c.RoomName) {
 		return ErrRoomNameTooLong
 	} else if !alnumPrintHanReg.MatchString(c.RoomName) {
 		return ErrRoomNameHasInvalidChar
@@ -54,6 +64,32 @@ func (c *CreateRoomReq) Validate() error {
 		}
 	}
 
+	if c.Org != "" && !orgNameReg.MatchString(c.Org) {
+		return ErrInvalidOrgName
+	}
+
+	return nil
+}
+
+// CloneRoomReq creates a room with the settings and playlist of the room
+type CloneRoomReq struct {
+	RoomName string `json:"roomName"`
+	Password string `json:"password"`
+}
+
+func (c *CloneRoomReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(c)
+}
+
+func (c *CloneRoomReq) Validate() error {
+	req := CreateRoomReq{
+		RoomName: c.RoomName,
+		Password: c.Password,
+	}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	c.RoomName = req.RoomName
 	return nil
 }
 
@@ -66,6 +102,7 @@ type RoomListResp struct {
 	Creator      string           `json:"creator"`
 	CreatedAt    int64            `json:"createdAt"`
 	Status       model.RoomStatus `json:"status"`
+	OrgID        string           `json:"orgId,omitempty"`
 }
 
 type LoginRoomReq struct {
@@ -129,5 +166,142 @@ func (s *SetRoomSettingReq) Decode(ctx *gin.Context) error {
 }
 
 func (s *SetRoomSettingReq) Validate() error {
+	if v, ok := (*s)["max_content_rating"]; ok {
+		str, ok := v.(string)
+		if !ok {
+			return errors.New("max_content_rating must be a string")
+		}
+		rating := model.ContentRating(str)
+		if err := normalizeContentRating(&rating); err != nil {
+			return err
+		}
+		(*s)["max_content_rating"] = rating
+	}
+	if v, ok := (*s)["join_provider"]; ok {
+		p, ok := v.(string)
+		if !ok {
+			return errors.New("join_provider must be a string")
+		}
+		if p != "" {
+			pi, ok := providers.AllProvider()[p]
+			if !ok {
+				return providers.FormatErrNotImplemented(p)
+			}
+			if _, ok := pi.(provider.MembershipProvider); !ok {
+				return fmt.Errorf("%s can not check memberships", p)
+			}
+		}
+	}
+	if v, ok := (*s)["join_provider_group"]; ok {
+		group, ok := v.(string)
+		if !ok {
+			return errors.New("join_provider_group must be a string")
+		}
+		if len(group) > 128 {
+			return errors.New("join_provider_group too long")
+		}
+	}
+	if v, ok := (*s)["sync_profile"]; ok {
+		str, ok := v.(string)
+		if !ok {
+			return errors.New("sync_profile must be a string")
+		}
+		profile := model.SyncProfile(str)
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+		(*s)["sync_profile"] = profile
+	}
+	if v, ok := (*s)["description"]; ok {
+		description, ok := v.(string)
+		if !ok {
+			return errors.New("description must be a string")
+		}
+		if utf8.RuneCountInString(description) > maxRoomDescriptionLength {
+			return errors.New("description too long")
+		}
+	}
+	if v, ok := (*s)["tags"]; ok {
+		str, ok := v.(string)
+		if !ok {
+			return errors.New("tags must be a string")
+		}
+		tags, err := normalizeRoomTags(str)
+		if err != nil {
+			return err
+		}
+		(*s)["tags"] = tags
+	}
+	return nil
+}
+
+const (
+	maxRoomDescriptionLength = 1024
+	maxRoomTags              = 10
+	maxRoomTagLength         = 32
+)
+
+// normalizeRoomTags lowercases the comma separated tags and drops empty and
+// repeated ones
+func normalizeRoomTags(s string) (string, error) {
+	tags := make([]string, 0, maxRoomTags)
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.ToLower(namepolicy.Normalize(tag))
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxRoomTagLength {
+			return "", fmt.Errorf("tag %q too long", tag)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxRoomTags {
+		return "", fmt.Errorf("a room can have at most %d tags", maxRoomTags)
+	}
+	return strings.Join(tags, ","), nil
+}
+
+type QuickJoinRoomReq struct {
+	Token string `json:"token"`
+}
+
+func (q *QuickJoinRoomReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(q)
+}
+
+func (q *QuickJoinRoomReq) Validate() error {
+	if q.Token == "" {
+		return errors.New("token is empty")
+	}
 	return nil
 }
+
+type QuickJoinResp struct {
+	URL       string `json:"url"`
+	ExpiresAt int64  `json:"expiresAt"`
+	// the client should fetch a new code after this many seconds
+	RefreshIn int64 `json:"refreshIn"`
+}
+
+type RoomSyncProfileResp struct {
+	Profile model.SyncProfile `json:"profile"`
+	Tuning  model.SyncTuning  `json:"tuning"`
+}
+
+type RoomCurrentMovie struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Live bool   `json:"live"`
+}
+
+type RoomCurrentResp struct {
+	Version uint64           `json:"version"`
+	Movie   RoomCurrentMovie `json:"movie"`
+	Status  op.Status        `json:"status"`
+	// the seek is extrapolated to the server time
+	Extrapolated bool `json:"extrapolated"`
+	// unix milli of the seek, clients extrapolate from it when playing
+	UpdatedAt int64 `json:"updatedAt"`
+	// unix milli of the response
+	ServerTime int64 `json:"serverTime"`
+}
@@ -0,0 +1,14 @@
+package model
+
+type SearchRoomResp struct {
+	*RoomListResp
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+type SearchMovieResp struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RoomID   string `json:"roomId"`
+	RoomName string `json:"roomName"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+// UserSecretResp never carries the value of the secret
+type UserSecretResp struct {
+	Name        string                 `json:"name"`
+	Kind        dbModel.UserSecretKind `json:"kind"`
+	Header      string                 `json:"header,omitempty"`
+	DefaultHost string                 `json:"defaultHost,omitempty"`
+	UpdatedAt   int64                  `json:"updatedAt"`
+}
+
+type SaveUserSecretReq struct {
+	Name        string                 `json:"name"`
+	Kind        dbModel.UserSecretKind `json:"kind"`
+	Header      string                 `json:"header"`
+	DefaultHost string                 `json:"defaultHost"`
+	Value       string                 `json:"value"`
+}
+
+func (s *SaveUserSecretReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(s)
+}
+
+func (s *SaveUserSecretReq) Validate() error {
+	if s.Value == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
+
+type UserSecretNameReq struct {
+	Name string `json:"name"`
+}
+
+func (u *UserSecretNameReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(u)
+}
+
+func (u *UserSecretNameReq) Validate() error {
+	if u.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
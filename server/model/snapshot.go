@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type PublishSnapshotReq struct {
+	Title string `json:"title"`
+}
+
+func (r *PublishSnapshotReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *PublishSnapshotReq) Validate() error {
+	if r.Title == "" {
+		return errors.New("title is empty")
+	}
+	if len(r.Title) > 128 {
+		return errors.New("title is too long")
+	}
+	return nil
+}
+
+type RoomSnapshotResp struct {
+	ID        string `json:"id"`
+	RoomID    string `json:"roomId"`
+	Creator   string `json:"creator"`
+	Title     string `json:"title"`
+	StartAt   int64  `json:"startAt"`
+	EndAt     int64  `json:"endAt"`
+	CreatedAt int64  `json:"createdAt"`
+	// public url of the replay
+	URL      string                    `json:"url"`
+	Playlist []*dbModel.SnapshotMovie  `json:"playlist"`
+	Chat     []*dbModel.SnapshotChat   `json:"chat,omitempty"`
+	Timeline []*dbModel.SnapshotStatus `json:"timeline,omitempty"`
+}
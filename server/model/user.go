@@ -2,10 +2,12 @@ package model
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 	json "github.com/json-iterator/go"
 	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/namepolicy"
 	"github.com/synctv-org/synctv/internal/provider"
 )
 
@@ -16,6 +18,20 @@ var (
 	ErrUsernameHasInvalidChar = errors.New("username has invalid char")
 )
 
+// validateUsername normalizes the username in place and checks it, the
+// length is counted in characters and display width, not bytes
+func validateUsername(username *string) error {
+	*username = namepolicy.Normalize(*username)
+	if *username == "" {
+		return ErrEmptyUsername
+	} else if namepolicy.TooLong(*username) {
+		return ErrUsernameTooLong
+	} else if !alnumPrintHanReg.MatchString(*username) {
+		return ErrUsernameHasInvalidChar
+	}
+	return nil
+}
+
 type SetUserPasswordReq struct {
 	Password string `json:"password"`
 }
@@ -45,12 +61,8 @@ func (l *LoginUserReq) Decode(ctx *gin.Context) error {
 }
 
 func (l *LoginUserReq) Validate() error {
-	if l.Username == "" {
-		return errors.New("username is empty")
-	} else if len(l.Username) > 32 {
-		return ErrUsernameTooLong
-	} else if !alnumPrintHanReg.MatchString(l.Username) {
-		return ErrUsernameHasInvalidChar
+	if err := validateUsername(&l.Username); err != nil {
+		return err
 	}
 
 	if l.Password == "" {
@@ -69,6 +81,8 @@ type UserInfoResp struct {
 	Role      dbModel.Role `json:"role"`
 	CreatedAt int64        `json:"createdAt"`
 	Email     string       `json:"email"`
+	// empty is unlimited
+	MaxContentRating dbModel.ContentRating `json:"maxContentRating"`
 }
 
 type SetUsernameReq struct {
@@ -76,20 +90,38 @@ type SetUsernameReq struct {
 }
 
 func (s *SetUsernameReq) Validate() error {
-	if s.Username == "" {
-		return errors.New("username is empty")
-	} else if len(s.Username) > 32 {
-		return ErrUsernameTooLong
-	} else if !alnumPrintHanReg.MatchString(s.Username) {
-		return ErrUsernameHasInvalidChar
-	}
-	return nil
+	return validateUsername(&s.Username)
 }
 
 func (s *SetUsernameReq) Decode(ctx *gin.Context) error {
 	return json.NewDecoder(ctx.Request.Body).Decode(s)
 }
 
+type SetContentRatingReq struct {
+	Rating dbModel.ContentRating `json:"rating"`
+}
+
+func (s *SetContentRatingReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(s)
+}
+
+func (s *SetContentRatingReq) Validate() error {
+	return normalizeContentRating(&s.Rating)
+}
+
+// normalizeContentRating accepts official ratings like PG-13 as well
+func normalizeContentRating(r *dbModel.ContentRating) error {
+	if r.Validate() == nil {
+		return nil
+	}
+	rating, ok := dbModel.ParseContentRating(string(*r))
+	if !ok {
+		return fmt.Errorf("invalid content rating: %q", string(*r))
+	}
+	*r = rating
+	return nil
+}
+
 type UserIDReq struct {
 	ID string `json:"id"`
 }
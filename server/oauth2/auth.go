@@ -1,19 +1,17 @@
 package auth
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"github.com/synctv-org/synctv/internal/bootstrap"
 	"github.com/synctv-org/synctv/internal/db"
 	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
 	"github.com/synctv-org/synctv/internal/provider"
-	"github.com/synctv-org/synctv/internal/provider/providers"
-	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/server/middlewares"
 	"github.com/synctv-org/synctv/server/model"
 	"github.com/synctv-org/synctv/utils"
@@ -24,7 +22,7 @@ import (
 func OAuth2(ctx *gin.Context) {
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	pi, err := providers.GetProvider(provider.OAuth2Provider(ctx.Param("type")))
+	pi, err := getProvider(ctx.Param("type"))
 	if err != nil {
 		log.Errorf("failed to get provider: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -35,7 +33,7 @@ func OAuth2(ctx *gin.Context) {
 	url, err := pi.NewAuthURL(ctx, state)
 	if err != nil {
 		log.Errorf("failed to get auth url: %v", err)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		ctx.AbortWithStatusJSON(providerErrorResp(err))
 		return
 	}
 	states.Store(state, newAuthFunc(ctx.Query("redirect")), time.Minute*5)
@@ -50,7 +48,7 @@ func OAuth2(ctx *gin.Context) {
 func OAuth2Api(ctx *gin.Context) {
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	pi, err := providers.GetProvider(ctx.Param("type"))
+	pi, err := getProvider(ctx.Param("type"))
 	if err != nil {
 		log.Errorf("failed to get provider: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -67,7 +65,7 @@ func OAuth2Api(ctx *gin.Context) {
 	url, err := pi.NewAuthURL(ctx, state)
 	if err != nil {
 		log.Errorf("failed to get auth url: %v", err)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		ctx.AbortWithStatusJSON(providerErrorResp(err))
 		return
 	}
 	states.Store(state, newAuthFunc(meta.Redirect), time.Minute*5)
@@ -88,7 +86,7 @@ func OAuth2Callback(ctx *gin.Context) {
 		return
 	}
 
-	pi, err := providers.GetProvider(provider.OAuth2Provider(ctx.Param("type")))
+	pi, err := getProvider(ctx.Param("type"))
 	if err != nil {
 		log.Errorf("failed to get provider: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -122,7 +120,7 @@ func OAuth2CallbackApi(ctx *gin.Context) {
 		return
 	}
 
-	pi, err := providers.GetProvider(provider.OAuth2Provider(ctx.Param("type")))
+	pi, err := getProvider(ctx.Param("type"))
 	if err != nil {
 		log.Errorf("failed to get provider: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -150,25 +148,34 @@ func newAuthFunc(redirect string) stateHandler {
 		ui, err := pi.GetUserInfo(ctx, code)
 		if err != nil {
 			log.Errorf("failed to get user info: %v", err)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			ctx.AbortWithStatusJSON(providerErrorResp(err))
 			return
 		}
 
-		pgs, loaded := bootstrap.ProviderGroupSettings[dbModel.SettingGroup(fmt.Sprintf("%s_%s", dbModel.SettingGroupOauth2, pi.Provider()))]
-		if !loaded {
-			log.Errorf("invalid oauth2 provider")
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid oauth2 provider"))
+		policy, err := signupPolicyOf(pi.Provider())
+		if err != nil {
+			log.Errorf("invalid oauth2 provider: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
 			return
 		}
 
-		var user *op.UserEntry
-		if settings.DisableUserSignup.Get() || pgs.DisableUserSignup.Get() {
-			user, err = op.GetUserByProvider(pi.Provider(), ui.ProviderUserID)
-		} else {
-			if settings.SignupNeedReview.Get() || pgs.SignupNeedReview.Get() {
-				user, err = op.CreateOrLoadUserWithProvider(ui.Username, utils.RandString(16), pi.Provider(), ui.ProviderUserID, db.WithRole(dbModel.RolePending))
-			} else {
-				user, err = op.CreateOrLoadUserWithProvider(ui.Username, utils.RandString(16), pi.Provider(), ui.ProviderUserID)
+		user, err := op.GetUserByProvider(pi.Provider(), ui.ProviderUserID)
+		if errors.Is(err, db.ErrNotFound("user")) && !policy.disabled {
+			signup := &pendingSignup{
+				provider:       pi.Provider(),
+				providerUserID: ui.ProviderUserID,
+				needReview:     policy.needReview,
+				redirect:       redirect,
+				orgID:          policy.orgID,
+			}
+			var username string
+			username, err = op.ResolveUsername(ui.Username, pi.Provider(), policy.collision)
+			if errors.Is(err, op.ErrUsernameTaken) {
+				respUsernameTaken(ctx, signup, ui.Username)
+				return
+			}
+			if err == nil {
+				user, err = signup.create(username)
 			}
 		}
 		if err != nil {
@@ -177,7 +184,14 @@ func newAuthFunc(redirect string) stateHandler {
 			return
 		}
 
-		token, err := middlewares.NewAuthUserToken(user.Value())
+		if ui.Token != nil {
+			if err := op.SaveProviderToken(user.Value().ID, pi.Provider(), ui.ProviderUserID, ui.Token); err != nil {
+				log.Warnf("failed to save provider token: %v", err)
+			}
+		}
+		joinProviderOrg(log, policy.orgID, user.Value().ID)
+
+		token, err := middlewares.NewLoginToken(ctx, user.Value())
 		if err != nil {
 			log.Errorf("failed to generate token: %v", err)
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -197,3 +211,109 @@ func newAuthFunc(redirect string) stateHandler {
 		}
 	}
 }
+
+// pendingSignup is a provider signup waiting for the user to pick another
+// username, because the one returned by the provider is taken
+type pendingSignup struct {
+	provider       provider.OAuth2Provider
+	providerUserID string
+	needReview     bool
+	redirect       string
+	orgID          string
+}
+
+func (p *pendingSignup) create(username string) (*op.UserEntry, error) {
+	var conf []db.CreateUserConfig
+	if p.needReview {
+		conf = append(conf, db.WithRole(dbModel.RolePending))
+	}
+	return op.CreateOrLoadUserWithProvider(username, utils.RandString(16), p.provider, p.providerUserID, conf...)
+}
+
+func respUsernameTaken(ctx *gin.Context, signup *pendingSignup, username string) {
+	token := utils.RandString(16)
+	signups.Store(token, signup, time.Minute*5)
+
+	if ctx.Request.Method == http.MethodGet {
+		// the page that started the login asks for another username
+		u, err := url.Parse(signup.redirect)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			return
+		}
+		q := u.Query()
+		q.Set("signupToken", token)
+		q.Set("username", username)
+		u.RawQuery = q.Encode()
+		if err := RenderRedirect(ctx, u.String()); err != nil {
+			ctx.MustGet("log").(*logrus.Entry).Errorf("failed to render redirect: %v", err)
+		}
+		return
+	}
+
+	resp := model.NewApiErrorResp(op.ErrUsernameTaken)
+	resp.Data = &model.OAuth2UsernameTakenResp{
+		SignupToken: token,
+		Username:    username,
+	}
+	ctx.AbortWithStatusJSON(http.StatusConflict, resp)
+}
+
+// POST
+// /oauth2/signup
+func OAuth2SignupApi(ctx *gin.Context) {
+	log := ctx.MustGet("log").(*logrus.Entry)
+
+	req := model.OAuth2SignupReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	signup, loaded := signups.Load(req.Token)
+	if !loaded {
+		log.Errorf("invalid signup token")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorStringResp("invalid signup token"))
+		return
+	}
+
+	if err := op.CheckUsername(req.Username, ""); err != nil {
+		// the token stays valid to try another username
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	exists, err := db.UsernameExists(req.Username)
+	if err != nil {
+		log.Errorf("failed to check username: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
+		return
+	}
+	if exists {
+		// the token stays valid to try another username
+		ctx.AbortWithStatusJSON(http.StatusConflict, model.NewApiErrorResp(op.ErrUsernameTaken))
+		return
+	}
+
+	user, err := signup.Value().create(req.Username)
+	if err != nil {
+		log.Errorf("failed to create user: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+	signups.Delete(req.Token)
+	joinProviderOrg(log, signup.Value().orgID, user.Value().ID)
+
+	token, err := middlewares.NewLoginToken(ctx, user.Value())
+	if err != nil {
+		log.Errorf("failed to generate token: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewApiDataResp(gin.H{
+		"token":    token,
+		"redirect": signup.Value().redirect,
+	}))
+}
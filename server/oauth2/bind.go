@@ -9,7 +9,6 @@ import (
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/op"
 	"github.com/synctv-org/synctv/internal/provider"
-	"github.com/synctv-org/synctv/internal/provider/providers"
 	"github.com/synctv-org/synctv/server/middlewares"
 	"github.com/synctv-org/synctv/server/model"
 	"github.com/synctv-org/synctv/utils"
@@ -19,7 +18,7 @@ func BindApi(ctx *gin.Context) {
 	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	pi, err := providers.GetProvider(provider.OAuth2Provider(ctx.Param("type")))
+	pi, err := getProvider(ctx.Param("type"))
 	if err != nil {
 		log.Errorf("failed to get provider: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -36,7 +35,7 @@ func BindApi(ctx *gin.Context) {
 	url, err := pi.NewAuthURL(ctx, state)
 	if err != nil {
 		log.Errorf("failed to get auth url: %v", err)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+		ctx.AbortWithStatusJSON(providerErrorResp(err))
 		return
 	}
 	states.Store(state, newBindFunc(user.ID, meta.Redirect), time.Minute*5)
@@ -49,7 +48,7 @@ func UnBindApi(ctx *gin.Context) {
 	user := ctx.MustGet("user").(*op.UserEntry).Value()
 	log := ctx.MustGet("log").(*logrus.Entry)
 
-	pi, err := providers.GetProvider(provider.OAuth2Provider(ctx.Param("type")))
+	pi, err := getProvider(ctx.Param("type"))
 	if err != nil {
 		log.Errorf("failed to get provider: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
@@ -73,7 +72,7 @@ func newBindFunc(userID, redirect string) stateHandler {
 		ui, err := pi.GetUserInfo(ctx, code)
 		if err != nil {
 			log.Errorf("failed to get user info: %v", err)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
+			ctx.AbortWithStatusJSON(providerErrorResp(err))
 			return
 		}
 
@@ -91,7 +90,13 @@ func newBindFunc(userID, redirect string) stateHandler {
 			return
 		}
 
-		token, err := middlewares.NewAuthUserToken(user.Value())
+		if ui.Token != nil {
+			if err := op.SaveProviderToken(userID, pi.Provider(), ui.ProviderUserID, ui.Token); err != nil {
+				log.Warnf("failed to save provider token: %v", err)
+			}
+		}
+
+		token, err := middlewares.NewLoginToken(ctx, user.Value())
 		if err != nil {
 			log.Errorf("failed to generate token: %v", err)
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewApiErrorResp(err))
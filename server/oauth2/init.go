@@ -21,6 +21,8 @@ func Init(e *gin.Engine) {
 
 		oauth2.POST("/callback/:type", OAuth2CallbackApi)
 
+		oauth2.POST("/signup", OAuth2SignupApi)
+
 		needAuthOauth2.POST("/bind/:type", BindApi)
 
 		needAuthOauth2.POST("/unbind/:type", UnBindApi)
@@ -1,14 +1,109 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/bootstrap"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/provider/providers"
+	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/server/model"
 )
 
+// getProvider returns the enabled provider, or the reason it was disabled,
+// providers of organizations are resolved by the organization they are named by
+func getProvider(p provider.OAuth2Provider) (provider.ProviderInterface, error) {
+	if _, ok := op.IsOrgProvider(p); ok {
+		pi, _, err := op.GetOrgProvider(p)
+		return pi, err
+	}
+	pi, err := providers.GetProvider(p)
+	if err != nil {
+		if reason, ok := bootstrap.ProviderDisabledReason(p); ok {
+			return nil, fmt.Errorf("%s is disabled: %s", p, reason)
+		}
+		return nil, err
+	}
+	return pi, nil
+}
+
+// signupPolicy is how users logging in with a provider for the first time
+// sign up
+type signupPolicy struct {
+	disabled   bool
+	needReview bool
+	collision  dbModel.UsernameCollision
+	// users of the provider of an organization join it
+	orgID string
+}
+
+func signupPolicyOf(p provider.OAuth2Provider) (*signupPolicy, error) {
+	if _, ok := op.IsOrgProvider(p); ok {
+		_, org, err := op.GetOrgProvider(p)
+		if err != nil {
+			return nil, err
+		}
+		return &signupPolicy{
+			disabled:   settings.DisableUserSignup.Get(),
+			needReview: settings.SignupNeedReview.Get(),
+			collision:  settings.OAuth2UsernameCollision.Get(),
+			orgID:      org.ID,
+		}, nil
+	}
+	pgs, ok := bootstrap.ProviderGroupSettings[dbModel.SettingGroup(fmt.Sprintf("%s_%s", dbModel.SettingGroupOauth2, p))]
+	if !ok {
+		return nil, errors.New("invalid oauth2 provider")
+	}
+	return &signupPolicy{
+		disabled:   settings.DisableUserSignup.Get() || pgs.DisableUserSignup.Get(),
+		needReview: settings.SignupNeedReview.Get() || pgs.SignupNeedReview.Get(),
+		collision:  pgs.UsernameCollisionStrategy(),
+	}, nil
+}
+
+// joinProviderOrg adds users logging in with the provider of an organization
+// to it
+func joinProviderOrg(log *logrus.Entry, orgID, userID string) {
+	if orgID == "" {
+		return
+	}
+	if err := op.AddOrgMember(orgID, userID, dbModel.OrgRoleMember); err != nil {
+		log.Warnf("failed to join organization: %v", err)
+	}
+}
+
+// providerErrorResp shows the message of provider errors to the user instead
+// of the opaque error
+func providerErrorResp(err error) (int, *model.ApiResp) {
+	var pe *provider.Error
+	if !errors.As(err, &pe) {
+		return http.StatusBadRequest, model.NewApiErrorResp(err)
+	}
+	code := http.StatusBadRequest
+	switch pe.Code {
+	case provider.ErrorCodeAccessDenied:
+		code = http.StatusForbidden
+	case provider.ErrorCodeUnavailable:
+		code = http.StatusBadGateway
+	case provider.ErrorCodeRateLimited:
+		code = http.StatusTooManyRequests
+	case provider.ErrorCodeInvalidConfig:
+		code = http.StatusInternalServerError
+	}
+	resp := model.NewApiErrorStringResp(pe.Message)
+	resp.Data = &model.OAuth2ErrorResp{
+		Code:      pe.Code.String(),
+		Retryable: pe.Retryable,
+	}
+	return code, resp
+}
+
 func OAuth2EnabledApi(ctx *gin.Context) {
 	log := ctx.MustGet("log").(*logrus.Entry)
 
@@ -18,7 +113,22 @@ func OAuth2EnabledApi(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewApiErrorResp(err))
 		return
 	}
-	ctx.JSON(200, gin.H{
-		"enabled": data,
-	})
+	disabled := make(map[provider.OAuth2Provider]string)
+	for _, s := range bootstrap.ProviderGroupSettings {
+		if reason, ok := bootstrap.ProviderDisabledReason(s.Provider); ok {
+			disabled[s.Provider] = reason
+		}
+	}
+	resp := gin.H{
+		"enabled":  data,
+		"disabled": disabled,
+	}
+	// the provider of the organization the login page is for
+	if name := ctx.Query("org"); name != "" {
+		p := op.OrgProviderName(name)
+		if _, _, err := op.GetOrgProvider(p); err == nil {
+			resp["org"] = p
+		}
+	}
+	ctx.JSON(200, resp)
 }
@@ -17,6 +17,7 @@ var (
 	redirectTemplate *template.Template
 	tokenTemplate    *template.Template
 	states           *synccache.SyncCache[string, stateHandler]
+	signups          *synccache.SyncCache[string, *pendingSignup]
 )
 
 type stateHandler func(ctx *gin.Context, pi provider.ProviderInterface, code string)
@@ -35,4 +36,5 @@ func init() {
 	redirectTemplate = template.Must(template.ParseFS(temp, "templates/redirect.html"))
 	tokenTemplate = template.Must(template.ParseFS(temp, "templates/token.html"))
 	states = synccache.NewSyncCache[string, stateHandler](time.Minute * 10)
+	signups = synccache.NewSyncCache[string, *pendingSignup](time.Minute * 10)
 }
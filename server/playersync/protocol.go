@@ -0,0 +1,107 @@
+// Package playersync implements a line based tcp/json sync protocol for
+// external players (mpv, vlc, ...) to take part in a room.
+//
+// Every message is a single json object terminated by a newline.
+//
+// The client starts with a hello, authenticated by a room token from
+// POST /api/room/login:
+//
+//	{"type":"hello","version":1,"token":"<room token>","client":"mpv-synctv/1.0"}
+//
+// the server answers with a welcome carrying the negotiated version, the
+// current movie and its status, or with an error and closes the connection:
+//
+//	{"type":"welcome","version":1,"roomId":"...","userId":"...","username":"...","movie":{...},"status":{...},"time":1700000000000}
+//
+// The playable source of a movie is not part of the protocol, the client
+// loads it from GET /api/movie/cast?id=<movie id> with the same token.
+//
+// After the welcome the server pushes:
+//
+//	{"type":"status","reason":"play|pause|seek|rate|correct|sync","status":{"playing":true,"seek":12.5,"rate":1},"sender":"...","time":...}
+//	{"type":"movie","movie":{"id":"...","name":"...","live":false},"status":{...},"time":...}
+//	{"type":"error","error":"..."}
+//	{"type":"pong","time":...}
+//
+// and the client may send:
+//
+//	{"type":"position","seek":12.5,"playing":true,"time":...}  report the local position, answered by a correct status if drifting
+//	{"type":"play","seek":12.5,"time":...}
+//	{"type":"pause","seek":12.5,"time":...}
+//	{"type":"seek","seek":12.5,"time":...}
+//	{"type":"rate","rate":1.5,"seek":12.5,"time":...}
+//	{"type":"sync"}  request the current status
+//	{"type":"ping"}
+//
+// time is a unix timestamp in milliseconds, it is used to compensate the
+// network latency of seek values. Clients should send a ping at least every
+// 30 seconds, idle connections are closed after a minute.
+package playersync
+
+// ProtocolVersion is the latest protocol version supported by the server,
+// clients announcing a newer version are served with this one
+const ProtocolVersion = 1
+
+const (
+	TypeHello    = "hello"
+	TypeWelcome  = "welcome"
+	TypeStatus   = "status"
+	TypeMovie    = "movie"
+	TypeError    = "error"
+	TypePing     = "ping"
+	TypePong     = "pong"
+	TypePosition = "position"
+	TypePlay     = "play"
+	TypePause    = "pause"
+	TypeSeek     = "seek"
+	TypeRate     = "rate"
+	TypeSync     = "sync"
+)
+
+const (
+	ReasonPlay    = "play"
+	ReasonPause   = "pause"
+	ReasonSeek    = "seek"
+	ReasonRate    = "rate"
+	ReasonCorrect = "correct"
+	ReasonSync    = "sync"
+)
+
+type Status struct {
+	Playing bool    `json:"playing"`
+	Seek    float64 `json:"seek"`
+	Rate    float64 `json:"rate"`
+}
+
+type Movie struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Live bool   `json:"live"`
+}
+
+// Request is a message sent by the client
+type Request struct {
+	Type    string  `json:"type"`
+	Version int     `json:"version,omitempty"`
+	Token   string  `json:"token,omitempty"`
+	Client  string  `json:"client,omitempty"`
+	Seek    float64 `json:"seek,omitempty"`
+	Rate    float64 `json:"rate,omitempty"`
+	Playing bool    `json:"playing,omitempty"`
+	Time    int64   `json:"time,omitempty"`
+}
+
+// Response is a message sent by the server
+type Response struct {
+	Type     string  `json:"type"`
+	Version  int     `json:"version,omitempty"`
+	RoomID   string  `json:"roomId,omitempty"`
+	UserID   string  `json:"userId,omitempty"`
+	Username string  `json:"username,omitempty"`
+	Reason   string  `json:"reason,omitempty"`
+	Sender   string  `json:"sender,omitempty"`
+	Movie    *Movie  `json:"movie,omitempty"`
+	Status   *Status `json:"status,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Time     int64   `json:"time"`
+}
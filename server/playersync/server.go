@@ -0,0 +1,346 @@
+package playersync
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	json "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/op"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/server/middlewares"
+)
+
+const (
+	helloTimeout = 10 * time.Second
+	idleTimeout  = time.Minute
+	maxLineSize  = 64 * 1024
+)
+
+// Serve accepts player connections on l until it is closed
+func Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(time.Second)
+				continue
+			}
+			return err
+		}
+		go func() {
+			if err := newSession(conn).serve(); err != nil {
+				log.Debugf("player sync: %s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+type session struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	wlock   sync.Mutex
+	enc     *json.Encoder
+
+	cli *op.Client
+}
+
+func newSession(conn net.Conn) *session {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	return &session{
+		conn:    conn,
+		scanner: scanner,
+		enc:     json.NewEncoder(conn),
+	}
+}
+
+func (s *session) send(resp *Response) error {
+	if resp.Time == 0 {
+		resp.Time = time.Now().UnixMilli()
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return s.enc.Encode(resp)
+}
+
+func (s *session) sendError(err error) error {
+	return s.send(&Response{
+		Type:  TypeError,
+		Error: err.Error(),
+	})
+}
+
+func (s *session) read(timeout time.Duration) (*Request, error) {
+	_ = s.conn.SetReadDeadline(time.Now().Add(timeout))
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, net.ErrClosed
+	}
+	var req Request
+	if err := json.Unmarshal(s.scanner.Bytes(), &req); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return &req, nil
+}
+
+func (s *session) auth(hello *Request) (*op.User, *op.Room, error) {
+	if hello.Type != TypeHello {
+		return nil, nil, errors.New("expect hello")
+	}
+	if hello.Version < 1 {
+		return nil, nil, errors.New("invalid protocol version")
+	}
+	userE, roomE, err := middlewares.AuthRoom(hello.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+	user := userE.Value()
+	if user.IsBanned() {
+		return nil, nil, errors.New("user banned")
+	}
+	if user.IsPending() {
+		return nil, nil, errors.New("user is pending, need admin to approve")
+	}
+	room := roomE.Value()
+	if room.IsBanned() {
+		return nil, nil, errors.New("room banned")
+	}
+	if room.IsPending() {
+		return nil, nil, errors.New("room is pending, need admin to approve")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), helloTimeout)
+	defer cancel()
+	if err := user.CheckRoomMembership(ctx, room); err != nil {
+		return nil, nil, err
+	}
+	return user, room, nil
+}
+
+func (s *session) serve() error {
+	defer s.conn.Close()
+
+	hello, err := s.read(helloTimeout)
+	if err != nil {
+		_ = s.sendError(err)
+		return err
+	}
+	user, room, err := s.auth(hello)
+	if err != nil {
+		_ = s.sendError(err)
+		return err
+	}
+
+	s.cli, err = room.NewClient(user, nil)
+	if err != nil {
+		_ = s.sendError(err)
+		return err
+	}
+	defer func() {
+		_ = room.UnregisterClient(s.cli)
+		s.cli.Close()
+	}()
+	user.UpdateLastAct()
+
+	movie, status := s.current()
+	err = s.send(&Response{
+		Type:     TypeWelcome,
+		Version:  min(hello.Version, ProtocolVersion),
+		RoomID:   room.ID,
+		UserID:   user.ID,
+		Username: user.Username,
+		Movie:    movie,
+		Status:   status,
+	})
+	if err != nil {
+		return err
+	}
+
+	go s.writeLoop()
+	return s.readLoop()
+}
+
+func (s *session) current() (*Movie, *Status) {
+	current := s.cli.Room().Current()
+	movie := &Movie{
+		ID:   current.Movie.ID,
+		Live: current.Movie.IsLive,
+	}
+	if current.Movie.ID != "" {
+		if m, err := s.cli.Room().GetMovieByID(current.Movie.ID); err == nil {
+			movie.Name = m.Movie.MovieBase.Name
+		}
+	}
+	return movie, &Status{
+		Playing: current.Status.Playing,
+		Seek:    current.Status.Seek,
+		Rate:    current.Status.Rate,
+	}
+}
+
+func (s *session) sendStatus(reason, sender string, status *op.Status) error {
+	return s.send(&Response{
+		Type:   TypeStatus,
+		Reason: reason,
+		Sender: sender,
+		Status: &Status{
+			Playing: status.Playing,
+			Seek:    status.Seek,
+			Rate:    status.Rate,
+		},
+	})
+}
+
+// writeLoop translates room broadcasts into protocol messages
+func (s *session) writeLoop() {
+	defer s.conn.Close()
+	for msg := range s.cli.GetReadChan() {
+		em, ok := msg.(*pb.ElementMessage)
+		if !ok {
+			continue
+		}
+		var resp *Response
+		switch em.Type {
+		case pb.ElementMessageType_PLAY,
+			pb.ElementMessageType_PAUSE,
+			pb.ElementMessageType_CHANGE_RATE,
+			pb.ElementMessageType_CHANGE_SEEK:
+			status := em.GetMovieStatusChanged().GetStatus()
+			resp = &Response{
+				Type:   TypeStatus,
+				Reason: statusReason(em.Type),
+				Sender: em.GetMovieStatusChanged().GetSender().GetUsername(),
+				Status: &Status{
+					Playing: status.GetPlaying(),
+					Seek:    status.GetSeek(),
+					Rate:    status.GetRate(),
+				},
+				Time: em.Time,
+			}
+		case pb.ElementMessageType_CURRENT_CHANGED:
+			movie, status := s.current()
+			resp = &Response{
+				Type:   TypeMovie,
+				Sender: em.GetCurrentChanged().GetUsername(),
+				Movie:  movie,
+				Status: status,
+			}
+		default:
+			continue
+		}
+		if err := s.send(resp); err != nil {
+			return
+		}
+	}
+}
+
+func statusReason(t pb.ElementMessageType) string {
+	switch t {
+	case pb.ElementMessageType_PLAY:
+		return ReasonPlay
+	case pb.ElementMessageType_PAUSE:
+		return ReasonPause
+	case pb.ElementMessageType_CHANGE_RATE:
+		return ReasonRate
+	default:
+		return ReasonSeek
+	}
+}
+
+func (s *session) readLoop() error {
+	for {
+		req, err := s.read(idleTimeout)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.cli.User().UpdateLastAct()
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *session) handle(req *Request) error {
+	cli := s.cli
+	timeDiff := cli.TimeDiff(req.Time)
+	switch req.Type {
+	case TypePing:
+		return s.send(&Response{Type: TypePong})
+	case TypeSync:
+		current := cli.Room().Current()
+		return s.sendStatus(ReasonSync, "", &current.Status)
+	case TypePosition:
+		current := cli.Room().Current()
+		if current.Movie.ID == "" || current.Movie.IsLive {
+			return nil
+		}
+		// players drifting past the snap threshold of the room sync profile are corrected
+		if current.Status.Playing != req.Playing ||
+			math.Abs(req.Seek+timeDiff-current.Status.Seek) > cli.Room().SyncTuning().SnapThreshold {
+			return s.sendStatus(ReasonCorrect, "", &current.Status)
+		}
+		return nil
+	case TypePlay, TypePause:
+		current := cli.Room().Current()
+		rate := current.Status.Rate
+		status, err := cli.SetStatus(req.Type == TypePlay, req.Seek, rate, timeDiff)
+		if err != nil {
+			return s.sendError(fmt.Errorf("set status error: %w", err))
+		}
+		t := pb.ElementMessageType_PAUSE
+		if req.Type == TypePlay {
+			t = pb.ElementMessageType_PLAY
+		}
+		return s.broadcastStatus(t, status)
+	case TypeSeek, TypeRate:
+		rate := req.Rate
+		if req.Type == TypeSeek || rate <= 0 {
+			rate = cli.Room().Current().Status.Rate
+		}
+		status, err := cli.SetSeekRate(req.Seek, rate, timeDiff)
+		if err != nil {
+			return s.sendError(fmt.Errorf("set seek rate error: %w", err))
+		}
+		t := pb.ElementMessageType_CHANGE_SEEK
+		if req.Type == TypeRate {
+			t = pb.ElementMessageType_CHANGE_RATE
+		}
+		return s.broadcastStatus(t, status)
+	default:
+		return s.sendError(fmt.Errorf("unknown message type: %s", req.Type))
+	}
+}
+
+func (s *session) broadcastStatus(t pb.ElementMessageType, status *op.Status) error {
+	return s.cli.Broadcast(&pb.ElementMessage{
+		Type: t,
+		Time: time.Now().UnixMilli(),
+		MovieStatusChanged: &pb.MovieStatusChanged{
+			Sender: &pb.Sender{
+				Username: s.cli.User().Username,
+				Userid:   s.cli.User().ID,
+			},
+			Status: &pb.MovieStatus{
+				Playing: status.Playing,
+				Seek:    status.Seek,
+				Rate:    status.Rate,
+			},
+		},
+	}, op.WithIgnoreClient(s.cli))
+}
@@ -2,15 +2,19 @@ package static
 
 import (
 	"io/fs"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/cmd/flags"
 	"github.com/synctv-org/synctv/public"
+	"github.com/synctv-org/synctv/server/middlewares"
 )
 
 func Init(e *gin.Engine) {
@@ -55,7 +59,53 @@ func Init(e *gin.Engine) {
 
 }
 
+// precompressed are the variants of assets built next to them, e.g.
+// app.js.br, in the order they are preferred
+var precompressed = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// serveFile serves the file of the root, empty is the index, or its
+// pre-compressed variant the client accepts
+func serveFile(ctx *gin.Context, fileSys fs.FS, name string, exists func(name string) bool) {
+	file := name
+	if file == "" {
+		file = "index.html"
+	}
+	var variants []string
+	for _, p := range precompressed {
+		if exists(file + p.ext) {
+			variants = append(variants, p.encoding)
+		}
+	}
+	if len(variants) != 0 {
+		h := ctx.Writer.Header()
+		h.Add("Vary", "Accept-Encoding")
+		accepted := middlewares.AcceptedEncodings(ctx.GetHeader("Accept-Encoding"))
+		for _, p := range precompressed {
+			if !slices.Contains(variants, p.encoding) || !slices.Contains(accepted, p.encoding) {
+				continue
+			}
+			if ct := mime.TypeByExtension(path.Ext(file)); ct != "" {
+				h.Set("Content-Type", ct)
+			}
+			h.Set("Content-Encoding", p.encoding)
+			ctx.FileFromFS(file+p.ext, http.FS(fileSys))
+			return
+		}
+	}
+	ctx.FileFromFS(name, http.FS(fileSys))
+}
+
 func newFSHandler(fileSys fs.FS) func(ctx *gin.Context) {
+	exists := func(name string) bool {
+		_, err := fs.Stat(fileSys, name)
+		return err == nil
+	}
 	return func(ctx *gin.Context) {
 		fp := strings.Trim(ctx.Param("filepath"), "/")
 		f, err := fileSys.Open(fp)
@@ -64,7 +114,7 @@ func newFSHandler(fileSys fs.FS) func(ctx *gin.Context) {
 		} else {
 			f.Close()
 		}
-		ctx.FileFromFS(fp, http.FS(fileSys))
+		serveFile(ctx, fileSys, fp, exists)
 	}
 }
 
@@ -77,12 +127,16 @@ func newStatCachedFSHandler(fileSys fs.FS) (func(ctx *gin.Context), error) {
 	if err != nil {
 		return nil, err
 	}
+	exists := func(name string) bool {
+		_, ok := cache["/"+name]
+		return ok
+	}
 	return func(ctx *gin.Context) {
 		fp := ctx.Param("filepath")
 		if _, ok := cache[fp]; !ok {
 			fp = ""
 		}
-		ctx.FileFromFS(fp, http.FS(fileSys))
+		serveFile(ctx, fileSys, strings.TrimPrefix(fp, "/"), exists)
 	}, nil
 }
 
@@ -0,0 +1,162 @@
+// Package syncplay implements the server side of the Syncplay protocol, so
+// Syncplay desktop clients can join synctv rooms without new software.
+//
+// Every message is a json object terminated by \r\n with a single top level
+// key naming it: TLS, Hello, Set, State, List, Chat or Error.
+//
+// The Syncplay room name selects the synctv room by id or by name, the room
+// password is appended after a colon:
+//
+//	movie-night
+//	movie-night:secret
+//
+// Syncplay users join as the guest user with their Syncplay username as a
+// nickname, so the room must allow guests and the guest permissions decide
+// whether they can control playback and chat. When a server password is
+// configured it is checked against the md5 hash sent by the client.
+package syncplay
+
+import (
+	"time"
+
+	json "github.com/json-iterator/go"
+)
+
+const (
+	// protocol version announced to clients
+	Version     = "1.2.255"
+	RealVersion = "1.7.0"
+
+	maxChatMessageLength = 150
+	maxUsernameLength    = 16
+	maxRoomNameLength    = 35
+	maxFilenameLength    = 250
+
+	// name of the pseudo user listing the current movie of the room
+	roomUsername = "[synctv]"
+)
+
+// message is a decoded line, keyed by the message name
+type message map[string]json.RawMessage
+
+type Room struct {
+	Name string `json:"name"`
+}
+
+type Hello struct {
+	Username    string         `json:"username"`
+	Password    string         `json:"password,omitempty"`
+	Room        Room           `json:"room"`
+	Version     string         `json:"version"`
+	RealVersion string         `json:"realversion,omitempty"`
+	Features    map[string]any `json:"features,omitempty"`
+	MOTD        string         `json:"motd,omitempty"`
+}
+
+type Playstate struct {
+	Position float64 `json:"position"`
+	Paused   bool    `json:"paused"`
+	DoSeek   bool    `json:"doSeek"`
+	SetBy    string  `json:"setBy,omitempty"`
+}
+
+type Ping struct {
+	LatencyCalculation       float64 `json:"latencyCalculation,omitempty"`
+	ClientLatencyCalculation float64 `json:"clientLatencyCalculation,omitempty"`
+	ClientRtt                float64 `json:"clientRtt,omitempty"`
+	ServerRtt                float64 `json:"serverRtt"`
+}
+
+// IgnoringOnTheFly counts forced state changes that are not acknowledged yet,
+// states of the other side are ignored until then
+type IgnoringOnTheFly struct {
+	Server int `json:"server,omitempty"`
+	Client int `json:"client,omitempty"`
+}
+
+type State struct {
+	Playstate        *Playstate        `json:"playstate,omitempty"`
+	Ping             *Ping             `json:"ping,omitempty"`
+	IgnoringOnTheFly *IgnoringOnTheFly `json:"ignoringOnTheFly,omitempty"`
+}
+
+type Ready struct {
+	Username          string `json:"username,omitempty"`
+	IsReady           bool   `json:"isReady"`
+	ManuallyInitiated bool   `json:"manuallyInitiated"`
+}
+
+type UserEvent struct {
+	Joined bool `json:"joined,omitempty"`
+	Left   bool `json:"left,omitempty"`
+}
+
+type User struct {
+	Room  Room           `json:"room"`
+	File  map[string]any `json:"file,omitempty"`
+	Event *UserEvent     `json:"event,omitempty"`
+}
+
+type ListUser struct {
+	Position   float64        `json:"position"`
+	File       map[string]any `json:"file"`
+	Controller bool           `json:"controller"`
+	IsReady    bool           `json:"isReady"`
+	Features   map[string]any `json:"features"`
+}
+
+type Chat struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+}
+
+func serverFeatures() map[string]any {
+	return map[string]any{
+		"isolateRooms":         false,
+		"readiness":            true,
+		"managedRooms":         false,
+		"persistentRooms":      false,
+		"chat":                 true,
+		"maxChatMessageLength": maxChatMessageLength,
+		"maxUsernameLength":    maxUsernameLength,
+		"maxRoomNameLength":    maxRoomNameLength,
+		"maxFilenameLength":    maxFilenameLength,
+	}
+}
+
+// pingService estimates the one way delay of a client the way the reference
+// server does, from echoed timestamps in seconds
+type pingService struct {
+	rtt    float64
+	avgRtt float64
+	fd     float64
+}
+
+const pingMovingAverageWeight = 0.85
+
+func timestamp() float64 {
+	return float64(time.Now().UnixMicro()) / 1e6
+}
+
+func (p *pingService) receive(ts, senderRtt float64) {
+	if ts == 0 {
+		return
+	}
+	p.rtt = timestamp() - ts
+	if p.rtt < 0 || senderRtt < 0 {
+		return
+	}
+	if p.avgRtt == 0 {
+		p.avgRtt = p.rtt
+	}
+	p.avgRtt = p.avgRtt*pingMovingAverageWeight + p.rtt*(1-pingMovingAverageWeight)
+	if senderRtt < p.rtt {
+		p.fd = p.avgRtt/2 + (p.rtt - senderRtt)
+	} else {
+		p.fd = p.avgRtt / 2
+	}
+}
@@ -0,0 +1,667 @@
+package syncplay
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/op"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+const (
+	helloTimeout  = 10 * time.Second
+	idleTimeout   = time.Minute
+	stateInterval = time.Second
+	maxLineSize   = 64 * 1024
+)
+
+// Serve accepts syncplay connections on l until it is closed
+func Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(time.Second)
+				continue
+			}
+			return err
+		}
+		go func() {
+			if err := newSession(conn).serve(); err != nil {
+				log.Debugf("syncplay: %s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+var (
+	sessionsLock sync.RWMutex
+	// room id -> syncplay sessions in the room
+	sessions = make(map[string]map[*session]struct{})
+)
+
+// join registers s in its room, renaming it if the username is taken
+func join(s *session) {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+	rs, ok := sessions[s.room.ID]
+	if !ok {
+		rs = make(map[*session]struct{})
+		sessions[s.room.ID] = rs
+	}
+	for taken := true; taken; {
+		taken = s.username == roomUsername
+		for other := range rs {
+			if other.username == s.username {
+				taken = true
+				break
+			}
+		}
+		if taken {
+			s.username += "_"
+		}
+	}
+	rs[s] = struct{}{}
+}
+
+func leave(s *session) {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+	rs := sessions[s.room.ID]
+	delete(rs, s)
+	if len(rs) == 0 {
+		delete(sessions, s.room.ID)
+	}
+}
+
+func roomSessions(roomID string) []*session {
+	sessionsLock.RLock()
+	defer sessionsLock.RUnlock()
+	list := make([]*session, 0, len(sessions[roomID]))
+	for s := range sessions[roomID] {
+		list = append(list, s)
+	}
+	return list
+}
+
+type session struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	wlock   sync.Mutex
+	done    chan struct{}
+
+	username string
+	// the room name the client asked for, echoed back so the client
+	// recognizes its own room
+	roomName string
+	room     *op.Room
+	cli      *op.Client
+
+	lock                 sync.Mutex
+	ping                 pingService
+	clientLatency        float64
+	clientLatencyArrival time.Time
+	serverIgnoring       int
+	clientIgnoring       int
+	file                 map[string]any
+	ready                bool
+}
+
+func newSession(conn net.Conn) *session {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	return &session{
+		conn:    conn,
+		scanner: scanner,
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *session) send(msg map[string]any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err = s.conn.Write(append(data, '\r', '\n'))
+	return err
+}
+
+func (s *session) sendError(err error) error {
+	return s.send(map[string]any{"Error": &Error{Message: err.Error()}})
+}
+
+func (s *session) read(timeout time.Duration) (message, error) {
+	_ = s.conn.SetReadDeadline(time.Now().Add(timeout))
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, net.ErrClosed
+	}
+	var msg message
+	if err := json.Unmarshal(s.scanner.Bytes(), &msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return msg, nil
+}
+
+func loadRoom(name string) (*op.Room, error) {
+	if len(name) == 32 {
+		if roomE, err := op.LoadOrInitRoomByID(name); err == nil {
+			return roomE.Value(), nil
+		}
+	}
+	r, err := db.GetRoomByName("", name)
+	if err != nil {
+		return nil, err
+	}
+	roomE, err := op.LoadOrInitRoomByID(r.ID)
+	if err != nil {
+		return nil, err
+	}
+	return roomE.Value(), nil
+}
+
+func (s *session) auth(hello *Hello) (*op.User, error) {
+	if pw := conf.Conf.Server.Syncplay.Password; pw != "" {
+		sum := md5.Sum([]byte(pw))
+		if hello.Password != hex.EncodeToString(sum[:]) {
+			return nil, errors.New("wrong server password")
+		}
+	}
+	name, password, _ := strings.Cut(hello.Room.Name, ":")
+	if name == "" {
+		return nil, errors.New("room name is empty")
+	}
+	room, err := loadRoom(name)
+	if err != nil {
+		return nil, err
+	}
+	if room.IsBanned() {
+		return nil, errors.New("room banned")
+	}
+	if room.IsPending() {
+		return nil, errors.New("room is pending, need admin to approve")
+	}
+	if !room.CheckPassword(password) {
+		return nil, errors.New("wrong room password")
+	}
+
+	userE, err := op.LoadOrInitUserByID(db.GuestUserID)
+	if err != nil {
+		return nil, err
+	}
+	user := userE.Value()
+	status, err := room.LoadOrCreateMemberStatus(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !status.IsActive() {
+		return nil, errors.New("guest is not allowed in this room")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), helloTimeout)
+	defer cancel()
+	if err := user.CheckRoomMembership(ctx, room); err != nil {
+		return nil, err
+	}
+
+	s.room = room
+	s.roomName = hello.Room.Name
+	s.username = strings.TrimSpace(hello.Username)
+	if s.username == "" {
+		s.username = "Anonymous"
+	}
+	if r := []rune(s.username); len(r) > maxUsernameLength {
+		s.username = string(r[:maxUsernameLength])
+	}
+	return user, nil
+}
+
+func (s *session) readHello() (*Hello, error) {
+	msg, err := s.read(helloTimeout)
+	if err != nil {
+		return nil, err
+	}
+	// encryption is not supported, the client falls back to plain text
+	if _, ok := msg["TLS"]; ok {
+		if err := s.send(map[string]any{"TLS": map[string]string{"startTLS": "false"}}); err != nil {
+			return nil, err
+		}
+		if msg, err = s.read(helloTimeout); err != nil {
+			return nil, err
+		}
+	}
+	raw, ok := msg["Hello"]
+	if !ok {
+		return nil, errors.New("expect hello")
+	}
+	var hello Hello
+	if err := json.Unmarshal(raw, &hello); err != nil {
+		return nil, fmt.Errorf("invalid hello: %w", err)
+	}
+	return &hello, nil
+}
+
+func (s *session) serve() error {
+	defer s.conn.Close()
+
+	hello, err := s.readHello()
+	if err != nil {
+		_ = s.sendError(err)
+		return err
+	}
+	user, err := s.auth(hello)
+	if err != nil {
+		_ = s.sendError(err)
+		return err
+	}
+
+	s.cli, err = s.room.NewClient(user, nil)
+	if err != nil {
+		_ = s.sendError(err)
+		return err
+	}
+	join(s)
+	defer func() {
+		close(s.done)
+		leave(s)
+		s.broadcastUser(&UserEvent{Left: true})
+		_ = s.room.UnregisterClient(s.cli)
+		s.cli.Close()
+	}()
+	user.UpdateLastAct()
+
+	err = s.send(map[string]any{"Hello": &Hello{
+		Username:    s.username,
+		Room:        Room{Name: s.roomName},
+		Version:     Version,
+		RealVersion: RealVersion,
+		Features:    serverFeatures(),
+		MOTD:        fmt.Sprintf("synctv room %s", s.room.Name),
+	}})
+	if err != nil {
+		return err
+	}
+	s.broadcastUser(&UserEvent{Joined: true})
+	if err := s.sendCurrentMovie(); err != nil {
+		return err
+	}
+	if err := s.sendState(true, true, ""); err != nil {
+		return err
+	}
+
+	go s.writeLoop()
+	go s.stateLoop()
+	return s.readLoop()
+}
+
+// sendState sends the room playstate, forced states are ignored by the client
+// until it acknowledges them
+func (s *session) sendState(forced, doSeek bool, setBy string) error {
+	current := s.room.Current()
+	playstate := &Playstate{
+		Position: current.Status.Seek,
+		Paused:   !current.Status.Playing,
+		DoSeek:   doSeek,
+		SetBy:    setBy,
+	}
+	if current.Movie.ID == "" {
+		playstate.Paused = true
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ping := &Ping{
+		LatencyCalculation: timestamp(),
+		ServerRtt:          s.ping.rtt,
+	}
+	if s.clientLatency != 0 {
+		ping.ClientLatencyCalculation = s.clientLatency + time.Since(s.clientLatencyArrival).Seconds()
+		s.clientLatency = 0
+	}
+	state := &State{
+		Playstate: playstate,
+		Ping:      ping,
+	}
+	if forced {
+		s.serverIgnoring++
+	}
+	if s.serverIgnoring != 0 || s.clientIgnoring != 0 {
+		state.IgnoringOnTheFly = &IgnoringOnTheFly{
+			Server: s.serverIgnoring,
+			Client: s.clientIgnoring,
+		}
+		s.clientIgnoring = 0
+	}
+	if s.serverIgnoring != 0 && !forced {
+		return nil
+	}
+	return s.send(map[string]any{"State": state})
+}
+
+// sendCurrentMovie lists the current movie of the room as the file of a
+// pseudo user, since syncplay clients only see files of other users
+func (s *session) sendCurrentMovie() error {
+	return s.send(map[string]any{"Set": map[string]any{
+		"user": map[string]*User{
+			roomUsername: {
+				Room: Room{Name: s.roomName},
+				File: s.currentFile(),
+			},
+		},
+	}})
+}
+
+func (s *session) currentFile() map[string]any {
+	file := map[string]any{
+		"name":     "",
+		"duration": 0,
+		"size":     0,
+	}
+	current := s.room.Current()
+	if current.Movie.ID == "" {
+		return file
+	}
+	if m, err := s.room.GetMovieByID(current.Movie.ID); err == nil {
+		name := []rune(m.Movie.MovieBase.Name)
+		if len(name) > maxFilenameLength {
+			name = name[:maxFilenameLength]
+		}
+		file["name"] = string(name)
+	}
+	return file
+}
+
+// broadcastUser tells the other syncplay sessions of the room about s
+func (s *session) broadcastUser(event *UserEvent) {
+	s.lock.Lock()
+	file := s.file
+	s.lock.Unlock()
+	for _, other := range roomSessions(s.room.ID) {
+		if other == s {
+			continue
+		}
+		_ = other.send(map[string]any{"Set": map[string]any{
+			"user": map[string]*User{
+				s.username: {
+					Room:  Room{Name: other.roomName},
+					File:  file,
+					Event: event,
+				},
+			},
+		}})
+	}
+}
+
+func (s *session) stateLoop() {
+	ticker := time.NewTicker(stateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.sendState(false, false, ""); err != nil {
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// writeLoop translates room broadcasts into syncplay messages
+func (s *session) writeLoop() {
+	defer s.conn.Close()
+	for msg := range s.cli.GetReadChan() {
+		em, ok := msg.(*pb.ElementMessage)
+		if !ok {
+			continue
+		}
+		var err error
+		switch em.Type {
+		case pb.ElementMessageType_PLAY,
+			pb.ElementMessageType_PAUSE,
+			pb.ElementMessageType_CHANGE_RATE,
+			pb.ElementMessageType_CHANGE_SEEK:
+			err = s.sendState(
+				true,
+				em.Type == pb.ElementMessageType_CHANGE_SEEK,
+				em.GetMovieStatusChanged().GetSender().GetUsername(),
+			)
+		case pb.ElementMessageType_CURRENT_CHANGED:
+			if err = s.sendCurrentMovie(); err == nil {
+				err = s.sendState(true, true, em.GetCurrentChanged().GetUsername())
+			}
+		case pb.ElementMessageType_CHAT_MESSAGE:
+			err = s.send(map[string]any{"Chat": &Chat{
+				Username: em.GetChatResp().GetSender().GetUsername(),
+				Message:  em.GetChatResp().GetMessage(),
+			}})
+		default:
+			continue
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *session) readLoop() error {
+	for {
+		msg, err := s.read(idleTimeout)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *session) handle(msg message) error {
+	for name, raw := range msg {
+		var err error
+		switch name {
+		case "State":
+			var state State
+			if err = json.Unmarshal(raw, &state); err == nil {
+				err = s.handleState(&state)
+			}
+		case "Set":
+			var set message
+			if err = json.Unmarshal(raw, &set); err == nil {
+				err = s.handleSet(set)
+			}
+		case "List":
+			err = s.handleList()
+		case "Chat":
+			var chat string
+			if err = json.Unmarshal(raw, &chat); err == nil {
+				err = s.handleChat(chat)
+			}
+		case "Error":
+			var e Error
+			_ = json.Unmarshal(raw, &e)
+			return fmt.Errorf("client error: %s", e.Message)
+		default:
+			log.Debugf("syncplay: unknown message: %s", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *session) handleState(state *State) error {
+	s.lock.Lock()
+	if ignoring := state.IgnoringOnTheFly; ignoring != nil {
+		if ignoring.Server != 0 && ignoring.Server == s.serverIgnoring {
+			s.serverIgnoring = 0
+		}
+		if ignoring.Client != 0 {
+			s.clientIgnoring = ignoring.Client
+		}
+	}
+	if state.Ping != nil {
+		s.ping.receive(state.Ping.LatencyCalculation, state.Ping.ClientRtt)
+		if state.Ping.ClientLatencyCalculation != 0 {
+			s.clientLatency = state.Ping.ClientLatencyCalculation
+			s.clientLatencyArrival = time.Now()
+		}
+	}
+	ignored := s.serverIgnoring != 0
+	delay := s.ping.fd
+	s.lock.Unlock()
+
+	if ignored || state.Playstate == nil {
+		return nil
+	}
+	return s.updateState(state.Playstate, delay)
+}
+
+// updateState applies a pause change or seek of the client to the room
+func (s *session) updateState(playstate *Playstate, delay float64) error {
+	current := s.room.Current()
+	if current.Movie.ID == "" || current.Movie.IsLive {
+		return nil
+	}
+	var (
+		status *op.Status
+		t      pb.ElementMessageType
+		err    error
+	)
+	switch {
+	case playstate.Paused == current.Status.Playing:
+		status, err = s.cli.SetStatus(!playstate.Paused, playstate.Position, current.Status.Rate, delay)
+		t = pb.ElementMessageType_PAUSE
+		if !playstate.Paused {
+			t = pb.ElementMessageType_PLAY
+		}
+	case playstate.DoSeek:
+		status, err = s.cli.SetSeekRate(playstate.Position, current.Status.Rate, delay)
+		t = pb.ElementMessageType_CHANGE_SEEK
+	default:
+		return nil
+	}
+	if err != nil {
+		// revert the client to the room state
+		return s.sendState(true, true, "")
+	}
+	s.cli.User().UpdateLastAct()
+	return s.cli.Broadcast(&pb.ElementMessage{
+		Type: t,
+		Time: time.Now().UnixMilli(),
+		MovieStatusChanged: &pb.MovieStatusChanged{
+			Sender: &pb.Sender{
+				Username: s.username,
+				Userid:   s.cli.User().ID,
+			},
+			Status: &pb.MovieStatus{
+				Playing: status.Playing,
+				Seek:    status.Seek,
+				Rate:    status.Rate,
+			},
+		},
+	}, op.WithIgnoreClient(s.cli))
+}
+
+func (s *session) handleSet(set message) error {
+	if raw, ok := set["file"]; ok {
+		var file map[string]any
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return err
+		}
+		s.lock.Lock()
+		s.file = file
+		s.lock.Unlock()
+		s.broadcastUser(nil)
+	}
+	if raw, ok := set["ready"]; ok {
+		var ready Ready
+		if err := json.Unmarshal(raw, &ready); err != nil {
+			return err
+		}
+		s.lock.Lock()
+		s.ready = ready.IsReady
+		s.lock.Unlock()
+		ready.Username = s.username
+		for _, other := range roomSessions(s.room.ID) {
+			_ = other.send(map[string]any{"Set": map[string]any{"ready": &ready}})
+		}
+	}
+	if _, ok := set["room"]; ok {
+		return s.sendError(errors.New("changing room is not supported, reconnect to join another room"))
+	}
+	return nil
+}
+
+func (s *session) handleList() error {
+	current := s.room.Current()
+	users := map[string]*ListUser{
+		roomUsername: {
+			Position: current.Status.Seek,
+			File:     s.currentFile(),
+			IsReady:  true,
+			Features: map[string]any{},
+		},
+	}
+	for _, other := range roomSessions(s.room.ID) {
+		other.lock.Lock()
+		file := other.file
+		if file == nil {
+			file = map[string]any{}
+		}
+		users[other.username] = &ListUser{
+			Position: current.Status.Seek,
+			File:     file,
+			IsReady:  other.ready,
+			Features: map[string]any{},
+		}
+		other.lock.Unlock()
+	}
+	return s.send(map[string]any{"List": map[string]any{s.roomName: users}})
+}
+
+func (s *session) handleChat(chat string) error {
+	chat = strings.TrimSpace(chat)
+	if chat == "" {
+		return nil
+	}
+	if r := []rune(chat); len(r) > maxChatMessageLength {
+		chat = string(r[:maxChatMessageLength])
+	}
+	s.cli.User().UpdateLastAct()
+	// syncplay users share the guest account, keep their nickname visible
+	err := s.cli.SendChatMessage(fmt.Sprintf("%s: %s", s.username, chat))
+	if err != nil {
+		// an error message would disconnect the client
+		return s.send(map[string]any{"Chat": &Chat{
+			Username: roomUsername,
+			Message:  fmt.Sprintf("send chat message error: %v", err),
+		}})
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package synctvtest
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultTimeout bounds every wait of a scripted client
+const DefaultTimeout = 5 * time.Second
+
+// Client is a scripted room websocket client
+type Client struct {
+	t    testing.TB
+	conn *websocket.Conn
+	msgs chan *pb.ElementMessage
+	// last resume token sent by the server
+	ResumeToken string
+}
+
+// Connect opens the room websocket as the user, query is appended to the
+// url, like resume or ack
+func (s *Server) Connect(t testing.TB, u *User, r *Room, query url.Values) *Client {
+	t.Helper()
+	token := s.RoomToken(t, u, r)
+	addr := "ws" + strings.TrimPrefix(s.URL, "http") + "/api/room/ws"
+	if len(query) != 0 {
+		addr += "?" + query.Encode()
+	}
+	dialer := websocket.Dialer{
+		Subprotocols:     []string{token},
+		HandshakeTimeout: DefaultTimeout,
+	}
+	conn, _, err := dialer.Dial(addr, nil)
+	if err != nil {
+		t.Fatalf("synctvtest: connect %s to %s: %v", u.Username, r.Name, err)
+	}
+	c := &Client{
+		t:    t,
+		conn: conn,
+		msgs: make(chan *pb.ElementMessage, 1024),
+	}
+	go c.read()
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *Client) read() {
+	defer close(c.msgs)
+	for {
+		t, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if t != websocket.BinaryMessage {
+			continue
+		}
+		var msg pb.ElementMessage
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return
+		}
+		c.msgs <- &msg
+	}
+}
+
+func (c *Client) Send(msg *pb.ElementMessage) {
+	c.t.Helper()
+	if msg.Time == 0 {
+		msg.Time = time.Now().UnixMilli()
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		c.t.Fatalf("synctvtest: marshal: %v", err)
+	}
+	if err := c.conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		c.t.Fatalf("synctvtest: send %s: %v", msg.Type, err)
+	}
+}
+
+func (c *Client) sendStatus(t pb.ElementMessageType, playing bool, seek float64) {
+	c.t.Helper()
+	c.Send(&pb.ElementMessage{
+		Type: t,
+		ChangeMovieStatusReq: &pb.MovieStatus{
+			Playing: playing,
+			Seek:    seek,
+			Rate:    1,
+		},
+	})
+}
+
+func (c *Client) Play(seek float64) {
+	c.t.Helper()
+	c.sendStatus(pb.ElementMessageType_PLAY, true, seek)
+}
+
+func (c *Client) Pause(seek float64) {
+	c.t.Helper()
+	c.sendStatus(pb.ElementMessageType_PAUSE, false, seek)
+}
+
+func (c *Client) Seek(seek float64) {
+	c.t.Helper()
+	c.sendStatus(pb.ElementMessageType_CHANGE_SEEK, false, seek)
+}
+
+func (c *Client) Chat(message string) {
+	c.t.Helper()
+	c.Send(&pb.ElementMessage{
+		Type:    pb.ElementMessageType_CHAT_MESSAGE,
+		ChatReq: message,
+	})
+}
+
+// Expect skips messages until one of the type arrives,
+// the test fails if none does within DefaultTimeout
+func (c *Client) Expect(t pb.ElementMessageType) *pb.ElementMessage {
+	c.t.Helper()
+	timeout := time.After(DefaultTimeout)
+	for {
+		select {
+		case msg, ok := <-c.msgs:
+			if !ok {
+				c.t.Fatalf("synctvtest: connection closed waiting for %s", t)
+			}
+			if msg.Type == pb.ElementMessageType_RESUME_TOKEN {
+				c.ResumeToken = msg.ResumeToken
+			}
+			if msg.Type == t {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("synctvtest: timeout waiting for %s", t)
+		}
+	}
+}
+
+// ExpectNone fails the test if a message of the type arrives within d
+func (c *Client) ExpectNone(t pb.ElementMessageType, d time.Duration) {
+	c.t.Helper()
+	timeout := time.After(d)
+	for {
+		select {
+		case msg, ok := <-c.msgs:
+			if !ok {
+				return
+			}
+			if msg.Type == t {
+				c.t.Fatalf("synctvtest: unexpected %s", t)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// Drop closes the connection without a close frame, like a lost network
+func (c *Client) Drop() {
+	_ = c.conn.UnderlyingConn().Close()
+}
+
+func (c *Client) Close() {
+	_ = c.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second),
+	)
+	_ = c.conn.Close()
+}
@@ -0,0 +1,43 @@
+package synctvtest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/synctv-org/synctv/internal/provider"
+)
+
+// StubProviderName is the oauth2 provider registered and enabled by the harness
+const StubProviderName provider.OAuth2Provider = "synctvtest"
+
+var stubProvider = &StubProvider{}
+
+// StubProvider authorizes without any remote server, the authorization
+// code is the username and also the provider user id
+type StubProvider struct {
+	option provider.Oauth2Option
+}
+
+func (p *StubProvider) Init(c provider.Oauth2Option) {
+	p.option = c
+}
+
+func (p *StubProvider) Provider() provider.OAuth2Provider {
+	return StubProviderName
+}
+
+// NewAuthURL points at the redirect url, the test appends the code
+func (p *StubProvider) NewAuthURL(ctx context.Context, state string) (string, error) {
+	return p.option.RedirectURL + "?" + url.Values{"state": {state}}.Encode(), nil
+}
+
+func (p *StubProvider) GetUserInfo(ctx context.Context, code string) (*provider.UserInfo, error) {
+	if code == "" {
+		return nil, errors.New("synctvtest: empty code")
+	}
+	return &provider.UserInfo{
+		Username:       code,
+		ProviderUserID: code,
+	}, nil
+}
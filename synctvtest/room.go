@@ -0,0 +1,69 @@
+package synctvtest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/middlewares"
+)
+
+type User struct {
+	*op.User
+	// token of the user apis
+	Token string
+}
+
+// NewUser creates a user, usernames must be unique in the test binary
+func (s *Server) NewUser(t testing.TB, username string) *User {
+	t.Helper()
+	e, err := op.CreateUser(username, username+"-password")
+	if err != nil {
+		t.Fatalf("synctvtest: create user %s: %v", username, err)
+	}
+	token, err := middlewares.NewAuthUserToken(e.Value(), "")
+	if err != nil {
+		t.Fatalf("synctvtest: user token %s: %v", username, err)
+	}
+	return &User{
+		User:  e.Value(),
+		Token: token,
+	}
+}
+
+type Room struct {
+	*op.Room
+}
+
+// NewRoom creates an active room without password owned by creator
+func (s *Server) NewRoom(t testing.TB, creator *User, name string) *Room {
+	t.Helper()
+	e, err := creator.User.CreateRoom(name, "")
+	if err != nil {
+		t.Fatalf("synctvtest: create room %s: %v", name, err)
+	}
+	return &Room{Room: e.Value()}
+}
+
+// RoomToken joins the user to the room and returns the token of the room apis
+func (s *Server) RoomToken(t testing.TB, u *User, r *Room) string {
+	t.Helper()
+	token, err := middlewares.NewAuthRoomToken(u.User, r.Room, "")
+	if err != nil {
+		t.Fatalf("synctvtest: room token %s in %s: %v", u.Username, r.Name, err)
+	}
+	return token
+}
+
+// RequireStatus fails the test unless the room status matches,
+// seeks are compared with the tolerance in seconds
+func RequireStatus(t testing.TB, r *Room, playing bool, seek, tolerance float64) {
+	t.Helper()
+	status := r.Current().Status
+	if status.Playing != playing {
+		t.Fatalf("synctvtest: room %s playing is %v, want %v", r.Name, status.Playing, playing)
+	}
+	if math.Abs(status.Seek-seek) > tolerance {
+		t.Fatalf("synctvtest: room %s seek is %.3f, want %.3f±%.3f", r.Name, status.Seek, seek, tolerance)
+	}
+}
@@ -0,0 +1,114 @@
+// Package synctvtest boots an in-memory synctv server for behavioral tests
+// of the hub and the http api.
+//
+// The server state (database, caches, settings) is process global, it is
+// booted once and shared by every Server, so tests isolate themselves by
+// creating their own users and rooms.
+package synctvtest
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/cmd/flags"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/provider/providers"
+	"github.com/synctv-org/synctv/internal/vendor"
+	"github.com/synctv-org/synctv/server"
+)
+
+var (
+	bootOnce sync.Once
+	bootErr  error
+)
+
+func boot() error {
+	bootOnce.Do(func() {
+		flags.Server.DisableWeb = true
+		flags.Server.DisableUpdateCheck = true
+		gin.SetMode(gin.TestMode)
+		bootErr = bootstrap.New(bootstrap.WithContext(context.Background())).Add(
+			bootstrap.InitDefaultConfig,
+			initConfig,
+			bootstrap.InitDatabase,
+			initProvider,
+			bootstrap.InitOp,
+			bootstrap.InitSetting,
+		).Run()
+	})
+	return bootErr
+}
+
+func initConfig(ctx context.Context) error {
+	conf.Conf.Database.Type = conf.DatabaseTypeSqlite3
+	conf.Conf.Database.Name = "memory"
+	conf.Conf.Server.Rtmp.Enable = false
+	return nil
+}
+
+func initProvider(ctx context.Context) error {
+	providers.RegisterProvider(stubProvider)
+	return providers.EnableProvider(StubProviderName)
+}
+
+type options struct {
+	bilibili map[string]vendor.BilibiliInterface
+	alist    map[string]vendor.AlistInterface
+	emby     map[string]vendor.EmbyInterface
+}
+
+type Option func(*options)
+
+// WithBilibili serves the bilibili backend of the name with cli
+func WithBilibili(name string, cli vendor.BilibiliInterface) Option {
+	return func(o *options) {
+		o.bilibili[name] = cli
+	}
+}
+
+// WithAlist serves the alist backend of the name with cli
+func WithAlist(name string, cli vendor.AlistInterface) Option {
+	return func(o *options) {
+		o.alist[name] = cli
+	}
+}
+
+// WithEmby serves the emby backend of the name with cli
+func WithEmby(name string, cli vendor.EmbyInterface) Option {
+	return func(o *options) {
+		o.emby[name] = cli
+	}
+}
+
+type Server struct {
+	*httptest.Server
+}
+
+// New boots the shared state if needed and serves the api on a local port.
+// vendor options replace the fake backends of every Server, tests using
+// them must not run in parallel.
+func New(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	if err := boot(); err != nil {
+		t.Fatalf("synctvtest: boot: %v", err)
+	}
+	o := &options{
+		bilibili: make(map[string]vendor.BilibiliInterface),
+		alist:    make(map[string]vendor.AlistInterface),
+		emby:     make(map[string]vendor.EmbyInterface),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	vendor.StoreFakeClients(o.bilibili, o.alist, o.emby)
+
+	s := &Server{
+		Server: httptest.NewServer(server.NewAndInit()),
+	}
+	t.Cleanup(s.Close)
+	return s
+}
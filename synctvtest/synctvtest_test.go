@@ -0,0 +1,26 @@
+package synctvtest
+
+import (
+	"testing"
+
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+func TestPlayIsBroadcast(t *testing.T) {
+	s := New(t)
+	owner := s.NewUser(t, "play-owner")
+	member := s.NewUser(t, "play-member")
+	room := s.NewRoom(t, owner, "play-room")
+
+	oc := s.Connect(t, owner, room, nil)
+	mc := s.Connect(t, member, room, nil)
+	oc.Expect(pb.ElementMessageType_RESUME_TOKEN)
+	mc.Expect(pb.ElementMessageType_RESUME_TOKEN)
+
+	oc.Play(42)
+	msg := mc.Expect(pb.ElementMessageType_PLAY)
+	if got := msg.GetMovieStatusChanged().GetStatus().GetSeek(); got < 42 {
+		t.Fatalf("broadcast seek is %.3f, want at least 42", got)
+	}
+	RequireStatus(t, room, true, 42, 2)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+)
+
+// SniffContainer guesses the container of a media source from its first
+// bytes, falling back to the content type and the file extension.
+// it returns an empty string if the container is unknown
+func SniffContainer(head []byte, contentType, ext string) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("#EXTM3U")):
+		return "m3u8"
+	case bytes.HasPrefix(head, []byte("FLV")):
+		return "flv"
+	case len(head) >= 8 && bytes.Equal(head[4:8], []byte("ftyp")):
+		return "mp4"
+	case bytes.HasPrefix(head, []byte{0x1a, 0x45, 0xdf, 0xa3}):
+		if bytes.Contains(head[:min(len(head), 64)], []byte("webm")) {
+			return "webm"
+		}
+		return "mkv"
+	case len(head) > 188 && head[0] == 0x47 && head[188] == 0x47:
+		return "ts"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return "ogg"
+	case len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")):
+		switch string(head[8:12]) {
+		case "WAVE":
+			return "wav"
+		case "AVI ":
+			return "avi"
+		}
+	case bytes.HasPrefix(head, []byte("ID3")),
+		len(head) >= 2 && head[0] == 0xff && head[1]&0xf6 == 0xf2:
+		return "mp3"
+	case len(head) >= 2 && head[0] == 0xff && head[1]&0xf6 == 0xf0:
+		return "aac"
+	case bytes.HasPrefix(head, []byte{0x30, 0x26, 0xb2, 0x75}):
+		return "wmv"
+	case bytes.HasPrefix(head, []byte(".RMF")):
+		return "rmvb"
+	}
+
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mt {
+		case "application/vnd.apple.mpegurl", "application/x-mpegurl", "audio/mpegurl", "audio/x-mpegurl":
+			return "m3u8"
+		case "video/x-flv":
+			return "flv"
+		case "video/mp4", "audio/mp4", "video/quicktime":
+			return "mp4"
+		case "video/webm", "audio/webm":
+			return "webm"
+		case "video/x-matroska":
+			return "mkv"
+		case "video/mp2t":
+			return "ts"
+		case "audio/mpeg":
+			return "mp3"
+		case "audio/aac":
+			return "aac"
+		}
+	}
+
+	switch ext = strings.ToLower(ext); ext {
+	case "m3u8", "flv", "mp4", "webm", "mkv", "ts", "ogg", "wav", "avi", "mp3", "aac", "wmv", "rmvb":
+		return ext
+	case "m4v", "m4a", "mov":
+		return "mp4"
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+var ErrPatchNotObject = errors.New("patch must be a json object")
+
+// MergePatch applies a json merge patch (RFC 7386) to the json object doc,
+// and returns the patched document with the top level keys the patch changed
+func MergePatch(doc, patch []byte) ([]byte, []string, error) {
+	var p map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &p); err != nil || p == nil {
+		return nil, nil, ErrPatchNotObject
+	}
+	var d map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, nil, err
+	}
+	if d == nil {
+		d = map[string]json.RawMessage{}
+	}
+	var changed []string
+	for k, v := range p {
+		old, ok := d[k]
+		if isJSONNull(v) {
+			if ok {
+				delete(d, k)
+				changed = append(changed, k)
+			}
+			continue
+		}
+		merged, err := mergeValue(old, v)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok || !jsonEqual(old, merged) {
+			d[k] = merged
+			changed = append(changed, k)
+		}
+	}
+	b, err := json.Marshal(d)
+	return b, changed, err
+}
+
+func mergeValue(old, patch json.RawMessage) (json.RawMessage, error) {
+	var p map[string]json.RawMessage
+	if json.Unmarshal(patch, &p) != nil || p == nil {
+		// anything but an object replaces the value
+		return patch, nil
+	}
+	var o map[string]json.RawMessage
+	if json.Unmarshal(old, &o) != nil || o == nil {
+		o = map[string]json.RawMessage{}
+	}
+	for k, v := range p {
+		if isJSONNull(v) {
+			delete(o, k)
+			continue
+		}
+		merged, err := mergeValue(o[k], v)
+		if err != nil {
+			return nil, err
+		}
+		o[k] = merged
+	}
+	return json.Marshal(o)
+}
+
+func isJSONNull(v json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(v), []byte("null"))
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	xb, _ := json.Marshal(x)
+	yb, _ := json.Marshal(y)
+	return bytes.Equal(xb, yb)
+}
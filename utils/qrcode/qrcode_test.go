@@ -0,0 +1,170 @@
+package qrcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormatBits(t *testing.T) {
+	tests := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{Medium, 0, 0x5412},
+		{Low, 0, 0x77C4},
+		{Quartile, 0, 0x355F},
+		{High, 0, 0x1689},
+	}
+	for _, tt := range tests {
+		if got := formatBits(tt.level, tt.mask); got != tt.want {
+			t.Errorf("formatBits(%d, %d) = %#x, want %#x", tt.level, tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	if got := versionBits(7); got != 0x07C94 {
+		t.Errorf("versionBits(7) = %#x, want 0x07c94", got)
+	}
+	if got := versionBits(40); got != 0x28C69 {
+		t.Errorf("versionBits(40) = %#x, want 0x28c69", got)
+	}
+}
+
+func TestDataCapacity(t *testing.T) {
+	tests := []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, Low, 19},
+		{1, High, 9},
+		{5, Quartile, 62},
+		{10, Medium, 216},
+		{40, Low, 2956},
+	}
+	for _, tt := range tests {
+		if got := numDataCodewords(tt.version, tt.level); got != tt.want {
+			t.Errorf("numDataCodewords(%d, %d) = %d, want %d", tt.version, tt.level, got, tt.want)
+		}
+	}
+}
+
+// readCodewords reads the codewords back in placement order
+func readCodewords(q *QRCode, isFunction [][]bool) []byte {
+	var bits []bool
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if !isFunction[y][x] {
+					bits = append(bits, q.modules[y][x] != maskBit(q.Mask, x, y))
+				}
+			}
+		}
+	}
+	out := make([]byte, len(bits)/8)
+	for i := range out {
+		for j := 0; j < 8; j++ {
+			if bits[i*8+j] {
+				out[i] |= 1 << (7 - uint(j))
+			}
+		}
+	}
+	return out
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, data := range []string{
+		"hello",
+		"https://example.com/web/join/0123456789abcdef0123456789abcdef?t=1700000000.abcdefghijklmnop",
+		strings.Repeat("synctv", 120),
+	} {
+		q, err := Encode([]byte(data), Medium)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// rebuild the function pattern map
+		ref := &QRCode{Version: q.Version, Size: q.Size, Level: q.Level}
+		ref.modules = make([][]bool, q.Size)
+		ref.isFunction = make([][]bool, q.Size)
+		for i := range ref.modules {
+			ref.modules[i] = make([]bool, q.Size)
+			ref.isFunction[i] = make([]bool, q.Size)
+		}
+		ref.drawFunctionPatterns()
+
+		// format bits must match the chosen mask
+		ref.drawFormatBits(q.Mask)
+		for y := 0; y < q.Size; y++ {
+			for x := 0; x < q.Size; x++ {
+				if ref.isFunction[y][x] && ref.modules[y][x] != q.modules[y][x] {
+					t.Fatalf("function module mismatch at (%d, %d)", x, y)
+				}
+			}
+		}
+
+		raw := readCodewords(q, ref.isFunction)
+		numBlocks := numErrorCorrectionBlocks[q.Level][q.Version]
+		eccLen := eccCodewordsPerBlock[q.Level][q.Version]
+		rawLen := numRawDataModules(q.Version) / 8
+		numShort := numBlocks - rawLen%numBlocks
+		shortLen := rawLen / numBlocks
+
+		blocks := make([][]byte, numBlocks)
+		k := 0
+		for i := 0; i <= shortLen; i++ {
+			for j := range blocks {
+				if i != shortLen-eccLen || j >= numShort {
+					blocks[j] = append(blocks[j], raw[k])
+					k++
+				}
+			}
+		}
+		var dataCodewords []byte
+		for _, b := range blocks {
+			dat, ecc := b[:len(b)-eccLen], b[len(b)-eccLen:]
+			if !bytes.Equal(reedSolomonRemainder(dat, reedSolomonDivisor(eccLen)), ecc) {
+				t.Fatalf("ecc mismatch")
+			}
+			dataCodewords = append(dataCodewords, dat...)
+		}
+
+		if dataCodewords[0]>>4 != 0x4 {
+			t.Fatalf("mode = %x, want byte mode", dataCodewords[0]>>4)
+		}
+		var length, offset int
+		if charCountBits(q.Version) == 8 {
+			length = int(dataCodewords[0]&0xF)<<4 | int(dataCodewords[1]>>4)
+			offset = 1
+		} else {
+			length = int(dataCodewords[0]&0xF)<<12 | int(dataCodewords[1])<<4 | int(dataCodewords[2]>>4)
+			offset = 2
+		}
+		if length != len(data) {
+			t.Fatalf("length = %d, want %d", length, len(data))
+		}
+		got := make([]byte, length)
+		for i := range got {
+			got[i] = dataCodewords[offset+i]<<4 | dataCodewords[offset+i+1]>>4
+		}
+		if string(got) != data {
+			t.Fatalf("decoded %q, want %q", got, data)
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(make([]byte, 3000), Low); err != ErrDataTooLong {
+		t.Errorf("err = %v, want ErrDataTooLong", err)
+	}
+}
@@ -0,0 +1,64 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// modules of light border around the symbol
+const quietZone = 4
+
+// Image renders the code with scale pixels per module
+func (q *QRCode) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	size := (q.Size + quietZone*2) * scale
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if !q.modules[y][x] {
+				continue
+			}
+			px, py := (x+quietZone)*scale, (y+quietZone)*scale
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(px+dx, py+dy, 1)
+				}
+			}
+		}
+	}
+	return img
+}
+
+func (q *QRCode) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, q.Image(scale))
+}
+
+func (q *QRCode) SVG() string {
+	size := q.Size + quietZone*2
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" stroke="none">`+"\n"+
+		`<rect width="100%%" height="100%%" fill="#FFFFFF"/>`+"\n"+
+		`<path d="`, size, size)
+	first := true
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if !q.modules[y][x] {
+				continue
+			}
+			if !first {
+				b.WriteByte(' ')
+			}
+			first = false
+			fmt.Fprintf(&b, "M%d,%dh1v1h-1z", x+quietZone, y+quietZone)
+		}
+	}
+	b.WriteString(`" fill="#000000"/>` + "\n</svg>\n")
+	return b.String()
+}
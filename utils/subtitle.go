@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"bytes"
+	"regexp"
+)
+
+var srtTimestampReg = regexp.MustCompile(`(\d{2}:\d{2}:\d{2}),(\d{3})`)
+
+// SrtToVtt converts a SubRip subtitle to WebVTT
+func SrtToVtt(srt []byte) []byte {
+	srt = bytes.TrimPrefix(srt, []byte("\ufeff"))
+	srt = bytes.ReplaceAll(srt, []byte("\r\n"), []byte("\n"))
+	if bytes.HasPrefix(srt, []byte("WEBVTT")) {
+		return srt
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(srt)+8))
+	buf.WriteString("WEBVTT\n\n")
+	for _, line := range bytes.SplitAfter(srt, []byte("\n")) {
+		if bytes.Contains(line, []byte("-->")) {
+			line = srtTimestampReg.ReplaceAll(line, []byte("$1.$2"))
+		}
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
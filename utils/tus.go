@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// ParseTusMetadata parses a tus Upload-Metadata header, a comma separated
+// list of keys each optionally followed by a space and a base64 value
+func ParseTusMetadata(header string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		meta[key] = string(b)
+	}
+	return meta
+}
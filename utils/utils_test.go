@@ -128,3 +128,76 @@ func TestTruncateByRune(t *testing.T) {
 		t.Errorf("TruncateByRune() = %v, want %v", utils.TruncateByRune(name, 10), "abcd测试")
 	}
 }
+
+func TestSrtToVtt(t *testing.T) {
+	srt := "\ufeff1\r\n00:00:01,000 --> 00:00:02,500\r\nHello, world\r\n\r\n2\r\n00:01:00,100 --> 00:01:02,000\r\nBye\r\n"
+	want := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.500\nHello, world\n\n2\n00:01:00.100 --> 00:01:02.000\nBye\n"
+	if got := string(utils.SrtToVtt([]byte(srt))); got != want {
+		t.Errorf("SrtToVtt() = %q, want %q", got, want)
+	}
+	vtt := "WEBVTT\n\n00:00.000 --> 00:01.000\nhi\n"
+	if got := string(utils.SrtToVtt([]byte(vtt))); got != vtt {
+		t.Errorf("SrtToVtt() = %q, want %q", got, vtt)
+	}
+}
+
+func TestSniffContainer(t *testing.T) {
+	tests := []struct {
+		name        string
+		head        []byte
+		contentType string
+		ext         string
+		want        string
+	}{
+		{"m3u8", []byte("#EXTM3U\n#EXT-X-VERSION:3\n"), "", "", "m3u8"},
+		{"mp4", []byte("\x00\x00\x00\x20ftypisom"), "application/octet-stream", "bin", "mp4"},
+		{"webm", []byte("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x84webm"), "", "", "webm"},
+		{"mkv", []byte("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x88matroska"), "", "", "mkv"},
+		{"avi", []byte("RIFF\x00\x00\x00\x00AVI LIST"), "", "", "avi"},
+		{"content type", nil, "video/x-flv; charset=binary", "", "flv"},
+		{"extension", nil, "", "MOV", "mp4"},
+		{"unknown", []byte("<html>"), "text/html", "php", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.SniffContainer(tt.head, tt.contentType, tt.ext); got != tt.want {
+				t.Errorf("SniffContainer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTusMetadata(t *testing.T) {
+	got := utils.ParseTusMetadata("filename bW92aWUubXA0, filetype dmlkZW8vbXA0,is_confidential,bad !!")
+	want := map[string]string{
+		"filename":        "movie.mp4",
+		"filetype":        "video/mp4",
+		"is_confidential": "",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseTusMetadata() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("ParseTusMetadata()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	doc := `{"name":"a","headers":{"x":"1","y":"2"},"live":false}`
+	got, changed, err := utils.MergePatch([]byte(doc), []byte(`{"name":"b","headers":{"y":null,"z":"3"},"live":false,"type":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"headers":{"x":"1","z":"3"},"live":false,"name":"b"}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if len(changed) != 2 {
+		t.Errorf("got changed %v, want name and headers", changed)
+	}
+	if _, _, err := utils.MergePatch([]byte(doc), []byte(`[1]`)); err == nil {
+		t.Error("want error for a patch that is not an object")
+	}
+}
@@ -9,6 +9,12 @@ import (
 
 type WebSocket struct {
 	Heartbeat time.Duration
+	// negotiate permessage-deflate, clients not supporting it stay uncompressed
+	Compression      bool
+	CompressionLevel int
+	// messages smaller than this are written uncompressed, deflating tiny
+	// status frames costs more cpu than it saves bandwidth
+	CompressionThreshold int
 }
 
 func DefaultWebSocket() *WebSocket {
@@ -23,6 +29,14 @@ func WithHeartbeatInterval(d time.Duration) WebSocketConfig {
 	}
 }
 
+func WithCompression(level, threshold int) WebSocketConfig {
+	return func(ws *WebSocket) {
+		ws.Compression = true
+		ws.CompressionLevel = level
+		ws.CompressionThreshold = threshold
+	}
+}
+
 func NewWebSocketServer(conf ...WebSocketConfig) *WebSocket {
 	ws := DefaultWebSocket()
 	for _, wsc := range conf {
@@ -37,6 +51,11 @@ func (ws *WebSocket) Server(w http.ResponseWriter, r *http.Request, Subprotocols
 		return err
 	}
 	defer wsc.Close()
+	if ws.Compression {
+		if err := wsc.SetCompressionLevel(ws.CompressionLevel); err != nil {
+			return err
+		}
+	}
 	return handler(wsc)
 }
 
@@ -56,6 +75,7 @@ func (ws *WebSocket) newUpgrader(conf ...UpgraderConf) *websocket.Upgrader {
 		CheckOrigin: func(r *http.Request) bool {
 			return true
 		},
+		EnableCompression: ws.Compression,
 	}
 	for _, uc := range conf {
 		uc(ug)
@@ -63,6 +83,11 @@ func (ws *WebSocket) newUpgrader(conf ...UpgraderConf) *websocket.Upgrader {
 	return ug
 }
 
+// CompressMessage reports whether a message of size bytes should be compressed
+func (ws *WebSocket) CompressMessage(size int) bool {
+	return ws.Compression && size >= ws.CompressionThreshold
+}
+
 func (ws *WebSocket) NewWebSocketClient(w http.ResponseWriter, r *http.Request, responseHeader http.Header, conf ...UpgraderConf) (*websocket.Conn, error) {
 	conn, err := ws.newUpgrader(conf...).Upgrade(w, r, responseHeader)
 	if err != nil {